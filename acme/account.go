@@ -7,13 +7,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"reflect"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
 	"github.com/xenolf/lego/acme"
 )
 
@@ -119,7 +119,7 @@ func (dc *DomainsCertificates) Swap(i, j int) {
 }
 
 func (dc *DomainsCertificates) Less(i, j int) bool {
-	if reflect.DeepEqual(dc.Certs[i].Domains, dc.Certs[j].Domains) {
+	if dc.Certs[i].Domains.equals(dc.Certs[j].Domains) {
 		return dc.Certs[i].tlsCert.Leaf.NotAfter.After(dc.Certs[j].tlsCert.Leaf.NotAfter)
 	}
 	if dc.Certs[i].Domains.Main == dc.Certs[j].Domains.Main {
@@ -132,7 +132,7 @@ func (dc *DomainsCertificates) removeDuplicates() {
 	sort.Sort(dc)
 	for i := 0; i < len(dc.Certs); i++ {
 		for i2 := i + 1; i2 < len(dc.Certs); i2++ {
-			if reflect.DeepEqual(dc.Certs[i].Domains, dc.Certs[i2].Domains) {
+			if dc.Certs[i].Domains.equals(dc.Certs[i2].Domains) {
 				// delete
 				log.Warnf("Remove duplicate cert: %+v, expiration :%s", dc.Certs[i2].Domains, dc.Certs[i2].tlsCert.Leaf.NotAfter.String())
 				dc.Certs = append(dc.Certs[:i2], dc.Certs[i2+1:]...)
@@ -169,7 +169,7 @@ func (dc *DomainsCertificates) renewCertificates(acmeCert *Certificate, domain D
 	defer dc.lock.Unlock()
 
 	for _, domainsCertificate := range dc.Certs {
-		if reflect.DeepEqual(domain, domainsCertificate.Domains) {
+		if domain.equals(domainsCertificate.Domains) {
 			tlsCert, err := tls.X509KeyPair(acmeCert.Certificate, acmeCert.PrivateKey)
 			if err != nil {
 				return err
@@ -198,14 +198,10 @@ func (dc *DomainsCertificates) addCertificateForDomains(acmeCert *Certificate, d
 func (dc *DomainsCertificates) getCertificateForDomain(domainToFind string) (*DomainsCertificate, bool) {
 	dc.lock.RLock()
 	defer dc.lock.RUnlock()
+	domainToFind = types.CanonicalDomain(domainToFind)
 	for _, domainsCertificate := range dc.Certs {
-		domains := []string{}
-		domains = append(domains, domainsCertificate.Domains.Main)
-		domains = append(domains, domainsCertificate.Domains.SANs...)
-		for _, domain := range domains {
-			if domain == domainToFind {
-				return domainsCertificate, true
-			}
+		if containsString(domainsCertificate.Domains.domains(), domainToFind) {
+			return domainsCertificate, true
 		}
 	}
 	return nil, false
@@ -215,7 +211,7 @@ func (dc *DomainsCertificates) exists(domainToFind Domain) (*DomainsCertificate,
 	dc.lock.RLock()
 	defer dc.lock.RUnlock()
 	for _, domainsCertificate := range dc.Certs {
-		if reflect.DeepEqual(domainToFind, domainsCertificate.Domains) {
+		if domainToFind.equals(domainsCertificate.Domains) {
 			return domainsCertificate, true
 		}
 	}
@@ -2,6 +2,7 @@ package acme
 
 import (
 	"context"
+	"crypto"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/BurntSushi/ty/fun"
@@ -27,7 +29,6 @@ import (
 	"github.com/containous/traefik/types"
 	"github.com/eapache/channels"
 	"github.com/xenolf/lego/acme"
-	"github.com/xenolf/lego/providers/dns"
 )
 
 var (
@@ -58,13 +59,15 @@ type ACME struct {
 	checkOnDemandDomain   func(domain string) bool
 	jobs                  *channels.InfiniteChannel
 	TLSConfig             *tls.Config `description:"TLS config in case wildcard certs are used"`
-	dynamicCerts          *safe.Safe
+	dynamicCerts          *atomic.Value
+	dnsResolver           *types.DNSResolver
 }
 
 // DNSChallenge contains DNS challenge Configuration
 type DNSChallenge struct {
-	Provider         string         `description:"Use a DNS-01 based challenge provider rather than HTTPS."`
-	DelayBeforeCheck flaeg.Duration `description:"Assume DNS propagates after a delay in seconds rather than finding and querying nameservers."`
+	Provider           string         `description:"Use a DNS-01 based challenge provider rather than HTTPS."`
+	DelayBeforeCheck   flaeg.Duration `description:"Assume DNS propagates after a delay in seconds rather than finding and querying nameservers."`
+	PropagationTimeout flaeg.Duration `description:"Maximum time waited for the TXT record to be propagated to the nameservers before letting ACME verify it."`
 }
 
 // HTTPChallenge contains HTTP challenge Configuration
@@ -113,15 +116,60 @@ type Domain struct {
 	SANs []string
 }
 
+// domains returns the canonical names of the main domain and of the SANs, in this order and without duplicates.
+func (d Domain) domains() []string {
+	domains := []string{types.CanonicalDomain(d.Main)}
+	for _, san := range d.SANs {
+		san = types.CanonicalDomain(san)
+		if !containsString(domains, san) {
+			domains = append(domains, san)
+		}
+	}
+	return domains
+}
+
+// equals reports whether d and other cover the same names, whatever the case or the order of the SANs.
+func (d Domain) equals(other Domain) bool {
+	domains, otherDomains := d.domains(), other.domains()
+	if domains[0] != otherDomains[0] || len(domains) != len(otherDomains) {
+		return false
+	}
+	for _, domain := range domains[1:] {
+		if !containsString(otherDomains[1:], domain) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDNSResolver sets the DNS servers resolving the hostname of the CA server, and checking the propagation of the DNS challenges
+func (a *ACME) SetDNSResolver(dnsResolver *types.DNSResolver) {
+	a.dnsResolver = dnsResolver
+}
+
 func (a *ACME) init() error {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if a.dnsResolver != nil {
+		dialer.Resolver = a.dnsResolver.NewResolver()
+	}
+
 	// FIXME temporary fix, waiting for https://github.com/xenolf/lego/pull/478
 	acme.HTTPClient = http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			Dial: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).Dial,
+			Proxy:                 http.ProxyFromEnvironment,
+			Dial:                  dialer.Dial,
 			TLSHandshakeTimeout:   15 * time.Second,
 			ResponseHeaderTimeout: 15 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
@@ -173,7 +221,7 @@ func (a *ACME) AddRoutes(router *mux.Router) {
 }
 
 // CreateClusterConfig creates a tls.config using ACME configuration in cluster mode
-func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tls.Config, certs *safe.Safe, checkOnDemandDomain func(domain string) bool) error {
+func (a *ACME) CreateClusterConfig(leadership *cluster.Leadership, tlsConfig *tls.Config, certs *atomic.Value, checkOnDemandDomain func(domain string) bool) error {
 	err := a.init()
 	if err != nil {
 		return err
@@ -294,7 +342,7 @@ func (a *ACME) leadershipListener(elected bool) error {
 }
 
 // CreateLocalConfig creates a tls.config using local ACME configuration
-func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, certs *safe.Safe, checkOnDemandDomain func(domain string) bool) error {
+func (a *ACME) CreateLocalConfig(tlsConfig *tls.Config, certs *atomic.Value, checkOnDemandDomain func(domain string) bool) error {
 	err := a.init()
 	if err != nil {
 		return err
@@ -418,9 +466,7 @@ func (a *ACME) retrieveCertificates() {
 			// check if cert isn't already loaded
 			account := a.store.Get().(*Account)
 			if _, exists := account.DomainsCertificate.exists(domain); !exists {
-				domains := []string{}
-				domains = append(domains, domain.Main)
-				domains = append(domains, domain.SANs...)
+				domains := domain.domains()
 				certificateResource, err := a.getDomainsCertificates(domains)
 				if err != nil {
 					log.Errorf("Error getting ACME certificate for domain %s: %s", domains, err.Error())
@@ -478,25 +524,19 @@ func (a *ACME) renewCertificates() {
 	}
 }
 
+// renewACMECertificate requests a new certificate for all the domains of the certificate to renew,
+// the main domain and all the SANs, reusing its private key.
 func (a *ACME) renewACMECertificate(certificateResource *DomainsCertificate) (*Certificate, error) {
-	renewedCert, err := a.client.RenewCertificate(acme.CertificateResource{
-		Domain:        certificateResource.Certificate.Domain,
-		CertURL:       certificateResource.Certificate.CertURL,
-		CertStableURL: certificateResource.Certificate.CertStableURL,
-		PrivateKey:    certificateResource.Certificate.PrivateKey,
-		Certificate:   certificateResource.Certificate.Certificate,
-	}, true, OSCPMustStaple)
+	var privateKey crypto.PrivateKey
+	if certificateResource.tlsCert != nil {
+		privateKey = certificateResource.tlsCert.PrivateKey
+	}
+	renewedCert, err := a.obtainCertificate(certificateResource.Domains.domains(), privateKey)
 	if err != nil {
 		return nil, err
 	}
 	log.Infof("Renewed certificate from  LE: %+v", certificateResource.Domains)
-	return &Certificate{
-		Domain:        renewedCert.Domain,
-		CertURL:       renewedCert.CertURL,
-		CertStableURL: renewedCert.CertStableURL,
-		PrivateKey:    renewedCert.PrivateKey,
-		Certificate:   renewedCert.Certificate,
-	}, nil
+	return renewedCert, nil
 }
 
 func (a *ACME) storeRenewedCertificate(certificateResource *DomainsCertificate, renewedACMECert *Certificate) error {
@@ -519,7 +559,7 @@ func (a *ACME) storeRenewedCertificate(certificateResource *DomainsCertificate,
 
 	oldAccount := a.store.Get().(*Account)
 	for _, oldCertificateResource := range oldAccount.DomainsCertificate.Certs {
-		if oldCertificateResource.Domains.Main == certificateResource.Domains.Main && strings.Join(oldCertificateResource.Domains.SANs, ",") == strings.Join(certificateResource.Domains.SANs, ",") && certificateResource.Certificate != renewedACMECert {
+		if oldCertificateResource.Domains.equals(certificateResource.Domains) && certificateResource.Certificate != renewedACMECert {
 			return fmt.Errorf("renewed certificate not stored: %+v", certificateResource.Domains)
 		}
 	}
@@ -561,8 +601,14 @@ func (a *ACME) buildACMEClient(account *Account) (*acme.Client, error) {
 			return nil, err
 		}
 
+		if a.dnsResolver != nil && len(a.dnsResolver.Servers) > 0 {
+			acme.RecursiveNameservers = a.dnsResolver.Nameservers()
+			acme.DNSTimeout = a.dnsResolver.GetTimeout()
+			log.Debugf("Checking the DNS propagation with the nameservers %v", acme.RecursiveNameservers)
+		}
+
 		var provider acme.ChallengeProvider
-		provider, err = dns.NewDNSChallengeProviderByName(a.DNSChallenge.Provider)
+		provider, err = newDNSChallengeProvider(a.DNSChallenge.Provider, time.Duration(a.DNSChallenge.PropagationTimeout))
 		if err != nil {
 			return nil, err
 		}
@@ -685,8 +731,10 @@ func (a *ACME) LoadCertificateForDomains(domains []string) {
 func (a *ACME) getProvidedCertificate(domains []string) *tls.Certificate {
 	log.Debugf("Looking for provided certificate to validate %s...", domains)
 	cert := searchProvidedCertificateForDomains(domains, a.TLSConfig.NameToCertificate)
-	if cert == nil && a.dynamicCerts != nil && a.dynamicCerts.Get() != nil {
-		cert = searchProvidedCertificateForDomains(domains, a.dynamicCerts.Get().(*traefikTls.DomainsCertificates).Get().(map[string]*tls.Certificate))
+	if cert == nil && a.dynamicCerts != nil {
+		if dynamicCerts, ok := a.dynamicCerts.Load().(*traefikTls.DomainsCertificates); ok && dynamicCerts != nil {
+			cert = searchProvidedCertificateForDomains(domains, dynamicCerts.Get().(map[string]*tls.Certificate))
+		}
 	}
 	log.Debugf("No provided certificate found for domains %s, get ACME certificate.", domains)
 	return cert
@@ -696,7 +744,7 @@ func searchProvidedCertificateForDomains(domains []string, certs map[string]*tls
 	// Use regex to test for provided certs that might have been added into TLSConfig
 	providedCertMatch := false
 	for k := range certs {
-		selector := "^" + strings.Replace(k, "*.", "[^\\.]*\\.?", -1) + "$"
+		selector := "^" + strings.Replace(traefikTls.KeyDomains(k), "*.", "[^\\.]*\\.?", -1) + "$"
 		for _, domainToCheck := range domains {
 			providedCertMatch, _ = regexp.MatchString(selector, domainToCheck)
 			if !providedCertMatch {
@@ -713,10 +761,15 @@ func searchProvidedCertificateForDomains(domains []string, certs map[string]*tls
 }
 
 func (a *ACME) getDomainsCertificates(domains []string) (*Certificate, error) {
-	domains = fun.Map(types.CanonicalDomain, domains).([]string)
+	return a.obtainCertificate(fun.Map(types.CanonicalDomain, domains).([]string), nil)
+}
+
+// obtainCertificate requests a single certificate covering all the domains, the first one being its main domain.
+// It fails if any of the domains cannot be validated, to never get a certificate missing some of them.
+func (a *ACME) obtainCertificate(domains []string, privateKey crypto.PrivateKey) (*Certificate, error) {
 	log.Debugf("Loading ACME certificates %s...", domains)
 	bundle := true
-	certificate, failures := a.client.ObtainCertificate(domains, bundle, nil, OSCPMustStaple)
+	certificate, failures := a.client.ObtainCertificate(domains, bundle, privateKey, OSCPMustStaple)
 	if len(failures) > 0 {
 		log.Error(failures)
 		return nil, fmt.Errorf("cannot obtain certificates %+v", failures)
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/containous/traefik/tls/generate"
+	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/xenolf/lego/acme"
 )
 
@@ -295,3 +297,88 @@ func TestAcme_getProvidedCertificate(t *testing.T) {
 	certificate = a.getProvidedCertificate(domains)
 	assert.Nil(t, certificate)
 }
+
+func TestDomainEquals(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		domain   Domain
+		other    Domain
+		expected bool
+	}{
+		{
+			desc:     "nil and empty SANs",
+			domain:   Domain{Main: "foo.com"},
+			other:    Domain{Main: "foo.com", SANs: []string{}},
+			expected: true,
+		},
+		{
+			desc:     "SANs in another order and case",
+			domain:   Domain{Main: "foo.com", SANs: []string{"bar.foo.com", "baz.foo.com"}},
+			other:    Domain{Main: "FOO.com", SANs: []string{"baz.foo.com", "Bar.foo.com"}},
+			expected: true,
+		},
+		{
+			desc:     "duplicated SANs",
+			domain:   Domain{Main: "foo.com", SANs: []string{"bar.foo.com", "foo.com", "bar.foo.com"}},
+			other:    Domain{Main: "foo.com", SANs: []string{"bar.foo.com"}},
+			expected: true,
+		},
+		{
+			desc:     "missing SAN",
+			domain:   Domain{Main: "foo.com", SANs: []string{"bar.foo.com", "baz.foo.com"}},
+			other:    Domain{Main: "foo.com", SANs: []string{"bar.foo.com"}},
+			expected: false,
+		},
+		{
+			desc:     "main domain swapped with a SAN",
+			domain:   Domain{Main: "foo.com", SANs: []string{"bar.foo.com"}},
+			other:    Domain{Main: "bar.foo.com", SANs: []string{"foo.com"}},
+			expected: false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, test.domain.equals(test.other))
+			assert.Equal(t, test.expected, test.other.equals(test.domain))
+		})
+	}
+}
+
+func TestDomainsCertificatesWithSANs(t *testing.T) {
+	fooCert, fooKey, err := generate.KeyPair("foo.com", time.Now())
+	require.NoError(t, err)
+
+	domainsCertificates := DomainsCertificates{}
+	domain := Domain{Main: "foo.com", SANs: []string{"bar.foo.com", "baz.foo.com"}}
+	_, err = domainsCertificates.addCertificateForDomains(&Certificate{Domain: "foo.com", PrivateKey: fooKey, Certificate: fooCert}, domain)
+	require.NoError(t, err)
+
+	// the certificate is served for the main domain and all the SANs
+	for _, serverName := range []string{"foo.com", "bar.foo.com", "BAZ.foo.com"} {
+		certificate, ok := domainsCertificates.getCertificateForDomain(types.CanonicalDomain(serverName))
+		require.True(t, ok, serverName)
+		assert.Equal(t, domain, certificate.Domains)
+	}
+	_, ok := domainsCertificates.getCertificateForDomain("qux.foo.com")
+	assert.False(t, ok)
+
+	// the certificate is not requested again when the SANs are configured in another order
+	_, exists := domainsCertificates.exists(Domain{Main: "foo.com", SANs: []string{"baz.foo.com", "bar.foo.com"}})
+	assert.True(t, exists)
+	_, exists = domainsCertificates.exists(Domain{Main: "foo.com", SANs: []string{"bar.foo.com"}})
+	assert.False(t, exists)
+
+	// the renewed certificate replaces the one of the whole SAN set
+	renewedCert, renewedKey, err := generate.KeyPair("foo.com", time.Now())
+	require.NoError(t, err)
+	renewedCertificate := &Certificate{Domain: "foo.com", PrivateKey: renewedKey, Certificate: renewedCert}
+	require.NoError(t, domainsCertificates.renewCertificates(renewedCertificate, Domain{Main: "foo.com", SANs: []string{"baz.foo.com", "bar.foo.com"}}))
+	require.Len(t, domainsCertificates.Certs, 1)
+	assert.Equal(t, renewedCertificate, domainsCertificates.Certs[0].Certificate)
+
+	assert.Equal(t, []string{"foo.com", "bar.foo.com", "baz.foo.com"}, domainsCertificates.Certs[0].Domains.domains())
+}
@@ -0,0 +1,85 @@
+package acme
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xenolf/lego/acme"
+	"github.com/xenolf/lego/providers/dns"
+)
+
+// DNSProvider creates the TXT record of a DNS-01 challenge, and removes it once the challenge is validated.
+// A DNSProvider can also implement Timeout() (timeout, interval time.Duration)
+// to wait longer than the default 60 seconds for the record to be propagated.
+type DNSProvider interface {
+	Present(domain, token, keyAuth string) error
+	CleanUp(domain, token, keyAuth string) error
+}
+
+// DNSProviderConstructor creates a DNSProvider, usually configured with environment variables as the built-in providers.
+type DNSProviderConstructor func() (DNSProvider, error)
+
+var (
+	dnsProvidersLock sync.RWMutex
+	dnsProviders     = make(map[string]DNSProviderConstructor)
+)
+
+// RegisterDNSProvider makes a custom DNS provider available under name for the DNS-01 challenge,
+// taking precedence over the built-in provider with the same name.
+// It panics if name is empty or already registered, being meant to be called from an init function.
+func RegisterDNSProvider(name string, constructor DNSProviderConstructor) {
+	dnsProvidersLock.Lock()
+	defer dnsProvidersLock.Unlock()
+
+	if len(name) == 0 || constructor == nil {
+		panic("acme: RegisterDNSProvider needs a name and a constructor")
+	}
+	if _, exists := dnsProviders[name]; exists {
+		panic(fmt.Sprintf("acme: DNS provider %s registered twice", name))
+	}
+	dnsProviders[name] = constructor
+}
+
+// newDNSChallengeProvider creates the registered DNS provider called name, or else the built-in one.
+// A positive propagationTimeout overrides the time waited for the TXT record to be propagated.
+func newDNSChallengeProvider(name string, propagationTimeout time.Duration) (acme.ChallengeProvider, error) {
+	if propagationTimeout < 0 {
+		return nil, fmt.Errorf("invalid negative PropagationTimeout: %d", propagationTimeout)
+	}
+
+	dnsProvidersLock.RLock()
+	constructor, registered := dnsProviders[name]
+	dnsProvidersLock.RUnlock()
+
+	var provider acme.ChallengeProvider
+	var err error
+	if registered {
+		provider, err = constructor()
+	} else {
+		provider, err = dns.NewDNSChallengeProviderByName(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if propagationTimeout > 0 {
+		interval := 2 * time.Second
+		if providerTimeout, ok := provider.(acme.ChallengeProviderTimeout); ok {
+			_, interval = providerTimeout.Timeout()
+		}
+		provider = &dnsProviderTimeout{ChallengeProvider: provider, timeout: propagationTimeout, interval: interval}
+	}
+	return provider, nil
+}
+
+// dnsProviderTimeout overrides the propagation timeout of a DNS provider.
+type dnsProviderTimeout struct {
+	acme.ChallengeProvider
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func (p *dnsProviderTimeout) Timeout() (time.Duration, time.Duration) {
+	return p.timeout, p.interval
+}
@@ -0,0 +1,103 @@
+package acme
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xenolf/lego/acme"
+)
+
+type fakeDNSProvider struct {
+	records map[string]string
+}
+
+func (p *fakeDNSProvider) Present(domain, token, keyAuth string) error {
+	p.records[domain] = keyAuth
+	return nil
+}
+
+func (p *fakeDNSProvider) CleanUp(domain, token, keyAuth string) error {
+	delete(p.records, domain)
+	return nil
+}
+
+func init() {
+	RegisterDNSProvider("fake", func() (DNSProvider, error) {
+		return &fakeDNSProvider{records: make(map[string]string)}, nil
+	})
+	RegisterDNSProvider("broken", func() (DNSProvider, error) {
+		return nil, errors.New("missing credentials")
+	})
+}
+
+func TestNewDNSChallengeProvider(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		name               string
+		propagationTimeout time.Duration
+		expectedTimeout    time.Duration
+		expectedError      bool
+	}{
+		{
+			desc: "registered provider",
+			name: "fake",
+		},
+		{
+			desc:               "registered provider with a propagation timeout",
+			name:               "fake",
+			propagationTimeout: 5 * time.Minute,
+			expectedTimeout:    5 * time.Minute,
+		},
+		{
+			desc:          "registered provider failing",
+			name:          "broken",
+			expectedError: true,
+		},
+		{
+			desc:          "unknown provider",
+			name:          "unknown",
+			expectedError: true,
+		},
+		{
+			desc:               "negative propagation timeout",
+			name:               "fake",
+			propagationTimeout: -time.Second,
+			expectedError:      true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider, err := newDNSChallengeProvider(test.name, test.propagationTimeout)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NoError(t, provider.Present("foo.com", "token", "keyAuth"))
+
+			providerTimeout, ok := provider.(acme.ChallengeProviderTimeout)
+			if test.expectedTimeout == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			timeout, interval := providerTimeout.Timeout()
+			assert.Equal(t, test.expectedTimeout, timeout)
+			assert.Equal(t, 2*time.Second, interval)
+		})
+	}
+}
+
+func TestRegisterDNSProviderTwice(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterDNSProvider("fake", func() (DNSProvider, error) { return nil, nil })
+	})
+}
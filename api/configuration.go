@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+)
+
+// effectiveConfiguration is the configuration resulting from the merge of the configurations of all the providers.
+// The frontends are sorted in the order their routes are matched.
+type effectiveConfiguration struct {
+	Frontends    []*effectiveFrontend   `json:"frontends,omitempty" toml:"frontends,omitempty"`
+	Backends     []*effectiveBackend    `json:"backends,omitempty" toml:"backends,omitempty"`
+	Certificates []*certificateMetadata `json:"certificates,omitempty" toml:"certificates,omitempty"`
+}
+
+type effectiveFrontend struct {
+	Provider          string `json:"provider" toml:"provider"`
+	Name              string `json:"name" toml:"name"`
+	EffectivePriority int    `json:"effectivePriority" toml:"effectivePriority"`
+	*types.Frontend
+}
+
+type effectiveBackend struct {
+	Provider string `json:"provider" toml:"provider"`
+	Name     string `json:"name" toml:"name"`
+	*types.Backend
+}
+
+// certificateMetadata describes a certificate of the configuration, without its private key.
+type certificateMetadata struct {
+	Provider     string     `json:"provider" toml:"provider"`
+	EntryPoints  []string   `json:"entryPoints,omitempty" toml:"entryPoints,omitempty"`
+	Subject      string     `json:"subject,omitempty" toml:"subject,omitempty"`
+	Issuer       string     `json:"issuer,omitempty" toml:"issuer,omitempty"`
+	DNSNames     []string   `json:"dnsNames,omitempty" toml:"dnsNames,omitempty"`
+	SerialNumber string     `json:"serialNumber,omitempty" toml:"serialNumber,omitempty"`
+	NotBefore    *time.Time `json:"notBefore,omitempty" toml:"notBefore,omitempty"`
+	NotAfter     *time.Time `json:"notAfter,omitempty" toml:"notAfter,omitempty"`
+	Error        string     `json:"error,omitempty" toml:"error,omitempty"`
+}
+
+func newEffectiveConfiguration(configurations types.Configurations) *effectiveConfiguration {
+	priorities := configurations.EffectivePriorities()
+
+	var providerNames []string
+	for providerName := range configurations {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+
+	effective := &effectiveConfiguration{}
+	for _, providerName := range providerNames {
+		configuration := configurations[providerName]
+		if configuration == nil {
+			continue
+		}
+
+		for frontendName, frontend := range configuration.Frontends {
+			if frontend == nil {
+				continue
+			}
+			effective.Frontends = append(effective.Frontends, &effectiveFrontend{
+				Provider:          providerName,
+				Name:              frontendName,
+				EffectivePriority: priorities[providerName][frontendName],
+				Frontend:          frontend,
+			})
+		}
+
+		var backendNames []string
+		for backendName := range configuration.Backends {
+			backendNames = append(backendNames, backendName)
+		}
+		sort.Strings(backendNames)
+		for _, backendName := range backendNames {
+			if configuration.Backends[backendName] == nil {
+				continue
+			}
+			effective.Backends = append(effective.Backends, &effectiveBackend{
+				Provider: providerName,
+				Name:     backendName,
+				Backend:  configuration.Backends[backendName],
+			})
+		}
+
+		for _, conf := range configuration.TLS {
+			if conf == nil || conf.Certificate == nil {
+				continue
+			}
+			effective.Certificates = append(effective.Certificates, newCertificateMetadata(providerName, conf))
+		}
+	}
+
+	sort.SliceStable(effective.Frontends, func(i, j int) bool {
+		return effective.Frontends[i].EffectivePriority > effective.Frontends[j].EffectivePriority
+	})
+	return effective
+}
+
+func newCertificateMetadata(providerName string, conf *traefikTls.Configuration) *certificateMetadata {
+	metadata := &certificateMetadata{
+		Provider:    providerName,
+		EntryPoints: conf.EntryPoints,
+	}
+
+	cert, err := parseCertificate(conf.Certificate.CertFile)
+	if err != nil {
+		metadata.Error = err.Error()
+		return metadata
+	}
+
+	metadata.Subject = cert.Subject.CommonName
+	metadata.Issuer = cert.Issuer.CommonName
+	metadata.DNSNames = cert.DNSNames
+	metadata.SerialNumber = cert.SerialNumber.String()
+	metadata.NotBefore = &cert.NotBefore
+	metadata.NotAfter = &cert.NotAfter
+	return metadata
+}
+
+// parseCertificate parses the first certificate of a PEM chain.
+func parseCertificate(certFile traefikTls.FileOrContent) (*x509.Certificate, error) {
+	content, err := certFile.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			return nil, errors.New("no PEM certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func (p Handler) getEffectiveConfigurationHandler(response http.ResponseWriter, request *http.Request) {
+	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
+	effective := newEffectiveConfiguration(currentConfigurations)
+
+	switch request.URL.Query().Get("format") {
+	case "", "json":
+		err := templatesRenderer.JSON(response, http.StatusOK, effective)
+		if err != nil {
+			log.Error(err)
+		}
+	case "toml":
+		buf := &bytes.Buffer{}
+		if err := toml.NewEncoder(buf).Encode(effective); err != nil {
+			log.Errorf("Error encoding the configuration in TOML: %v", err)
+			http.Error(response, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Header().Set("Content-Type", "application/toml; charset=UTF-8")
+		response.WriteHeader(http.StatusOK)
+		response.Write(buf.Bytes())
+	default:
+		http.Error(response, "unsupported format, expected json or toml", http.StatusBadRequest)
+	}
+}
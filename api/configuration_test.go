@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveConfiguration(t *testing.T) {
+	cert, key, err := generate.KeyPair("foo.example.com", time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+
+	configurations := types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend1", Routes: map[string]types.Route{"route": {Rule: "Host:foo.example.com"}}},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.1"}}},
+			},
+			TLS: []*traefikTls.Configuration{
+				{
+					EntryPoints: []string{"https"},
+					Certificate: &traefikTls.Certificate{CertFile: traefikTls.FileOrContent(cert), KeyFile: traefikTls.FileOrContent(key)},
+				},
+				{
+					Certificate: &traefikTls.Certificate{CertFile: "not a certificate", KeyFile: "not a key"},
+				},
+			},
+		},
+		"docker": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend1", Routes: map[string]types.Route{"route": {Rule: "Host:foo.example.com;Path:/api"}}},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.2"}}},
+			},
+		},
+	}
+
+	effective := newEffectiveConfiguration(configurations)
+
+	// the frontend with the longest rule is matched first
+	require.Len(t, effective.Frontends, 2)
+	assert.Equal(t, "docker", effective.Frontends[0].Provider)
+	assert.Equal(t, 2, effective.Frontends[0].EffectivePriority)
+	assert.Equal(t, "file", effective.Frontends[1].Provider)
+	assert.Equal(t, 1, effective.Frontends[1].EffectivePriority)
+
+	require.Len(t, effective.Backends, 2)
+	assert.Equal(t, "docker", effective.Backends[0].Provider)
+	assert.Equal(t, "http://10.0.0.2", effective.Backends[0].Servers["server1"].URL)
+	assert.Equal(t, "file", effective.Backends[1].Provider)
+
+	require.Len(t, effective.Certificates, 2)
+	assert.Equal(t, []string{"foo.example.com"}, effective.Certificates[0].DNSNames)
+	assert.Equal(t, []string{"https"}, effective.Certificates[0].EntryPoints)
+	require.NotNil(t, effective.Certificates[0].NotAfter)
+	assert.Empty(t, effective.Certificates[0].Error)
+	assert.Equal(t, "no PEM certificate found", effective.Certificates[1].Error)
+}
+
+func TestGetEffectiveConfigurationHandler(t *testing.T) {
+	_, key, err := generate.KeyPair("foo.example.com", time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+
+	currentConfigurations := &safe.Safe{}
+	currentConfigurations.Set(types.Configurations{
+		"file": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {Backend: "backend1", Routes: map[string]types.Route{"route": {Rule: "Host:foo.example.com"}}},
+			},
+			Backends: map[string]*types.Backend{
+				"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://10.0.0.1"}}},
+			},
+			TLS: []*traefikTls.Configuration{
+				{Certificate: &traefikTls.Certificate{CertFile: "not a certificate", KeyFile: traefikTls.FileOrContent(key)}},
+			},
+		},
+	})
+
+	router := mux.NewRouter()
+	Handler{CurrentConfigurations: currentConfigurations}.AddRoutes(router)
+
+	testCases := []struct {
+		desc                string
+		format              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{
+			desc:                "default format",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=UTF-8",
+		},
+		{
+			desc:                "json",
+			format:              "json",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=UTF-8",
+		},
+		{
+			desc:                "toml",
+			format:              "toml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/toml; charset=UTF-8",
+		},
+		{
+			desc:           "unsupported format",
+			format:         "yaml",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(http.MethodGet, "/api/configuration?format="+test.format, nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if test.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+
+			// the private keys are never exported
+			assert.NotContains(t, recorder.Body.String(), "PRIVATE KEY")
+
+			effective := map[string]interface{}{}
+			var err error
+			if test.format == "toml" {
+				_, err = toml.Decode(recorder.Body.String(), &effective)
+			} else {
+				err = json.Unmarshal(recorder.Body.Bytes(), &effective)
+			}
+			require.NoError(t, err)
+			assert.Len(t, effective["frontends"], 1)
+			assert.Len(t, effective["backends"], 1)
+			assert.Len(t, effective["certificates"], 1)
+			assert.Contains(t, recorder.Body.String(), "http://10.0.0.1")
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"runtime"
 
 	"github.com/containous/mux"
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
 )
 
 func init() {
@@ -38,9 +40,23 @@ func (g DebugHandler) AddRoutes(router *mux.Router) {
 			fmt.Fprint(w, "\n}\n")
 		})
 
+	router.Methods(http.MethodGet).Path("/debug/tls").HandlerFunc(getTLSHandler)
+
 	router.Methods(http.MethodGet).PathPrefix("/debug/pprof/cmdline").HandlerFunc(pprof.Cmdline)
 	router.Methods(http.MethodGet).PathPrefix("/debug/pprof/profile").HandlerFunc(pprof.Profile)
 	router.Methods(http.MethodGet).PathPrefix("/debug/pprof/symbol").HandlerFunc(pprof.Symbol)
 	router.Methods(http.MethodGet).PathPrefix("/debug/pprof/trace").HandlerFunc(pprof.Trace)
 	router.Methods(http.MethodGet).PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
 }
+
+type tlsRepresentation struct {
+	TLS *traefikTls.ConnectionInfo `json:"tls"`
+}
+
+// getTLSHandler exposes the TLS parameters negotiated for the connection of the current request
+func getTLSHandler(response http.ResponseWriter, request *http.Request) {
+	err := templatesRenderer.JSON(response, http.StatusOK, tlsRepresentation{TLS: traefikTls.NewConnectionInfo(request.TLS)})
+	if err != nil {
+		log.Error(err)
+	}
+}
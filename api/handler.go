@@ -1,12 +1,16 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/containous/mux"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/version"
 	thoas_stats "github.com/thoas/stats"
@@ -19,9 +23,46 @@ type Handler struct {
 	Dashboard             bool   `description:"Activate dashboard" export:"true"`
 	Debug                 bool   `export:"true"`
 	CurrentConfigurations *safe.Safe
-	Statistics            *types.Statistics          `description:"Enable more detailed statistics" export:"true"`
-	Stats                 *thoas_stats.Stats         `json:"-"`
-	StatsRecorder         *middlewares.StatsRecorder `json:"-"`
+	Statistics            *types.Statistics                                                    `description:"Enable more detailed statistics" export:"true"`
+	Stats                 *thoas_stats.Stats                                                   `json:"-"`
+	StatsRecorder         *middlewares.StatsRecorder                                           `json:"-"`
+	DrainBackend          func(providerName, backendName string, draining bool) error          `json:"-"`
+	SetServerWeight       func(providerName, backendName, serverName string, weight int) error `json:"-"`
+	CircuitBreakers       *middlewares.CircuitBreakers                                         `json:"-"`
+}
+
+// configurationRepresentation is the representation of the configuration of a provider,
+// with the effective priorities of its frontends.
+type configurationRepresentation struct {
+	Backends  map[string]*types.Backend          `json:"backends,omitempty"`
+	Frontends map[string]*frontendRepresentation `json:"frontends,omitempty"`
+	TLS       []*traefikTls.Configuration        `json:"tls,omitempty"`
+}
+
+// frontendRepresentation is the representation of a frontend, with the priority of its routes,
+// the routes with a higher priority being matched first.
+type frontendRepresentation struct {
+	*types.Frontend
+	EffectivePriority int `json:"effectivePriority"`
+}
+
+func newConfigurationRepresentation(configuration *types.Configuration, priorities map[string]int) *configurationRepresentation {
+	representation := &configurationRepresentation{
+		Backends: configuration.Backends,
+		TLS:      configuration.TLS,
+	}
+	if configuration.Frontends != nil {
+		representation.Frontends = newFrontendsRepresentation(configuration.Frontends, priorities)
+	}
+	return representation
+}
+
+func newFrontendsRepresentation(frontends map[string]*types.Frontend, priorities map[string]int) map[string]*frontendRepresentation {
+	representation := make(map[string]*frontendRepresentation, len(frontends))
+	for name, frontend := range frontends {
+		representation[name] = &frontendRepresentation{Frontend: frontend, EffectivePriority: priorities[name]}
+	}
+	return representation
 }
 
 var (
@@ -38,15 +79,19 @@ func (p Handler) AddRoutes(router *mux.Router) {
 
 	router.Methods(http.MethodGet).Path("/api").HandlerFunc(p.getConfigHandler)
 	router.Methods(http.MethodGet).Path("/api/providers").HandlerFunc(p.getConfigHandler)
+	router.Methods(http.MethodGet).Path("/api/configuration").HandlerFunc(p.getEffectiveConfigurationHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}").HandlerFunc(p.getProviderHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/backends").HandlerFunc(p.getBackendsHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/backends/{backend}").HandlerFunc(p.getBackendHandler)
+	router.Methods(http.MethodPut).Path("/api/providers/{provider}/backends/{backend}/draining").HandlerFunc(p.putBackendDrainingHandler)
+	router.Methods(http.MethodPut).Path("/api/providers/{provider}/backends/{backend}/servers/{server}/weight").HandlerFunc(p.putServerWeightHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/backends/{backend}/servers").HandlerFunc(p.getServersHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/backends/{backend}/servers/{server}").HandlerFunc(p.getServerHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends").HandlerFunc(p.getFrontendsHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends/{frontend}").HandlerFunc(p.getFrontendHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends/{frontend}/routes").HandlerFunc(p.getRoutesHandler)
 	router.Methods(http.MethodGet).Path("/api/providers/{provider}/frontends/{frontend}/routes/{route}").HandlerFunc(p.getRouteHandler)
+	router.Methods(http.MethodGet).Path("/api/circuitbreakers").HandlerFunc(p.getCircuitBreakersHandler)
 
 	// health route
 	router.Methods(http.MethodGet).Path("/health").HandlerFunc(p.getHealthHandler)
@@ -69,7 +114,13 @@ func getProviderIDFromVars(vars map[string]string) string {
 
 func (p Handler) getConfigHandler(response http.ResponseWriter, request *http.Request) {
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
-	err := templatesRenderer.JSON(response, http.StatusOK, currentConfigurations)
+	priorities := currentConfigurations.EffectivePriorities()
+
+	representation := make(map[string]*configurationRepresentation, len(currentConfigurations))
+	for providerName, configuration := range currentConfigurations {
+		representation[providerName] = newConfigurationRepresentation(configuration, priorities[providerName])
+	}
+	err := templatesRenderer.JSON(response, http.StatusOK, representation)
 	if err != nil {
 		log.Error(err)
 	}
@@ -80,7 +131,8 @@ func (p Handler) getProviderHandler(response http.ResponseWriter, request *http.
 
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
-		err := templatesRenderer.JSON(response, http.StatusOK, provider)
+		priorities := currentConfigurations.EffectivePriorities()
+		err := templatesRenderer.JSON(response, http.StatusOK, newConfigurationRepresentation(provider, priorities[providerID]))
 		if err != nil {
 			log.Error(err)
 		}
@@ -121,6 +173,87 @@ func (p Handler) getBackendHandler(response http.ResponseWriter, request *http.R
 	http.NotFound(response, request)
 }
 
+type backendDraining struct {
+	Draining bool `json:"draining"`
+}
+
+func (p Handler) putBackendDrainingHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	providerID := getProviderIDFromVars(vars)
+	backendID := vars["backend"]
+
+	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
+	provider, ok := currentConfigurations[providerID]
+	if !ok || provider.Backends[backendID] == nil || p.DrainBackend == nil {
+		http.NotFound(response, request)
+		return
+	}
+
+	state := new(backendDraining)
+	body, _ := ioutil.ReadAll(request.Body)
+	if err := json.Unmarshal(body, state); err != nil {
+		log.Errorf("Error parsing draining state %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := p.DrainBackend(providerID, backendID, state.Draining); err != nil {
+		log.Error(err)
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, state)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+type serverWeight struct {
+	Weight int `json:"weight"`
+}
+
+func (p Handler) putServerWeightHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	providerID := getProviderIDFromVars(vars)
+	backendID := vars["backend"]
+	serverID := vars["server"]
+
+	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
+	provider, ok := currentConfigurations[providerID]
+	if !ok || provider.Backends[backendID] == nil || p.SetServerWeight == nil {
+		http.NotFound(response, request)
+		return
+	}
+	if _, ok := provider.Backends[backendID].Servers[serverID]; !ok {
+		http.NotFound(response, request)
+		return
+	}
+
+	state := new(serverWeight)
+	body, _ := ioutil.ReadAll(request.Body)
+	if err := json.Unmarshal(body, state); err != nil {
+		log.Errorf("Error parsing server weight %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+	if state.Weight < 0 {
+		http.Error(response, fmt.Sprintf("invalid weight %d", state.Weight), http.StatusBadRequest)
+		return
+	}
+
+	if err := p.SetServerWeight(providerID, backendID, serverID, state.Weight); err != nil {
+		log.Error(err)
+		http.NotFound(response, request)
+		return
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, state)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
 func (p Handler) getServersHandler(response http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
 	providerID := getProviderIDFromVars(vars)
@@ -165,7 +298,8 @@ func (p Handler) getFrontendsHandler(response http.ResponseWriter, request *http
 
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
-		err := templatesRenderer.JSON(response, http.StatusOK, provider.Frontends)
+		priorities := currentConfigurations.EffectivePriorities()
+		err := templatesRenderer.JSON(response, http.StatusOK, newFrontendsRepresentation(provider.Frontends, priorities[providerID]))
 		if err != nil {
 			log.Error(err)
 		}
@@ -182,7 +316,8 @@ func (p Handler) getFrontendHandler(response http.ResponseWriter, request *http.
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
 		if frontend, ok := provider.Frontends[frontendID]; ok {
-			err := templatesRenderer.JSON(response, http.StatusOK, frontend)
+			priorities := currentConfigurations.EffectivePriorities()
+			err := templatesRenderer.JSON(response, http.StatusOK, &frontendRepresentation{Frontend: frontend, EffectivePriority: priorities[providerID][frontendID]})
 			if err != nil {
 				log.Error(err)
 			}
@@ -231,6 +366,18 @@ func (p Handler) getRouteHandler(response http.ResponseWriter, request *http.Req
 	http.NotFound(response, request)
 }
 
+func (p Handler) getCircuitBreakersHandler(response http.ResponseWriter, request *http.Request) {
+	states := []middlewares.CircuitBreakerState{}
+	if p.CircuitBreakers != nil {
+		states = p.CircuitBreakers.States()
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, states)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
 // healthResponse combines data returned by thoas/stats with statistics (if
 // they are enabled).
 type healthResponse struct {
@@ -247,6 +247,34 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
 [backends]
 {{range $backendName, $backend := .Backends}}
 
+  {{ $hostHeader := getHostHeader $backend }}
+  {{ $draining := isDraining $backend }}
+  {{ $sourceAddress := getSourceAddress $backend }}
+  {{ $h2c := isH2C $backend }}
+  {{ $forceHTTP1 := isForceHTTP1 $backend }}
+  {{ $percentWeights := isPercentWeights $backend }}
+  {{if or $hostHeader $draining $sourceAddress $h2c $forceHTTP1 $percentWeights }}
+  [backends."backend-{{ $backendName }}"]
+    {{if $hostHeader }}
+    hostHeader = "{{ $hostHeader }}"
+    {{end}}
+    {{if $draining }}
+    draining = true
+    {{end}}
+    {{if $sourceAddress }}
+    sourceAddress = "{{ $sourceAddress }}"
+    {{end}}
+    {{if $h2c }}
+    h2c = true
+    {{end}}
+    {{if $forceHTTP1 }}
+    forceHTTP1 = true
+    {{end}}
+    {{if $percentWeights }}
+    percentWeights = true
+    {{end}}
+  {{end}}
+
   {{ $circuitBreaker := getCircuitBreaker $backend }}
   {{if $circuitBreaker }}
   [backends."backend-{{ $backendName }}".circuitBreaker]
@@ -322,6 +350,10 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
     priority = {{ getServicePriority $container $serviceName }}
     passHostHeader = {{ getServicePassHostHeader $container $serviceName }}
     passTLSCert = {{ getServicePassTLSCert $container $serviceName }}
+    {{ $customHost := getServiceCustomHost $container $serviceName }}
+    {{if $customHost }}
+    customHost = "{{ $customHost }}"
+    {{end}}
 
     entryPoints = [{{range getServiceEntryPoints $container $serviceName }}
       "{{.}}",
@@ -438,6 +470,10 @@ var _templatesDockerTmpl = []byte(`{{$backendServers := .Servers}}
     priority = {{ getPriority $container }}
     passHostHeader = {{ getPassHostHeader $container }}
     passTLSCert = {{ getPassTLSCert $container }}
+    {{ $customHost := getCustomHost $container }}
+    {{if $customHost }}
+    customHost = "{{ $customHost }}"
+    {{end}}
 
     entryPoints = [{{range getEntryPoints $container }}
       "{{.}}",
@@ -819,6 +855,13 @@ var _templatesKubernetesTmpl = []byte(`[backends]
       retryExpression = "{{ $backend.Buffering.RetryExpression }}"
     {{end}}
 
+    {{if $backend.TLS }}
+    [backends."{{ $backendName }}".tls]
+      ca = """{{ $backend.TLS.CA }}"""
+      cert = """{{ $backend.TLS.Cert }}"""
+      key = """{{ $backend.TLS.Key }}"""
+    {{end}}
+
     {{range $serverName, $server := $backend.Servers }}
     [backends."{{ $backendName }}".servers."{{ $serverName }}"]
       url = "{{ $server.URL }}"
@@ -835,6 +878,9 @@ var _templatesKubernetesTmpl = []byte(`[backends]
     priority = {{ $frontend.Priority }}
     passHostHeader = {{ $frontend.PassHostHeader }}
     passTLSCert = {{ $frontend.PassTLSCert }}
+    {{if $frontend.CustomHost }}
+    customHost = "{{ $frontend.CustomHost }}"
+    {{end}}
 
     entryPoints = [{{range $frontend.EntryPoints }}
       "{{.}}",
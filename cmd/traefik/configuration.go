@@ -8,7 +8,9 @@ import (
 	"github.com/containous/traefik/api"
 	"github.com/containous/traefik/configuration"
 	"github.com/containous/traefik/middlewares/accesslog"
+	"github.com/containous/traefik/middlewares/requestid"
 	"github.com/containous/traefik/middlewares/tracing"
+	"github.com/containous/traefik/middlewares/tracing/datadog"
 	"github.com/containous/traefik/middlewares/tracing/jaeger"
 	"github.com/containous/traefik/middlewares/tracing/zipkin"
 	"github.com/containous/traefik/ping"
@@ -25,7 +27,9 @@ import (
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
 	"github.com/containous/traefik/provider/rancher"
+	"github.com/containous/traefik/provider/redis"
 	"github.com/containous/traefik/provider/rest"
+	"github.com/containous/traefik/provider/vault"
 	"github.com/containous/traefik/provider/zk"
 	"github.com/containous/traefik/types"
 	sf "github.com/jjcollinge/servicefabric"
@@ -45,6 +49,7 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultDocker.ExposedByDefault = true
 	defaultDocker.Endpoint = "unix:///var/run/docker.sock"
 	defaultDocker.SwarmMode = false
+	defaultDocker.SecretsPath = docker.DefaultSecretsPath
 
 	// default File
 	var defaultFile file.Provider
@@ -75,9 +80,11 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		StatsD: &types.Statsd{
 			Address:      "localhost:8125",
 			PushInterval: "10s",
+			Prefix:       "traefik",
 		},
 		InfluxDB: &types.InfluxDB{
 			Address:      "localhost:8089",
+			Protocol:     "udp",
 			PushInterval: "10s",
 		},
 	}
@@ -113,6 +120,13 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	defaultEtcd.Prefix = "/traefik"
 	defaultEtcd.Constraints = types.Constraints{}
 
+	// default Redis
+	var defaultRedis redis.Provider
+	defaultRedis.Watch = true
+	defaultRedis.Endpoint = "127.0.0.1:6379"
+	defaultRedis.Prefix = "traefik"
+	defaultRedis.Constraints = types.Constraints{}
+
 	//default Zookeeper
 	var defaultZookeeper zk.Provider
 	defaultZookeeper.Watch = true
@@ -168,6 +182,11 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	var defaultEureka eureka.Provider
 	defaultEureka.Delay = "30s"
 
+	// default Vault
+	var defaultVault vault.Provider
+	defaultVault.Endpoint = "http://127.0.0.1:8200"
+	defaultVault.RefreshInterval = flaeg.Duration(vault.DefaultRefreshInterval)
+
 	// default ServiceFabric
 	var defaultServiceFabric servicefabric.Provider
 	defaultServiceFabric.APIVersion = sf.DefaultAPIVersion
@@ -205,6 +224,11 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		DialTimeout: flaeg.Duration(configuration.DefaultDialTimeout),
 	}
 
+	// default RequestID
+	defaultRequestID := configuration.RequestID{
+		HeaderName: requestid.DefaultHeaderName,
+	}
+
 	// default Tracing
 	defaultTracing := tracing.Tracing{
 		Backend:     "jaeger",
@@ -214,12 +238,19 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 			SamplingType:       "const",
 			SamplingParam:      1.0,
 			LocalAgentHostPort: "127.0.0.1:6832",
+			Propagation:        jaeger.JaegerPropagation,
 		},
 		Zipkin: &zipkin.Config{
-			HTTPEndpoint: "http://localhost:9411/api/v1/spans",
-			SameSpan:     false,
-			ID128Bit:     true,
-			Debug:        false,
+			HTTPEndpoint:  "http://localhost:9411/api/v1/spans",
+			SameSpan:      false,
+			ID128Bit:      true,
+			Debug:         false,
+			SampleRate:    1.0,
+			BatchInterval: flaeg.Duration(time.Second),
+		},
+		Datadog: &datadog.Config{
+			LocalAgentHostPort: "localhost:8126",
+			SampleRate:         1.0,
 		},
 	}
 
@@ -250,9 +281,11 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		StatsD: &types.Statsd{
 			Address:      "localhost:8125",
 			PushInterval: "10s",
+			Prefix:       "traefik",
 		},
 		InfluxDB: &types.InfluxDB{
 			Address:      "localhost:8089",
+			Protocol:     "udp",
 			PushInterval: "10s",
 		},
 	}
@@ -268,16 +301,19 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		Etcd:               &defaultEtcd,
 		Zookeeper:          &defaultZookeeper,
 		Boltdb:             &defaultBoltDb,
+		Redis:              &defaultRedis,
 		Kubernetes:         &defaultKubernetes,
 		Mesos:              &defaultMesos,
 		ECS:                &defaultECS,
 		Rancher:            &defaultRancher,
 		Eureka:             &defaultEureka,
 		DynamoDB:           &defaultDynamoDB,
+		Vault:              &defaultVault,
 		Retry:              &configuration.Retry{},
 		HealthCheck:        &healthCheck,
 		RespondingTimeouts: &respondingTimeouts,
 		ForwardingTimeouts: &forwardingTimeouts,
+		RequestID:          &defaultRequestID,
 		TraefikLog:         &defaultTraefikLog,
 		AccessLog:          &defaultAccessLog,
 		LifeCycle:          &defaultLifeCycle,
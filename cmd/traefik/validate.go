@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/server"
+	"github.com/containous/traefik/types"
+)
+
+func newValidateCmd(traefikConfiguration *TraefikConfiguration, traefikPointersConfiguration *TraefikConfiguration) *flaeg.Command {
+	return &flaeg.Command{
+		Name:                  "validate",
+		Description:           `Validate the configuration and the file provider configuration, without starting traefik`,
+		Config:                traefikConfiguration,
+		DefaultPointersConfig: traefikPointersConfiguration,
+		Run: runValidate(traefikConfiguration),
+		Metadata: map[string]string{
+			"parseAllSources": "true",
+		},
+	}
+}
+
+func runValidate(traefikConfiguration *TraefikConfiguration) func() error {
+	return func() error {
+		errs := validate(traefikConfiguration)
+		if len(errs) > 0 {
+			fmt.Printf("Invalid configuration %s:\n", traefikConfiguration.ConfigFile)
+			for _, err := range errs {
+				fmt.Printf("  - %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Valid configuration %s\n", traefikConfiguration.ConfigFile)
+		os.Exit(0)
+		return nil
+	}
+}
+
+// validate returns the errors of the configuration, and of the dynamic configuration of the file provider.
+func validate(traefikConfiguration *TraefikConfiguration) []error {
+	globalConfiguration := traefikConfiguration.GlobalConfiguration
+	globalConfiguration.SetEffectiveConfiguration(traefikConfiguration.ConfigFile)
+
+	configurations := types.Configurations{}
+	if globalConfiguration.File != nil {
+		configuration, err := globalConfiguration.File.BuildConfiguration()
+		if err != nil {
+			return []error{fmt.Errorf("unable to load the file provider configuration: %v", err)}
+		}
+		if configuration != nil {
+			configurations["file"] = configuration
+		}
+	}
+
+	return server.ValidateConfiguration(globalConfiguration, configurations)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/provider/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		dynamic        string
+		expectedErrors int
+	}{
+		{
+			desc: "valid configuration",
+			dynamic: `
+[frontends.frontend1]
+backend = "backend1"
+entryPoints = ["http"]
+  [frontends.frontend1.routes.route1]
+  rule = "Host:foo.example.com"
+[backends.backend1.servers.server1]
+url = "http://127.0.0.1:8080"
+`,
+		},
+		{
+			desc: "invalid rule and entrypoint",
+			dynamic: `
+[frontends.frontend1]
+backend = "backend1"
+entryPoints = ["https"]
+  [frontends.frontend1.routes.route1]
+  rule = "Hostt:foo.example.com"
+[backends.backend1.servers.server1]
+url = "http://127.0.0.1:8080"
+`,
+			expectedErrors: 2,
+		},
+		{
+			desc:           "unreadable dynamic configuration",
+			dynamic:        `[frontends`,
+			expectedErrors: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "traefik-validate")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			filename := filepath.Join(dir, "rules.toml")
+			require.NoError(t, ioutil.WriteFile(filename, []byte(test.dynamic), 0644))
+
+			traefikConfiguration := &TraefikConfiguration{
+				GlobalConfiguration: configuration.GlobalConfiguration{
+					EntryPoints: configuration.EntryPoints{
+						"http": &configuration.EntryPoint{Address: ":80"},
+					},
+					DefaultEntryPoints: configuration.DefaultEntryPoints{"http"},
+					File:               &file.Provider{Filename: filename},
+				},
+			}
+
+			errs := validate(traefikConfiguration)
+			assert.Len(t, errs, test.expectedErrors, "%v", errs)
+		})
+	}
+}
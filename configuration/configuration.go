@@ -2,9 +2,13 @@ package configuration
 
 import (
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/abronan/valkeyrie/store"
 	"github.com/containous/flaeg"
 	"github.com/containous/traefik-extra-service-fabric"
 	"github.com/containous/traefik/acme"
@@ -12,6 +16,7 @@ import (
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares/tracing"
 	"github.com/containous/traefik/ping"
+	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/provider/boltdb"
 	"github.com/containous/traefik/provider/consul"
 	"github.com/containous/traefik/provider/consulcatalog"
@@ -25,7 +30,9 @@ import (
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
 	"github.com/containous/traefik/provider/rancher"
+	"github.com/containous/traefik/provider/redis"
 	"github.com/containous/traefik/provider/rest"
+	"github.com/containous/traefik/provider/vault"
 	"github.com/containous/traefik/provider/zk"
 	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
@@ -47,6 +54,25 @@ const (
 	// DefaultGraceTimeout controls how long Traefik serves pending requests
 	// prior to shutting down.
 	DefaultGraceTimeout = 10 * time.Second
+
+	// DefaultResetStreamsPeriod is the default period over which the streams reset by an HTTP/2 client are counted.
+	DefaultResetStreamsPeriod = time.Second
+)
+
+const (
+	// AmbiguousRoutesFirst (default) routes requests to the first declared frontend
+	// when several frontends share the same rules and priority.
+	AmbiguousRoutesFirst = "first"
+
+	// AmbiguousRoutesFail rejects configurations where several frontends
+	// share the same rules and priority.
+	AmbiguousRoutesFail = "fail"
+)
+
+const (
+	// StatusClientClosedRequest (default) is the non-standard status code reported
+	// when the client closes its connection before the backend response is received.
+	StatusClientClosedRequest = 499
 )
 
 // GlobalConfiguration holds global configuration (with providers, etc.).
@@ -69,6 +95,9 @@ type GlobalConfiguration struct {
 	ACME                      *acme.ACME              `description:"Enable ACME (Let's Encrypt): automatic SSL" export:"true"`
 	DefaultEntryPoints        DefaultEntryPoints      `description:"Entrypoints to be used by frontends that do not specify any entrypoint" export:"true"`
 	ProvidersThrottleDuration flaeg.Duration          `description:"Backends throttle duration: minimum duration between 2 events from providers before applying a new configuration. It avoids unnecessary reloads if multiples events are sent in a short amount of time." export:"true"`
+	AmbiguousRoutes           string                  `description:"Behavior when several frontends match the same requests with the same priority: first | fail" export:"true"`
+	ClientClosedRequestStatus int                     `description:"Status code reported when the client closes its connection before the backend response is received: 499 | 502" export:"true"`
+	DefaultMiddlewares        *DefaultMiddlewares     `description:"Middlewares applied to every frontend before its own ones" export:"true"`
 	MaxIdleConnsPerHost       int                     `description:"If non-zero, controls the maximum idle (keep-alive) to keep per-host.  If zero, DefaultMaxIdleConnsPerHost is used" export:"true"`
 	IdleTimeout               flaeg.Duration          `description:"(Deprecated) maximum amount of time an idle (keep-alive) connection will remain idle before closing itself." export:"true"` // Deprecated
 	InsecureSkipVerify        bool                    `description:"Disable SSL certificate verification" export:"true"`
@@ -77,6 +106,9 @@ type GlobalConfiguration struct {
 	HealthCheck               *HealthCheckConfig      `description:"Health check parameters" export:"true"`
 	RespondingTimeouts        *RespondingTimeouts     `description:"Timeouts for incoming requests to the Traefik instance" export:"true"`
 	ForwardingTimeouts        *ForwardingTimeouts     `description:"Timeouts for requests forwarded to the backend servers" export:"true"`
+	DNSResolver               *types.DNSResolver      `description:"DNS servers resolving the hostnames of the backend servers and checking the ACME DNS challenges, instead of the system resolver" export:"true"`
+	RequestID                 *RequestID              `description:"Give an ID to each request, sent to the backends and the clients in a header" export:"true"`
+	RateLimitStore            *RateLimitStore         `description:"Share the counters of the rate limits between the Traefik instances with Redis" export:"true"`
 	Web                       *WebCompatibility       `description:"(Deprecated) Enable Web backend with default settings" export:"true"` // Deprecated
 	Docker                    *docker.Provider        `description:"Enable Docker backend with default settings" export:"true"`
 	File                      *file.Provider          `description:"Enable File backend with default settings" export:"true"`
@@ -86,6 +118,7 @@ type GlobalConfiguration struct {
 	Etcd                      *etcd.Provider          `description:"Enable Etcd backend with default settings" export:"true"`
 	Zookeeper                 *zk.Provider            `description:"Enable Zookeeper backend with default settings" export:"true"`
 	Boltdb                    *boltdb.Provider        `description:"Enable Boltdb backend with default settings" export:"true"`
+	Redis                     *redis.Provider         `description:"Enable Redis backend with default settings" export:"true"`
 	Kubernetes                *kubernetes.Provider    `description:"Enable Kubernetes backend with default settings" export:"true"`
 	Mesos                     *mesos.Provider         `description:"Enable Mesos backend with default settings" export:"true"`
 	Eureka                    *eureka.Provider        `description:"Enable Eureka backend with default settings" export:"true"`
@@ -94,9 +127,19 @@ type GlobalConfiguration struct {
 	DynamoDB                  *dynamodb.Provider      `description:"Enable DynamoDB backend with default settings" export:"true"`
 	ServiceFabric             *servicefabric.Provider `description:"Enable Service Fabric backend with default settings" export:"true"`
 	Rest                      *rest.Provider          `description:"Enable Rest backend with default settings" export:"true"`
+	Vault                     *vault.Provider         `description:"Enable Vault certificates with default settings" export:"true"`
 	API                       *api.Handler            `description:"Enable api/dashboard" export:"true"`
 	Metrics                   *types.Metrics          `description:"Enable a metrics exporter" export:"true"`
 	Ping                      *ping.Handler           `description:"Enable ping" export:"true"`
+	DefaultCertificates       tls.DefaultCertificates `description:"Certificates served when no other certificate matches the requested server name, only configurable through the configuration file"`
+}
+
+// DefaultMiddlewares holds the middlewares applied to every frontend which doesn't opt out of them,
+// before the middlewares of the frontend.
+type DefaultMiddlewares struct {
+	WhitelistSourceRange []string       `export:"true"` // only configurable through the configuration file
+	BasicAuth            []string       // only configurable through the configuration file
+	Headers              *types.Headers `export:"true"` // only configurable through the configuration file
 }
 
 // WebCompatibility is a configuration to handle compatibility with deprecated web provider options
@@ -262,17 +305,102 @@ func (gc *GlobalConfiguration) SetEffectiveConfiguration(configFile string) {
 	}
 }
 
+// GetProviderThrottleDuration returns the minimum duration between 2 configurations applied from the provider
+// sending the given name: the throttle duration of the provider if set, ProvidersThrottleDuration otherwise.
+func (gc *GlobalConfiguration) GetProviderThrottleDuration(providerName string) time.Duration {
+	if baseProvider := gc.getBaseProvider(providerName); baseProvider != nil && baseProvider.ThrottleDuration > 0 {
+		return time.Duration(baseProvider.ThrottleDuration)
+	}
+	return time.Duration(gc.ProvidersThrottleDuration)
+}
+
+// getBaseProvider returns the base configuration of the enabled provider sending the given name, or nil.
+func (gc *GlobalConfiguration) getBaseProvider(providerName string) *provider.BaseProvider {
+	switch {
+	case providerName == "docker" && gc.Docker != nil:
+		return &gc.Docker.BaseProvider
+	case providerName == "marathon" && gc.Marathon != nil:
+		return &gc.Marathon.BaseProvider
+	case providerName == "file" && gc.File != nil:
+		return &gc.File.BaseProvider
+	case providerName == string(store.CONSUL) && gc.Consul != nil:
+		return &gc.Consul.BaseProvider
+	case providerName == "consul_catalog" && gc.ConsulCatalog != nil:
+		return &gc.ConsulCatalog.BaseProvider
+	case (providerName == string(store.ETCD) || providerName == string(store.ETCDV3)) && gc.Etcd != nil:
+		return &gc.Etcd.BaseProvider
+	case providerName == string(store.ZK) && gc.Zookeeper != nil:
+		return &gc.Zookeeper.BaseProvider
+	case providerName == string(store.BOLTDB) && gc.Boltdb != nil:
+		return &gc.Boltdb.BaseProvider
+	case providerName == string(store.REDIS) && gc.Redis != nil:
+		return &gc.Redis.BaseProvider
+	case providerName == "kubernetes" && gc.Kubernetes != nil:
+		return &gc.Kubernetes.BaseProvider
+	case providerName == "mesos" && gc.Mesos != nil:
+		return &gc.Mesos.BaseProvider
+	case providerName == "eureka" && gc.Eureka != nil:
+		return &gc.Eureka.BaseProvider
+	case providerName == "ecs" && gc.ECS != nil:
+		return &gc.ECS.BaseProvider
+	case providerName == "rancher" && gc.Rancher != nil:
+		return &gc.Rancher.BaseProvider
+	case providerName == "dynamodb" && gc.DynamoDB != nil:
+		return &gc.DynamoDB.BaseProvider
+	case providerName == "servicefabric" && gc.ServiceFabric != nil:
+		return &gc.ServiceFabric.BaseProvider
+	case providerName == "vault" && gc.Vault != nil:
+		return &gc.Vault.BaseProvider
+	default:
+		return nil
+	}
+}
+
 // ValidateConfiguration validate that configuration is coherent
 func (gc *GlobalConfiguration) ValidateConfiguration() {
+	if errs := gc.Validate(); len(errs) > 0 {
+		log.Fatal(errs[0])
+	}
+}
+
+// Validate returns the errors making the configuration incoherent
+func (gc *GlobalConfiguration) Validate() []error {
+	var errs []error
+
+	switch gc.AmbiguousRoutes {
+	case "", AmbiguousRoutesFirst, AmbiguousRoutesFail:
+	default:
+		errs = append(errs, fmt.Errorf("Unknown ambiguous routes behavior %q, must be %q or %q", gc.AmbiguousRoutes, AmbiguousRoutesFirst, AmbiguousRoutesFail))
+	}
+
+	switch gc.ClientClosedRequestStatus {
+	case 0, StatusClientClosedRequest, http.StatusBadGateway:
+	default:
+		errs = append(errs, fmt.Errorf("Unsupported client closed request status %d, must be %d or %d", gc.ClientClosedRequestStatus, StatusClientClosedRequest, http.StatusBadGateway))
+	}
+
+	var entryPointNames []string
+	for entryPointName := range gc.EntryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+	for _, entryPointName := range entryPointNames {
+		if entryPoint := gc.EntryPoints[entryPointName]; entryPoint.TCP != nil && entryPoint.TLS != nil {
+			errs = append(errs, fmt.Errorf("TLS configuration on the TCP entrypoint %q", entryPointName))
+		}
+	}
+
 	if gc.ACME != nil {
 		if _, ok := gc.EntryPoints[gc.ACME.EntryPoint]; !ok {
-			log.Fatalf("Unknown entrypoint %q for ACME configuration", gc.ACME.EntryPoint)
+			errs = append(errs, fmt.Errorf("Unknown entrypoint %q for ACME configuration", gc.ACME.EntryPoint))
 		} else {
 			if gc.EntryPoints[gc.ACME.EntryPoint].TLS == nil {
-				log.Fatalf("Entrypoint without TLS %q for ACME configuration", gc.ACME.EntryPoint)
+				errs = append(errs, fmt.Errorf("Entrypoint without TLS %q for ACME configuration", gc.ACME.EntryPoint))
 			}
 		}
 	}
+
+	return errs
 }
 
 // DefaultEntryPoints holds default entry points
@@ -356,6 +484,21 @@ func (ep *EntryPoints) Set(value string) error {
 			EntryPoint:  result["redirect_entrypoint"],
 			Regex:       result["redirect_regex"],
 			Replacement: result["redirect_replacement"],
+			Permanent:   toBool(result, "redirect_permanent"),
+		}
+		if len(result["redirect_statuscode"]) > 0 {
+			statusCode, err := strconv.Atoi(result["redirect_statuscode"])
+			if err != nil {
+				return fmt.Errorf("invalid redirect status code %q", result["redirect_statuscode"])
+			}
+			redirect.StatusCode = statusCode
+		}
+		if len(result["redirect_port"]) > 0 {
+			port, err := strconv.Atoi(result["redirect_port"])
+			if err != nil {
+				return fmt.Errorf("invalid redirect port %q", result["redirect_port"])
+			}
+			redirect.Port = port
 		}
 	}
 
@@ -390,6 +533,15 @@ func (ep *EntryPoints) Set(value string) error {
 		forwardedHeaders.TrustedIPs = strings.Split(fhTrustedIPs, ",")
 	}
 
+	var maxHeaderBytes int
+	if len(result["maxheaderbytes"]) > 0 {
+		var err error
+		maxHeaderBytes, err = strconv.Atoi(result["maxheaderbytes"])
+		if err != nil || maxHeaderBytes < 0 {
+			return fmt.Errorf("invalid max header bytes %q", result["maxheaderbytes"])
+		}
+	}
+
 	if proxyProtocol != nil && proxyProtocol.Insecure {
 		log.Warn("ProxyProtocol.Insecure:true is dangerous. Please use 'ProxyProtocol.TrustedIPs:IPs' and remove 'ProxyProtocol.Insecure:true'")
 	}
@@ -402,6 +554,7 @@ func (ep *EntryPoints) Set(value string) error {
 		WhitelistSourceRange: whiteListSourceRange,
 		ProxyProtocol:        proxyProtocol,
 		ForwardedHeaders:     forwardedHeaders,
+		MaxHeaderBytes:       maxHeaderBytes,
 	}
 
 	return nil
@@ -460,13 +613,50 @@ type EntryPoint struct {
 	Auth                 *types.Auth     `export:"true"`
 	WhitelistSourceRange []string
 	Compress             bool              `export:"true"`
+	Compression          *Compression      `export:"true"`
 	ProxyProtocol        *ProxyProtocol    `export:"true"`
 	ForwardedHeaders     *ForwardedHeaders `export:"true"`
+	HTTP2                *EntryPointHTTP2  `export:"true"`
+	TCP                  *EntryPointTCP    `export:"true"`
+	MaxHeaderBytes       int               `export:"true"`
+}
+
+// Compression contains the configuration of the compression of the responses of an entry point
+type Compression struct {
+	BrotliQuality        int      `description:"Quality of the Brotli compression, from 1 (fastest) to 11 (smallest). Defaults to 5" export:"true"`
+	MinSize              int      `description:"Minimum size in bytes of the responses to compress. Defaults to 512" export:"true"`
+	ExcludedContentTypes []string `description:"Content types of the responses which are not compressed. Defaults to already compressed images, audio, video, archives and fonts" export:"true"`
+	Transcode            bool     `description:"Decompress the gzip and deflate responses of the backends to compress them with the encoding preferred by the client" export:"true"`
+}
+
+// EntryPointHTTP2 contains the settings of the HTTP/2 server of a TLS entry point.
+// The zero values keep the defaults of the HTTP/2 server.
+type EntryPointHTTP2 struct {
+	MaxConcurrentStreams uint32         `description:"Maximum number of concurrent streams per connection. Defaults to 250" export:"true"`
+	MaxReadFrameSize     uint32         `description:"Maximum size in bytes of the frames read from the clients, from 16384 to 16777215. Defaults to 1048576" export:"true"`
+	IdleTimeout          flaeg.Duration `description:"Maximum duration an idle HTTP/2 connection remains open. Defaults to the idle timeout of the responding timeouts" export:"true"`
+	MaxResetStreams      int            `description:"Maximum number of streams a client can reset within ResetStreamsPeriod before its connection is closed with a GOAWAY frame. Disabled when zero" export:"true"`
+	ResetStreamsPeriod   flaeg.Duration `description:"Period over which the streams reset by a client are counted. Defaults to 1 second" export:"true"`
+}
+
+// EntryPointTCP contains the configuration of a TCP entry point, forwarding the connections to servers without handling HTTP.
+// The TLS connections are forwarded according to the server name sent by the client, without terminating TLS.
+type EntryPointTCP struct {
+	Servers     []string       `description:"Addresses of the servers receiving the connections not matching any route" export:"true"`
+	Routes      []*TCPRoute    `description:"Servers receiving the TLS connections, by server name" export:"true"`
+	PeekTimeout flaeg.Duration `description:"Maximum duration to wait for the TLS handshake of the connections to read their server name, the connections of the protocols where the server speaks first being delayed as much. Required with routes" export:"true"`
+}
+
+// TCPRoute contains the servers receiving the TLS connections of a server name.
+type TCPRoute struct {
+	ServerName string   `description:"Server name sent by the client, *.example.com matching the subdomains of example.com" export:"true"`
+	Servers    []string `description:"Addresses of the servers receiving the connections" export:"true"`
 }
 
 // Retry contains request retry config
 type Retry struct {
-	Attempts int `description:"Number of attempts" export:"true"`
+	Attempts int                `description:"Number of attempts" export:"true"`
+	Budget   *types.RetryBudget `description:"Cap the retries of each backend to a ratio of its requests" export:"true"`
 }
 
 // HealthCheckConfig contains health check configuration parameters.
@@ -487,6 +677,24 @@ type ForwardingTimeouts struct {
 	ResponseHeaderTimeout flaeg.Duration `description:"The amount of time to wait for a server's response headers after fully writing the request (including its body, if any). If zero, no timeout exists" export:"true"`
 }
 
+// RequestID contains the configuration of the request IDs, correlating the access logs and the traces of the requests.
+type RequestID struct {
+	HeaderName string `description:"Header carrying the request ID. Defaults to X-Request-Id" export:"true"`
+}
+
+// RateLimitStore contains the configuration of the Redis servers sharing the counters of the rate limits between the Traefik instances.
+type RateLimitStore struct {
+	Endpoint        string           `description:"Comma separated Redis server endpoints"`
+	Username        string           `description:"Redis username"`
+	Password        string           `description:"Redis password"`
+	DB              int              `description:"Index of the Redis database" export:"true"`
+	TLS             *types.ClientTLS `description:"Enable TLS support" export:"true"`
+	Prefix          string           `description:"Prefix of the keys of the counters. Defaults to traefik:ratelimit" export:"true"`
+	Timeout         flaeg.Duration   `description:"Timeout of the requests to Redis. Defaults to 100ms" export:"true"`
+	DialTimeout     flaeg.Duration   `description:"Timeout of the connections to Redis. Defaults to 1s" export:"true"`
+	FallbackToLocal bool             `description:"Limit the requests on each instance when Redis is unreachable, instead of letting them through" export:"true"`
+}
+
 // ProxyProtocol contains Proxy-Protocol configuration
 type ProxyProtocol struct {
 	Insecure   bool
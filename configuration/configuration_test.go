@@ -1,12 +1,17 @@
 package configuration
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/containous/flaeg"
+	"github.com/containous/traefik/acme"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/etcd"
 	"github.com/containous/traefik/provider/file"
+	"github.com/containous/traefik/provider/kubernetes"
+	"github.com/containous/traefik/provider/kv"
 	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
@@ -289,6 +294,31 @@ func TestEntryPoints_Set(t *testing.T) {
 				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
 			},
 		},
+		{
+			name:                   "redirect status code and port",
+			expression:             "Name:foo Redirect.EntryPoint:https Redirect.Permanent:true Redirect.StatusCode:308 Redirect.Port:8443",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				Redirect: &types.Redirect{
+					EntryPoint: "https",
+					Permanent:  true,
+					StatusCode: 308,
+					Port:       8443,
+				},
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+			},
+		},
+		{
+			name:                   "max header bytes",
+			expression:             "Name:foo MaxHeaderBytes:65536",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+				MaxHeaderBytes:       65536,
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -306,6 +336,41 @@ func TestEntryPoints_Set(t *testing.T) {
 	}
 }
 
+func TestEntryPoints_SetInvalidRedirect(t *testing.T) {
+	testCases := []struct {
+		name          string
+		expression    string
+		expectedError string
+	}{
+		{
+			name:          "invalid status code",
+			expression:    "Name:foo Redirect.EntryPoint:https Redirect.StatusCode:foo",
+			expectedError: `invalid redirect status code "foo"`,
+		},
+		{
+			name:          "invalid port",
+			expression:    "Name:foo Redirect.EntryPoint:https Redirect.Port:foo",
+			expectedError: `invalid redirect port "foo"`,
+		},
+		{
+			name:          "invalid max header bytes",
+			expression:    "Name:foo MaxHeaderBytes:-1",
+			expectedError: `invalid max header bytes "-1"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			eps := EntryPoints{}
+			err := eps.Set(test.expression)
+			assert.EqualError(t, err, test.expectedError)
+		})
+	}
+}
+
 func TestSetEffectiveConfigurationGraceTimeout(t *testing.T) {
 	tests := []struct {
 		desc                  string
@@ -391,3 +456,120 @@ func TestSetEffectiveConfigurationFileProviderFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestGetProviderThrottleDuration(t *testing.T) {
+	gc := &GlobalConfiguration{
+		ProvidersThrottleDuration: flaeg.Duration(2 * time.Second),
+		File:                      &file.Provider{},
+		Kubernetes: &kubernetes.Provider{
+			BaseProvider: provider.BaseProvider{ThrottleDuration: flaeg.Duration(10 * time.Second)},
+		},
+		Etcd: &etcd.Provider{
+			Provider: kv.Provider{BaseProvider: provider.BaseProvider{ThrottleDuration: flaeg.Duration(time.Second)}},
+		},
+	}
+
+	tests := []struct {
+		desc             string
+		providerName     string
+		expectedDuration time.Duration
+	}{
+		{
+			desc:             "provider without throttle duration",
+			providerName:     "file",
+			expectedDuration: 2 * time.Second,
+		},
+		{
+			desc:             "provider with a throttle duration",
+			providerName:     "kubernetes",
+			expectedDuration: 10 * time.Second,
+		},
+		{
+			desc:             "key-value store provider with a throttle duration",
+			providerName:     "etcdv3",
+			expectedDuration: time.Second,
+		},
+		{
+			desc:             "provider not enabled",
+			providerName:     "docker",
+			expectedDuration: 2 * time.Second,
+		},
+		{
+			desc:             "unknown provider",
+			providerName:     "web",
+			expectedDuration: 2 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedDuration, gc.GetProviderThrottleDuration(test.providerName))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		gc             *GlobalConfiguration
+		expectedErrors []string
+	}{
+		{
+			desc: "valid configuration",
+			gc: &GlobalConfiguration{
+				EntryPoints:               EntryPoints{"https": {TLS: &tls.TLS{}}},
+				ACME:                      &acme.ACME{EntryPoint: "https"},
+				AmbiguousRoutes:           AmbiguousRoutesFail,
+				ClientClosedRequestStatus: http.StatusBadGateway,
+			},
+		},
+		{
+			desc: "all the errors",
+			gc: &GlobalConfiguration{
+				EntryPoints:               EntryPoints{"http": {}},
+				ACME:                      &acme.ACME{EntryPoint: "http"},
+				AmbiguousRoutes:           "unknown",
+				ClientClosedRequestStatus: http.StatusInternalServerError,
+			},
+			expectedErrors: []string{
+				`Unknown ambiguous routes behavior "unknown", must be "first" or "fail"`,
+				`Unsupported client closed request status 500, must be 499 or 502`,
+				`Entrypoint without TLS "http" for ACME configuration`,
+			},
+		},
+		{
+			desc: "unknown ACME entrypoint",
+			gc: &GlobalConfiguration{
+				ACME: &acme.ACME{EntryPoint: "https"},
+			},
+			expectedErrors: []string{`Unknown entrypoint "https" for ACME configuration`},
+		},
+		{
+			desc: "TLS on TCP entrypoint",
+			gc: &GlobalConfiguration{
+				EntryPoints: EntryPoints{
+					"tcp":  {TCP: &EntryPointTCP{Servers: []string{"127.0.0.1:443"}}},
+					"tcp2": {TCP: &EntryPointTCP{Servers: []string{"127.0.0.1:443"}}, TLS: &tls.TLS{}},
+				},
+			},
+			expectedErrors: []string{`TLS configuration on the TCP entrypoint "tcp2"`},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var errs []string
+			for _, err := range test.gc.Validate() {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expectedErrors, errs)
+		})
+	}
+}
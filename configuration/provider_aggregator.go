@@ -44,6 +44,9 @@ func NewProviderAggregator(gc *GlobalConfiguration) provider.Provider {
 	if gc.Boltdb != nil {
 		provider.providers = append(provider.providers, gc.Boltdb)
 	}
+	if gc.Redis != nil {
+		provider.providers = append(provider.providers, gc.Redis)
+	}
 	if gc.Kubernetes != nil {
 		provider.providers = append(provider.providers, gc.Kubernetes)
 	}
@@ -65,6 +68,9 @@ func NewProviderAggregator(gc *GlobalConfiguration) provider.Provider {
 	if gc.ServiceFabric != nil {
 		provider.providers = append(provider.providers, gc.ServiceFabric)
 	}
+	if gc.Vault != nil {
+		provider.providers = append(provider.providers, gc.Vault)
+	}
 	if len(provider.providers) == 1 {
 		return provider.providers[0]
 	}
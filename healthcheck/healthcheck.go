@@ -3,10 +3,14 @@ package healthcheck
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +20,9 @@ import (
 	"github.com/vulcand/oxy/roundrobin"
 )
 
+// maxBodySize is the size of the beginning of the response bodies checked against the expected body
+const maxBodySize = 1 << 20
+
 var singleton *HealthCheck
 var once sync.Once
 
@@ -28,12 +35,16 @@ func GetHealthCheck(metrics metricsRegistry) *HealthCheck {
 }
 
 // Options are the public health check options.
+// The body of the responses must contain ExpectedBody, and match ExpectedBodyRegex, when they are set.
 type Options struct {
-	Path      string
-	Port      int
-	Transport http.RoundTripper
-	Interval  time.Duration
-	LB        LoadBalancer
+	Path              string
+	Port              int
+	Headers           map[string]string
+	ExpectedBody      string
+	ExpectedBodyRegex *regexp.Regexp
+	Transport         http.RoundTripper
+	Interval          time.Duration
+	LB                LoadBalancer
 }
 
 func (opt Options) String() string {
@@ -150,17 +161,28 @@ func (hc *HealthCheck) checkBackend(backend *BackendHealthCheck) {
 }
 
 func (backend *BackendHealthCheck) newRequest(serverURL *url.URL) (*http.Request, error) {
-	if backend.Port == 0 {
-		return http.NewRequest(http.MethodGet, serverURL.String()+backend.Path, nil)
+	rawURL := serverURL.String() + backend.Path
+	if backend.Port != 0 {
+		// copy the url and add the port to the host
+		u := &url.URL{}
+		*u = *serverURL
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(backend.Port))
+		u.Path = u.Path + backend.Path
+		rawURL = u.String()
 	}
 
-	// copy the url and add the port to the host
-	u := &url.URL{}
-	*u = *serverURL
-	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(backend.Port))
-	u.Path = u.Path + backend.Path
-
-	return http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range backend.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	return req, nil
 }
 
 // checkHealth returns a nil error in case it was successful and otherwise
@@ -186,5 +208,24 @@ func checkHealth(serverURL *url.URL, backend *BackendHealthCheck) error {
 	case resp.StatusCode != http.StatusOK:
 		return fmt.Errorf("received non-200 status code: %v", resp.StatusCode)
 	}
+	return backend.checkBody(resp.Body)
+}
+
+// checkBody returns a non-nil error if the beginning of the response body doesn't contain or match the expected body
+func (backend *BackendHealthCheck) checkBody(body io.Reader) error {
+	if len(backend.ExpectedBody) == 0 && backend.ExpectedBodyRegex == nil {
+		return nil
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("failed to read the response body: %s", err)
+	}
+	if len(backend.ExpectedBody) > 0 && !strings.Contains(string(content), backend.ExpectedBody) {
+		return fmt.Errorf("response body doesn't contain %q", backend.ExpectedBody)
+	}
+	if backend.ExpectedBodyRegex != nil && !backend.ExpectedBodyRegex.Match(content) {
+		return fmt.Errorf("response body doesn't match %q", backend.ExpectedBodyRegex)
+	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"sync"
 	"testing"
 	"time"
@@ -270,3 +271,63 @@ func (th *testHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		th.done()
 	}
 }
+
+func TestCheckHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Host != "health.localhost" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"status": "up", "database": "down"}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse the server URL: %s", err)
+	}
+	headers := map[string]string{"Authorization": "Bearer token", "Host": "health.localhost"}
+
+	tests := []struct {
+		desc     string
+		options  Options
+		wantFail bool
+	}{
+		{
+			desc:    "headers",
+			options: Options{Headers: headers},
+		},
+		{
+			desc:     "missing headers",
+			options:  Options{},
+			wantFail: true,
+		},
+		{
+			desc:    "expected body",
+			options: Options{Headers: headers, ExpectedBody: `"status": "up"`, ExpectedBodyRegex: regexp.MustCompile(`"status": *"up"`)},
+		},
+		{
+			desc:     "body without the expected string",
+			options:  Options{Headers: headers, ExpectedBody: `"database": "up"`},
+			wantFail: true,
+		},
+		{
+			desc:     "body not matching the expected regex",
+			options:  Options{Headers: headers, ExpectedBodyRegex: regexp.MustCompile(`^\{"database"`)},
+			wantFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			err := checkHealth(serverURL, NewBackendHealthCheck(test.options, "backendName"))
+			if test.wantFail && err == nil {
+				t.Errorf("got a successful health check, want a failed one")
+			}
+			if !test.wantFail && err != nil {
+				t.Errorf("got failed health check: %s", err)
+			}
+		})
+	}
+}
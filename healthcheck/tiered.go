@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+// TieredLoadBalancer is a LoadBalancer which only sends the traffic to the available servers of the lowest tier.
+// Servers of a higher tier are added to the wrapped load-balancer only when all the servers of the lower tiers are removed.
+type TieredLoadBalancer struct {
+	lb      LoadBalancer
+	tiers   map[string]int
+	servers map[string]*tieredServer
+	lock    sync.Mutex
+}
+
+type tieredServer struct {
+	url     *url.URL
+	options []roundrobin.ServerOption
+}
+
+// NewTieredLoadBalancer creates a TieredLoadBalancer wrapping lb.
+// tiers gives the tier of each server, by URL. Servers without tier are in tier 0.
+func NewTieredLoadBalancer(lb LoadBalancer, tiers map[string]int) *TieredLoadBalancer {
+	return &TieredLoadBalancer{
+		lb:      lb,
+		tiers:   tiers,
+		servers: make(map[string]*tieredServer),
+	}
+}
+
+// RemoveServer removes a server from the available ones
+func (t *TieredLoadBalancer) RemoveServer(u *url.URL) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.servers, u.String())
+	return t.rebalance()
+}
+
+// UpsertServer adds a server to the available ones
+func (t *TieredLoadBalancer) UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.servers[u.String()] = &tieredServer{url: u, options: options}
+	return t.rebalance()
+}
+
+// Servers returns the available servers of all the tiers
+func (t *TieredLoadBalancer) Servers() []*url.URL {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var keys []string
+	for key := range t.servers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var servers []*url.URL
+	for _, key := range keys {
+		servers = append(servers, t.servers[key].url)
+	}
+	return servers
+}
+
+// rebalance makes the wrapped load-balancer use the available servers of the lowest tier only
+func (t *TieredLoadBalancer) rebalance() error {
+	var activeTier int
+	first := true
+	for key := range t.servers {
+		if tier := t.tiers[key]; first || tier < activeTier {
+			activeTier = tier
+			first = false
+		}
+	}
+
+	for _, u := range t.lb.Servers() {
+		if _, ok := t.servers[u.String()]; !ok || t.tiers[u.String()] != activeTier {
+			if err := t.lb.RemoveServer(u); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, server := range t.servers {
+		if t.tiers[key] == activeTier {
+			if err := t.lb.UpsertServer(server.url, server.options...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func TestTieredLoadBalancer(t *testing.T) {
+	tier1Healthy := []int32{1, 1}
+	var tier1Servers, tier2Servers []*httptest.Server
+	for i := range tier1Healthy {
+		healthy := &tier1Healthy[i]
+		tier1Servers = append(tier1Servers, httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if atomic.LoadInt32(healthy) == 1 {
+				rw.WriteHeader(http.StatusOK)
+			} else {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})))
+		tier2Servers = append(tier2Servers, httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		})))
+	}
+
+	tiers := make(map[string]int)
+	hostTiers := make(map[string]int)
+	for tier, servers := range map[int][]*httptest.Server{1: tier1Servers, 2: tier2Servers} {
+		for _, server := range servers {
+			defer server.Close()
+			tiers[server.URL] = tier
+			hostTiers[testhelpers.MustParseURL(server.URL).Host] = tier
+		}
+	}
+
+	// the next handler only records the tier of the server selected by the load-balancer
+	trafficPerTier := make(map[int]int)
+	rr, err := roundrobin.New(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		trafficPerTier[hostTiers[req.URL.Host]]++
+	}))
+	require.NoError(t, err)
+
+	lb := NewTieredLoadBalancer(rr, tiers)
+	for serverURL := range tiers {
+		require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL(serverURL), roundrobin.Weight(1)))
+	}
+	assert.Len(t, lb.Servers(), 4)
+
+	backend := NewBackendHealthCheck(Options{
+		Path:     "/health",
+		Interval: time.Second,
+		LB:       lb,
+	}, "backend")
+	check := HealthCheck{
+		Backends: make(map[string]*BackendHealthCheck),
+		metrics:  testhelpers.NewCollectingHealthCheckMetrics(),
+	}
+
+	sendRequests := func() map[int]int {
+		for k := range trafficPerTier {
+			delete(trafficPerTier, k)
+		}
+		for i := 0; i < 10; i++ {
+			rr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+		}
+		return trafficPerTier
+	}
+
+	assert.Equal(t, map[int]int{1: 10}, sendRequests(), "tier 1 servers are healthy")
+
+	atomic.StoreInt32(&tier1Healthy[0], 0)
+	check.checkBackend(backend)
+	assert.Equal(t, map[int]int{1: 10}, sendRequests(), "one tier 1 server is still healthy")
+
+	atomic.StoreInt32(&tier1Healthy[1], 0)
+	check.checkBackend(backend)
+	assert.Equal(t, map[int]int{2: 10}, sendRequests(), "all tier 1 servers are unhealthy")
+	assert.Len(t, lb.Servers(), 2)
+
+	atomic.StoreInt32(&tier1Healthy[0], 1)
+	check.checkBackend(backend)
+	assert.Equal(t, map[int]int{1: 10}, sendRequests(), "a tier 1 server is back")
+}
@@ -0,0 +1,250 @@
+package integration
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/abronan/valkeyrie/store"
+	"github.com/containous/traefik/integration/try"
+	"github.com/containous/traefik/provider/kv"
+	"github.com/containous/traefik/provider/redis"
+	traefikRedis "github.com/containous/traefik/redis"
+	"github.com/go-check/check"
+	checker "github.com/vdemeester/shakers"
+)
+
+const redisPassword = "traefik"
+
+// Redis test suites (using libcompose), checking the Redis client against a real server
+type RedisSuite struct {
+	BaseSuite
+	redisIP  string
+	whoamiIP string
+}
+
+func (s *RedisSuite) SetUpTest(c *check.C) {
+	s.createComposeProject(c, "redis")
+	s.composeProject.Start(c)
+
+	s.redisIP = s.composeProject.Container(c, "redis").NetworkSettings.IPAddress
+	s.whoamiIP = s.composeProject.Container(c, "whoami").NetworkSettings.IPAddress
+
+	// wait for redis
+	err := try.Do(60*time.Second, func() error {
+		conn, err := s.dial(traefikRedis.Options{Password: redisPassword})
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	})
+	c.Assert(err, checker.IsNil)
+}
+
+func (s *RedisSuite) TearDownTest(c *check.C) {
+	// shutdown and delete compose project
+	if s.composeProject != nil {
+		s.composeProject.Stop(c)
+	}
+}
+
+func (s *RedisSuite) TearDownSuite(c *check.C) {}
+
+func (s *RedisSuite) dial(options traefikRedis.Options) (*traefikRedis.Conn, error) {
+	options.ConnectionTimeout = 5 * time.Second
+	return traefikRedis.Dial([]string{s.redisIP + ":6379"}, options, 5*time.Second)
+}
+
+func (s *RedisSuite) TestAuthentication(c *check.C) {
+	_, err := s.dial(traefikRedis.Options{})
+	c.Assert(err, checker.NotNil)
+
+	_, err = s.dial(traefikRedis.Options{Password: "invalid"})
+	c.Assert(err, checker.NotNil)
+
+	_, err = s.dial(traefikRedis.Options{Username: "unknown", Password: redisPassword})
+	c.Assert(err, checker.NotNil)
+
+	conn, err := s.dial(traefikRedis.Options{Username: "default", Password: redisPassword})
+	c.Assert(err, checker.IsNil)
+	conn.Close()
+}
+
+func (s *RedisSuite) TestSelectDatabase(c *check.C) {
+	// the server has 16 databases by default
+	_, err := s.dial(traefikRedis.Options{Password: redisPassword, DB: 16})
+	c.Assert(err, checker.NotNil)
+
+	conn, err := s.dial(traefikRedis.Options{Password: redisPassword, DB: 1})
+	c.Assert(err, checker.IsNil)
+	defer conn.Close()
+
+	_, err = conn.Do("SET", "key", "db1")
+	c.Assert(err, checker.IsNil)
+
+	other, err := s.dial(traefikRedis.Options{Password: redisPassword})
+	c.Assert(err, checker.IsNil)
+	defer other.Close()
+
+	reply, err := other.Do("GET", "key")
+	c.Assert(err, checker.IsNil)
+	c.Assert(reply, checker.IsNil)
+}
+
+func (s *RedisSuite) TestReplies(c *check.C) {
+	conn, err := s.dial(traefikRedis.Options{Password: redisPassword})
+	c.Assert(err, checker.IsNil)
+	defer conn.Close()
+
+	replies, err := conn.Pipeline(
+		[]string{"SET", "foo", "bar\r\nbaz"},
+		[]string{"INCRBY", "counter", "3"},
+		[]string{"MGET", "foo", "missing"},
+		[]string{"INCR", "foo"},
+		[]string{"GET", "missing"},
+	)
+	c.Assert(err, checker.IsNil)
+	c.Assert(replies, checker.HasLen, 5)
+
+	c.Assert(replies[0], checker.Equals, "OK")
+	c.Assert(replies[1], checker.Equals, int64(3))
+	values, ok := replies[2].([]interface{})
+	c.Assert(ok, checker.True)
+	c.Assert(values, checker.HasLen, 2)
+	c.Assert(string(values[0].([]byte)), checker.Equals, "bar\r\nbaz")
+	c.Assert(values[1], checker.IsNil)
+	_, ok = replies[3].(traefikRedis.Error)
+	c.Assert(ok, checker.True)
+	c.Assert(replies[4], checker.IsNil)
+
+	// the error replies are returned as errors by Do
+	_, err = conn.Do("INCR", "foo")
+	_, ok = err.(traefikRedis.Error)
+	c.Assert(ok, checker.True)
+
+	// the connection is still usable after an error reply
+	reply, err := conn.Do("GET", "counter")
+	c.Assert(err, checker.IsNil)
+	c.Assert(string(reply.([]byte)), checker.Equals, "3")
+}
+
+func (s *RedisSuite) TestStore(c *check.C) {
+	provider := &redis.Provider{Provider: kv.Provider{Endpoint: s.redisIP + ":6379", Password: redisPassword}}
+	kvStore, err := provider.CreateStore()
+	c.Assert(err, checker.IsNil)
+	defer kvStore.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	events, err := kvStore.WatchTree("traefik/backends/", stopCh, nil)
+	c.Assert(err, checker.IsNil)
+
+	// the current keys are sent at once
+	select {
+	case pairs := <-events:
+		c.Assert(pairs, checker.HasLen, 0)
+	case <-time.After(5 * time.Second):
+		c.Fatal("no event received from the watch")
+	}
+
+	err = kvStore.Put("traefik/backends/backend1/servers/server1/url", []byte("http://"+s.whoamiIP), nil)
+	c.Assert(err, checker.IsNil)
+	err = kvStore.Put("traefik/backends/backend1/servers/server1/weight", []byte("1"), nil)
+	c.Assert(err, checker.IsNil)
+
+	// the keyspace notifications are enabled on the server
+	err = try.Do(5*time.Second, func() error {
+		select {
+		case pairs := <-events:
+			if len(pairs) != 2 {
+				return store.ErrKeyNotFound
+			}
+			return nil
+		case <-time.After(time.Second):
+			return store.ErrKeyNotFound
+		}
+	})
+	c.Assert(err, checker.IsNil)
+
+	pairs, err := kvStore.List("traefik/backends/backend1/", nil)
+	c.Assert(err, checker.IsNil)
+	c.Assert(pairs, checker.HasLen, 2)
+
+	pair, err := kvStore.Get("traefik/backends/backend1/servers/server1/weight", nil)
+	c.Assert(err, checker.IsNil)
+	c.Assert(string(pair.Value), checker.Equals, "1")
+
+	err = kvStore.DeleteTree("traefik/backends/")
+	c.Assert(err, checker.IsNil)
+
+	exists, err := kvStore.Exists("traefik/backends/backend1/servers/server1/url", nil)
+	c.Assert(err, checker.IsNil)
+	c.Assert(exists, checker.False)
+}
+
+func (s *RedisSuite) TestProviderConfiguration(c *check.C) {
+	file := s.adaptFile(c, "fixtures/redis/simple.toml", struct{ RedisHost string }{s.redisIP})
+	defer os.Remove(file)
+
+	cmd, display := s.traefikCmd(withConfigFile(file))
+	defer display(c)
+	err := cmd.Start()
+	c.Assert(err, checker.IsNil)
+	defer cmd.Process.Kill()
+
+	// Expected a 404 as we did not configure anything
+	err = try.GetRequest("http://127.0.0.1:8000/", 10*time.Second, try.StatusCodeIs(http.StatusNotFound))
+	c.Assert(err, checker.IsNil)
+
+	conn, err := s.dial(traefikRedis.Options{Password: redisPassword})
+	c.Assert(err, checker.IsNil)
+	defer conn.Close()
+
+	_, err = conn.Pipeline(
+		[]string{"SET", "traefik/backends/backend1/servers/server1/url", "http://" + s.whoamiIP + ":80"},
+		[]string{"SET", "traefik/frontends/frontend1/backend", "backend1"},
+		[]string{"SET", "traefik/frontends/frontend1/routes/test_1/rule", "Path:/whoami"},
+	)
+	c.Assert(err, checker.IsNil)
+
+	// the configuration is reloaded on the keyspace notifications
+	err = try.GetRequest("http://127.0.0.1:8000/whoami", 10*time.Second, try.StatusCodeIs(http.StatusOK), try.BodyContains("Hostname"))
+	c.Assert(err, checker.IsNil)
+}
+
+func (s *RedisSuite) TestRateLimitStore(c *check.C) {
+	file := s.adaptFile(c, "fixtures/redis/ratelimit.toml", struct {
+		RedisHost  string
+		WhoamiHost string
+	}{s.redisIP, s.whoamiIP})
+	defer os.Remove(file)
+
+	cmd, display := s.traefikCmd(withConfigFile(file))
+	defer display(c)
+	err := cmd.Start()
+	c.Assert(err, checker.IsNil)
+	defer cmd.Process.Kill()
+
+	err = try.GetRequest("http://127.0.0.1:8000/", 10*time.Second, try.StatusCodeIs(http.StatusOK))
+	c.Assert(err, checker.IsNil)
+	err = try.GetRequest("http://127.0.0.1:8000/", 500*time.Millisecond, try.StatusCodeIs(http.StatusOK))
+	c.Assert(err, checker.IsNil)
+	err = try.GetRequest("http://127.0.0.1:8000/", 500*time.Millisecond, try.StatusCodeIs(http.StatusTooManyRequests))
+	c.Assert(err, checker.IsNil)
+
+	// the counters are stored in the configured database
+	conn, err := s.dial(traefikRedis.Options{Password: redisPassword, DB: 1})
+	c.Assert(err, checker.IsNil)
+	defer conn.Close()
+
+	reply, err := conn.Do("KEYS", "traefik:ratelimit:frontend1:*")
+	c.Assert(err, checker.IsNil)
+	keys, err := traefikRedis.ReplyStrings(reply)
+	c.Assert(err, checker.IsNil)
+	c.Assert(keys, checker.HasLen, 1)
+
+	reply, err = conn.Do("GET", keys[0])
+	c.Assert(err, checker.IsNil)
+	c.Assert(string(reply.([]byte)), checker.Equals, "2")
+}
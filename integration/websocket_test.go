@@ -558,3 +558,57 @@ func (s *WebsocketSuite) TestHeaderAreForwared(c *check.C) {
 	c.Assert(err, checker.IsNil)
 	c.Assert(string(msg), checker.Equals, "OK")
 }
+
+func (s *WebsocketSuite) TestSubprotocol(c *check.C) {
+	var upgrader = gorillawebsocket.Upgrader{Subprotocols: []string{"chat"}}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(gorillawebsocket.Subprotocols(r), checker.DeepEquals, []string{"superchat", "chat"})
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			mt, message, err := c.ReadMessage()
+			if err != nil {
+				break
+			}
+			err = c.WriteMessage(mt, message)
+			if err != nil {
+				break
+			}
+		}
+	}))
+
+	file := s.adaptFile(c, "fixtures/websocket/config.toml", struct {
+		WebsocketServer string
+	}{
+		WebsocketServer: srv.URL,
+	})
+
+	defer os.Remove(file)
+	cmd, display := s.traefikCmd(withConfigFile(file), "--debug")
+	defer display(c)
+
+	err := cmd.Start()
+	c.Assert(err, check.IsNil)
+	defer cmd.Process.Kill()
+
+	// wait for traefik
+	err = try.GetRequest("http://127.0.0.1:8080/api/providers", 10*time.Second, try.BodyContains("127.0.0.1"))
+	c.Assert(err, checker.IsNil)
+
+	dialer := gorillawebsocket.Dialer{Subprotocols: []string{"superchat", "chat"}}
+	conn, resp, err := dialer.Dial("ws://127.0.0.1:8000/ws", nil)
+	c.Assert(err, checker.IsNil)
+	c.Assert(resp.Header.Get("Sec-WebSocket-Protocol"), checker.Equals, "chat")
+	c.Assert(conn.Subprotocol(), checker.Equals, "chat")
+
+	err = conn.WriteMessage(gorillawebsocket.TextMessage, []byte("OK"))
+	c.Assert(err, checker.IsNil)
+
+	_, msg, err := conn.ReadMessage()
+	c.Assert(err, checker.IsNil)
+	c.Assert(string(msg), checker.Equals, "OK")
+}
@@ -198,14 +198,14 @@ func Fatalln(args ...interface{}) {
 // OpenFile opens the log file using the specified path
 func OpenFile(path string) error {
 	logFilePath = path
-	var err error
-	logFile, err = os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-
-	if err == nil {
-		SetOutput(logFile)
+	file, err := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
 	}
 
-	return err
+	logFile = file
+	SetOutput(logFile)
+	return nil
 }
 
 // CloseFile closes the log and sets the Output to stdout
@@ -221,22 +221,23 @@ func CloseFile() error {
 // RotateFile closes and reopens the log file to allow for rotation
 // by an external source.  If the log isn't backed by a file then
 // it does nothing.
+// The new file is opened before the previous one is closed, so that no log is lost,
+// and the previous file is kept if the new one can't be opened.
 func RotateFile() error {
 	if logFile == nil && logFilePath == "" {
 		Debug("Traefik log is not writing to a file, ignoring rotate request")
 		return nil
 	}
 
-	if logFile != nil {
-		defer func(f *os.File) {
-			f.Close()
-		}(logFile)
-	}
-
+	previous := logFile
 	if err := OpenFile(logFilePath); err != nil {
 		return fmt.Errorf("error opening log file: %s", err)
 	}
 
+	// the output of the logger is swapped with its lock held, so the previous file is not written anymore
+	if previous != nil {
+		previous.Close()
+	}
 	return nil
 }
 
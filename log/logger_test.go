@@ -3,6 +3,7 @@ package log
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -60,6 +61,40 @@ func TestLogRotation(t *testing.T) {
 	close(writeDone)
 }
 
+func TestLogRotationFailure(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "traefik_")
+	if err != nil {
+		t.Fatalf("Error setting up temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fileName := filepath.Join(tempDir, "traefik.log")
+	if err := OpenFile(fileName); err != nil {
+		t.Fatalf("Error opening temporary file %s: %s", fileName, err)
+	}
+	defer CloseFile()
+
+	Println("Test log line")
+
+	// the log file can't be reopened, as its path is now a directory
+	rotatedFileName := fileName + ".rotated"
+	if err := os.Rename(fileName, rotatedFileName); err != nil {
+		t.Fatalf("Error renaming file: %s", err)
+	}
+	if err := os.Mkdir(fileName, 0755); err != nil {
+		t.Fatalf("Error creating directory: %s", err)
+	}
+	if err := RotateFile(); err == nil {
+		t.Fatal("Expected an error rotating the file")
+	}
+
+	// the previous file is kept
+	Println("Test log line")
+	if gotLineCount := lineCount(t, rotatedFileName); gotLineCount != 2 {
+		t.Errorf("Wanted 2 written log lines, got %d", gotLineCount)
+	}
+}
+
 func lineCount(t *testing.T, fileName string) int {
 	t.Helper()
 	fileContents, err := ioutil.ReadFile(fileName)
@@ -1,7 +1,8 @@
 package metrics
 
 import (
-	"bytes"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/containous/traefik/log"
@@ -12,38 +13,172 @@ import (
 	influxdb "github.com/influxdata/influxdb/client/v2"
 )
 
-var influxDBClient = influx.New(map[string]string{}, influxdb.BatchPointsConfig{}, kitlog.LoggerFunc(func(keyvals ...interface{}) error {
-	log.Info(keyvals)
-	return nil
-}))
+var influxDBClient *influxDBClients
 
 type influxDBWriter struct {
-	buf    bytes.Buffer
 	config *types.InfluxDB
 }
 
 var influxDBTicker *time.Ticker
 
 const (
-	influxDBMetricsReqsName    = "traefik.requests.total"
-	influxDBMetricsLatencyName = "traefik.request.duration"
-	influxDBRetriesTotalName   = "traefik.backend.retries.total"
+	// InfluxDB protocols
+	influxDBProtocolUDP  = "udp"
+	influxDBProtocolHTTP = "http"
+
+	// Metric names mirror the Prometheus ones, using "." as separator
+	influxDBConfigReloadsName           = "traefik.config.reloads.total"
+	influxDBConfigReloadsFailureName    = "traefik.config.reloads.failure.total"
+	influxDBLastConfigReloadSuccessName = "traefik.config.last.reload.success"
+	influxDBLastConfigReloadFailureName = "traefik.config.last.reload.failure"
+
+	influxDBEntrypointReqsName            = "traefik.entrypoint.requests.total"
+	influxDBEntrypointReqDurationName     = "traefik.entrypoint.request.duration"
+	influxDBEntrypointOpenConnsName       = "traefik.entrypoint.open.connections"
+	influxDBEntrypointRapidResetConnsName = "traefik.entrypoint.rapid.reset.connections.total"
+	influxDBEntrypointTLSHandshakesName   = "traefik.entrypoint.tls.handshakes.total"
+
+	influxDBMetricsBackendReqsName        = "traefik.backend.requests.total"
+	influxDBMetricsBackendLatencyName     = "traefik.backend.request.duration"
+	influxDBBackendRespHeaderDurationName = "traefik.backend.response.header.duration"
+	influxDBBackendOpenConnsName          = "traefik.backend.open.connections"
+	influxDBRetriesTotalName              = "traefik.backend.retries.total"
+	influxDBBackendServerUpName           = "traefik.backend.server.up"
+	influxDBBackendWebSocketConnsName     = "traefik.backend.websocket.connections"
+	influxDBBackendCircuitBreakerOpenName = "traefik.backend.circuit.breaker.open"
+	influxDBBackendEjectedServersName     = "traefik.backend.ejected.servers"
+	influxDBBackendRetryBudgetName        = "traefik.backend.retry.budget"
 )
 
 // RegisterInfluxDB registers the metrics pusher if this didn't happen yet and creates a InfluxDB Registry instance.
 func RegisterInfluxDB(config *types.InfluxDB) Registry {
 	if influxDBTicker == nil {
+		influxDBClient = newInfluxDBClient(config)
 		influxDBTicker = initInfluxDBTicker(config)
 	}
 
 	return &standardRegistry{
-		enabled:                     true,
-		backendReqsCounter:          influxDBClient.NewCounter(influxDBMetricsReqsName),
-		backendReqDurationHistogram: influxDBClient.NewHistogram(influxDBMetricsLatencyName),
-		backendRetriesCounter:       influxDBClient.NewCounter(influxDBRetriesTotalName),
+		enabled:                            true,
+		configReloadsCounter:               influxDBClient.NewCounter(influxDBConfigReloadsName),
+		configReloadsFailureCounter:        influxDBClient.NewCounter(influxDBConfigReloadsFailureName),
+		lastConfigReloadSuccessGauge:       influxDBClient.NewGauge(influxDBLastConfigReloadSuccessName),
+		lastConfigReloadFailureGauge:       influxDBClient.NewGauge(influxDBLastConfigReloadFailureName),
+		entrypointReqsCounter:              influxDBClient.NewCounter(influxDBEntrypointReqsName),
+		entrypointReqDurationHistogram:     influxDBClient.NewHistogram(influxDBEntrypointReqDurationName),
+		entrypointOpenConnsGauge:           influxDBClient.NewGauge(influxDBEntrypointOpenConnsName),
+		entrypointRapidResetConnsCounter:   influxDBClient.NewCounter(influxDBEntrypointRapidResetConnsName),
+		entrypointTLSHandshakesCounter:     influxDBClient.NewCounter(influxDBEntrypointTLSHandshakesName),
+		backendReqsCounter:                 influxDBClient.NewCounter(influxDBMetricsBackendReqsName),
+		backendReqDurationHistogram:        influxDBClient.NewHistogram(influxDBMetricsBackendLatencyName),
+		backendRespHeaderDurationHistogram: influxDBClient.NewHistogram(influxDBBackendRespHeaderDurationName),
+		backendOpenConnsGauge:              influxDBClient.NewGauge(influxDBBackendOpenConnsName),
+		backendRetriesCounter:              influxDBClient.NewCounter(influxDBRetriesTotalName),
+		backendServerUpGauge:               influxDBClient.NewGauge(influxDBBackendServerUpName),
+		backendWebSocketConnsGauge:         influxDBClient.NewGauge(influxDBBackendWebSocketConnsName),
+		backendCircuitBreakerOpenGauge:     influxDBClient.NewGauge(influxDBBackendCircuitBreakerOpenName),
+		backendEjectedServersGauge:         influxDBClient.NewGauge(influxDBBackendEjectedServersName),
+		backendRetryBudgetGauge:            influxDBClient.NewGauge(influxDBBackendRetryBudgetName),
+	}
+}
+
+// influxDBClients holds a go-kit InfluxDB client per metric, each of them adding the configured tags to the points of its metric.
+// go-kit merges the label values of the points into the tags of their client, so that a client shared by several metrics
+// would leak the labels of a metric into the points of the next ones.
+type influxDBClients struct {
+	lock    sync.Mutex
+	tags    map[string]string
+	config  influxdb.BatchPointsConfig
+	clients map[string]*influx.Influx
+}
+
+// newInfluxDBClient creates the InfluxDB clients adding the configured tags to the metrics.
+// The database and retention policy are only sent with the HTTP protocol, the UDP listener of InfluxDB having its own.
+func newInfluxDBClient(config *types.InfluxDB) *influxDBClients {
+	var batchPointsConfig influxdb.BatchPointsConfig
+	if config.Protocol == influxDBProtocolHTTP {
+		batchPointsConfig.Database = config.Database
+		batchPointsConfig.RetentionPolicy = config.RetentionPolicy
+	}
+
+	return &influxDBClients{
+		tags:    config.Tags,
+		config:  batchPointsConfig,
+		clients: make(map[string]*influx.Influx),
+	}
+}
+
+// client returns the client of a metric, creating it with its own copy of the configured tags.
+func (c *influxDBClients) client(name string) *influx.Influx {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if client, ok := c.clients[name]; ok {
+		return client
+	}
+
+	tags := make(map[string]string, len(c.tags))
+	for tagName, value := range c.tags {
+		tags[tagName] = value
+	}
+
+	client := influx.New(tags, c.config, kitlog.LoggerFunc(func(keyvals ...interface{}) error {
+		log.Info(keyvals)
+		return nil
+	}))
+	c.clients[name] = client
+	return client
+}
+
+func (c *influxDBClients) NewCounter(name string) *influx.Counter {
+	return c.client(name).NewCounter(name)
+}
+
+func (c *influxDBClients) NewGauge(name string) *influx.Gauge {
+	return c.client(name).NewGauge(name)
+}
+
+func (c *influxDBClients) NewHistogram(name string) *influx.Histogram {
+	return c.client(name).NewHistogram(name)
+}
+
+// WriteLoop writes the points of all the metrics in a single batch, every time the channel receives.
+func (c *influxDBClients) WriteLoop(ch <-chan time.Time, w influx.BatchPointsWriter) {
+	for range ch {
+		if err := c.WriteTo(w); err != nil {
+			log.Infof("Error writing the metrics to InfluxDB: %v", err)
+		}
 	}
 }
 
+// WriteTo flushes the points of all the metrics to the writer, in a single batch.
+func (c *influxDBClients) WriteTo(w influx.BatchPointsWriter) error {
+	bp, err := influxdb.NewBatchPoints(c.config)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	for _, client := range c.clients {
+		if err := client.WriteTo(influxDBBatch{bp}); err != nil {
+			c.lock.Unlock()
+			return err
+		}
+	}
+	c.lock.Unlock()
+
+	return w.Write(bp)
+}
+
+// influxDBBatch is a writer adding the points of a client to a batch.
+type influxDBBatch struct {
+	influxdb.BatchPoints
+}
+
+func (b influxDBBatch) Write(bp influxdb.BatchPoints) error {
+	b.AddPoints(bp.Points())
+	return nil
+}
+
 // initInfluxDBTicker initializes metrics pusher and creates a influxDBClient if not created already
 func initInfluxDBTicker(config *types.InfluxDB) *time.Ticker {
 	pushInterval, err := time.ParseDuration(config.PushInterval)
@@ -54,9 +189,10 @@ func initInfluxDBTicker(config *types.InfluxDB) *time.Ticker {
 
 	report := time.NewTicker(pushInterval)
 
+	// the client is replaced when the metrics are registered again after being stopped
+	client := influxDBClient
 	safe.Go(func() {
-		var buf bytes.Buffer
-		influxDBClient.WriteLoop(report.C, &influxDBWriter{buf: buf, config: config})
+		client.WriteLoop(report.C, &influxDBWriter{config: config})
 	})
 
 	return report
@@ -71,10 +207,7 @@ func StopInfluxDB() {
 }
 
 func (w *influxDBWriter) Write(bp influxdb.BatchPoints) error {
-	c, err := influxdb.NewUDPClient(influxdb.UDPConfig{
-		Addr: w.config.Address,
-	})
-
+	c, err := w.newClient()
 	if err != nil {
 		return err
 	}
@@ -83,3 +216,22 @@ func (w *influxDBWriter) Write(bp influxdb.BatchPoints) error {
 
 	return c.Write(bp)
 }
+
+// newClient creates a client sending the metrics over UDP, or over HTTP to the /write endpoint of InfluxDB
+func (w *influxDBWriter) newClient() (influxdb.Client, error) {
+	switch w.config.Protocol {
+	case "", influxDBProtocolUDP:
+		return influxdb.NewUDPClient(influxdb.UDPConfig{
+			Addr: w.config.Address,
+		})
+	case influxDBProtocolHTTP:
+		return influxdb.NewHTTPClient(influxdb.HTTPConfig{
+			Addr:     w.config.Address,
+			Username: w.config.Username,
+			Password: w.config.Password,
+			Timeout:  10 * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported InfluxDB protocol %q", w.config.Protocol)
+	}
+}
@@ -1,13 +1,16 @@
 package metrics
 
 import (
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stvp/go-udp-testing"
 )
 
@@ -24,10 +27,14 @@ func TestInfluxDB(t *testing.T) {
 	}
 
 	expected := []string{
-		`(traefik\.requests\.total,code=200,method=GET,service=test count=1) [\d]{19}`,
-		`(traefik\.requests\.total,code=404,method=GET,service=test count=1) [\d]{19}`,
-		`(traefik\.request\.duration,code=200,method=GET,service=test p50=10000,p90=10000,p95=10000,p99=10000) [\d]{19}`,
-		`(traefik\.backend\.retries\.total(?:,code=[\d]{3},method=GET)?,service=test count=2) [\d]{19}`,
+		`(traefik\.backend\.requests\.total,code=200,method=GET,service=test count=1) [\d]{19}`,
+		`(traefik\.backend\.requests\.total,code=404,method=GET,service=test count=1) [\d]{19}`,
+		`(traefik\.backend\.request\.duration,code=200,method=GET,service=test p50=10000,p90=10000,p95=10000,p99=10000) [\d]{19}`,
+		`(traefik\.backend\.retries\.total,service=test count=2) [\d]{19}`,
+		`(traefik\.backend\.server\.up,backend=test,url=http://127\.0\.0\.1 value=1) [\d]{19}`,
+		`(traefik\.config\.reloads\.total count=1) [\d]{19}`,
+		`(traefik\.entrypoint\.requests\.total,code=200,entrypoint=http,method=GET count=1) [\d]{19}`,
+		`(traefik\.entrypoint\.open\.connections,entrypoint=http value=2) [\d]{19}`,
 	}
 
 	msg := udp.ReceiveString(t, func() {
@@ -35,12 +42,72 @@ func TestInfluxDB(t *testing.T) {
 		influxDBRegistry.BackendReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusNotFound), "method", http.MethodGet).Add(1)
 		influxDBRegistry.BackendRetriesCounter().With("service", "test").Add(1)
 		influxDBRegistry.BackendRetriesCounter().With("service", "test").Add(1)
-		influxDBRegistry.BackendReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
+		influxDBRegistry.BackendReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Observe(10000)
+		influxDBRegistry.BackendServerUpGauge().With("backend", "test", "url", "http://127.0.0.1").Set(1)
+		influxDBRegistry.ConfigReloadsCounter().Add(1)
+		influxDBRegistry.EntrypointReqsCounter().With("entrypoint", "http", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
+		influxDBRegistry.EntrypointOpenConnsGauge().With("entrypoint", "http").Set(2)
 	})
 
 	assertMessage(t, msg, expected)
 }
 
+func TestInfluxDBHTTP(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests <- req
+		bodies <- string(body)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	influxDBRegistry := RegisterInfluxDB(&types.InfluxDB{
+		Address:         server.URL,
+		Protocol:        "http",
+		PushInterval:    "100ms",
+		Database:        "metrics",
+		RetentionPolicy: "week",
+		Username:        "user",
+		Password:        "secret",
+		Tags:            map[string]string{"instance": "traefik1"},
+	})
+	defer StopInfluxDB()
+
+	influxDBRegistry.BackendReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
+	influxDBRegistry.EntrypointOpenConnsGauge().With("entrypoint", "http").Set(2)
+	influxDBRegistry.ConfigReloadsCounter().Add(1)
+
+	var req *http.Request
+	var body string
+	select {
+	case req = <-requests:
+		body = <-bodies
+	case <-time.After(5 * time.Second):
+		t.Fatal("no metrics received")
+	}
+
+	assert.Equal(t, "/write", req.URL.Path)
+	assert.Equal(t, "metrics", req.URL.Query().Get("db"))
+	assert.Equal(t, "week", req.URL.Query().Get("rp"))
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	assertMessage(t, body, []string{
+		`(traefik\.backend\.requests\.total,code=200,instance=traefik1,method=GET,service=test count=1) [\d]{19}`,
+		// the labels of a metric don't leak into the tags of the others
+		`(traefik\.entrypoint\.open\.connections,entrypoint=http,instance=traefik1 value=2) [\d]{19}`,
+		`(traefik\.config\.reloads\.total,instance=traefik1 count=1) [\d]{19}`,
+	})
+}
+
 func assertMessage(t *testing.T, msg string, patterns []string) {
 	t.Helper()
 	for _, pattern := range patterns {
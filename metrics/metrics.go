@@ -19,13 +19,20 @@ type Registry interface {
 	EntrypointReqsCounter() metrics.Counter
 	EntrypointReqDurationHistogram() metrics.Histogram
 	EntrypointOpenConnsGauge() metrics.Gauge
+	EntrypointRapidResetConnsCounter() metrics.Counter
+	EntrypointTLSHandshakesCounter() metrics.Counter
 
 	// backend metrics
 	BackendReqsCounter() metrics.Counter
 	BackendReqDurationHistogram() metrics.Histogram
+	BackendRespHeaderDurationHistogram() metrics.Histogram
 	BackendOpenConnsGauge() metrics.Gauge
 	BackendRetriesCounter() metrics.Counter
 	BackendServerUpGauge() metrics.Gauge
+	BackendWebSocketConnsGauge() metrics.Gauge
+	BackendCircuitBreakerOpenGauge() metrics.Gauge
+	BackendEjectedServersGauge() metrics.Gauge
+	BackendRetryBudgetGauge() metrics.Gauge
 }
 
 // NewVoidRegistry is a noop implementation of metrics.Registry.
@@ -45,11 +52,18 @@ func NewMultiRegistry(registries []Registry) Registry {
 	entrypointReqsCounter := []metrics.Counter{}
 	entrypointReqDurationHistogram := []metrics.Histogram{}
 	entrypointOpenConnsGauge := []metrics.Gauge{}
+	entrypointRapidResetConnsCounter := []metrics.Counter{}
+	entrypointTLSHandshakesCounter := []metrics.Counter{}
 	backendReqsCounter := []metrics.Counter{}
 	backendReqDurationHistogram := []metrics.Histogram{}
+	backendRespHeaderDurationHistogram := []metrics.Histogram{}
 	backendOpenConnsGauge := []metrics.Gauge{}
 	backendRetriesCounter := []metrics.Counter{}
 	backendServerUpGauge := []metrics.Gauge{}
+	backendWebSocketConnsGauge := []metrics.Gauge{}
+	backendCircuitBreakerOpenGauge := []metrics.Gauge{}
+	backendEjectedServersGauge := []metrics.Gauge{}
+	backendRetryBudgetGauge := []metrics.Gauge{}
 
 	for _, r := range registries {
 		if r.ConfigReloadsCounter() != nil {
@@ -73,12 +87,21 @@ func NewMultiRegistry(registries []Registry) Registry {
 		if r.EntrypointOpenConnsGauge() != nil {
 			entrypointOpenConnsGauge = append(entrypointOpenConnsGauge, r.EntrypointOpenConnsGauge())
 		}
+		if r.EntrypointRapidResetConnsCounter() != nil {
+			entrypointRapidResetConnsCounter = append(entrypointRapidResetConnsCounter, r.EntrypointRapidResetConnsCounter())
+		}
+		if r.EntrypointTLSHandshakesCounter() != nil {
+			entrypointTLSHandshakesCounter = append(entrypointTLSHandshakesCounter, r.EntrypointTLSHandshakesCounter())
+		}
 		if r.BackendReqsCounter() != nil {
 			backendReqsCounter = append(backendReqsCounter, r.BackendReqsCounter())
 		}
 		if r.BackendReqDurationHistogram() != nil {
 			backendReqDurationHistogram = append(backendReqDurationHistogram, r.BackendReqDurationHistogram())
 		}
+		if r.BackendRespHeaderDurationHistogram() != nil {
+			backendRespHeaderDurationHistogram = append(backendRespHeaderDurationHistogram, r.BackendRespHeaderDurationHistogram())
+		}
 		if r.BackendOpenConnsGauge() != nil {
 			backendOpenConnsGauge = append(backendOpenConnsGauge, r.BackendOpenConnsGauge())
 		}
@@ -88,39 +111,65 @@ func NewMultiRegistry(registries []Registry) Registry {
 		if r.BackendServerUpGauge() != nil {
 			backendServerUpGauge = append(backendServerUpGauge, r.BackendServerUpGauge())
 		}
+		if r.BackendWebSocketConnsGauge() != nil {
+			backendWebSocketConnsGauge = append(backendWebSocketConnsGauge, r.BackendWebSocketConnsGauge())
+		}
+		if r.BackendCircuitBreakerOpenGauge() != nil {
+			backendCircuitBreakerOpenGauge = append(backendCircuitBreakerOpenGauge, r.BackendCircuitBreakerOpenGauge())
+		}
+		if r.BackendEjectedServersGauge() != nil {
+			backendEjectedServersGauge = append(backendEjectedServersGauge, r.BackendEjectedServersGauge())
+		}
+		if r.BackendRetryBudgetGauge() != nil {
+			backendRetryBudgetGauge = append(backendRetryBudgetGauge, r.BackendRetryBudgetGauge())
+		}
 	}
 
 	return &standardRegistry{
-		enabled:                        len(registries) > 0,
-		configReloadsCounter:           multi.NewCounter(configReloadsCounter...),
-		configReloadsFailureCounter:    multi.NewCounter(configReloadsFailureCounter...),
-		lastConfigReloadSuccessGauge:   multi.NewGauge(lastConfigReloadSuccessGauge...),
-		lastConfigReloadFailureGauge:   multi.NewGauge(lastConfigReloadFailureGauge...),
-		entrypointReqsCounter:          multi.NewCounter(entrypointReqsCounter...),
-		entrypointReqDurationHistogram: multi.NewHistogram(entrypointReqDurationHistogram...),
-		entrypointOpenConnsGauge:       multi.NewGauge(entrypointOpenConnsGauge...),
-		backendReqsCounter:             multi.NewCounter(backendReqsCounter...),
-		backendReqDurationHistogram:    multi.NewHistogram(backendReqDurationHistogram...),
-		backendOpenConnsGauge:          multi.NewGauge(backendOpenConnsGauge...),
-		backendRetriesCounter:          multi.NewCounter(backendRetriesCounter...),
-		backendServerUpGauge:           multi.NewGauge(backendServerUpGauge...),
+		enabled:                            len(registries) > 0,
+		configReloadsCounter:               multi.NewCounter(configReloadsCounter...),
+		configReloadsFailureCounter:        multi.NewCounter(configReloadsFailureCounter...),
+		lastConfigReloadSuccessGauge:       multi.NewGauge(lastConfigReloadSuccessGauge...),
+		lastConfigReloadFailureGauge:       multi.NewGauge(lastConfigReloadFailureGauge...),
+		entrypointReqsCounter:              multi.NewCounter(entrypointReqsCounter...),
+		entrypointReqDurationHistogram:     multi.NewHistogram(entrypointReqDurationHistogram...),
+		entrypointOpenConnsGauge:           multi.NewGauge(entrypointOpenConnsGauge...),
+		entrypointRapidResetConnsCounter:   multi.NewCounter(entrypointRapidResetConnsCounter...),
+		entrypointTLSHandshakesCounter:     multi.NewCounter(entrypointTLSHandshakesCounter...),
+		backendReqsCounter:                 multi.NewCounter(backendReqsCounter...),
+		backendReqDurationHistogram:        multi.NewHistogram(backendReqDurationHistogram...),
+		backendRespHeaderDurationHistogram: multi.NewHistogram(backendRespHeaderDurationHistogram...),
+		backendOpenConnsGauge:              multi.NewGauge(backendOpenConnsGauge...),
+		backendRetriesCounter:              multi.NewCounter(backendRetriesCounter...),
+		backendServerUpGauge:               multi.NewGauge(backendServerUpGauge...),
+		backendWebSocketConnsGauge:         multi.NewGauge(backendWebSocketConnsGauge...),
+		backendCircuitBreakerOpenGauge:     multi.NewGauge(backendCircuitBreakerOpenGauge...),
+		backendEjectedServersGauge:         multi.NewGauge(backendEjectedServersGauge...),
+		backendRetryBudgetGauge:            multi.NewGauge(backendRetryBudgetGauge...),
 	}
 }
 
 type standardRegistry struct {
-	enabled                        bool
-	configReloadsCounter           metrics.Counter
-	configReloadsFailureCounter    metrics.Counter
-	lastConfigReloadSuccessGauge   metrics.Gauge
-	lastConfigReloadFailureGauge   metrics.Gauge
-	entrypointReqsCounter          metrics.Counter
-	entrypointReqDurationHistogram metrics.Histogram
-	entrypointOpenConnsGauge       metrics.Gauge
-	backendReqsCounter             metrics.Counter
-	backendReqDurationHistogram    metrics.Histogram
-	backendOpenConnsGauge          metrics.Gauge
-	backendRetriesCounter          metrics.Counter
-	backendServerUpGauge           metrics.Gauge
+	enabled                            bool
+	configReloadsCounter               metrics.Counter
+	configReloadsFailureCounter        metrics.Counter
+	lastConfigReloadSuccessGauge       metrics.Gauge
+	lastConfigReloadFailureGauge       metrics.Gauge
+	entrypointReqsCounter              metrics.Counter
+	entrypointReqDurationHistogram     metrics.Histogram
+	entrypointOpenConnsGauge           metrics.Gauge
+	entrypointRapidResetConnsCounter   metrics.Counter
+	entrypointTLSHandshakesCounter     metrics.Counter
+	backendReqsCounter                 metrics.Counter
+	backendReqDurationHistogram        metrics.Histogram
+	backendRespHeaderDurationHistogram metrics.Histogram
+	backendOpenConnsGauge              metrics.Gauge
+	backendRetriesCounter              metrics.Counter
+	backendServerUpGauge               metrics.Gauge
+	backendWebSocketConnsGauge         metrics.Gauge
+	backendCircuitBreakerOpenGauge     metrics.Gauge
+	backendEjectedServersGauge         metrics.Gauge
+	backendRetryBudgetGauge            metrics.Gauge
 }
 
 func (r *standardRegistry) IsEnabled() bool {
@@ -155,6 +204,14 @@ func (r *standardRegistry) EntrypointOpenConnsGauge() metrics.Gauge {
 	return r.entrypointOpenConnsGauge
 }
 
+func (r *standardRegistry) EntrypointRapidResetConnsCounter() metrics.Counter {
+	return r.entrypointRapidResetConnsCounter
+}
+
+func (r *standardRegistry) EntrypointTLSHandshakesCounter() metrics.Counter {
+	return r.entrypointTLSHandshakesCounter
+}
+
 func (r *standardRegistry) BackendReqsCounter() metrics.Counter {
 	return r.backendReqsCounter
 }
@@ -163,6 +220,10 @@ func (r *standardRegistry) BackendReqDurationHistogram() metrics.Histogram {
 	return r.backendReqDurationHistogram
 }
 
+func (r *standardRegistry) BackendRespHeaderDurationHistogram() metrics.Histogram {
+	return r.backendRespHeaderDurationHistogram
+}
+
 func (r *standardRegistry) BackendOpenConnsGauge() metrics.Gauge {
 	return r.backendOpenConnsGauge
 }
@@ -174,3 +235,19 @@ func (r *standardRegistry) BackendRetriesCounter() metrics.Counter {
 func (r *standardRegistry) BackendServerUpGauge() metrics.Gauge {
 	return r.backendServerUpGauge
 }
+
+func (r *standardRegistry) BackendWebSocketConnsGauge() metrics.Gauge {
+	return r.backendWebSocketConnsGauge
+}
+
+func (r *standardRegistry) BackendCircuitBreakerOpenGauge() metrics.Gauge {
+	return r.backendCircuitBreakerOpenGauge
+}
+
+func (r *standardRegistry) BackendEjectedServersGauge() metrics.Gauge {
+	return r.backendEjectedServersGauge
+}
+
+func (r *standardRegistry) BackendRetryBudgetGauge() metrics.Gauge {
+	return r.backendRetryBudgetGauge
+}
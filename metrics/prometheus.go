@@ -24,16 +24,23 @@ const (
 	configLastReloadFailureName    = metricNamePrefix + "config_last_reload_failure"
 
 	// entrypoint
-	entrypointReqsTotalName   = metricNamePrefix + "entrypoint_requests_total"
-	entrypointReqDurationName = metricNamePrefix + "entrypoint_request_duration_seconds"
-	entrypointOpenConnsName   = metricNamePrefix + "entrypoint_open_connections"
+	entrypointReqsTotalName            = metricNamePrefix + "entrypoint_requests_total"
+	entrypointReqDurationName          = metricNamePrefix + "entrypoint_request_duration_seconds"
+	entrypointOpenConnsName            = metricNamePrefix + "entrypoint_open_connections"
+	entrypointRapidResetConnsTotalName = metricNamePrefix + "entrypoint_rapid_reset_connections_total"
+	entrypointTLSHandshakesTotalName   = metricNamePrefix + "entrypoint_tls_handshakes_total"
 
 	// backend level
-	backendReqsTotalName    = metricNamePrefix + "backend_requests_total"
-	backendReqDurationName  = metricNamePrefix + "backend_request_duration_seconds"
-	backendOpenConnsName    = metricNamePrefix + "backend_open_connections"
-	backendRetriesTotalName = metricNamePrefix + "backend_retries_total"
-	backendServerUpName     = metricNamePrefix + "backend_server_up"
+	backendReqsTotalName          = metricNamePrefix + "backend_requests_total"
+	backendReqDurationName        = metricNamePrefix + "backend_request_duration_seconds"
+	backendRespHeaderDurationName = metricNamePrefix + "backend_response_header_duration_seconds"
+	backendOpenConnsName          = metricNamePrefix + "backend_open_connections"
+	backendRetriesTotalName       = metricNamePrefix + "backend_retries_total"
+	backendServerUpName           = metricNamePrefix + "backend_server_up"
+	backendWebSocketConnsName     = metricNamePrefix + "backend_websocket_connections"
+	backendCircuitBreakerOpenName = metricNamePrefix + "backend_circuit_breaker_open"
+	backendEjectedServersName     = metricNamePrefix + "backend_ejected_servers"
+	backendRetryBudgetName        = metricNamePrefix + "backend_retry_budget"
 )
 
 const (
@@ -107,6 +114,14 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 		Name: entrypointOpenConnsName,
 		Help: "How many open connections exist on an entrypoint, partitioned by method and protocol.",
 	}, []string{"method", "protocol", "entrypoint"})
+	entrypointRapidResetConns := newCounterFrom(promState.collectors, stdprometheus.CounterOpts{
+		Name: entrypointRapidResetConnsTotalName,
+		Help: "How many HTTP/2 connections were closed on an entrypoint because the client reset too many streams.",
+	}, []string{"entrypoint"})
+	entrypointTLSHandshakes := newCounterFrom(promState.collectors, stdprometheus.CounterOpts{
+		Name: entrypointTLSHandshakesTotalName,
+		Help: "How many TLS handshakes completed on an entrypoint, partitioned by whether they resumed a session.",
+	}, []string{"entrypoint", "resumed"})
 
 	backendReqs := newCounterFrom(promState.collectors, stdprometheus.CounterOpts{
 		Name: backendReqsTotalName,
@@ -117,6 +132,11 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 		Help:    "How long it took to process the request on a backend, partitioned by status code, protocol, and method.",
 		Buckets: buckets,
 	}, []string{"code", "method", "protocol", "backend"})
+	backendRespHeaderDurations := newHistogramFrom(promState.collectors, stdprometheus.HistogramOpts{
+		Name:    backendRespHeaderDurationName,
+		Help:    "How long it took a backend to send the response headers of a request, partitioned by status code class.",
+		Buckets: buckets,
+	}, []string{"code_class", "backend"})
 	backendOpenConns := newGaugeFrom(promState.collectors, stdprometheus.GaugeOpts{
 		Name: backendOpenConnsName,
 		Help: "How many open connections exist on a backend, partitioned by method and protocol.",
@@ -129,6 +149,22 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 		Name: backendServerUpName,
 		Help: "Backend server is up, described by gauge value of 0 or 1.",
 	}, []string{"backend", "url"})
+	backendWebSocketConns := newGaugeFrom(promState.collectors, stdprometheus.GaugeOpts{
+		Name: backendWebSocketConnsName,
+		Help: "How many upgraded WebSocket connections are open on a backend.",
+	}, []string{"backend"})
+	backendCircuitBreakerOpen := newGaugeFrom(promState.collectors, stdprometheus.GaugeOpts{
+		Name: backendCircuitBreakerOpenName,
+		Help: "Circuit breaker of a backend is tripped, either open or half-open, described by gauge value of 0 or 1.",
+	}, []string{"backend"})
+	backendEjectedServers := newGaugeFrom(promState.collectors, stdprometheus.GaugeOpts{
+		Name: backendEjectedServersName,
+		Help: "How many servers of a backend are ejected by its per-server circuit breaker.",
+	}, []string{"backend"})
+	backendRetryBudget := newGaugeFrom(promState.collectors, stdprometheus.GaugeOpts{
+		Name: backendRetryBudgetName,
+		Help: "How many retries are left in the retry budget of a backend.",
+	}, []string{"backend"})
 
 	promState.describers = []func(chan<- *stdprometheus.Desc){
 		configReloads.cv.Describe,
@@ -138,28 +174,42 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 		entrypointReqs.cv.Describe,
 		entrypointReqDurations.hv.Describe,
 		entrypointOpenConns.gv.Describe,
+		entrypointRapidResetConns.cv.Describe,
+		entrypointTLSHandshakes.cv.Describe,
 		backendReqs.cv.Describe,
 		backendReqDurations.hv.Describe,
+		backendRespHeaderDurations.hv.Describe,
 		backendOpenConns.gv.Describe,
 		backendRetries.cv.Describe,
 		backendServerUp.gv.Describe,
+		backendWebSocketConns.gv.Describe,
+		backendCircuitBreakerOpen.gv.Describe,
+		backendEjectedServers.gv.Describe,
+		backendRetryBudget.gv.Describe,
 	}
 	stdprometheus.MustRegister(promState)
 
 	return &standardRegistry{
-		enabled:                        true,
-		configReloadsCounter:           configReloads,
-		configReloadsFailureCounter:    configReloadsFailures,
-		lastConfigReloadSuccessGauge:   lastConfigReloadSuccess,
-		lastConfigReloadFailureGauge:   lastConfigReloadFailure,
-		entrypointReqsCounter:          entrypointReqs,
-		entrypointReqDurationHistogram: entrypointReqDurations,
-		entrypointOpenConnsGauge:       entrypointOpenConns,
-		backendReqsCounter:             backendReqs,
-		backendReqDurationHistogram:    backendReqDurations,
-		backendOpenConnsGauge:          backendOpenConns,
-		backendRetriesCounter:          backendRetries,
-		backendServerUpGauge:           backendServerUp,
+		enabled:                            true,
+		configReloadsCounter:               configReloads,
+		configReloadsFailureCounter:        configReloadsFailures,
+		lastConfigReloadSuccessGauge:       lastConfigReloadSuccess,
+		lastConfigReloadFailureGauge:       lastConfigReloadFailure,
+		entrypointReqsCounter:              entrypointReqs,
+		entrypointReqDurationHistogram:     entrypointReqDurations,
+		entrypointOpenConnsGauge:           entrypointOpenConns,
+		entrypointRapidResetConnsCounter:   entrypointRapidResetConns,
+		entrypointTLSHandshakesCounter:     entrypointTLSHandshakes,
+		backendReqsCounter:                 backendReqs,
+		backendReqDurationHistogram:        backendReqDurations,
+		backendRespHeaderDurationHistogram: backendRespHeaderDurations,
+		backendOpenConnsGauge:              backendOpenConns,
+		backendRetriesCounter:              backendRetries,
+		backendServerUpGauge:               backendServerUp,
+		backendWebSocketConnsGauge:         backendWebSocketConns,
+		backendCircuitBreakerOpenGauge:     backendCircuitBreakerOpen,
+		backendEjectedServersGauge:         backendEjectedServers,
+		backendRetryBudgetGauge:            backendRetryBudget,
 	}
 }
 
@@ -37,6 +37,10 @@ func TestPrometheus(t *testing.T) {
 		EntrypointOpenConnsGauge().
 		With("method", http.MethodGet, "protocol", "http", "entrypoint", "http").
 		Set(1)
+	prometheusRegistry.
+		EntrypointTLSHandshakesCounter().
+		With("entrypoint", "https", "resumed", "true").
+		Add(1)
 
 	prometheusRegistry.
 		BackendReqsCounter().
@@ -46,6 +50,10 @@ func TestPrometheus(t *testing.T) {
 		BackendReqDurationHistogram().
 		With("backend", "backend1", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet, "protocol", "http").
 		Observe(10000)
+	prometheusRegistry.
+		BackendRespHeaderDurationHistogram().
+		With("backend", "backend1", "code_class", "2xx").
+		Observe(10000)
 	prometheusRegistry.
 		BackendOpenConnsGauge().
 		With("backend", "backend1", "method", http.MethodGet, "protocol", "http").
@@ -58,6 +66,22 @@ func TestPrometheus(t *testing.T) {
 		BackendServerUpGauge().
 		With("backend", "backend1", "url", "http://127.0.0.10:80").
 		Set(1)
+	prometheusRegistry.
+		BackendWebSocketConnsGauge().
+		With("backend", "backend1").
+		Set(1)
+	prometheusRegistry.
+		BackendCircuitBreakerOpenGauge().
+		With("backend", "backend1").
+		Set(1)
+	prometheusRegistry.
+		BackendEjectedServersGauge().
+		With("backend", "backend1").
+		Set(2)
+	prometheusRegistry.
+		BackendRetryBudgetGauge().
+		With("backend", "backend1").
+		Set(5)
 
 	delayForTrackingCompletion()
 
@@ -113,6 +137,14 @@ func TestPrometheus(t *testing.T) {
 			},
 			assert: buildGaugeAssert(t, entrypointOpenConnsName, 1),
 		},
+		{
+			name: entrypointTLSHandshakesTotalName,
+			labels: map[string]string{
+				"entrypoint": "https",
+				"resumed":    "true",
+			},
+			assert: buildCounterAssert(t, entrypointTLSHandshakesTotalName, 1),
+		},
 		{
 			name: backendReqsTotalName,
 			labels: map[string]string{
@@ -133,6 +165,14 @@ func TestPrometheus(t *testing.T) {
 			},
 			assert: buildHistogramAssert(t, backendReqDurationName, 1),
 		},
+		{
+			name: backendRespHeaderDurationName,
+			labels: map[string]string{
+				"code_class": "2xx",
+				"backend":    "backend1",
+			},
+			assert: buildHistogramAssert(t, backendRespHeaderDurationName, 1),
+		},
 		{
 			name: backendOpenConnsName,
 			labels: map[string]string{
@@ -157,6 +197,34 @@ func TestPrometheus(t *testing.T) {
 			},
 			assert: buildGaugeAssert(t, backendServerUpName, 1),
 		},
+		{
+			name: backendWebSocketConnsName,
+			labels: map[string]string{
+				"backend": "backend1",
+			},
+			assert: buildGaugeAssert(t, backendWebSocketConnsName, 1),
+		},
+		{
+			name: backendCircuitBreakerOpenName,
+			labels: map[string]string{
+				"backend": "backend1",
+			},
+			assert: buildGaugeAssert(t, backendCircuitBreakerOpenName, 1),
+		},
+		{
+			name: backendEjectedServersName,
+			labels: map[string]string{
+				"backend": "backend1",
+			},
+			assert: buildGaugeAssert(t, backendEjectedServersName, 2),
+		},
+		{
+			name: backendRetryBudgetName,
+			labels: map[string]string{
+				"backend": "backend1",
+			},
+			assert: buildGaugeAssert(t, backendRetryBudgetName, 5),
+		},
 	}
 
 	for _, test := range tests {
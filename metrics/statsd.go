@@ -7,36 +7,86 @@ import (
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/multi"
 	"github.com/go-kit/kit/metrics/statsd"
 )
 
-var statsdClient = statsd.New("traefik.", kitlog.LoggerFunc(func(keyvals ...interface{}) error {
-	log.Info(keyvals)
-	return nil
-}))
+var statsdClient *statsd.Statsd
 
 var statsdTicker *time.Ticker
 
 const (
-	statsdMetricsReqsName    = "requests.total"
-	statsdMetricsLatencyName = "request.duration"
-	statsdRetriesTotalName   = "backend.retries.total"
+	statsdDefaultPrefix = "traefik"
+
+	// Metric names mirror the Prometheus ones, using "." as separator
+	statsdConfigReloadsName           = "config.reloads.total"
+	statsdConfigReloadsFailureName    = "config.reloads.failure.total"
+	statsdLastConfigReloadSuccessName = "config.last.reload.success"
+	statsdLastConfigReloadFailureName = "config.last.reload.failure"
+
+	statsdEntrypointReqsName            = "entrypoint.requests.total"
+	statsdEntrypointReqDurationName     = "entrypoint.request.duration"
+	statsdEntrypointOpenConnsName       = "entrypoint.open.connections"
+	statsdEntrypointRapidResetConnsName = "entrypoint.rapid.reset.connections.total"
+	statsdEntrypointTLSHandshakesName   = "entrypoint.tls.handshakes.total"
+
+	statsdMetricsBackendReqsName        = "backend.requests.total"
+	statsdMetricsBackendLatencyName     = "backend.request.duration"
+	statsdBackendOpenConnsName          = "backend.open.connections"
+	statsdRetriesTotalName              = "backend.retries.total"
+	statsdBackendServerUpName           = "backend.server.up"
+	statsdBackendWebSocketConnsName     = "backend.websocket.connections"
+	statsdBackendCircuitBreakerOpenName = "backend.circuit.breaker.open"
+	statsdBackendEjectedServersName     = "backend.ejected.servers"
+	statsdBackendRetryBudgetName        = "backend.retry.budget"
+
+	// Names of the backend metrics before they mirrored the Prometheus ones, still sent for the existing dashboards
+	statsdLegacyReqsName    = "requests.total"
+	statsdLegacyLatencyName = "request.duration"
 )
 
 // RegisterStatsd registers the metrics pusher if this didn't happen yet and creates a statsd Registry instance.
 func RegisterStatsd(config *types.Statsd) Registry {
 	if statsdTicker == nil {
+		statsdClient = newStatsdClient(config.Prefix)
 		statsdTicker = initStatsdTicker(config)
 	}
 
 	return &standardRegistry{
-		enabled:                     true,
-		backendReqsCounter:          statsdClient.NewCounter(statsdMetricsReqsName, 1.0),
-		backendReqDurationHistogram: statsdClient.NewTiming(statsdMetricsLatencyName, 1.0),
-		backendRetriesCounter:       statsdClient.NewCounter(statsdRetriesTotalName, 1.0),
+		enabled:                          true,
+		configReloadsCounter:             statsdClient.NewCounter(statsdConfigReloadsName, 1.0),
+		configReloadsFailureCounter:      statsdClient.NewCounter(statsdConfigReloadsFailureName, 1.0),
+		lastConfigReloadSuccessGauge:     statsdClient.NewGauge(statsdLastConfigReloadSuccessName),
+		lastConfigReloadFailureGauge:     statsdClient.NewGauge(statsdLastConfigReloadFailureName),
+		entrypointReqsCounter:            statsdClient.NewCounter(statsdEntrypointReqsName, 1.0),
+		entrypointReqDurationHistogram:   statsdClient.NewTiming(statsdEntrypointReqDurationName, 1.0),
+		entrypointOpenConnsGauge:         statsdClient.NewGauge(statsdEntrypointOpenConnsName),
+		entrypointRapidResetConnsCounter: statsdClient.NewCounter(statsdEntrypointRapidResetConnsName, 1.0),
+		entrypointTLSHandshakesCounter:   statsdClient.NewCounter(statsdEntrypointTLSHandshakesName, 1.0),
+		backendReqsCounter:               multi.NewCounter(statsdClient.NewCounter(statsdMetricsBackendReqsName, 1.0), statsdClient.NewCounter(statsdLegacyReqsName, 1.0)),
+		backendReqDurationHistogram:      multi.NewHistogram(statsdClient.NewTiming(statsdMetricsBackendLatencyName, 1.0), statsdClient.NewTiming(statsdLegacyLatencyName, 1.0)),
+		backendOpenConnsGauge:            statsdClient.NewGauge(statsdBackendOpenConnsName),
+		backendRetriesCounter:            statsdClient.NewCounter(statsdRetriesTotalName, 1.0),
+		backendServerUpGauge:             statsdClient.NewGauge(statsdBackendServerUpName),
+		backendWebSocketConnsGauge:       statsdClient.NewGauge(statsdBackendWebSocketConnsName),
+		backendCircuitBreakerOpenGauge:   statsdClient.NewGauge(statsdBackendCircuitBreakerOpenName),
+		backendEjectedServersGauge:       statsdClient.NewGauge(statsdBackendEjectedServersName),
+		backendRetryBudgetGauge:          statsdClient.NewGauge(statsdBackendRetryBudgetName),
 	}
 }
 
+// newStatsdClient creates a statsd client sending metrics with the given prefix
+func newStatsdClient(prefix string) *statsd.Statsd {
+	if len(prefix) == 0 {
+		prefix = statsdDefaultPrefix
+	}
+
+	return statsd.New(prefix+".", kitlog.LoggerFunc(func(keyvals ...interface{}) error {
+		log.Info(keyvals)
+		return nil
+	}))
+}
+
 // initStatsdTicker initializes metrics pusher and creates a statsdClient if not created already
 func initStatsdTicker(config *types.Statsd) *time.Ticker {
 	address := config.Address
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -23,8 +24,12 @@ func TestStatsD(t *testing.T) {
 
 	expected := []string{
 		// We are only validating counts, as it is nearly impossible to validate latency, since it varies every run
-		"traefik.requests.total:2.000000|c\n",
+		"traefik.backend.requests.total:2.000000|c\n",
 		"traefik.backend.retries.total:2.000000|c\n",
+		"traefik.backend.request.duration:10000.000000|ms",
+		"traefik.backend.server.up:1.000000|g\n",
+		// the backend metrics are still sent with their former names
+		"traefik.requests.total:2.000000|c\n",
 		"traefik.request.duration:10000.000000|ms",
 	}
 
@@ -34,5 +39,25 @@ func TestStatsD(t *testing.T) {
 		statsdRegistry.BackendRetriesCounter().With("service", "test").Add(1)
 		statsdRegistry.BackendRetriesCounter().With("service", "test").Add(1)
 		statsdRegistry.BackendReqDurationHistogram().With("service", "test", "code", string(http.StatusOK)).Observe(10000)
+		statsdRegistry.BackendServerUpGauge().With("backend", "test", "url", "http://127.0.0.1").Set(1)
+	})
+}
+
+func TestStatsDWithPrefix(t *testing.T) {
+	udp.SetAddr(":18125")
+	// This is needed to make sure that UDP Listener listens for data a bit longer, otherwise it will quit after a millisecond
+	udp.Timeout = 5 * time.Second
+
+	statsdRegistry := RegisterStatsd(&types.Statsd{Address: ":18125", PushInterval: "1s", Prefix: "testPrefix"})
+	defer StopStatsd()
+
+	expected := []string{
+		"testPrefix.entrypoint.requests.total:1.000000|c\n",
+		"testPrefix.config.reloads.total:1.000000|c\n",
+	}
+
+	udp.ShouldReceiveAll(t, expected, func() {
+		statsdRegistry.EntrypointReqsCounter().With("entrypoint", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
+		statsdRegistry.ConfigReloadsCounter().Add(1)
 	})
 }
@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/types"
+)
+
+// Filter decides which requests of a frontend are written to the access log.
+type Filter struct {
+	disabled      bool
+	excludedPaths []string
+	excludedCodes [][2]int
+}
+
+// NewFilter creates a Filter from the access log settings of a frontend.
+func NewFilter(config *types.FrontendAccessLog) (*Filter, error) {
+	filter := &Filter{disabled: config.Disabled}
+
+	for _, path := range config.ExcludedPaths {
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid excluded path %q", path)
+		}
+		filter.excludedPaths = append(filter.excludedPaths, path)
+	}
+
+	for _, block := range config.ExcludedStatus {
+		codes := strings.Split(block, "-")
+		// a single status code is a range of one code
+		if len(codes) == 1 {
+			codes = append(codes, codes[0])
+		}
+		if len(codes) != 2 {
+			return nil, fmt.Errorf("invalid excluded status %q", block)
+		}
+		lowCode, err := strconv.Atoi(strings.TrimSpace(codes[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded status %q", block)
+		}
+		highCode, err := strconv.Atoi(strings.TrimSpace(codes[1]))
+		if err != nil || highCode < lowCode {
+			return nil, fmt.Errorf("invalid excluded status %q", block)
+		}
+		filter.excludedCodes = append(filter.excludedCodes, [2]int{lowCode, highCode})
+	}
+
+	return filter, nil
+}
+
+// keep reports whether a request to the path, answered with the status code, is written to the access log.
+func (f *Filter) keep(path string, status int) bool {
+	if f.disabled {
+		return false
+	}
+	for _, excludedPath := range f.excludedPaths {
+		if strings.HasPrefix(path, excludedPath) {
+			return false
+		}
+	}
+	for _, block := range f.excludedCodes {
+		if status >= block[0] && status <= block[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveFilter sends the access log filter of a frontend to the logger.
+type SaveFilter struct {
+	next   http.Handler
+	filter *Filter
+}
+
+// NewSaveFilter creates a SaveFilter handler.
+func NewSaveFilter(next http.Handler, filter *Filter) http.Handler {
+	return &SaveFilter{next, filter}
+}
+
+func (sf *SaveFilter) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	table := GetLogDataTable(r)
+	table.filter = sf.filter
+
+	sf.next.ServeHTTP(rw, r)
+}
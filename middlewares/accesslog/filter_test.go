@@ -0,0 +1,174 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilter(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.FrontendAccessLog
+		expectedError string
+	}{
+		{
+			desc:   "disabled",
+			config: &types.FrontendAccessLog{Disabled: true},
+		},
+		{
+			desc: "excluded paths and status",
+			config: &types.FrontendAccessLog{
+				ExcludedPaths:  []string{"/health", " /metrics"},
+				ExcludedStatus: []string{"200-299", "404"},
+			},
+		},
+		{
+			desc:          "relative path",
+			config:        &types.FrontendAccessLog{ExcludedPaths: []string{"health"}},
+			expectedError: `invalid excluded path "health"`,
+		},
+		{
+			desc:          "invalid status",
+			config:        &types.FrontendAccessLog{ExcludedStatus: []string{"foo"}},
+			expectedError: `invalid excluded status "foo"`,
+		},
+		{
+			desc:          "too many bounds",
+			config:        &types.FrontendAccessLog{ExcludedStatus: []string{"200-299-399"}},
+			expectedError: `invalid excluded status "200-299-399"`,
+		},
+		{
+			desc:          "reversed range",
+			config:        &types.FrontendAccessLog{ExcludedStatus: []string{"299-200"}},
+			expectedError: `invalid excluded status "299-200"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewFilter(test.config)
+			if len(test.expectedError) > 0 {
+				assert.EqualError(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFilterKeep(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   *types.FrontendAccessLog
+		path     string
+		status   int
+		expected bool
+	}{
+		{
+			desc:     "no exclusion",
+			config:   &types.FrontendAccessLog{},
+			path:     "/foo",
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			desc:     "disabled",
+			config:   &types.FrontendAccessLog{Disabled: true},
+			path:     "/foo",
+			status:   http.StatusInternalServerError,
+			expected: false,
+		},
+		{
+			desc:     "excluded path prefix",
+			config:   &types.FrontendAccessLog{ExcludedPaths: []string{"/health"}},
+			path:     "/health/ready",
+			status:   http.StatusOK,
+			expected: false,
+		},
+		{
+			desc:     "other path",
+			config:   &types.FrontendAccessLog{ExcludedPaths: []string{"/health"}},
+			path:     "/foo",
+			status:   http.StatusOK,
+			expected: true,
+		},
+		{
+			desc:     "excluded status range",
+			config:   &types.FrontendAccessLog{ExcludedStatus: []string{"200-299"}},
+			path:     "/foo",
+			status:   http.StatusNoContent,
+			expected: false,
+		},
+		{
+			desc:     "excluded single status",
+			config:   &types.FrontendAccessLog{ExcludedStatus: []string{"404"}},
+			path:     "/foo",
+			status:   http.StatusNotFound,
+			expected: false,
+		},
+		{
+			desc:     "status out of the ranges",
+			config:   &types.FrontendAccessLog{ExcludedStatus: []string{"200-299", "404"}},
+			path:     "/foo",
+			status:   http.StatusInternalServerError,
+			expected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			filter, err := NewFilter(test.config)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, filter.keep(test.path, test.status))
+		})
+	}
+}
+
+func TestLogHandlerWithFilter(t *testing.T) {
+	filter, err := NewFilter(&types.FrontendAccessLog{ExcludedPaths: []string{"/health"}, ExcludedStatus: []string{"304"}})
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	logHandler := &LogHandler{
+		logger: &logrus.Logger{
+			Out:       output,
+			Formatter: new(logrus.JSONFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.InfoLevel,
+		},
+	}
+
+	frontend := NewSaveFilter(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/cached" {
+			rw.WriteHeader(http.StatusNotModified)
+		}
+	}), filter)
+
+	for _, path := range []string{"/health", "/cached", "/foo"} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		logHandler.ServeHTTP(httptest.NewRecorder(), req, frontend.ServeHTTP)
+	}
+
+	// the requests not handled by the frontend are logged
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/health", nil)
+	logHandler.ServeHTTP(httptest.NewRecorder(), req, func(rw http.ResponseWriter, req *http.Request) {})
+
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"RequestPath":"/foo"`)
+	assert.Contains(t, string(lines[1]), `"RequestPath":"/health"`)
+}
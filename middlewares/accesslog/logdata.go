@@ -70,6 +70,20 @@ const (
 	Overhead = "Overhead"
 	// RetryAttempts is the map key used for the amount of attempts the request was retried.
 	RetryAttempts = "RetryAttempts"
+	// TLSVersion is the map key used for the TLS version negotiated with the client, if the request was received over TLS.
+	TLSVersion = "TLSVersion"
+	// TLSCipher is the map key used for the name of the cipher suite negotiated with the client.
+	TLSCipher = "TLSCipher"
+	// TLSServerName is the map key used for the server name (SNI) requested by the client, if any.
+	TLSServerName = "TLSServerName"
+	// TLSClientCertPresented is the map key used to indicate whether the client presented a certificate.
+	TLSClientCertPresented = "TLSClientCertPresented"
+	// TLSClientCertVerified is the map key used to indicate whether the client certificate was verified against the client CAs.
+	TLSClientCertVerified = "TLSClientCertVerified"
+	// RequestID is the map key used for the ID given to the request, if request IDs are enabled.
+	RequestID = "RequestID"
+	// SampleRate is the map key used for the number of requests the logged one stands for, if the access logs are sampled.
+	SampleRate = "SampleRate"
 )
 
 // These are written out in the default case when no config is provided to specify keys of interest.
@@ -113,6 +127,13 @@ func init() {
 	allCoreKeys[StartLocal] = struct{}{}
 	allCoreKeys[Overhead] = struct{}{}
 	allCoreKeys[RetryAttempts] = struct{}{}
+	allCoreKeys[TLSVersion] = struct{}{}
+	allCoreKeys[TLSCipher] = struct{}{}
+	allCoreKeys[TLSServerName] = struct{}{}
+	allCoreKeys[TLSClientCertPresented] = struct{}{}
+	allCoreKeys[TLSClientCertVerified] = struct{}{}
+	allCoreKeys[RequestID] = struct{}{}
+	allCoreKeys[SampleRate] = struct{}{}
 }
 
 // CoreLogData holds the fields computed from the request/response.
@@ -124,4 +145,7 @@ type LogData struct {
 	Request            http.Header
 	OriginResponse     http.Header
 	DownstreamResponse http.Header
+
+	// filter, set by the frontend handling the request, decides whether the request is logged
+	filter *Filter
 }
@@ -3,6 +3,7 @@ package accesslog
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -12,6 +13,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/containous/traefik/middlewares/requestid"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/sirupsen/logrus"
 )
@@ -35,6 +38,8 @@ type LogHandler struct {
 	logger   *logrus.Logger
 	file     *os.File
 	filePath string
+	syslog   io.WriteCloser
+	sampler  *sampler
 	mu       sync.Mutex
 }
 
@@ -60,13 +65,37 @@ func NewLogHandler(config *types.AccessLog) (*LogHandler, error) {
 		return nil, fmt.Errorf("unsupported access log format: %s", config.Format)
 	}
 
-	logger := &logrus.Logger{
-		Out:       file,
+	logHandler := &LogHandler{file: file, filePath: config.FilePath}
+	if config.Sampling != nil {
+		s, err := newSampler(config.Sampling)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring access log sampling: %s", err)
+		}
+		logHandler.sampler = s
+	}
+	if config.Syslog != nil {
+		syslogWriter, err := newSyslogWriter(config.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to access log syslog server: %s", err)
+		}
+		logHandler.syslog = syslogWriter
+	}
+
+	logHandler.logger = &logrus.Logger{
+		Out:       logHandler.output(),
 		Formatter: formatter,
 		Hooks:     make(logrus.LevelHooks),
 		Level:     logrus.InfoLevel,
 	}
-	return &LogHandler{logger: logger, file: file, filePath: config.FilePath}, nil
+	return logHandler, nil
+}
+
+// output returns the writer of the access logs, sending them to the syslog server too when configured
+func (l *LogHandler) output() io.Writer {
+	if l.syslog == nil {
+		return l.file
+	}
+	return io.MultiWriter(l.file, l.syslog)
 }
 
 func openAccessLogFile(filePath string) (*os.File, error) {
@@ -132,39 +161,71 @@ func (l *LogHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next h
 		core[ClientHost] = forwardedFor
 	}
 
+	if tlsInfo := traefikTls.NewConnectionInfo(req.TLS); tlsInfo != nil {
+		core[TLSVersion] = tlsInfo.Version
+		core[TLSCipher] = tlsInfo.CipherSuite
+		core[TLSServerName] = tlsInfo.ServerName
+		core[TLSClientCertPresented] = tlsInfo.ClientCertPresented
+		core[TLSClientCertVerified] = tlsInfo.ClientCertVerified
+	}
+
+	if id := requestid.Get(req); len(id) > 0 {
+		core[RequestID] = id
+	}
+
 	crw := &captureResponseWriter{rw: rw}
 
 	next.ServeHTTP(crw, reqWithDataTable)
 
 	core[ClientUsername] = usernameIfPresent(reqWithDataTable.URL)
 
+	if logDataTable.filter != nil && !logDataTable.filter.keep(req.URL.Path, crw.Status()) {
+		return
+	}
+
+	if l.sampler != nil {
+		logged, sampleRate := l.sampler.sample(crw.Status())
+		if !logged {
+			return
+		}
+		core[SampleRate] = sampleRate
+	}
+
 	logDataTable.DownstreamResponse = crw.Header()
 	l.logTheRoundTrip(logDataTable, crr, crw)
 }
 
-// Close closes the Logger (i.e. the file etc).
+// Close closes the Logger (i.e. the file, the syslog connection etc).
 func (l *LogHandler) Close() error {
+	if l.syslog != nil {
+		l.syslog.Close()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.file.Close()
 }
 
 // Rotate closes and reopens the log file to allow for rotation
-// by an external source.
+// by an external source. The new file is opened before the previous one is closed, so that no access log is lost,
+// and the previous file is kept if the new one can't be opened. It does nothing if the access log isn't backed by a file.
 func (l *LogHandler) Rotate() error {
-	var err error
-
-	if l.file != nil {
-		defer func(f *os.File) {
-			f.Close()
-		}(l.file)
+	if len(l.filePath) == 0 {
+		return nil
 	}
 
-	l.file, err = os.OpenFile(l.filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0664)
+	file, err := openAccessLogFile(l.filePath)
 	if err != nil {
 		return err
 	}
+
+	// the access logs are written with the lock held, so the previous file is not written anymore once swapped
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.logger.Out = l.file
+	previous := l.file
+	l.file = file
+	l.logger.Out = l.output()
+	l.mu.Unlock()
+
+	previous.Close()
 	return nil
 }
 
@@ -24,7 +24,7 @@ func (f *CommonLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	timestamp := entry.Data[StartUTC].(time.Time).Format(commonLogTimeFormat)
 	elapsedMillis := entry.Data[Duration].(time.Duration).Nanoseconds() / 1000000
 
-	_, err := fmt.Fprintf(b, "%s - %s [%s] \"%s %s %s\" %v %v %s %s %v %s %s %dms\n",
+	_, err := fmt.Fprintf(b, "%s - %s [%s] \"%s %s %s\" %v %v %s %s %v %s %s %dms",
 		entry.Data[ClientHost],
 		entry.Data[ClientUsername],
 		timestamp,
@@ -39,8 +39,17 @@ func (f *CommonLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 		toLog(entry.Data[FrontendName], defaultValue),
 		toLog(entry.Data[BackendURL], defaultValue),
 		elapsedMillis)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sample rate is only known, and added, when the access logs are sampled
+	if sampleRate, ok := entry.Data[SampleRate]; ok {
+		fmt.Fprintf(b, " %v", sampleRate)
+	}
+	b.WriteString("\n")
 
-	return b.Bytes(), err
+	return b.Bytes(), nil
 }
 
 func toLog(v interface{}, defaultValue string) interface{} {
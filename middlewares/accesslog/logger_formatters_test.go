@@ -57,6 +57,28 @@ func TestCommonLogFormatter_Format(t *testing.T) {
 				BackendURL:           "http://10.0.0.2/toto",
 			},
 			expectedLog: `10.0.0.1 - Client [10/Nov/2009:23:00:00 +0000] "GET /foo http" 123 132 "referer" "agent" - "foo" "http://10.0.0.2/toto" 123000ms
+`,
+		},
+		{
+			name: "sampled",
+			data: map[string]interface{}{
+				StartUTC:             time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+				Duration:             123 * time.Second,
+				ClientHost:           "10.0.0.1",
+				ClientUsername:       "Client",
+				RequestMethod:        http.MethodGet,
+				RequestPath:          "/foo",
+				RequestProtocol:      "http",
+				OriginStatus:         200,
+				OriginContentSize:    132,
+				"request_Referer":    "referer",
+				"request_User-Agent": "agent",
+				RequestCount:         nil,
+				FrontendName:         "foo",
+				BackendURL:           "http://10.0.0.2/toto",
+				SampleRate:           10,
+			},
+			expectedLog: `10.0.0.1 - Client [10/Nov/2009:23:00:00 +0000] "GET /foo http" 200 132 "referer" "agent" - "foo" "http://10.0.0.2/toto" 123000ms 10
 `,
 		},
 	}
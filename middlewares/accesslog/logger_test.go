@@ -1,6 +1,8 @@
 package accesslog
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/containous/traefik/middlewares/requestid"
 	"github.com/containous/traefik/types"
 	shellwords "github.com/mattn/go-shellwords"
 	"github.com/stretchr/testify/assert"
@@ -98,6 +101,48 @@ func TestLogRotation(t *testing.T) {
 	close(writeDone)
 }
 
+func TestLogRotationFailure(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "traefik_")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	fileName := filepath.Join(tempDir, "access.log")
+	rotatedFileName := fileName + ".rotated"
+
+	logHandler, err := NewLogHandler(&types.AccessLog{FilePath: fileName, Format: CommonFormat})
+	require.NoError(t, err)
+	defer logHandler.Close()
+
+	next := func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+	logHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost", nil), next)
+
+	// the access log file can't be reopened, as its path is now a directory
+	require.NoError(t, os.Rename(fileName, rotatedFileName))
+	require.NoError(t, os.Mkdir(fileName, 0755))
+	assert.Error(t, logHandler.Rotate())
+
+	// the previous file is kept
+	logHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost", nil), next)
+	assert.Equal(t, 2, lineCount(t, rotatedFileName))
+}
+
+func TestLogRotationStdout(t *testing.T) {
+	file, restoreStdout := captureStdout(t)
+	defer restoreStdout()
+
+	logHandler, err := NewLogHandler(&types.AccessLog{Format: CommonFormat})
+	require.NoError(t, err)
+
+	// the rotation does nothing, and keeps logging to stdout
+	require.NoError(t, logHandler.Rotate())
+	logHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost", nil), func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	assert.Equal(t, 1, lineCount(t, file.Name()))
+}
+
 func lineCount(t *testing.T, fileName string) int {
 	t.Helper()
 	fileContents, err := ioutil.ReadFile(fileName)
@@ -244,6 +289,65 @@ func TestLoggerJSON(t *testing.T) {
 	assert.Equal(t, len(jsonData), assertCount, string(logData))
 }
 
+func TestLoggerJSONWithTLS(t *testing.T) {
+	tmpDir := createTempDir(t, JSONFormat)
+	defer os.RemoveAll(tmpDir)
+
+	logFilePath := filepath.Join(tmpDir, logFileNameSuffix)
+	logger, err := NewLogHandler(&types.AccessLog{FilePath: logFilePath, Format: JSONFormat})
+	require.NoError(t, err)
+	defer logger.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "https://"+testHostname+"/"+testPath, nil)
+	req.TLS = &tls.ConnectionState{
+		Version:          tls.VersionTLS12,
+		CipherSuite:      tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		ServerName:       testHostname,
+		PeerCertificates: []*x509.Certificate{{}},
+	}
+
+	logger.ServeHTTP(httptest.NewRecorder(), req, logWriterTestHandlerFunc)
+
+	logData, err := ioutil.ReadFile(logFilePath)
+	require.NoError(t, err)
+
+	jsonData := make(map[string]interface{})
+	err = json.Unmarshal(logData, &jsonData)
+	require.NoError(t, err)
+
+	assert.Equal(t, "VersionTLS12", jsonData[TLSVersion])
+	assert.Equal(t, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", jsonData[TLSCipher])
+	assert.Equal(t, testHostname, jsonData[TLSServerName])
+	assert.Equal(t, true, jsonData[TLSClientCertPresented])
+	assert.Equal(t, false, jsonData[TLSClientCertVerified])
+}
+
+func TestLoggerJSONWithRequestID(t *testing.T) {
+	tmpDir := createTempDir(t, JSONFormat)
+	defer os.RemoveAll(tmpDir)
+
+	logFilePath := filepath.Join(tmpDir, logFileNameSuffix)
+	logger, err := NewLogHandler(&types.AccessLog{FilePath: logFilePath, Format: JSONFormat})
+	require.NoError(t, err)
+	defer logger.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "http://"+testHostname+"/"+testPath, nil)
+	req.Header.Set("X-Request-Id", "foo")
+
+	requestid.New("").ServeHTTP(httptest.NewRecorder(), req, func(rw http.ResponseWriter, req *http.Request) {
+		logger.ServeHTTP(rw, req, logWriterTestHandlerFunc)
+	})
+
+	logData, err := ioutil.ReadFile(logFilePath)
+	require.NoError(t, err)
+
+	jsonData := make(map[string]interface{})
+	err = json.Unmarshal(logData, &jsonData)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo", jsonData[RequestID])
+}
+
 func TestNewLogHandlerOutputStdout(t *testing.T) {
 	file, restoreStdout := captureStdout(t)
 	defer restoreStdout()
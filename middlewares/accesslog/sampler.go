@@ -0,0 +1,35 @@
+package accesslog
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/containous/traefik/types"
+)
+
+// sampler selects the requests written to the access log, logging one request out of rate.
+type sampler struct {
+	rate        uint64
+	successOnly bool
+	count       uint64
+}
+
+func newSampler(config *types.AccessLogSampling) (*sampler, error) {
+	if config.Rate < 0 {
+		return nil, fmt.Errorf("invalid sampling rate %d", config.Rate)
+	}
+	if config.Rate <= 1 {
+		return nil, nil
+	}
+	return &sampler{rate: uint64(config.Rate), successOnly: config.SuccessOnly}, nil
+}
+
+// sample reports whether the request answered with the status code is logged,
+// and the number of requests it stands for.
+func (s *sampler) sample(status int) (bool, int) {
+	if s.successOnly && (status < 200 || status > 299) {
+		return true, 1
+	}
+	count := atomic.AddUint64(&s.count, 1)
+	return (count-1)%s.rate == 0, int(s.rate)
+}
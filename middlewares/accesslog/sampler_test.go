@@ -0,0 +1,132 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSampler(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.AccessLogSampling
+		expectedSampler bool
+		expectedError   string
+	}{
+		{
+			desc:   "no rate",
+			config: &types.AccessLogSampling{},
+		},
+		{
+			desc:   "rate of one",
+			config: &types.AccessLogSampling{Rate: 1, SuccessOnly: true},
+		},
+		{
+			desc:            "rate",
+			config:          &types.AccessLogSampling{Rate: 10},
+			expectedSampler: true,
+		},
+		{
+			desc:          "negative rate",
+			config:        &types.AccessLogSampling{Rate: -1},
+			expectedError: "invalid sampling rate -1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := newSampler(test.config)
+			if len(test.expectedError) > 0 {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSampler, s != nil)
+		})
+	}
+}
+
+func TestSamplerSample(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         *types.AccessLogSampling
+		statuses       []int
+		expectedLogged []bool
+		expectedRates  []int
+	}{
+		{
+			desc:           "all statuses sampled",
+			config:         &types.AccessLogSampling{Rate: 3},
+			statuses:       []int{200, 500, 500, 200, 404, 200, 200},
+			expectedLogged: []bool{true, false, false, true, false, false, true},
+			expectedRates:  []int{3, 3, 3, 3, 3, 3, 3},
+		},
+		{
+			desc:           "success only",
+			config:         &types.AccessLogSampling{Rate: 2, SuccessOnly: true},
+			statuses:       []int{200, 204, 500, 200, 302, 201},
+			expectedLogged: []bool{true, false, true, true, true, false},
+			expectedRates:  []int{2, 2, 1, 2, 1, 2},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := newSampler(test.config)
+			require.NoError(t, err)
+
+			var logged []bool
+			var rates []int
+			for _, status := range test.statuses {
+				ok, rate := s.sample(status)
+				logged = append(logged, ok)
+				rates = append(rates, rate)
+			}
+			assert.Equal(t, test.expectedLogged, logged)
+			assert.Equal(t, test.expectedRates, rates)
+		})
+	}
+}
+
+func TestLogHandlerWithSampling(t *testing.T) {
+	s, err := newSampler(&types.AccessLogSampling{Rate: 2, SuccessOnly: true})
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	logHandler := &LogHandler{
+		logger: &logrus.Logger{
+			Out:       output,
+			Formatter: new(logrus.JSONFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.InfoLevel,
+		},
+		sampler: s,
+	}
+
+	for _, status := range []int{http.StatusOK, http.StatusOK, http.StatusBadGateway, http.StatusOK} {
+		status := status
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/foo", nil)
+		logHandler.ServeHTTP(httptest.NewRecorder(), req, func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(status)
+		})
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Contains(t, string(lines[0]), `"SampleRate":2`)
+	assert.Contains(t, string(lines[1]), `"DownstreamStatus":502`)
+	assert.Contains(t, string(lines[1]), `"SampleRate":1`)
+	assert.Contains(t, string(lines[2]), `"SampleRate":2`)
+}
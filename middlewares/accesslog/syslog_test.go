@@ -0,0 +1,105 @@
+// +build !windows
+
+package accesslog
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerSyslog(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		facility         string
+		tag              string
+		expectedPriority string
+		expectedTag      string
+	}{
+		{
+			desc:             "default facility and tag",
+			expectedPriority: "<134>",
+			expectedTag:      "traefik",
+		},
+		{
+			desc:             "custom facility and tag",
+			facility:         "local7",
+			tag:              "access",
+			expectedPriority: "<190>",
+			expectedTag:      "access",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tmpDir := createTempDir(t, CommonFormat)
+			defer os.RemoveAll(tmpDir)
+
+			config := &types.AccessLog{
+				FilePath: filepath.Join(tmpDir, "access.log"),
+				Format:   CommonFormat,
+				Syslog: &types.AccessLogSyslog{
+					Address:  conn.LocalAddr().String(),
+					Network:  "udp",
+					Facility: test.facility,
+					Tag:      test.tag,
+				},
+			}
+			doLogging(t, config)
+
+			buf := make([]byte, 2048)
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+
+			header := regexp.MustCompile(`^` + regexp.QuoteMeta(test.expectedPriority) + `\S+ \S+ ` + regexp.QuoteMeta(test.expectedTag) + `\[\d+\]: `)
+			message := buf[:n]
+			loc := header.FindIndex(message)
+			require.NotNil(t, loc, "unexpected syslog message: %s", message)
+			assertValidLogData(t, message[loc[1]:])
+
+			logData, err := ioutil.ReadFile(config.FilePath)
+			require.NoError(t, err)
+			assertValidLogData(t, logData)
+		})
+	}
+}
+
+func TestNewLogHandlerSyslogErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		syslog *types.AccessLogSyslog
+	}{
+		{
+			desc:   "unsupported network",
+			syslog: &types.AccessLogSyslog{Address: "127.0.0.1:514", Network: "unix"},
+		},
+		{
+			desc:   "unsupported facility",
+			syslog: &types.AccessLogSyslog{Address: "127.0.0.1:514", Facility: "foo"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewLogHandler(&types.AccessLog{Format: CommonFormat, Syslog: test.syslog})
+			assert.Error(t, err)
+		})
+	}
+}
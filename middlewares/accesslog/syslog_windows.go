@@ -0,0 +1,14 @@
+// +build windows
+
+package accesslog
+
+import (
+	"errors"
+	"io"
+
+	"github.com/containous/traefik/types"
+)
+
+func newSyslogWriter(config *types.AccessLogSyslog) (io.WriteCloser, error) {
+	return nil, errors.New("syslog access logs are not supported on Windows")
+}
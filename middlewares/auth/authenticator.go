@@ -10,6 +10,7 @@ import (
 	goauth "github.com/abbot/go-http-auth"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares/tracing"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/urfave/negroni"
 )
@@ -53,6 +54,9 @@ func NewAuthenticator(authConfig *types.Auth, tracingMiddleware *tracing.Tracing
 		tracingAuthenticator.name = "Auth Digest"
 		tracingAuthenticator.clientSpanKind = false
 	} else if authConfig.Forward != nil {
+		if err := traefikTls.CheckSubjectAttributes(authConfig.Forward.PassClientCertSubject); err != nil {
+			return nil, err
+		}
 		tracingAuthenticator.handler = createAuthForwardHandler(authConfig)
 		tracingAuthenticator.name = "Auth Forward"
 		tracingAuthenticator.clientSpanKind = true
@@ -1,20 +1,25 @@
 package auth
 
 import (
+	"encoding/pem"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares/tracing"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/vulcand/oxy/forward"
 	"github.com/vulcand/oxy/utils"
 )
 
 const (
-	xForwardedURI = "X-Forwarded-Uri"
+	xForwardedURI                  = "X-Forwarded-Uri"
+	xForwardedTLSClientCert        = "X-Forwarded-Tls-Client-Cert"
+	xForwardedTLSClientCertSubject = "X-Forwarded-Tls-Client-Cert-Subject"
 )
 
 // Forward the authentication to a external server
@@ -45,6 +50,7 @@ func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next
 	}
 
 	writeHeader(r, forwardReq, config.TrustForwardHeader)
+	writeClientCertHeaders(r, forwardReq, config)
 
 	tracing.InjectRequestHeaders(forwardReq)
 
@@ -137,3 +143,26 @@ func writeHeader(req *http.Request, forwardReq *http.Request, trustForwardHeader
 		forwardReq.Header.Del(xForwardedURI)
 	}
 }
+
+// writeClientCertHeaders passes the verified client certificate of the request, URL escaped in PEM format,
+// and the selected attributes of its subject, the headers sent by the client being removed
+func writeClientCertHeaders(req *http.Request, forwardReq *http.Request, config *types.Forward) {
+	if !config.PassClientCert && len(config.PassClientCertSubject) == 0 {
+		return
+	}
+
+	forwardReq.Header.Del(xForwardedTLSClientCert)
+	forwardReq.Header.Del(xForwardedTLSClientCertSubject)
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.PeerCertificates) == 0 {
+		return
+	}
+
+	cert := req.TLS.PeerCertificates[0]
+	if config.PassClientCert {
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		forwardReq.Header.Set(xForwardedTLSClientCert, url.QueryEscape(string(certPEM)))
+	}
+	if subject := traefikTls.FormatSubject(cert.Subject, config.PassClientCertSubject); len(subject) > 0 {
+		forwardReq.Header.Set(xForwardedTLSClientCertSubject, subject)
+	}
+}
@@ -1,10 +1,15 @@
 package auth
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/containous/traefik/middlewares/tracing"
@@ -268,3 +273,94 @@ func Test_writeHeader(t *testing.T) {
 		})
 	}
 }
+
+func Test_writeClientCertHeaders(t *testing.T) {
+	cert := &x509.Certificate{
+		Raw:     []byte("certificate"),
+		Subject: pkix.Name{CommonName: "client1", Organization: []string{"Other", "PartnerA"}},
+	}
+	certPEM := url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	verified := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	testCases := []struct {
+		name            string
+		config          *types.Forward
+		state           *tls.ConnectionState
+		expectedHeaders map[string]string
+	}{
+		{
+			name:   "certificate and subject",
+			config: &types.Forward{PassClientCert: true, PassClientCertSubject: []string{"cn", "O", "OU"}},
+			state:  verified,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Tls-Client-Cert":         certPEM,
+				"X-Forwarded-Tls-Client-Cert-Subject": "CN=client1,O=Other,O=PartnerA",
+			},
+		},
+		{
+			name:   "subject only",
+			config: &types.Forward{PassClientCertSubject: []string{"CN"}},
+			state:  verified,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Tls-Client-Cert":         "",
+				"X-Forwarded-Tls-Client-Cert-Subject": "CN=client1",
+			},
+		},
+		{
+			name:   "unverified certificate",
+			config: &types.Forward{PassClientCert: true, PassClientCertSubject: []string{"CN"}},
+			state:  &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Tls-Client-Cert":         "",
+				"X-Forwarded-Tls-Client-Cert-Subject": "",
+			},
+		},
+		{
+			name:   "no TLS",
+			config: &types.Forward{PassClientCert: true, PassClientCertSubject: []string{"CN"}},
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Tls-Client-Cert":         "",
+				"X-Forwarded-Tls-Client-Cert-Subject": "",
+			},
+		},
+		{
+			name:   "not passed",
+			config: &types.Forward{},
+			state:  verified,
+			expectedHeaders: map[string]string{
+				"X-Forwarded-Tls-Client-Cert":         "spoofed",
+				"X-Forwarded-Tls-Client-Cert-Subject": "spoofed",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "https://foo.bar/path", nil)
+			req.Header.Set("X-Forwarded-Tls-Client-Cert", "spoofed")
+			req.Header.Set("X-Forwarded-Tls-Client-Cert-Subject", "spoofed")
+			req.TLS = test.state
+
+			forwardReq := testhelpers.MustNewRequest(http.MethodGet, "http://auth.bar/", nil)
+			writeHeader(req, forwardReq, false)
+			writeClientCertHeaders(req, forwardReq, test.config)
+
+			for key, value := range test.expectedHeaders {
+				assert.Equal(t, value, forwardReq.Header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestForwardAuthInvalidClientCertSubject(t *testing.T) {
+	_, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address:               "http://auth.bar",
+			PassClientCertSubject: []string{"CN", "Email"},
+		},
+	}, &tracing.Tracing{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/tracing"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/dgrijalva/jwt-go"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	jwksCacheDuration        = time.Hour
+	jwksMinRefreshInterval   = time.Minute
+	jwksFetchTimeout         = 10 * time.Second
+	bearerAuthenticate       = `Bearer realm="traefik"`
+	invalidTokenAuthenticate = `Bearer realm="traefik", error="invalid_token"`
+)
+
+var (
+	hmacMethods      = []string{"HS256", "HS384", "HS512"}
+	publicKeyMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+	errMissingToken  = errors.New("no bearer token")
+)
+
+// JWTAuth is a middleware rejecting with a 401 the requests without a valid JSON Web Token in their Authorization header.
+// The claims of the token selected by the configuration are forwarded as headers.
+type JWTAuth struct {
+	config *types.JWTAuth
+	parser *jwt.Parser
+	secret []byte
+	keys   *jwksCache
+}
+
+// NewJWTAuth creates a JWTAuth, whose keys are only fetched from the JWKS URL when the first token is validated
+func NewJWTAuth(config *types.JWTAuth) (*JWTAuth, error) {
+	if config == nil {
+		return nil, errors.New("jwt auth is nil")
+	}
+
+	jwtAuth := &JWTAuth{config: config}
+	switch {
+	case len(config.JWKSURL) > 0 && len(config.Secret) > 0:
+		return nil, errors.New("both a JWKS URL and a secret are defined")
+	case len(config.JWKSURL) > 0:
+		u, err := url.Parse(config.JWKSURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("invalid JWKS URL %q", config.JWKSURL)
+		}
+		jwtAuth.parser = &jwt.Parser{ValidMethods: publicKeyMethods}
+		jwtAuth.keys = newJWKSCache(config.JWKSURL)
+	case len(config.Secret) > 0:
+		jwtAuth.parser = &jwt.Parser{ValidMethods: hmacMethods}
+		jwtAuth.secret = []byte(config.Secret)
+	default:
+		return nil, errors.New("neither a JWKS URL nor a secret is defined")
+	}
+
+	for claim, header := range config.ForwardClaims {
+		if len(strings.TrimSpace(claim)) == 0 || len(strings.TrimSpace(header)) == 0 {
+			return nil, fmt.Errorf("invalid forwarded claim %q with header %q", claim, header)
+		}
+	}
+
+	return jwtAuth, nil
+}
+
+func (j *JWTAuth) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	claims, err := j.validate(req)
+	if err != nil {
+		tracing.SetErrorAndDebugLog(req, "JWT auth failed: %v", err)
+		if err == errMissingToken {
+			rw.Header().Set("WWW-Authenticate", bearerAuthenticate)
+		} else {
+			rw.Header().Set("WWW-Authenticate", invalidTokenAuthenticate)
+		}
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	for claim, header := range j.config.ForwardClaims {
+		// the forwarded claims can't be set by the clients
+		req.Header.Del(header)
+		if value, ok := claimValue(claims[claim]); ok {
+			req.Header.Set(header, value)
+		}
+	}
+
+	log.Debugf("JWT auth succeeded")
+	next.ServeHTTP(rw, req)
+}
+
+// validate returns the claims of the bearer token of the request, once its signature and claims are verified
+func (j *JWTAuth) validate(req *http.Request) (jwt.MapClaims, error) {
+	authorization := req.Header.Get("Authorization")
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || len(strings.TrimSpace(parts[1])) == 0 {
+		return nil, errMissingToken
+	}
+
+	token, err := j.parser.Parse(strings.TrimSpace(parts[1]), j.key)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("unexpected claims")
+	}
+	if !claims.VerifyExpiresAt(jwt.TimeFunc().Unix(), true) {
+		return nil, errors.New("token without expiration time")
+	}
+	if len(j.config.Issuer) > 0 && !claims.VerifyIssuer(j.config.Issuer, true) {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+	if len(j.config.Audience) > 0 && !hasAudience(claims, j.config.Audience) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+	return claims, nil
+}
+
+// key returns the key verifying the signature of the token
+func (j *JWTAuth) key(token *jwt.Token) (interface{}, error) {
+	if j.keys == nil {
+		return j.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	return j.keys.key(kid)
+}
+
+// hasAudience checks that the audience of the claims, either a string or an array of strings, has the expected one
+func hasAudience(claims jwt.MapClaims, expected string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == expected
+	case []interface{}:
+		for _, value := range aud {
+			if value == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimValue returns the value of a claim forwarded in a header, the values other than strings being JSON encoded
+func claimValue(claim interface{}) (string, bool) {
+	switch value := claim.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	}
+}
+
+// jwksCache holds the public keys published at a JWKS URL, which are fetched again once expired,
+// or when a token is signed with an unknown key, at most once per minRefreshInterval.
+// The keys are fetched by one request at a time, outside of the lock: the expired keys are still served meanwhile,
+// and only the requests with a token signed by an unknown key wait for the fetch.
+// The previous keys are kept while the keys can't be fetched.
+type jwksCache struct {
+	url                string
+	client             *http.Client
+	minRefreshInterval time.Duration
+
+	lock       sync.Mutex
+	keys       map[string]interface{}
+	expiration time.Time
+	lastFetch  time.Time
+	// fetching is closed once the keys being fetched are cached, and is nil when no fetch is in progress
+	fetching chan struct{}
+}
+
+func newJWKSCache(jwksURL string) *jwksCache {
+	return &jwksCache{
+		url:                jwksURL,
+		client:             &http.Client{Timeout: jwksFetchTimeout},
+		minRefreshInterval: jwksMinRefreshInterval,
+	}
+}
+
+// key returns the key with the given ID, or the only key of the set if the token has no key ID
+func (c *jwksCache) key(kid string) (interface{}, error) {
+	c.lock.Lock()
+	now := time.Now()
+	key, ok := c.lookup(kid)
+	if (!ok || now.After(c.expiration)) && c.fetching == nil && now.Sub(c.lastFetch) >= c.minRefreshInterval {
+		c.lastFetch = now
+		c.fetching = make(chan struct{})
+		fetching := c.fetching
+		safe.Go(func() {
+			c.refresh(fetching)
+		})
+	}
+	fetching := c.fetching
+	c.lock.Unlock()
+
+	if !ok && fetching != nil {
+		<-fetching
+		c.lock.Lock()
+		key, ok = c.lookup(kid)
+		c.lock.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the keys, then replaces the cached ones and closes fetching
+func (c *jwksCache) refresh(fetching chan struct{}) {
+	keys, err := c.fetch()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		log.Warnf("Unable to fetch the JWKS %s: %v", c.url, err)
+	} else {
+		c.keys = keys
+		c.expiration = time.Now().Add(jwksCacheDuration)
+	}
+	c.fetching = nil
+	close(fetching)
+}
+
+func (c *jwksCache) lookup(kid string) (interface{}, bool) {
+	if key, ok := c.keys[kid]; ok {
+		return key, true
+	}
+	if len(kid) == 0 && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// fetch returns the public signing keys of the JWKS, by key ID, the keys which can't be decoded being skipped
+func (c *jwksCache) fetch() (map[string]interface{}, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, raw := range set.Keys {
+		var jwk jose.JsonWebKey
+		if err := jwk.UnmarshalJSON(raw); err != nil {
+			log.Debugf("Skipping a key of the JWKS %s: %v", c.url, err)
+			continue
+		}
+		if len(jwk.Use) > 0 && jwk.Use != "sig" {
+			continue
+		}
+
+		switch key := jwk.Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys[jwk.KeyID] = key
+		case *rsa.PrivateKey:
+			keys[jwk.KeyID] = &key.PublicKey
+		case *ecdsa.PrivateKey:
+			keys[jwk.KeyID] = &key.PublicKey
+		default:
+			log.Debugf("Skipping the key %q of the JWKS %s: not a public key", jwk.KeyID, c.url)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing key")
+	}
+	return keys, nil
+}
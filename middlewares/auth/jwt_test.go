@@ -0,0 +1,392 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+	"gopkg.in/square/go-jose.v1"
+)
+
+func TestNewJWTAuthInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.JWTAuth
+	}{
+		{
+			desc:   "no key",
+			config: &types.JWTAuth{Issuer: "issuer"},
+		},
+		{
+			desc:   "JWKS URL and secret",
+			config: &types.JWTAuth{JWKSURL: "https://issuer/jwks.json", Secret: "secret"},
+		},
+		{
+			desc:   "invalid JWKS URL",
+			config: &types.JWTAuth{JWKSURL: "/jwks.json"},
+		},
+		{
+			desc:   "forwarded claim without header",
+			config: &types.JWTAuth{Secret: "secret", ForwardClaims: map[string]string{"sub": ""}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewJWTAuth(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJWTAuthSecret(t *testing.T) {
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":    "user1",
+			"iss":    "issuer",
+			"aud":    []string{"other", "api"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"groups": []string{"admin", "dev"},
+		}
+	}
+
+	testCases := []struct {
+		desc                 string
+		authorization        func(t *testing.T) string
+		expectedStatus       int
+		expectedAuthenticate string
+		expectedHeaders      map[string]string
+	}{
+		{
+			desc: "valid token",
+			authorization: func(t *testing.T) string {
+				return "Bearer " + signHMAC(t, validClaims(), "secret")
+			},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"X-User": "user1", "X-Groups": `["admin","dev"]`},
+		},
+		{
+			desc: "no token",
+			authorization: func(t *testing.T) string {
+				return ""
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik"`,
+		},
+		{
+			desc: "basic authorization",
+			authorization: func(t *testing.T) string {
+				return "Basic dXNlcjE6cGFzc3dvcmQ="
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik"`,
+		},
+		{
+			desc: "invalid signature",
+			authorization: func(t *testing.T) string {
+				return "Bearer " + signHMAC(t, validClaims(), "other")
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc: "expired token",
+			authorization: func(t *testing.T) string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return "Bearer " + signHMAC(t, claims, "secret")
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc: "token without expiration time",
+			authorization: func(t *testing.T) string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return "Bearer " + signHMAC(t, claims, "secret")
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc: "unexpected issuer",
+			authorization: func(t *testing.T) string {
+				claims := validClaims()
+				claims["iss"] = "other"
+				return "Bearer " + signHMAC(t, claims, "secret")
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc: "unexpected audience",
+			authorization: func(t *testing.T) string {
+				claims := validClaims()
+				claims["aud"] = "other"
+				return "Bearer " + signHMAC(t, claims, "secret")
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc: "unsigned token",
+			authorization: func(t *testing.T) string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				require.NoError(t, err)
+				return "Bearer " + token
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedAuthenticate: `Bearer realm="traefik", error="invalid_token"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			jwtAuth, err := NewJWTAuth(&types.JWTAuth{
+				Secret:        "secret",
+				Issuer:        "issuer",
+				Audience:      "api",
+				ForwardClaims: map[string]string{"sub": "X-User", "groups": "X-Groups", "email": "X-Email"},
+			})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			if authorization := test.authorization(t); len(authorization) > 0 {
+				req.Header.Set("Authorization", authorization)
+			}
+			// set by the client, and not forwarded without a matching claim
+			req.Header.Set("X-Email", "admin@localhost")
+
+			var forwarded http.Header
+			recorder := httptest.NewRecorder()
+			jwtAuth.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {
+				forwarded = req.Header
+			})
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedAuthenticate, recorder.Header().Get("WWW-Authenticate"))
+			if test.expectedStatus == http.StatusOK {
+				require.NotNil(t, forwarded)
+				for header, value := range test.expectedHeaders {
+					assert.Equal(t, value, forwarded.Get(header), header)
+				}
+				assert.Empty(t, forwarded.Get("X-Email"))
+			} else {
+				assert.Nil(t, forwarded)
+			}
+		})
+	}
+}
+
+func TestJWTAuthJWKS(t *testing.T) {
+	key1 := generateECDSAKey(t)
+	key2 := generateECDSAKey(t)
+
+	var lock sync.Mutex
+	var fetches int
+	jwks := jwksOf(t, map[string]*ecdsa.PrivateKey{"key1": key1})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		fetches++
+		rw.Write(jwks)
+	}))
+	defer server.Close()
+
+	fetchCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return fetches
+	}
+
+	jwtAuth, err := NewJWTAuth(&types.JWTAuth{JWKSURL: server.URL})
+	require.NoError(t, err)
+
+	n := negroni.New(jwtAuth)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+
+	serve := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("Authorization", authorization)
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	claims := jwt.MapClaims{"sub": "user1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	// the keys are cached
+	assert.Equal(t, http.StatusOK, serve("Bearer "+signECDSA(t, claims, "key1", key1)))
+	assert.Equal(t, http.StatusOK, serve("Bearer "+signECDSA(t, claims, "key1", key1)))
+	assert.Equal(t, 1, fetchCount())
+
+	// the secret of an HMAC token is not taken from the public keys
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "+signHMAC(t, claims, "secret")))
+
+	// the keys are not fetched again right after they were fetched
+	lock.Lock()
+	jwks = jwksOf(t, map[string]*ecdsa.PrivateKey{"key1": key1, "key2": key2})
+	lock.Unlock()
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "+signECDSA(t, claims, "key2", key2)))
+	assert.Equal(t, 1, fetchCount())
+
+	// the rotated keys are fetched when a token is signed with an unknown key
+	jwtAuth.keys.minRefreshInterval = 0
+	assert.Equal(t, http.StatusOK, serve("Bearer "+signECDSA(t, claims, "key2", key2)))
+	assert.Equal(t, 2, fetchCount())
+
+	// a token signed with a key of the set, but with another key ID, is rejected
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "+signECDSA(t, claims, "key1", key2)))
+}
+
+func TestJWTAuthJWKSUnavailable(t *testing.T) {
+	key := generateECDSAKey(t)
+	jwks := jwksOf(t, map[string]*ecdsa.PrivateKey{"key": key})
+
+	var lock sync.Mutex
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !available {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Write(jwks)
+	}))
+	defer server.Close()
+
+	jwtAuth, err := NewJWTAuth(&types.JWTAuth{JWKSURL: server.URL})
+	require.NoError(t, err)
+
+	// the token without key ID is verified with the only key of the set
+	token := signECDSA(t, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}, "", key)
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		jwtAuth.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {})
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, serve())
+
+	// the expired keys are kept while they can't be fetched again
+	lock.Lock()
+	available = false
+	lock.Unlock()
+	jwtAuth.keys.expiration = time.Now().Add(-time.Minute)
+	jwtAuth.keys.minRefreshInterval = 0
+	assert.Equal(t, http.StatusOK, serve())
+}
+
+func TestJWTAuthJWKSConcurrentFetch(t *testing.T) {
+	key1 := generateECDSAKey(t)
+	key2 := generateECDSAKey(t)
+
+	var lock sync.Mutex
+	var fetches int
+	jwks := jwksOf(t, map[string]*ecdsa.PrivateKey{"key1": key1})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		lock.Lock()
+		fetches++
+		body := jwks
+		blocked := fetches > 1
+		lock.Unlock()
+		if blocked {
+			<-release
+		}
+		rw.Write(body)
+	}))
+	defer server.Close()
+
+	jwtAuth, err := NewJWTAuth(&types.JWTAuth{JWKSURL: server.URL})
+	require.NoError(t, err)
+
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		jwtAuth.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {})
+		return recorder.Code
+	}
+	claims := jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}
+	assert.Equal(t, http.StatusOK, serve(signECDSA(t, claims, "key1", key1)))
+
+	// the expired keys are served while the keys are fetched again
+	lock.Lock()
+	jwks = jwksOf(t, map[string]*ecdsa.PrivateKey{"key1": key1, "key2": key2})
+	lock.Unlock()
+	jwtAuth.keys.lock.Lock()
+	jwtAuth.keys.expiration = time.Now().Add(-time.Minute)
+	jwtAuth.keys.minRefreshInterval = 0
+	jwtAuth.keys.lock.Unlock()
+	assert.Equal(t, http.StatusOK, serve(signECDSA(t, claims, "key1", key1)))
+
+	// the tokens signed with an unknown key wait for the keys being fetched
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve(signECDSA(t, claims, "key2", key2))
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK}, codes)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 2, fetches)
+}
+
+func generateECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func jwksOf(t *testing.T, keys map[string]*ecdsa.PrivateKey) []byte {
+	set := jose.JsonWebKeySet{}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jose.JsonWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: "ES256", Use: "sig"})
+	}
+	jwks, err := json.Marshal(set)
+	require.NoError(t, err)
+	return jwks
+}
+
+func signHMAC(t *testing.T, claims jwt.MapClaims, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func signECDSA(t *testing.T, claims jwt.MapClaims, kid string, key *ecdsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	if len(kid) > 0 {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
@@ -0,0 +1,434 @@
+package middlewares
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	// DefaultCacheMaxSize is the default size of all the responses cached by a frontend, in bytes
+	DefaultCacheMaxSize int64 = 32 * 1024 * 1024
+	// DefaultCacheMaxBodySize is the default size of the largest response body cached by a frontend, in bytes
+	DefaultCacheMaxBodySize int64 = 1024 * 1024
+
+	cacheStatusHeader = "X-Cache"
+	cacheMaxVariants  = 8
+)
+
+// cacheableStatusCodes are the status codes of the responses which can be cached
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// Cache is a middleware caching in memory the responses of the next handler to the GET and HEAD requests,
+// which are sent with an X-Cache: HIT header while they are fresh, the forwarded requests being sent with an X-Cache: MISS header.
+// The responses are fresh according to their Cache-Control s-maxage or max-age directives, or their Expires header,
+// or during the TTL without any of them, and are not cached without TTL.
+// The requests with an Authorization header, or a Cache-Control: no-store header, are not cached.
+// The least recently used responses are evicted once the size of the cached responses exceeds the maximum size.
+type Cache struct {
+	next                 http.Handler
+	headers              []string
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+	maxSize              int64
+	maxBodySize          int64
+
+	lock    sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// cacheEntry holds the responses cached under a key, one per values of the headers listed in their Vary header
+type cacheEntry struct {
+	key       string
+	responses []*cachedResponse
+}
+
+type cachedResponse struct {
+	vary       []string
+	varyValues []string
+	code       int
+	header     http.Header
+	body       []byte
+	size       int64
+	// date is the time at which the response was generated, its Age header being taken into account
+	date         time.Time
+	expiration   time.Time
+	noStale      bool
+	revalidating bool
+}
+
+// NewCache creates a Cache in front of next
+func NewCache(next http.Handler, config *types.Cache) (*Cache, error) {
+	ttl, err := parseCacheDuration("TTL", config.TTL)
+	if err != nil {
+		return nil, err
+	}
+	staleWhileRevalidate, err := parseCacheDuration("stale while revalidate duration", config.StaleWhileRevalidate)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := config.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultCacheMaxSize
+	}
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultCacheMaxBodySize
+	}
+	if maxBodySize > maxSize {
+		return nil, fmt.Errorf("maximum body size %d larger than the maximum size %d", maxBodySize, maxSize)
+	}
+
+	headers := make([]string, 0, len(config.Headers))
+	for _, header := range config.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(header))
+	}
+
+	return &Cache{
+		next:                 next,
+		headers:              headers,
+		ttl:                  ttl,
+		staleWhileRevalidate: staleWhileRevalidate,
+		maxSize:              maxSize,
+		maxBodySize:          maxBodySize,
+		entries:              make(map[string]*list.Element),
+		lru:                  list.New(),
+	}, nil
+}
+
+func parseCacheDuration(name string, value string) (time.Duration, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", name, value)
+	}
+	return duration, nil
+}
+
+func (c *Cache) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !isCacheable(req) {
+		c.next.ServeHTTP(rw, req)
+		return
+	}
+
+	key := c.key(req)
+	now := time.Now()
+	if !hasCacheControlDirective(req.Header, "no-cache") {
+		if response, stale := c.get(key, req, now); response != nil {
+			c.serve(rw, req, response, now, stale)
+			return
+		}
+	}
+
+	rw.Header().Set(cacheStatusHeader, "MISS")
+	writer := newSingleFlightResponseWriter(rw, c.maxBodySize)
+	c.next.ServeHTTP(writer, req)
+	if req.Context().Err() == nil {
+		c.store(key, req, writer, now)
+	}
+}
+
+// get returns the cached response to the request, if any, and whether it is stale.
+// A stale response is fetched again in the background, once at a time.
+func (c *Cache) get(key string, req *http.Request, now time.Time) (*cachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	for _, response := range element.Value.(*cacheEntry).responses {
+		if !response.matches(req) {
+			continue
+		}
+		if now.Before(response.expiration) {
+			c.lru.MoveToFront(element)
+			return response, false
+		}
+		if response.noStale || !now.Before(response.expiration.Add(c.staleWhileRevalidate)) {
+			return nil, false
+		}
+		c.lru.MoveToFront(element)
+		if !response.revalidating {
+			response.revalidating = true
+			go c.revalidate(key, req, response)
+		}
+		return response, true
+	}
+	return nil, false
+}
+
+// revalidate fetches again the stale response to the request, without any client waiting for it
+func (c *Cache) revalidate(key string, req *http.Request, stale *cachedResponse) {
+	revalidationReq := req.WithContext(context.Background())
+	revalidationURL := *req.URL
+	revalidationReq.URL = &revalidationURL
+	revalidationReq.Header = cloneHeader(req.Header)
+
+	log.Debugf("Revalidating the cached response to %s %s", req.Method, req.URL)
+	writer := newSingleFlightResponseWriter(&discardResponseWriter{header: make(http.Header)}, c.maxBodySize)
+	c.next.ServeHTTP(writer, revalidationReq)
+	c.store(key, revalidationReq, writer, time.Now())
+
+	c.lock.Lock()
+	stale.revalidating = false
+	c.lock.Unlock()
+}
+
+func (c *Cache) serve(rw http.ResponseWriter, req *http.Request, response *cachedResponse, now time.Time, stale bool) {
+	for name, values := range response.header {
+		rw.Header()[name] = append([]string(nil), values...)
+	}
+	rw.Header().Set(cacheStatusHeader, "HIT")
+	rw.Header().Set("Age", strconv.FormatInt(int64(now.Sub(response.date)/time.Second), 10))
+	if stale {
+		rw.Header().Add("Warning", `110 - "Response is Stale"`)
+	}
+	rw.WriteHeader(response.code)
+	if req.Method != http.MethodHead {
+		rw.Write(response.body)
+	}
+}
+
+// store caches the response recorded by writer, if it can be cached
+func (c *Cache) store(key string, req *http.Request, writer singleFlightResponseWriter, now time.Time) {
+	code := writer.getCode()
+	header := writer.getHeader()
+	if writer.isTruncated() || !cacheableStatusCodes[code] || !isShareable(header) || hasCacheControlDirective(header, "no-cache") {
+		return
+	}
+
+	vary := varyHeaders(header)
+	for _, name := range vary {
+		if name == "*" {
+			return
+		}
+	}
+
+	age := time.Duration(headerSeconds(header.Get("Age"))) * time.Second
+	lifetime := c.freshnessLifetime(header, now)
+	if lifetime <= age {
+		return
+	}
+
+	response := &cachedResponse{
+		vary:       vary,
+		varyValues: headerValues(req.Header, vary),
+		code:       code,
+		header:     header,
+		body:       writer.getBody(),
+		date:       now.Add(-age),
+		expiration: now.Add(lifetime - age),
+		noStale:    hasCacheControlDirective(header, "must-revalidate", "proxy-revalidate"),
+	}
+	response.header.Del("Age")
+	response.size = int64(len(response.body)) + cachedHeaderSize(response.header)
+	if response.size > c.maxSize {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(element)
+	} else {
+		element = c.lru.PushFront(&cacheEntry{key: key})
+		c.entries[key] = element
+	}
+	entry := element.Value.(*cacheEntry)
+
+	// the response replaces the one with the same variant, and the oldest variant once there are too many
+	responses := []*cachedResponse{response}
+	for _, cached := range entry.responses {
+		if cached.sameVariant(response) || len(responses) == cacheMaxVariants {
+			c.size -= cached.size
+			continue
+		}
+		responses = append(responses, cached)
+	}
+	entry.responses = responses
+	c.size += response.size
+
+	for c.size > c.maxSize {
+		oldest := c.lru.Back()
+		if oldest == element {
+			// the other variants of the response are evicted
+			for _, cached := range entry.responses[1:] {
+				c.size -= cached.size
+			}
+			entry.responses = entry.responses[:1]
+			break
+		}
+		c.remove(oldest)
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	for _, response := range entry.responses {
+		c.size -= response.size
+	}
+	c.lru.Remove(element)
+	delete(c.entries, entry.key)
+}
+
+// freshnessLifetime returns the duration during which the response is fresh, since it was generated
+func (c *Cache) freshnessLifetime(header http.Header, now time.Time) time.Duration {
+	if seconds, ok := cacheControlSeconds(header, "s-maxage"); ok {
+		return time.Duration(seconds) * time.Second
+	}
+	if seconds, ok := cacheControlSeconds(header, "max-age"); ok {
+		return time.Duration(seconds) * time.Second
+	}
+	if expires, ok := header["Expires"]; ok {
+		expiration, err := http.ParseTime(strings.Join(expires, ""))
+		if err != nil {
+			// an invalid expiration time means that the response is already expired
+			return 0
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		return expiration.Sub(date)
+	}
+	return c.ttl
+}
+
+func (c *Cache) key(req *http.Request) string {
+	parts := []string{req.Method, strings.ToLower(req.Host), req.URL.RequestURI()}
+	parts = append(parts, headerValues(req.Header, c.headers)...)
+	return strings.Join(parts, "\x00")
+}
+
+func (r *cachedResponse) matches(req *http.Request) bool {
+	for i, value := range headerValues(req.Header, r.vary) {
+		if value != r.varyValues[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *cachedResponse) sameVariant(other *cachedResponse) bool {
+	if strings.Join(r.vary, ",") != strings.Join(other.vary, ",") {
+		return false
+	}
+	for i, value := range r.varyValues {
+		if value != other.varyValues[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isCacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.ContentLength > 0 || len(req.TransferEncoding) > 0 {
+		return false
+	}
+	for _, header := range []string{"Authorization", "Range", "Upgrade"} {
+		if len(req.Header.Get(header)) > 0 {
+			return false
+		}
+	}
+	return !hasCacheControlDirective(req.Header, "no-store")
+}
+
+// varyHeaders returns the canonical names of the headers listed in the Vary header
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+func headerValues(header http.Header, names []string) []string {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, strings.Join(header[name], ","))
+	}
+	return values
+}
+
+func cachedHeaderSize(header http.Header) int64 {
+	var size int64
+	for name, values := range header {
+		for _, value := range values {
+			size += int64(len(name) + len(value))
+		}
+	}
+	return size
+}
+
+// cacheControlSeconds returns the number of seconds of a Cache-Control directive such as max-age=60
+func cacheControlSeconds(header http.Header, name string) (int64, bool) {
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+			if len(parts) == 2 && strings.EqualFold(parts[0], name) {
+				return headerSeconds(strings.Trim(parts[1], `"`)), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// headerSeconds parses a number of seconds, invalid and negative numbers being 0
+func headerSeconds(value string) int64 {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// discardResponseWriter is the writer of the responses sent to no client
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (rw *discardResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (rw *discardResponseWriter) WriteHeader(code int) {}
@@ -0,0 +1,422 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheTestRequest struct {
+	method string
+	path   string
+	header map[string]string
+}
+
+func TestCache(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         *types.Cache
+		responseHeader map[string]string
+		responseCode   int
+		requests       []cacheTestRequest
+		expectedStatus []string
+		expectedCalls  int32
+	}{
+		{
+			desc:           "TTL",
+			config:         &types.Cache{TTL: "1m"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "HIT"},
+			expectedCalls:  1,
+		},
+		{
+			desc:           "no TTL",
+			config:         &types.Cache{},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "max-age without TTL",
+			config:         &types.Cache{},
+			responseHeader: map[string]string{"Cache-Control": "public, max-age=60"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "HIT"},
+			expectedCalls:  1,
+		},
+		{
+			desc:           "max-age overriding the TTL",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Cache-Control": "max-age=0"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "s-maxage overriding max-age",
+			config:         &types.Cache{},
+			responseHeader: map[string]string{"Cache-Control": "max-age=0, s-maxage=60"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "HIT"},
+			expectedCalls:  1,
+		},
+		{
+			desc:           "expired Age",
+			config:         &types.Cache{},
+			responseHeader: map[string]string{"Cache-Control": "max-age=60", "Age": "60"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "Expires",
+			config: &types.Cache{},
+			responseHeader: map[string]string{
+				"Date":    "Mon, 01 Jan 2018 10:00:00 GMT",
+				"Expires": "Mon, 01 Jan 2018 10:01:00 GMT",
+			},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "HIT"},
+			expectedCalls:  1,
+		},
+		{
+			desc:           "invalid Expires",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Expires": "0"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "private response",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Cache-Control": "private, max-age=60"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "no-store response",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Cache-Control": "no-store"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "response with a cookie",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Set-Cookie": "session=foo"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "not cacheable status code",
+			config:         &types.Cache{TTL: "1m"},
+			responseCode:   http.StatusInternalServerError,
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "vary",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Vary": "Accept-Encoding"},
+			requests: []cacheTestRequest{
+				{header: map[string]string{"Accept-Encoding": "gzip"}},
+				{header: map[string]string{"Accept-Encoding": "gzip"}},
+				{header: map[string]string{"Accept-Encoding": "br"}},
+				{header: map[string]string{"Accept-Encoding": "br"}},
+				{header: map[string]string{"Accept-Encoding": "gzip"}},
+			},
+			expectedStatus: []string{"MISS", "HIT", "MISS", "HIT", "HIT"},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "vary on any header",
+			config:         &types.Cache{TTL: "1m"},
+			responseHeader: map[string]string{"Vary": "*"},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "key with selected headers",
+			config: &types.Cache{TTL: "1m", Headers: []string{"x-tenant"}},
+			requests: []cacheTestRequest{
+				{header: map[string]string{"X-Tenant": "a"}},
+				{header: map[string]string{"X-Tenant": "a"}},
+				{header: map[string]string{"X-Tenant": "b"}},
+			},
+			expectedStatus: []string{"MISS", "HIT", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "key with method, host and path",
+			config: &types.Cache{TTL: "1m"},
+			requests: []cacheTestRequest{
+				{path: "http://foo.com/a?q=1"},
+				{path: "http://FOO.com/a?q=1"},
+				{path: "http://foo.com/a?q=2"},
+				{path: "http://bar.com/a?q=1"},
+				{method: http.MethodHead, path: "http://foo.com/a?q=1"},
+			},
+			expectedStatus: []string{"MISS", "HIT", "MISS", "MISS", "MISS"},
+			expectedCalls:  4,
+		},
+		{
+			desc:   "request with authorization",
+			config: &types.Cache{TTL: "1m"},
+			requests: []cacheTestRequest{
+				{header: map[string]string{"Authorization": "Basic Zm9vOmJhcg=="}},
+				{header: map[string]string{"Authorization": "Basic Zm9vOmJhcg=="}},
+			},
+			expectedStatus: []string{"", ""},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "request with no-cache",
+			config: &types.Cache{TTL: "1m"},
+			requests: []cacheTestRequest{
+				{},
+				{header: map[string]string{"Cache-Control": "no-cache"}},
+				{},
+			},
+			expectedStatus: []string{"MISS", "MISS", "HIT"},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "request with no-store",
+			config: &types.Cache{TTL: "1m"},
+			requests: []cacheTestRequest{
+				{header: map[string]string{"Cache-Control": "no-store"}},
+				{},
+			},
+			expectedStatus: []string{"", "MISS"},
+			expectedCalls:  2,
+		},
+		{
+			desc:   "POST request",
+			config: &types.Cache{TTL: "1m"},
+			requests: []cacheTestRequest{
+				{method: http.MethodPost},
+				{method: http.MethodPost},
+			},
+			expectedStatus: []string{"", ""},
+			expectedCalls:  2,
+		},
+		{
+			desc:           "response larger than the maximum body size",
+			config:         &types.Cache{TTL: "1m", MaxBodySize: 2},
+			requests:       []cacheTestRequest{{}, {}},
+			expectedStatus: []string{"MISS", "MISS"},
+			expectedCalls:  2,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				for name, value := range test.responseHeader {
+					rw.Header().Set(name, value)
+				}
+				if test.responseCode != 0 {
+					rw.WriteHeader(test.responseCode)
+				}
+				rw.Write([]byte("body"))
+			})
+
+			cache, err := NewCache(next, test.config)
+			require.NoError(t, err)
+
+			for i, request := range test.requests {
+				method := request.method
+				if len(method) == 0 {
+					method = http.MethodGet
+				}
+				path := request.path
+				if len(path) == 0 {
+					path = "http://foo.com/"
+				}
+				req := httptest.NewRequest(method, path, nil)
+				for name, value := range request.header {
+					req.Header.Set(name, value)
+				}
+
+				recorder := httptest.NewRecorder()
+				cache.ServeHTTP(recorder, req)
+				assert.Equal(t, test.expectedStatus[i], recorder.Header().Get("X-Cache"), "request %d", i)
+				if method != http.MethodHead {
+					assert.Equal(t, "body", recorder.Body.String(), "request %d", i)
+				}
+			}
+			assert.Equal(t, test.expectedCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("Age", "10")
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"error":"not found"}`))
+	})
+	cache, err := NewCache(next, &types.Cache{})
+	require.NoError(t, err)
+
+	cache.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+
+	recorder := httptest.NewRecorder()
+	cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, `{"error":"not found"}`, recorder.Body.String())
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	age, err := strconv.Atoi(recorder.Header().Get("Age"))
+	require.NoError(t, err)
+	assert.True(t, age >= 10, "unexpected age %d", age)
+	assert.Empty(t, recorder.Header().Get("Warning"))
+}
+
+func TestCacheEviction(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write([]byte(strings.Repeat("a", 100)))
+	})
+	// each response takes 100 bytes for its body and 33 for its headers, so that two of them fit in the cache
+	cache, err := NewCache(next, &types.Cache{TTL: "1m", MaxSize: 300, MaxBodySize: 200})
+	require.NoError(t, err)
+
+	cacheStatus := func(path string) string {
+		recorder := httptest.NewRecorder()
+		cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com"+path, nil))
+		return recorder.Header().Get("X-Cache")
+	}
+
+	assert.Equal(t, "MISS", cacheStatus("/a"))
+	assert.Equal(t, "MISS", cacheStatus("/b"))
+	assert.Equal(t, "HIT", cacheStatus("/a"))
+	// the least recently used response is evicted
+	assert.Equal(t, "MISS", cacheStatus("/c"))
+	assert.Equal(t, "HIT", cacheStatus("/a"))
+	assert.Equal(t, "HIT", cacheStatus("/c"))
+	assert.Equal(t, "MISS", cacheStatus("/b"))
+	assert.True(t, cache.size <= 300, "unexpected cache size %d", cache.size)
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		cacheControl   string
+		expectedStatus string
+		expectedBody   string
+	}{
+		{
+			desc:           "stale response",
+			expectedStatus: "HIT",
+			expectedBody:   "response 1",
+		},
+		{
+			desc:           "must revalidate",
+			cacheControl:   "must-revalidate",
+			expectedStatus: "MISS",
+			expectedBody:   "response 2",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int32
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				call := atomic.AddInt32(&calls, 1)
+				if len(test.cacheControl) > 0 {
+					rw.Header().Set("Cache-Control", test.cacheControl)
+				}
+				fmt.Fprintf(rw, "response %d", call)
+			})
+			cache, err := NewCache(next, &types.Cache{TTL: "50ms", StaleWhileRevalidate: "1h"})
+			require.NoError(t, err)
+
+			serve := func() *httptest.ResponseRecorder {
+				recorder := httptest.NewRecorder()
+				cache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+				return recorder
+			}
+
+			serve()
+			time.Sleep(100 * time.Millisecond)
+
+			recorder := serve()
+			assert.Equal(t, test.expectedStatus, recorder.Header().Get("X-Cache"))
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			if test.expectedStatus != "HIT" {
+				return
+			}
+			assert.Equal(t, `110 - "Response is Stale"`, recorder.Header().Get("Warning"))
+
+			// the revalidated response replaces the stale one
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				recorder = serve()
+				if recorder.Body.String() == "response 2" || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+			assert.Equal(t, "response 2", recorder.Body.String())
+			assert.Empty(t, recorder.Header().Get("Warning"))
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestNewCacheInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.Cache
+	}{
+		{
+			desc:   "invalid TTL",
+			config: &types.Cache{TTL: "foo"},
+		},
+		{
+			desc:   "negative stale while revalidate duration",
+			config: &types.Cache{StaleWhileRevalidate: "-1s"},
+		},
+		{
+			desc:   "maximum body size larger than the maximum size",
+			config: &types.Cache{MaxSize: 1024, MaxBodySize: 2048},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCache(http.NotFoundHandler(), test.config)
+			assert.Error(t, err)
+		})
+	}
+}
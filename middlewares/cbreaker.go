@@ -2,23 +2,57 @@ package middlewares
 
 import (
 	"net/http"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/containous/traefik/middlewares/tracing"
+	gokitmetrics "github.com/go-kit/kit/metrics"
 	"github.com/vulcand/oxy/cbreaker"
 )
 
+// States of a circuit breaker
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerOpen     = "open"
+	CircuitBreakerHalfOpen = "half-open"
+)
+
+// circuitBreakerFallbackDuration is the time a tripped circuit breaker stays open before becoming half-open,
+// letting a growing ratio of the requests reach the backend to check whether it recovered.
+const circuitBreakerFallbackDuration = 10 * time.Second
+
 // CircuitBreaker holds the oxy circuit breaker.
 type CircuitBreaker struct {
 	circuitBreaker *cbreaker.CircuitBreaker
+	backendName    string
+	entryPointName string
+
+	lock            sync.RWMutex
+	tripped         bool
+	lastTransition  time.Time
+	circuitBreakers *CircuitBreakers
 }
 
-// NewCircuitBreaker returns a new CircuitBreaker.
-func NewCircuitBreaker(next http.Handler, expression string, options ...cbreaker.CircuitBreakerOption) (*CircuitBreaker, error) {
+// NewCircuitBreaker returns a new CircuitBreaker of the backend on the entrypoint, keeping track of its state.
+func NewCircuitBreaker(next http.Handler, backendName, entryPointName, expression string, options ...cbreaker.CircuitBreakerOption) (*CircuitBreaker, error) {
+	cb := &CircuitBreaker{
+		backendName:    backendName,
+		entryPointName: entryPointName,
+		lastTransition: time.Now(),
+	}
+
+	options = append(options,
+		cbreaker.FallbackDuration(circuitBreakerFallbackDuration),
+		cbreaker.OnTripped(circuitBreakerSideEffect(func() { cb.setTripped(true) })),
+		cbreaker.OnStandby(circuitBreakerSideEffect(func() { cb.setTripped(false) })),
+	)
 	circuitBreaker, err := cbreaker.New(next, expression, options...)
 	if err != nil {
 		return nil, err
 	}
-	return &CircuitBreaker{circuitBreaker}, nil
+	cb.circuitBreaker = circuitBreaker
+	return cb, nil
 }
 
 // NewCircuitBreakerOptions returns a new CircuitBreakerOption
@@ -34,3 +68,131 @@ func NewCircuitBreakerOptions(expression string) cbreaker.CircuitBreakerOption {
 func (cb *CircuitBreaker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	cb.circuitBreaker.ServeHTTP(rw, r)
 }
+
+// CircuitBreakerState is the state of a circuit breaker, with the time it was entered.
+type CircuitBreakerState struct {
+	Backend        string    `json:"backend"`
+	EntryPoint     string    `json:"entryPoint"`
+	State          string    `json:"state"`
+	LastTransition time.Time `json:"lastTransition"`
+}
+
+// State returns the current state of the circuit breaker.
+// A tripped circuit breaker is open during the fallback duration, then half-open until it recovers or trips again.
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+
+	state := CircuitBreakerState{
+		Backend:        cb.backendName,
+		EntryPoint:     cb.entryPointName,
+		State:          CircuitBreakerClosed,
+		LastTransition: cb.lastTransition,
+	}
+	if cb.tripped {
+		state.State = CircuitBreakerOpen
+		if halfOpen := cb.lastTransition.Add(circuitBreakerFallbackDuration); !time.Now().Before(halfOpen) {
+			state.State = CircuitBreakerHalfOpen
+			state.LastTransition = halfOpen
+		}
+	}
+	return state
+}
+
+func (cb *CircuitBreaker) isTripped() bool {
+	cb.lock.RLock()
+	defer cb.lock.RUnlock()
+	return cb.tripped
+}
+
+func (cb *CircuitBreaker) setTripped(tripped bool) {
+	cb.lock.Lock()
+	cb.tripped = tripped
+	cb.lastTransition = time.Now()
+	circuitBreakers := cb.circuitBreakers
+	cb.lock.Unlock()
+
+	if circuitBreakers != nil {
+		circuitBreakers.report(cb.backendName)
+	}
+}
+
+func (cb *CircuitBreaker) setCircuitBreakers(circuitBreakers *CircuitBreakers) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.circuitBreakers = circuitBreakers
+}
+
+// circuitBreakerSideEffect runs a function on the transitions of the oxy circuit breaker.
+type circuitBreakerSideEffect func()
+
+func (s circuitBreakerSideEffect) Exec() error {
+	s()
+	return nil
+}
+
+// CircuitBreakers holds the circuit breakers of the current configuration,
+// and reports with a gauge whether a circuit breaker of each backend is tripped, either open or half-open.
+type CircuitBreakers struct {
+	gauge           gokitmetrics.Gauge
+	lock            sync.RWMutex
+	circuitBreakers []*CircuitBreaker
+}
+
+// NewCircuitBreakers creates a CircuitBreakers reporting the tripped circuit breakers of each backend with gauge.
+func NewCircuitBreakers(gauge gokitmetrics.Gauge) *CircuitBreakers {
+	return &CircuitBreakers{gauge: gauge}
+}
+
+// Set replaces the circuit breakers of the previous configuration.
+func (c *CircuitBreakers) Set(circuitBreakers []*CircuitBreaker) {
+	c.lock.Lock()
+	previous := c.circuitBreakers
+	c.circuitBreakers = circuitBreakers
+	c.lock.Unlock()
+
+	backendNames := make(map[string]bool)
+	for _, cb := range previous {
+		cb.setCircuitBreakers(nil)
+		backendNames[cb.backendName] = true
+	}
+	for _, cb := range circuitBreakers {
+		cb.setCircuitBreakers(c)
+		backendNames[cb.backendName] = true
+	}
+	for backendName := range backendNames {
+		c.report(backendName)
+	}
+}
+
+// States returns the states of the circuit breakers, sorted by backend and entrypoint.
+func (c *CircuitBreakers) States() []CircuitBreakerState {
+	c.lock.RLock()
+	states := make([]CircuitBreakerState, 0, len(c.circuitBreakers))
+	for _, cb := range c.circuitBreakers {
+		states = append(states, cb.State())
+	}
+	c.lock.RUnlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Backend != states[j].Backend {
+			return states[i].Backend < states[j].Backend
+		}
+		return states[i].EntryPoint < states[j].EntryPoint
+	})
+	return states
+}
+
+func (c *CircuitBreakers) report(backendName string) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var tripped float64
+	for _, cb := range c.circuitBreakers {
+		if cb.backendName == backendName && cb.isTripped() {
+			tripped = 1
+			break
+		}
+	}
+	c.gauge.With("backend", backendName).Set(tripped)
+}
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerState(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		tripped                bool
+		sinceTransition        time.Duration
+		expectedState          string
+		expectedLastTransition time.Duration
+	}{
+		{
+			desc:          "closed",
+			expectedState: CircuitBreakerClosed,
+		},
+		{
+			desc:            "open",
+			tripped:         true,
+			sinceTransition: time.Second,
+			expectedState:   CircuitBreakerOpen,
+		},
+		{
+			desc:                   "half-open after the fallback duration",
+			tripped:                true,
+			sinceTransition:        circuitBreakerFallbackDuration + time.Second,
+			expectedState:          CircuitBreakerHalfOpen,
+			expectedLastTransition: circuitBreakerFallbackDuration,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cb, err := NewCircuitBreaker(http.NotFoundHandler(), "backend1", "http", "NetworkErrorRatio() > 0.5")
+			require.NoError(t, err)
+
+			transition := time.Now().Add(-test.sinceTransition)
+			cb.tripped = test.tripped
+			cb.lastTransition = transition
+
+			state := cb.State()
+			assert.Equal(t, "backend1", state.Backend)
+			assert.Equal(t, "http", state.EntryPoint)
+			assert.Equal(t, test.expectedState, state.State)
+			assert.Equal(t, transition.Add(test.expectedLastTransition), state.LastTransition)
+		})
+	}
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	})
+
+	cb, err := NewCircuitBreaker(handler, "backend1", "http", "ResponseCodeRatio(500, 600, 0, 600) > 0.5")
+	require.NoError(t, err)
+
+	gauge := &gaugeMock{}
+	circuitBreakers := NewCircuitBreakers(gauge)
+	circuitBreakers.Set([]*CircuitBreaker{cb})
+
+	value, labelValues := gauge.get()
+	assert.Equal(t, float64(0), value)
+	assert.Equal(t, []string{"backend", "backend1"}, labelValues)
+
+	// the ratio is only checked once the oxy circuit breaker has enough samples
+	for i := 0; i < 100 && !cb.isTripped(); i++ {
+		cb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil), nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	states := circuitBreakers.States()
+	require.Len(t, states, 1)
+	assert.Equal(t, CircuitBreakerOpen, states[0].State)
+
+	value, _ = gauge.get()
+	assert.Equal(t, float64(1), value)
+
+	circuitBreakers.Set(nil)
+	assert.Empty(t, circuitBreakers.States())
+
+	value, labelValues = gauge.get()
+	assert.Equal(t, float64(0), value)
+	assert.Equal(t, []string{"backend", "backend1"}, labelValues)
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/containous/traefik/middlewares/tracing"
+	traefikTls "github.com/containous/traefik/tls"
+)
+
+// ClientCertSubject is a middleware rejecting with a 403 the requests which were not sent with a verified client certificate
+// whose subject matches the expected one
+type ClientCertSubject struct {
+	Handler http.Handler
+	Matcher *traefikTls.SubjectMatcher
+}
+
+func (c *ClientCertSubject) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !c.Matcher.Match(r.TLS) {
+		tracing.SetErrorAndDebugLog(r, "no verified client certificate matching the expected subject - rejecting")
+		reject(w)
+		return
+	}
+	c.Handler.ServeHTTP(w, r)
+}
+
+// SetHandler sets handler
+func (c *ClientCertSubject) SetHandler(handler http.Handler) {
+	c.Handler = handler
+}
@@ -1,33 +1,463 @@
 package middlewares
 
 import (
+	"bufio"
 	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/NYTimes/gziphandler"
+	"github.com/andybalholm/brotli"
 	"github.com/containous/traefik/log"
 )
 
-// Compress is a middleware that allows redirection
-type Compress struct{}
+const (
+	brotliEncoding  = "br"
+	gzipEncoding    = "gzip"
+	deflateEncoding = "deflate"
+)
+
+// DefaultCompressBrotliQuality is the default quality of the Brotli compression,
+// offering a good trade-off between speed and compression.
+const DefaultCompressBrotliQuality = 5
+
+// DefaultCompressExcludedContentTypes are the content types of the responses which are not compressed by default,
+// as they are already compressed.
+var DefaultCompressExcludedContentTypes = []string{
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"audio/*",
+	"video/*",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"font/woff",
+	"font/woff2",
+}
+
+// Compress is a middleware compressing the responses with Brotli or gzip, according to the encodings accepted by the client
+type Compress struct {
+	brotliQuality        int
+	minSize              int
+	excludedContentTypes []string
+	transcode            bool
+
+	brotliWriters sync.Pool
+	gzipWriters   sync.Pool
+}
+
+// NewCompress returns a Compress middleware.
+// A zero brotliQuality or minSize, or nil excludedContentTypes, stand for their default values.
+// With transcode, the gzip and deflate responses are decompressed to be compressed with the encoding negotiated with the client.
+func NewCompress(brotliQuality int, minSize int, excludedContentTypes []string, transcode bool) (*Compress, error) {
+	if brotliQuality < 0 || brotliQuality > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid Brotli quality %d, it must be between 1 and %d", brotliQuality, brotli.BestCompression)
+	}
+	if minSize < 0 {
+		return nil, fmt.Errorf("invalid compression minimum size %d", minSize)
+	}
+
+	var contentTypes []string
+	for _, contentType := range excludedContentTypes {
+		contentTypes = append(contentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+	}
+
+	return &Compress{
+		brotliQuality:        brotliQuality,
+		minSize:              minSize,
+		excludedContentTypes: contentTypes,
+		transcode:            transcode,
+	}, nil
+}
 
 // ServerHTTP is a function used by Negroni
 func (c *Compress) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	contentType := r.Header.Get("Content-Type")
 	if strings.HasPrefix(contentType, "application/grpc") {
 		next.ServeHTTP(rw, r)
+		return
+	}
+
+	rw.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		next.ServeHTTP(rw, r)
+		return
+	}
+
+	crw := &compressResponseWriter{ResponseWriter: rw, compress: c, encoding: encoding}
+	defer func() {
+		if err := crw.close(); err != nil {
+			log.Debugf("Error while compressing the response: %v", err)
+		}
+	}()
+	next.ServeHTTP(crw, r)
+}
+
+// negotiateEncoding returns the encoding of the response according to the Accept-Encoding header of the request:
+// Brotli, gzip, or an empty string if none of them is accepted. Brotli is preferred when both are equally accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, value := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(value, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if coding == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.ToLower(strings.TrimSpace(param))
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					q = 0
+				}
+				quality = q
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	qualityOf := func(coding string) float64 {
+		if quality, ok := qualities[coding]; ok {
+			return quality
+		}
+		return qualities["*"]
+	}
+
+	brotliQuality, gzipQuality := qualityOf(brotliEncoding), qualityOf(gzipEncoding)
+	switch {
+	case brotliQuality > 0 && brotliQuality >= gzipQuality:
+		return brotliEncoding
+	case gzipQuality > 0:
+		return gzipEncoding
+	default:
+		return ""
+	}
+}
+
+func (c *Compress) getMinSize() int {
+	if c.minSize == 0 {
+		return gziphandler.DefaultMinSize
+	}
+	return c.minSize
+}
+
+func (c *Compress) isExcluded(contentType string) bool {
+	excludedContentTypes := c.excludedContentTypes
+	if excludedContentTypes == nil {
+		excludedContentTypes = DefaultCompressExcludedContentTypes
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	for _, excluded := range excludedContentTypes {
+		if strings.HasSuffix(excluded, "/*") && strings.HasPrefix(mediaType, excluded[:len(excluded)-1]) || mediaType == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// encoder is implemented by both the gzip and Brotli writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (c *Compress) getEncoder(encoding string, w io.Writer) encoder {
+	if encoding == brotliEncoding {
+		if bw, ok := c.brotliWriters.Get().(*brotli.Writer); ok {
+			bw.Reset(w)
+			return bw
+		}
+		quality := c.brotliQuality
+		if quality == 0 {
+			quality = DefaultCompressBrotliQuality
+		}
+		return brotli.NewWriterLevel(w, quality)
+	}
+
+	if gw, ok := c.gzipWriters.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return gw
+	}
+	gw, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	return gw
+}
+
+func (c *Compress) putEncoder(encoding string, e encoder) {
+	if encoding == brotliEncoding {
+		c.brotliWriters.Put(e)
 	} else {
-		gzipHandler(next).ServeHTTP(rw, r)
+		c.gzipWriters.Put(e)
+	}
+}
+
+// compressResponseWriter compresses the body of the response once it is known to be large enough,
+// and not already compressed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compress *Compress
+	encoding string
+
+	statusCode int
+	// buf holds the beginning of the body until the minimum size to compress it is reached
+	buf        []byte
+	encoder    encoder
+	identity   bool
+	transcoder *transcoder
+}
+
+// transcoder decodes the compressed body of the response as it is written, the decoded body being compressed by the encoder.
+type transcoder struct {
+	pipe *io.PipeWriter
+	done chan error
+	// lock protects the encoder and the response writer, written by the decoding goroutine
+	lock sync.Mutex
+}
+
+func (t *transcoder) Write(p []byte) (int, error) {
+	return t.pipe.Write(p)
+}
+
+// lockedWriter writes to the encoder while holding the lock of the transcoder.
+type lockedWriter struct {
+	lock *sync.Mutex
+	w    io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.w.Write(p)
+}
+
+func (w *compressResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
 	}
 }
 
-func gzipHandler(h http.Handler) http.Handler {
-	wrapper, err := gziphandler.GzipHandlerWithOpts(
-		gziphandler.CompressionLevel(gzip.DefaultCompression),
-		gziphandler.MinSize(gziphandler.DefaultMinSize))
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.transcoder != nil {
+		return w.transcoder.Write(p)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	if w.identity {
+		return w.ResponseWriter.Write(p)
+	}
+
+	if _, ok := w.Header()["Content-Type"]; !ok {
+		w.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+
+	if w.isTranscodable() {
+		w.startTranscoding()
+		return w.transcoder.Write(p)
+	}
+
+	if !w.isCompressible() {
+		if err := w.startIdentity(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.compress.getMinSize() {
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// isCompressible returns whether the response has a body, which is not already compressed.
+func (w *compressResponseWriter) isCompressible() bool {
+	if !w.hasBody() {
+		return false
+	}
+	return w.Header().Get("Content-Encoding") == "" && !w.compress.isExcluded(w.Header().Get("Content-Type"))
+}
+
+// isTranscodable returns whether the response has a body compressed with gzip or deflate,
+// which is to be compressed with another encoding.
+func (w *compressResponseWriter) isTranscodable() bool {
+	if !w.compress.transcode || !w.hasBody() {
+		return false
+	}
+	encoding := strings.ToLower(strings.TrimSpace(w.Header().Get("Content-Encoding")))
+	return (encoding == gzipEncoding || encoding == deflateEncoding) && encoding != w.encoding
+}
+
+func (w *compressResponseWriter) hasBody() bool {
+	return w.statusCode == 0 || w.statusCode >= http.StatusOK && w.statusCode != http.StatusNoContent && w.statusCode != http.StatusNotModified
+}
+
+func (w *compressResponseWriter) writeHeader() {
+	if w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+}
+
+// startIdentity writes the header and the buffered body as is, the rest of the body being written directly.
+func (w *compressResponseWriter) startIdentity() error {
+	w.identity = true
+	w.writeHeader()
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) > 0 {
+		if _, err := w.ResponseWriter.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startEncoding writes the header, and compresses the buffered body and the rest of it.
+func (w *compressResponseWriter) startEncoding() error {
+	w.Header().Set("Content-Encoding", w.encoding)
+	// the length of the compressed body is unknown
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+
+	w.encoder = w.compress.getEncoder(w.encoding, w.ResponseWriter)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) > 0 {
+		if _, err := w.encoder.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startTranscoding writes the header, and decodes the body in a goroutine to compress it with the encoder.
+func (w *compressResponseWriter) startTranscoding() {
+	sourceEncoding := strings.ToLower(strings.TrimSpace(w.Header().Get("Content-Encoding")))
+
+	w.Header().Set("Content-Encoding", w.encoding)
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+
+	w.encoder = w.compress.getEncoder(w.encoding, w.ResponseWriter)
+
+	reader, writer := io.Pipe()
+	t := &transcoder{pipe: writer, done: make(chan error, 1)}
+	w.transcoder = t
+
+	encoder := w.encoder
+	go func() {
+		err := decodeBody(sourceEncoding, reader, &lockedWriter{lock: &t.lock, w: encoder})
+		// the rest of the body is rejected if it cannot be decoded
+		reader.CloseWithError(err)
+		t.done <- err
+	}()
+}
+
+// decodeBody writes to w the body read from r, decoded according to its encoding.
+func decodeBody(encoding string, r io.Reader, w io.Writer) error {
+	var decoder io.ReadCloser
+	var err error
+	if encoding == gzipEncoding {
+		decoder, err = gzip.NewReader(r)
+	} else {
+		decoder, err = zlib.NewReader(r)
+	}
 	if err != nil {
-		log.Error(err)
+		return err
+	}
+
+	if _, err = io.Copy(w, decoder); err != nil {
+		return err
+	}
+	return decoder.Close()
+}
+
+// Flush sends the body written so far, deciding whether to compress it if the minimum size is not reached yet,
+// as the response is streamed.
+func (w *compressResponseWriter) Flush() {
+	if w.encoder == nil && !w.identity {
+		var err error
+		switch {
+		case w.isTranscodable():
+			w.startTranscoding()
+		case w.isCompressible():
+			err = w.startEncoding()
+		default:
+			err = w.startIdentity()
+		}
+		if err != nil {
+			log.Debugf("Error while flushing the response: %v", err)
+			return
+		}
+	}
+
+	if w.transcoder != nil {
+		// the body decoded so far is flushed, the decoder may still hold a part of it
+		w.transcoder.lock.Lock()
+		defer w.transcoder.lock.Unlock()
+	}
+
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			log.Debugf("Error while flushing the compressed response: %v", err)
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close ends the compressed body, or writes the response as is if it was too small to be compressed.
+func (w *compressResponseWriter) close() error {
+	var transcodeErr error
+	if w.transcoder != nil {
+		w.transcoder.pipe.Close()
+		transcodeErr = <-w.transcoder.done
+		w.transcoder = nil
+	}
+	if w.encoder != nil {
+		err := w.encoder.Close()
+		w.compress.putEncoder(w.encoding, w.encoder)
+		w.encoder = nil
+		if transcodeErr != nil {
+			return transcodeErr
+		}
+		return err
+	}
+	if !w.identity {
+		return w.startIdentity()
+	}
+	return nil
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+}
+
+func (w *compressResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
 	}
-	return wrapper(h)
+	return make(<-chan bool)
 }
@@ -1,12 +1,18 @@
 package middlewares
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/NYTimes/gziphandler"
+	"github.com/andybalholm/brotli"
 	"github.com/containous/traefik/testhelpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -245,3 +251,354 @@ func generateBytes(len int) []byte {
 	}
 	return value
 }
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{
+			desc: "no Accept-Encoding",
+		},
+		{
+			desc:             "gzip only",
+			acceptEncoding:   "gzip",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "Brotli preferred over gzip",
+			acceptEncoding:   "gzip, deflate, br",
+			expectedEncoding: "br",
+		},
+		{
+			desc:             "gzip with a higher quality",
+			acceptEncoding:   "br;q=0.5, gzip;q=0.8",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:             "Brotli refused",
+			acceptEncoding:   "br;q=0, gzip",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:           "both refused",
+			acceptEncoding: "br;q=0, gzip;q=0, identity",
+		},
+		{
+			desc:             "wildcard",
+			acceptEncoding:   "*",
+			expectedEncoding: "br",
+		},
+		{
+			desc:             "wildcard with Brotli refused",
+			acceptEncoding:   "*;q=0.5, br;q=0",
+			expectedEncoding: "gzip",
+		},
+		{
+			desc:           "unsupported encoding",
+			acceptEncoding: "deflate",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedEncoding, negotiateEncoding(test.acceptEncoding))
+		})
+	}
+}
+
+func TestShouldCompressWithBrotli(t *testing.T) {
+	handler, err := NewCompress(1, 0, nil, false)
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Add(acceptEncodingHeader, "gzip, br")
+
+	baseBody := generateBytes(gziphandler.DefaultMinSize)
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Length", strconv.Itoa(len(baseBody)))
+		rw.Write(baseBody)
+	}
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req, next)
+
+	assert.Equal(t, "br", rw.Header().Get(contentEncodingHeader))
+	assert.Equal(t, acceptEncodingHeader, rw.Header().Get(varyHeader))
+	assert.Empty(t, rw.Header().Get("Content-Length"))
+
+	body, err := ioutil.ReadAll(brotli.NewReader(rw.Body))
+	require.NoError(t, err)
+	assert.Equal(t, baseBody, body)
+}
+
+func TestCompressOptions(t *testing.T) {
+	largeBody := generateBytes(2000)
+
+	testCases := []struct {
+		desc                 string
+		minSize              int
+		excludedContentTypes []string
+		contentType          string
+		body                 []byte
+		expectedEncoding     string
+	}{
+		{
+			desc:             "below the default minimum size",
+			body:             largeBody[:gziphandler.DefaultMinSize-1],
+			expectedEncoding: "",
+		},
+		{
+			desc:             "above a custom minimum size",
+			minSize:          100,
+			body:             largeBody[:100],
+			expectedEncoding: "br",
+		},
+		{
+			desc:             "below a custom minimum size",
+			minSize:          1500,
+			body:             largeBody[:1499],
+			expectedEncoding: "",
+		},
+		{
+			desc:             "image excluded by default",
+			contentType:      "image/png",
+			body:             largeBody,
+			expectedEncoding: "",
+		},
+		{
+			desc:             "video excluded by default with a wildcard",
+			contentType:      "video/mp4",
+			body:             largeBody,
+			expectedEncoding: "",
+		},
+		{
+			desc:             "SVG image compressed by default",
+			contentType:      "image/svg+xml; charset=utf-8",
+			body:             largeBody,
+			expectedEncoding: "br",
+		},
+		{
+			desc:                 "custom excluded content type",
+			excludedContentTypes: []string{"text/*"},
+			contentType:          "Text/HTML; charset=utf-8",
+			body:                 largeBody,
+			expectedEncoding:     "",
+		},
+		{
+			desc:                 "content type no longer excluded",
+			excludedContentTypes: []string{"text/*"},
+			contentType:          "image/png",
+			body:                 largeBody,
+			expectedEncoding:     "br",
+		},
+		{
+			desc:                 "sniffed content type excluded",
+			excludedContentTypes: []string{"application/octet-stream"},
+			body:                 largeBody,
+			expectedEncoding:     "",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewCompress(0, test.minSize, test.excludedContentTypes, false)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Add(acceptEncodingHeader, "br")
+
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				if test.contentType != "" {
+					rw.Header().Set(contentTypeHeader, test.contentType)
+				}
+				// the body is written in several parts
+				rw.Write(test.body[:len(test.body)/2])
+				rw.Write(test.body[len(test.body)/2:])
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req, next)
+
+			assert.Equal(t, test.expectedEncoding, rw.Header().Get(contentEncodingHeader))
+			if test.expectedEncoding == "" {
+				assert.Equal(t, test.body, rw.Body.Bytes())
+			} else {
+				assert.NotEqual(t, test.body, rw.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestNewCompressInvalidOptions(t *testing.T) {
+	_, err := NewCompress(brotli.BestCompression+1, 0, nil, false)
+	assert.Error(t, err)
+
+	_, err = NewCompress(-1, 0, nil, false)
+	assert.Error(t, err)
+
+	_, err = NewCompress(0, -1, nil, false)
+	assert.Error(t, err)
+}
+
+func TestShouldCompressStreamedResponse(t *testing.T) {
+	comp := &Compress{}
+	negro := negroni.New(comp)
+	negro.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(contentTypeHeader, "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(rw, "data: %d\n\n", i)
+			rw.(http.Flusher).Flush()
+		}
+	})
+	ts := httptest.NewServer(negro)
+	defer ts.Close()
+
+	req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Add(acceptEncodingHeader, gzipValue)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, gzipValue, resp.Header.Get(contentEncodingHeader))
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "data: 0\n\ndata: 1\n\ndata: 2\n\n", string(body))
+}
+
+func TestCompressTranscode(t *testing.T) {
+	body := generateBytes(2000)
+
+	gzipBody := new(bytes.Buffer)
+	gw := gzip.NewWriter(gzipBody)
+	_, err := gw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	deflateBody := new(bytes.Buffer)
+	zw := zlib.NewWriter(deflateBody)
+	_, err = zw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	brotliBody := new(bytes.Buffer)
+	bw := brotli.NewWriterLevel(brotliBody, DefaultCompressBrotliQuality)
+	_, err = bw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, bw.Close())
+
+	testCases := []struct {
+		desc             string
+		transcode        bool
+		acceptEncoding   string
+		contentEncoding  string
+		backendBody      []byte
+		expectedEncoding string
+		expectedBody     []byte
+	}{
+		{
+			desc:             "gzip to Brotli",
+			transcode:        true,
+			acceptEncoding:   "br",
+			contentEncoding:  "gzip",
+			backendBody:      gzipBody.Bytes(),
+			expectedEncoding: "br",
+			expectedBody:     brotliBody.Bytes(),
+		},
+		{
+			desc:             "deflate to Brotli",
+			transcode:        true,
+			acceptEncoding:   "br",
+			contentEncoding:  "deflate",
+			backendBody:      deflateBody.Bytes(),
+			expectedEncoding: "br",
+			expectedBody:     brotliBody.Bytes(),
+		},
+		{
+			desc:             "gzip already accepted",
+			transcode:        true,
+			acceptEncoding:   "gzip",
+			contentEncoding:  "gzip",
+			backendBody:      gzipBody.Bytes(),
+			expectedEncoding: "gzip",
+			expectedBody:     gzipBody.Bytes(),
+		},
+		{
+			desc:             "gzip kept without transcoding",
+			acceptEncoding:   "br",
+			contentEncoding:  "gzip",
+			backendBody:      gzipBody.Bytes(),
+			expectedEncoding: "gzip",
+			expectedBody:     gzipBody.Bytes(),
+		},
+		{
+			desc:             "unknown encoding kept",
+			transcode:        true,
+			acceptEncoding:   "br",
+			contentEncoding:  "compress",
+			backendBody:      body,
+			expectedEncoding: "compress",
+			expectedBody:     body,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewCompress(0, 0, nil, test.transcode)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Add(acceptEncodingHeader, test.acceptEncoding)
+
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set(contentEncodingHeader, test.contentEncoding)
+				rw.Header().Set("Content-Length", strconv.Itoa(len(test.backendBody)))
+				// the body is written in several parts
+				rw.Write(test.backendBody[:len(test.backendBody)/2])
+				rw.Write(test.backendBody[len(test.backendBody)/2:])
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req, next)
+
+			assert.Equal(t, test.expectedEncoding, rw.Header().Get(contentEncodingHeader))
+			assert.Equal(t, test.expectedBody, rw.Body.Bytes())
+		})
+	}
+}
+
+func TestCompressTranscodeInvalidBody(t *testing.T) {
+	handler, err := NewCompress(0, 0, nil, true)
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Add(acceptEncodingHeader, "br")
+
+	var writeErr error
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(contentEncodingHeader, gzipValue)
+		rw.Write(generateBytes(gziphandler.DefaultMinSize))
+		// the invalid body is rejected once the decoder read it
+		_, writeErr = rw.Write(generateBytes(gziphandler.DefaultMinSize))
+	}
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req, next)
+
+	assert.Error(t, writeErr)
+	assert.Equal(t, "br", rw.Header().Get(contentEncodingHeader))
+}
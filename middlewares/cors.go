@@ -0,0 +1,213 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/middlewares/tracing"
+	"github.com/containous/traefik/types"
+)
+
+var (
+	defaultCORSMethods  = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	corsResponseHeaders = []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Expose-Headers"}
+)
+
+// CORS is a middleware applying the Cross-Origin Resource Sharing policy of a frontend.
+// The preflight requests are answered without being forwarded, with a 403 when the origin, the method or one of the headers is not allowed,
+// and the CORS headers of the responses to the allowed origins are set by ModifyResponseHeaders, replacing the ones of the backend.
+type CORS struct {
+	anyOrigin        bool
+	origins          []*regexp.Regexp
+	methods          []string
+	anyHeader        bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORS creates a CORS, allowing the GET, HEAD and POST methods when no method is configured.
+func NewCORS(config *types.CORS) (*CORS, error) {
+	if config == nil {
+		return nil, errors.New("cors is nil")
+	}
+	if len(config.AllowedOrigins) == 0 && len(config.AllowedOriginsRegex) == 0 {
+		return nil, errors.New("no allowed origin")
+	}
+	if config.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid max age %d", config.MaxAge)
+	}
+
+	c := &CORS{
+		methods:          defaultCORSMethods,
+		headers:          make(map[string]bool),
+		exposedHeaders:   strings.Join(config.ExposedHeaders, ", "),
+		allowCredentials: config.AllowCredentials,
+	}
+	if config.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(config.MaxAge)
+	}
+
+	// the origins are matched case-insensitively, their scheme and host being case-insensitive
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			c.anyOrigin = true
+			continue
+		}
+		pattern := strings.Replace(regexp.QuoteMeta(strings.TrimSuffix(origin, "/")), `\*`, `[^/]*`, -1)
+		c.origins = append(c.origins, regexp.MustCompile(`(?i)^`+pattern+`$`))
+	}
+	for _, origin := range config.AllowedOriginsRegex {
+		regex, err := regexp.Compile(`(?i)^(?:` + origin + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed origin regex %q: %v", origin, err)
+		}
+		c.origins = append(c.origins, regex)
+	}
+
+	if len(config.AllowedMethods) > 0 {
+		c.methods = nil
+		for _, method := range config.AllowedMethods {
+			c.methods = append(c.methods, strings.ToUpper(strings.TrimSpace(method)))
+		}
+	}
+
+	for _, header := range config.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[strings.ToLower(header)] = true
+	}
+
+	return c, nil
+}
+
+func (c *CORS) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	origin := req.Header.Get("Origin")
+	requestMethod := req.Header.Get("Access-Control-Request-Method")
+	if req.Method != http.MethodOptions || len(origin) == 0 || len(requestMethod) == 0 {
+		next.ServeHTTP(rw, req)
+		return
+	}
+
+	header := rw.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.isAllowedOrigin(origin) {
+		tracing.SetErrorAndDebugLog(req, "rejecting CORS preflight request from origin %q", origin)
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !c.isAllowedMethod(requestMethod) {
+		tracing.SetErrorAndDebugLog(req, "rejecting CORS preflight request for method %q", requestMethod)
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+	requestHeaders, ok := c.checkRequestHeaders(req.Header.Get("Access-Control-Request-Headers"))
+	if !ok {
+		tracing.SetErrorAndDebugLog(req, "rejecting CORS preflight request with headers %q", requestHeaders)
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.setOriginHeaders(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if len(requestHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	if len(c.maxAge) > 0 {
+		header.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// ModifyResponseHeaders sets the CORS headers of the response to a request of an allowed origin
+func (c *CORS) ModifyResponseHeaders(res *http.Response) error {
+	if res.Request == nil {
+		return nil
+	}
+	c.setResponseHeaders(res.Header, res.Request.Header.Get("Origin"))
+	return nil
+}
+
+// SetResponseHeaders sets the CORS headers of the responses which are not forwarded by a backend, such as the static responses
+func (c *CORS) SetResponseHeaders(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	c.setResponseHeaders(rw.Header(), req.Header.Get("Origin"))
+	next.ServeHTTP(rw, req)
+}
+
+func (c *CORS) setResponseHeaders(header http.Header, origin string) {
+	if len(origin) == 0 {
+		return
+	}
+	for _, name := range corsResponseHeaders {
+		header.Del(name)
+	}
+	header.Add("Vary", "Origin")
+
+	if !c.isAllowedOrigin(origin) {
+		return
+	}
+	c.setOriginHeaders(header, origin)
+	if len(c.exposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+}
+
+// setOriginHeaders allows the origin, which is sent back when the credentials are allowed, as they can't be with *
+func (c *CORS) setOriginHeaders(header http.Header, origin string) {
+	if c.allowCredentials {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+	} else if c.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+}
+
+func (c *CORS) isAllowedOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	for _, regex := range c.origins {
+		if regex.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) isAllowedMethod(method string) bool {
+	for _, allowed := range c.methods {
+		if allowed == strings.ToUpper(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequestHeaders returns the normalized headers requested by a preflight request, and whether they are all allowed
+func (c *CORS) checkRequestHeaders(requestHeaders string) (string, bool) {
+	var headers []string
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = strings.TrimSpace(header)
+		if len(header) == 0 {
+			continue
+		}
+		if !c.anyHeader && !c.headers[strings.ToLower(header)] {
+			return requestHeaders, false
+		}
+		headers = append(headers, header)
+	}
+	return strings.Join(headers, ", "), true
+}
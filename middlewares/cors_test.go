@@ -0,0 +1,288 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCORSInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.CORS
+	}{
+		{
+			desc:   "no allowed origin",
+			config: &types.CORS{AllowedMethods: []string{http.MethodGet}},
+		},
+		{
+			desc:   "invalid regex",
+			config: &types.CORS{AllowedOriginsRegex: []string{"https://[a-z"}},
+		},
+		{
+			desc:   "negative max age",
+			config: &types.CORS{AllowedOrigins: []string{"*"}, MaxAge: -1},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCORS(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.CORS
+		method          string
+		origin          string
+		requestMethod   string
+		requestHeaders  string
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			desc:           "exact origin",
+			config:         &types.CORS{AllowedOrigins: []string{"https://example.com"}, MaxAge: 600},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodPost,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://example.com",
+				"Access-Control-Allow-Methods": "GET, HEAD, POST",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			desc:           "origin with another case",
+			config:         &types.CORS{AllowedOrigins: []string{"https://example.com"}},
+			method:         http.MethodOptions,
+			origin:         "HTTPS://Example.COM",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "HTTPS://Example.COM",
+			},
+		},
+		{
+			desc:           "wildcard origin",
+			config:         &types.CORS{AllowedOrigins: []string{"https://*.example.com"}},
+			method:         http.MethodOptions,
+			origin:         "https://app.example.com",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.example.com",
+			},
+		},
+		{
+			desc:           "wildcard origin of another domain",
+			config:         &types.CORS{AllowedOrigins: []string{"https://*.example.com"}},
+			method:         http.MethodOptions,
+			origin:         "https://app.example.com.evil.org",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "regex origin",
+			config:         &types.CORS{AllowedOriginsRegex: []string{`https?://localhost:\d+`}},
+			method:         http.MethodOptions,
+			origin:         "http://localhost:3000",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "http://localhost:3000",
+			},
+		},
+		{
+			desc:           "regex origin matching only a part of the origin",
+			config:         &types.CORS{AllowedOriginsRegex: []string{`https://example\.com`}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com.evil.org",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "any origin",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			desc:           "any origin with credentials",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			desc:           "disallowed method",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"get", "put"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodDelete,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "allowed headers",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"Authorization", "Content-Type"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodPost,
+			requestHeaders: "content-type,authorization",
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Headers": "content-type, authorization",
+			},
+		},
+		{
+			desc:           "disallowed header",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"Content-Type"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodPost,
+			requestHeaders: "content-type, x-api-key",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "any header",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			requestMethod:  http.MethodPost,
+			requestHeaders: "x-api-key",
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Headers": "x-api-key",
+			},
+		},
+		{
+			desc:           "options request without request method",
+			config:         &types.CORS{AllowedOrigins: []string{"*"}},
+			method:         http.MethodOptions,
+			origin:         "https://example.com",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "get request",
+			config:         &types.CORS{AllowedOrigins: []string{"https://example.com"}},
+			method:         http.MethodGet,
+			origin:         "https://other.com",
+			requestMethod:  http.MethodGet,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cors, err := NewCORS(test.config)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(test.method, "http://localhost", nil)
+			req.Header.Set("Origin", test.origin)
+			if len(test.requestMethod) > 0 {
+				req.Header.Set("Access-Control-Request-Method", test.requestMethod)
+			}
+			if len(test.requestHeaders) > 0 {
+				req.Header.Set("Access-Control-Request-Headers", test.requestHeaders)
+			}
+
+			recorder := httptest.NewRecorder()
+			cors.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(header), header)
+			}
+		})
+	}
+}
+
+func TestCORSModifyResponseHeaders(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *types.CORS
+		origin          string
+		expectedHeaders map[string]string
+	}{
+		{
+			desc:   "allowed origin",
+			config: &types.CORS{AllowedOrigins: []string{"https://example.com"}, ExposedHeaders: []string{"X-Request-Id", "X-Total"}},
+			origin: "https://example.com",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://example.com",
+				"Access-Control-Expose-Headers": "X-Request-Id, X-Total",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			desc:   "disallowed origin",
+			config: &types.CORS{AllowedOrigins: []string{"https://example.com"}},
+			origin: "https://other.com",
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "",
+				"Access-Control-Expose-Headers": "",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			desc:   "no origin",
+			config: &types.CORS{AllowedOrigins: []string{"https://example.com"}},
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://backend.com",
+				"Vary":                        "",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cors, err := NewCORS(test.config)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			if len(test.origin) > 0 {
+				req.Header.Set("Origin", test.origin)
+			}
+			res := &http.Response{
+				Header:  http.Header{"Access-Control-Allow-Origin": {"https://backend.com"}},
+				Request: req,
+			}
+
+			require.NoError(t, cors.ModifyResponseHeaders(res))
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, res.Header.Get(header), header)
+			}
+		})
+	}
+}
@@ -1,31 +1,85 @@
 package middlewares
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/types"
 )
 
 // EmptyBackendHandler is a middlware that checks whether the current Backend
 // has at least one active Server in respect to the healthchecks and if this
-// is not the case, it will stop the middleware chain and respond with 503.
+// is not the case, it will stop the middleware chain and respond with 503,
+// or with the configured no server response.
 type EmptyBackendHandler struct {
-	lb   healthcheck.LoadBalancer
-	next http.Handler
+	lb       healthcheck.LoadBalancer
+	next     http.Handler
+	noServer http.Handler
 }
 
 // NewEmptyBackendHandler creates a new EmptyBackendHandler instance.
-func NewEmptyBackendHandler(lb healthcheck.LoadBalancer, next http.Handler) *EmptyBackendHandler {
-	return &EmptyBackendHandler{lb: lb, next: next}
+// noServer responds when there is no active Server, a 503 is sent if it is nil.
+func NewEmptyBackendHandler(lb healthcheck.LoadBalancer, next http.Handler, noServer http.Handler) *EmptyBackendHandler {
+	return &EmptyBackendHandler{lb: lb, next: next, noServer: noServer}
 }
 
 // ServeHTTP responds with 503 when there is no active Server and otherwise
 // invokes the next handler in the middleware chain.
 func (h *EmptyBackendHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	if len(h.lb.Servers()) == 0 {
+		if h.noServer != nil {
+			h.noServer.ServeHTTP(rw, r)
+			return
+		}
 		rw.WriteHeader(http.StatusServiceUnavailable)
 		rw.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
 	} else {
 		h.next.ServeHTTP(rw, r)
 	}
 }
+
+// NewNoServerHandler creates the handler sending the configured response when there is no active Server,
+// or returns nil if there is no configuration.
+func NewNoServerHandler(config *types.NoServerResponse) (http.Handler, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	if len(config.Redirect) > 0 {
+		code := config.StatusCode
+		if code == 0 {
+			code = http.StatusFound
+		}
+		if code < 300 || code > 399 {
+			return nil, fmt.Errorf("invalid redirect status code %d", code)
+		}
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			http.Redirect(rw, req, config.Redirect, code)
+		}), nil
+	}
+
+	code := config.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	if !isValidStatusCode(code) {
+		return nil, fmt.Errorf("invalid status code %d", code)
+	}
+
+	contentType := config.ContentType
+	if len(contentType) == 0 {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	body := config.Body
+	if len(body) == 0 {
+		body = http.StatusText(code)
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", contentType)
+		rw.WriteHeader(code)
+		rw.Write([]byte(body))
+	}), nil
+}
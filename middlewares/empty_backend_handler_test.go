@@ -8,6 +8,9 @@ import (
 	"testing"
 
 	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vulcand/oxy/roundrobin"
 )
 
@@ -35,7 +38,7 @@ func TestEmptyBackendHandler(t *testing.T) {
 			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 			})
-			handler := NewEmptyBackendHandler(&healthCheckLoadBalancer{test.amountServer}, nextHandler)
+			handler := NewEmptyBackendHandler(&healthCheckLoadBalancer{test.amountServer}, nextHandler, nil)
 
 			recorder := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
@@ -68,3 +71,112 @@ func (lb *healthCheckLoadBalancer) Servers() []*url.URL {
 	}
 	return servers
 }
+
+func TestNoServerHandler(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		config              *types.NoServerResponse
+		expectedCode        int
+		expectedBody        string
+		expectedContentType string
+		expectedLocation    string
+	}{
+		{
+			desc:                "default response",
+			config:              &types.NoServerResponse{},
+			expectedCode:        http.StatusServiceUnavailable,
+			expectedBody:        http.StatusText(http.StatusServiceUnavailable),
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc: "custom response",
+			config: &types.NoServerResponse{
+				StatusCode:  http.StatusOK,
+				ContentType: "text/html",
+				Body:        "<h1>Maintenance</h1>",
+			},
+			expectedCode:        http.StatusOK,
+			expectedBody:        "<h1>Maintenance</h1>",
+			expectedContentType: "text/html",
+		},
+		{
+			desc:                "redirect",
+			config:              &types.NoServerResponse{Redirect: "http://maintenance.example.com/"},
+			expectedCode:        http.StatusFound,
+			expectedBody:        "<a href=\"http://maintenance.example.com/\">Found</a>.\n\n",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedLocation:    "http://maintenance.example.com/",
+		},
+		{
+			desc:                "temporary redirect",
+			config:              &types.NoServerResponse{Redirect: "http://maintenance.example.com/", StatusCode: http.StatusTemporaryRedirect},
+			expectedCode:        http.StatusTemporaryRedirect,
+			expectedBody:        "<a href=\"http://maintenance.example.com/\">Temporary Redirect</a>.\n\n",
+			expectedContentType: "text/html; charset=utf-8",
+			expectedLocation:    "http://maintenance.example.com/",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			noServerHandler, err := NewNoServerHandler(test.config)
+			require.NoError(t, err)
+
+			handler := NewEmptyBackendHandler(&healthCheckLoadBalancer{}, http.NotFoundHandler(), noServerHandler)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+
+			assert.Equal(t, test.expectedCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestNewNoServerHandler(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *types.NoServerResponse
+		expectHandler bool
+		expectError   bool
+	}{
+		{
+			desc: "no configuration",
+		},
+		{
+			desc:          "valid status code",
+			config:        &types.NoServerResponse{StatusCode: http.StatusServiceUnavailable},
+			expectHandler: true,
+		},
+		{
+			desc:        "invalid status code",
+			config:      &types.NoServerResponse{StatusCode: 42},
+			expectError: true,
+		},
+		{
+			desc:        "redirect without a redirect status code",
+			config:      &types.NoServerResponse{Redirect: "http://maintenance.example.com/", StatusCode: http.StatusOK},
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewNoServerHandler(test.config)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectHandler, handler != nil)
+		})
+	}
+}
@@ -3,10 +3,13 @@ package middlewares
 import (
 	"bufio"
 	"bytes"
+	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/types"
@@ -17,18 +20,28 @@ import (
 // Compile time validation that the response recorder implements http interfaces correctly.
 var _ Stateful = &errorPagesResponseRecorderWithCloseNotify{}
 
+// ErrorPageStatusHeader is the header sending the status code of the original response to the error page backend
+const ErrorPageStatusHeader = "X-Error-Status"
+
+// defaultErrorPageTimeout is the maximum duration to get an error page from its backend, when the error page has no timeout
+const defaultErrorPageTimeout = 10 * time.Second
+
 //ErrorPagesHandler is a middleware that provides the custom error pages
 type ErrorPagesHandler struct {
-	HTTPCodeRanges     [][2]int
-	BackendURL         string
-	errorPageForwarder *forward.Forwarder
+	HTTPCodeRanges [][2]int
+	BackendURL     string
+	client         *http.Client
 }
 
 //NewErrorPagesHandler initializes the utils.ErrorHandler for the custom error pages
 func NewErrorPagesHandler(errorPage *types.ErrorPage, backendURL string) (*ErrorPagesHandler, error) {
-	fwd, err := forward.New()
-	if err != nil {
-		return nil, err
+	timeout := defaultErrorPageTimeout
+	if len(errorPage.Timeout) > 0 {
+		var err error
+		timeout, err = time.ParseDuration(errorPage.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", errorPage.Timeout)
+		}
 	}
 
 	//Break out the http status code ranges into a low int and high int
@@ -51,9 +64,15 @@ func NewErrorPagesHandler(errorPage *types.ErrorPage, backendURL string) (*Error
 		blocks = append(blocks, [2]int{lowCode, highCode})
 	}
 	return &ErrorPagesHandler{
-			HTTPCodeRanges:     blocks,
-			BackendURL:         backendURL + errorPage.Query,
-			errorPageForwarder: fwd},
+			HTTPCodeRanges: blocks,
+			BackendURL:     backendURL + errorPage.Query,
+			client: &http.Client{
+				Timeout: timeout,
+				// the redirections are sent to the client, as any response of the error page backend
+				CheckRedirect: func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}},
 		nil
 }
 
@@ -62,26 +81,63 @@ func (ep *ErrorPagesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request,
 
 	next.ServeHTTP(recorder, req)
 
-	w.WriteHeader(recorder.GetCode())
 	//check the recorder code against the configured http status code ranges
 	for _, block := range ep.HTTPCodeRanges {
 		if recorder.GetCode() >= block[0] && recorder.GetCode() <= block[1] {
 			log.Errorf("Caught HTTP Status Code %d, returning error page", recorder.GetCode())
-			finalURL := strings.Replace(ep.BackendURL, "{status}", strconv.Itoa(recorder.GetCode()), -1)
-			if newReq, err := http.NewRequest(http.MethodGet, finalURL, nil); err != nil {
-				w.Write([]byte(http.StatusText(recorder.GetCode())))
-			} else {
-				ep.errorPageForwarder.ServeHTTP(w, newReq)
-			}
+			ep.serveErrorPage(w, recorder.GetCode())
 			return
 		}
 	}
 
 	//did not catch a configured status code so proceed with the request
-	utils.CopyHeaders(w.Header(), recorder.Header())
+	if !recorder.IsStreamingResponseStarted() {
+		utils.CopyHeaders(w.Header(), recorder.Header())
+		w.WriteHeader(recorder.GetCode())
+	}
 	w.Write(recorder.GetBody().Bytes())
 }
 
+// serveErrorPage writes the response of the error page backend with the status code of the original response.
+// A page made of the status text is written instead when the error page backend fails or times out.
+func (ep *ErrorPagesHandler) serveErrorPage(w http.ResponseWriter, code int) {
+	finalURL := strings.Replace(ep.BackendURL, "{status}", strconv.Itoa(code), -1)
+	body, header, err := ep.getErrorPage(finalURL, code)
+	if err != nil {
+		log.Errorf("Error getting the error page %s, returning the status text: %v", finalURL, err)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(code)
+		w.Write([]byte(http.StatusText(code)))
+		return
+	}
+
+	utils.CopyHeaders(w.Header(), header)
+	utils.RemoveHeaders(w.Header(), forward.HopHeaders...)
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+func (ep *ErrorPagesHandler) getErrorPage(url string, code int) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set(ErrorPageStatusHeader, strconv.Itoa(code))
+
+	resp, err := ep.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// the body is read before writing the response, to fall back to the status text if the backend times out while sending it
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Header, nil
+}
+
 type errorPagesResponseRecorder interface {
 	http.ResponseWriter
 	http.Flusher
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
@@ -153,6 +154,147 @@ func TestErrorPageSingleCode(t *testing.T) {
 	assert.NotContains(t, recorder.Body.String(), "oops", "Should not return the oops page")
 }
 
+func TestErrorPageBackend(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		errorPage       *types.ErrorPage
+		backendHandler  http.HandlerFunc
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			desc:      "backend response",
+			errorPage: &types.ErrorPage{Status: []string{"500-599"}, Query: "/{status}.html"},
+			backendHandler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "text/html")
+				rw.Header().Set("Connection", "close")
+				rw.WriteHeader(http.StatusOK)
+				fmt.Fprintf(rw, "<h1>%s %s</h1>", req.URL.Path, req.Header.Get(ErrorPageStatusHeader))
+			},
+			expectedBody:    "<h1>/503.html 503</h1>",
+			expectedHeaders: map[string]string{"Content-Type": "text/html", "Connection": ""},
+		},
+		{
+			desc:      "backend timeout",
+			errorPage: &types.ErrorPage{Status: []string{"500-599"}, Timeout: "50ms"},
+			backendHandler: func(rw http.ResponseWriter, req *http.Request) {
+				time.Sleep(time.Second)
+				fmt.Fprint(rw, "too late")
+			},
+			expectedBody:    http.StatusText(http.StatusServiceUnavailable),
+			expectedHeaders: map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+		},
+		{
+			desc:      "backend redirection",
+			errorPage: &types.ErrorPage{Status: []string{"500-599"}},
+			backendHandler: func(rw http.ResponseWriter, req *http.Request) {
+				http.Redirect(rw, req, "/elsewhere", http.StatusFound)
+			},
+			expectedBody:    "",
+			expectedHeaders: map[string]string{"Location": "/elsewhere"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(test.backendHandler)
+			defer ts.Close()
+
+			errorPageHandler, err := NewErrorPagesHandler(test.errorPage, ts.URL)
+			require.NoError(t, err)
+
+			n := negroni.New()
+			n.Use(errorPageHandler)
+			n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(rw, "oops")
+			}))
+
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+
+			assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			if len(test.expectedBody) > 0 {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+			assert.NotContains(t, recorder.Body.String(), "oops")
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+		})
+	}
+}
+
+func TestErrorPageUnreachableBackend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	ts.Close()
+
+	errorPageHandler, err := NewErrorPagesHandler(&types.ErrorPage{Status: []string{"500"}}, ts.URL)
+	require.NoError(t, err)
+
+	n := negroni.New()
+	n.Use(errorPageHandler)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), recorder.Body.String())
+}
+
+func TestErrorPagePassThroughHeaders(t *testing.T) {
+	errorPageHandler, err := NewErrorPagesHandler(&types.ErrorPage{Status: []string{"500-599"}}, "http://127.0.0.1")
+	require.NoError(t, err)
+
+	n := negroni.New()
+	n.Use(errorPageHandler)
+	n.UseHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Custom", "foo")
+		rw.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(rw, "not found")
+	}))
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.com/", nil))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "foo", recorder.Header().Get("X-Custom"))
+	assert.Equal(t, "not found", recorder.Body.String())
+}
+
+func TestNewErrorPagesHandlerTimeout(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		timeout       string
+		expectedError bool
+	}{
+		{desc: "default timeout"},
+		{desc: "valid timeout", timeout: "2s"},
+		{desc: "invalid timeout", timeout: "foo", expectedError: true},
+		{desc: "negative timeout", timeout: "-2s", expectedError: true},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewErrorPagesHandler(&types.ErrorPage{Status: []string{"500"}, Timeout: test.timeout}, "http://127.0.0.1")
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestNewErrorPagesResponseRecorder(t *testing.T) {
 	testCases := []struct {
 		desc     string
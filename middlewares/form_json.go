@@ -0,0 +1,287 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/containous/traefik/middlewares/tracing"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	formContentType = "application/x-www-form-urlencoded"
+	jsonContentType = "application/json"
+
+	// DefaultFormJSONMaxBodySize is the size of the largest body converted, when not configured.
+	DefaultFormJSONMaxBodySize = 1024 * 1024
+)
+
+// FormJSON is a middleware converting the form encoded bodies of the requests into JSON objects,
+// each field holding a string, or an array of strings when it has several values.
+// The JSON objects of the responses, whose fields hold scalars or arrays of scalars, can be converted back into form encoded bodies.
+type FormJSON struct {
+	next        http.Handler
+	responses   bool
+	maxBodySize int64
+}
+
+// NewFormJSON creates a FormJSON converting the bodies of the requests to next.
+func NewFormJSON(next http.Handler, config *types.FormJSON) (*FormJSON, error) {
+	if config.MaxBodySize < 0 {
+		return nil, fmt.Errorf("invalid negative maximum body size %d", config.MaxBodySize)
+	}
+
+	maxBodySize := config.MaxBodySize
+	if maxBodySize == 0 {
+		maxBodySize = DefaultFormJSONMaxBodySize
+	}
+	return &FormJSON{
+		next:        next,
+		responses:   config.Responses,
+		maxBodySize: maxBodySize,
+	}, nil
+}
+
+func (f *FormJSON) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Body != nil && hasMediaType(req.Header.Get("Content-Type"), formContentType) {
+		body, err := ioutil.ReadAll(io.LimitReader(req.Body, f.maxBodySize+1))
+		if err != nil {
+			tracing.SetErrorAndDebugLog(req, "unable to read the form body: %v", err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > f.maxBodySize {
+			tracing.SetErrorAndDebugLog(req, "form body larger than %d bytes", f.maxBodySize)
+			http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		jsonBody, err := formToJSON(body)
+		if err != nil {
+			tracing.SetErrorAndDebugLog(req, "invalid form body: %v", err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		jsonReq := req.WithContext(req.Context())
+		jsonReq.Header = make(http.Header, len(req.Header))
+		for name, values := range req.Header {
+			jsonReq.Header[name] = values
+		}
+		jsonReq.Header.Set("Content-Type", jsonContentType)
+		jsonReq.Header.Del("Content-Length")
+		jsonReq.Body = ioutil.NopCloser(bytes.NewReader(jsonBody))
+		jsonReq.ContentLength = int64(len(jsonBody))
+		jsonReq.TransferEncoding = nil
+		req = jsonReq
+	}
+
+	if !f.responses {
+		f.next.ServeHTTP(rw, req)
+		return
+	}
+
+	writer := newFormJSONResponseWriter(rw, f.maxBodySize)
+	f.next.ServeHTTP(writer, req)
+	writer.(formJSONFinisher).finish()
+}
+
+// hasMediaType reports whether the media type of a Content-Type header, without its parameters, is mediaType.
+func hasMediaType(contentType string, mediaType string) bool {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	return err == nil && parsed == mediaType
+}
+
+// formToJSON converts a form encoded body into a JSON object, whose fields are in the order of their names.
+func formToJSON(body []byte) ([]byte, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]interface{}, len(values))
+	for name, fieldValues := range values {
+		if len(fieldValues) == 1 {
+			object[name] = fieldValues[0]
+		} else {
+			object[name] = fieldValues
+		}
+	}
+	return json.Marshal(object)
+}
+
+// jsonToForm converts a JSON object into a form encoded body, the fields holding an array having a value per element.
+// It fails when the body is not a JSON object, or when a field holds an object or null.
+func jsonToForm(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+
+	values := make(url.Values, len(object))
+	for name, value := range object {
+		elements, ok := value.([]interface{})
+		if !ok {
+			elements = []interface{}{value}
+		}
+		for _, element := range elements {
+			formValue, err := formValue(element)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", name, err)
+			}
+			values.Add(name, formValue)
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+func formValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+type formJSONFinisher interface {
+	finish()
+}
+
+func newFormJSONResponseWriter(rw http.ResponseWriter, maxBodySize int64) http.ResponseWriter {
+	writer := &formJSONResponseWriterWithoutCloseNotify{
+		responseWriter: rw,
+		maxBodySize:    maxBodySize,
+	}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &formJSONResponseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
+
+// formJSONResponseWriterWithoutCloseNotify buffers the JSON responses to convert them into form encoded ones.
+// The other responses, and the JSON responses which can't be converted or are larger than maxBodySize, are sent as is.
+type formJSONResponseWriterWithoutCloseNotify struct {
+	responseWriter http.ResponseWriter
+	maxBodySize    int64
+	code           int
+	buffering      bool
+	body           bytes.Buffer
+}
+
+func (rw *formJSONResponseWriterWithoutCloseNotify) Header() http.Header {
+	return rw.responseWriter.Header()
+}
+
+func (rw *formJSONResponseWriterWithoutCloseNotify) WriteHeader(code int) {
+	if rw.code != 0 {
+		return
+	}
+	rw.code = code
+
+	rw.buffering = code != http.StatusNoContent && code != http.StatusNotModified &&
+		hasMediaType(rw.Header().Get("Content-Type"), jsonContentType)
+	if !rw.buffering {
+		rw.responseWriter.WriteHeader(code)
+	}
+}
+
+func (rw *formJSONResponseWriterWithoutCloseNotify) Write(b []byte) (int, error) {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.buffering {
+		return rw.responseWriter.Write(b)
+	}
+
+	if int64(rw.body.Len()+len(b)) > rw.maxBodySize {
+		if err := rw.stopBuffering(); err != nil {
+			return 0, err
+		}
+		return rw.responseWriter.Write(b)
+	}
+	return rw.body.Write(b)
+}
+
+// stopBuffering sends the buffered response as is, the rest of the body being sent without being buffered.
+func (rw *formJSONResponseWriterWithoutCloseNotify) stopBuffering() error {
+	rw.buffering = false
+	rw.responseWriter.WriteHeader(rw.code)
+	_, err := rw.responseWriter.Write(rw.body.Bytes())
+	rw.body.Reset()
+	return err
+}
+
+// Hijack hijacks the connection
+func (rw *formJSONResponseWriterWithoutCloseNotify) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", rw.responseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+// A JSON response being buffered is kept buffered until its end, as the forwarder flushes the responses periodically.
+func (rw *formJSONResponseWriterWithoutCloseNotify) Flush() {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.buffering {
+		return
+	}
+	if flusher, ok := rw.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish converts and sends the buffered JSON response, once the wrapped handler has returned.
+func (rw *formJSONResponseWriterWithoutCloseNotify) finish() {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.buffering {
+		return
+	}
+
+	// a compressed response can't be converted
+	form, err := jsonToForm(rw.body.Bytes())
+	if err != nil || len(rw.Header().Get("Content-Encoding")) > 0 {
+		rw.stopBuffering()
+		return
+	}
+
+	rw.Header().Set("Content-Type", formContentType)
+	rw.Header().Set("Content-Length", strconv.Itoa(len(form)))
+	rw.responseWriter.WriteHeader(rw.code)
+	rw.responseWriter.Write(form)
+}
+
+type formJSONResponseWriterWithCloseNotify struct {
+	*formJSONResponseWriterWithoutCloseNotify
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (rw *formJSONResponseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.responseWriter.(http.CloseNotifier).CloseNotify()
+}
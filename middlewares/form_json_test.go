@@ -0,0 +1,206 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/forward"
+)
+
+func TestFormJSONRequests(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		contentType         string
+		body                string
+		maxBodySize         int64
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			desc:                "form converted",
+			contentType:         "application/x-www-form-urlencoded",
+			body:                "name=traefik&tags=proxy&tags=go&empty=",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"empty":"","name":"traefik","tags":["proxy","go"]}`,
+		},
+		{
+			desc:                "form with a charset converted",
+			contentType:         "application/x-www-form-urlencoded; charset=utf-8",
+			body:                "name=tr%C3%A6fik",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"træfik"}`,
+		},
+		{
+			desc:                "other content type forwarded as is",
+			contentType:         "text/plain",
+			body:                "name=traefik",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "name=traefik",
+		},
+		{
+			desc:           "invalid form",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "name=%zz",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			desc:           "form too large",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "name=traefik",
+			maxBodySize:    10,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var contentType, body string
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				contentType = req.Header.Get("Content-Type")
+				data, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(data)), req.ContentLength)
+				body = string(data)
+			})
+
+			formJSON, err := NewFormJSON(next, &types.FormJSON{MaxBodySize: test.maxBodySize})
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodPost, "http://localhost/", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			recorder := httptest.NewRecorder()
+			formJSON.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedContentType, contentType)
+			assert.Equal(t, test.expectedBody, body)
+		})
+	}
+}
+
+func TestFormJSONResponses(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		contentType         string
+		body                string
+		maxBodySize         int64
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			desc:                "JSON object converted",
+			contentType:         "application/json",
+			body:                `{"name":"traefik","stars":20000,"stable":true,"tags":["proxy","go"]}`,
+			expectedContentType: "application/x-www-form-urlencoded",
+			expectedBody:        "name=traefik&stable=true&stars=20000&tags=proxy&tags=go",
+		},
+		{
+			desc:                "nested JSON object sent as is",
+			contentType:         "application/json",
+			body:                `{"name":{"first":"traefik"}}`,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":{"first":"traefik"}}`,
+		},
+		{
+			desc:                "JSON array sent as is",
+			contentType:         "application/json",
+			body:                `["traefik"]`,
+			expectedContentType: "application/json",
+			expectedBody:        `["traefik"]`,
+		},
+		{
+			desc:                "JSON object too large sent as is",
+			contentType:         "application/json",
+			body:                `{"name":"traefik"}`,
+			maxBodySize:         10,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"traefik"}`,
+		},
+		{
+			desc:                "other content type sent as is",
+			contentType:         "text/plain",
+			body:                `{"name":"traefik"}`,
+			expectedContentType: "text/plain",
+			expectedBody:        `{"name":"traefik"}`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", test.contentType)
+				rw.WriteHeader(http.StatusCreated)
+				// the body is written in two parts
+				rw.Write([]byte(test.body[:len(test.body)/2]))
+				rw.Write([]byte(test.body[len(test.body)/2:]))
+			})
+
+			formJSON, err := NewFormJSON(next, &types.FormJSON{Responses: true, MaxBodySize: test.maxBodySize})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			formJSON.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost/", nil))
+
+			assert.Equal(t, http.StatusCreated, recorder.Code)
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestFormJSONResponsesSlowBackend(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte(`{"name":`))
+		rw.(http.Flusher).Flush()
+		// slower than the flush interval of the forwarder
+		time.Sleep(50 * time.Millisecond)
+		rw.Write([]byte(`"traefik"}`))
+	}))
+	defer backend.Close()
+
+	fwd, err := forward.New(forward.Stream(true), forward.StreamingFlushInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		req.URL = testhelpers.MustParseURL(backend.URL)
+		fwd.ServeHTTP(rw, req)
+	})
+
+	formJSON, err := NewFormJSON(next, &types.FormJSON{Responses: true})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	formJSON.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost/", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-www-form-urlencoded", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "name=traefik", recorder.Body.String())
+	assert.False(t, recorder.Flushed)
+}
+
+func TestNewFormJSONInvalidMaxBodySize(t *testing.T) {
+	_, err := NewFormJSON(http.NotFoundHandler(), &types.FormJSON{MaxBodySize: -1})
+	assert.Error(t, err)
+}
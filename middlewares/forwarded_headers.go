@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/whitelist"
+	"github.com/vulcand/oxy/forward"
+)
+
+// ForwardedHeaders is a middleware removing the X-Forwarded-* and X-Real-Ip headers of the requests
+// which were not sent by a trusted IP, so that the access logs, the whitelists and the backends never rely on spoofed values
+type ForwardedHeaders struct {
+	insecure   bool
+	trustedIPs *whitelist.IP
+}
+
+// NewForwardedHeaders creates a ForwardedHeaders trusting the headers sent by trustedIPs, or by any client if insecure
+func NewForwardedHeaders(insecure bool, trustedIPs []string) (*ForwardedHeaders, error) {
+	ips, err := whitelist.NewIP(trustedIPs, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForwardedHeaders{
+		insecure:   insecure,
+		trustedIPs: ips,
+	}, nil
+}
+
+func (f *ForwardedHeaders) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !f.insecure && !f.isTrusted(r.RemoteAddr) {
+		for _, header := range forward.XHeaders {
+			r.Header.Del(header)
+		}
+	}
+	next.ServeHTTP(rw, r)
+}
+
+func (f *ForwardedHeaders) isTrusted(remoteAddr string) bool {
+	clientIP, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		clientIP = remoteAddr
+	}
+
+	trusted, _, err := f.trustedIPs.Contains(clientIP)
+	if err != nil {
+		log.Debugf("Unable to check if the forwarded headers of %s are trusted: %v", remoteAddr, err)
+		return false
+	}
+	return trusted
+}
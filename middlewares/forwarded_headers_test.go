@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardedHeaders(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		insecure        bool
+		trustedIPs      []string
+		remoteAddr      string
+		expectedHeaders bool
+	}{
+		{
+			desc:            "insecure",
+			insecure:        true,
+			remoteAddr:      "10.0.1.1:80",
+			expectedHeaders: true,
+		},
+		{
+			desc:            "trusted IP",
+			trustedIPs:      []string{"10.0.1.0/24"},
+			remoteAddr:      "10.0.1.1:80",
+			expectedHeaders: true,
+		},
+		{
+			desc:            "untrusted IP",
+			trustedIPs:      []string{"10.0.1.0/24"},
+			remoteAddr:      "10.0.2.1:80",
+			expectedHeaders: false,
+		},
+		{
+			desc:            "trusted IPv6",
+			trustedIPs:      []string{"10.0.1.0/24", "2001:db8::/32"},
+			remoteAddr:      "[2001:db8::1]:80",
+			expectedHeaders: true,
+		},
+		{
+			desc:            "trusted IPv6 with a zone",
+			trustedIPs:      []string{"fe80::/64"},
+			remoteAddr:      "[fe80::1%eth0]:80",
+			expectedHeaders: true,
+		},
+		{
+			desc:            "untrusted IPv6",
+			trustedIPs:      []string{"10.0.1.0/24", "2001:db8::/32"},
+			remoteAddr:      "[2001:db9::1]:80",
+			expectedHeaders: false,
+		},
+		{
+			desc:            "trusted IPv4-mapped IPv6",
+			trustedIPs:      []string{"10.0.1.0/24"},
+			remoteAddr:      "[::ffff:10.0.1.1]:80",
+			expectedHeaders: true,
+		},
+		{
+			desc:            "invalid remote address",
+			trustedIPs:      []string{"10.0.1.0/24"},
+			remoteAddr:      "foo",
+			expectedHeaders: false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			forwardedHeaders, err := NewForwardedHeaders(test.insecure, test.trustedIPs)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Set("X-Forwarded-For", "10.0.3.1, 2001:db8:3::1, [2001:db8:4::1]:443")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Real-Ip", "10.0.3.1")
+
+			var headers http.Header
+			next := func(rw http.ResponseWriter, req *http.Request) {
+				headers = req.Header
+			}
+			forwardedHeaders.ServeHTTP(httptest.NewRecorder(), req, next)
+
+			for _, name := range []string{"X-Forwarded-For", "X-Forwarded-Proto", "X-Real-Ip"} {
+				if test.expectedHeaders {
+					assert.NotEmpty(t, headers.Get(name), name)
+				} else {
+					assert.Empty(t, headers.Get(name), name)
+				}
+			}
+		})
+	}
+}
+
+func TestForwardedHeadersWithoutTrustedIPs(t *testing.T) {
+	_, err := NewForwardedHeaders(false, nil)
+	assert.Error(t, err)
+}
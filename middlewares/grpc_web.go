@@ -0,0 +1,337 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/containous/traefik/types"
+)
+
+const (
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	// grpcWebTrailerFlag marks the frame holding the trailers, at the end of a gRPC-Web response
+	grpcWebTrailerFlag = 0x80
+)
+
+// GRPCWeb is a middleware translating the gRPC-Web requests of the browsers into gRPC requests,
+// and the gRPC responses, whose trailers are sent in the last frame of the body, into gRPC-Web responses.
+// It answers the CORS preflight requests of the allowed origins as well.
+type GRPCWeb struct {
+	next           http.Handler
+	allowedOrigins []string
+}
+
+// NewGRPCWeb creates a GRPCWeb translating the gRPC-Web requests to next.
+func NewGRPCWeb(next http.Handler, config *types.GRPCWeb) *GRPCWeb {
+	return &GRPCWeb{
+		next:           next,
+		allowedOrigins: config.AllowedOrigins,
+	}
+}
+
+func (g *GRPCWeb) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	if req.Method == http.MethodOptions && len(origin) > 0 && len(req.Header.Get("Access-Control-Request-Method")) > 0 && len(g.allowedOrigins) > 0 {
+		g.servePreflight(rw, req, origin)
+		return
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if req.Method != http.MethodPost || !strings.HasPrefix(contentType, grpcWebContentType) {
+		g.next.ServeHTTP(rw, req)
+		return
+	}
+
+	if len(origin) > 0 && g.isAllowedOrigin(origin) {
+		rw.Header().Set("Access-Control-Allow-Origin", origin)
+		rw.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message")
+		rw.Header().Add("Vary", "Origin")
+	}
+
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	var subtype string
+	if text {
+		subtype = strings.TrimPrefix(contentType, grpcWebTextContentType)
+	} else {
+		subtype = strings.TrimPrefix(contentType, grpcWebContentType)
+	}
+
+	grpcReq := req.WithContext(req.Context())
+	grpcReq.Header = make(http.Header, len(req.Header))
+	for name, values := range req.Header {
+		grpcReq.Header[name] = values
+	}
+	grpcReq.Header.Set("Content-Type", grpcContentType+subtype)
+	grpcReq.Header.Set("Te", "trailers")
+	grpcReq.Header.Del("X-Grpc-Web")
+	if text {
+		grpcReq.Body = &base64BodyReader{body: req.Body}
+		grpcReq.ContentLength = -1
+		grpcReq.Header.Del("Content-Length")
+	}
+
+	responseContentType := grpcWebContentType + subtype
+	if text {
+		responseContentType = grpcWebTextContentType + subtype
+	}
+	writer := newGRPCWebResponseWriter(rw, responseContentType, text)
+	g.next.ServeHTTP(writer, grpcReq)
+	writer.(grpcWebFinisher).finish()
+}
+
+func (g *GRPCWeb) servePreflight(rw http.ResponseWriter, req *http.Request, origin string) {
+	if !g.isAllowedOrigin(origin) {
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	header := rw.Header()
+	header.Set("Access-Control-Allow-Origin", origin)
+	header.Set("Access-Control-Allow-Methods", http.MethodPost)
+	if requestedHeaders := req.Header.Get("Access-Control-Request-Headers"); len(requestedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", requestedHeaders)
+	}
+	header.Set("Access-Control-Max-Age", "600")
+	header.Add("Vary", "Origin")
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (g *GRPCWeb) isAllowedOrigin(origin string) bool {
+	for _, allowedOrigin := range g.allowedOrigins {
+		if allowedOrigin == "*" || strings.EqualFold(allowedOrigin, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// base64BodyReader decodes the body of a gRPC-Web text request,
+// made of base64 chunks which can each be padded.
+type base64BodyReader struct {
+	body    io.ReadCloser
+	encoded []byte
+	decoded []byte
+	err     error
+}
+
+func (r *base64BodyReader) Read(p []byte) (int, error) {
+	for len(r.decoded) == 0 {
+		if r.err != nil {
+			if r.err == io.EOF && len(r.encoded) > 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, r.err
+		}
+
+		buf := make([]byte, 4096)
+		n, err := r.body.Read(buf)
+		r.err = err
+		r.encoded = append(r.encoded, buf[:n]...)
+
+		// the quanta of 4 characters are decoded separately, as the padding can end each chunk
+		quanta := len(r.encoded) / 4 * 4
+		decoded := make([]byte, 3)
+		for i := 0; i < quanta; i += 4 {
+			m, err := base64.StdEncoding.Decode(decoded, r.encoded[i:i+4])
+			if err != nil {
+				r.err = err
+				r.encoded = nil
+				break
+			}
+			r.decoded = append(r.decoded, decoded[:m]...)
+		}
+		if r.encoded != nil {
+			r.encoded = append([]byte(nil), r.encoded[quanta:]...)
+		}
+	}
+
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+	return n, nil
+}
+
+func (r *base64BodyReader) Close() error {
+	return r.body.Close()
+}
+
+type grpcWebFinisher interface {
+	finish()
+}
+
+func newGRPCWebResponseWriter(rw http.ResponseWriter, contentType string, text bool) http.ResponseWriter {
+	writer := &grpcWebResponseWriterWithoutCloseNotify{
+		responseWriter: rw,
+		header:         make(http.Header),
+		contentType:    contentType,
+		text:           text,
+	}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &grpcWebResponseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
+
+// grpcWebResponseWriterWithoutCloseNotify translates a gRPC response into a gRPC-Web one.
+// The headers set after the header is written, or declared in the Trailer header, are the trailers,
+// which are written in the last frame of the body.
+type grpcWebResponseWriterWithoutCloseNotify struct {
+	responseWriter http.ResponseWriter
+	header         http.Header
+	contentType    string
+	text           bool
+	wroteHeader    bool
+	translated     bool
+	headerNames    map[string]bool
+	trailerNames   []string
+	encoder        io.WriteCloser
+}
+
+func (rw *grpcWebResponseWriterWithoutCloseNotify) Header() http.Header {
+	return rw.header
+}
+
+func (rw *grpcWebResponseWriterWithoutCloseNotify) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	// the responses which are not gRPC ones, such as the errors of the proxy, are left as is
+	rw.translated = strings.HasPrefix(rw.header.Get("Content-Type"), grpcContentType)
+
+	rw.headerNames = make(map[string]bool, len(rw.header))
+	header := rw.responseWriter.Header()
+	for name, values := range rw.header {
+		rw.headerNames[name] = true
+		if rw.translated && name == "Trailer" {
+			for _, value := range values {
+				for _, trailerName := range strings.Split(value, ",") {
+					rw.trailerNames = append(rw.trailerNames, http.CanonicalHeaderKey(strings.TrimSpace(trailerName)))
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(name, http.TrailerPrefix) {
+			continue
+		}
+		header[name] = values
+	}
+
+	if rw.translated {
+		header.Set("Content-Type", rw.contentType)
+		header.Del("Content-Length")
+		if rw.text {
+			rw.encoder = base64.NewEncoder(base64.StdEncoding, rw.responseWriter)
+		}
+	}
+	rw.responseWriter.WriteHeader(code)
+}
+
+func (rw *grpcWebResponseWriterWithoutCloseNotify) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.encoder != nil {
+		return rw.encoder.Write(b)
+	}
+	return rw.responseWriter.Write(b)
+}
+
+// Hijack hijacks the connection
+func (rw *grpcWebResponseWriterWithoutCloseNotify) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", rw.responseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+// The base64 encoding of a text response is padded, and a new chunk is started.
+func (rw *grpcWebResponseWriterWithoutCloseNotify) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.encoder != nil {
+		rw.encoder.Close()
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, rw.responseWriter)
+	}
+	if flusher, ok := rw.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the trailers frame, once the wrapped handler has returned.
+func (rw *grpcWebResponseWriterWithoutCloseNotify) finish() {
+	if !rw.wroteHeader {
+		// a response without body holds its trailers in its header
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	if !rw.translated {
+		return
+	}
+
+	trailers := make(http.Header)
+	for _, name := range rw.trailerNames {
+		if values, ok := rw.header[name]; ok {
+			trailers[name] = values
+		}
+	}
+	for name, values := range rw.header {
+		if strings.HasPrefix(name, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(name, http.TrailerPrefix))] = values
+		} else if !rw.headerNames[name] {
+			trailers[name] = values
+		}
+	}
+
+	if len(trailers) > 0 {
+		rw.Write(grpcWebTrailersFrame(trailers))
+	}
+	if rw.encoder != nil {
+		rw.encoder.Close()
+	}
+}
+
+// grpcWebTrailersFrame encodes the trailers in a frame, as lower-case header lines.
+func grpcWebTrailersFrame(trailers http.Header) []byte {
+	var names []string
+	for name := range trailers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload := &bytes.Buffer{}
+	for _, name := range names {
+		for _, value := range trailers[name] {
+			fmt.Fprintf(payload, "%s: %s\r\n", strings.ToLower(name), value)
+		}
+	}
+
+	frame := make([]byte, 5, 5+payload.Len())
+	frame[0] = grpcWebTrailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(payload.Len()))
+	return append(frame, payload.Bytes()...)
+}
+
+type grpcWebResponseWriterWithCloseNotify struct {
+	*grpcWebResponseWriterWithoutCloseNotify
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (rw *grpcWebResponseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.responseWriter.(http.CloseNotifier).CloseNotify()
+}
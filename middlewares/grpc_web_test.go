@@ -0,0 +1,302 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func grpcFrame(flag byte, payload string) []byte {
+	frame := make([]byte, 5)
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestGRPCWeb(t *testing.T) {
+	requestFrame := grpcFrame(0, "request")
+	responseFrame := grpcFrame(0, "response")
+	trailersFrame := grpcFrame(grpcWebTrailerFlag, "grpc-message: ok\r\ngrpc-status: 0\r\n")
+
+	// the handler checks the gRPC request, and answers with a message followed by the trailers
+	grpcHandler := func(trailerPrefix bool) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil || !bytes.Equal(requestFrame, body) ||
+				req.Header.Get("Content-Type") != "application/grpc+proto" || req.Header.Get("Te") != "trailers" || len(req.Header.Get("X-Grpc-Web")) > 0 {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			rw.Header().Set("Content-Type", "application/grpc+proto")
+			if !trailerPrefix {
+				rw.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+			}
+			rw.WriteHeader(http.StatusOK)
+			rw.Write(responseFrame)
+			rw.(http.Flusher).Flush()
+			if trailerPrefix {
+				rw.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+				rw.Header().Set(http.TrailerPrefix+"Grpc-Message", "ok")
+			} else {
+				rw.Header().Set("Grpc-Status", "0")
+				rw.Header().Set("Grpc-Message", "ok")
+			}
+		}
+	}
+
+	testCases := []struct {
+		desc                string
+		handler             http.Handler
+		contentType         string
+		body                string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        []byte
+		expectedHeader      http.Header
+	}{
+		{
+			desc:                "binary request with declared trailers",
+			handler:             grpcHandler(false),
+			contentType:         "application/grpc-web+proto",
+			body:                string(requestFrame),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/grpc-web+proto",
+			expectedBody:        append(append([]byte{}, responseFrame...), trailersFrame...),
+		},
+		{
+			desc:                "binary request with undeclared trailers",
+			handler:             grpcHandler(true),
+			contentType:         "application/grpc-web+proto",
+			body:                string(requestFrame),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/grpc-web+proto",
+			expectedBody:        append(append([]byte{}, responseFrame...), trailersFrame...),
+		},
+		{
+			desc:                "text request in padded chunks",
+			handler:             grpcHandler(false),
+			contentType:         "application/grpc-web-text+proto",
+			body:                base64.StdEncoding.EncodeToString(requestFrame[:4]) + base64.StdEncoding.EncodeToString(requestFrame[4:]),
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/grpc-web-text+proto",
+			// the flush of the message ends a base64 chunk
+			expectedBody: []byte(base64.StdEncoding.EncodeToString(responseFrame) + base64.StdEncoding.EncodeToString(trailersFrame)),
+		},
+		{
+			desc: "trailers only response",
+			handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/grpc")
+				rw.Header().Set("Grpc-Status", "12")
+			}),
+			contentType:         "application/grpc-web",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/grpc-web",
+			expectedHeader:      http.Header{"Grpc-Status": {"12"}},
+		},
+		{
+			desc: "not a gRPC response",
+			handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "text/plain")
+				rw.WriteHeader(http.StatusBadGateway)
+				rw.Write([]byte("Bad Gateway"))
+			}),
+			contentType:         "application/grpc-web-text",
+			expectedStatus:      http.StatusBadGateway,
+			expectedContentType: "text/plain",
+			expectedBody:        []byte("Bad Gateway"),
+		},
+		{
+			desc: "not a gRPC-Web request",
+			handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", req.Header.Get("Content-Type"))
+				rw.Write([]byte("OK"))
+			}),
+			contentType:         "application/json",
+			body:                "{}",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        []byte("OK"),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewGRPCWeb(test.handler, &types.GRPCWeb{})
+
+			req := testhelpers.MustNewRequest(http.MethodPost, "http://localhost/helloworld.Greeter/SayHello", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			req.Header.Set("X-Grpc-Web", "1")
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, test.expectedBody, recorder.Body.Bytes())
+			assert.Empty(t, recorder.Header().Get("Trailer"))
+			for name := range test.expectedHeader {
+				assert.Equal(t, test.expectedHeader.Get(name), recorder.Header().Get(name))
+			}
+		})
+	}
+}
+
+func TestGRPCWebCORS(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		allowedOrigins []string
+		method         string
+		header         http.Header
+		expectedStatus int
+		expectedHeader http.Header
+	}{
+		{
+			desc:           "preflight of an allowed origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {"POST"},
+				"Access-Control-Request-Headers": {"content-type,x-grpc-web"},
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeader: http.Header{
+				"Access-Control-Allow-Origin":  {"https://app.example.com"},
+				"Access-Control-Allow-Methods": {"POST"},
+				"Access-Control-Allow-Headers": {"content-type,x-grpc-web"},
+			},
+		},
+		{
+			desc:           "preflight of any origin",
+			allowedOrigins: []string{"*"},
+			method:         http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://other.example.com"},
+				"Access-Control-Request-Method": {"POST"},
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeader: http.Header{
+				"Access-Control-Allow-Origin": {"https://other.example.com"},
+			},
+		},
+		{
+			desc:           "preflight of a forbidden origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://evil.example.com"},
+				"Access-Control-Request-Method": {"POST"},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedHeader: http.Header{
+				"Access-Control-Allow-Origin": {""},
+			},
+		},
+		{
+			desc:   "preflight without allowed origins",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://app.example.com"},
+				"Access-Control-Request-Method": {"POST"},
+			},
+			expectedStatus: http.StatusTeapot,
+		},
+		{
+			desc:           "request of an allowed origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			method:         http.MethodPost,
+			header: http.Header{
+				"Origin":       {"https://app.example.com"},
+				"Content-Type": {"application/grpc-web"},
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedHeader: http.Header{
+				"Access-Control-Allow-Origin":   {"https://app.example.com"},
+				"Access-Control-Expose-Headers": {"Grpc-Status, Grpc-Message"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusTeapot)
+			})
+			handler := NewGRPCWeb(next, &types.GRPCWeb{AllowedOrigins: test.allowedOrigins})
+
+			req := testhelpers.MustNewRequest(test.method, "http://localhost/helloworld.Greeter/SayHello", nil)
+			req.Header = test.header
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			for name := range test.expectedHeader {
+				assert.Equal(t, test.expectedHeader.Get(name), recorder.Header().Get(name), name)
+			}
+		})
+	}
+}
+
+func TestBase64BodyReader(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		body          string
+		expected      string
+		expectedError bool
+	}{
+		{
+			desc:     "single chunk",
+			body:     base64.StdEncoding.EncodeToString([]byte("hello world")),
+			expected: "hello world",
+		},
+		{
+			desc:     "padded chunks",
+			body:     base64.StdEncoding.EncodeToString([]byte("hello")) + base64.StdEncoding.EncodeToString([]byte(" world")),
+			expected: "hello world",
+		},
+		{
+			desc:          "invalid base64",
+			body:          "aGVsbG8*",
+			expectedError: true,
+		},
+		{
+			desc:          "truncated body",
+			body:          "aGVsbG8",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			reader := &base64BodyReader{body: ioutil.NopCloser(strings.NewReader(test.body))}
+			decoded, err := ioutil.ReadAll(reader)
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(decoded))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/vulcand/oxy/utils"
+)
+
+// HeaderOverride is a middleware sending the requests whose header matches a value to another handler,
+// such as a given server or a canary backend, instead of the load-balancer.
+type HeaderOverride struct {
+	next   http.Handler
+	target http.Handler
+	header string
+	value  string
+}
+
+// NewHeaderOverride creates a HeaderOverride.
+// The requests are sent to target when one of the values of the header is value, or when the header is not empty if value is empty.
+func NewHeaderOverride(next http.Handler, target http.Handler, header string, value string) *HeaderOverride {
+	return &HeaderOverride{
+		next:   next,
+		target: target,
+		header: http.CanonicalHeaderKey(header),
+		value:  value,
+	}
+}
+
+func (h *HeaderOverride) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if h.matches(req) {
+		h.target.ServeHTTP(rw, req)
+		return
+	}
+	h.next.ServeHTTP(rw, req)
+}
+
+func (h *HeaderOverride) matches(req *http.Request) bool {
+	for _, value := range req.Header[h.header] {
+		if len(h.value) == 0 && len(value) > 0 || len(h.value) > 0 && value == h.value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewServerForwarder creates a handler sending the requests to the server at serverURL with the forwarder,
+// as the load-balancers do for the server they select.
+func NewServerForwarder(forwarder http.Handler, serverURL *url.URL) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		newReq := *req
+		newReq.URL = utils.CopyURL(serverURL)
+		forwarder.ServeHTTP(rw, &newReq)
+	})
+}
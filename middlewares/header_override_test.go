@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderOverride(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		value          string
+		headers        []string
+		expectedTarget bool
+	}{
+		{
+			desc:           "matching value",
+			value:          "true",
+			headers:        []string{"true"},
+			expectedTarget: true,
+		},
+		{
+			desc:           "one of the values matching",
+			value:          "true",
+			headers:        []string{"false", "true"},
+			expectedTarget: true,
+		},
+		{
+			desc:    "other value",
+			value:   "true",
+			headers: []string{"false"},
+		},
+		{
+			desc:  "no header",
+			value: "true",
+		},
+		{
+			desc:           "any value",
+			headers:        []string{"foo"},
+			expectedTarget: true,
+		},
+		{
+			desc:    "empty value",
+			headers: []string{""},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte("next"))
+			})
+			target := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte("target"))
+			})
+			handler := NewHeaderOverride(next, target, "x-canary", test.value)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			for _, value := range test.headers {
+				req.Header.Add("X-Canary", value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if test.expectedTarget {
+				assert.Equal(t, "target", recorder.Body.String())
+			} else {
+				assert.Equal(t, "next", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerForwarder(t *testing.T) {
+	serverURL, err := url.Parse("http://10.0.0.1:8080")
+	require.NoError(t, err)
+
+	var forwardedURL *url.URL
+	forwarder := NewServerForwarder(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		forwardedURL = req.URL
+	}), serverURL)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/foo?bar=baz", nil)
+	forwarder.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, forwardedURL)
+	assert.Equal(t, "http://10.0.0.1:8080", forwardedURL.String())
+	assert.Equal(t, "/foo", req.URL.Path)
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/containous/traefik/log"
+)
+
+// DefaultMaxHeaderBytes is the default maximum size of the header of the requests, in bytes
+const DefaultMaxHeaderBytes = http.DefaultMaxHeaderBytes
+
+// HeaderSizeLimiter is a middleware rejecting with a 431 the requests whose header is larger than a maximum size,
+// logging the IP of their client.
+// The size of a header is the size of its request line and of its fields, as sent in HTTP/1.1.
+type HeaderSizeLimiter struct {
+	entryPointName string
+	maxBytes       int
+}
+
+// NewHeaderSizeLimiter creates a HeaderSizeLimiter for the requests of the given entrypoint.
+func NewHeaderSizeLimiter(entryPointName string, maxBytes int) *HeaderSizeLimiter {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxHeaderBytes
+	}
+	return &HeaderSizeLimiter{
+		entryPointName: entryPointName,
+		maxBytes:       maxBytes,
+	}
+}
+
+func (h *HeaderSizeLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	if size := headerSize(req); size > h.maxBytes {
+		clientIP, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			clientIP = req.RemoteAddr
+		}
+		log.Warnf("Rejecting request %s %s from %s on entrypoint %s: header size %d bytes exceeds %d bytes", req.Method, req.URL.Path, clientIP, h.entryPointName, size, h.maxBytes)
+		http.Error(rw, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+	next.ServeHTTP(rw, req)
+}
+
+func headerSize(req *http.Request) int {
+	// request line: METHOD URI PROTO\r\n
+	size := len(req.Method) + len(req.RequestURI) + len(req.Proto) + 4
+	if len(req.Host) > 0 {
+		// the Host field is removed from the header by the server
+		size += len("Host: \r\n") + len(req.Host)
+	}
+	for name, values := range req.Header {
+		for _, value := range values {
+			size += len(name) + len(value) + len(": \r\n")
+		}
+	}
+	return size
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/negroni"
+)
+
+func TestHeaderSizeLimiter(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		maxBytes     int
+		cookieSize   int
+		expectedCode int
+	}{
+		{
+			desc:         "header smaller than the limit",
+			maxBytes:     1024,
+			cookieSize:   512,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "header larger than the limit",
+			maxBytes:     1024,
+			cookieSize:   1024,
+			expectedCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			desc:         "default limit",
+			cookieSize:   DefaultMaxHeaderBytes,
+			expectedCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			n := negroni.New(NewHeaderSizeLimiter("http", test.maxBytes))
+			n.UseHandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost/foo", nil)
+			req.RequestURI = "/foo"
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("Cookie", "session="+strings.Repeat("a", test.cookieSize))
+
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, req)
+			assert.Equal(t, test.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestHeaderSize(t *testing.T) {
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost/foo?bar=1", nil)
+	req.RequestURI = "/foo?bar=1"
+	req.Header.Set("Accept", "*/*")
+	req.Header.Add("Cookie", "a=1")
+	req.Header.Add("Cookie", "b=2")
+
+	raw := "GET /foo?bar=1 HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Accept: */*\r\n" +
+		"Cookie: a=1\r\n" +
+		"Cookie: b=2\r\n"
+	assert.Equal(t, len(raw), headerSize(req))
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/vulcand/oxy/forward"
+)
+
+// HostHeader is a middleware used to override the Host header of a request
+// with the value expected by the backend
+type HostHeader struct {
+	Handler http.Handler
+	Host    string
+}
+
+func (h *HostHeader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(forward.XForwardedHost) == "" && r.Host != "" {
+		r.Header.Set(forward.XForwardedHost, r.Host)
+	}
+	r.Host = h.Host
+	h.Handler.ServeHTTP(w, r)
+}
+
+// SetHandler sets handler
+func (h *HostHeader) SetHandler(Handler http.Handler) {
+	h.Handler = Handler
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/vulcand/oxy/forward"
+)
+
+func TestHostHeader(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		host                  string
+		forwardedHost         string
+		expectedHost          string
+		expectedForwardedHost string
+	}{
+		{
+			desc:                  "host is overridden",
+			host:                  "backend.internal",
+			expectedHost:          "backend.internal",
+			expectedForwardedHost: "localhost",
+		},
+		{
+			desc:                  "existing X-Forwarded-Host is kept",
+			host:                  "backend.internal",
+			forwardedHost:         "public.example.com",
+			expectedHost:          "backend.internal",
+			expectedForwardedHost: "public.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var actualHost, actualForwardedHost string
+			handler := &HostHeader{
+				Host: test.host,
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					actualHost = r.Host
+					actualForwardedHost = r.Header.Get(forward.XForwardedHost)
+				}),
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost/", nil)
+			if test.forwardedHost != "" {
+				req.Header.Set(forward.XForwardedHost, test.forwardedHost)
+			}
+
+			handler.ServeHTTP(nil, req)
+
+			assert.Equal(t, test.expectedHost, actualHost, "Unexpected host.")
+			assert.Equal(t, test.expectedForwardedHost, actualForwardedHost, "Unexpected X-Forwarded-Host.")
+		})
+	}
+}
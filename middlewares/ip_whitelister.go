@@ -42,14 +42,13 @@ func NewIPWhitelister(whitelistStrings []string) (*IPWhiteLister, error) {
 func (wl *IPWhiteLister) handle(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		tracing.SetErrorAndWarnLog(r, "unable to parse remote-address from header: %s - rejecting", r.RemoteAddr)
-		reject(w)
-		return
+		// the remote address has no port
+		ipAddress = r.RemoteAddr
 	}
 
 	allowed, ip, err := wl.whiteLister.Contains(ipAddress)
 	if err != nil {
-		tracing.SetErrorAndDebugLog(r, "source-IP %s matched none of the whitelists - rejecting", ipAddress)
+		tracing.SetErrorAndWarnLog(r, "unable to parse remote-address %s: %v - rejecting", r.RemoteAddr, err)
 		reject(w)
 		return
 	}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/containous/traefik/log"
+	"golang.org/x/text/language"
+)
+
+// LanguageBackends forwards the requests of a frontend to the backend of the locale best matching their Accept-Language header,
+// the languages being tried by decreasing quality, or else to the default backend.
+type LanguageBackends struct {
+	matcher language.Matcher
+	// handlers holds the handler of each supported tag of the matcher, the first one being the default handler
+	handlers []http.Handler
+	locales  []string
+}
+
+// NewLanguageBackends creates a LanguageBackends forwarding the requests to the handlers of the locales, such as fr or en-GB,
+// and to defaultHandler when none of them is acceptable.
+func NewLanguageBackends(locales map[string]http.Handler, defaultHandler http.Handler) (*LanguageBackends, error) {
+	var names []string
+	for locale := range locales {
+		names = append(names, locale)
+	}
+	sort.Strings(names)
+
+	tags := []language.Tag{language.Und}
+	handlers := []http.Handler{defaultHandler}
+	seen := make(map[language.Tag]string)
+	for _, locale := range names {
+		tag, err := parseLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[tag]; ok {
+			return nil, fmt.Errorf("locales %q and %q are the same", other, locale)
+		}
+		seen[tag] = locale
+
+		tags = append(tags, tag)
+		handlers = append(handlers, locales[locale])
+	}
+
+	return &LanguageBackends{
+		matcher:  language.NewMatcher(tags),
+		handlers: handlers,
+		locales:  append([]string{"default"}, names...),
+	}, nil
+}
+
+// parseLocale parses a locale of the language backends, returning its canonical tag.
+func parseLocale(locale string) (language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid locale %q: %v", locale, err)
+	}
+	if tag == language.Und {
+		return language.Und, fmt.Errorf("invalid locale %q: no language", locale)
+	}
+	return tag, nil
+}
+
+func (l *LanguageBackends) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	index := l.match(req.Header.Get("Accept-Language"))
+	log.Debugf("Forwarding the request to the backend of the locale %s", l.locales[index])
+
+	// the response depends on the Accept-Language header of the request
+	rw.Header().Add("Vary", "Accept-Language")
+	l.handlers[index].ServeHTTP(rw, req)
+}
+
+// match returns the index of the handler of the locale best matching the Accept-Language header
+func (l *LanguageBackends) match(acceptLanguage string) int {
+	if len(acceptLanguage) == 0 {
+		return 0
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		log.Debugf("Invalid Accept-Language header %q: %v", acceptLanguage, err)
+		return 0
+	}
+
+	// the matcher would prefer an exact match of any language to a close match of the preferred one,
+	// so the languages, sorted by quality, are matched one by one
+	for _, tag := range tags {
+		if _, index, confidence := l.matcher.Match(tag); confidence != language.No {
+			return index
+		}
+	}
+	return 0
+}
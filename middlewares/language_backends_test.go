@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLanguageBackendsInvalid(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		locales []string
+	}{
+		{
+			desc:    "invalid locale",
+			locales: []string{"fr", "not a locale"},
+		},
+		{
+			desc:    "undetermined locale",
+			locales: []string{"und"},
+		},
+		{
+			desc:    "duplicated locale",
+			locales: []string{"en-GB", "en-gb"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			locales := make(map[string]http.Handler)
+			for _, locale := range test.locales {
+				locales[locale] = newNamedHandler(locale)
+			}
+
+			_, err := NewLanguageBackends(locales, newNamedHandler("default"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLanguageBackends(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			desc:     "no Accept-Language",
+			expected: "default",
+		},
+		{
+			desc:           "exact match",
+			acceptLanguage: "fr",
+			expected:       "fr",
+		},
+		{
+			desc:           "region of a language",
+			acceptLanguage: "fr-CH",
+			expected:       "fr",
+		},
+		{
+			desc:           "region",
+			acceptLanguage: "en-GB",
+			expected:       "en-GB",
+		},
+		{
+			desc:           "another region of a language",
+			acceptLanguage: "en-US",
+			expected:       "en-GB",
+		},
+		{
+			desc:           "first language by quality",
+			acceptLanguage: "de;q=0.5, fr;q=0.8, en-GB;q=0.7",
+			expected:       "fr",
+		},
+		{
+			desc:           "close match of the preferred language",
+			acceptLanguage: "fr-BE, de;q=0.9",
+			expected:       "fr",
+		},
+		{
+			desc:           "unsupported language preferred",
+			acceptLanguage: "es, de;q=0.5",
+			expected:       "de",
+		},
+		{
+			desc:           "language of a zero quality",
+			acceptLanguage: "fr;q=0, es",
+			expected:       "default",
+		},
+		{
+			desc:           "unsupported language",
+			acceptLanguage: "ja",
+			expected:       "default",
+		},
+		{
+			desc:           "any language",
+			acceptLanguage: "*",
+			expected:       "default",
+		},
+		{
+			desc:           "invalid header",
+			acceptLanguage: "fr;q=foo",
+			expected:       "default",
+		},
+	}
+
+	languageBackends, err := NewLanguageBackends(map[string]http.Handler{
+		"fr":    newNamedHandler("fr"),
+		"de":    newNamedHandler("de"),
+		"en-GB": newNamedHandler("en-GB"),
+	}, newNamedHandler("default"))
+	require.NoError(t, err)
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			if len(test.acceptLanguage) > 0 {
+				req.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+			recorder := httptest.NewRecorder()
+			languageBackends.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expected, recorder.Body.String())
+			assert.Equal(t, "Accept-Language", recorder.Header().Get("Vary"))
+		})
+	}
+}
+
+func newNamedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(name))
+	})
+}
@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+// LeastConn is a load-balancer sending each request to the server with the fewest requests in flight.
+// With a sticky session, a request is sent to the server of its cookie while this server is available,
+// otherwise the least loaded server is selected and the cookie is updated to stick to it.
+type LeastConn struct {
+	next          http.Handler
+	stickySession *roundrobin.StickySession
+
+	lock    sync.Mutex
+	servers []*leastConnServer
+	// index of the last selected server, used to spread the requests between servers with the same load
+	index int
+}
+
+type leastConnServer struct {
+	url   *url.URL
+	conns int
+}
+
+// NewLeastConn creates a LeastConn forwarding the requests to next, with an optional sticky session.
+func NewLeastConn(next http.Handler, stickySession *roundrobin.StickySession) *LeastConn {
+	return &LeastConn{
+		next:          next,
+		stickySession: stickySession,
+		index:         -1,
+	}
+}
+
+func (l *LeastConn) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	// make shallow copy of request before changing anything to avoid side effects
+	newReq := *req
+
+	var stickyURL *url.URL
+	if l.stickySession != nil {
+		cookieURL, present, err := l.stickySession.GetBackend(&newReq, l.Servers())
+		if err != nil {
+			log.Infof("Error using server from cookie: %v", err)
+		}
+		if present {
+			stickyURL = cookieURL
+		}
+	}
+
+	server, stuck := l.acquire(stickyURL)
+	if server == nil {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		rw.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+		return
+	}
+	defer l.release(server)
+
+	if l.stickySession != nil && !stuck {
+		l.stickySession.StickBackend(server.url, &rw)
+	}
+
+	newReq.URL = server.url
+	l.next.ServeHTTP(rw, &newReq)
+}
+
+// acquire selects the server of the sticky URL, or the least loaded one when it is not available, and counts the request.
+// It reports whether the server of the sticky URL was selected.
+func (l *LeastConn) acquire(stickyURL *url.URL) (*leastConnServer, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if stickyURL != nil {
+		if i := l.indexOf(stickyURL); i >= 0 {
+			l.servers[i].conns++
+			return l.servers[i], true
+		}
+	}
+
+	var selected *leastConnServer
+	start := l.index
+	for i := 1; i <= len(l.servers); i++ {
+		index := (start + i) % len(l.servers)
+		if server := l.servers[index]; selected == nil || server.conns < selected.conns {
+			selected = server
+			l.index = index
+		}
+	}
+
+	if selected != nil {
+		selected.conns++
+	}
+	return selected, false
+}
+
+func (l *LeastConn) release(server *leastConnServer) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	server.conns--
+}
+
+// RemoveServer removes a server from the available ones
+func (l *LeastConn) RemoveServer(u *url.URL) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if i := l.indexOf(u); i >= 0 {
+		l.servers = append(l.servers[:i], l.servers[i+1:]...)
+	}
+	return nil
+}
+
+// UpsertServer adds a server to the available ones.
+// The options, such as the weight of the server, are not used by the least connections method.
+func (l *LeastConn) UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.indexOf(u) < 0 {
+		l.servers = append(l.servers, &leastConnServer{url: u})
+	}
+	return nil
+}
+
+// Servers returns the available servers
+func (l *LeastConn) Servers() []*url.URL {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	servers := make([]*url.URL, len(l.servers))
+	for i, server := range l.servers {
+		servers[i] = server.url
+	}
+	return servers
+}
+
+func (l *LeastConn) indexOf(u *url.URL) int {
+	for i, server := range l.servers {
+		if server.url.Path == u.Path && server.url.Host == u.Host && server.url.Scheme == u.Scheme {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func TestLeastConnSelectsLeastLoadedServer(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Block") != "" {
+			started <- req.URL.Host
+			<-release
+		}
+		rw.Header().Set("X-Server", req.URL.Host)
+	})
+
+	lb := NewLeastConn(next, nil)
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server1")))
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server2")))
+
+	// keep a request in flight on the first selected server
+	go func() {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("X-Block", "true")
+		lb.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	busyServer := <-started
+	defer close(release)
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		lb.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+		assert.NotEqual(t, busyServer, recorder.Header().Get("X-Server"))
+	}
+}
+
+func TestLeastConnSpreadsRequests(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Server", req.URL.Host)
+	})
+
+	lb := NewLeastConn(next, nil)
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server1")))
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server2")))
+
+	servers := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		recorder := httptest.NewRecorder()
+		lb.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+		servers[recorder.Header().Get("X-Server")]++
+	}
+
+	assert.Equal(t, map[string]int{"server1": 2, "server2": 2}, servers)
+}
+
+func TestLeastConnStickySession(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		cookie         string
+		removedServer  string
+		expectedServer string
+		expectedCookie string
+	}{
+		{
+			desc:           "no cookie",
+			expectedServer: "server1",
+			expectedCookie: "http://server1",
+		},
+		{
+			desc:           "cookie of an available server",
+			cookie:         "http://server2",
+			expectedServer: "server2",
+		},
+		{
+			desc:           "cookie of a removed server",
+			cookie:         "http://server2",
+			removedServer:  "http://server2",
+			expectedServer: "server1",
+			expectedCookie: "http://server1",
+		},
+		{
+			desc:           "cookie of an unknown server",
+			cookie:         "http://server3",
+			expectedServer: "server1",
+			expectedCookie: "http://server1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("X-Server", req.URL.Host)
+			})
+
+			lb := NewLeastConn(next, roundrobin.NewStickySession("test"))
+			require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server1")))
+			require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server2")))
+			if len(test.removedServer) > 0 {
+				require.NoError(t, lb.RemoveServer(testhelpers.MustParseURL(test.removedServer)))
+			}
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			if len(test.cookie) > 0 {
+				req.AddCookie(&http.Cookie{Name: "test", Value: test.cookie})
+			}
+
+			recorder := httptest.NewRecorder()
+			lb.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedServer, recorder.Header().Get("X-Server"))
+
+			var cookie string
+			for _, c := range recorder.Result().Cookies() {
+				if c.Name == "test" {
+					cookie = c.Value
+				}
+			}
+			assert.Equal(t, test.expectedCookie, cookie)
+		})
+	}
+}
+
+func TestLeastConnWithoutServer(t *testing.T) {
+	lb := NewLeastConn(http.NotFoundHandler(), nil)
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server1")))
+	require.NoError(t, lb.RemoveServer(testhelpers.MustParseURL("http://server1")))
+
+	assert.Equal(t, []*url.URL{}, lb.Servers())
+
+	recorder := httptest.NewRecorder()
+	lb.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
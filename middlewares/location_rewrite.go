@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/forward"
+)
+
+// LocationRewrite replaces the backend host by the public one in the Location header of the responses,
+// and optionally in the Content-Location header and in the domain of the cookies.
+// The public host and scheme are the ones forwarded to the backend in the X-Forwarded-Host and X-Forwarded-Proto headers.
+type LocationRewrite struct {
+	backendHost     string
+	contentLocation bool
+	setCookieDomain bool
+}
+
+// NewLocationRewrite creates a LocationRewrite from the frontend configuration, or returns nil if there is none.
+func NewLocationRewrite(config *types.LocationRewrite) *LocationRewrite {
+	if config == nil {
+		return nil
+	}
+
+	return &LocationRewrite{
+		backendHost:     strings.ToLower(config.BackendHost),
+		contentLocation: config.ContentLocation,
+		setCookieDomain: config.SetCookieDomain,
+	}
+}
+
+// ModifyResponseHeaders rewrites the headers of a response coming from the backend
+func (l *LocationRewrite) ModifyResponseHeaders(res *http.Response) error {
+	if res.Request == nil {
+		return nil
+	}
+
+	publicHost := res.Request.Header.Get(forward.XForwardedHost)
+	if len(publicHost) == 0 {
+		return nil
+	}
+
+	// without a configured backend host, the one the request was sent to is used
+	backendHost := l.backendHost
+	if len(backendHost) == 0 {
+		backendHost = strings.ToLower(res.Request.URL.Host)
+	}
+
+	scheme := publicScheme(res.Request.Header.Get(forward.XForwardedProto))
+
+	rewriteURLHeader(res.Header, "Location", backendHost, publicHost, scheme)
+	if l.contentLocation {
+		rewriteURLHeader(res.Header, "Content-Location", backendHost, publicHost, scheme)
+	}
+	if l.setCookieDomain {
+		rewriteCookiesDomain(res.Header, hostname(backendHost), hostname(publicHost))
+	}
+	return nil
+}
+
+// rewriteURLHeader replaces the host of the absolute URL of the header if it is the backend one.
+func rewriteURLHeader(header http.Header, name, backendHost, publicHost, publicScheme string) {
+	value := header.Get(name)
+	if len(value) == 0 {
+		return
+	}
+
+	location, err := url.Parse(value)
+	if err != nil || len(location.Host) == 0 {
+		// relative URLs are already relative to the public host
+		return
+	}
+	if location.Scheme != "http" && location.Scheme != "https" {
+		return
+	}
+	if !matchHost(location.Host, location.Scheme, backendHost) {
+		return
+	}
+
+	location.Host = publicHost
+	if len(publicScheme) > 0 {
+		location.Scheme = publicScheme
+	}
+	header.Set(name, location.String())
+}
+
+// matchHost checks that host, as found in a URL with the given scheme, is the backend host.
+// The port is only compared when the backend host has one.
+func matchHost(host, scheme, backendHost string) bool {
+	host = strings.ToLower(host)
+	if _, _, err := net.SplitHostPort(backendHost); err != nil {
+		return hostname(host) == backendHost
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := "80"
+		if scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(host, port)
+	}
+	return host == backendHost
+}
+
+// rewriteCookiesDomain replaces the backend domain by the public one in the Domain attribute of the cookies,
+// leaving the other attributes untouched.
+func rewriteCookiesDomain(header http.Header, backendDomain, publicDomain string) {
+	cookies := header["Set-Cookie"]
+	for i, cookie := range cookies {
+		attributes := strings.Split(cookie, ";")
+		// the first attribute is the name and value of the cookie
+		for j := 1; j < len(attributes); j++ {
+			attribute := attributes[j]
+			key, value := attribute, ""
+			if index := strings.Index(attribute, "="); index >= 0 {
+				key, value = attribute[:index], attribute[index+1:]
+			}
+			if !strings.EqualFold(strings.TrimSpace(key), "Domain") {
+				continue
+			}
+
+			if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(value), "."), backendDomain) {
+				attributes[j] = " Domain=" + publicDomain
+			}
+		}
+		cookies[i] = strings.Join(attributes, ";")
+	}
+}
+
+func publicScheme(forwardedProto string) string {
+	switch strings.ToLower(forwardedProto) {
+	case "https", "wss":
+		return "https"
+	case "http", "ws":
+		return "http"
+	default:
+		return ""
+	}
+}
+
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
@@ -0,0 +1,167 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/forward"
+)
+
+func TestLocationRewrite(t *testing.T) {
+	testCases := []struct {
+		desc                    string
+		config                  *types.LocationRewrite
+		forwardedHost           string
+		forwardedProto          string
+		location                string
+		contentLocation         string
+		cookies                 []string
+		expectedLocation        string
+		expectedContentLocation string
+		expectedCookies         []string
+	}{
+		{
+			desc:             "backend host of the request",
+			config:           &types.LocationRewrite{},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "https",
+			location:         "http://10.0.0.1:8080/login?next=%2Fhome",
+			expectedLocation: "https://public.example.com/login?next=%2Fhome",
+		},
+		{
+			desc:             "configured backend host without port",
+			config:           &types.LocationRewrite{BackendHost: "Backend.Internal"},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "http",
+			location:         "https://backend.internal:8443/foo",
+			expectedLocation: "http://public.example.com/foo",
+		},
+		{
+			desc:             "configured backend host with the default port of the scheme",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal:443"},
+			forwardedHost:    "public.example.com:8443",
+			forwardedProto:   "https",
+			location:         "https://backend.internal/foo",
+			expectedLocation: "https://public.example.com:8443/foo",
+		},
+		{
+			desc:             "other port than the configured one",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal:8080"},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "https",
+			location:         "http://backend.internal:9090/foo",
+			expectedLocation: "http://backend.internal:9090/foo",
+		},
+		{
+			desc:             "other host",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal"},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "https",
+			location:         "https://auth.example.com/login",
+			expectedLocation: "https://auth.example.com/login",
+		},
+		{
+			desc:             "relative location",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal"},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "https",
+			location:         "/login",
+			expectedLocation: "/login",
+		},
+		{
+			desc:             "websocket scheme",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal"},
+			forwardedHost:    "public.example.com",
+			forwardedProto:   "wss",
+			location:         "http://backend.internal/foo",
+			expectedLocation: "https://public.example.com/foo",
+		},
+		{
+			desc:                    "content location not enabled",
+			config:                  &types.LocationRewrite{BackendHost: "backend.internal"},
+			forwardedHost:           "public.example.com",
+			forwardedProto:          "https",
+			contentLocation:         "http://backend.internal/foo.json",
+			expectedContentLocation: "http://backend.internal/foo.json",
+		},
+		{
+			desc:                    "content location",
+			config:                  &types.LocationRewrite{BackendHost: "backend.internal", ContentLocation: true},
+			forwardedHost:           "public.example.com",
+			forwardedProto:          "https",
+			contentLocation:         "http://backend.internal/foo.json",
+			expectedContentLocation: "https://public.example.com/foo.json",
+		},
+		{
+			desc:            "cookie domain not enabled",
+			config:          &types.LocationRewrite{BackendHost: "backend.internal"},
+			forwardedHost:   "public.example.com",
+			forwardedProto:  "https",
+			cookies:         []string{"session=foo; Domain=backend.internal; Path=/"},
+			expectedCookies: []string{"session=foo; Domain=backend.internal; Path=/"},
+		},
+		{
+			desc:           "cookie domain",
+			config:         &types.LocationRewrite{BackendHost: "backend.internal:8080", SetCookieDomain: true},
+			forwardedHost:  "public.example.com:8443",
+			forwardedProto: "https",
+			cookies: []string{
+				"session=foo; domain=.Backend.Internal; Path=/; Secure; HttpOnly",
+				"lang=en; Domain=other.internal",
+				"Domain=backend.internal; Path=/",
+			},
+			expectedCookies: []string{
+				"session=foo; Domain=public.example.com; Path=/; Secure; HttpOnly",
+				"lang=en; Domain=other.internal",
+				"Domain=backend.internal; Path=/",
+			},
+		},
+		{
+			desc:             "no public host",
+			config:           &types.LocationRewrite{BackendHost: "backend.internal"},
+			location:         "http://backend.internal/foo",
+			expectedLocation: "http://backend.internal/foo",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://10.0.0.1:8080/", nil)
+			if len(test.forwardedHost) > 0 {
+				req.Header.Set(forward.XForwardedHost, test.forwardedHost)
+			}
+			if len(test.forwardedProto) > 0 {
+				req.Header.Set(forward.XForwardedProto, test.forwardedProto)
+			}
+
+			res := &http.Response{Header: make(http.Header), Request: req}
+			if len(test.location) > 0 {
+				res.Header.Set("Location", test.location)
+			}
+			if len(test.contentLocation) > 0 {
+				res.Header.Set("Content-Location", test.contentLocation)
+			}
+			for _, cookie := range test.cookies {
+				res.Header.Add("Set-Cookie", cookie)
+			}
+
+			err := NewLocationRewrite(test.config).ModifyResponseHeaders(res)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedLocation, res.Header.Get("Location"))
+			assert.Equal(t, test.expectedContentLocation, res.Header.Get("Content-Location"))
+			assert.Equal(t, test.expectedCookies, res.Header["Set-Cookie"])
+		})
+	}
+}
+
+func TestNewLocationRewriteWithoutConfig(t *testing.T) {
+	assert.Nil(t, NewLocationRewrite(nil))
+}
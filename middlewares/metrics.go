@@ -33,19 +33,25 @@ func NewEntryPointMetricsMiddleware(registry metrics.Registry, entryPointName st
 // NewBackendMetricsMiddleware creates a new metrics middleware for a Backend.
 func NewBackendMetricsMiddleware(registry metrics.Registry, backendName string) negroni.Handler {
 	return &metricsMiddleware{
-		reqsCounter:          registry.BackendReqsCounter(),
-		reqDurationHistogram: registry.BackendReqDurationHistogram(),
-		openConnsGauge:       registry.BackendOpenConnsGauge(),
-		baseLabels:           []string{"backend", backendName},
+		reqsCounter:                 registry.BackendReqsCounter(),
+		reqDurationHistogram:        registry.BackendReqDurationHistogram(),
+		openConnsGauge:              registry.BackendOpenConnsGauge(),
+		baseLabels:                  []string{"backend", backendName},
+		respHeaderDurationHistogram: registry.BackendRespHeaderDurationHistogram(),
 	}
 }
 
+// codeClasses are the values of the status code class label, indexed by the first digit of the status code
+var codeClasses = [...]string{"", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
 type metricsMiddleware struct {
 	reqsCounter          gokitmetrics.Counter
 	reqDurationHistogram gokitmetrics.Histogram
 	openConnsGauge       gokitmetrics.Gauge
 	baseLabels           []string
 	openConns            int64
+	// respHeaderDurationHistogram, when set, measures the time until the response headers are written
+	respHeaderDurationHistogram gokitmetrics.Histogram
 }
 
 func (m *metricsMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
@@ -60,12 +66,31 @@ func (m *metricsMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, n
 	}(labels)
 
 	start := time.Now()
-	recorder := &responseRecorder{rw, http.StatusOK}
+	recorder := &responseRecorder{ResponseWriter: rw, statusCode: http.StatusOK}
 	next(recorder, r)
+	end := time.Now()
+
+	if m.respHeaderDurationHistogram != nil {
+		headerTime := recorder.headerTime
+		if headerTime.IsZero() {
+			headerTime = end
+		}
+		m.respHeaderDurationHistogram.With(append([]string{"code_class", getCodeClass(recorder.statusCode)}, m.baseLabels...)...).
+			Observe(headerTime.Sub(start).Seconds())
+	}
 
 	labels = append(labels, "code", strconv.Itoa(recorder.statusCode))
 	m.reqsCounter.With(labels...).Add(1)
-	m.reqDurationHistogram.With(labels...).Observe(float64(time.Since(start).Seconds()))
+	m.reqDurationHistogram.With(labels...).Observe(float64(end.Sub(start).Seconds()))
+}
+
+// getCodeClass returns the class of the status code, such as "2xx", or "other" for an invalid one.
+func getCodeClass(statusCode int) string {
+	class := statusCode / 100
+	if class < 1 || class >= len(codeClasses) {
+		return "other"
+	}
+	return codeClasses[class]
 }
 
 func getRequestProtocol(req *http.Request) string {
@@ -0,0 +1,214 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+const (
+	// DefaultMirrorMaxBodySize is the size of the largest request body mirrored, when not configured.
+	DefaultMirrorMaxBodySize = 1024 * 1024
+	// mirrorTimeout is the time allowed to a mirror server to respond.
+	mirrorTimeout = 30 * time.Second
+	// maxMirroredRequests is the maximum number of mirrored requests in flight, the others being dropped.
+	maxMirroredRequests = 1000
+)
+
+// Mirror is a middleware sending a copy of a sample of the requests to the servers of mirror backends,
+// their responses being discarded. The mirrored requests are sent asynchronously, and never affect the response to the client.
+type Mirror struct {
+	handler     http.Handler
+	mirrors     []*mirrorBackend
+	percent     int
+	maxBodySize int64
+	transport   http.RoundTripper
+
+	inFlight   int32
+	randomLock sync.Mutex
+	random     *rand.Rand
+}
+
+// mirrorBackend sends the mirrored requests to its servers in turn.
+type mirrorBackend struct {
+	name    string
+	servers []*url.URL
+	next    uint32
+}
+
+// NewMirror creates a Mirror middleware in front of handler, mirroring percent of the requests with transport
+// to each of the mirror backends, given as the URLs of their servers by backend name.
+// The requests with a body larger than maxBodySize are not mirrored, a zero maxBodySize standing for the default one.
+func NewMirror(handler http.Handler, mirrors map[string][]string, percent int, maxBodySize int64, transport http.RoundTripper) (*Mirror, error) {
+	if len(mirrors) == 0 {
+		return nil, errors.New("no mirror backend")
+	}
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("invalid mirroring percentage %d, it must be between 0 and 100", percent)
+	}
+	if maxBodySize < 0 {
+		return nil, fmt.Errorf("invalid mirroring maximum body size %d", maxBodySize)
+	}
+	if maxBodySize == 0 {
+		maxBodySize = DefaultMirrorMaxBodySize
+	}
+
+	m := &Mirror{
+		handler:     handler,
+		percent:     percent,
+		maxBodySize: maxBodySize,
+		transport:   transport,
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for name, servers := range mirrors {
+		if len(servers) == 0 {
+			return nil, fmt.Errorf("no server for mirror backend %s", name)
+		}
+
+		mirror := &mirrorBackend{name: name}
+		for _, server := range servers {
+			serverURL, err := url.Parse(server)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URL %q for mirror backend %s: %v", server, name, err)
+			}
+			mirror.servers = append(mirror.servers, serverURL)
+		}
+		m.mirrors = append(m.mirrors, mirror)
+	}
+	return m, nil
+}
+
+func (m *Mirror) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	// the upgraded connections, such as websockets, can't be mirrored
+	if len(req.Header.Get("Upgrade")) > 0 || !m.sample() {
+		m.handler.ServeHTTP(rw, req)
+		return
+	}
+
+	body, ok, err := m.bufferBody(req)
+	if err != nil {
+		// the error is left to the handler, reading the request body again
+		log.Debugf("Not mirroring the request, as its body can't be read: %v", err)
+		m.handler.ServeHTTP(rw, req)
+		return
+	}
+	if !ok {
+		log.Debugf("Not mirroring the request, as its body is larger than %d bytes", m.maxBodySize)
+		m.handler.ServeHTTP(rw, req)
+		return
+	}
+
+	for _, mirror := range m.mirrors {
+		if atomic.AddInt32(&m.inFlight, 1) > maxMirroredRequests {
+			atomic.AddInt32(&m.inFlight, -1)
+			log.Debugf("Not mirroring the request to %s, as too many mirrored requests are in flight", mirror.name)
+			continue
+		}
+
+		mirrorReq := mirror.newRequest(req, body)
+		go m.send(mirror, mirrorReq)
+	}
+
+	m.handler.ServeHTTP(rw, req)
+}
+
+func (m *Mirror) sample() bool {
+	if m.percent >= 100 {
+		return true
+	}
+
+	m.randomLock.Lock()
+	defer m.randomLock.Unlock()
+	return m.random.Intn(100) < m.percent
+}
+
+// bufferBody reads the body of req to mirror it, and replaces it by a copy for the handler.
+// It returns false if the body is larger than the maximum size, req being left with its whole body.
+func (m *Mirror) bufferBody(req *http.Request) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, m.maxBodySize+1))
+	if err != nil {
+		req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+		return nil, false, err
+	}
+
+	if int64(len(body)) > m.maxBodySize {
+		req.Body = &multiReadCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+		return nil, false, nil
+	}
+
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, true, nil
+}
+
+func (m *Mirror) send(mirror *mirrorBackend, req *http.Request) {
+	defer atomic.AddInt32(&m.inFlight, -1)
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while mirroring the request to %s: %v", mirror.name, err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	defer cancel()
+
+	resp, err := m.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		log.Debugf("Error while mirroring the request to %s: %v", mirror.name, err)
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// newRequest returns a copy of req, with the given body, to the next server of the mirror backend.
+func (b *mirrorBackend) newRequest(req *http.Request, body []byte) *http.Request {
+	server := b.servers[int(atomic.AddUint32(&b.next, 1)-1)%len(b.servers)]
+
+	mirrorURL := *req.URL
+	mirrorURL.Scheme = server.Scheme
+	mirrorURL.Host = server.Host
+
+	mirrorReq := &http.Request{
+		Method:        req.Method,
+		URL:           &mirrorURL,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header, len(req.Header)),
+		Host:          req.Host,
+		ContentLength: int64(len(body)),
+	}
+	for name, values := range req.Header {
+		mirrorReq.Header[name] = append([]string(nil), values...)
+	}
+	// the hop-by-hop headers are not forwarded
+	for _, name := range []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"} {
+		mirrorReq.Header.Del(name)
+	}
+	if len(body) > 0 {
+		mirrorReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return mirrorReq
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,211 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mirroredRequest struct {
+	method string
+	uri    string
+	host   string
+	body   string
+}
+
+func newMirrorServer(requests chan<- mirroredRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- mirroredRequest{method: req.Method, uri: req.RequestURI, host: req.Host, body: string(body)}
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+}
+
+func TestMirror(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		percent          int
+		maxBodySize      int64
+		body             string
+		header           http.Header
+		expectedMirrored bool
+	}{
+		{
+			desc:             "mirrored request",
+			percent:          100,
+			body:             "request body",
+			expectedMirrored: true,
+		},
+		{
+			desc:             "mirrored request without body",
+			percent:          100,
+			expectedMirrored: true,
+		},
+		{
+			desc:    "request not sampled",
+			percent: 0,
+			body:    "request body",
+		},
+		{
+			desc:        "request body too large",
+			percent:     100,
+			maxBodySize: 4,
+			body:        "request body",
+		},
+		{
+			desc:    "websocket request",
+			percent: 100,
+			header:  http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			requests1 := make(chan mirroredRequest, 10)
+			mirror1 := newMirrorServer(requests1)
+			defer mirror1.Close()
+			requests2 := make(chan mirroredRequest, 10)
+			mirror2 := newMirrorServer(requests2)
+			defer mirror2.Close()
+
+			handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				// the handler always gets the whole body
+				assert.Equal(t, test.body, string(body))
+				rw.Write([]byte("primary"))
+			})
+
+			mirror, err := NewMirror(handler, map[string][]string{
+				"mirror1": {mirror1.URL},
+				"mirror2": {mirror2.URL},
+			}, test.percent, test.maxBodySize, http.DefaultTransport)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "http://foo.example.com/bar?baz=1", strings.NewReader(test.body))
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+			recorder := httptest.NewRecorder()
+			mirror.ServeHTTP(recorder, req)
+
+			// the failures of the mirrors don't affect the response
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "primary", recorder.Body.String())
+
+			for _, requests := range []chan mirroredRequest{requests1, requests2} {
+				if !test.expectedMirrored {
+					select {
+					case mirrored := <-requests:
+						t.Errorf("unexpected mirrored request %+v", mirrored)
+					case <-time.After(50 * time.Millisecond):
+					}
+					continue
+				}
+
+				select {
+				case mirrored := <-requests:
+					assert.Equal(t, mirroredRequest{method: http.MethodPost, uri: "/bar?baz=1", host: "foo.example.com", body: test.body}, mirrored)
+				case <-time.After(time.Second):
+					t.Error("request not mirrored")
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorServersInTurn(t *testing.T) {
+	requests := make(chan mirroredRequest, 10)
+	mirror1 := newMirrorServer(requests)
+	defer mirror1.Close()
+	mirror2 := newMirrorServer(requests)
+	defer mirror2.Close()
+
+	mirror, err := NewMirror(http.NotFoundHandler(), map[string][]string{
+		"mirror": {mirror1.URL, mirror2.URL},
+	}, 100, 0, http.DefaultTransport)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		mirror.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil))
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case <-requests:
+		case <-time.After(time.Second):
+			t.Fatal("request not mirrored")
+		}
+	}
+}
+
+func TestMirrorUnreachable(t *testing.T) {
+	mirror, err := NewMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("primary"))
+	}), map[string][]string{
+		"mirror": {"http://127.0.0.1:1"},
+	}, 100, 0, http.DefaultTransport)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	mirror.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "primary", recorder.Body.String())
+}
+
+func TestNewMirrorInvalidOptions(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		mirrors     map[string][]string
+		percent     int
+		maxBodySize int64
+	}{
+		{
+			desc:    "no mirror backend",
+			percent: 100,
+		},
+		{
+			desc:    "mirror backend without server",
+			mirrors: map[string][]string{"mirror": nil},
+			percent: 100,
+		},
+		{
+			desc:    "invalid server URL",
+			mirrors: map[string][]string{"mirror": {"http://[::1"}},
+			percent: 100,
+		},
+		{
+			desc:    "percentage too large",
+			mirrors: map[string][]string{"mirror": {"http://127.0.0.1"}},
+			percent: 101,
+		},
+		{
+			desc:        "negative maximum body size",
+			mirrors:     map[string][]string{"mirror": {"http://127.0.0.1"}},
+			percent:     100,
+			maxBodySize: -1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewMirror(http.NotFoundHandler(), test.mirrors, test.percent, test.maxBodySize, http.DefaultTransport)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -16,12 +17,30 @@ import (
 )
 
 const (
-	defaultRedirectRegex = `^(?:https?:\/\/)?([\w\._-]+)(?::\d+)?(.*)$`
+	defaultRedirectRegex = `^(?:https?:\/\/)?(\[[\da-fA-F:.]+\]|[\w\._-]+)(?::\d+)?(.*)$`
 )
 
+// StatusCode returns the status code of a redirection: statusCode when not zero,
+// otherwise 301 (Moved Permanently) when permanent, or 302 (Found).
+func StatusCode(permanent bool, statusCode int) (int, error) {
+	switch statusCode {
+	case 0:
+		if permanent {
+			return http.StatusMovedPermanently, nil
+		}
+		return http.StatusFound, nil
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return statusCode, nil
+	default:
+		return 0, fmt.Errorf("invalid redirect status code %d", statusCode)
+	}
+}
+
 // NewEntryPointHandler create a new redirection handler base on entry point
-func NewEntryPointHandler(dstEntryPoint *configuration.EntryPoint, permanent bool) (negroni.Handler, error) {
-	exp := regexp.MustCompile(`(:\d+)`)
+// The port of the redirect URL is port when not zero, instead of the one of the entry point address,
+// and is omitted when it is the default port of the scheme.
+func NewEntryPointHandler(dstEntryPoint *configuration.EntryPoint, port int, statusCode int) (negroni.Handler, error) {
+	exp := regexp.MustCompile(`:(\d+)`)
 	match := exp.FindStringSubmatch(dstEntryPoint.Address)
 	if len(match) == 0 {
 		return nil, fmt.Errorf("bad Address format %q", dstEntryPoint.Address)
@@ -32,13 +51,24 @@ func NewEntryPointHandler(dstEntryPoint *configuration.EntryPoint, permanent boo
 		protocol = "https"
 	}
 
-	replacement := protocol + "://$1" + match[0] + "$2"
+	portSuffix := match[0]
+	if port != 0 {
+		if port < 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid redirect port %d", port)
+		}
+		portSuffix = ":" + strconv.Itoa(port)
+		if protocol == "http" && port == 80 || protocol == "https" && port == 443 {
+			portSuffix = ""
+		}
+	}
+
+	replacement := protocol + "://$1" + portSuffix + "$2"
 
-	return NewRegexHandler(defaultRedirectRegex, replacement, permanent)
+	return NewRegexHandler(defaultRedirectRegex, replacement, statusCode)
 }
 
 // NewRegexHandler create a new redirection handler base on regex
-func NewRegexHandler(exp string, replacement string, permanent bool) (negroni.Handler, error) {
+func NewRegexHandler(exp string, replacement string, statusCode int) (negroni.Handler, error) {
 	re, err := regexp.Compile(exp)
 	if err != nil {
 		return nil, err
@@ -47,7 +77,7 @@ func NewRegexHandler(exp string, replacement string, permanent bool) (negroni.Ha
 	return &handler{
 		regexp:      re,
 		replacement: replacement,
-		permanent:   permanent,
+		statusCode:  statusCode,
 		errHandler:  utils.DefaultHandler,
 	}, nil
 }
@@ -55,7 +85,7 @@ func NewRegexHandler(exp string, replacement string, permanent bool) (negroni.Ha
 type handler struct {
 	regexp      *regexp.Regexp
 	replacement string
-	permanent   bool
+	statusCode  int
 	errHandler  utils.ErrorHandler
 }
 
@@ -86,7 +116,7 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http
 	}
 
 	if newURL != oldURL {
-		handler := &moveHandler{location: parsedURL, permanent: h.permanent}
+		handler := &moveHandler{location: parsedURL, statusCode: h.statusCode}
 		handler.ServeHTTP(rw, req)
 		return
 	}
@@ -99,15 +129,15 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http
 }
 
 type moveHandler struct {
-	location  *url.URL
-	permanent bool
+	location   *url.URL
+	statusCode int
 }
 
 func (m *moveHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	rw.Header().Set("Location", m.location.String())
-	status := http.StatusFound
-	if m.permanent {
-		status = http.StatusMovedPermanently
+	status := m.statusCode
+	if status == 0 {
+		status = http.StatusFound
 	}
 	rw.WriteHeader(status)
 	rw.Write([]byte(http.StatusText(status)))
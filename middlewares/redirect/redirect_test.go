@@ -17,7 +17,8 @@ func TestNewEntryPointHandler(t *testing.T) {
 	testCases := []struct {
 		desc           string
 		entryPoint     *configuration.EntryPoint
-		permanent      bool
+		port           int
+		statusCode     int
 		url            string
 		expectedURL    string
 		expectedStatus int
@@ -47,7 +48,7 @@ func TestNewEntryPointHandler(t *testing.T) {
 		{
 			desc:           "HTTP to HTTPS permanent",
 			entryPoint:     &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
-			permanent:      true,
+			statusCode:     http.StatusMovedPermanently,
 			url:            "http://foo:80",
 			expectedURL:    "https://foo:443",
 			expectedStatus: http.StatusMovedPermanently,
@@ -55,11 +56,56 @@ func TestNewEntryPointHandler(t *testing.T) {
 		{
 			desc:           "HTTPS to HTTP permanent",
 			entryPoint:     &configuration.EntryPoint{Address: ":80"},
-			permanent:      true,
+			statusCode:     http.StatusMovedPermanently,
 			url:            "https://foo:443",
 			expectedURL:    "http://foo:80",
 			expectedStatus: http.StatusMovedPermanently,
 		},
+		{
+			desc:           "HTTP to HTTPS with status code 308",
+			entryPoint:     &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+			statusCode:     http.StatusPermanentRedirect,
+			url:            "http://foo:80",
+			expectedURL:    "https://foo:443",
+			expectedStatus: http.StatusPermanentRedirect,
+		},
+		{
+			desc:           "path and query preserved",
+			entryPoint:     &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+			url:            "http://foo.example.com/bar/baz?a=1&b=2",
+			expectedURL:    "https://foo.example.com:443/bar/baz?a=1&b=2",
+			expectedStatus: http.StatusFound,
+		},
+		{
+			desc:           "port override",
+			entryPoint:     &configuration.EntryPoint{Address: ":8443", TLS: &tls.TLS{}},
+			port:           9443,
+			url:            "http://foo:8080/bar?a=1",
+			expectedURL:    "https://foo:9443/bar?a=1",
+			expectedStatus: http.StatusFound,
+		},
+		{
+			desc:           "default port override omitted",
+			entryPoint:     &configuration.EntryPoint{Address: ":8443", TLS: &tls.TLS{}},
+			port:           443,
+			url:            "http://foo:8080/bar",
+			expectedURL:    "https://foo/bar",
+			expectedStatus: http.StatusFound,
+		},
+		{
+			desc:           "IPv6 host",
+			entryPoint:     &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+			url:            "http://[::1]:80/bar",
+			expectedURL:    "https://[::1]:443/bar",
+			expectedStatus: http.StatusFound,
+		},
+		{
+			desc:          "invalid port",
+			entryPoint:    &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+			port:          70000,
+			url:           "http://foo:80",
+			errorExpected: true,
+		},
 		{
 			desc:          "invalid address",
 			entryPoint:    &configuration.EntryPoint{Address: ":foo", TLS: &tls.TLS{}},
@@ -73,7 +119,7 @@ func TestNewEntryPointHandler(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			handler, err := NewEntryPointHandler(test.entryPoint, test.permanent)
+			handler, err := NewEntryPointHandler(test.entryPoint, test.port, test.statusCode)
 
 			if test.errorExpected {
 				require.Error(t, err)
@@ -82,6 +128,10 @@ func TestNewEntryPointHandler(t *testing.T) {
 
 				recorder := httptest.NewRecorder()
 				r := testhelpers.MustNewRequest(http.MethodGet, test.url, nil)
+				if len(r.URL.Path) > 0 {
+					// as received by the server, the request URI holds the path and the query
+					r.RequestURI = r.URL.RequestURI()
+				}
 				handler.ServeHTTP(recorder, r, nil)
 
 				location, err := recorder.Result().Location()
@@ -94,12 +144,63 @@ func TestNewEntryPointHandler(t *testing.T) {
 	}
 }
 
+func TestStatusCode(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		permanent          bool
+		statusCode         int
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			desc:               "default",
+			expectedStatusCode: http.StatusFound,
+		},
+		{
+			desc:               "permanent",
+			permanent:          true,
+			expectedStatusCode: http.StatusMovedPermanently,
+		},
+		{
+			desc:               "status code",
+			statusCode:         http.StatusTemporaryRedirect,
+			expectedStatusCode: http.StatusTemporaryRedirect,
+		},
+		{
+			desc:               "status code precedence over permanent",
+			permanent:          true,
+			statusCode:         http.StatusPermanentRedirect,
+			expectedStatusCode: http.StatusPermanentRedirect,
+		},
+		{
+			desc:          "not a redirect status code",
+			statusCode:    http.StatusOK,
+			expectedError: "invalid redirect status code 200",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			statusCode, err := StatusCode(test.permanent, test.statusCode)
+			if len(test.expectedError) > 0 {
+				assert.EqualError(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedStatusCode, statusCode)
+			}
+		})
+	}
+}
+
 func TestNewRegexHandler(t *testing.T) {
 	testCases := []struct {
 		desc           string
 		regex          string
 		replacement    string
-		permanent      bool
+		statusCode     int
 		url            string
 		expectedURL    string
 		expectedStatus int
@@ -141,7 +242,7 @@ func TestNewRegexHandler(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			handler, err := NewRegexHandler(test.regex, test.replacement, test.permanent)
+			handler, err := NewRegexHandler(test.regex, test.replacement, test.statusCode)
 
 			if test.errorExpected {
 				require.Nil(t, handler)
@@ -0,0 +1,304 @@
+package middlewares
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	traefikRedis "github.com/containous/traefik/redis"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/utils"
+)
+
+// Default settings of the rate limit store
+const (
+	DefaultRateLimitStorePrefix      = "traefik:ratelimit"
+	DefaultRateLimitStoreTimeout     = 100 * time.Millisecond
+	DefaultRateLimitStoreDialTimeout = time.Second
+)
+
+const (
+	// rateLimitStoreRetryInterval is the duration during which the store is not used after a failure,
+	// so that the requests are not slowed down by the attempts to reach an unavailable server
+	rateLimitStoreRetryInterval = time.Second
+	// rateLimitStoreMaxIdleConns is the maximum number of connections kept open to the Redis server when they are not used
+	rateLimitStoreMaxIdleConns = 16
+)
+
+var errRateLimitStoreUnavailable = errors.New("rate limit store unavailable")
+
+// rateLimitScript counts a request in the windows of the rates, unless one of them is exceeded, in a single step.
+// KEYS are the counters of the current and previous windows of each rate, and ARGV the amount of the request,
+// followed by the expiration of the counters in milliseconds, the weight of the previous window and the average of each rate.
+// It returns, for each rate, 1 if it is exceeded and 0 otherwise.
+const rateLimitScript = `
+local amount = tonumber(ARGV[1])
+local exceeded = {}
+local rejected = false
+for i = 1, #KEYS / 2 do
+	local current = tonumber(redis.call('GET', KEYS[2 * i - 1]) or '0')
+	local previous = tonumber(redis.call('GET', KEYS[2 * i]) or '0')
+	if previous * tonumber(ARGV[3 * i]) + current + amount > tonumber(ARGV[3 * i + 1]) then
+		exceeded[i] = 1
+		rejected = true
+	else
+		exceeded[i] = 0
+	end
+end
+if not rejected then
+	for i = 1, #KEYS / 2 do
+		redis.call('INCRBY', KEYS[2 * i - 1], amount)
+		redis.call('PEXPIRE', KEYS[2 * i - 1], ARGV[3 * i - 1])
+	end
+end
+return exceeded
+`
+
+// rateLimitScriptSHA1 is the SHA1 digest of the rate limit script, which is run with EVALSHA once cached by the server
+var rateLimitScriptSHA1 = func() string {
+	digest := sha1.Sum([]byte(rateLimitScript))
+	return hex.EncodeToString(digest[:])
+}()
+
+// RateLimitStore holds the connections to the Redis servers sharing the counters of the rate limits between the Traefik instances.
+// It is shared by the configurations.
+type RateLimitStore struct {
+	endpoints []string
+	options   traefikRedis.Options
+	prefix    string
+	timeout   time.Duration
+	now       func() time.Time
+
+	conns            chan *traefikRedis.Conn
+	lock             sync.Mutex
+	unavailableUntil time.Time
+}
+
+// NewRateLimitStore creates a RateLimitStore connecting to the first reachable endpoint, whose requests time out after timeout.
+// The connections time out after the connection timeout of the options, DefaultRateLimitStoreDialTimeout when not set.
+// The keys of the counters start with prefix.
+func NewRateLimitStore(endpoints []string, options traefikRedis.Options, prefix string, timeout time.Duration) *RateLimitStore {
+	if len(prefix) == 0 {
+		prefix = DefaultRateLimitStorePrefix
+	}
+	if timeout <= 0 {
+		timeout = DefaultRateLimitStoreTimeout
+	}
+	if options.ConnectionTimeout <= 0 {
+		options.ConnectionTimeout = DefaultRateLimitStoreDialTimeout
+	}
+	return &RateLimitStore{
+		endpoints: endpoints,
+		options:   options,
+		prefix:    prefix,
+		timeout:   timeout,
+		now:       time.Now,
+		conns:     make(chan *traefikRedis.Conn, rateLimitStoreMaxIdleConns),
+	}
+}
+
+// Close closes the idle connections.
+func (s *RateLimitStore) Close() {
+	for {
+		select {
+		case conn := <-s.conns:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// pipeline sends the commands on an idle connection, or a new one, and reads their replies.
+// A store failing to answer is not used for rateLimitStoreRetryInterval.
+func (s *RateLimitStore) pipeline(commands ...[]string) ([]interface{}, error) {
+	s.lock.Lock()
+	unavailable := s.now().Before(s.unavailableUntil)
+	s.lock.Unlock()
+	if unavailable {
+		return nil, errRateLimitStoreUnavailable
+	}
+
+	var conn *traefikRedis.Conn
+	select {
+	case conn = <-s.conns:
+	default:
+		var err error
+		conn, err = traefikRedis.Dial(s.endpoints, s.options, s.timeout)
+		if err != nil {
+			s.setUnavailable()
+			return nil, err
+		}
+	}
+
+	replies, err := conn.Pipeline(commands...)
+	if err != nil {
+		conn.Close()
+		s.setUnavailable()
+		return nil, err
+	}
+
+	select {
+	case s.conns <- conn:
+	default:
+		conn.Close()
+	}
+	return replies, nil
+}
+
+func (s *RateLimitStore) setUnavailable() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.unavailableUntil = s.now().Add(rateLimitStoreRetryInterval)
+}
+
+// consume adds amount to the counters of the source for each rate, and returns the delay before the source can send
+// another request, or zero if none of the rates is exceeded. The counters of a rejected request are left unchanged,
+// the check and the increment being done at once by a script on the server.
+// The script is run by its digest, and only sent again when the server doesn't have it in its cache.
+// The number of requests in the sliding window of a rate is estimated from the counters of the current and previous windows,
+// weighted by the overlap of the previous window with the sliding one.
+func (s *RateLimitStore) consume(name, source string, amount int64, rates []redisRate) (time.Duration, error) {
+	now := s.now().UnixNano()
+
+	elapsed := make([]int64, len(rates))
+	var keys []string
+	args := []string{strconv.FormatInt(amount, 10)}
+	for i, rate := range rates {
+		period := int64(rate.period)
+		window := now / period
+		elapsed[i] = now % period
+
+		key := s.prefix + ":" + name + ":" + rate.name + ":" + rate.period.String() + ":" + source + ":"
+		keys = append(keys, key+strconv.FormatInt(window, 10), key+strconv.FormatInt(window-1, 10))
+		overlap := 1 - float64(elapsed[i])/float64(rate.period)
+		args = append(args,
+			strconv.FormatInt(2*period/int64(time.Millisecond), 10),
+			strconv.FormatFloat(overlap, 'f', -1, 64),
+			strconv.FormatInt(rate.average, 10))
+	}
+
+	command := append([]string{"EVALSHA", rateLimitScriptSHA1, strconv.Itoa(len(keys))}, keys...)
+	command = append(command, args...)
+	replies, err := s.pipeline(command)
+	if err != nil {
+		return 0, err
+	}
+	if replyErr, ok := replies[0].(traefikRedis.Error); ok && strings.HasPrefix(string(replyErr), "NOSCRIPT") {
+		command[0], command[1] = "EVAL", rateLimitScript
+		replies, err = s.pipeline(command)
+		if err != nil {
+			return 0, err
+		}
+	}
+	exceeded, ok := replies[0].([]interface{})
+	if !ok || len(exceeded) != len(rates) {
+		return 0, replyError(replies[0], traefikRedis.ErrUnexpectedReply)
+	}
+
+	var delay time.Duration
+	for i, rate := range rates {
+		if exceeded[i] != int64(1) {
+			continue
+		}
+		if remaining := rate.period - time.Duration(elapsed[i]); remaining > delay {
+			delay = remaining
+		}
+	}
+	return delay, nil
+}
+
+// replyError returns the error sent by the server instead of the reply, or err
+func replyError(reply interface{}, err error) error {
+	if replyErr, ok := reply.(traefikRedis.Error); ok {
+		return replyErr
+	}
+	return err
+}
+
+type redisRate struct {
+	// name is the name of the rate in the rate set, the counters of the rates with the same period being distinct
+	name    string
+	period  time.Duration
+	average int64
+}
+
+// RedisRateLimiter is a rate limiter whose counters are shared between the Traefik instances through a RateLimitStore.
+// The requests of each source are counted in a sliding window of the period of each rate,
+// and rejected with a 429 once the average of the rate is exceeded. The burst of the rates is not used.
+// When the store is unreachable, the requests are sent to a fallback handler, limiting them locally or letting them through.
+type RedisRateLimiter struct {
+	next     http.Handler
+	fallback http.Handler
+	name     string
+	extract  utils.SourceExtractor
+	rates    []redisRate
+	store    *RateLimitStore
+}
+
+// NewRedisRateLimiter creates a RedisRateLimiter, whose counters are named after name, in front of next.
+func NewRedisRateLimiter(next http.Handler, name string, extract utils.SourceExtractor, rateSet map[string]*types.Rate, store *RateLimitStore, fallback http.Handler) (*RedisRateLimiter, error) {
+	var rates []redisRate
+	for rateName, rate := range rateSet {
+		if rate == nil {
+			continue
+		}
+		if rate.Period <= 0 {
+			return nil, fmt.Errorf("invalid period: %v", time.Duration(rate.Period))
+		}
+		if rate.Average <= 0 {
+			return nil, fmt.Errorf("invalid average: %d", rate.Average)
+		}
+		rates = append(rates, redisRate{name: rateName, period: time.Duration(rate.Period), average: rate.Average})
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("no rate defined")
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].period != rates[j].period {
+			return rates[i].period < rates[j].period
+		}
+		return rates[i].name < rates[j].name
+	})
+
+	return &RedisRateLimiter{
+		next:     next,
+		fallback: fallback,
+		name:     name,
+		extract:  extract,
+		rates:    rates,
+		store:    store,
+	}, nil
+}
+
+func (r *RedisRateLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	source, amount, err := r.extract.Extract(req)
+	if err != nil {
+		utils.DefaultHandler.ServeHTTP(rw, req, err)
+		return
+	}
+
+	delay, err := r.store.consume(r.name, source, amount, r.rates)
+	if err != nil {
+		log.Debugf("Error counting the request of %s for the rate limit of %s: %v", source, r.name, err)
+		r.fallback.ServeHTTP(rw, req)
+		return
+	}
+
+	if delay > 0 {
+		log.Debugf("Limiting request %s %s from %s, retry in %s", req.Method, req.URL, source, delay)
+		rw.Header().Set("X-Retry-In", delay.String())
+		rw.WriteHeader(http.StatusTooManyRequests)
+		rw.Write([]byte(fmt.Sprintf("max rate reached: retry-in %v", delay)))
+		return
+	}
+	r.next.ServeHTTP(rw, req)
+}
@@ -0,0 +1,342 @@
+package middlewares
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	traefikRedis "github.com/containous/traefik/redis"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/utils"
+)
+
+func TestRedisRateLimiter(t *testing.T) {
+	server := startFakeCounterServer(t)
+	defer server.Close()
+
+	clock := time.Unix(1000, 0)
+	store := NewRateLimitStore([]string{server.Addr()}, traefikRedis.Options{}, "", time.Second)
+	store.now = func() time.Time { return clock }
+	defer store.Close()
+
+	extract, err := utils.NewExtractor("client.ip")
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	rateSet := map[string]*types.Rate{
+		"rate1": {Period: flaeg.Duration(10 * time.Second), Average: 2},
+	}
+
+	// two instances share the counters
+	limiters := make([]http.Handler, 2)
+	for i := range limiters {
+		limiters[i], err = NewRedisRateLimiter(next, "frontend1", extract, rateSet, store, nil)
+		require.NoError(t, err)
+	}
+
+	serve := func(limiter http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, serve(limiters[0], "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, serve(limiters[1], "10.0.0.1:1234").Code)
+
+	recorder := serve(limiters[0], "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "10s", recorder.Header().Get("X-Retry-In"))
+	// the rejected request is not counted
+	assert.EqualValues(t, 2, server.counter("traefik:ratelimit:frontend1:rate1:10s:10.0.0.1:100"))
+
+	// the other sources are not limited
+	assert.Equal(t, http.StatusOK, serve(limiters[1], "10.0.0.2:1234").Code)
+
+	// half of the previous window overlaps the sliding one
+	clock = clock.Add(15 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(limiters[1], "10.0.0.1:1234").Code)
+	recorder = serve(limiters[1], "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "5s", recorder.Header().Get("X-Retry-In"))
+
+	// the requests rejected in the previous window are not counted
+	clock = clock.Add(10 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(limiters[0], "10.0.0.1:1234").Code)
+
+	// the script is only sent once, then run by its digest
+	assert.Equal(t, 1, server.scriptLoads())
+}
+
+func TestRedisRateLimiterRatesWithSamePeriod(t *testing.T) {
+	server := startFakeCounterServer(t)
+	defer server.Close()
+
+	store := NewRateLimitStore([]string{server.Addr()}, traefikRedis.Options{}, "", time.Second)
+	store.now = func() time.Time { return time.Unix(1000, 0) }
+	defer store.Close()
+
+	extract, err := utils.NewExtractor("client.ip")
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	limiter, err := NewRedisRateLimiter(next, "frontend1", extract, map[string]*types.Rate{
+		"rate1": {Period: flaeg.Duration(10 * time.Second), Average: 2},
+		"rate2": {Period: flaeg.Duration(10 * time.Second), Average: 3},
+	}, store, nil)
+	require.NoError(t, err)
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, req)
+		codes = append(codes, recorder.Code)
+	}
+
+	// each request is counted once by each rate
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	assert.EqualValues(t, 2, server.counter("traefik:ratelimit:frontend1:rate1:10s:10.0.0.1:100"))
+	assert.EqualValues(t, 2, server.counter("traefik:ratelimit:frontend1:rate2:10s:10.0.0.1:100"))
+}
+
+func TestRedisRateLimiterUnreachableStore(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	store := NewRateLimitStore([]string{addr}, traefikRedis.Options{}, "", time.Second)
+	defer store.Close()
+
+	extract, err := utils.NewExtractor("client.ip")
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	fallback := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	})
+
+	limiter, err := NewRedisRateLimiter(next, "frontend1", extract, map[string]*types.Rate{
+		"rate1": {Period: flaeg.Duration(time.Second), Average: 1},
+	}, store, fallback)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+	}
+}
+
+func TestNewRateLimitStoreTimeouts(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		timeout             time.Duration
+		dialTimeout         time.Duration
+		expectedTimeout     time.Duration
+		expectedDialTimeout time.Duration
+	}{
+		{
+			desc:                "defaults",
+			expectedTimeout:     DefaultRateLimitStoreTimeout,
+			expectedDialTimeout: DefaultRateLimitStoreDialTimeout,
+		},
+		{
+			desc:                "dial timeout independent of the timeout",
+			timeout:             50 * time.Millisecond,
+			expectedTimeout:     50 * time.Millisecond,
+			expectedDialTimeout: DefaultRateLimitStoreDialTimeout,
+		},
+		{
+			desc:                "configured dial timeout",
+			timeout:             50 * time.Millisecond,
+			dialTimeout:         3 * time.Second,
+			expectedTimeout:     50 * time.Millisecond,
+			expectedDialTimeout: 3 * time.Second,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			store := NewRateLimitStore([]string{"127.0.0.1:6379"}, traefikRedis.Options{ConnectionTimeout: test.dialTimeout}, "", test.timeout)
+			assert.Equal(t, test.expectedTimeout, store.timeout)
+			assert.Equal(t, test.expectedDialTimeout, store.options.ConnectionTimeout)
+		})
+	}
+}
+
+func TestNewRedisRateLimiterInvalid(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		rateSet map[string]*types.Rate
+	}{
+		{
+			desc: "no rate",
+		},
+		{
+			desc:    "invalid period",
+			rateSet: map[string]*types.Rate{"rate1": {Average: 1}},
+		},
+		{
+			desc:    "invalid average",
+			rateSet: map[string]*types.Rate{"rate1": {Period: flaeg.Duration(time.Second)}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewRedisRateLimiter(http.NotFoundHandler(), "frontend1", nil, test.rateSet, nil, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// fakeCounterServer is a Redis server supporting the commands used by the rate limit store,
+// running the rate limit script as the Lua interpreter of Redis would.
+type fakeCounterServer struct {
+	listener net.Listener
+	lock     sync.Mutex
+	counters map[string]int64
+	// scripts is the number of times the script was sent, the server caching it for EVALSHA
+	scripts int
+}
+
+func startFakeCounterServer(t *testing.T) *fakeCounterServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeCounterServer{
+		listener: listener,
+		counters: make(map[string]int64),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (f *fakeCounterServer) Addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeCounterServer) counter(key string) int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.counters[key]
+}
+
+func (f *fakeCounterServer) scriptLoads() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.scripts
+}
+
+func (f *fakeCounterServer) Close() {
+	f.listener.Close()
+}
+
+func (f *fakeCounterServer) serve(netConn net.Conn) {
+	defer netConn.Close()
+	c := traefikRedis.NewConn(netConn, 0)
+	writer := bufio.NewWriter(netConn)
+
+	for {
+		request, err := c.Receive()
+		if err != nil {
+			return
+		}
+		args, err := traefikRedis.ReplyStrings(request)
+		if err != nil || len(args) == 0 {
+			return
+		}
+
+		f.lock.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "EVAL":
+			if len(args) > 1 && args[1] == rateLimitScript {
+				f.scripts++
+			}
+			writer.WriteString(f.evalRateLimitScript(args))
+		case "EVALSHA":
+			if f.scripts == 0 || len(args) < 2 || args[1] != rateLimitScriptSHA1 {
+				writer.WriteString("-NOSCRIPT No matching script. Please use EVAL.\r\n")
+				break
+			}
+			writer.WriteString(f.evalRateLimitScript(append([]string{"EVAL", rateLimitScript}, args[2:]...)))
+		case "GET":
+			if value, ok := f.counters[args[1]]; ok {
+				data := strconv.FormatInt(value, 10)
+				writer.WriteString("$" + strconv.Itoa(len(data)) + "\r\n" + data + "\r\n")
+			} else {
+				writer.WriteString("$-1\r\n")
+			}
+		default:
+			writer.WriteString("-ERR unknown command\r\n")
+		}
+		f.lock.Unlock()
+		writer.Flush()
+	}
+}
+
+// evalRateLimitScript runs the rate limit script on the counters, and returns its reply.
+func (f *fakeCounterServer) evalRateLimitScript(args []string) string {
+	if len(args) < 3 || args[1] != rateLimitScript {
+		return "-ERR unknown script\r\n"
+	}
+	numKeys, _ := strconv.Atoi(args[2])
+	keys := args[3 : 3+numKeys]
+	argv := args[3+numKeys:]
+
+	amount, _ := strconv.ParseInt(argv[0], 10, 64)
+	exceeded := make([]int, len(keys)/2)
+	rejected := false
+	for i := range exceeded {
+		weight, _ := strconv.ParseFloat(argv[3*i+2], 64)
+		average, _ := strconv.ParseFloat(argv[3*i+3], 64)
+		if float64(f.counters[keys[2*i+1]])*weight+float64(f.counters[keys[2*i]]+amount) > average {
+			exceeded[i] = 1
+			rejected = true
+		}
+	}
+	if !rejected {
+		for i := range exceeded {
+			f.counters[keys[2*i]] += amount
+		}
+	}
+
+	reply := "*" + strconv.Itoa(len(exceeded)) + "\r\n"
+	for _, value := range exceeded {
+		reply += ":" + strconv.Itoa(value) + "\r\n"
+	}
+	return reply
+}
@@ -12,6 +12,7 @@ func TestReplacePathRegex(t *testing.T) {
 	testCases := []struct {
 		desc           string
 		path           string
+		query          string
 		replacement    string
 		regex          string
 		expectedPath   string
@@ -41,6 +42,15 @@ func TestReplacePathRegex(t *testing.T) {
 			expectedPath:   "/downloads/src-source.go",
 			expectedHeader: "/downloads/src/source.go",
 		},
+		{
+			desc:           "query string preserved",
+			path:           "/api/v1/users",
+			query:          "page=2&sort=name",
+			replacement:    "/$1",
+			regex:          `^/api/v1/(.*)`,
+			expectedPath:   "/users",
+			expectedHeader: "/api/v1/users",
+		},
 		{
 			desc:         "invalid regular expression",
 			path:         "/invalid/regexp/test",
@@ -55,25 +65,32 @@ func TestReplacePathRegex(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			var actualPath, actualHeader, requestURI string
+			var actualPath, actualQuery, actualHeader, requestURI string
 			handler := NewReplacePathRegexHandler(
 				test.regex,
 				test.replacement,
 				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					actualPath = r.URL.Path
+					actualQuery = r.URL.RawQuery
 					actualHeader = r.Header.Get(ReplacedPathHeader)
 					requestURI = r.RequestURI
 				}),
 			)
 
 			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost"+test.path, nil)
+			req.URL.RawQuery = test.query
 
 			handler.ServeHTTP(nil, req)
 
 			assert.Equal(t, test.expectedPath, actualPath, "Unexpected path.")
+			assert.Equal(t, test.query, actualQuery, "Unexpected query.")
 			assert.Equal(t, test.expectedHeader, actualHeader, "Unexpected '%s' header.", ReplacedPathHeader)
 			if test.expectedHeader != "" {
-				assert.Equal(t, actualPath, requestURI, "Unexpected request URI.")
+				expectedRequestURI := actualPath
+				if len(test.query) > 0 {
+					expectedRequestURI += "?" + test.query
+				}
+				assert.Equal(t, expectedRequestURI, requestURI, "Unexpected request URI.")
 			}
 		})
 	}
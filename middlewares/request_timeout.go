@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestTimeout is a middleware used to cancel the requests, and their forwarding to the backend,
+// which are not completed within the given timeout
+type RequestTimeout struct {
+	Handler http.Handler
+	Timeout time.Duration
+}
+
+func (t *RequestTimeout) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), t.Timeout)
+	defer cancel()
+	t.Handler.ServeHTTP(w, r.WithContext(ctx))
+}
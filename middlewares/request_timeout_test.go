@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	tests := []struct {
+		desc        string
+		timeout     time.Duration
+		delay       time.Duration
+		expectedErr error
+	}{
+		{
+			desc:    "request completed within the timeout",
+			timeout: time.Second,
+		},
+		{
+			desc:        "request exceeding the timeout",
+			timeout:     10 * time.Millisecond,
+			delay:       time.Second,
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var actualErr error
+			handler := &RequestTimeout{
+				Timeout: test.timeout,
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					select {
+					case <-time.After(test.delay):
+					case <-r.Context().Done():
+					}
+					actualErr = r.Context().Err()
+				}),
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost/", nil))
+
+			assert.Equal(t, test.expectedErr, actualErr)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package requestid
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/satori/go.uuid"
+)
+
+// DefaultHeaderName is the header carrying the request ID when no other name is configured.
+const DefaultHeaderName = "X-Request-Id"
+
+type contextKey struct{}
+
+// Handler gives an ID to each request, to correlate the logs and traces of the services handling it.
+// The ID received from the client is kept, otherwise a new one is generated.
+// It is sent to the backend and to the client in the header, and stored in the request context for the access log and the tracing spans.
+type Handler struct {
+	headerName string
+}
+
+// New creates a Handler using the header headerName, or DefaultHeaderName if empty.
+func New(headerName string) *Handler {
+	if len(headerName) == 0 {
+		headerName = DefaultHeaderName
+	}
+	return &Handler{headerName: http.CanonicalHeaderKey(headerName)}
+}
+
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	id := req.Header.Get(h.headerName)
+	if len(id) == 0 {
+		id = uuid.NewV4().String()
+	}
+	req.Header.Set(h.headerName, id)
+	rw.Header().Set(h.headerName, id)
+
+	req = req.WithContext(context.WithValue(req.Context(), contextKey{}, id))
+	next(newResponseWriter(rw, h.headerName, id), req)
+}
+
+// Get returns the ID given to the request, or an empty string if none.
+func Get(req *http.Request) string {
+	if id, ok := req.Context().Value(contextKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// responseWriter sets the request ID header of the response when its headers are written,
+// replacing the header possibly returned by the backend.
+type responseWriter struct {
+	http.ResponseWriter
+	headerName  string
+	id          string
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.Header()[rw.headerName] = []string{rw.id}
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Hijack hijacks the connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", rw.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type responseWriterWithCloseNotify struct {
+	*responseWriter
+}
+
+func (rw *responseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func newResponseWriter(rw http.ResponseWriter, headerName, id string) http.ResponseWriter {
+	writer := &responseWriter{ResponseWriter: rw, headerName: headerName, id: id}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &responseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
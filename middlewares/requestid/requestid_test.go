@@ -0,0 +1,93 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		headerName     string
+		requestHeaders map[string]string
+		backendHeaders map[string]string
+		expectedID     string
+	}{
+		{
+			desc:       "generated ID",
+			headerName: "",
+		},
+		{
+			desc:           "ID preserved",
+			headerName:     "",
+			requestHeaders: map[string]string{"X-Request-Id": "foo"},
+			expectedID:     "foo",
+		},
+		{
+			desc:           "ID preserved with a custom header",
+			headerName:     "x-correlation-id",
+			requestHeaders: map[string]string{"X-Correlation-Id": "foo", "X-Request-Id": "bar"},
+			expectedID:     "foo",
+		},
+		{
+			desc:           "ID returned by the backend replaced",
+			headerName:     "",
+			requestHeaders: map[string]string{"X-Request-Id": "foo"},
+			backendHeaders: map[string]string{"X-Request-Id": "bar"},
+			expectedID:     "foo",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := New(test.headerName)
+
+			req := httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil)
+			for name, value := range test.requestHeaders {
+				req.Header.Set(name, value)
+			}
+
+			var backendID, contextID string
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {
+				backendID = req.Header.Get(handler.headerName)
+				contextID = Get(req)
+				for name, value := range test.backendHeaders {
+					rw.Header().Add(name, value)
+				}
+				rw.Write([]byte("OK"))
+			})
+
+			if len(test.expectedID) > 0 {
+				assert.Equal(t, test.expectedID, backendID)
+			} else {
+				assert.Len(t, backendID, 36)
+			}
+			assert.Equal(t, backendID, contextID)
+			assert.Equal(t, []string{backendID}, recorder.Header()[handler.headerName])
+			assert.Equal(t, "OK", recorder.Body.String())
+		})
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	handler := New("")
+
+	ids := make(map[string]struct{})
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil), func(rw http.ResponseWriter, req *http.Request) {})
+		ids[recorder.Header().Get(DefaultHeaderName)] = struct{}{}
+	}
+	assert.Len(t, ids, 10)
+}
+
+func TestGetWithoutID(t *testing.T) {
+	assert.Empty(t, Get(httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil)))
+}
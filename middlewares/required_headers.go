@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/containous/traefik/middlewares/tracing"
+	"github.com/containous/traefik/types"
+)
+
+// RequiredHeaders is a middleware rejecting with a 400 the requests missing one of the required headers,
+// or whose value is not allowed, with a body describing each of the invalid headers.
+type RequiredHeaders struct {
+	headers []requiredHeader
+}
+
+type requiredHeader struct {
+	name   string
+	values []string
+	regex  *regexp.Regexp
+}
+
+// NewRequiredHeaders creates a RequiredHeaders checking the headers of the requests, by name.
+func NewRequiredHeaders(headers map[string]*types.RequiredHeader) (*RequiredHeaders, error) {
+	r := &RequiredHeaders{}
+	for name := range headers {
+		header := requiredHeader{name: http.CanonicalHeaderKey(name)}
+		if headers[name] != nil {
+			header.values = headers[name].Values
+			if len(headers[name].Regex) > 0 {
+				regex, err := regexp.Compile(headers[name].Regex)
+				if err != nil {
+					return nil, fmt.Errorf("invalid regex of the required header %s: %v", name, err)
+				}
+				header.regex = regex
+			}
+		}
+		r.headers = append(r.headers, header)
+	}
+
+	// the invalid headers are described in the order of their names
+	sort.Slice(r.headers, func(i, j int) bool {
+		return r.headers[i].name < r.headers[j].name
+	})
+	return r, nil
+}
+
+func (r *RequiredHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var invalid []string
+	for _, header := range r.headers {
+		if err := header.check(req); len(err) > 0 {
+			invalid = append(invalid, err)
+		}
+	}
+
+	if len(invalid) > 0 {
+		tracing.SetErrorAndDebugLog(req, "rejecting request with invalid headers: %s", strings.Join(invalid, ", "))
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.Header().Set("X-Content-Type-Options", "nosniff")
+		rw.WriteHeader(http.StatusBadRequest)
+		for _, err := range invalid {
+			fmt.Fprintln(rw, err)
+		}
+		return
+	}
+	next.ServeHTTP(rw, req)
+}
+
+// check returns the description of the error of the header of the request, or an empty string if it is valid
+func (h requiredHeader) check(req *http.Request) string {
+	value := req.Header.Get(h.name)
+	if h.name == "Host" {
+		value = req.Host
+	}
+	if len(value) == 0 {
+		return fmt.Sprintf("missing required header %s", h.name)
+	}
+
+	if len(h.values) > 0 && !h.isAllowedValue(value) {
+		return fmt.Sprintf("invalid value of header %s: expected one of %s", h.name, strings.Join(h.values, ", "))
+	}
+	if h.regex != nil && !h.regex.MatchString(value) {
+		return fmt.Sprintf("invalid value of header %s: expected to match %s", h.name, h.regex)
+	}
+	return ""
+}
+
+func (h requiredHeader) isAllowedValue(value string) bool {
+	for _, allowed := range h.values {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
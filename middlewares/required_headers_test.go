@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredHeaders(t *testing.T) {
+	requiredHeaders := map[string]*types.RequiredHeader{
+		"authorization": nil,
+		"X-Api-Version": {Values: []string{"1", "2"}},
+		"X-Tenant":      {Regex: "^[a-z]+$"},
+	}
+
+	testCases := []struct {
+		desc           string
+		header         http.Header
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc: "valid headers",
+			header: http.Header{
+				"Authorization": {"Bearer token"},
+				"X-Api-Version": {"2"},
+				"X-Tenant":      {"foo"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "missing header",
+			header: http.Header{
+				"X-Api-Version": {"1"},
+				"X-Tenant":      {"foo"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "missing required header Authorization\n",
+		},
+		{
+			desc: "empty header",
+			header: http.Header{
+				"Authorization": {""},
+				"X-Api-Version": {"1"},
+				"X-Tenant":      {"foo"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "missing required header Authorization\n",
+		},
+		{
+			desc: "value not allowed",
+			header: http.Header{
+				"Authorization": {"Bearer token"},
+				"X-Api-Version": {"3"},
+				"X-Tenant":      {"foo"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid value of header X-Api-Version: expected one of 1, 2\n",
+		},
+		{
+			desc: "value not matching the regex",
+			header: http.Header{
+				"Authorization": {"Bearer token"},
+				"X-Api-Version": {"1"},
+				"X-Tenant":      {"Foo"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid value of header X-Tenant: expected to match ^[a-z]+$\n",
+		},
+		{
+			desc:           "all headers missing",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "missing required header Authorization\nmissing required header X-Api-Version\nmissing required header X-Tenant\n",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewRequiredHeaders(requiredHeaders)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req, func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNewRequiredHeadersInvalidRegex(t *testing.T) {
+	_, err := NewRequiredHeaders(map[string]*types.RequiredHeader{"X-Tenant": {Regex: "[a-z"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ResponseHeadersRemover is a middleware removing headers from the responses, such as the ones revealing the software of the backends.
+// The headers are removed when the response is written, after all the middlewares it wraps have modified them.
+type ResponseHeadersRemover struct {
+	next    http.Handler
+	headers []string
+}
+
+// NewResponseHeadersRemover creates a ResponseHeadersRemover removing headers from the responses of next.
+func NewResponseHeadersRemover(next http.Handler, headers []string) *ResponseHeadersRemover {
+	canonicalHeaders := make([]string, 0, len(headers))
+	for _, header := range headers {
+		canonicalHeaders = append(canonicalHeaders, http.CanonicalHeaderKey(header))
+	}
+	return &ResponseHeadersRemover{
+		next:    next,
+		headers: canonicalHeaders,
+	}
+}
+
+func (r *ResponseHeadersRemover) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.next.ServeHTTP(newHeadersRemoverResponseWriter(rw, r.headers), req)
+}
+
+func newHeadersRemoverResponseWriter(rw http.ResponseWriter, headers []string) http.ResponseWriter {
+	writer := &headersRemoverResponseWriterWithoutCloseNotify{
+		responseWriter: rw,
+		headers:        headers,
+	}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &headersRemoverResponseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
+
+// headersRemoverResponseWriterWithoutCloseNotify removes the headers from the response when its header is written.
+type headersRemoverResponseWriterWithoutCloseNotify struct {
+	responseWriter http.ResponseWriter
+	headers        []string
+	wroteHeader    bool
+}
+
+func (rw *headersRemoverResponseWriterWithoutCloseNotify) Header() http.Header {
+	return rw.responseWriter.Header()
+}
+
+func (rw *headersRemoverResponseWriterWithoutCloseNotify) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		header := rw.responseWriter.Header()
+		for _, name := range rw.headers {
+			header.Del(name)
+		}
+	}
+	rw.responseWriter.WriteHeader(code)
+}
+
+func (rw *headersRemoverResponseWriterWithoutCloseNotify) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.responseWriter.Write(b)
+}
+
+// Hijack hijacks the connection
+func (rw *headersRemoverResponseWriterWithoutCloseNotify) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", rw.responseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+func (rw *headersRemoverResponseWriterWithoutCloseNotify) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rw.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type headersRemoverResponseWriterWithCloseNotify struct {
+	*headersRemoverResponseWriterWithoutCloseNotify
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (rw *headersRemoverResponseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.responseWriter.(http.CloseNotifier).CloseNotify()
+}
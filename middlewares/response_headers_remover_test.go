@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseHeadersRemover(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		handler http.HandlerFunc
+	}{
+		{
+			desc: "headers written with the status code",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Server", "Apache")
+				rw.Header().Set("X-Powered-By", "PHP")
+				rw.Header().Set("X-Foo", "bar")
+				rw.WriteHeader(http.StatusOK)
+				rw.Write([]byte("OK"))
+			},
+		},
+		{
+			desc: "headers written with the body",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Server", "Apache")
+				rw.Header().Set("X-Powered-By", "PHP")
+				rw.Header().Set("X-Foo", "bar")
+				rw.Write([]byte("OK"))
+			},
+		},
+		{
+			desc: "headers written with a flush",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Server", "Apache")
+				rw.Header().Set("X-Powered-By", "PHP")
+				rw.Header().Set("X-Foo", "bar")
+				rw.(http.Flusher).Flush()
+				rw.Write([]byte("OK"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewResponseHeadersRemover(test.handler, []string{"server", "X-Powered-By"})
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "OK", recorder.Body.String())
+			assert.Empty(t, recorder.Header().Get("Server"))
+			assert.Empty(t, recorder.Header().Get("X-Powered-By"))
+			assert.Equal(t, "bar", recorder.Header().Get("X-Foo"))
+		})
+	}
+}
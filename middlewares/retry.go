@@ -16,14 +16,22 @@ var _ Stateful = &retryResponseWriterWithCloseNotify{}
 // Retry is a middleware that retries requests
 type Retry struct {
 	attempts int
+	budget   *RetryBudget
 	next     http.Handler
 	listener RetryListener
 }
 
 // NewRetry returns a new Retry instance
 func NewRetry(attempts int, next http.Handler, listener RetryListener) *Retry {
+	return NewRetryWithBudget(attempts, nil, next, listener)
+}
+
+// NewRetryWithBudget returns a new Retry instance, retrying the requests only while the budget allows it.
+// A nil budget does not limit the retries.
+func NewRetryWithBudget(attempts int, budget *RetryBudget, next http.Handler, listener RetryListener) *Retry {
 	return &Retry{
 		attempts: attempts,
+		budget:   budget,
 		next:     next,
 		listener: listener,
 	}
@@ -38,16 +46,35 @@ func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		r.Body = ioutil.NopCloser(body)
 	}
 
+	if retry.budget != nil {
+		retry.budget.deposit()
+	}
+
 	attempts := 1
 	for {
+		lastAttempt := attempts >= retry.attempts
+		// The token of the next retry is taken before the attempt, as its response is discarded when it fails.
+		// It is given back if the attempt does not fail.
+		budgetReserved := false
+		if !lastAttempt && retry.budget != nil {
+			budgetReserved = retry.budget.withdraw()
+			if !budgetReserved {
+				log.Debugf("Retry budget exhausted, no new attempt after attempt %d for request: %v", attempts, r.URL)
+				lastAttempt = true
+			}
+		}
+
 		netErrorOccurred := false
 		// We pass in a pointer to netErrorOccurred so that we can set it to true on network errors
 		// when proxying the HTTP requests to the backends. This happens in the custom RecordingErrorHandler.
 		newCtx := context.WithValue(r.Context(), defaultNetErrCtxKey, &netErrorOccurred)
-		retryResponseWriter := newRetryResponseWriter(rw, attempts >= retry.attempts, &netErrorOccurred)
+		retryResponseWriter := newRetryResponseWriter(rw, lastAttempt, &netErrorOccurred)
 
 		retry.next.ServeHTTP(retryResponseWriter, r.WithContext(newCtx))
 		if !retryResponseWriter.ShouldRetry() {
+			if budgetReserved {
+				retry.budget.refund()
+			}
 			break
 		}
 
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// DefaultRetryBudgetBurst is the number of retries allowed on top of the ratio of the requests
+// when the burst of a retry budget is not set.
+const DefaultRetryBudgetBurst = 10
+
+// RetryBudget is a token bucket capping the retries to a ratio of the requests:
+// each request adds the ratio to the bucket, and each retry takes a whole token out of it.
+// The bucket starts full and holds at most burst tokens, so that a few retries are still
+// allowed to the backends receiving little traffic.
+type RetryBudget struct {
+	lock   sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+	gauge  metrics.Gauge
+}
+
+// NewRetryBudget returns a new RetryBudget, reporting the number of tokens left to the given gauge if any.
+func NewRetryBudget(ratio float64, burst int, gauge metrics.Gauge) (*RetryBudget, error) {
+	if ratio <= 0 {
+		return nil, fmt.Errorf("retry budget ratio must be positive, got %v", ratio)
+	}
+	if burst < 0 {
+		return nil, fmt.Errorf("retry budget burst must not be negative, got %d", burst)
+	}
+	if burst == 0 {
+		burst = DefaultRetryBudgetBurst
+	}
+
+	budget := &RetryBudget{
+		ratio:  ratio,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		gauge:  gauge,
+	}
+	budget.report()
+	return budget, nil
+}
+
+// deposit adds the share of a new request to the budget.
+func (b *RetryBudget) deposit() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.report()
+}
+
+// withdraw takes the token of a retry out of the budget, and returns false if the budget is exhausted.
+func (b *RetryBudget) withdraw() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	b.report()
+	return true
+}
+
+// refund gives back the token of a retry which did not happen.
+func (b *RetryBudget) refund() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.report()
+}
+
+// Tokens returns the number of tokens left in the budget.
+func (b *RetryBudget) Tokens() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.tokens
+}
+
+func (b *RetryBudget) report() {
+	if b.gauge != nil {
+		b.gauge.Set(b.tokens)
+	}
+}
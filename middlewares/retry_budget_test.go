@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetryBudgetInvalid(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		ratio float64
+		burst int
+	}{
+		{
+			desc:  "zero ratio",
+			ratio: 0,
+		},
+		{
+			desc:  "negative ratio",
+			ratio: -0.1,
+		},
+		{
+			desc:  "negative burst",
+			ratio: 0.1,
+			burst: -1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewRetryBudget(test.ratio, test.burst, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	gauge := generic.NewGauge("budget")
+	budget, err := NewRetryBudget(0.25, 2, gauge)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), gauge.Value())
+
+	assert.True(t, budget.withdraw())
+	assert.True(t, budget.withdraw())
+	assert.False(t, budget.withdraw())
+	assert.Equal(t, float64(0), gauge.Value())
+
+	for i := 0; i < 3; i++ {
+		budget.deposit()
+	}
+	assert.False(t, budget.withdraw())
+	budget.deposit()
+	assert.True(t, budget.withdraw())
+	assert.Equal(t, float64(0), budget.Tokens())
+
+	budget.refund()
+	budget.refund()
+	budget.refund()
+	assert.Equal(t, float64(2), budget.Tokens())
+	assert.Equal(t, float64(2), gauge.Value())
+}
+
+func TestRetryBudgetDefaultBurst(t *testing.T) {
+	budget, err := NewRetryBudget(0.1, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, float64(DefaultRetryBudgetBurst), budget.Tokens())
+}
+
+func TestRetryWithBudget(t *testing.T) {
+	budget, err := NewRetryBudget(0.5, 1, nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		failAtCalls    []int
+		responseStatus int
+		retriedCount   int
+		expectedTokens float64
+	}{
+		{
+			desc:           "succeeding request keeps the reserved retry",
+			responseStatus: http.StatusOK,
+			expectedTokens: 1,
+		},
+		{
+			desc:           "retry within the budget",
+			failAtCalls:    []int{1},
+			responseStatus: http.StatusOK,
+			retriedCount:   1,
+			expectedTokens: 0,
+		},
+		{
+			desc:           "budget exhausted",
+			failAtCalls:    []int{1},
+			responseStatus: http.StatusBadGateway,
+			expectedTokens: 0.5,
+		},
+		{
+			desc:           "budget exhausted after a retry",
+			failAtCalls:    []int{1, 2},
+			responseStatus: http.StatusBadGateway,
+			retriedCount:   1,
+			expectedTokens: 0,
+		},
+	}
+
+	// the test cases share the budget and run in order
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			listener := &countingRetryListener{}
+			handler := &networkFailingHTTPHandler{failAtCalls: test.failAtCalls, netErrorRecorder: &DefaultNetErrorRecorder{}}
+			retry := NewRetryWithBudget(3, budget, handler, listener)
+
+			recorder := httptest.NewRecorder()
+			retry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost:3000/ok", ioutil.NopCloser(nil)))
+
+			assert.Equal(t, test.responseStatus, recorder.Code)
+			assert.Equal(t, test.retriedCount, listener.timesCalled)
+			assert.Equal(t, test.expectedTokens, budget.Tokens())
+		})
+	}
+}
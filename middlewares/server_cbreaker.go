@@ -0,0 +1,292 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/log"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+// Default settings of the per-server circuit breakers
+const (
+	DefaultServerCircuitBreakerFailures            = 5
+	DefaultServerCircuitBreakerCooldown            = 30 * time.Second
+	DefaultServerCircuitBreakerResponseTimeSamples = 100
+)
+
+// ServerCircuitBreaker ejects from the load-balancer of a backend the servers whose responses fail consecutively,
+// with a 5xx status code, or are too slow, and re-admits them after a cooldown, so that a failing server doesn't impact the others.
+// The last server of the load-balancer is never ejected.
+type ServerCircuitBreaker struct {
+	next                http.Handler
+	backendName         string
+	maxFailures         int
+	cooldown            time.Duration
+	weights             map[string]int
+	maxResponseTime     time.Duration
+	responseTimeSamples int
+
+	lock            sync.Mutex
+	lb              healthcheck.LoadBalancer
+	failures        map[string]int
+	responseTimes   map[string]*responseTimeWindow
+	ejected         map[string]bool
+	circuitBreakers *ServerCircuitBreakers
+}
+
+// NewServerCircuitBreaker creates a ServerCircuitBreaker forwarding the requests to next,
+// which receives them once a server of the load-balancer set with SetLoadBalancer has been selected.
+// The weights of the servers, by URL, are restored when they are re-admitted.
+func NewServerCircuitBreaker(next http.Handler, backendName string, maxFailures int, cooldown time.Duration, weights map[string]int) *ServerCircuitBreaker {
+	if maxFailures <= 0 {
+		maxFailures = DefaultServerCircuitBreakerFailures
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultServerCircuitBreakerCooldown
+	}
+	return &ServerCircuitBreaker{
+		next:        next,
+		backendName: backendName,
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+		weights:     weights,
+		failures:    make(map[string]int),
+		ejected:     make(map[string]bool),
+	}
+}
+
+// SetMaxResponseTime makes the servers whose 95th percentile of the response times, over their last samples responses,
+// exceeds maxResponseTime ejected as well.
+func (s *ServerCircuitBreaker) SetMaxResponseTime(maxResponseTime time.Duration, samples int) {
+	if samples <= 0 {
+		samples = DefaultServerCircuitBreakerResponseTimeSamples
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.maxResponseTime = maxResponseTime
+	s.responseTimeSamples = samples
+	s.responseTimes = make(map[string]*responseTimeWindow)
+}
+
+// SetLoadBalancer sets the load-balancer the failing servers are ejected from
+func (s *ServerCircuitBreaker) SetLoadBalancer(lb healthcheck.LoadBalancer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lb = lb
+}
+
+func (s *ServerCircuitBreaker) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	recorder := &responseRecorder{ResponseWriter: rw, statusCode: http.StatusOK}
+	s.next.ServeHTTP(recorder, req)
+
+	if req.URL == nil || len(req.URL.Host) == 0 {
+		return
+	}
+	s.record(req.URL, recorder.statusCode >= http.StatusInternalServerError, time.Since(start))
+}
+
+func (s *ServerCircuitBreaker) record(serverURL *url.URL, failed bool, responseTime time.Duration) {
+	key := serverURL.String()
+
+	s.lock.Lock()
+	var reason string
+	if failed {
+		s.failures[key]++
+		if s.failures[key] >= s.maxFailures {
+			reason = fmt.Sprintf("%d consecutive failures", s.failures[key])
+		}
+	} else {
+		delete(s.failures, key)
+	}
+
+	if s.maxResponseTime > 0 {
+		window := s.responseTimes[key]
+		if window == nil {
+			window = &responseTimeWindow{samples: make([]time.Duration, 0, s.responseTimeSamples)}
+			s.responseTimes[key] = window
+		}
+		window.add(responseTime)
+		if p95, ok := window.percentile(95); ok && p95 > s.maxResponseTime && len(reason) == 0 {
+			reason = fmt.Sprintf("a 95th percentile response time of %s over %d responses", p95, len(window.samples))
+		}
+	}
+
+	if len(reason) == 0 || s.ejected[key] || !s.canEject(key) {
+		s.lock.Unlock()
+		return
+	}
+
+	if err := s.lb.RemoveServer(serverURL); err != nil {
+		s.lock.Unlock()
+		log.Errorf("Error ejecting server %s from backend %s: %v", key, s.backendName, err)
+		return
+	}
+	log.Warnf("Ejecting server %s from backend %s for %s after %s", key, s.backendName, s.cooldown, reason)
+	delete(s.failures, key)
+	if s.responseTimes != nil {
+		// the server is judged on its new responses once re-admitted
+		delete(s.responseTimes, key)
+	}
+	s.ejected[key] = true
+	lb := s.lb
+	circuitBreakers := s.circuitBreakers
+	s.lock.Unlock()
+
+	if circuitBreakers != nil {
+		circuitBreakers.report(s.backendName)
+	}
+
+	time.AfterFunc(s.cooldown, func() {
+		s.readmit(lb, serverURL)
+	})
+}
+
+// responseTimeWindow holds the response times of the last responses of a server
+type responseTimeWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func (w *responseTimeWindow) add(responseTime time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, responseTime)
+		return
+	}
+	w.samples[w.next] = responseTime
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+// percentile returns the given percentile of the response times, once the window is full
+func (w *responseTimeWindow) percentile(percentile int) (time.Duration, bool) {
+	if len(w.samples) == 0 || len(w.samples) < cap(w.samples) {
+		return 0, false
+	}
+
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	// nearest-rank method
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1], true
+}
+
+// canEject returns true if the server is in the load-balancer, and is not the last one
+func (s *ServerCircuitBreaker) canEject(key string) bool {
+	if s.lb == nil {
+		return false
+	}
+	servers := s.lb.Servers()
+	if len(servers) <= 1 {
+		return false
+	}
+	for _, server := range servers {
+		if server.String() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServerCircuitBreaker) readmit(lb healthcheck.LoadBalancer, serverURL *url.URL) {
+	key := serverURL.String()
+
+	weight, ok := s.weights[key]
+	if !ok {
+		weight = 1
+	}
+	log.Infof("Re-admitting server %s in backend %s", key, s.backendName)
+	if err := lb.UpsertServer(serverURL, roundrobin.Weight(weight)); err != nil {
+		log.Errorf("Error re-admitting server %s in backend %s: %v", key, s.backendName, err)
+	}
+
+	s.lock.Lock()
+	delete(s.ejected, key)
+	circuitBreakers := s.circuitBreakers
+	s.lock.Unlock()
+
+	if circuitBreakers != nil {
+		circuitBreakers.report(s.backendName)
+	}
+}
+
+// ejectedServers returns the URLs of the ejected servers
+func (s *ServerCircuitBreaker) ejectedServers() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var servers []string
+	for key := range s.ejected {
+		servers = append(servers, key)
+	}
+	return servers
+}
+
+func (s *ServerCircuitBreaker) setCircuitBreakers(circuitBreakers *ServerCircuitBreakers) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.circuitBreakers = circuitBreakers
+}
+
+// ServerCircuitBreakers holds the per-server circuit breakers of the current configuration,
+// and reports with a gauge how many servers of each backend are ejected.
+type ServerCircuitBreakers struct {
+	gauge           gokitmetrics.Gauge
+	lock            sync.RWMutex
+	circuitBreakers []*ServerCircuitBreaker
+}
+
+// NewServerCircuitBreakers creates a ServerCircuitBreakers reporting the ejected servers of each backend with gauge.
+func NewServerCircuitBreakers(gauge gokitmetrics.Gauge) *ServerCircuitBreakers {
+	return &ServerCircuitBreakers{gauge: gauge}
+}
+
+// Set replaces the per-server circuit breakers of the previous configuration.
+func (c *ServerCircuitBreakers) Set(circuitBreakers []*ServerCircuitBreaker) {
+	c.lock.Lock()
+	previous := c.circuitBreakers
+	c.circuitBreakers = circuitBreakers
+	c.lock.Unlock()
+
+	backendNames := make(map[string]bool)
+	for _, cb := range previous {
+		cb.setCircuitBreakers(nil)
+		backendNames[cb.backendName] = true
+	}
+	for _, cb := range circuitBreakers {
+		cb.setCircuitBreakers(c)
+		backendNames[cb.backendName] = true
+	}
+	for backendName := range backendNames {
+		c.report(backendName)
+	}
+}
+
+// report sets the gauge of a backend to the number of its distinct servers ejected on any entrypoint
+func (c *ServerCircuitBreakers) report(backendName string) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	ejected := make(map[string]bool)
+	for _, cb := range c.circuitBreakers {
+		if cb.backendName != backendName {
+			continue
+		}
+		for _, server := range cb.ejectedServers() {
+			ejected[server] = true
+		}
+	}
+	c.gauge.With("backend", backendName).Set(float64(len(ejected)))
+}
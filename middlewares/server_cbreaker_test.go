@@ -0,0 +1,211 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func TestServerCircuitBreakerEjectsFailingServer(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Host == "bad:80" {
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	cb := NewServerCircuitBreaker(handler, "backend1", 2, 100*time.Millisecond, map[string]int{"http://bad:80": 3})
+	lb, err := roundrobin.New(cb)
+	require.NoError(t, err)
+	cb.SetLoadBalancer(lb)
+
+	good := testhelpers.MustParseURL("http://good:80")
+	bad := testhelpers.MustParseURL("http://bad:80")
+	require.NoError(t, lb.UpsertServer(good))
+	require.NoError(t, lb.UpsertServer(bad))
+
+	gauge := &gaugeMock{}
+	circuitBreakers := NewServerCircuitBreakers(gauge)
+	circuitBreakers.Set([]*ServerCircuitBreaker{cb})
+
+	// the bad server receives every other request, and is ejected after its second consecutive failure
+	for i := 0; i < 4; i++ {
+		lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	}
+	assert.Equal(t, []*url.URL{good}, lb.Servers())
+
+	value, labelValues := gauge.get()
+	assert.Equal(t, float64(1), value)
+	assert.Equal(t, []string{"backend", "backend1"}, labelValues)
+
+	for i := 0; i < 4; i++ {
+		recorder := httptest.NewRecorder()
+		lb.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	// the bad server is re-admitted after the cooldown, with its weight
+	for i := 0; i < 100; i++ {
+		if value, _ = gauge.get(); value == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, float64(0), value)
+	require.Len(t, lb.Servers(), 2)
+
+	selected := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		server, err := lb.NextServer()
+		require.NoError(t, err)
+		selected[server.String()]++
+	}
+	assert.Equal(t, map[string]int{good.String(): 1, bad.String(): 3}, selected)
+}
+
+func TestServerCircuitBreakerKeepsLastServer(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	})
+
+	cb := NewServerCircuitBreaker(handler, "backend1", 1, time.Minute, nil)
+	lb, err := roundrobin.New(cb)
+	require.NoError(t, err)
+	cb.SetLoadBalancer(lb)
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server:80")))
+
+	for i := 0; i < 3; i++ {
+		lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	}
+	assert.Len(t, lb.Servers(), 1)
+}
+
+func TestServerCircuitBreakerResetsOnSuccess(t *testing.T) {
+	var fail bool
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if fail {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	cb := NewServerCircuitBreaker(handler, "backend1", 2, time.Minute, nil)
+	lb, err := roundrobin.New(cb)
+	require.NoError(t, err)
+	cb.SetLoadBalancer(lb)
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server1:80")))
+	require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://server2:80")))
+
+	// the failures of each server are interleaved with successes, so they are never consecutive
+	for i := 0; i < 8; i++ {
+		fail = i%4 < 2
+		lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	}
+	assert.Len(t, lb.Servers(), 2)
+}
+
+func TestServerCircuitBreakerEjectsSlowServer(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Host == "slow:80" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	cb := NewServerCircuitBreaker(handler, "backend1", 0, 100*time.Millisecond, nil)
+	cb.SetMaxResponseTime(10*time.Millisecond, 3)
+	lb, err := roundrobin.New(cb)
+	require.NoError(t, err)
+	cb.SetLoadBalancer(lb)
+
+	fast := testhelpers.MustParseURL("http://fast:80")
+	slow := testhelpers.MustParseURL("http://slow:80")
+	require.NoError(t, lb.UpsertServer(fast))
+	require.NoError(t, lb.UpsertServer(slow))
+
+	// the slow server is judged once it has answered 3 requests
+	for i := 0; i < 4; i++ {
+		lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	}
+	assert.Len(t, lb.Servers(), 2)
+
+	lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	lb.ServeHTTP(httptest.NewRecorder(), testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil))
+	assert.Equal(t, []*url.URL{fast}, lb.Servers())
+
+	// the slow server is re-admitted after the cooldown
+	for i := 0; i < 100 && len(lb.Servers()) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Len(t, lb.Servers(), 2)
+}
+
+func TestResponseTimeWindowPercentile(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		size          int
+		responseTimes []time.Duration
+		expected      time.Duration
+		expectedOK    bool
+	}{
+		{
+			desc:          "window not full",
+			size:          3,
+			responseTimes: []time.Duration{time.Second, time.Second},
+		},
+		{
+			desc:          "outlier below the 95th percentile",
+			size:          20,
+			responseTimes: append(repeatDuration(time.Millisecond, 19), time.Second),
+			expected:      time.Millisecond,
+			expectedOK:    true,
+		},
+		{
+			desc:          "outliers above the 95th percentile",
+			size:          20,
+			responseTimes: append(repeatDuration(time.Millisecond, 18), time.Second, time.Second),
+			expected:      time.Second,
+			expectedOK:    true,
+		},
+		{
+			desc:          "oldest response times replaced",
+			size:          2,
+			responseTimes: []time.Duration{time.Second, time.Second, time.Millisecond, time.Millisecond},
+			expected:      time.Millisecond,
+			expectedOK:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			window := &responseTimeWindow{samples: make([]time.Duration, 0, test.size)}
+			for _, responseTime := range test.responseTimes {
+				window.add(responseTime)
+			}
+
+			percentile, ok := window.percentile(95)
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expected, percentile)
+		})
+	}
+}
+
+func repeatDuration(duration time.Duration, count int) []time.Duration {
+	durations := make([]time.Duration, count)
+	for i := range durations {
+		durations[i] = duration
+	}
+	return durations
+}
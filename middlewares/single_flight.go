@@ -0,0 +1,267 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// DefaultSingleFlightMaxBodySize is the default size of the largest response shared by the coalesced requests, in bytes
+const DefaultSingleFlightMaxBodySize int64 = 1024 * 1024
+
+// SingleFlight is a middleware coalescing the concurrent identical GET and HEAD requests into a single request to the next handler,
+// whose response is sent to all the waiting clients.
+// The requests are identical when they have the same method, host, path, query and values of the selected headers.
+// The requests with a Cache-Control: no-cache header, a body, a Range or an Upgrade header are not coalesced,
+// and the responses with a Set-Cookie header, or a Cache-Control: private or no-store header, are not shared.
+type SingleFlight struct {
+	next        http.Handler
+	headers     []string
+	maxBodySize int64
+
+	lock  sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall holds the response of the request sent to the next handler, shared once done is closed
+type flightCall struct {
+	done   chan struct{}
+	shared bool
+	code   int
+	header http.Header
+	body   []byte
+}
+
+// NewSingleFlight creates a SingleFlight in front of next
+func NewSingleFlight(next http.Handler, config *types.SingleFlight) *SingleFlight {
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultSingleFlightMaxBodySize
+	}
+	headers := make([]string, 0, len(config.Headers))
+	for _, header := range config.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(header))
+	}
+	return &SingleFlight{
+		next:        next,
+		headers:     headers,
+		maxBodySize: maxBodySize,
+		calls:       make(map[string]*flightCall),
+	}
+}
+
+func (s *SingleFlight) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !isCoalescable(req) {
+		s.next.ServeHTTP(rw, req)
+		return
+	}
+
+	key := s.key(req)
+	s.lock.Lock()
+	if call, ok := s.calls[key]; ok {
+		s.lock.Unlock()
+		s.wait(rw, req, call)
+		return
+	}
+	call := &flightCall{done: make(chan struct{})}
+	s.calls[key] = call
+	s.lock.Unlock()
+
+	writer := newSingleFlightResponseWriter(rw, s.maxBodySize)
+	completed := false
+	// the waiting requests are released even if the next handler panics
+	defer func() {
+		if completed && !writer.isTruncated() && req.Context().Err() == nil && isShareable(writer.getHeader()) {
+			call.shared = true
+			call.code = writer.getCode()
+			call.header = writer.getHeader()
+			call.body = writer.getBody()
+		}
+
+		s.lock.Lock()
+		delete(s.calls, key)
+		s.lock.Unlock()
+		close(call.done)
+	}()
+	s.next.ServeHTTP(writer, req)
+	completed = true
+}
+
+// wait sends the response of call once it is done, or forwards the request itself if the response cannot be shared.
+// The requests waiting for a response which cannot be shared are forwarded concurrently, without being coalesced again.
+func (s *SingleFlight) wait(rw http.ResponseWriter, req *http.Request, call *flightCall) {
+	select {
+	case <-call.done:
+	case <-req.Context().Done():
+		return
+	}
+
+	if !call.shared {
+		log.Debugf("Unable to share the response of %s %s, forwarding the request", req.Method, req.URL)
+		s.next.ServeHTTP(rw, req)
+		return
+	}
+
+	for name, values := range call.header {
+		rw.Header()[name] = append([]string(nil), values...)
+	}
+	rw.WriteHeader(call.code)
+	rw.Write(call.body)
+}
+
+func (s *SingleFlight) key(req *http.Request) string {
+	parts := []string{req.Method, strings.ToLower(req.Host), req.URL.RequestURI()}
+	for _, header := range s.headers {
+		parts = append(parts, strings.Join(req.Header[header], ","))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func isCoalescable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.ContentLength > 0 || len(req.TransferEncoding) > 0 {
+		return false
+	}
+	if len(req.Header.Get("Range")) > 0 || len(req.Header.Get("Upgrade")) > 0 {
+		return false
+	}
+	return !hasCacheControlDirective(req.Header, "no-cache")
+}
+
+func isShareable(header http.Header) bool {
+	if len(header["Set-Cookie"]) > 0 {
+		return false
+	}
+	return !hasCacheControlDirective(header, "private", "no-store")
+}
+
+func hasCacheControlDirective(header http.Header, names ...string) bool {
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			// the directive may have an argument, such as private="Set-Cookie"
+			directive = strings.TrimSpace(strings.SplitN(directive, "=", 2)[0])
+			for _, name := range names {
+				if strings.EqualFold(directive, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for name, values := range header {
+		clone[name] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+type singleFlightResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	getCode() int
+	getHeader() http.Header
+	getBody() []byte
+	isTruncated() bool
+}
+
+func newSingleFlightResponseWriter(rw http.ResponseWriter, maxBodySize int64) singleFlightResponseWriter {
+	writer := &singleFlightResponseWriterWithoutCloseNotify{
+		responseWriter: rw,
+		maxBodySize:    maxBodySize,
+	}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &singleFlightResponseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
+
+// singleFlightResponseWriterWithoutCloseNotify writes the response to the client, and records it to share it with the coalesced requests,
+// unless its body is larger than maxBodySize.
+type singleFlightResponseWriterWithoutCloseNotify struct {
+	responseWriter http.ResponseWriter
+	maxBodySize    int64
+	code           int
+	header         http.Header
+	body           bytes.Buffer
+	truncated      bool
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) Header() http.Header {
+	return rw.responseWriter.Header()
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) WriteHeader(code int) {
+	if rw.code != 0 {
+		return
+	}
+	rw.code = code
+	rw.header = cloneHeader(rw.responseWriter.Header())
+	rw.responseWriter.WriteHeader(code)
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) Write(b []byte) (int, error) {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.truncated {
+		if int64(rw.body.Len()+len(b)) > rw.maxBodySize {
+			rw.truncated = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	return rw.responseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (rw *singleFlightResponseWriterWithoutCloseNotify) Flush() {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rw.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) getCode() int {
+	if rw.code == 0 {
+		return http.StatusOK
+	}
+	return rw.code
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) getHeader() http.Header {
+	if rw.header == nil {
+		return cloneHeader(rw.responseWriter.Header())
+	}
+	return rw.header
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) getBody() []byte {
+	return rw.body.Bytes()
+}
+
+func (rw *singleFlightResponseWriterWithoutCloseNotify) isTruncated() bool {
+	return rw.truncated
+}
+
+type singleFlightResponseWriterWithCloseNotify struct {
+	*singleFlightResponseWriterWithoutCloseNotify
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (rw *singleFlightResponseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.responseWriter.(http.CloseNotifier).CloseNotify()
+}
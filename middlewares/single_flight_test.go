@@ -0,0 +1,346 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingHandler counts the requests it receives, and answers them once release is closed
+type blockingHandler struct {
+	requests int32
+	received chan struct{}
+	release  chan struct{}
+	header   http.Header
+	body     string
+}
+
+func newBlockingHandler(body string) *blockingHandler {
+	return &blockingHandler{
+		received: make(chan struct{}, 100),
+		release:  make(chan struct{}),
+		header:   http.Header{},
+		body:     body,
+	}
+}
+
+func (h *blockingHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	count := atomic.AddInt32(&h.requests, 1)
+	h.received <- struct{}{}
+	<-h.release
+	for name, values := range h.header {
+		rw.Header()[name] = values
+	}
+	rw.Header().Set("X-Request", strconv.Itoa(int(count)))
+	rw.WriteHeader(http.StatusOK)
+	rw.Write([]byte(h.body))
+}
+
+func (h *blockingHandler) count() int {
+	return int(atomic.LoadInt32(&h.requests))
+}
+
+// serveConcurrently sends the requests to handler once the first one is received by backend, and waits for the waiting ones to be coalesced
+func serveConcurrently(handler http.Handler, backend *blockingHandler, requests []*http.Request) []*httptest.ResponseRecorder {
+	recorders := make([]*httptest.ResponseRecorder, len(requests))
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(recorder *httptest.ResponseRecorder, req *http.Request) {
+			defer wg.Done()
+			handler.ServeHTTP(recorder, req)
+		}(recorders[i], req)
+		if i == 0 {
+			<-backend.received
+		}
+	}
+	// leaves time to the requests to reach the middleware
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+	return recorders
+}
+
+func TestSingleFlightCoalescesIdenticalRequests(t *testing.T) {
+	backend := newBlockingHandler("expensive")
+	backend.header.Set("Cache-Control", "public, max-age=60")
+	handler := NewSingleFlight(backend, &types.SingleFlight{})
+
+	var requests []*http.Request
+	for i := 0; i < 5; i++ {
+		requests = append(requests, testhelpers.MustNewRequest(http.MethodGet, "http://foo.com/bar?q=1", nil))
+	}
+	recorders := serveConcurrently(handler, backend, requests)
+
+	assert.Equal(t, 1, backend.count())
+	for _, recorder := range recorders {
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "expensive", recorder.Body.String())
+		assert.Equal(t, "1", recorder.Header().Get("X-Request"))
+		assert.Equal(t, "public, max-age=60", recorder.Header().Get("Cache-Control"))
+	}
+	assert.Empty(t, handler.calls)
+}
+
+func TestSingleFlightKey(t *testing.T) {
+	newRequest := func(method, url, accept string) *http.Request {
+		req := testhelpers.MustNewRequest(method, url, nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("User-Agent", "client-"+url)
+		return req
+	}
+
+	testCases := []struct {
+		desc             string
+		headers          []string
+		requests         []*http.Request
+		expectedRequests int
+	}{
+		{
+			desc:    "headers not selected",
+			headers: nil,
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar", "text/html"),
+				newRequest(http.MethodGet, "http://foo.com/bar", "application/json"),
+			},
+			expectedRequests: 1,
+		},
+		{
+			desc:    "selected headers differ",
+			headers: []string{"accept"},
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar", "text/html"),
+				newRequest(http.MethodGet, "http://foo.com/bar", "application/json"),
+			},
+			expectedRequests: 2,
+		},
+		{
+			desc: "paths differ",
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar", "text/html"),
+				newRequest(http.MethodGet, "http://foo.com/baz", "text/html"),
+			},
+			expectedRequests: 2,
+		},
+		{
+			desc: "queries differ",
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar?q=1", "text/html"),
+				newRequest(http.MethodGet, "http://foo.com/bar?q=2", "text/html"),
+			},
+			expectedRequests: 2,
+		},
+		{
+			desc: "hosts differ",
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar", "text/html"),
+				newRequest(http.MethodGet, "http://bar.com/bar", "text/html"),
+			},
+			expectedRequests: 2,
+		},
+		{
+			desc: "methods differ",
+			requests: []*http.Request{
+				newRequest(http.MethodGet, "http://foo.com/bar", "text/html"),
+				newRequest(http.MethodHead, "http://foo.com/bar", "text/html"),
+			},
+			expectedRequests: 2,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			backend := newBlockingHandler("expensive")
+			handler := NewSingleFlight(backend, &types.SingleFlight{Headers: test.headers})
+
+			recorders := serveConcurrently(handler, backend, test.requests)
+			assert.Equal(t, test.expectedRequests, backend.count())
+			for _, recorder := range recorders {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestSingleFlightBypass(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		method        string
+		requestHeader http.Header
+		body          string
+	}{
+		{
+			desc:   "unsafe method",
+			method: http.MethodPost,
+		},
+		{
+			desc:          "no-cache request",
+			method:        http.MethodGet,
+			requestHeader: http.Header{"Cache-Control": {"max-age=0, no-cache"}},
+		},
+		{
+			desc:          "range request",
+			method:        http.MethodGet,
+			requestHeader: http.Header{"Range": {"bytes=0-10"}},
+		},
+		{
+			desc:          "upgrade request",
+			method:        http.MethodGet,
+			requestHeader: http.Header{"Upgrade": {"websocket"}},
+		},
+		{
+			desc:   "request with a body",
+			method: http.MethodGet,
+			body:   "foo",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			backend := newBlockingHandler("expensive")
+			handler := NewSingleFlight(backend, &types.SingleFlight{})
+
+			var requests []*http.Request
+			for i := 0; i < 2; i++ {
+				req := testhelpers.MustNewRequest(test.method, "http://foo.com/bar", strings.NewReader(test.body))
+				if len(test.body) == 0 {
+					req.Body = nil
+					req.ContentLength = 0
+				}
+				for name, values := range test.requestHeader {
+					req.Header[name] = values
+				}
+				requests = append(requests, req)
+			}
+
+			serveConcurrently(handler, backend, requests)
+			assert.Equal(t, 2, backend.count())
+		})
+	}
+}
+
+func TestSingleFlightUnshareableResponse(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		responseHeader http.Header
+		maxBodySize    int64
+	}{
+		{
+			desc:           "private response",
+			responseHeader: http.Header{"Cache-Control": {"private"}},
+		},
+		{
+			desc:           "no-store response",
+			responseHeader: http.Header{"Cache-Control": {"no-store"}},
+		},
+		{
+			desc:           "response with a cookie",
+			responseHeader: http.Header{"Set-Cookie": {"session=1"}},
+		},
+		{
+			desc:        "response too large",
+			maxBodySize: 4,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			backend := newBlockingHandler("expensive")
+			backend.header = test.responseHeader
+
+			var requests []*http.Request
+			for i := 0; i < 4; i++ {
+				requests = append(requests, testhelpers.MustNewRequest(http.MethodGet, "http://foo.com/bar", nil))
+			}
+
+			// the forwarded waiting requests reach the backend only once all of them are forwarded,
+			// which times out if they are sent one after the other
+			var forwarded sync.WaitGroup
+			forwarded.Add(len(requests) - 1)
+			allForwarded := make(chan struct{})
+			go func() {
+				forwarded.Wait()
+				close(allForwarded)
+			}()
+			var calls int32
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if atomic.AddInt32(&calls, 1) > 1 {
+					forwarded.Done()
+					select {
+					case <-allForwarded:
+					case <-time.After(2 * time.Second):
+						rw.WriteHeader(http.StatusGatewayTimeout)
+						return
+					}
+				}
+				backend.ServeHTTP(rw, req)
+			})
+			handler := NewSingleFlight(next, &types.SingleFlight{MaxBodySize: test.maxBodySize})
+
+			recorders := serveConcurrently(handler, backend, requests)
+
+			// the waiting requests are forwarded concurrently once the response is known not to be shareable
+			assert.Equal(t, len(requests), backend.count())
+			assert.Equal(t, "1", recorders[0].Header().Get("X-Request"))
+			for _, recorder := range recorders {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "expensive", recorder.Body.String())
+			}
+			assert.Empty(t, handler.calls)
+		})
+	}
+}
+
+func TestSingleFlightCanceledRequest(t *testing.T) {
+	backend := newBlockingHandler("expensive")
+	handler := NewSingleFlight(backend, &types.SingleFlight{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := testhelpers.MustNewRequest(http.MethodGet, "http://foo.com/bar", nil).WithContext(ctx)
+	second := testhelpers.MustNewRequest(http.MethodGet, "http://foo.com/bar", nil)
+
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		handler.ServeHTTP(httptest.NewRecorder(), first)
+	}()
+	<-backend.received
+
+	recorder := httptest.NewRecorder()
+	secondDone := make(chan struct{})
+	go func() {
+		defer close(secondDone)
+		handler.ServeHTTP(recorder, second)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	close(backend.release)
+	<-firstDone
+	<-secondDone
+
+	// the response of the canceled request is not shared
+	require.Equal(t, 2, backend.count())
+	assert.Equal(t, "2", recorder.Header().Get("X-Request"))
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/vulcand/oxy/utils"
+)
+
+// ClientCertCNExtractor is the name of the source extractor using the common name of the verified client certificate
+const ClientCertCNExtractor = "client.cert.cn"
+
+// NewSourceExtractor creates the extractor of the source of the requests, such as client.ip, request.host or request.header.<name>,
+// adding client.cert.cn to the ones of oxy.
+// The requests without a verified client certificate have an empty common name.
+func NewSourceExtractor(variable string) (utils.SourceExtractor, error) {
+	if variable == ClientCertCNExtractor {
+		return utils.ExtractorFunc(extractClientCertCN), nil
+	}
+	return utils.NewExtractor(variable)
+}
+
+func extractClientCertCN(req *http.Request) (string, int64, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return "", 1, nil
+	}
+	return req.TLS.VerifiedChains[0][0].Subject.CommonName, 1, nil
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSourceExtractor(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		variable       string
+		tlsState       *tls.ConnectionState
+		expectedSource string
+	}{
+		{
+			desc:           "client IP",
+			variable:       "client.ip",
+			expectedSource: "10.0.0.1",
+		},
+		{
+			desc:     "client certificate common name",
+			variable: "client.cert.cn",
+			tlsState: &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "client1"}}}},
+			},
+			expectedSource: "client1",
+		},
+		{
+			desc:     "unverified client certificate",
+			variable: "client.cert.cn",
+			tlsState: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "client1"}}},
+			},
+			expectedSource: "",
+		},
+		{
+			desc:           "no TLS",
+			variable:       "client.cert.cn",
+			expectedSource: "",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			extract, err := NewSourceExtractor(test.variable)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.TLS = test.tlsState
+
+			source, amount, err := extract.Extract(req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSource, source)
+			assert.EqualValues(t, 1, amount)
+		})
+	}
+}
+
+func TestNewSourceExtractorInvalid(t *testing.T) {
+	_, err := NewSourceExtractor("client.cert")
+	assert.Error(t, err)
+}
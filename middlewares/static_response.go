@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"text/template"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// StaticResponse is a handler sending the response configured on a frontend, without any backend
+type StaticResponse struct {
+	statusCode int
+	headers    map[string]string
+	body       *template.Template
+	redirect   *template.Template
+}
+
+// staticResponseData is the data of the templates of a static response, giving access to the request
+type staticResponseData struct {
+	req *http.Request
+}
+
+// Method returns the method of the request
+func (d staticResponseData) Method() string {
+	return d.req.Method
+}
+
+// Host returns the host of the request, without its port
+func (d staticResponseData) Host() string {
+	host, _, err := net.SplitHostPort(d.req.Host)
+	if err != nil {
+		return d.req.Host
+	}
+	return host
+}
+
+// Path returns the path of the request
+func (d staticResponseData) Path() string {
+	return d.req.URL.Path
+}
+
+// RequestURI returns the path and the query of the request
+func (d staticResponseData) RequestURI() string {
+	return d.req.URL.RequestURI()
+}
+
+// Query returns the first value of a query parameter of the request
+func (d staticResponseData) Query(key string) string {
+	return d.req.URL.Query().Get(key)
+}
+
+// Header returns the first value of a header of the request
+func (d staticResponseData) Header(key string) string {
+	return d.req.Header.Get(key)
+}
+
+// RemoteAddr returns the address of the client
+func (d staticResponseData) RemoteAddr() string {
+	return d.req.RemoteAddr
+}
+
+// NewStaticResponse creates the handler sending the static response of a frontend.
+// A redirection uses a 302 by default and its status code must be a redirection one,
+// otherwise a 200 is sent by default.
+func NewStaticResponse(config *types.StaticResponse) (*StaticResponse, error) {
+	if config == nil {
+		return nil, fmt.Errorf("no static response defined")
+	}
+
+	handler := &StaticResponse{
+		statusCode: config.StatusCode,
+		headers:    config.Headers,
+	}
+
+	if len(config.Redirect) > 0 {
+		if len(config.Body) > 0 {
+			return nil, fmt.Errorf("both a body and a redirection are defined")
+		}
+		if handler.statusCode == 0 {
+			handler.statusCode = http.StatusFound
+		}
+		if handler.statusCode < 300 || handler.statusCode > 399 {
+			return nil, fmt.Errorf("invalid redirect status code %d", handler.statusCode)
+		}
+		redirect, err := template.New("redirect").Parse(config.Redirect)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect template: %v", err)
+		}
+		handler.redirect = redirect
+		return handler, nil
+	}
+
+	if handler.statusCode == 0 {
+		handler.statusCode = http.StatusOK
+	}
+	if !isValidStatusCode(handler.statusCode) {
+		return nil, fmt.Errorf("invalid status code %d", handler.statusCode)
+	}
+	body, err := template.New("body").Parse(config.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %v", err)
+	}
+	handler.body = body
+	return handler, nil
+}
+
+func (s *StaticResponse) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tmpl := s.body
+	if s.redirect != nil {
+		tmpl = s.redirect
+	}
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, staticResponseData{req: req}); err != nil {
+		log.Errorf("Error executing the static response template: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	for name, value := range s.headers {
+		rw.Header().Set(name, value)
+	}
+
+	if s.redirect != nil {
+		http.Redirect(rw, req, content.String(), s.statusCode)
+		return
+	}
+
+	if len(rw.Header().Get("Content-Type")) == 0 {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	rw.WriteHeader(s.statusCode)
+	rw.Write(content.Bytes())
+}
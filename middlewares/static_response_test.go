@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticResponse(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		config           *types.StaticResponse
+		expectedStatus   int
+		expectedHeaders  map[string]string
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			desc:            "empty response",
+			config:          &types.StaticResponse{},
+			expectedStatus:  http.StatusOK,
+			expectedHeaders: map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+		},
+		{
+			desc: "status code, headers and body",
+			config: &types.StaticResponse{
+				StatusCode: http.StatusServiceUnavailable,
+				Headers:    map[string]string{"Content-Type": "application/json", "Retry-After": "120"},
+				Body:       `{"status":"maintenance"}`,
+			},
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedHeaders: map[string]string{"Content-Type": "application/json", "Retry-After": "120"},
+			expectedBody:    `{"status":"maintenance"}`,
+		},
+		{
+			desc: "templated body",
+			config: &types.StaticResponse{
+				Body: `{{ .Method }} {{ .Host }}{{ .RequestURI }} {{ .Path }} {{ .Query "id" }} {{ .Header "X-Foo" }}`,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "GET foo.com/bar?id=1 /bar 1 foo",
+		},
+		{
+			desc: "redirection",
+			config: &types.StaticResponse{
+				Redirect: "https://www.{{ .Host }}{{ .RequestURI }}",
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://www.foo.com/bar?id=1",
+		},
+		{
+			desc: "redirection with a status code",
+			config: &types.StaticResponse{
+				StatusCode: http.StatusMovedPermanently,
+				Redirect:   "https://example.com/",
+			},
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com/",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewStaticResponse(test.config)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.com:8080/bar?id=1", nil)
+			req.Header.Set("X-Foo", "foo")
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+			if len(test.expectedLocation) > 0 {
+				assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+			} else {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestStaticResponseInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.StaticResponse
+	}{
+		{
+			desc: "no configuration",
+		},
+		{
+			desc:   "invalid status code",
+			config: &types.StaticResponse{StatusCode: 42},
+		},
+		{
+			desc:   "redirection with a non redirection status code",
+			config: &types.StaticResponse{StatusCode: http.StatusOK, Redirect: "https://example.com/"},
+		},
+		{
+			desc:   "body and redirection",
+			config: &types.StaticResponse{Body: "foo", Redirect: "https://example.com/"},
+		},
+		{
+			desc:   "invalid body template",
+			config: &types.StaticResponse{Body: "{{ .Host "},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewStaticResponse(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -49,14 +49,27 @@ type statsError struct {
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	// headerTime is the time at which the response headers were written, if they were
+	headerTime time.Time
 }
 
 // WriteHeader captures the status code for later retrieval.
 func (r *responseRecorder) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
+	if r.headerTime.IsZero() {
+		r.headerTime = time.Now()
+	}
 	r.statusCode = status
 }
 
+// Write captures the time of the implicit writing of the response headers.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.headerTime.IsZero() {
+		r.headerTime = time.Now()
+	}
+	return r.ResponseWriter.Write(b)
+}
+
 // Hijack hijacks the connection
 func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return r.ResponseWriter.(http.Hijacker).Hijack()
@@ -78,7 +91,7 @@ func (r *responseRecorder) Flush() {
 // is processed. If the response is 4xx or 5xx, add it to the list of 10 most
 // recent errors.
 func (s *StatsRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	recorder := &responseRecorder{w, http.StatusOK}
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
 	next(recorder, r)
 	if recorder.statusCode >= http.StatusBadRequest {
 		s.mutex.Lock()
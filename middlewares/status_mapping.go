@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/forward"
+	"github.com/vulcand/oxy/utils"
+)
+
+// StatusMappingHandler is a middleware replacing the status codes of the responses by other ones,
+// optionally replacing their body by an error page as well.
+type StatusMappingHandler struct {
+	codes              map[int]int
+	errorPageURL       string
+	errorPageForwarder *forward.Forwarder
+}
+
+// NewStatusMappingHandler creates a StatusMappingHandler.
+// When errorPageURL is not empty, the body of the mapped responses is replaced by the page found at errorPageURL followed by the query of the mapping,
+// where {status} is replaced by the new status code.
+func NewStatusMappingHandler(statusMapping *types.StatusMapping, errorPageURL string) (*StatusMappingHandler, error) {
+	if len(statusMapping.Codes) == 0 {
+		return nil, fmt.Errorf("no status code to map")
+	}
+
+	codes := make(map[int]int, len(statusMapping.Codes))
+	for source, target := range statusMapping.Codes {
+		code, err := strconv.Atoi(strings.TrimSpace(source))
+		if err != nil || !isValidStatusCode(code) {
+			return nil, fmt.Errorf("invalid status code %q", source)
+		}
+		if !isValidStatusCode(target) {
+			return nil, fmt.Errorf("invalid status code %d for %d", target, code)
+		}
+		codes[code] = target
+	}
+
+	handler := &StatusMappingHandler{codes: codes}
+	if len(errorPageURL) > 0 {
+		fwd, err := forward.New()
+		if err != nil {
+			return nil, err
+		}
+		handler.errorPageURL = errorPageURL + statusMapping.Query
+		handler.errorPageForwarder = fwd
+	}
+	return handler, nil
+}
+
+func isValidStatusCode(code int) bool {
+	return code >= 100 && code <= 599
+}
+
+func (s *StatusMappingHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	writer := newStatusMappingResponseWriter(rw, s.codes, s.errorPageForwarder != nil)
+
+	next.ServeHTTP(writer, req)
+
+	code := writer.getDiscardedCode()
+	if code == 0 {
+		return
+	}
+
+	errorPageReq, err := http.NewRequest(http.MethodGet, strings.Replace(s.errorPageURL, "{status}", strconv.Itoa(code), -1), nil)
+	if err != nil {
+		rw.WriteHeader(code)
+		rw.Write([]byte(http.StatusText(code)))
+		return
+	}
+	s.errorPageForwarder.ServeHTTP(&statusOverrideResponseWriter{ResponseWriter: rw, code: code}, errorPageReq)
+}
+
+type statusMappingResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	getDiscardedCode() int
+}
+
+func newStatusMappingResponseWriter(rw http.ResponseWriter, codes map[int]int, discardBody bool) statusMappingResponseWriter {
+	writer := &statusMappingResponseWriterWithoutCloseNotify{
+		responseWriter: rw,
+		header:         make(http.Header),
+		codes:          codes,
+		discardBody:    discardBody,
+	}
+	if _, ok := rw.(http.CloseNotifier); ok {
+		return &statusMappingResponseWriterWithCloseNotify{writer}
+	}
+	return writer
+}
+
+// statusMappingResponseWriterWithoutCloseNotify writes the response with the mapped status code,
+// or discards it to be replaced by an error page.
+type statusMappingResponseWriterWithoutCloseNotify struct {
+	responseWriter http.ResponseWriter
+	header         http.Header
+	codes          map[int]int
+	discardBody    bool
+	wroteHeader    bool
+	discardedCode  int
+}
+
+func (rw *statusMappingResponseWriterWithoutCloseNotify) Header() http.Header {
+	return rw.header
+}
+
+func (rw *statusMappingResponseWriterWithoutCloseNotify) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	if target, ok := rw.codes[code]; ok {
+		if rw.discardBody {
+			rw.discardedCode = target
+			return
+		}
+		code = target
+	}
+
+	utils.CopyHeaders(rw.responseWriter.Header(), rw.header)
+	rw.responseWriter.WriteHeader(code)
+}
+
+func (rw *statusMappingResponseWriterWithoutCloseNotify) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.discardedCode != 0 {
+		return len(b), nil
+	}
+	return rw.responseWriter.Write(b)
+}
+
+func (rw *statusMappingResponseWriterWithoutCloseNotify) getDiscardedCode() int {
+	return rw.discardedCode
+}
+
+// Hijack hijacks the connection
+func (rw *statusMappingResponseWriterWithoutCloseNotify) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", rw.responseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+func (rw *statusMappingResponseWriterWithoutCloseNotify) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.discardedCode != 0 {
+		return
+	}
+	if flusher, ok := rw.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type statusMappingResponseWriterWithCloseNotify struct {
+	*statusMappingResponseWriterWithoutCloseNotify
+}
+
+// CloseNotify returns a channel that receives at most a
+// single value (true) when the client connection has gone
+// away.
+func (rw *statusMappingResponseWriterWithCloseNotify) CloseNotify() <-chan bool {
+	return rw.responseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// statusOverrideResponseWriter writes the error page with the mapped status code instead of its own.
+type statusOverrideResponseWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (rw *statusOverrideResponseWriter) WriteHeader(_ int) {
+	rw.ResponseWriter.WriteHeader(rw.code)
+}
@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+func TestStatusMappingHandler(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		statusMapping       *types.StatusMapping
+		errorPage           bool
+		backendCode         int
+		expectedCode        int
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			desc:                "mapped code",
+			statusMapping:       &types.StatusMapping{Codes: map[string]int{"418": 503}},
+			backendCode:         http.StatusTeapot,
+			expectedCode:        http.StatusServiceUnavailable,
+			expectedBody:        "backend body",
+			expectedContentType: "text/plain",
+		},
+		{
+			desc:                "code not mapped",
+			statusMapping:       &types.StatusMapping{Codes: map[string]int{"418": 503}},
+			backendCode:         http.StatusNotFound,
+			expectedCode:        http.StatusNotFound,
+			expectedBody:        "backend body",
+			expectedContentType: "text/plain",
+		},
+		{
+			desc:                "mapped code with an error page",
+			statusMapping:       &types.StatusMapping{Codes: map[string]int{"418": 503}, Query: "/{status}.html"},
+			errorPage:           true,
+			backendCode:         http.StatusTeapot,
+			expectedCode:        http.StatusServiceUnavailable,
+			expectedBody:        "error page /503.html",
+			expectedContentType: "text/html",
+		},
+		{
+			desc:                "code not mapped with an error page",
+			statusMapping:       &types.StatusMapping{Codes: map[string]int{"418": 503}, Query: "/{status}.html"},
+			errorPage:           true,
+			backendCode:         http.StatusOK,
+			expectedCode:        http.StatusOK,
+			expectedBody:        "backend body",
+			expectedContentType: "text/plain",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var errorPageURL string
+			if test.errorPage {
+				errorPageServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					rw.Header().Set("Content-Type", "text/html")
+					fmt.Fprintf(rw, "error page %s", req.URL.Path)
+				}))
+				defer errorPageServer.Close()
+				errorPageURL = errorPageServer.URL
+			}
+
+			statusMappingHandler, err := NewStatusMappingHandler(test.statusMapping, errorPageURL)
+			require.NoError(t, err)
+
+			handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "text/plain")
+				rw.WriteHeader(test.backendCode)
+				fmt.Fprint(rw, "backend body")
+			})
+
+			n := negroni.New()
+			n.Use(statusMappingHandler)
+			n.UseHandler(handler)
+
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://localhost/test", nil))
+
+			assert.Equal(t, test.expectedCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestNewStatusMappingHandlerErrors(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		statusMapping *types.StatusMapping
+	}{
+		{
+			desc:          "no code",
+			statusMapping: &types.StatusMapping{},
+		},
+		{
+			desc:          "invalid source code",
+			statusMapping: &types.StatusMapping{Codes: map[string]int{"teapot": 503}},
+		},
+		{
+			desc:          "out of range source code",
+			statusMapping: &types.StatusMapping{Codes: map[string]int{"999": 503}},
+		},
+		{
+			desc:          "out of range target code",
+			statusMapping: &types.StatusMapping{Codes: map[string]int{"418": 42}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewStatusMappingHandler(test.statusMapping, "")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package datadog
+
+import (
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	opentracing "github.com/opentracing/opentracing-go"
+	ddtrace "gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentracer"
+	datadog "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Name sets the name of this tracer
+const Name = "datadog"
+
+// Config provides configuration settings for a datadog tracer
+type Config struct {
+	LocalAgentHostPort string  `description:"Set datadog-agent's host:port that the reporter will used." export:"false"`
+	GlobalTag          string  `description:"Key:Value tag to be set on all the spans." export:"true"`
+	Debug              bool    `description:"Enable Datadog debug." export:"true"`
+	SampleRate         float64 `description:"The rate between 0.0 and 1.0 of requests to trace." export:"true"`
+	PrioritySampling   bool    `description:"Enable priority sampling, to keep or drop all the parts of a distributed trace together." export:"true"`
+}
+
+// Setup sets up the tracer
+func (c *Config) Setup(serviceName string) (opentracing.Tracer, io.Closer, error) {
+	opts := []datadog.StartOption{
+		datadog.WithAgentAddr(c.LocalAgentHostPort),
+		datadog.WithServiceName(serviceName),
+		datadog.WithDebugMode(c.Debug),
+	}
+	if len(c.GlobalTag) > 0 {
+		tag := strings.SplitN(c.GlobalTag, ":", 2)
+		var value string
+		if len(tag) == 2 {
+			value = tag[1]
+		}
+		opts = append(opts, datadog.WithGlobalTag(tag[0], value))
+	}
+	// with priority sampling, all the traces are sent, and the agent drops the rejected ones
+	if !c.PrioritySampling {
+		opts = append(opts, datadog.WithSampler(datadog.NewRateSampler(c.SampleRate)))
+	}
+
+	ddTracer := opentracer.New(opts...)
+	tracer := &tracer{
+		Tracer:           ddTracer,
+		prioritySampling: c.PrioritySampling,
+		sampleRate:       c.SampleRate,
+	}
+
+	// Without this, child spans are getting the NOOP tracer
+	opentracing.SetGlobalTracer(tracer)
+
+	log.Debug("Datadog tracer configured")
+
+	// the Datadog tracer wrapped by the OpenTracing one is not stopped by datadog.Stop
+	stopper, _ := ddTracer.(interface{ Stop() })
+
+	return tracer, &closer{stopper: stopper}, nil
+}
+
+// tracer sets the sampling priority of the root spans,
+// propagated to the backends with the context of the trace in the Datadog headers.
+type tracer struct {
+	opentracing.Tracer
+	prioritySampling bool
+	sampleRate       float64
+}
+
+func (t *tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	s := &span{Span: t.Tracer.StartSpan(operationName, opts...)}
+
+	if t.prioritySampling && isRootSpan(opts) {
+		priority := ext.PriorityAutoReject
+		if rand.Float64() < t.sampleRate {
+			priority = ext.PriorityAutoKeep
+		}
+		s.SetTag(ext.SamplingPriority, priority)
+	}
+	return s
+}
+
+func isRootSpan(opts []opentracing.StartSpanOption) bool {
+	var sso opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&sso)
+	}
+	for _, ref := range sso.References {
+		if _, ok := ref.ReferencedContext.(ddtrace.SpanContext); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// span sends the status code as a string tag, as expected by Datadog.
+type span struct {
+	opentracing.Span
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	if key == ext.HTTPCode {
+		if code, ok := value.(uint16); ok {
+			value = strconv.Itoa(int(code))
+		}
+	}
+	s.Span.SetTag(key, value)
+	return s
+}
+
+// closer stops the Datadog tracer, sending the pending traces to the agent.
+type closer struct {
+	stopper interface{ Stop() }
+}
+
+func (c *closer) Close() error {
+	datadog.Stop()
+	if c.stopper != nil {
+		c.stopper.Stop()
+	}
+	return nil
+}
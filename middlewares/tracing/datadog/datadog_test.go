@@ -0,0 +1,81 @@
+package datadog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		sampleRate       float64
+		prioritySampling bool
+		expectedPriority string
+	}{
+		{
+			desc:       "all requests sampled",
+			sampleRate: 1.0,
+		},
+		{
+			desc:       "no request sampled",
+			sampleRate: 0,
+		},
+		{
+			desc:             "priority sampling keeping all the requests",
+			sampleRate:       1.0,
+			prioritySampling: true,
+			expectedPriority: "1",
+		},
+		{
+			desc:             "priority sampling dropping all the requests",
+			sampleRate:       0,
+			prioritySampling: true,
+			expectedPriority: "0",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			agentServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer agentServer.Close()
+
+			config := &Config{
+				LocalAgentHostPort: strings.TrimPrefix(agentServer.URL, "http://"),
+				SampleRate:         test.sampleRate,
+				PrioritySampling:   test.prioritySampling,
+			}
+
+			tracer, closer, err := config.Setup("traefik")
+			require.NoError(t, err)
+
+			span := tracer.StartSpan("test")
+			ext.HTTPStatusCode.Set(span, 200)
+
+			child := tracer.StartSpan("child", opentracing.ChildOf(span.Context()))
+			header := http.Header{}
+			err = tracer.Inject(child.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+			require.NoError(t, err)
+			child.Finish()
+			span.Finish()
+
+			assert.NotEmpty(t, header.Get("X-Datadog-Trace-Id"))
+			assert.NotEmpty(t, header.Get("X-Datadog-Parent-Id"))
+			assert.Equal(t, test.expectedPriority, header.Get("X-Datadog-Sampling-Priority"))
+
+			extracted, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+			require.NoError(t, err)
+			assert.NotNil(t, extracted)
+
+			require.NoError(t, closer.Close())
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 
 	"github.com/containous/traefik/log"
-	"github.com/opentracing/opentracing-go/ext"
 	"github.com/urfave/negroni"
 )
 
@@ -32,9 +31,7 @@ func (f *forwarderMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	defer finish()
 	span.SetTag("frontend.name", f.frontend)
 	span.SetTag("backend.name", f.backend)
-	ext.HTTPMethod.Set(span, r.Method)
-	ext.HTTPUrl.Set(span, r.URL.String())
-	span.SetTag("http.host", r.Host)
+	LogRequest(span, r)
 
 	InjectRequestHeaders(r)
 
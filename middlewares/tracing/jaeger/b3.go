@@ -0,0 +1,86 @@
+package jaeger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	jaegercli "github.com/uber/jaeger-client-go"
+)
+
+const (
+	b3TraceIDHeader      = "X-B3-TraceId"
+	b3SpanIDHeader       = "X-B3-SpanId"
+	b3ParentSpanIDHeader = "X-B3-ParentSpanId"
+	b3SampledHeader      = "X-B3-Sampled"
+	b3FlagsHeader        = "X-B3-Flags"
+)
+
+// b3Propagator injects and extracts the span contexts with the X-B3 headers of Zipkin.
+// The baggage items are not propagated.
+type b3Propagator struct{}
+
+func (p *b3Propagator) Inject(sc jaegercli.SpanContext, abstractCarrier interface{}) error {
+	carrier, ok := abstractCarrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	traceID := sc.TraceID()
+	if traceID.High != 0 {
+		carrier.Set(b3TraceIDHeader, fmt.Sprintf("%016x%016x", traceID.High, traceID.Low))
+	} else {
+		carrier.Set(b3TraceIDHeader, fmt.Sprintf("%016x", traceID.Low))
+	}
+	carrier.Set(b3SpanIDHeader, fmt.Sprintf("%016x", uint64(sc.SpanID())))
+	if sc.ParentID() != 0 {
+		carrier.Set(b3ParentSpanIDHeader, fmt.Sprintf("%016x", uint64(sc.ParentID())))
+	}
+	if sc.IsDebug() {
+		carrier.Set(b3FlagsHeader, "1")
+	} else if sc.IsSampled() {
+		carrier.Set(b3SampledHeader, "1")
+	} else {
+		carrier.Set(b3SampledHeader, "0")
+	}
+	return nil
+}
+
+func (p *b3Propagator) Extract(abstractCarrier interface{}) (jaegercli.SpanContext, error) {
+	carrier, ok := abstractCarrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaegercli.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	var traceID jaegercli.TraceID
+	var spanID, parentID jaegercli.SpanID
+	var sampled bool
+	err := carrier.ForeachKey(func(key, value string) error {
+		var err error
+		switch strings.ToLower(key) {
+		case strings.ToLower(b3TraceIDHeader):
+			traceID, err = jaegercli.TraceIDFromString(value)
+		case strings.ToLower(b3SpanIDHeader):
+			spanID, err = jaegercli.SpanIDFromString(value)
+		case strings.ToLower(b3ParentSpanIDHeader):
+			parentID, err = jaegercli.SpanIDFromString(value)
+		case strings.ToLower(b3SampledHeader):
+			sampled = value == "1" || value == "true"
+		case strings.ToLower(b3FlagsHeader):
+			// the debug flag implies the sampling
+			sampled = sampled || value == "1"
+		}
+		if err != nil {
+			return opentracing.ErrSpanContextCorrupted
+		}
+		return nil
+	})
+	if err != nil {
+		return jaegercli.SpanContext{}, err
+	}
+
+	if !traceID.IsValid() || spanID == 0 {
+		return jaegercli.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+	return jaegercli.NewSpanContext(traceID, spanID, parentID, sampled, nil), nil
+}
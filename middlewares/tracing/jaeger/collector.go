@@ -0,0 +1,83 @@
+package jaeger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	jaegercli "github.com/uber/jaeger-client-go"
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+const (
+	// collectorBatchSize is the number of spans after which the buffered spans are sent to the collector
+	collectorBatchSize = 100
+	collectorTimeout   = 5 * time.Second
+)
+
+// collectorTransport sends the spans in Thrift batches to the HTTP endpoint of a jaeger-collector.
+// As a jaeger Transport, it is only used by the goroutine of its reporter.
+type collectorTransport struct {
+	endpoint string
+	client   *http.Client
+	process  *j.Process
+	spans    []*j.Span
+}
+
+func newCollectorTransport(endpoint string) *collectorTransport {
+	return &collectorTransport{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: collectorTimeout},
+	}
+}
+
+func (t *collectorTransport) Append(span *jaegercli.Span) (int, error) {
+	if t.process == nil {
+		t.process = jaegercli.BuildJaegerProcessThrift(span)
+	}
+	t.spans = append(t.spans, jaegercli.BuildJaegerThrift(span))
+	if len(t.spans) >= collectorBatchSize {
+		return t.Flush()
+	}
+	return 0, nil
+}
+
+func (t *collectorTransport) Flush() (int, error) {
+	count := len(t.spans)
+	if count == 0 {
+		return 0, nil
+	}
+	batch := &j.Batch{Process: t.process, Spans: t.spans}
+	t.spans = nil
+
+	body, err := thrift.NewTSerializer().Write(batch)
+	if err != nil {
+		return count, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return count, err
+	}
+	req.Header.Set("Content-Type", "application/x-thrift")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return count, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return count, fmt.Errorf("jaeger collector %s answered with status %d", t.endpoint, resp.StatusCode)
+	}
+	return count, nil
+}
+
+func (t *collectorTransport) Close() error {
+	return nil
+}
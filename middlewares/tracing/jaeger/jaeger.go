@@ -1,10 +1,13 @@
 package jaeger
 
 import (
+	"fmt"
 	"io"
+	"net/url"
 
 	"github.com/containous/traefik/log"
 	"github.com/opentracing/opentracing-go"
+	jaegercli "github.com/uber/jaeger-client-go"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	jaegerlog "github.com/uber/jaeger-client-go/log"
 	jaegermet "github.com/uber/jaeger-lib/metrics"
@@ -13,42 +16,98 @@ import (
 // Name sets the name of this tracer
 const Name = "jaeger"
 
+const (
+	// JaegerPropagation propagates the spans with the uber-trace-id header of Jaeger
+	JaegerPropagation = "jaeger"
+	// B3Propagation propagates the spans with the X-B3 headers of Zipkin
+	B3Propagation = "b3"
+)
+
 // Config provides configuration settings for a jaeger tracer
 type Config struct {
 	SamplingServerURL  string  `description:"set the sampling server url." export:"false"`
 	SamplingType       string  `description:"set the sampling type." export:"true"`
 	SamplingParam      float64 `description:"set the sampling parameter." export:"true"`
 	LocalAgentHostPort string  `description:"set jaeger-agent's host:port that the reporter will used." export:"false"`
+	CollectorEndpoint  string  `description:"set jaeger-collector's HTTP endpoint that the reporter will use instead of the agent." export:"false"`
+	Propagation        string  `description:"set the propagation format ('jaeger','b3')." export:"true"`
 }
 
 // Setup sets up the tracer
 func (c *Config) Setup(componentName string) (opentracing.Tracer, io.Closer, error) {
-	jcfg := jaegercfg.Configuration{
-		Sampler: &jaegercfg.SamplerConfig{
-			SamplingServerURL: c.SamplingServerURL,
-			Type:              c.SamplingType,
-			Param:             c.SamplingParam,
-		},
-		Reporter: &jaegercfg.ReporterConfig{
-			LogSpans:           true,
-			LocalAgentHostPort: c.LocalAgentHostPort,
-		},
+	var propagator *b3Propagator
+	switch c.Propagation {
+	case "", JaegerPropagation:
+	case B3Propagation:
+		propagator = &b3Propagator{}
+	default:
+		return nil, nil, fmt.Errorf("unknown jaeger propagation %q", c.Propagation)
 	}
 
 	jLogger := jaegerlog.StdLogger
-	jMetricsFactory := jaegermet.NullFactory
-
-	// Initialize tracer with a logger and a metrics factory
-	closer, err := jcfg.InitGlobalTracer(
-		componentName,
-		jaegercfg.Logger(jLogger),
-		jaegercfg.Metrics(jMetricsFactory),
-	)
+	jMetrics := jaegercli.NewMetrics(jaegermet.NullFactory, nil)
+
+	samplerConfig := &jaegercfg.SamplerConfig{
+		SamplingServerURL: c.SamplingServerURL,
+		Type:              c.SamplingType,
+		Param:             c.SamplingParam,
+	}
+	sampler, err := samplerConfig.NewSampler(componentName, jMetrics)
 	if err != nil {
-		log.Warnf("Could not initialize jaeger tracer: %s", err.Error())
+		log.Warnf("Could not initialize jaeger sampler: %s", err.Error())
 		return nil, nil, err
 	}
-	log.Debugf("jaeger tracer configured", err)
 
-	return opentracing.GlobalTracer(), closer, nil
+	reporter, err := c.newReporter(componentName, jMetrics, jLogger)
+	if err != nil {
+		sampler.Close()
+		log.Warnf("Could not initialize jaeger reporter: %s", err.Error())
+		return nil, nil, err
+	}
+
+	options := []jaegercli.TracerOption{
+		jaegercli.TracerOptions.Metrics(jMetrics),
+		jaegercli.TracerOptions.Logger(jLogger),
+	}
+	if propagator != nil {
+		options = append(options,
+			jaegercli.TracerOptions.Injector(opentracing.HTTPHeaders, propagator),
+			jaegercli.TracerOptions.Extractor(opentracing.HTTPHeaders, propagator),
+			jaegercli.TracerOptions.Injector(opentracing.TextMap, propagator),
+			jaegercli.TracerOptions.Extractor(opentracing.TextMap, propagator),
+		)
+	}
+
+	// The closer flushes the spans buffered by the reporter
+	tracer, closer := jaegercli.NewTracer(componentName, sampler, reporter, options...)
+	opentracing.InitGlobalTracer(tracer)
+	log.Debug("jaeger tracer configured")
+
+	return tracer, closer, nil
+}
+
+// newReporter creates a reporter sending the spans to the collector if its endpoint is set, to the agent otherwise,
+// and logging them.
+func (c *Config) newReporter(serviceName string, metrics *jaegercli.Metrics, logger jaegercli.Logger) (jaegercli.Reporter, error) {
+	if len(c.CollectorEndpoint) == 0 {
+		reporterConfig := &jaegercfg.ReporterConfig{
+			LogSpans:           true,
+			LocalAgentHostPort: c.LocalAgentHostPort,
+		}
+		return reporterConfig.NewReporter(serviceName, metrics, logger)
+	}
+
+	endpoint, err := url.Parse(c.CollectorEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jaeger collector endpoint %q: %v", c.CollectorEndpoint, err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid jaeger collector endpoint %q: the scheme must be http or https", c.CollectorEndpoint)
+	}
+
+	reporter := jaegercli.NewRemoteReporter(
+		newCollectorTransport(c.CollectorEndpoint),
+		jaegercli.ReporterOptions.Logger(logger),
+		jaegercli.ReporterOptions.Metrics(metrics))
+	return jaegercli.NewCompositeReporter(jaegercli.NewLoggingReporter(logger), reporter), nil
 }
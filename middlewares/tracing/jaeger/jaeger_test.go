@@ -0,0 +1,154 @@
+package jaeger
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func TestSetupCollector(t *testing.T) {
+	batches := make(chan *j.Batch, 1)
+	collectorServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil || req.Header.Get("Content-Type") != "application/x-thrift" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batch := j.NewBatch()
+		if err := thrift.NewTDeserializer().Read(batch, body); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches <- batch
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer collectorServer.Close()
+
+	config := &Config{
+		SamplingType:      "const",
+		SamplingParam:     1,
+		CollectorEndpoint: collectorServer.URL + "/api/traces",
+	}
+
+	tracer, closer, err := config.Setup("traefik")
+	require.NoError(t, err)
+
+	span := tracer.StartSpan("test")
+	span.SetTag("frontend.name", "frontend1")
+	span.Finish()
+
+	// closing the tracer flushes the buffered spans
+	require.NoError(t, closer.Close())
+
+	batch := <-batches
+	assert.Equal(t, "traefik", batch.Process.ServiceName)
+	require.Len(t, batch.Spans, 1)
+	assert.Equal(t, "test", batch.Spans[0].OperationName)
+}
+
+func TestSetupErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *Config
+	}{
+		{
+			desc:   "unknown sampling type",
+			config: &Config{SamplingType: "foo"},
+		},
+		{
+			desc:   "invalid probabilistic sampling parameter",
+			config: &Config{SamplingType: "probabilistic", SamplingParam: 2},
+		},
+		{
+			desc:   "unknown propagation",
+			config: &Config{SamplingType: "const", Propagation: "foo"},
+		},
+		{
+			desc:   "invalid collector endpoint",
+			config: &Config{SamplingType: "const", CollectorEndpoint: "localhost:14268"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			_, _, err := test.config.Setup("traefik")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestB3Propagation(t *testing.T) {
+	collectorServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer collectorServer.Close()
+
+	config := &Config{
+		SamplingType:      "const",
+		SamplingParam:     1,
+		CollectorEndpoint: collectorServer.URL,
+		Propagation:       B3Propagation,
+	}
+	tracer, closer, err := config.Setup("traefik")
+	require.NoError(t, err)
+	defer closer.Close()
+
+	header := http.Header{}
+	header.Set("X-B3-TraceId", "463ac35c9f6413ad48485a3953bb6124")
+	header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	header.Set("X-B3-Sampled", "1")
+
+	spanContext, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	require.NoError(t, err)
+
+	span := tracer.StartSpan("test", opentracing.ChildOf(spanContext))
+	defer span.Finish()
+
+	injected := http.Header{}
+	err = tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(injected))
+	require.NoError(t, err)
+
+	assert.Equal(t, "463ac35c9f6413ad48485a3953bb6124", injected.Get("X-B3-TraceId"))
+	assert.Equal(t, "a2fb4a1d1a96d312", injected.Get("X-B3-ParentSpanId"))
+	assert.Len(t, injected.Get("X-B3-SpanId"), 16)
+	assert.NotEqual(t, "a2fb4a1d1a96d312", injected.Get("X-B3-SpanId"))
+	assert.Equal(t, "1", injected.Get("X-B3-Sampled"))
+	assert.Empty(t, injected.Get("Uber-Trace-Id"))
+}
+
+func TestB3PropagationExtractErrors(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		header        http.Header
+		expectedError error
+	}{
+		{
+			desc:          "no headers",
+			header:        http.Header{},
+			expectedError: opentracing.ErrSpanContextNotFound,
+		},
+		{
+			desc:          "invalid trace id",
+			header:        http.Header{"X-B3-Traceid": {"foo"}, "X-B3-Spanid": {"a2fb4a1d1a96d312"}},
+			expectedError: opentracing.ErrSpanContextCorrupted,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := (&b3Propagator{}).Extract(opentracing.HTTPHeadersCarrier(test.header))
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// RetryListener is an implementation of the RetryListener of the retry middleware
+// that tags the span of the retried request with its number of retry attempts.
+type RetryListener struct{}
+
+// Retried implements the RetryListener interface and will be called for each retry that happens.
+func (l *RetryListener) Retried(req *http.Request, attempt int) {
+	// it is the request attempt x, but the retry attempt is x-1
+	if attempt > 0 {
+		attempt--
+	}
+
+	if span := GetSpan(req); span != nil {
+		span.SetTag("retry.attempts", attempt)
+		LogEventf(req, "Retry attempt %d", attempt)
+	}
+}
@@ -6,18 +6,22 @@ import (
 	"net/http"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/requestid"
+	"github.com/containous/traefik/middlewares/tracing/datadog"
 	"github.com/containous/traefik/middlewares/tracing/jaeger"
 	"github.com/containous/traefik/middlewares/tracing/zipkin"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 )
 
 // Tracing middleware
 type Tracing struct {
-	Backend     string         `description:"Selects the tracking backend ('jaeger','zipkin')." export:"true"`
-	ServiceName string         `description:"Set the name for this service" export:"true"`
-	Jaeger      *jaeger.Config `description:"Settings for jaeger"`
-	Zipkin      *zipkin.Config `description:"Settings for zipkin"`
+	Backend     string          `description:"Selects the tracking backend ('jaeger','zipkin','datadog')." export:"true"`
+	ServiceName string          `description:"Set the name for this service" export:"true"`
+	Jaeger      *jaeger.Config  `description:"Settings for jaeger"`
+	Zipkin      *zipkin.Config  `description:"Settings for zipkin"`
+	Datadog     *datadog.Config `description:"Settings for datadog"`
 
 	opentracing.Tracer
 	closer io.Closer
@@ -37,6 +41,8 @@ func (t *Tracing) Setup() {
 		t.Tracer, t.closer, err = t.Jaeger.Setup(t.ServiceName)
 	case zipkin.Name:
 		t.Tracer, t.closer, err = t.Zipkin.Setup(t.ServiceName)
+	case datadog.Name:
+		t.Tracer, t.closer, err = t.Datadog.Setup(t.ServiceName)
 	default:
 		log.Warnf("Unknown tracer %q", t.Backend)
 		return
@@ -60,7 +66,7 @@ func (t *Tracing) IsEnabled() bool {
 
 // Close tracer
 func (t *Tracing) Close() {
-	if t.closer != nil {
+	if t != nil && t.closer != nil {
 		t.closer.Close()
 	}
 }
@@ -71,6 +77,16 @@ func LogRequest(span opentracing.Span, r *http.Request) {
 		ext.HTTPMethod.Set(span, r.Method)
 		ext.HTTPUrl.Set(span, r.URL.String())
 		span.SetTag("http.host", r.Host)
+		if tlsInfo := traefikTls.NewConnectionInfo(r.TLS); tlsInfo != nil {
+			if len(tlsInfo.ServerName) > 0 {
+				span.SetTag("tls.sni", tlsInfo.ServerName)
+			}
+			span.SetTag("tls.version", tlsInfo.Version)
+			span.SetTag("tls.cipher", tlsInfo.CipherSuite)
+		}
+		if id := requestid.Get(r); len(id) > 0 {
+			span.SetTag("request.id", id)
+		}
 	}
 }
 
@@ -0,0 +1,72 @@
+package zipkin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		sampleRate      float64
+		expectedSampled string
+		expectedFlush   bool
+	}{
+		{
+			desc:            "all requests sampled",
+			sampleRate:      1.0,
+			expectedSampled: "true",
+			expectedFlush:   true,
+		},
+		{
+			desc:            "no request sampled",
+			sampleRate:      0,
+			expectedSampled: "false",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			var flushed bool
+			collectorServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				flushed = true
+				rw.WriteHeader(http.StatusAccepted)
+			}))
+			defer collectorServer.Close()
+
+			config := &Config{
+				HTTPEndpoint:  collectorServer.URL,
+				SampleRate:    test.sampleRate,
+				BatchInterval: flaeg.Duration(time.Minute),
+			}
+
+			tracer, closer, err := config.Setup("traefik")
+			require.NoError(t, err)
+
+			span := tracer.StartSpan("test")
+			header := http.Header{}
+			err = tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+			require.NoError(t, err)
+			span.Finish()
+
+			assert.NotEmpty(t, header.Get("X-B3-TraceId"))
+			assert.NotEmpty(t, header.Get("X-B3-SpanId"))
+			assert.Equal(t, test.expectedSampled, header.Get("X-B3-Sampled"))
+
+			extracted, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+			require.NoError(t, err)
+			assert.NotNil(t, extracted)
+
+			// spans are sent on close even if the batch interval is not elapsed
+			require.NoError(t, closer.Close())
+			assert.Equal(t, test.expectedFlush, flushed)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+)
+
+// WebSocket is a middleware handling the WebSocket connections upgraded by the forwarder of a backend.
+// The upgraded connections get their own timeouts instead of the timeouts of the entrypoint,
+// the cookies set for the upgrade request (such as the sticky session one) are sent with the handshake response,
+// and the open connections are counted for the backend.
+type WebSocket struct {
+	handler      http.Handler
+	backendName  string
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+	conns        *WebSocketConns
+}
+
+// NewWebSocket creates a WebSocket middleware in front of the forwarder of the backend.
+// A zero writeTimeout or idleTimeout disables the corresponding timeout.
+func NewWebSocket(handler http.Handler, backendName string, writeTimeout, idleTimeout time.Duration, conns *WebSocketConns) *WebSocket {
+	return &WebSocket{
+		handler:      handler,
+		backendName:  backendName,
+		writeTimeout: writeTimeout,
+		idleTimeout:  idleTimeout,
+		conns:        conns,
+	}
+}
+
+func (w *WebSocket) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if isWebsocketRequest(req) {
+		removeConnectionHeaders(req)
+	}
+
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok || !isWebsocketRequest(req) {
+		w.handler.ServeHTTP(rw, req)
+		return
+	}
+
+	w.handler.ServeHTTP(&webSocketResponseWriter{ResponseWriter: rw, hijacker: hijacker, webSocket: w}, req)
+}
+
+// removeConnectionHeaders removes the hop-by-hop headers listed in the Connection header of an upgrade request,
+// which are not removed by the forwarder. The Upgrade header and the headers of the handshake,
+// such as the requested subprotocols, are kept.
+func removeConnectionHeaders(req *http.Request) {
+	for _, value := range req.Header["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if len(name) == 0 || name == "Upgrade" || name == "Connection" || strings.HasPrefix(name, "Sec-Websocket-") {
+				continue
+			}
+			req.Header.Del(name)
+		}
+	}
+}
+
+// WebSocketConns counts the open WebSocket connections of the backends, and reports them with a gauge.
+// It is shared by the configurations, the connections being kept open across the reloads.
+type WebSocketConns struct {
+	gauge gokitmetrics.Gauge
+	lock  sync.Mutex
+	conns map[string]int
+}
+
+// NewWebSocketConns creates a WebSocketConns reporting the open connections of each backend with gauge.
+func NewWebSocketConns(gauge gokitmetrics.Gauge) *WebSocketConns {
+	return &WebSocketConns{
+		gauge: gauge,
+		conns: make(map[string]int),
+	}
+}
+
+// Get returns the number of open WebSocket connections of the backend.
+func (c *WebSocketConns) Get(backendName string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.conns[backendName]
+}
+
+func (c *WebSocketConns) add(backendName string, delta int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	conns := c.conns[backendName] + delta
+	if conns > 0 {
+		c.conns[backendName] = conns
+	} else {
+		delete(c.conns, backendName)
+	}
+	c.gauge.With("backend", backendName).Set(float64(conns))
+}
+
+type webSocketResponseWriter struct {
+	http.ResponseWriter
+	hijacker  http.Hijacker
+	webSocket *WebSocket
+}
+
+func (rw *webSocketResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := rw.hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ws := rw.webSocket
+	wsConn := &webSocketConn{
+		Conn:         conn,
+		writeTimeout: ws.writeTimeout,
+		idleTimeout:  ws.idleTimeout,
+		cookies:      rw.Header()["Set-Cookie"],
+	}
+	if ws.conns != nil {
+		ws.conns.add(ws.backendName, 1)
+		wsConn.onClose = func() { ws.conns.add(ws.backendName, -1) }
+	}
+	return wsConn, brw, nil
+}
+
+// webSocketConn is an upgraded client connection, with a deadline for each write,
+// and a deadline for reads postponed by the activity in both directions.
+type webSocketConn struct {
+	net.Conn
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+
+	// cookies are added to the handshake response, being the first write
+	cookies   []string
+	handshake bool
+
+	closeOnce sync.Once
+	onClose   func()
+}
+
+func (c *webSocketConn) Read(b []byte) (int, error) {
+	if c.idleTimeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *webSocketConn) Write(b []byte) (int, error) {
+	if !c.handshake {
+		c.handshake = true
+		if len(c.cookies) > 0 {
+			return c.writeHandshake(b)
+		}
+	}
+
+	if c.writeTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	} else {
+		// the deadline possibly set by the upgrader is cleared
+		c.Conn.SetWriteDeadline(time.Time{})
+	}
+
+	n, err := c.Conn.Write(b)
+	if err == nil && c.idleTimeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+	}
+	return n, err
+}
+
+// writeHandshake writes the handshake response b with the cookies, when it switches the protocol.
+func (c *webSocketConn) writeHandshake(b []byte) (int, error) {
+	end := bytes.Index(b, []byte("\r\n\r\n"))
+	if end < 0 || !bytes.HasPrefix(b, []byte("HTTP/1.1 101 ")) {
+		return c.Write(b)
+	}
+
+	response := make([]byte, 0, len(b)+64*len(c.cookies))
+	response = append(response, b[:end+2]...)
+	for _, cookie := range c.cookies {
+		response = append(response, "Set-Cookie: "...)
+		response = append(response, cookie...)
+		response = append(response, "\r\n"...)
+	}
+	response = append(response, b[end+2:]...)
+
+	if _, err := c.Write(response); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *webSocketConn) Close() error {
+	if c.onClose != nil {
+		c.closeOnce.Do(c.onClose)
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,234 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/forward"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+// gaugeMock records the last value set, with its labels.
+type gaugeMock struct {
+	lock        sync.Mutex
+	value       float64
+	labelValues []string
+}
+
+func (g *gaugeMock) With(labelValues ...string) gokitmetrics.Gauge {
+	return &labeledGaugeMock{gauge: g, labelValues: labelValues}
+}
+
+func (g *gaugeMock) Set(value float64) {
+	g.With().Set(value)
+}
+
+func (g *gaugeMock) get() (float64, []string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.value, g.labelValues
+}
+
+type labeledGaugeMock struct {
+	gauge       *gaugeMock
+	labelValues []string
+}
+
+func (g *labeledGaugeMock) With(labelValues ...string) gokitmetrics.Gauge {
+	return g.gauge.With(append(g.labelValues, labelValues...)...)
+}
+
+func (g *labeledGaugeMock) Set(value float64) {
+	g.gauge.lock.Lock()
+	defer g.gauge.lock.Unlock()
+	g.gauge.value = value
+	g.gauge.labelValues = g.labelValues
+}
+
+func newEchoWebSocketServer() *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestWebSocketStickySession(t *testing.T) {
+	backend := newEchoWebSocketServer()
+	defer backend.Close()
+
+	fwd, err := forward.New()
+	require.NoError(t, err)
+
+	gauge := &gaugeMock{}
+	conns := NewWebSocketConns(gauge)
+
+	lb, err := roundrobin.New(NewWebSocket(fwd, "backend1", 0, 0, conns), roundrobin.EnableStickySession(roundrobin.NewStickySession("sticky")))
+	require.NoError(t, err)
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	require.NoError(t, lb.UpsertServer(backendURL))
+
+	frontend := httptest.NewServer(lb)
+	defer frontend.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(frontend.URL, "http"), nil)
+	require.NoError(t, err)
+
+	// the sticky session cookie is set by the handshake response
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "sticky", cookies[0].Name)
+	assert.Equal(t, backend.URL, cookies[0].Value)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(msg))
+
+	assert.Equal(t, 1, conns.Get("backend1"))
+	value, labelValues := gauge.get()
+	assert.Equal(t, float64(1), value)
+	assert.Equal(t, []string{"backend", "backend1"}, labelValues)
+
+	conn.Close()
+	// the connection is closed by the forwarder once the client has gone
+	for i := 0; i < 100 && conns.Get("backend1") > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, conns.Get("backend1"))
+	value, _ = gauge.get()
+	assert.Equal(t, float64(0), value)
+}
+
+func TestWebSocketIdleTimeout(t *testing.T) {
+	backend := newEchoWebSocketServer()
+	defer backend.Close()
+
+	fwd, err := forward.New()
+	require.NoError(t, err)
+
+	conns := NewWebSocketConns(&gaugeMock{})
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		req.URL.Scheme = "http"
+		req.URL.Host = strings.TrimPrefix(backend.URL, "http://")
+		fwd.ServeHTTP(rw, req)
+	})
+
+	frontend := httptest.NewUnstartedServer(NewWebSocket(handler, "backend1", time.Second, 200*time.Millisecond, conns))
+	// the timeouts of the server don't apply to the upgraded connections
+	frontend.Config.ReadTimeout = 100 * time.Millisecond
+	frontend.Config.WriteTimeout = 100 * time.Millisecond
+	frontend.Start()
+	defer frontend.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(frontend.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the activity postpones the idle timeout
+	for i := 0; i < 5; i++ {
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(msg))
+	}
+
+	// the idle connection is closed
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	require.Error(t, err)
+	netErr, ok := err.(interface{ Timeout() bool })
+	assert.False(t, ok && netErr.Timeout(), "the connection was not closed by the idle timeout")
+}
+
+func TestWebSocketNotUpgraded(t *testing.T) {
+	conns := NewWebSocketConns(&gaugeMock{})
+	handler := NewWebSocket(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, ok := rw.(*webSocketResponseWriter)
+		assert.False(t, ok)
+		rw.Write([]byte("OK"))
+	}), "backend1", 0, 0, conns)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil))
+
+	assert.Equal(t, "OK", recorder.Body.String())
+	assert.Equal(t, 0, conns.Get("backend1"))
+}
+
+func TestWebSocketSubprotocol(t *testing.T) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{"chat"}}
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer backend.Close()
+
+	fwd, err := forward.New()
+	require.NoError(t, err)
+
+	lb, err := roundrobin.New(NewWebSocket(fwd, "backend1", 0, 0, nil))
+	require.NoError(t, err)
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	require.NoError(t, lb.UpsertServer(backendURL))
+
+	frontend := httptest.NewServer(lb)
+	defer frontend.Close()
+
+	dialer := &websocket.Dialer{Subprotocols: []string{"superchat", "chat"}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(frontend.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the subprotocol chosen by the backend is sent back to the client
+	assert.Equal(t, "chat", resp.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Equal(t, "chat", conn.Subprotocol())
+}
+
+func TestWebSocketConnectionHeaders(t *testing.T) {
+	handler := NewWebSocket(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get("X-Hop"))
+		assert.Empty(t, req.Header.Get("Keep-Alive"))
+		assert.Equal(t, "websocket", req.Header.Get("Upgrade"))
+		assert.Equal(t, "chat", req.Header.Get("Sec-WebSocket-Protocol"))
+		assert.Equal(t, "end-to-end", req.Header.Get("X-End"))
+	}), "backend1", 0, 0, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://foo.example.com", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade, X-Hop, Sec-WebSocket-Protocol")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("X-Hop", "hop-by-hop")
+	req.Header.Set("X-End", "end-to-end")
+	req.Header.Set("Sec-WebSocket-Protocol", "chat")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
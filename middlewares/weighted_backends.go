@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/log"
+)
+
+// WeightedBackends distributes the requests of a frontend across several backends, according to their weights,
+// with a smooth weighted round robin. The backends without any available server are skipped while the others have one.
+type WeightedBackends struct {
+	lock     sync.Mutex
+	backends []*weightedBackend
+}
+
+type weightedBackend struct {
+	name    string
+	weight  int
+	handler http.Handler
+	lb      healthcheck.LoadBalancer
+	// current is the weight accumulated by the backend since it was last selected
+	current int
+}
+
+// NewWeightedBackends creates an empty WeightedBackends
+func NewWeightedBackends() *WeightedBackends {
+	return &WeightedBackends{}
+}
+
+// AddBackend adds a backend receiving the requests with handler, in proportion to its weight.
+// The backend is considered available while lb has servers, lb being optional.
+func (w *WeightedBackends) AddBackend(name string, weight int, handler http.Handler, lb healthcheck.LoadBalancer) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.backends = append(w.backends, &weightedBackend{name: name, weight: weight, handler: handler, lb: lb})
+}
+
+func (w *WeightedBackends) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	backend := w.next()
+	if backend == nil {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		rw.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+		return
+	}
+	log.Debugf("Forwarding the request to the weighted backend %s", backend.name)
+	backend.handler.ServeHTTP(rw, req)
+}
+
+// next selects the backend of the next request, among the available ones if any
+func (w *WeightedBackends) next() *weightedBackend {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	candidates := make([]*weightedBackend, 0, len(w.backends))
+	for _, backend := range w.backends {
+		if backend.weight > 0 && backend.isAvailable() {
+			candidates = append(candidates, backend)
+		}
+	}
+	if len(candidates) == 0 {
+		// the selected backend responds that it has no server
+		for _, backend := range w.backends {
+			if backend.weight > 0 {
+				candidates = append(candidates, backend)
+			}
+		}
+	}
+
+	var selected *weightedBackend
+	total := 0
+	for _, backend := range candidates {
+		backend.current += backend.weight
+		total += backend.weight
+		if selected == nil || backend.current > selected.current {
+			selected = backend
+		}
+	}
+	if selected != nil {
+		selected.current -= total
+	}
+	return selected
+}
+
+func (b *weightedBackend) isAvailable() bool {
+	return b.lb == nil || len(b.lb.Servers()) > 0
+}
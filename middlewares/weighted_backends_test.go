@@ -0,0 +1,108 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+)
+
+func TestWeightedBackends(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		weights          map[string]int
+		unavailable      map[string]bool
+		expectedStatus   int
+		expectedRequests map[string]int
+	}{
+		{
+			desc:             "weighted distribution",
+			weights:          map[string]int{"blue": 3, "green": 1},
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"blue": 6, "green": 2},
+		},
+		{
+			desc:             "backend with a null weight",
+			weights:          map[string]int{"blue": 0, "green": 1},
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"green": 8},
+		},
+		{
+			desc:             "backend without available server",
+			weights:          map[string]int{"blue": 3, "green": 1},
+			unavailable:      map[string]bool{"blue": true},
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"green": 8},
+		},
+		{
+			desc:             "no backend with an available server",
+			weights:          map[string]int{"blue": 3, "green": 1},
+			unavailable:      map[string]bool{"blue": true, "green": true},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: map[string]int{"blue": 6, "green": 2},
+		},
+		{
+			desc:             "no backend with a weight",
+			weights:          map[string]int{"blue": 0},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: map[string]int{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			requests := make(map[string]int)
+			weighted := NewWeightedBackends()
+			for _, name := range []string{"blue", "green"} {
+				weight, ok := test.weights[name]
+				if !ok {
+					continue
+				}
+				name := name
+				lb, err := roundrobin.New(http.NotFoundHandler())
+				require.NoError(t, err)
+				if !test.unavailable[name] {
+					require.NoError(t, lb.UpsertServer(testhelpers.MustParseURL("http://"+name)))
+				}
+				weighted.AddBackend(name, weight, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					requests[name]++
+					if test.unavailable[name] {
+						rw.WriteHeader(http.StatusServiceUnavailable)
+					}
+				}), lb)
+			}
+
+			for i := 0; i < 8; i++ {
+				recorder := httptest.NewRecorder()
+				weighted.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+				assert.Equal(t, test.expectedStatus, recorder.Code)
+			}
+			assert.Equal(t, test.expectedRequests, requests)
+		})
+	}
+}
+
+func TestWeightedBackendsSmoothDistribution(t *testing.T) {
+	var selected []string
+	weighted := NewWeightedBackends()
+	for i, name := range []string{"blue", "green"} {
+		name := name
+		weighted.AddBackend(name, 2-i, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			selected = append(selected, name)
+		}), nil)
+	}
+
+	for i := 0; i < 6; i++ {
+		weighted.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	// the requests of the backends are interleaved
+	assert.Equal(t, []string{"blue", "green", "blue", "blue", "green", "blue"}, selected)
+}
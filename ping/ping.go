@@ -3,19 +3,32 @@ package ping
 import (
 	"fmt"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/containous/mux"
 )
 
 //Handler expose ping routes
 type Handler struct {
-	EntryPoint string `description:"Ping entryPoint" export:"true"`
+	EntryPoint  string `description:"Ping entryPoint" export:"true"`
+	terminating int32
+}
+
+// SetTerminating makes the ping route answer 503 Service Unavailable from now on,
+// for the load-balancers to take Traefik out of rotation while it stops.
+func (h *Handler) SetTerminating() {
+	atomic.StoreInt32(&h.terminating, 1)
 }
 
 // AddRoutes add ping routes on a router
-func (g Handler) AddRoutes(router *mux.Router) {
+func (h *Handler) AddRoutes(router *mux.Router) {
 	router.Methods(http.MethodGet, http.MethodHead).Path("/ping").
 		HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-			fmt.Fprint(response, "OK")
+			statusCode := http.StatusOK
+			if atomic.LoadInt32(&h.terminating) == 1 {
+				statusCode = http.StatusServiceUnavailable
+			}
+			response.WriteHeader(statusCode)
+			fmt.Fprint(response, http.StatusText(statusCode))
 		})
 }
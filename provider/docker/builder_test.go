@@ -53,6 +53,10 @@ func labels(labels map[string]string) func(*docker.ContainerJSON) {
 	}
 }
 
+func running(c *docker.ContainerJSON) {
+	c.ContainerJSONBase.State = &docker.ContainerState{Running: true}
+}
+
 func ports(portMap nat.PortMap) func(*docker.ContainerJSON) {
 	return func(c *docker.ContainerJSON) {
 		c.NetworkSettings.NetworkSettingsBase.Ports = portMap
@@ -27,6 +27,12 @@ func (p *Provider) buildConfiguration(containersInspected []dockerData) *types.C
 		"getBuffering":      getBuffering,
 		"getCircuitBreaker": getCircuitBreaker,
 		"getLoadBalancer":   getLoadBalancer,
+		"getHostHeader":     getFuncStringLabel(label.TraefikBackendHostHeader, ""),
+		"isDraining":        getFuncBoolLabel(label.TraefikBackendDraining, false),
+		"getSourceAddress":  getFuncStringLabel(label.TraefikBackendSourceAddress, ""),
+		"isH2C":             getFuncBoolLabel(label.TraefikBackendH2C, false),
+		"isForceHTTP1":      getFuncBoolLabel(label.TraefikBackendForceHTTP1, false),
+		"isPercentWeights":  getFuncBoolLabel(label.TraefikBackendPercentWeights, false),
 
 		// TODO Deprecated [breaking]
 		"hasCircuitBreakerLabel": hasFunc(label.TraefikBackendCircuitBreakerExpression),
@@ -55,6 +61,7 @@ func (p *Provider) buildConfiguration(containersInspected []dockerData) *types.C
 		"getPriority":             getFuncIntLabel(label.TraefikFrontendPriority, label.DefaultFrontendPriorityInt),
 		"getPassHostHeader":       getFuncBoolLabel(label.TraefikFrontendPassHostHeader, label.DefaultPassHostHeaderBool),
 		"getPassTLSCert":          getFuncBoolLabel(label.TraefikFrontendPassTLSCert, label.DefaultPassTLSCert),
+		"getCustomHost":           getFuncStringLabel(label.TraefikFrontendCustomHost, ""),
 		"getEntryPoints":          getFuncSliceStringLabel(label.TraefikFrontendEntryPoints),
 		"getBasicAuth":            getFuncSliceStringLabel(label.TraefikFrontendAuthBasic),
 		"getWhitelistSourceRange": getFuncSliceStringLabel(label.TraefikFrontendWhitelistSourceRange),
@@ -81,6 +88,7 @@ func (p *Provider) buildConfiguration(containersInspected []dockerData) *types.C
 		"getServiceFrontendRule":         p.getServiceFrontendRule,
 		"getServicePassHostHeader":       getFuncServiceBoolLabel(label.SuffixFrontendPassHostHeader, label.DefaultPassHostHeaderBool),
 		"getServicePassTLSCert":          getFuncServiceBoolLabel(label.SuffixFrontendPassTLSCert, label.DefaultPassTLSCert),
+		"getServiceCustomHost":           getFuncServiceStringLabel(label.SuffixFrontendCustomHost, ""),
 		"getServicePriority":             getFuncServiceIntLabel(label.SuffixFrontendPriority, label.DefaultFrontendPriorityInt),
 
 		"getServiceRedirect":   getServiceRedirect,
@@ -128,6 +136,9 @@ func (p *Provider) buildConfiguration(containersInspected []dockerData) *types.C
 	if err != nil {
 		log.Error(err)
 	}
+	if configuration != nil {
+		configuration.TLS = p.getSecretCertificates(filteredContainers)
+	}
 
 	return configuration
 }
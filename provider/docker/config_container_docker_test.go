@@ -102,6 +102,12 @@ func TestDockerBuildConfiguration(t *testing.T) {
 						label.TraefikBackendHealthCheckPath:                  "/health",
 						label.TraefikBackendHealthCheckPort:                  "880",
 						label.TraefikBackendHealthCheckInterval:              "6",
+						label.TraefikBackendHostHeader:                       "backend.docker.localhost",
+						label.TraefikBackendSourceAddress:                    "192.168.0.10",
+						label.TraefikBackendH2C:                              "true",
+						label.TraefikBackendForceHTTP1:                       "true",
+						label.TraefikBackendPercentWeights:                   "true",
+						label.TraefikBackendDraining:                         "true",
 						label.TraefikBackendLoadBalancerMethod:               "drr",
 						label.TraefikBackendLoadBalancerSticky:               "true",
 						label.TraefikBackendLoadBalancerStickiness:           "true",
@@ -118,6 +124,7 @@ func TestDockerBuildConfiguration(t *testing.T) {
 						label.TraefikFrontendEntryPoints:          "http,https",
 						label.TraefikFrontendPassHostHeader:       "true",
 						label.TraefikFrontendPassTLSCert:          "true",
+						label.TraefikFrontendCustomHost:           "traefik.wtf",
 						label.TraefikFrontendPriority:             "666",
 						label.TraefikFrontendRedirectEntryPoint:   "https",
 						label.TraefikFrontendRedirectRegex:        "nope",
@@ -181,6 +188,7 @@ func TestDockerBuildConfiguration(t *testing.T) {
 						},
 					},
 					PassHostHeader: true,
+					CustomHost:     "traefik.wtf",
 					PassTLSCert:    true,
 					Priority:       666,
 					BasicAuth: []string{
@@ -298,6 +306,12 @@ func TestDockerBuildConfiguration(t *testing.T) {
 						MemRequestBodyBytes:  2097152,
 						RetryExpression:      "IsNetworkError() && Attempts() <= 2",
 					},
+					HostHeader:     "backend.docker.localhost",
+					Draining:       true,
+					SourceAddress:  "192.168.0.10",
+					H2C:            true,
+					ForceHTTP1:     true,
+					PercentWeights: true,
 				},
 			},
 		},
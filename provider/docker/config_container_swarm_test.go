@@ -127,6 +127,7 @@ func TestSwarmBuildConfiguration(t *testing.T) {
 						label.TraefikFrontendEntryPoints:          "http,https",
 						label.TraefikFrontendPassHostHeader:       "true",
 						label.TraefikFrontendPassTLSCert:          "true",
+						label.TraefikFrontendCustomHost:           "traefik.wtf",
 						label.TraefikFrontendPriority:             "666",
 						label.TraefikFrontendRedirectEntryPoint:   "https",
 						label.TraefikFrontendRedirectRegex:        "nope",
@@ -187,6 +188,7 @@ func TestSwarmBuildConfiguration(t *testing.T) {
 						},
 					},
 					PassHostHeader: true,
+					CustomHost:     "traefik.wtf",
 					PassTLSCert:    true,
 					Priority:       666,
 					BasicAuth: []string{
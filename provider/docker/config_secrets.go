@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider/label"
+	traefikTls "github.com/containous/traefik/tls"
+)
+
+// getSecretCertificates returns the TLS certificates of the frontends of the containers, whose certificate and key are Docker secrets
+// mounted in the container of Traefik. They are read again each time the configuration is built, to follow their rotation.
+func (p *Provider) getSecretCertificates(containers []dockerData) []*traefikTls.Configuration {
+	var configurations []*traefikTls.Configuration
+	seen := make(map[string]bool)
+	for _, container := range containers {
+		certSecret := label.GetStringValue(container.Labels, label.TraefikFrontendTLSCertSecret, "")
+		keySecret := label.GetStringValue(container.Labels, label.TraefikFrontendTLSKeySecret, "")
+		if len(certSecret) == 0 && len(keySecret) == 0 {
+			continue
+		}
+
+		entryPoints := label.GetSliceStringValue(container.Labels, label.TraefikFrontendEntryPoints)
+		sort.Strings(entryPoints)
+		// the tasks of a service share its labels
+		key := certSecret + "/" + keySecret + "@" + strings.Join(entryPoints, ",")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		certificate, err := p.readSecretCertificate(certSecret, keySecret)
+		if err != nil {
+			log.Errorf("Unable to read the TLS certificate of %s: %v", container.Name, err)
+			continue
+		}
+		configurations = append(configurations, &traefikTls.Configuration{
+			EntryPoints: entryPoints,
+			Certificate: certificate,
+		})
+	}
+	return configurations
+}
+
+func (p *Provider) readSecretCertificate(certSecret, keySecret string) (*traefikTls.Certificate, error) {
+	if len(certSecret) == 0 || len(keySecret) == 0 {
+		return nil, fmt.Errorf("both the %s and %s labels are required", label.TraefikFrontendTLSCertSecret, label.TraefikFrontendTLSKeySecret)
+	}
+
+	certPEM, err := p.readSecret(certSecret)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := p.readSecret(keySecret)
+	if err != nil {
+		return nil, err
+	}
+	return &traefikTls.Certificate{
+		CertFile: traefikTls.FileOrContent(certPEM),
+		KeyFile:  traefikTls.FileOrContent(keyPEM),
+	}, nil
+}
+
+// readSecret reads the content of a secret mounted in the secrets directory
+func (p *Provider) readSecret(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	secretsPath := p.SecretsPath
+	if len(secretsPath) == 0 {
+		secretsPath = DefaultSecretsPath
+	}
+	content, err := ioutil.ReadFile(filepath.Join(secretsPath, name))
+	if err != nil {
+		return "", fmt.Errorf("unable to read the secret %s: %v", name, err)
+	}
+	return string(content), nil
+}
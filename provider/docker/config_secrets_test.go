@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containous/traefik/provider/label"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerGetSecretCertificates(t *testing.T) {
+	secretsPath, err := ioutil.TempDir("", "traefik-docker-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(secretsPath)
+
+	for name, content := range map[string]string{"cert.pem": "CERT", "key.pem": "KEY", "other.pem": "OTHER"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(secretsPath, name), []byte(content), 0600))
+	}
+
+	testCases := []struct {
+		desc     string
+		services []dockerData
+		expected []*traefikTls.Configuration
+	}{
+		{
+			desc: "no secret",
+			services: []dockerData{
+				{Name: "service", Labels: map[string]string{label.TraefikPort: "80"}},
+			},
+		},
+		{
+			desc: "secrets of a service",
+			services: []dockerData{
+				{Name: "service", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "cert.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+					label.TraefikFrontendEntryPoints:   "https,admin",
+				}},
+			},
+			expected: []*traefikTls.Configuration{{
+				EntryPoints: []string{"admin", "https"},
+				Certificate: &traefikTls.Certificate{CertFile: "CERT", KeyFile: "KEY"},
+			}},
+		},
+		{
+			desc: "tasks of a service",
+			services: []dockerData{
+				{Name: "service.1", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "cert.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+				}},
+				{Name: "service.2", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "cert.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+				}},
+			},
+			expected: []*traefikTls.Configuration{{
+				Certificate: &traefikTls.Certificate{CertFile: "CERT", KeyFile: "KEY"},
+			}},
+		},
+		{
+			desc: "invalid secrets",
+			services: []dockerData{
+				{Name: "without-key", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "cert.pem",
+				}},
+				{Name: "unknown", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "unknown.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+				}},
+				{Name: "outside", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "../cert.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+				}},
+				{Name: "valid", Labels: map[string]string{
+					label.TraefikFrontendTLSCertSecret: "other.pem",
+					label.TraefikFrontendTLSKeySecret:  "key.pem",
+				}},
+			},
+			expected: []*traefikTls.Configuration{{
+				Certificate: &traefikTls.Certificate{CertFile: "OTHER", KeyFile: "KEY"},
+			}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			provider := &Provider{SecretsPath: secretsPath}
+			assert.Equal(t, test.expected, provider.getSecretCertificates(test.services))
+		})
+	}
+}
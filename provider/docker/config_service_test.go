@@ -82,6 +82,7 @@ func TestDockerServiceBuildConfiguration(t *testing.T) {
 						label.Prefix + "service." + label.SuffixFrontendEntryPoints:          "http,https",
 						label.Prefix + "service." + label.SuffixFrontendPassHostHeader:       "true",
 						label.Prefix + "service." + label.SuffixFrontendPassTLSCert:          "true",
+						label.Prefix + "service." + label.SuffixFrontendCustomHost:           "traefik.wtf",
 						label.Prefix + "service." + label.SuffixFrontendPriority:             "666",
 						label.Prefix + "service." + label.SuffixFrontendRedirectEntryPoint:   "https",
 						label.Prefix + "service." + label.SuffixFrontendRedirectRegex:        "nope",
@@ -139,6 +140,7 @@ func TestDockerServiceBuildConfiguration(t *testing.T) {
 						"https",
 					},
 					PassHostHeader: true,
+					CustomHost:     "traefik.wtf",
 					PassTLSCert:    true,
 					Priority:       666,
 					BasicAuth: []string{
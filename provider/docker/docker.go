@@ -32,6 +32,8 @@ const (
 	SwarmAPIVersion = "1.24"
 	// SwarmDefaultWatchTime is the duration of the interval when polling docker
 	SwarmDefaultWatchTime = 15 * time.Second
+	// DefaultSecretsPath is the directory where Docker mounts the secrets of the containers
+	DefaultSecretsPath = "/run/secrets"
 )
 
 var _ provider.Provider = (*Provider)(nil)
@@ -45,6 +47,7 @@ type Provider struct {
 	ExposedByDefault      bool             `description:"Expose containers by default" export:"true"`
 	UseBindPortIP         bool             `description:"Use the ip address from the bound port, rather than from the inner network" export:"true"`
 	SwarmMode             bool             `description:"Use Docker on Swarm Mode" export:"true"`
+	SecretsPath           string           `description:"Directory where the Docker secrets holding the TLS certificates of the containers are mounted" export:"true"`
 }
 
 // dockerData holds the need data to the Provider p
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/containous/traefik/provider/label"
+	docker "github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+	"golang.org/x/net/context"
+)
+
+type fakeContainersClient struct {
+	dockerclient.APIClient
+	containers []docker.ContainerJSON
+}
+
+func (c *fakeContainersClient) ContainerList(ctx context.Context, options docker.ContainerListOptions) ([]docker.Container, error) {
+	var containers []docker.Container
+	for _, container := range c.containers {
+		containers = append(containers, docker.Container{ID: container.Name})
+	}
+	return containers, nil
+}
+
+func (c *fakeContainersClient) ContainerInspect(ctx context.Context, containerID string) (docker.ContainerJSON, error) {
+	for _, container := range c.containers {
+		if container.Name == containerID {
+			return container, nil
+		}
+	}
+	return docker.ContainerJSON{}, fmt.Errorf("no such container: %s", containerID)
+}
+
+func TestDockerWeightedServersDistribution(t *testing.T) {
+	weightedContainer := func(containerName, ip, weight string) docker.ContainerJSON {
+		return containerJSON(
+			running,
+			name(containerName),
+			labels(map[string]string{
+				label.TraefikBackend: "whoami",
+				label.TraefikPort:    "80",
+				label.TraefikWeight:  weight,
+			}),
+			withNetwork("bridge", ipv4(ip)),
+		)
+	}
+
+	testCases := []struct {
+		desc                 string
+		containers           []docker.ContainerJSON
+		expectedDistribution map[string]int
+	}{
+		{
+			desc: "weights from labels",
+			containers: []docker.ContainerJSON{
+				weightedContainer("test1", "127.0.0.1", "1"),
+				weightedContainer("test2", "127.0.0.2", "4"),
+			},
+			expectedDistribution: map[string]int{"127.0.0.1:80": 2, "127.0.0.2:80": 8},
+		},
+		{
+			desc: "updated weights",
+			containers: []docker.ContainerJSON{
+				weightedContainer("test1", "127.0.0.1", "3"),
+				weightedContainer("test2", "127.0.0.2", "2"),
+			},
+			expectedDistribution: map[string]int{"127.0.0.1:80": 6, "127.0.0.2:80": 4},
+		},
+		{
+			desc: "default weight",
+			containers: []docker.ContainerJSON{
+				weightedContainer("test1", "127.0.0.1", "1"),
+				weightedContainer("test2", "127.0.0.2", "invalid"),
+			},
+			expectedDistribution: map[string]int{"127.0.0.1:80": 5, "127.0.0.2:80": 5},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dockerDataList, err := listContainers(context.Background(), &fakeContainersClient{containers: test.containers})
+			require.NoError(t, err)
+
+			provider := &Provider{
+				Domain:           "docker.localhost",
+				ExposedByDefault: true,
+			}
+			config := provider.buildConfiguration(dockerDataList)
+			require.Contains(t, config.Backends, "backend-whoami")
+
+			// the next handler only records the server selected by the load-balancer
+			distribution := make(map[string]int)
+			wrr, err := roundrobin.New(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				distribution[req.URL.Host]++
+			}))
+			require.NoError(t, err)
+
+			for _, server := range config.Backends["backend-whoami"].Servers {
+				serverURL, err := url.Parse(server.URL)
+				require.NoError(t, err)
+				require.NoError(t, wrr.UpsertServer(serverURL, roundrobin.Weight(server.Weight)))
+			}
+
+			for i := 0; i < 10; i++ {
+				wrr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://whoami.docker.localhost/", nil))
+			}
+
+			assert.Equal(t, test.expectedDistribution, distribution)
+		})
+	}
+}
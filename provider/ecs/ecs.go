@@ -9,6 +9,7 @@ import (
 	"github.com/BurntSushi/ty/fun"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -41,6 +42,8 @@ type Provider struct {
 	Region               string   `description:"The AWS region to use for requests" export:"true"`
 	AccessKeyID          string   `description:"The AWS credentials access key to use for making requests"`
 	SecretAccessKey      string   `description:"The AWS credentials access key to use for making requests"`
+	RoleARN              string   `description:"The ARN of an IAM role to assume for making requests" export:"true"`
+	ExternalID           string   `description:"The external ID to use when assuming the IAM role"`
 }
 
 type ecsInstance struct {
@@ -86,6 +89,16 @@ func (p *Provider) createClient() (*awsClient, error) {
 			}),
 	}
 
+	if len(p.RoleARN) > 0 {
+		log.Infof("Assuming IAM role %s", p.RoleARN)
+		// the credentials above are used to assume the role
+		cfg.Credentials = stscreds.NewCredentials(session.New(cfg), p.RoleARN, func(provider *stscreds.AssumeRoleProvider) {
+			if len(p.ExternalID) > 0 {
+				provider.ExternalID = aws.String(p.ExternalID)
+			}
+		})
+	}
+
 	if p.Trace {
 		cfg.WithLogger(aws.LoggerFunc(func(args ...interface{}) {
 			log.Debug(args...)
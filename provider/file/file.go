@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -82,6 +83,14 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 						callback(configurationChan, evt)
 					}
 				} else {
+					// the sub-directories created in the directory are watched as well
+					if evt.Op&fsnotify.Create == fsnotify.Create {
+						if fileInfo, err := os.Stat(evt.Name); err == nil && fileInfo.IsDir() {
+							if err := addDirectoryWatches(watcher, evt.Name); err != nil {
+								log.Errorf("Error adding file watcher: %s", err)
+							}
+						}
+					}
 					callback(configurationChan, evt)
 				}
 			case err := <-watcher.Errors:
@@ -89,7 +98,11 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 			}
 		}
 	})
-	err = watcher.Add(directory)
+	if p.Directory != "" {
+		err = addDirectoryWatches(watcher, directory)
+	} else {
+		err = watcher.Add(directory)
+	}
 	if err != nil {
 		return fmt.Errorf("error adding file watcher: %s", err)
 	}
@@ -97,6 +110,19 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 	return nil
 }
 
+// addDirectoryWatches watches the directory and its sub-directories, whose files are loaded as well
+func addDirectoryWatches(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
 func (p *Provider) watcherCallback(configurationChan chan<- types.ConfigMessage, event fsnotify.Event) {
 	watchItem := p.Filename
 	if p.Directory != "" {
@@ -150,7 +176,6 @@ func loadFileConfigFromDirectory(directory string, configuration *types.Configur
 		}
 	}
 
-	configTLSMaps := make(map[*tls.Configuration]struct{})
 	for _, item := range fileList {
 
 		if item.IsDir() {
@@ -186,17 +211,26 @@ func loadFileConfigFromDirectory(directory string, configuration *types.Configur
 			}
 		}
 
+		// The TLS configurations are kept in the order of the files, so that the certificates they define are
+		// merged deterministically: when several certificates are defined for the same domains, the first one is used.
 		for _, conf := range c.TLS {
-			if _, exists := configTLSMaps[conf]; exists {
+			if tlsConfigurationExists(configuration.TLS, conf) {
 				log.Warnf("TLS Configuration %v already configured, skipping", conf)
 			} else {
-				configTLSMaps[conf] = struct{}{}
+				configuration.TLS = append(configuration.TLS, conf)
 			}
 		}
 
 	}
-	for conf := range configTLSMaps {
-		configuration.TLS = append(configuration.TLS, conf)
-	}
 	return configuration, nil
 }
+
+// tlsConfigurationExists returns true if the configurations already contain the same certificate for the same entrypoints
+func tlsConfigurationExists(configurations []*tls.Configuration, conf *tls.Configuration) bool {
+	for _, existing := range configurations {
+		if existing == conf || reflect.DeepEqual(existing, conf) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,52 +2,50 @@ package file
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProvideSingleFileAndWatch(t *testing.T) {
 	tempDir := createTempDir(t, "testfile")
 	defer os.RemoveAll(tempDir)
 
-	expectedNumFrontends := 2
-	expectedNumBackends := 2
-	expectedNumTLSConf := 2
-
 	tempFile := createFile(t,
 		tempDir, "simple.toml",
-		createFrontendConfiguration(expectedNumFrontends),
-		createBackendConfiguration(expectedNumBackends),
-		createTLS(expectedNumTLSConf))
+		createFrontendConfiguration(2),
+		createBackendConfiguration(2),
+		createTLS(2))
 
-	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+	configurationChan, signal := createConfigurationRoutine(t)
 
 	provide(configurationChan, watch, withFile(tempFile))
 
 	// Wait for initial message to be tested
-	err := waitForSignal(signal, 2*time.Second, "initial config")
+	err := waitForSignal(signal, configurationCounts{frontends: 2, backends: 2, tlses: 2}, 2*time.Second, "initial config")
 	assert.NoError(t, err)
 
 	// Now test again with single frontend and backend
-	expectedNumFrontends = 1
-	expectedNumBackends = 1
-	expectedNumTLSConf = 1
-
 	createFile(t,
 		tempDir, "simple.toml",
-		createFrontendConfiguration(expectedNumFrontends),
-		createBackendConfiguration(expectedNumBackends),
-		createTLS(expectedNumTLSConf))
+		createFrontendConfiguration(1),
+		createBackendConfiguration(1),
+		createTLS(1))
 
-	err = waitForSignal(signal, 2*time.Second, "single frontend, backend, TLS configuration")
+	err = waitForSignal(signal, configurationCounts{frontends: 1, backends: 1, tlses: 1}, 2*time.Second, "single frontend, backend, TLS configuration")
 	assert.NoError(t, err)
 }
 
@@ -55,37 +53,29 @@ func TestProvideSingleFileAndNotWatch(t *testing.T) {
 	tempDir := createTempDir(t, "testfile")
 	defer os.RemoveAll(tempDir)
 
-	expectedNumFrontends := 2
-	expectedNumBackends := 2
-	expectedNumTLSConf := 2
-
 	tempFile := createFile(t,
 		tempDir, "simple.toml",
-		createFrontendConfiguration(expectedNumFrontends),
-		createBackendConfiguration(expectedNumBackends),
-		createTLS(expectedNumTLSConf))
+		createFrontendConfiguration(2),
+		createBackendConfiguration(2),
+		createTLS(2))
 
-	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+	configurationChan, signal := createConfigurationRoutine(t)
 
 	provide(configurationChan, withFile(tempFile))
 
 	// Wait for initial message to be tested
-	err := waitForSignal(signal, 2*time.Second, "initial config")
+	err := waitForSignal(signal, configurationCounts{frontends: 2, backends: 2, tlses: 2}, 2*time.Second, "initial config")
 	assert.NoError(t, err)
 
 	// Now test again with single frontend and backend
-	expectedNumFrontends = 1
-	expectedNumBackends = 1
-	expectedNumTLSConf = 1
-
 	createFile(t,
 		tempDir, "simple.toml",
-		createFrontendConfiguration(expectedNumFrontends),
-		createBackendConfiguration(expectedNumBackends),
-		createTLS(expectedNumTLSConf))
+		createFrontendConfiguration(1),
+		createBackendConfiguration(1),
+		createTLS(1))
 
 	// Must fail because we don't watch the changes
-	err = waitForSignal(signal, 2*time.Second, "single frontend, backend and TLS configuration")
+	err = waitForSignal(signal, configurationCounts{frontends: 1, backends: 1, tlses: 1}, 2*time.Second, "single frontend, backend and TLS configuration")
 	assert.Error(t, err)
 }
 
@@ -93,44 +83,73 @@ func TestProvideDirectoryAndWatch(t *testing.T) {
 	tempDir := createTempDir(t, "testdir")
 	defer os.RemoveAll(tempDir)
 
-	expectedNumFrontends := 2
-	expectedNumBackends := 2
-	expectedNumTLSConf := 2
-
-	tempFile1 := createRandomFile(t, tempDir, createFrontendConfiguration(expectedNumFrontends))
-	tempFile2 := createRandomFile(t, tempDir, createBackendConfiguration(expectedNumBackends))
-	tempFile3 := createRandomFile(t, tempDir, createTLS(expectedNumTLSConf))
+	tempFile1 := createRandomFile(t, tempDir, createFrontendConfiguration(2))
+	tempFile2 := createRandomFile(t, tempDir, createBackendConfiguration(2))
+	tempFile3 := createRandomFile(t, tempDir, createTLS(2))
 
-	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+	configurationChan, signal := createConfigurationRoutine(t)
 
 	provide(configurationChan, watch, withDirectory(tempDir))
 
 	// Wait for initial config message to be tested
-	err := waitForSignal(signal, 2*time.Second, "initial config")
+	err := waitForSignal(signal, configurationCounts{frontends: 2, backends: 2, tlses: 2}, 2*time.Second, "initial config")
 	assert.NoError(t, err)
 
 	// Now remove the backends file
-	expectedNumFrontends = 2
-	expectedNumBackends = 0
-	expectedNumTLSConf = 2
 	os.Remove(tempFile2.Name())
-	err = waitForSignal(signal, 2*time.Second, "remove the backends file")
+	err = waitForSignal(signal, configurationCounts{frontends: 2, backends: 0, tlses: 2}, 2*time.Second, "remove the backends file")
 	assert.NoError(t, err)
 
 	// Now remove the frontends file
-	expectedNumFrontends = 0
-	expectedNumBackends = 0
-	expectedNumTLSConf = 2
 	os.Remove(tempFile1.Name())
-	err = waitForSignal(signal, 2*time.Second, "remove the frontends file")
+	err = waitForSignal(signal, configurationCounts{frontends: 0, backends: 0, tlses: 2}, 2*time.Second, "remove the frontends file")
 	assert.NoError(t, err)
 
 	// Now remove the TLS configuration file
-	expectedNumFrontends = 0
-	expectedNumBackends = 0
-	expectedNumTLSConf = 0
 	os.Remove(tempFile3.Name())
-	err = waitForSignal(signal, 2*time.Second, "remove the TLS configuration file")
+	err = waitForSignal(signal, configurationCounts{frontends: 0, backends: 0, tlses: 0}, 2*time.Second, "remove the TLS configuration file")
+	assert.NoError(t, err)
+}
+
+func TestProvideDirectoryAndWatchSubDirectories(t *testing.T) {
+	tempDir := createTempDir(t, "testdir")
+	defer os.RemoveAll(tempDir)
+	tempFrontendsDir := createSubDir(t, tempDir, "frontends")
+	tempTLSSourceDir := createTempDir(t, "testtls")
+	defer os.RemoveAll(tempTLSSourceDir)
+
+	tempFile1 := createRandomFile(t, tempFrontendsDir, createFrontendConfiguration(2))
+	createRandomFile(t, tempDir, createBackendConfiguration(2))
+
+	configurationChan, signal := createConfigurationRoutine(t)
+
+	// the watcher is stopped before the removal of the directories, which would send other configurations
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+	pvd := &Provider{Directory: tempDir}
+	pvd.Watch = true
+	err := pvd.Provide(configurationChan, pool, nil)
+	require.NoError(t, err)
+
+	// Wait for initial config message to be tested
+	err = waitForSignal(signal, configurationCounts{frontends: 2, backends: 2, tlses: 0}, 2*time.Second, "initial config")
+	assert.NoError(t, err)
+
+	// Now remove the frontends file of the existing sub-directory
+	os.Remove(tempFile1.Name())
+	err = waitForSignal(signal, configurationCounts{frontends: 0, backends: 2, tlses: 0}, 2*time.Second, "remove the frontends file")
+	assert.NoError(t, err)
+
+	// Now create a new sub-directory
+	tempTLSDir := createSubDir(t, tempDir, "tls")
+	err = waitForSignal(signal, configurationCounts{frontends: 0, backends: 2, tlses: 0}, 2*time.Second, "create the TLS sub-directory")
+	assert.NoError(t, err)
+
+	// Now move a TLS configuration file in the new sub-directory
+	tempFile3 := createRandomFile(t, tempTLSSourceDir, createTLS(2))
+	err = os.Rename(tempFile3.Name(), filepath.Join(tempTLSDir, filepath.Base(tempFile3.Name())))
+	require.NoError(t, err)
+	err = waitForSignal(signal, configurationCounts{frontends: 0, backends: 2, tlses: 2}, 2*time.Second, "move the TLS configuration file")
 	assert.NoError(t, err)
 }
 
@@ -139,59 +158,106 @@ func TestProvideDirectoryAndNotWatch(t *testing.T) {
 	tempTLSDir := createSubDir(t, tempDir, "tls")
 	defer os.RemoveAll(tempDir)
 
-	expectedNumFrontends := 2
-	expectedNumBackends := 2
-	expectedNumTLSConf := 2
-
-	createRandomFile(t, tempDir, createFrontendConfiguration(expectedNumFrontends))
-	tempFile2 := createRandomFile(t, tempDir, createBackendConfiguration(expectedNumBackends))
-	createRandomFile(t, tempTLSDir, createTLS(expectedNumTLSConf))
+	createRandomFile(t, tempDir, createFrontendConfiguration(2))
+	tempFile2 := createRandomFile(t, tempDir, createBackendConfiguration(2))
+	createRandomFile(t, tempTLSDir, createTLS(2))
 
-	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+	configurationChan, signal := createConfigurationRoutine(t)
 
 	provide(configurationChan, withDirectory(tempDir))
 
 	// Wait for initial config message to be tested
-	err := waitForSignal(signal, 2*time.Second, "initial config")
+	err := waitForSignal(signal, configurationCounts{frontends: 2, backends: 2, tlses: 2}, 2*time.Second, "initial config")
 	assert.NoError(t, err)
 
 	// Now remove the backends file
-	expectedNumFrontends = 2
-	expectedNumBackends = 0
-	expectedNumTLSConf = 2
 	os.Remove(tempFile2.Name())
 
 	// Must fail because we don't watch the changes
-	err = waitForSignal(signal, 2*time.Second, "remove the backends file")
+	err = waitForSignal(signal, configurationCounts{frontends: 2, backends: 0, tlses: 2}, 2*time.Second, "remove the backends file")
 	assert.Error(t, err)
 
 }
 
-func createConfigurationRoutine(t *testing.T, expectedNumFrontends *int, expectedNumBackends *int, expectedNumTLSes *int) (chan types.ConfigMessage, chan interface{}) {
+func TestLoadFileConfigFromDirectoryMergesCertificates(t *testing.T) {
+	tempDir := createTempDir(t, "testcerts")
+	defer os.RemoveAll(tempDir)
+
+	// a.toml defines a certificate for a.example.com and one for shared.example.com,
+	// b.toml defines a certificate for b.example.com and another one for shared.example.com
+	sharedCertFirst := createCertificate(t, tempDir, "shared1", "shared.example.com")
+	createFile(t, tempDir, "a.toml",
+		createTLSWithCertificate(createCertificate(t, tempDir, "a", "a.example.com")),
+		createTLSWithCertificate(sharedCertFirst))
+	createFile(t, tempDir, "b.toml",
+		createTLSWithCertificate(createCertificate(t, tempDir, "b", "b.example.com")),
+		createTLSWithCertificate(createCertificate(t, tempDir, "shared2", "shared.example.com")))
+
+	configuration, err := loadFileConfigFromDirectory(tempDir, nil)
+	require.NoError(t, err)
+	require.Len(t, configuration.TLS, 4)
+
+	certs := make(map[string]*tls.DomainsCertificates)
+	err = tls.SortTLSPerEntryPoints(configuration.TLS, certs, nil)
+	require.NoError(t, err)
+	require.NotNil(t, certs["https"])
+	assert.Len(t, *certs["https"], 3)
+
+	for _, domain := range []string{"a.example.com", "b.example.com"} {
+		cert := certs["https"].GetBestCertificate(domain)
+		require.NotNil(t, cert, domain)
+		x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, []string{domain}, x509Cert.DNSNames)
+	}
+
+	// the certificate of the first file is kept for the domains defined in both files
+	expectedShared, err := sharedCertFirst.CertFile.Read()
+	require.NoError(t, err)
+	block, _ := pem.Decode(expectedShared)
+	require.NotNil(t, block)
+	cert := certs["https"].GetBestCertificate("shared.example.com")
+	require.NotNil(t, cert)
+	assert.Equal(t, block.Bytes, cert.Certificate[0])
+}
+
+// configurationCounts holds the numbers of frontends, backends and TLS configurations of a configuration sent by the provider
+type configurationCounts struct {
+	frontends int
+	backends  int
+	tlses     int
+}
+
+// createConfigurationRoutine receives the configurations sent by the provider, and sends their counts on the signal channel,
+// the expected counts being only read by the test itself
+func createConfigurationRoutine(t *testing.T) (chan types.ConfigMessage, chan configurationCounts) {
 	configurationChan := make(chan types.ConfigMessage)
-	signal := make(chan interface{})
+	signal := make(chan configurationCounts)
 
 	safe.Go(func() {
 		for {
 			data := <-configurationChan
 			assert.Equal(t, "file", data.ProviderName)
-			assert.Len(t, data.Configuration.Frontends, *expectedNumFrontends)
-			assert.Len(t, data.Configuration.Backends, *expectedNumBackends)
-			assert.Len(t, data.Configuration.TLS, *expectedNumTLSes)
-			signal <- nil
+			signal <- configurationCounts{
+				frontends: len(data.Configuration.Frontends),
+				backends:  len(data.Configuration.Backends),
+				tlses:     len(data.Configuration.TLS),
+			}
 		}
 	})
 
 	return configurationChan, signal
 }
 
-func waitForSignal(signal chan interface{}, timeout time.Duration, caseName string) error {
+func waitForSignal(signal chan configurationCounts, expected configurationCounts, timeout time.Duration, caseName string) error {
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
 	select {
-	case <-signal:
-
+	case counts := <-signal:
+		if counts != expected {
+			return fmt.Errorf("Unexpected configuration for %s: %+v instead of %+v", caseName, counts, expected)
+		}
 	case <-timer.C:
 		return fmt.Errorf("Timed out waiting for assertions to be tested: %s", caseName)
 	}
@@ -310,3 +376,28 @@ func createTLS(n int) string {
 	}
 	return conf
 }
+
+// createCertificate Helper
+func createCertificate(t *testing.T, tempDir string, name string, domain string) *tls.Certificate {
+	t.Helper()
+	cert, key, err := generate.KeyPair(domain, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	certFile := createFile(t, tempDir, name+".cert", string(cert))
+	keyFile := createFile(t, tempDir, name+".key", string(key))
+
+	return &tls.Certificate{
+		CertFile: tls.FileOrContent(certFile.Name()),
+		KeyFile:  tls.FileOrContent(keyFile.Name()),
+	}
+}
+
+// createTLSWithCertificate Helper
+func createTLSWithCertificate(cert *tls.Certificate) string {
+	return fmt.Sprintf(`[[TLS]]
+	EntryPoints = ["https"]
+	[TLS.Certificate]
+	CertFile = %q
+	KeyFile = %q
+`, cert.CertFile, cert.KeyFile)
+}
@@ -12,6 +12,7 @@ const (
 	annotationKubernetesRewriteTarget            = "ingress.kubernetes.io/rewrite-target"
 	annotationKubernetesWhitelistSourceRange     = "ingress.kubernetes.io/whitelist-source-range"
 	annotationKubernetesPreserveHost             = "ingress.kubernetes.io/preserve-host"
+	annotationKubernetesCustomHost               = "ingress.kubernetes.io/custom-host"
 	annotationKubernetesPassTLSCert              = "ingress.kubernetes.io/pass-tls-cert"
 	annotationKubernetesFrontendEntryPoints      = "ingress.kubernetes.io/frontend-entry-points"
 	annotationKubernetesPriority                 = "ingress.kubernetes.io/priority"
@@ -29,6 +30,7 @@ const (
 	annotationKubernetesRateLimit                = "ingress.kubernetes.io/rate-limit"
 	annotationKubernetesErrorPages               = "ingress.kubernetes.io/error-pages"
 	annotationKubernetesBuffering                = "ingress.kubernetes.io/buffering"
+	annotationKubernetesBackendTLSSecret         = "ingress.kubernetes.io/backend-tls-secret"
 
 	annotationKubernetesSSLRedirect             = "ingress.kubernetes.io/ssl-redirect"
 	annotationKubernetesHSTSMaxAge              = "ingress.kubernetes.io/hsts-max-age"
@@ -151,6 +151,16 @@ func maxConnAmount(value int64) func(*types.Backend) {
 	}
 }
 
+func backendTLS(ca, cert, key string) func(*types.Backend) {
+	return func(b *types.Backend) {
+		b.TLS = &types.BackendTLS{
+			CA:   tls.FileOrContent(ca),
+			Cert: tls.FileOrContent(cert),
+			Key:  tls.FileOrContent(key),
+		}
+	}
+}
+
 // Frontend
 
 func buildFrontends(opts ...func(*types.Frontend) string) map[string]*types.Frontend {
@@ -323,6 +333,12 @@ func limitPeriod(period time.Duration) func(*types.Rate) {
 	}
 }
 
+func customHost(host string) func(*types.Frontend) {
+	return func(f *types.Frontend) {
+		f.CustomHost = host
+	}
+}
+
 func passTLSCert() func(*types.Frontend) {
 	return func(f *types.Frontend) {
 		f.PassTLSCert = true
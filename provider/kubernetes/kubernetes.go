@@ -205,6 +205,7 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 					templateObjects.Frontends[baseName] = &types.Frontend{
 						Backend:              baseName,
 						PassHostHeader:       passHostHeader,
+						CustomHost:           getStringValue(i.Annotations, annotationKubernetesCustomHost, ""),
 						PassTLSCert:          passTLSCert,
 						Routes:               make(map[string]types.Route),
 						Priority:             priority,
@@ -249,6 +250,14 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 				templateObjects.Backends[baseName].MaxConn = getMaxConn(service)
 				templateObjects.Backends[baseName].Buffering = getBuffering(service)
 
+				backendTLS, err := getBackendTLS(service, k8sClient)
+				if err != nil {
+					log.Errorf("Error configuring the backend TLS of service %s/%s: %v", service.Namespace, service.Name, err)
+					delete(templateObjects.Frontends, baseName)
+					continue
+				}
+				templateObjects.Backends[baseName].TLS = backendTLS
+
 				protocol := label.DefaultProtocol
 				for _, port := range service.Spec.Ports {
 					if equalPorts(port, pa.Backend.ServicePort) {
@@ -581,6 +590,44 @@ func getMaxConn(service *v1.Service) *types.MaxConn {
 	return nil
 }
 
+// getBackendTLS loads the CA bundle and the client certificate of the connections to the servers of a service
+// from the secret referenced by its backend TLS secret annotation.
+func getBackendTLS(service *v1.Service, k8sClient Client) (*types.BackendTLS, error) {
+	secretName := getStringValue(service.Annotations, annotationKubernetesBackendTLSSecret, "")
+	if secretName == "" {
+		return nil, nil
+	}
+
+	secret, exists, err := k8sClient.GetSecret(service.Namespace, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secret %s/%s: %v", service.Namespace, secretName, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("secret %s/%s does not exist", service.Namespace, secretName)
+	}
+
+	caData, caExists := secret.Data["ca.crt"]
+	tlsCrtData, tlsCrtExists := secret.Data["tls.crt"]
+	tlsKeyData, tlsKeyExists := secret.Data["tls.key"]
+
+	if !caExists && !tlsCrtExists && !tlsKeyExists {
+		return nil, fmt.Errorf("secret %s/%s contains neither a ca.crt entry nor tls.crt and tls.key entries", service.Namespace, secretName)
+	}
+	if tlsCrtExists != tlsKeyExists {
+		missingEntry := "tls.crt"
+		if !tlsKeyExists {
+			missingEntry = "tls.key"
+		}
+		return nil, fmt.Errorf("secret %s/%s is missing the following TLS data entries: %s", service.Namespace, secretName, missingEntry)
+	}
+
+	return &types.BackendTLS{
+		CA:   tls.FileOrContent(caData),
+		Cert: tls.FileOrContent(tlsCrtData),
+		Key:  tls.FileOrContent(tlsKeyData),
+	}, nil
+}
+
 func getCircuitBreaker(service *v1.Service) *types.CircuitBreaker {
 	if expression := getStringValue(service.Annotations, annotationKubernetesCircuitBreakerExpression, ""); expression != "" {
 		return &types.CircuitBreaker{
@@ -14,9 +14,11 @@ const (
 	SuffixBackendID                                = "backend.id"
 	SuffixBackendCircuitBreaker                    = "backend.circuitbreaker"
 	SuffixBackendCircuitBreakerExpression          = "backend.circuitbreaker.expression"
+	SuffixBackendDraining                          = "backend.draining"
 	SuffixBackendHealthCheckPath                   = "backend.healthcheck.path"
 	SuffixBackendHealthCheckPort                   = "backend.healthcheck.port"
 	SuffixBackendHealthCheckInterval               = "backend.healthcheck.interval"
+	SuffixBackendHostHeader                        = "backend.hostHeader"
 	SuffixBackendLoadBalancer                      = "backend.loadbalancer"
 	SuffixBackendLoadBalancerMethod                = SuffixBackendLoadBalancer + ".method"
 	SuffixBackendLoadBalancerSticky                = SuffixBackendLoadBalancer + ".sticky"
@@ -24,6 +26,10 @@ const (
 	SuffixBackendLoadBalancerStickinessCookieName  = SuffixBackendLoadBalancer + ".stickiness.cookieName"
 	SuffixBackendMaxConnAmount                     = "backend.maxconn.amount"
 	SuffixBackendMaxConnExtractorFunc              = "backend.maxconn.extractorfunc"
+	SuffixBackendSourceAddress                     = "backend.sourceAddress"
+	SuffixBackendH2C                               = "backend.h2c"
+	SuffixBackendForceHTTP1                        = "backend.forceHTTP1"
+	SuffixBackendPercentWeights                    = "backend.percentWeights"
 	SuffixBackendBuffering                         = "backend.buffering"
 	SuffixBackendBufferingMaxRequestBodyBytes      = SuffixBackendBuffering + ".maxRequestBodyBytes"
 	SuffixBackendBufferingMemRequestBodyBytes      = SuffixBackendBuffering + ".memRequestBodyBytes"
@@ -56,7 +62,10 @@ const (
 	SuffixFrontendHeadersReferrerPolicy            = SuffixFrontendHeaders + "referrerPolicy"
 	SuffixFrontendHeadersIsDevelopment             = SuffixFrontendHeaders + "isDevelopment"
 	SuffixFrontendPassHostHeader                   = "frontend.passHostHeader"
+	SuffixFrontendCustomHost                       = "frontend.customHost"
 	SuffixFrontendPassTLSCert                      = "frontend.passTLSCert"
+	SuffixFrontendTLSCertSecret                    = "frontend.tls.certSecret"
+	SuffixFrontendTLSKeySecret                     = "frontend.tls.keySecret"
 	SuffixFrontendPriority                         = "frontend.priority"
 	SuffixFrontendRateLimitExtractorFunc           = "frontend.rateLimit.extractorFunc"
 	SuffixFrontendRedirectEntryPoint               = "frontend.redirect.entryPoint"
@@ -77,9 +86,11 @@ const (
 	TraefikBackendID                               = Prefix + SuffixBackendID
 	TraefikBackendCircuitBreaker                   = Prefix + SuffixBackendCircuitBreaker
 	TraefikBackendCircuitBreakerExpression         = Prefix + SuffixBackendCircuitBreakerExpression
+	TraefikBackendDraining                         = Prefix + SuffixBackendDraining
 	TraefikBackendHealthCheckPath                  = Prefix + SuffixBackendHealthCheckPath
 	TraefikBackendHealthCheckPort                  = Prefix + SuffixBackendHealthCheckPort
 	TraefikBackendHealthCheckInterval              = Prefix + SuffixBackendHealthCheckInterval
+	TraefikBackendHostHeader                       = Prefix + SuffixBackendHostHeader
 	TraefikBackendLoadBalancer                     = Prefix + SuffixBackendLoadBalancer
 	TraefikBackendLoadBalancerMethod               = Prefix + SuffixBackendLoadBalancerMethod
 	TraefikBackendLoadBalancerSticky               = Prefix + SuffixBackendLoadBalancerSticky
@@ -87,6 +98,10 @@ const (
 	TraefikBackendLoadBalancerStickinessCookieName = Prefix + SuffixBackendLoadBalancerStickinessCookieName
 	TraefikBackendMaxConnAmount                    = Prefix + SuffixBackendMaxConnAmount
 	TraefikBackendMaxConnExtractorFunc             = Prefix + SuffixBackendMaxConnExtractorFunc
+	TraefikBackendSourceAddress                    = Prefix + SuffixBackendSourceAddress
+	TraefikBackendH2C                              = Prefix + SuffixBackendH2C
+	TraefikBackendForceHTTP1                       = Prefix + SuffixBackendForceHTTP1
+	TraefikBackendPercentWeights                   = Prefix + SuffixBackendPercentWeights
 	TraefikBackendBuffering                        = Prefix + SuffixBackendBuffering
 	TraefikBackendBufferingMaxRequestBodyBytes     = Prefix + SuffixBackendBufferingMaxRequestBodyBytes
 	TraefikBackendBufferingMemRequestBodyBytes     = Prefix + SuffixBackendBufferingMemRequestBodyBytes
@@ -97,7 +112,10 @@ const (
 	TraefikFrontendAuthBasic                       = Prefix + SuffixFrontendAuthBasic
 	TraefikFrontendEntryPoints                     = Prefix + SuffixFrontendEntryPoints
 	TraefikFrontendPassHostHeader                  = Prefix + SuffixFrontendPassHostHeader
+	TraefikFrontendCustomHost                      = Prefix + SuffixFrontendCustomHost
 	TraefikFrontendPassTLSCert                     = Prefix + SuffixFrontendPassTLSCert
+	TraefikFrontendTLSCertSecret                   = Prefix + SuffixFrontendTLSCertSecret
+	TraefikFrontendTLSKeySecret                    = Prefix + SuffixFrontendTLSKeySecret
 	TraefikFrontendPriority                        = Prefix + SuffixFrontendPriority
 	TraefikFrontendRateLimitExtractorFunc          = Prefix + SuffixFrontendRateLimitExtractorFunc
 	TraefikFrontendRedirectEntryPoint              = Prefix + SuffixFrontendRedirectEntryPoint
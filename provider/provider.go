@@ -9,6 +9,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/Masterminds/sprig"
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/autogen/gentemplates"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/safe"
@@ -29,6 +30,7 @@ type BaseProvider struct {
 	Constraints               types.Constraints `description:"Filter services by constraint, matching with Traefik tags." export:"true"`
 	Trace                     bool              `description:"Display additional provider logs (if available)." export:"true"`
 	DebugLogGeneratedTemplate bool              `description:"Enable debug logging of generated configuration template." export:"true"`
+	ThrottleDuration          flaeg.Duration    `description:"Minimum duration between 2 configurations applied from this provider. Overrides ProvidersThrottleDuration if set." export:"true"`
 }
 
 // MatchConstraints must match with EVERY single constraint
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abronan/valkeyrie/store"
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/kv"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	kv.Provider  `mapstructure:",squash" export:"true"`
+	DB           int            `description:"Index of the Redis database" export:"true"`
+	PollInterval flaeg.Duration `description:"Interval between two reads of the keys when watching, instead of the keyspace notifications" export:"true"`
+}
+
+// Provide allows the redis provider to Provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+	store, err := p.CreateStore()
+	if err != nil {
+		return fmt.Errorf("failed to Connect to KV store: %v", err)
+	}
+	p.SetKVClient(store)
+	return p.Provider.Provide(configurationChan, pool, constraints)
+}
+
+// CreateStore creates the KV store
+func (p *Provider) CreateStore() (store.Store, error) {
+	p.SetStoreType(store.REDIS)
+
+	config := storeConfig{
+		ConnectionTimeout: 30 * time.Second,
+		Username:          p.Username,
+		Password:          p.Password,
+		DB:                p.DB,
+		PollInterval:      time.Duration(p.PollInterval),
+	}
+	if p.TLS != nil {
+		var err error
+		config.TLS, err = p.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newStore(strings.Split(p.Endpoint, ","), config), nil
+}
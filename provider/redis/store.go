@@ -0,0 +1,405 @@
+package redis
+
+import (
+	"bytes"
+	"crypto/tls"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abronan/valkeyrie/store"
+	"github.com/containous/traefik/log"
+	traefikRedis "github.com/containous/traefik/redis"
+)
+
+// defaultPollInterval is the interval between two reads of the watched keys,
+// when the keyspace notifications are not enabled on the Redis server
+const defaultPollInterval = 15 * time.Second
+
+// scanCount is the number of keys requested at each iteration of a SCAN
+const scanCount = "1000"
+
+var _ store.Store = (*redisStore)(nil)
+
+// storeConfig contains the options of the connections to the Redis servers.
+type storeConfig struct {
+	TLS               *tls.Config
+	ConnectionTimeout time.Duration
+	Username          string
+	Password          string
+	DB                int
+	// PollInterval, when not zero, makes the watches read the keys at this interval instead of using the keyspace notifications
+	PollInterval time.Duration
+}
+
+// redisStore is a valkeyrie store reading and writing the values as Redis strings, whose keys are the full paths.
+// It is limited to the calls used by the KV provider: the locks and the atomic operations are not supported.
+type redisStore struct {
+	endpoints []string
+	config    storeConfig
+
+	lock sync.Mutex
+	conn *traefikRedis.Conn
+}
+
+func newStore(endpoints []string, config storeConfig) *redisStore {
+	return &redisStore{
+		endpoints: endpoints,
+		config:    config,
+	}
+}
+
+// dial connects to the first reachable endpoint, then authenticates and selects the database.
+func (s *redisStore) dial(timeout time.Duration) (*traefikRedis.Conn, error) {
+	return traefikRedis.Dial(s.endpoints, traefikRedis.Options{
+		TLS:               s.config.TLS,
+		ConnectionTimeout: s.config.ConnectionTimeout,
+		Username:          s.config.Username,
+		Password:          s.config.Password,
+		DB:                s.config.DB,
+	}, timeout)
+}
+
+// do sends a command on the shared connection, which is opened again after a network error.
+func (s *redisStore) do(args ...string) (interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		c, err := s.dial(s.config.ConnectionTimeout)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = c
+	}
+
+	reply, err := s.conn.Do(args...)
+	if _, ok := err.(traefikRedis.Error); err != nil && !ok {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+// Put a value at the specified key
+func (s *redisStore) Put(key string, value []byte, options *store.WriteOptions) error {
+	args := []string{"SET", key, string(value)}
+	if options != nil && options.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(int64(options.TTL/time.Millisecond), 10))
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+// Get a value given its key
+func (s *redisStore) Get(key string, options *store.ReadOptions) (*store.KVPair, error) {
+	reply, err := s.do("GET", key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return &store.KVPair{Key: key, Value: value}, nil
+}
+
+// Delete the value at the specified key
+func (s *redisStore) Delete(key string) error {
+	reply, err := s.do("DEL", key)
+	if err != nil {
+		return err
+	}
+	if reply == int64(0) {
+		return store.ErrKeyNotFound
+	}
+	return nil
+}
+
+// Exists verifies if a key exists in the store
+func (s *redisStore) Exists(key string, options *store.ReadOptions) (bool, error) {
+	reply, err := s.do("EXISTS", key)
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+// List the keys starting with the directory, and their values
+func (s *redisStore) List(directory string, options *store.ReadOptions) ([]*store.KVPair, error) {
+	keys, err := s.scan(directory)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+
+	reply, err := s.do(append([]string{"MGET"}, keys...)...)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != len(keys) {
+		return nil, traefikRedis.ErrUnexpectedReply
+	}
+
+	var pairs []*store.KVPair
+	for i, key := range keys {
+		// the keys deleted since the scan are skipped
+		if value, ok := values[i].([]byte); ok {
+			pairs = append(pairs, &store.KVPair{Key: key, Value: value})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return pairs, nil
+}
+
+// scan returns the sorted keys starting with the directory.
+func (s *redisStore) scan(directory string) ([]string, error) {
+	pattern := escapePattern(directory) + "*"
+
+	keys := make(map[string]struct{})
+	cursor := "0"
+	for {
+		reply, err := s.do("SCAN", cursor, "MATCH", pattern, "COUNT", scanCount)
+		if err != nil {
+			return nil, err
+		}
+		elements, ok := reply.([]interface{})
+		if !ok || len(elements) != 2 {
+			return nil, traefikRedis.ErrUnexpectedReply
+		}
+		if cursor, err = traefikRedis.ReplyString(elements[0]); err != nil {
+			return nil, err
+		}
+		batch, err := traefikRedis.ReplyStrings(elements[1])
+		if err != nil {
+			return nil, err
+		}
+		// a key can be returned several times by SCAN
+		for _, key := range batch {
+			keys[key] = struct{}{}
+		}
+		if cursor == "0" {
+			break
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	return sortedKeys, nil
+}
+
+// escapePattern escapes the special characters of the glob-style patterns of Redis.
+func escapePattern(value string) string {
+	var escaped bytes.Buffer
+	for _, char := range value {
+		if strings.ContainsRune(`*?[]\`, char) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
+
+// DeleteTree deletes the keys starting with the directory
+func (s *redisStore) DeleteTree(directory string) error {
+	keys, err := s.scan(directory)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	_, err = s.do(append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Watch for changes on a key
+func (s *redisStore) Watch(key string, stopCh <-chan struct{}, options *store.ReadOptions) (<-chan *store.KVPair, error) {
+	changes, err := s.watch(escapePattern(key), stopCh)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan *store.KVPair)
+	go func() {
+		defer close(events)
+		var previous *store.KVPair
+		for range changes {
+			pair, err := s.Get(key, options)
+			if err != nil && err != store.ErrKeyNotFound {
+				log.Errorf("Cannot get the Redis key %s: %v", key, err)
+				return
+			}
+			if previous != nil && reflect.DeepEqual(previous, pair) {
+				continue
+			}
+			previous = pair
+			select {
+			case events <- pair:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// WatchTree watches for changes on the keys starting with the directory
+func (s *redisStore) WatchTree(directory string, stopCh <-chan struct{}, options *store.ReadOptions) (<-chan []*store.KVPair, error) {
+	changes, err := s.watch(escapePattern(directory)+"*", stopCh)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan []*store.KVPair)
+	go func() {
+		defer close(events)
+		var previous []*store.KVPair
+		first := true
+		for range changes {
+			pairs, err := s.List(directory, options)
+			if err != nil && err != store.ErrKeyNotFound {
+				log.Errorf("Cannot list the Redis keys of %s: %v", directory, err)
+				return
+			}
+			if !first && reflect.DeepEqual(previous, pairs) {
+				continue
+			}
+			first = false
+			previous = pairs
+			select {
+			case events <- pairs:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// watch returns a channel receiving a value at once, then whenever a key matching the pattern may have changed.
+// The keyspace notifications are used when they are enabled on the server and no poll interval is set, otherwise the keys are polled.
+// The channel is closed once stopCh is closed, or after an error.
+func (s *redisStore) watch(pattern string, stopCh <-chan struct{}) (<-chan struct{}, error) {
+	pollInterval := s.config.PollInterval
+	if pollInterval <= 0 {
+		enabled, err := s.keyspaceNotificationsEnabled()
+		if err != nil {
+			log.Warnf("Cannot read the keyspace notifications configuration of Redis, polling the keys every %s: %v", defaultPollInterval, err)
+			pollInterval = defaultPollInterval
+		} else if !enabled {
+			log.Warnf("The keyspace notifications of the generic and string commands are not enabled on Redis, polling the keys every %s", defaultPollInterval)
+			pollInterval = defaultPollInterval
+		}
+	}
+
+	changes := make(chan struct{}, 1)
+	changes <- struct{}{}
+
+	if pollInterval > 0 {
+		go func() {
+			defer close(changes)
+			ticker := time.NewTicker(pollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stopCh:
+					return
+				case <-ticker.C:
+					notify(changes)
+				}
+			}
+		}()
+		return changes, nil
+	}
+
+	c, err := s.dial(s.config.ConnectionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	channel := "__keyspace@" + strconv.Itoa(s.config.DB) + "__:" + pattern
+	if _, err := c.Do("PSUBSCRIBE", channel); err != nil {
+		c.Close()
+		return nil, err
+	}
+	// the subscription connection waits for the notifications without timeout
+	c.SetTimeout(0)
+
+	go func() {
+		<-stopCh
+		c.Close()
+	}()
+	go func() {
+		defer close(changes)
+		defer c.Close()
+		for {
+			if _, err := c.Receive(); err != nil {
+				select {
+				case <-stopCh:
+				default:
+					log.Errorf("Error receiving the Redis keyspace notifications: %v", err)
+				}
+				return
+			}
+			notify(changes)
+		}
+	}()
+	return changes, nil
+}
+
+// notify sends a value on the channel, unless one is already pending.
+func notify(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// keyspaceNotificationsEnabled reports whether the server sends the keyspace notifications of the generic and string commands.
+func (s *redisStore) keyspaceNotificationsEnabled() (bool, error) {
+	reply, err := s.do("CONFIG", "GET", "notify-keyspace-events")
+	if err != nil {
+		return false, err
+	}
+	values, err := traefikRedis.ReplyStrings(reply)
+	if err != nil || len(values) != 2 {
+		return false, traefikRedis.ErrUnexpectedReply
+	}
+
+	flags := values[1]
+	return strings.Contains(flags, "K") && (strings.Contains(flags, "A") || strings.Contains(flags, "g") && strings.Contains(flags, "$")), nil
+}
+
+// NewLock is not supported
+func (s *redisStore) NewLock(key string, options *store.LockOptions) (store.Locker, error) {
+	return nil, store.ErrCallNotSupported
+}
+
+// AtomicPut is not supported
+func (s *redisStore) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
+	return false, nil, store.ErrCallNotSupported
+}
+
+// AtomicDelete is not supported
+func (s *redisStore) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	return false, store.ErrCallNotSupported
+}
+
+// Close the store connection
+func (s *redisStore) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
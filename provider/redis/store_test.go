@@ -0,0 +1,380 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abronan/valkeyrie/store"
+	"github.com/containous/traefik/provider/kv"
+	traefikRedis "github.com/containous/traefik/redis"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	server := startFakeServer(t, "secret", "")
+	defer server.Close()
+
+	s := newStore([]string{server.Addr()}, storeConfig{ConnectionTimeout: time.Second, Password: "wrong"})
+	_, err := s.Get("traefik/foo", nil)
+	assert.EqualError(t, err, "WRONGPASS invalid password")
+
+	s = newStore([]string{"127.0.0.1:1", server.Addr()}, storeConfig{ConnectionTimeout: time.Second, Password: "secret", DB: 2})
+	defer s.Close()
+
+	_, err = s.Get("traefik/foo", nil)
+	assert.Equal(t, store.ErrKeyNotFound, err)
+	_, err = s.List("traefik/", nil)
+	assert.Equal(t, store.ErrKeyNotFound, err)
+
+	require.NoError(t, s.Put("traefik/foo", []byte("bar"), nil))
+	require.NoError(t, s.Put("traefik/*/baz", []byte("qux"), nil))
+	require.NoError(t, s.Put("other/foo", []byte("bar"), nil))
+
+	pair, err := s.Get("traefik/foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, &store.KVPair{Key: "traefik/foo", Value: []byte("bar")}, pair)
+
+	exists, err := s.Exists("traefik/foo", nil)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	pairs, err := s.List("traefik/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.KVPair{
+		{Key: "traefik/*/baz", Value: []byte("qux")},
+		{Key: "traefik/foo", Value: []byte("bar")},
+	}, pairs)
+
+	pairs, err = s.List("traefik/*/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.KVPair{{Key: "traefik/*/baz", Value: []byte("qux")}}, pairs)
+
+	require.NoError(t, s.Delete("traefik/foo"))
+	assert.Equal(t, store.ErrKeyNotFound, s.Delete("traefik/foo"))
+	exists, err = s.Exists("traefik/foo", nil)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, s.DeleteTree("traefik/"))
+	_, err = s.List("traefik/", nil)
+	assert.Equal(t, store.ErrKeyNotFound, err)
+	_, err = s.Get("other/foo", nil)
+	assert.NoError(t, err)
+
+	_, err = s.NewLock("traefik/lock", nil)
+	assert.Equal(t, store.ErrCallNotSupported, err)
+}
+
+func TestStoreWatchTree(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		keyspaceEvents  string
+		pollInterval    time.Duration
+		expectedPolling bool
+	}{
+		{
+			desc:           "keyspace notifications",
+			keyspaceEvents: "KA",
+		},
+		{
+			desc:            "poll interval",
+			keyspaceEvents:  "KA",
+			pollInterval:    20 * time.Millisecond,
+			expectedPolling: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := startFakeServer(t, "", test.keyspaceEvents)
+			defer server.Close()
+
+			s := newStore([]string{server.Addr()}, storeConfig{ConnectionTimeout: time.Second, PollInterval: test.pollInterval})
+			defer s.Close()
+			require.NoError(t, s.Put("traefik/foo", []byte("bar"), nil))
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			events, err := s.WatchTree("traefik/", stopCh, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, []*store.KVPair{{Key: "traefik/foo", Value: []byte("bar")}}, receive(t, events))
+			assert.Equal(t, !test.expectedPolling, server.Subscribed())
+
+			require.NoError(t, s.Put("traefik/foo", []byte("baz"), nil))
+			assert.Equal(t, []*store.KVPair{{Key: "traefik/foo", Value: []byte("baz")}}, receive(t, events))
+
+			require.NoError(t, s.Delete("traefik/foo"))
+			assert.Nil(t, receive(t, events))
+		})
+	}
+}
+
+func TestStoreKeyspaceNotificationsEnabled(t *testing.T) {
+	testCases := []struct {
+		keyspaceEvents string
+		expected       bool
+	}{
+		{keyspaceEvents: "", expected: false},
+		{keyspaceEvents: "KA", expected: true},
+		{keyspaceEvents: "AKE", expected: true},
+		{keyspaceEvents: "K$g", expected: true},
+		{keyspaceEvents: "K$", expected: false},
+		{keyspaceEvents: "EA", expected: false},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.keyspaceEvents, func(t *testing.T) {
+			t.Parallel()
+
+			server := startFakeServer(t, "", test.keyspaceEvents)
+			defer server.Close()
+
+			s := newStore([]string{server.Addr()}, storeConfig{ConnectionTimeout: time.Second})
+			defer s.Close()
+
+			enabled, err := s.keyspaceNotificationsEnabled()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, enabled)
+		})
+	}
+}
+
+func TestProvide(t *testing.T) {
+	server := startFakeServer(t, "secret", "")
+	defer server.Close()
+
+	s := newStore([]string{server.Addr()}, storeConfig{ConnectionTimeout: time.Second, Password: "secret"})
+	defer s.Close()
+	for key, value := range map[string]string{
+		"traefik/backends/backend1/servers/server1/url":    "http://172.17.0.2:80",
+		"traefik/frontends/frontend1/backend":              "backend1",
+		"traefik/frontends/frontend1/routes/test_1/rule":   "Host:test.localhost",
+		"traefik/tls/snitest/entrypoints":                  "https",
+		"traefik/tls/snitest/certificate/certfile":         "integration/fixtures/https/snitest.com.cert",
+		"traefik/tls/snitest/certificate/keyfile":          "integration/fixtures/https/snitest.com.key",
+		"traefik/frontends/frontend1/routes/test_1/ignore": "",
+	} {
+		require.NoError(t, s.Put(key, []byte(value), nil))
+	}
+
+	p := &Provider{Provider: kv.Provider{Endpoint: server.Addr(), Prefix: "traefik", Password: "secret"}}
+	configurationChan := make(chan types.ConfigMessage, 1)
+	pool := safe.NewPool(context.Background())
+	defer pool.Cleanup()
+	require.NoError(t, p.Provide(configurationChan, pool, nil))
+
+	message := <-configurationChan
+	assert.Equal(t, "redis", message.ProviderName)
+	require.NotNil(t, message.Configuration)
+	assert.Equal(t, "http://172.17.0.2:80", message.Configuration.Backends["backend1"].Servers["server1"].URL)
+	assert.Equal(t, "backend1", message.Configuration.Frontends["frontend1"].Backend)
+	assert.Equal(t, "Host:test.localhost", message.Configuration.Frontends["frontend1"].Routes["test_1"].Rule)
+	require.Len(t, message.Configuration.TLS, 1)
+	assert.Equal(t, []string{"https"}, message.Configuration.TLS[0].EntryPoints)
+	assert.EqualValues(t, "integration/fixtures/https/snitest.com.cert", message.Configuration.TLS[0].Certificate.CertFile)
+}
+
+func receive(t *testing.T, events <-chan []*store.KVPair) []*store.KVPair {
+	select {
+	case pairs, ok := <-events:
+		require.True(t, ok, "events channel closed")
+		return pairs
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+		return nil
+	}
+}
+
+// fakeServer is a Redis server supporting the commands used by the store.
+type fakeServer struct {
+	listener       net.Listener
+	password       string
+	keyspaceEvents string
+
+	lock        sync.Mutex
+	data        map[string]string
+	subscribers map[*bufio.Writer]string
+}
+
+func startFakeServer(t *testing.T, password, keyspaceEvents string) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeServer{
+		listener:       listener,
+		password:       password,
+		keyspaceEvents: keyspaceEvents,
+		data:           make(map[string]string),
+		subscribers:    make(map[*bufio.Writer]string),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (f *fakeServer) Addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeServer) Close() {
+	f.listener.Close()
+}
+
+func (f *fakeServer) Subscribed() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.subscribers) > 0
+}
+
+func (f *fakeServer) serve(netConn net.Conn) {
+	defer netConn.Close()
+	c := traefikRedis.NewConn(netConn, 0)
+	writer := bufio.NewWriter(netConn)
+	defer func() {
+		f.lock.Lock()
+		delete(f.subscribers, writer)
+		f.lock.Unlock()
+	}()
+
+	authenticated := len(f.password) == 0
+	for {
+		request, err := c.Receive()
+		if err != nil {
+			return
+		}
+		args, err := traefikRedis.ReplyStrings(request)
+		if err != nil || len(args) == 0 {
+			return
+		}
+
+		f.lock.Lock()
+		command := strings.ToUpper(args[0])
+		switch {
+		case command == "AUTH":
+			authenticated = args[len(args)-1] == f.password
+			if authenticated {
+				writeReply(writer, "+OK")
+			} else {
+				writeReply(writer, "-WRONGPASS invalid password")
+			}
+		case !authenticated:
+			writeReply(writer, "-NOAUTH Authentication required.")
+		case command == "SELECT":
+			writeReply(writer, "+OK")
+		case command == "GET":
+			value, ok := f.data[args[1]]
+			writeBulk(writer, value, ok)
+		case command == "SET":
+			f.data[args[1]] = args[2]
+			f.publish(args[1], "set")
+			writeReply(writer, "+OK")
+		case command == "DEL":
+			var count int
+			for _, key := range args[1:] {
+				if _, ok := f.data[key]; ok {
+					delete(f.data, key)
+					f.publish(key, "del")
+					count++
+				}
+			}
+			writeReply(writer, ":"+strconv.Itoa(count))
+		case command == "EXISTS":
+			_, ok := f.data[args[1]]
+			if ok {
+				writeReply(writer, ":1")
+			} else {
+				writeReply(writer, ":0")
+			}
+		case command == "SCAN":
+			var keys []string
+			for key := range f.data {
+				if matchPattern(args[3], key) {
+					keys = append(keys, key)
+				}
+			}
+			writeReply(writer, "*2")
+			writeBulk(writer, "0", true)
+			writeReply(writer, "*"+strconv.Itoa(len(keys)))
+			for _, key := range keys {
+				writeBulk(writer, key, true)
+			}
+		case command == "MGET":
+			writeReply(writer, "*"+strconv.Itoa(len(args)-1))
+			for _, key := range args[1:] {
+				value, ok := f.data[key]
+				writeBulk(writer, value, ok)
+			}
+		case command == "CONFIG":
+			writeReply(writer, "*2")
+			writeBulk(writer, args[2], true)
+			writeBulk(writer, f.keyspaceEvents, true)
+		case command == "PSUBSCRIBE":
+			f.subscribers[writer] = args[1]
+			writeReply(writer, "*3")
+			writeBulk(writer, "psubscribe", true)
+			writeBulk(writer, args[1], true)
+			writeReply(writer, ":1")
+		default:
+			writeReply(writer, "-ERR unknown command")
+		}
+		writer.Flush()
+		f.lock.Unlock()
+	}
+}
+
+// publish sends the keyspace notification of the key to the subscribers, the lock being held.
+func (f *fakeServer) publish(key, event string) {
+	for writer, pattern := range f.subscribers {
+		channel := "__keyspace@0__:" + key
+		if !matchPattern(pattern, channel) {
+			continue
+		}
+		writeReply(writer, "*4")
+		writeBulk(writer, "pmessage", true)
+		writeBulk(writer, pattern, true)
+		writeBulk(writer, channel, true)
+		writeBulk(writer, event, true)
+		writer.Flush()
+	}
+}
+
+// matchPattern matches the patterns made of an escaped prefix followed by *.
+func matchPattern(pattern, value string) bool {
+	prefix := strings.TrimSuffix(pattern, "*")
+	prefix = strings.NewReplacer(`\*`, `*`, `\?`, `?`, `\[`, `[`, `\]`, `]`, `\\`, `\`).Replace(prefix)
+	return strings.HasPrefix(value, prefix)
+}
+
+func writeReply(writer *bufio.Writer, line string) {
+	writer.WriteString(line + "\r\n")
+}
+
+func writeBulk(writer *bufio.Writer, value string, ok bool) {
+	if !ok {
+		writeReply(writer, "$-1")
+		return
+	}
+	writeReply(writer, "$"+strconv.Itoa(len(value)))
+	writeReply(writer, value)
+}
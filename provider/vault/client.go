@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// client is a minimal client of the Vault HTTP API, authenticated with a token or AppRole.
+type client struct {
+	endpoint   string
+	httpClient *http.Client
+	appRole    *AppRole
+
+	lock            sync.Mutex
+	token           string
+	tokenExpiration time.Time
+}
+
+// secret is a response of the Vault API.
+type secret struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *secretAuth            `json:"auth"`
+	Errors        []string               `json:"errors"`
+}
+
+type secretAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+}
+
+// responseError is returned when Vault answers with an error status.
+type responseError struct {
+	statusCode int
+	errors     []string
+}
+
+func (e *responseError) Error() string {
+	if len(e.errors) == 0 {
+		return fmt.Sprintf("Vault responded with status %d", e.statusCode)
+	}
+	return fmt.Sprintf("Vault responded with status %d: %s", e.statusCode, strings.Join(e.errors, ", "))
+}
+
+// read returns the secret at path, or, when data is given, the secret generated by writing data at path,
+// as the PKI engine does to issue certificates.
+func (c *client) read(ctx context.Context, path string, data map[string]interface{}) (*secret, error) {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to authenticate: %v", err)
+	}
+
+	method := http.MethodGet
+	if data != nil {
+		method = http.MethodPost
+	}
+
+	result, err := c.do(ctx, method, path, token, data)
+	if respErr, ok := err.(*responseError); ok && respErr.statusCode == http.StatusForbidden {
+		// the token may have been revoked, a new one is requested on the next attempt
+		c.resetToken()
+	}
+	return result, err
+}
+
+func (c *client) getToken(ctx context.Context) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.appRole == nil || len(c.token) > 0 && (c.tokenExpiration.IsZero() || time.Now().Before(c.tokenExpiration)) {
+		return c.token, nil
+	}
+
+	mount := c.appRole.Mount
+	if len(mount) == 0 {
+		mount = "approle"
+	}
+
+	result, err := c.do(ctx, http.MethodPost, "auth/"+strings.Trim(mount, "/")+"/login", "", map[string]interface{}{
+		"role_id":   c.appRole.RoleID,
+		"secret_id": c.appRole.SecretID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Auth == nil || len(result.Auth.ClientToken) == 0 {
+		return "", fmt.Errorf("no token in the AppRole login response")
+	}
+
+	c.token = result.Auth.ClientToken
+	c.tokenExpiration = time.Time{}
+	if result.Auth.LeaseDuration > 0 {
+		// the token is renewed before it expires
+		c.tokenExpiration = time.Now().Add(renewalDelay(time.Duration(result.Auth.LeaseDuration) * time.Second))
+	}
+	return c.token, nil
+}
+
+func (c *client) resetToken() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.appRole != nil {
+		c.token = ""
+	}
+}
+
+func (c *client) do(ctx context.Context, method, path, token string, data map[string]interface{}) (*secret, error) {
+	var body io.Reader
+	if data != nil {
+		content, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.endpoint, "/")+"/v1/"+strings.TrimPrefix(path, "/"), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if len(token) > 0 {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &secret{}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, result); err != nil && resp.StatusCode < http.StatusBadRequest {
+			return nil, fmt.Errorf("invalid Vault response: %v", err)
+		}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &responseError{statusCode: resp.StatusCode, errors: result.Errors}
+	}
+	return result, nil
+}
@@ -0,0 +1,242 @@
+package vault
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/job"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	providerName = "vault"
+
+	// DefaultRefreshInterval is the maximum duration between two fetches of the certificates, when not configured.
+	DefaultRefreshInterval = time.Hour
+	// minRefreshInterval prevents fetching the certificates continuously when their leases are very short.
+	minRefreshInterval = 10 * time.Second
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+// Provider holds configuration of the Vault provider, serving TLS certificates read from Vault.
+type Provider struct {
+	provider.BaseProvider `mapstructure:",squash" export:"true"`
+	Endpoint              string           `description:"Vault server endpoint" export:"true"`
+	Token                 string           `description:"Vault token, VAULT_TOKEN if empty"`
+	AppRole               *AppRole         `description:"Enable AppRole authentication" export:"true"`
+	TLS                   *types.ClientTLS `description:"Enable TLS support" export:"true"`
+	RefreshInterval       flaeg.Duration   `description:"Maximum duration between two fetches of the certificates" export:"true"`
+	Certificates          []*Certificate   `export:"true"` // only configurable through the configuration file
+}
+
+// AppRole holds the AppRole authentication configuration
+type AppRole struct {
+	RoleID   string `description:"AppRole role ID" export:"true"`
+	SecretID string `description:"AppRole secret ID"`
+	Mount    string `description:"Mount path of the AppRole authentication method" export:"true"`
+}
+
+// Certificate holds the path of a certificate in Vault, and the entrypoints serving it.
+// A certificate with a common name is issued by the PKI engine, otherwise it is read from a secret
+// holding a certificate and a private_key.
+type Certificate struct {
+	Path        string
+	EntryPoints []string
+	CommonName  string
+	AltNames    []string
+	TTL         string
+}
+
+// Provide allows the Vault provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	vaultClient, err := p.createClient()
+	if err != nil {
+		return err
+	}
+
+	pool.Go(func(stop chan bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		safe.Go(func() {
+			<-stop
+			cancel()
+		})
+
+		for {
+			var configuration *types.Configuration
+			var renewal time.Duration
+			operation := func() error {
+				var err error
+				configuration, renewal, err = p.buildConfiguration(ctx, vaultClient)
+				if ctx.Err() != nil {
+					return backoff.Permanent(ctx.Err())
+				}
+				return err
+			}
+			notify := func(err error, time time.Duration) {
+				log.Errorf("Vault provider error: %s, retrying in %s", err, time)
+			}
+			err := backoff.RetryNotify(safe.OperationWithRecover(operation), backoff.WithContext(job.NewBackOff(backoff.NewExponentialBackOff()), ctx), notify)
+			if err != nil {
+				// the provider is stopped
+				return
+			}
+
+			configurationChan <- types.ConfigMessage{
+				ProviderName:  providerName,
+				Configuration: configuration,
+			}
+
+			log.Debugf("Renewing the Vault certificates in %s", renewal)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(renewal):
+			}
+		}
+	})
+	return nil
+}
+
+func (p *Provider) createClient() (*client, error) {
+	if len(p.Endpoint) == 0 {
+		return nil, errors.New("no Vault endpoint")
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if p.TLS != nil {
+		tlsConfig, err := p.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the Vault TLS configuration: %v", err)
+		}
+		httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	token := p.Token
+	if len(token) == 0 && p.AppRole == nil {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	return &client{
+		endpoint:   p.Endpoint,
+		httpClient: httpClient,
+		appRole:    p.AppRole,
+		token:      token,
+	}, nil
+}
+
+// buildConfiguration fetches the certificates, and returns the delay after which they must be fetched again.
+func (p *Provider) buildConfiguration(ctx context.Context, vaultClient *client) (*types.Configuration, time.Duration, error) {
+	renewal := time.Duration(p.RefreshInterval)
+	if renewal <= 0 {
+		renewal = DefaultRefreshInterval
+	}
+
+	configuration := &types.Configuration{}
+	for _, certificate := range p.Certificates {
+		tlsConfiguration, ttl, err := fetchCertificate(ctx, vaultClient, certificate)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to fetch the certificate %s: %v", certificate.Path, err)
+		}
+		configuration.TLS = append(configuration.TLS, tlsConfiguration)
+
+		if ttl > 0 && renewalDelay(ttl) < renewal {
+			renewal = renewalDelay(ttl)
+		}
+	}
+
+	if renewal < minRefreshInterval {
+		renewal = minRefreshInterval
+	}
+	return configuration, renewal, nil
+}
+
+// fetchCertificate returns the TLS configuration of the certificate, and the duration it is valid for.
+func fetchCertificate(ctx context.Context, vaultClient *client, certificate *Certificate) (*traefikTls.Configuration, time.Duration, error) {
+	var data map[string]interface{}
+	if len(certificate.CommonName) > 0 {
+		data = map[string]interface{}{"common_name": certificate.CommonName}
+		if len(certificate.AltNames) > 0 {
+			data["alt_names"] = strings.Join(certificate.AltNames, ",")
+		}
+		if len(certificate.TTL) > 0 {
+			data["ttl"] = certificate.TTL
+		}
+	}
+
+	result, err := vaultClient.read(ctx, certificate.Path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	secretData := result.Data
+	// the secrets of the version 2 of the KV engine are nested
+	if nested, ok := secretData["data"].(map[string]interface{}); ok {
+		secretData = nested
+	}
+
+	certPEM, _ := secretData["certificate"].(string)
+	keyPEM, _ := secretData["private_key"].(string)
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, 0, errors.New("no certificate or private_key in the secret")
+	}
+
+	chain := []string{strings.TrimSpace(certPEM)}
+	if caChain, ok := secretData["ca_chain"].([]interface{}); ok {
+		for _, ca := range caChain {
+			if caPEM, ok := ca.(string); ok {
+				chain = append(chain, strings.TrimSpace(caPEM))
+			}
+		}
+	} else if issuingCA, ok := secretData["issuing_ca"].(string); ok && len(issuingCA) > 0 {
+		chain = append(chain, strings.TrimSpace(issuingCA))
+	}
+
+	ttl := time.Duration(result.LeaseDuration) * time.Second
+	if ttl <= 0 {
+		ttl = certificateTTL(certPEM)
+	}
+
+	return &traefikTls.Configuration{
+		EntryPoints: certificate.EntryPoints,
+		Certificate: &traefikTls.Certificate{
+			CertFile: traefikTls.FileOrContent(strings.Join(chain, "\n") + "\n"),
+			KeyFile:  traefikTls.FileOrContent(keyPEM),
+		},
+	}, ttl, nil
+}
+
+// certificateTTL returns the remaining validity of the first certificate of certPEM, or 0 if it can't be parsed.
+func certificateTTL(certPEM string) time.Duration {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return 0
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return 0
+	}
+	return time.Until(cert.NotAfter)
+}
+
+// renewalDelay returns the delay after which a secret valid for ttl is renewed.
+func renewalDelay(ttl time.Duration) time.Duration {
+	return ttl * 2 / 3
+}
@@ -0,0 +1,279 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault serves a KV secret and a PKI role, to the clients logged in with AppRole.
+type fakeVault struct {
+	t        *testing.T
+	certPEM  string
+	keyPEM   string
+	lock     sync.Mutex
+	tokens   map[string]bool
+	logins   int
+	issued   []map[string]interface{}
+	failRead int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	certPEM, keyPEM, err := generate.KeyPair("vault.example.com", time.Now().Add(3*time.Hour))
+	require.NoError(t, err)
+
+	return &fakeVault{
+		t:       t,
+		certPEM: string(certPEM),
+		keyPEM:  string(keyPEM),
+		tokens:  make(map[string]bool),
+	}
+}
+
+func (v *fakeVault) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	var body map[string]interface{}
+	if req.Method == http.MethodPost {
+		require.NoError(v.t, json.NewDecoder(req.Body).Decode(&body))
+	}
+
+	if req.URL.Path == "/v1/auth/approle/login" {
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		v.logins++
+		token := "token" + string(rune('0'+v.logins))
+		v.tokens[token] = true
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600},
+		})
+		return
+	}
+
+	if !v.tokens[req.Header.Get("X-Vault-Token")] || v.failRead > 0 {
+		if v.failRead > 0 {
+			v.failRead--
+			// the token is revoked
+			delete(v.tokens, req.Header.Get("X-Vault-Token"))
+		}
+		rw.WriteHeader(http.StatusForbidden)
+		rw.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/v1/secret/data/certs/kv":
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{"certificate": v.certPEM, "private_key": v.keyPEM},
+			},
+		})
+	case req.Method == http.MethodPost && req.URL.Path == "/v1/pki/issue/web":
+		v.issued = append(v.issued, body)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"lease_duration": 60,
+			"data": map[string]interface{}{
+				"certificate": v.certPEM,
+				"private_key": v.keyPEM,
+				"ca_chain":    []string{"ca1", "ca2"},
+			},
+		})
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+func TestBuildConfiguration(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	p := &Provider{
+		Endpoint: server.URL,
+		AppRole:  &AppRole{RoleID: "role", SecretID: "secret"},
+		Certificates: []*Certificate{
+			{
+				Path:        "secret/data/certs/kv",
+				EntryPoints: []string{"https"},
+			},
+			{
+				Path:       "pki/issue/web",
+				CommonName: "vault.example.com",
+				AltNames:   []string{"www.vault.example.com", "api.vault.example.com"},
+				TTL:        "1m",
+			},
+		},
+	}
+
+	vaultClient, err := p.createClient()
+	require.NoError(t, err)
+
+	configuration, renewal, err := p.buildConfiguration(context.Background(), vaultClient)
+	require.NoError(t, err)
+
+	require.Len(t, configuration.TLS, 2)
+	assert.Equal(t, []string{"https"}, configuration.TLS[0].EntryPoints)
+	assert.Equal(t, vault.certPEM, configuration.TLS[0].Certificate.CertFile.String())
+	assert.Equal(t, vault.keyPEM, configuration.TLS[0].Certificate.KeyFile.String())
+	assert.Empty(t, configuration.TLS[1].EntryPoints)
+	assert.Equal(t, vault.certPEM+"ca1\nca2\n", configuration.TLS[1].Certificate.CertFile.String())
+
+	assert.Equal(t, []map[string]interface{}{
+		{"common_name": "vault.example.com", "alt_names": "www.vault.example.com,api.vault.example.com", "ttl": "1m"},
+	}, vault.issued)
+	// renewed at 2/3 of the lease of the issued certificate
+	assert.Equal(t, 40*time.Second, renewal)
+	assert.Equal(t, 1, vault.logins)
+
+	// a revoked token is replaced by logging in again on the next attempt
+	vault.failRead = 1
+	_, _, err = p.buildConfiguration(context.Background(), vaultClient)
+	assert.Error(t, err)
+	_, _, err = p.buildConfiguration(context.Background(), vaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, 2, vault.logins)
+}
+
+func TestBuildConfigurationRenewal(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	vault.tokens["static"] = true
+
+	testCases := []struct {
+		desc            string
+		refreshInterval time.Duration
+		expectedRenewal time.Duration
+	}{
+		{
+			desc:            "validity of the certificate",
+			expectedRenewal: 2 * time.Hour,
+		},
+		{
+			desc:            "shorter refresh interval",
+			refreshInterval: 30 * time.Minute,
+			expectedRenewal: 30 * time.Minute,
+		},
+		{
+			desc:            "minimum refresh interval",
+			refreshInterval: time.Second,
+			expectedRenewal: minRefreshInterval,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			p := &Provider{
+				Endpoint:        server.URL,
+				Token:           "static",
+				RefreshInterval: flaeg.Duration(test.refreshInterval),
+				Certificates:    []*Certificate{{Path: "secret/data/certs/kv"}},
+			}
+			if test.refreshInterval == 0 {
+				p.RefreshInterval = flaeg.Duration(3 * time.Hour)
+			}
+
+			vaultClient, err := p.createClient()
+			require.NoError(t, err)
+
+			_, renewal, err := p.buildConfiguration(context.Background(), vaultClient)
+			require.NoError(t, err)
+			// the certificate is valid for 3 hours, and renewed at 2/3 of its remaining validity
+			assert.InDelta(t, float64(test.expectedRenewal), float64(renewal), float64(time.Minute))
+		})
+	}
+}
+
+func TestBuildConfigurationErrors(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	testCases := []struct {
+		desc     string
+		provider *Provider
+	}{
+		{
+			desc: "invalid AppRole credentials",
+			provider: &Provider{
+				AppRole:      &AppRole{RoleID: "role", SecretID: "invalid"},
+				Certificates: []*Certificate{{Path: "secret/data/certs/kv"}},
+			},
+		},
+		{
+			desc: "invalid token",
+			provider: &Provider{
+				Token:        "invalid",
+				Certificates: []*Certificate{{Path: "secret/data/certs/kv"}},
+			},
+		},
+		{
+			desc: "unknown secret",
+			provider: &Provider{
+				AppRole:      &AppRole{RoleID: "role", SecretID: "secret"},
+				Certificates: []*Certificate{{Path: "secret/data/certs/unknown"}},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			test.provider.Endpoint = server.URL
+			vaultClient, err := test.provider.createClient()
+			require.NoError(t, err)
+
+			_, _, err = test.provider.buildConfiguration(context.Background(), vaultClient)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProvide(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	// the first reads fail, and are retried
+	vault.failRead = 2
+
+	p := &Provider{
+		Endpoint:     server.URL,
+		AppRole:      &AppRole{RoleID: "role", SecretID: "secret"},
+		Certificates: []*Certificate{{Path: "secret/data/certs/kv"}},
+	}
+
+	configurationChan := make(chan types.ConfigMessage, 1)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	require.NoError(t, p.Provide(configurationChan, pool, nil))
+
+	select {
+	case message := <-configurationChan:
+		assert.Equal(t, "vault", message.ProviderName)
+		require.Len(t, message.Configuration.TLS, 1)
+		assert.Equal(t, vault.certPEM, message.Configuration.TLS[0].Certificate.CertFile.String())
+	case <-time.After(10 * time.Second):
+		t.Fatal("no configuration provided")
+	}
+}
@@ -0,0 +1,284 @@
+// Package redis is a minimal client of the Redis servers, shared by the Redis provider and the distributed rate limits.
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+const (
+	// maxBulkSize is the maximum size of the bulk strings, which is the maximum size of the values of the Redis servers
+	maxBulkSize = 512 * 1024 * 1024
+	// maxArrayLen is the maximum number of elements of the arrays
+	maxArrayLen = 1024 * 1024
+	// maxArrayDepth is the maximum number of levels of the nested arrays
+	maxArrayDepth = 8
+)
+
+// Error is an error reply sent by the Redis server
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Options contains the options of the connections to the Redis servers.
+type Options struct {
+	TLS               *tls.Config
+	ConnectionTimeout time.Duration
+	Username          string
+	Password          string
+	DB                int
+}
+
+// Conn is a connection to a Redis server, speaking the RESP protocol.
+type Conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+// NewConn creates a Conn on a network connection, whose commands time out after timeout, if not zero.
+func NewConn(netConn net.Conn, timeout time.Duration) *Conn {
+	return &Conn{
+		Conn:    netConn,
+		reader:  bufio.NewReader(netConn),
+		timeout: timeout,
+	}
+}
+
+// Dial connects to the first reachable endpoint, then authenticates and selects the database.
+// The commands sent on the connection time out after timeout, if not zero.
+func Dial(endpoints []string, options Options, timeout time.Duration) (*Conn, error) {
+	var netConn net.Conn
+	var err error
+	for _, endpoint := range endpoints {
+		dialer := &net.Dialer{Timeout: options.ConnectionTimeout}
+		if options.TLS != nil {
+			netConn, err = tls.DialWithDialer(dialer, "tcp", strings.TrimSpace(endpoint), options.TLS)
+		} else {
+			netConn, err = dialer.Dial("tcp", strings.TrimSpace(endpoint))
+		}
+		if err == nil {
+			break
+		}
+		log.Debugf("Cannot connect to Redis server %s: %v", endpoint, err)
+	}
+	if netConn == nil {
+		if err == nil {
+			err = errors.New("no endpoint defined")
+		}
+		return nil, err
+	}
+
+	c := NewConn(netConn, timeout)
+	if len(options.Password) > 0 {
+		args := []string{"AUTH", options.Password}
+		if len(options.Username) > 0 {
+			args = []string{"AUTH", options.Username, options.Password}
+		}
+		if _, err := c.Do(args...); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if options.DB != 0 {
+		if _, err := c.Do("SELECT", strconv.Itoa(options.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// SetTimeout changes the timeout of the commands, zero disabling it.
+func (c *Conn) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Do sends a command and reads its reply, the whole exchange timing out after the timeout of the connection, if not zero.
+// The replies are strings for the simple strings, int64 for the integers, []byte or nil for the bulk strings,
+// and []interface{} or nil for the arrays. The error replies are returned as an Error.
+func (c *Conn) Do(args ...string) (interface{}, error) {
+	if c.timeout > 0 {
+		c.SetDeadline(time.Now().Add(c.timeout))
+		defer c.SetDeadline(time.Time{})
+	}
+
+	if err := c.Send(args...); err != nil {
+		return nil, err
+	}
+	reply, err := c.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := reply.(Error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Pipeline sends several commands at once, then reads their replies, the whole exchange timing out after the timeout of the connection, if not zero.
+// The error replies are returned as an Error in the replies.
+func (c *Conn) Pipeline(commands ...[]string) ([]interface{}, error) {
+	if c.timeout > 0 {
+		c.SetDeadline(time.Now().Add(c.timeout))
+		defer c.SetDeadline(time.Time{})
+	}
+
+	var buf []byte
+	for _, args := range commands {
+		buf = appendCommand(buf, args)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		reply, err := c.Receive()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// Send sends a command without reading its reply.
+func (c *Conn) Send(args ...string) error {
+	_, err := c.Write(appendCommand(nil, args))
+	return err
+}
+
+func appendCommand(buf []byte, args []string) []byte {
+	buf = append(buf, "*"+strconv.Itoa(len(args))+"\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// Receive reads a reply, or a command sent to a server. The error replies are not returned as errors.
+func (c *Conn) Receive() (interface{}, error) {
+	return c.receive(0)
+}
+
+func (c *Conn) receive(depth int) (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return Error(value), nil
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		size, err := parseSize(line, value, maxBulkSize)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		size, err := parseSize(line, value, maxArrayLen)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		if depth >= maxArrayDepth {
+			return nil, fmt.Errorf("invalid reply %q: arrays nested deeper than %d levels", line, maxArrayDepth)
+		}
+		elements := make([]interface{}, size)
+		for i := range elements {
+			if elements[i], err = c.receive(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return elements, nil
+	default:
+		return nil, fmt.Errorf("invalid reply %q", line)
+	}
+}
+
+// parseSize parses the size of a bulk string or an array, -1 standing for a nil reply.
+// The sizes above max are rejected, rather than allocating the memory announced by the server.
+func parseSize(line, value string, max int) (int, error) {
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reply %q: %v", line, err)
+	}
+	if size < -1 || size > max {
+		return 0, fmt.Errorf("invalid reply %q: size out of range [-1, %d]", line, max)
+	}
+	return size, nil
+}
+
+// ErrUnexpectedReply is returned when a reply is not of the expected type
+var ErrUnexpectedReply = errors.New("unexpected reply")
+
+// ReplyString converts a simple or bulk string reply to a string.
+func ReplyString(reply interface{}) (string, error) {
+	switch value := reply.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	default:
+		return "", ErrUnexpectedReply
+	}
+}
+
+// ReplyStrings converts an array reply of strings to a slice of strings.
+func ReplyStrings(reply interface{}) ([]string, error) {
+	elements, ok := reply.([]interface{})
+	if !ok {
+		return nil, ErrUnexpectedReply
+	}
+
+	values := make([]string, len(elements))
+	for i, element := range elements {
+		value, err := ReplyString(element)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ReplyInt converts an integer reply, or a bulk string reply holding an integer, to an int64.
+// A nil bulk string, sent for a missing key, is converted to 0.
+func ReplyInt(reply interface{}) (int64, error) {
+	switch value := reply.(type) {
+	case int64:
+		return value, nil
+	case []byte:
+		return strconv.ParseInt(string(value), 10, 64)
+	case nil:
+		return 0, nil
+	default:
+		return 0, ErrUnexpectedReply
+	}
+}
@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		c := NewConn(server, 0)
+		writer := bufio.NewWriter(server)
+		for _, reply := range []string{":2\r\n", "$-1\r\n", "-ERR wrong type\r\n", "*2\r\n$3\r\nfoo\r\n+bar\r\n"} {
+			if _, err := c.Receive(); err != nil {
+				return
+			}
+			writer.WriteString(reply)
+		}
+		writer.Flush()
+	}()
+
+	c := NewConn(client, time.Second)
+	replies, err := c.Pipeline(
+		[]string{"INCRBY", "counter", "2"},
+		[]string{"GET", "missing"},
+		[]string{"GET", "list"},
+		[]string{"MGET", "foo", "bar"})
+	require.NoError(t, err)
+	require.Len(t, replies, 4)
+
+	count, err := ReplyInt(replies[0])
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	count, err = ReplyInt(replies[1])
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	assert.Equal(t, Error("ERR wrong type"), replies[2])
+
+	values, err := ReplyStrings(replies[3])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, values)
+}
+
+func TestReplyInt(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		reply         interface{}
+		expectedValue int64
+		expectedError bool
+	}{
+		{
+			desc:          "integer",
+			reply:         int64(42),
+			expectedValue: 42,
+		},
+		{
+			desc:          "bulk string",
+			reply:         []byte("42"),
+			expectedValue: 42,
+		},
+		{
+			desc:          "nil bulk string",
+			expectedValue: 0,
+		},
+		{
+			desc:          "invalid bulk string",
+			reply:         []byte("foo"),
+			expectedError: true,
+		},
+		{
+			desc:          "array",
+			reply:         []interface{}{int64(42)},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := ReplyInt(test.reply)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedValue, value)
+		})
+	}
+}
+
+func TestReceive(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		reply         string
+		expectedReply interface{}
+		expectedError bool
+	}{
+		{
+			desc:          "bulk string",
+			reply:         "$3\r\nfoo\r\n",
+			expectedReply: []byte("foo"),
+		},
+		{
+			desc:  "nil bulk string",
+			reply: "$-1\r\n",
+		},
+		{
+			desc:          "negative bulk string size",
+			reply:         "$-2\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "bulk string too large",
+			reply:         "$536870913\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "invalid bulk string size",
+			reply:         "$foo\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "array",
+			reply:         "*2\r\n:1\r\n*1\r\n+foo\r\n",
+			expectedReply: []interface{}{int64(1), []interface{}{"foo"}},
+		},
+		{
+			desc:  "nil array",
+			reply: "*-1\r\n",
+		},
+		{
+			desc:          "negative array size",
+			reply:         "*-2\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "array too large",
+			reply:         "*9223372036854775807\r\n",
+			expectedError: true,
+		},
+		{
+			desc:          "arrays nested too deep",
+			reply:         strings.Repeat("*1\r\n", 9) + "+foo\r\n",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Conn{reader: bufio.NewReader(strings.NewReader(test.reply))}
+			reply, err := c.Receive()
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedReply, reply)
+		})
+	}
+}
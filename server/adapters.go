@@ -2,8 +2,52 @@ package server
 
 import (
 	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/containous/mux"
 )
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
+
+// methodNotAllowedHandler replies to the requests matching the rules of a frontend except its methods,
+// listing in the Allow header the methods of the frontends matching the request.
+func methodNotAllowedHandler(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowedMethods []string
+		// the routes of the frontends are checked as a whole, their sub-routers are skipped
+		router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			methods, err := route.GetMethods()
+			if err != nil {
+				return mux.SkipRouter
+			}
+
+			for _, method := range methods {
+				if containsMethod(allowedMethods, method) {
+					continue
+				}
+				req := *r
+				req.Method = method
+				if route.Match(&req, &mux.RouteMatch{}) {
+					allowedMethods = append(allowedMethods, method)
+				}
+			}
+			return mux.SkipRouter
+		})
+
+		sort.Strings(allowedMethods)
+		w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/containous/traefik/types"
+)
+
+// backendTLSConfig holds the CA pool verifying the certificates of the servers of a backend,
+// and the client certificate presented to them.
+type backendTLSConfig struct {
+	rootCAs     *x509.CertPool
+	certificate *tls.Certificate
+}
+
+func newBackendTLSConfig(backendTLS *types.BackendTLS) (*backendTLSConfig, error) {
+	config := &backendTLSConfig{}
+
+	if len(backendTLS.CA) > 0 {
+		ca, err := backendTLS.CA.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the backend CA: %v", err)
+		}
+		config.rootCAs = x509.NewCertPool()
+		if !config.rootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid certificate(s) in the backend CA")
+		}
+	}
+
+	if len(backendTLS.Cert) > 0 || len(backendTLS.Key) > 0 {
+		if len(backendTLS.Cert) == 0 || len(backendTLS.Key) == 0 {
+			return nil, errors.New("both the backend client certificate and key must be set")
+		}
+		cert, err := backendTLS.Cert.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the backend client certificate: %v", err)
+		}
+		key, err := backendTLS.Key.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the backend client key: %v", err)
+		}
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the backend client certificate: %v", err)
+		}
+		config.certificate = &certificate
+	}
+
+	return config, nil
+}
+
+// apply returns a copy of tlsConfig verifying the servers with the CA pool of the backend,
+// and presenting its client certificate.
+func (c *backendTLSConfig) apply(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if c.rootCAs != nil {
+		tlsConfig.RootCAs = c.rootCAs
+	}
+	if c.certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*c.certificate}
+		tlsConfig.NameToCertificate = nil
+	}
+	return tlsConfig
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBackendTLSConfig(t *testing.T) {
+	cert, key, err := generate.KeyPair("backend.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                string
+		backendTLS          *types.BackendTLS
+		expectedRootCAs     bool
+		expectedCertificate bool
+		expectedError       bool
+	}{
+		{
+			desc:            "CA only",
+			backendTLS:      &types.BackendTLS{CA: traefikTls.FileOrContent(cert)},
+			expectedRootCAs: true,
+		},
+		{
+			desc:                "client certificate only",
+			backendTLS:          &types.BackendTLS{Cert: traefikTls.FileOrContent(cert), Key: traefikTls.FileOrContent(key)},
+			expectedCertificate: true,
+		},
+		{
+			desc:          "invalid CA",
+			backendTLS:    &types.BackendTLS{CA: "foo"},
+			expectedError: true,
+		},
+		{
+			desc:          "client certificate without key",
+			backendTLS:    &types.BackendTLS{Cert: traefikTls.FileOrContent(cert)},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid client certificate",
+			backendTLS:    &types.BackendTLS{Cert: "foo", Key: traefikTls.FileOrContent(key)},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := newBackendTLSConfig(test.backendTLS)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			tlsConfig := config.apply(nil)
+			assert.Equal(t, test.expectedRootCAs, tlsConfig.RootCAs != nil)
+			assert.Equal(t, test.expectedCertificate, len(tlsConfig.Certificates) == 1)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// connectionTrackerPollInterval is the interval at which the remaining connections are checked during the shutdown
+const connectionTrackerPollInterval = 100 * time.Millisecond
+
+// connectionTracker is a listener tracking the connections it accepts until they are closed.
+// It allows to close at the end of the grace period the hijacked connections, such as the WebSockets,
+// which are neither waited for nor closed by the HTTP server.
+type connectionTracker struct {
+	net.Listener
+	lock  sync.Mutex
+	conns map[*trackedConn]struct{}
+}
+
+func newConnectionTracker(listener net.Listener) *connectionTracker {
+	return &connectionTracker{
+		Listener: listener,
+		conns:    make(map[*trackedConn]struct{}),
+	}
+}
+
+// Accept waits for and returns the next connection, which is tracked until it is closed.
+func (t *connectionTracker) Accept() (net.Conn, error) {
+	conn, err := t.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := &trackedConn{Conn: conn, tracker: t}
+	t.lock.Lock()
+	t.conns[tracked] = struct{}{}
+	t.lock.Unlock()
+	return tracked, nil
+}
+
+// Shutdown waits for the open connections to be closed, or closes them once the context is done.
+func (t *connectionTracker) Shutdown(ctx context.Context) error {
+	ticker := time.NewTicker(connectionTrackerPollInterval)
+	defer ticker.Stop()
+	for {
+		if t.count() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			t.closeAll()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *connectionTracker) count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.conns)
+}
+
+func (t *connectionTracker) closeAll() {
+	t.lock.Lock()
+	conns := make([]*trackedConn, 0, len(t.conns))
+	for conn := range t.conns {
+		conns = append(conns, conn)
+	}
+	t.lock.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+func (t *connectionTracker) remove(conn *trackedConn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.conns, conn)
+}
+
+// trackedConn is a connection removed from its tracker once closed.
+type trackedConn struct {
+	net.Conn
+	tracker *connectionTracker
+}
+
+func (c *trackedConn) Close() error {
+	c.tracker.remove(c)
+	return c.Conn.Close()
+}
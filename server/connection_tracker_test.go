@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionTrackerClosesHijackedConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	connections := newConnectionTracker(listener)
+
+	hijacked := make(chan struct{})
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			conn, _, err := rw.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+			close(hijacked)
+		}),
+	}
+	go server.Serve(connections)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: foo.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+	require.NoError(t, err)
+	<-hijacked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// the HTTP server neither waits for nor closes the hijacked connections
+	require.NoError(t, server.Shutdown(ctx))
+	assert.Equal(t, 1, connections.count())
+
+	assert.Equal(t, context.DeadlineExceeded, connections.Shutdown(ctx))
+	assert.Equal(t, 0, connections.count())
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	data, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n\r\n", string(data))
+}
+
+func TestConnectionTrackerWaitsForConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	connections := newConnectionTracker(listener)
+	defer connections.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := connections.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn := <-accepted
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		conn.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, connections.Shutdown(ctx))
+	assert.Equal(t, 0, connections.count())
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+// defaultDNSRefreshInterval is the interval between the resolutions of a server hostname, when not configured.
+const defaultDNSRefreshInterval = 30 * time.Second
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dnsRefreshTransport resolves the hostnames of the servers itself, once every interval, and dials the resolved addresses.
+// When the addresses of a hostname change, the round-tripper is recreated, so that the following requests are sent over
+// connections to the new addresses, the connections of the previous round-tripper being closed once idle.
+type dnsRefreshTransport struct {
+	interval   time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+
+	newRoundTripper func() (http.RoundTripper, error)
+	roundTripperMu  sync.RWMutex
+	roundTripper    http.RoundTripper
+
+	hostsMu sync.Mutex
+	hosts   map[string]*resolvedHost
+}
+
+type resolvedHost struct {
+	addrs      []string
+	expiration time.Time
+	resolving  bool
+}
+
+// newDNSRefreshTransport creates a dnsRefreshTransport resolving the hostnames once every interval.
+// Its round-tripper must be created with start, once the dialers of the transports are wrapped by dialContext.
+func newDNSRefreshTransport(interval time.Duration) *dnsRefreshTransport {
+	if interval <= 0 {
+		interval = defaultDNSRefreshInterval
+	}
+
+	return &dnsRefreshTransport{
+		interval:   interval,
+		lookupHost: net.DefaultResolver.LookupHost,
+		hosts:      make(map[string]*resolvedHost),
+	}
+}
+
+// start creates the round-tripper with newRoundTripper, which is called again each time it must be recreated.
+func (t *dnsRefreshTransport) start(newRoundTripper func() (http.RoundTripper, error)) error {
+	roundTripper, err := newRoundTripper()
+	if err != nil {
+		return err
+	}
+
+	t.newRoundTripper = newRoundTripper
+	t.roundTripper = roundTripper
+	return nil
+}
+
+func (t *dnsRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.refresh(req.Context(), req.URL.Hostname()) {
+		t.recreateRoundTripper()
+	}
+
+	t.roundTripperMu.RLock()
+	roundTripper := t.roundTripper
+	t.roundTripperMu.RUnlock()
+
+	return roundTripper.RoundTrip(req)
+}
+
+// websocketTLSClientConfig returns the TLS configuration used to reach the wss servers.
+// The websocket connections are dialed by the forwarder, resolving the hostnames of the servers with the default resolver.
+func (t *dnsRefreshTransport) websocketTLSClientConfig() *tls.Config {
+	t.roundTripperMu.RLock()
+	defer t.roundTripperMu.RUnlock()
+
+	switch roundTripper := t.roundTripper.(type) {
+	case interface{ websocketTLSClientConfig() *tls.Config }:
+		return roundTripper.websocketTLSClientConfig()
+	case *http.Transport:
+		if roundTripper.TLSClientConfig != nil {
+			return roundTripper.TLSClientConfig
+		}
+	}
+	return &tls.Config{}
+}
+
+func (t *dnsRefreshTransport) recreateRoundTripper() {
+	roundTripper, err := t.newRoundTripper()
+	if err != nil {
+		log.Errorf("Failed to recreate the round-tripper after a DNS change: %v", err)
+		return
+	}
+
+	t.roundTripperMu.Lock()
+	previous := t.roundTripper
+	t.roundTripper = roundTripper
+	t.roundTripperMu.Unlock()
+
+	if closer, ok := previous.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// refresh resolves host if its addresses are unknown or expired, and returns whether they changed.
+// Only the first resolution of a host blocks the requests, the expired addresses being used while they are refreshed.
+func (t *dnsRefreshTransport) refresh(ctx context.Context, host string) bool {
+	if host == "" || net.ParseIP(host) != nil {
+		return false
+	}
+
+	t.hostsMu.Lock()
+	resolved, ok := t.hosts[host]
+	if ok && (resolved.resolving || time.Now().Before(resolved.expiration)) {
+		t.hostsMu.Unlock()
+		return false
+	}
+	if !ok {
+		resolved = &resolvedHost{}
+		t.hosts[host] = resolved
+	}
+	resolved.resolving = true
+	t.hostsMu.Unlock()
+
+	addrs, err := t.lookupHost(ctx, host)
+	sort.Strings(addrs)
+
+	t.hostsMu.Lock()
+	defer t.hostsMu.Unlock()
+
+	resolved.resolving = false
+	resolved.expiration = time.Now().Add(t.interval)
+	if err != nil || len(addrs) == 0 {
+		// the previous addresses are kept until the next resolution
+		log.Warnf("Unable to resolve %s: %v", host, err)
+		return false
+	}
+
+	changed := len(resolved.addrs) > 0 && !equalAddrs(resolved.addrs, addrs)
+	if changed {
+		log.Debugf("Addresses of %s changed from %v to %v", host, resolved.addrs, addrs)
+	}
+	resolved.addrs = addrs
+	return changed
+}
+
+func (t *dnsRefreshTransport) getAddrs(host string) []string {
+	t.hostsMu.Lock()
+	defer t.hostsMu.Unlock()
+
+	if resolved, ok := t.hosts[host]; ok {
+		return resolved.addrs
+	}
+	return nil
+}
+
+// dialContext wraps dial to dial the resolved addresses of the hostnames, one after the other until a connection succeeds.
+// The hostnames which have not been resolved yet are dialed as is.
+func (t *dnsRefreshTransport) dialContext(dial dialContextFunc) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dial(ctx, network, addr)
+		}
+
+		addrs := t.getAddrs(host)
+		if len(addrs) == 0 {
+			return dial(ctx, network, addr)
+		}
+
+		var dialErr error
+		for _, ip := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+		}
+		return nil, fmt.Errorf("unable to dial any address of %s: %v", host, dialErr)
+	}
+}
+
+func equalAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
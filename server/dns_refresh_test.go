@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSRefreshTransport(t *testing.T) {
+	var closedLock sync.Mutex
+	closed := make(map[string]bool)
+	newServer := func(name string) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(name))
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed {
+				closedLock.Lock()
+				closed[name] = true
+				closedLock.Unlock()
+			}
+		}
+		server.Start()
+		return server
+	}
+
+	server1 := newServer("server1")
+	defer server1.Close()
+	server2 := newServer("server2")
+	defer server2.Close()
+
+	// the resolved addresses are routed to the test servers
+	routes := map[string]string{
+		"10.0.0.1:80": server1.Listener.Addr().String(),
+		"10.0.0.2:80": server2.Listener.Addr().String(),
+	}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		route, ok := routes[addr]
+		if !ok {
+			return nil, errors.New("no route to " + addr)
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, route)
+	}
+
+	var lookupLock sync.Mutex
+	var lookups int
+	addrs := []string{"10.0.0.1"}
+	var lookupErr error
+
+	transport := newDNSRefreshTransport(time.Millisecond)
+	transport.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		lookupLock.Lock()
+		defer lookupLock.Unlock()
+
+		assert.Equal(t, "backend.example.com", host)
+		lookups++
+		return addrs, lookupErr
+	}
+	err := transport.start(func() (http.RoundTripper, error) {
+		return &http.Transport{DialContext: transport.dialContext(dial)}, nil
+	})
+	require.NoError(t, err)
+
+	get := func() string {
+		req := httptest.NewRequest(http.MethodGet, "http://backend.example.com", nil)
+		req.RequestURI = ""
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "server1", get())
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "server1", get())
+
+	// a failed resolution keeps the previous addresses
+	lookupLock.Lock()
+	lookupErr = errors.New("lookup failure")
+	lookupLock.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "server1", get())
+
+	lookupLock.Lock()
+	addrs = []string{"10.0.0.2"}
+	lookupErr = nil
+	lookupLock.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "server2", get())
+
+	lookupLock.Lock()
+	assert.Equal(t, 4, lookups)
+	lookupLock.Unlock()
+
+	// the idle connection to the previous address is closed
+	isClosed := func() bool {
+		closedLock.Lock()
+		defer closedLock.Unlock()
+		return closed["server1"]
+	}
+	for deadline := time.Now().Add(time.Second); !isClosed() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, isClosed())
+}
+
+func TestDNSRefreshTransportIPHost(t *testing.T) {
+	transport := newDNSRefreshTransport(0)
+	transport.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		t.Errorf("unexpected resolution of %s", host)
+		return nil, nil
+	}
+
+	assert.Equal(t, defaultDNSRefreshInterval, transport.interval)
+	assert.False(t, transport.refresh(context.Background(), "10.0.0.1"))
+	assert.False(t, transport.refresh(context.Background(), "::1"))
+}
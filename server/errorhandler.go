@@ -1,29 +1,58 @@
 package server
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
 
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
 )
 
+// StatusClientClosedRequest is the non-standard status code used by default when the client
+// closes the connection before the backend response is received.
+const StatusClientClosedRequest = configuration.StatusClientClosedRequest
+
+// StatusClientClosedRequestText is the text associated with StatusClientClosedRequest.
+const StatusClientClosedRequestText = "Client Closed Request"
+
 // RecordingErrorHandler is an error handler, implementing the vulcand/oxy
 // error handler interface, which is recording network errors by using the netErrorRecorder.
 // In addition it sets a proper HTTP status code and body, depending on the type of error occurred.
 type RecordingErrorHandler struct {
-	netErrorRecorder middlewares.NetErrorRecorder
+	netErrorRecorder          middlewares.NetErrorRecorder
+	clientClosedRequestStatus int
 }
 
 // NewRecordingErrorHandler creates and returns a new instance of RecordingErrorHandler.
-func NewRecordingErrorHandler(recorder middlewares.NetErrorRecorder) *RecordingErrorHandler {
-	return &RecordingErrorHandler{recorder}
+// The requests whose client closed the connection get the clientClosedRequestStatus status code,
+// or StatusClientClosedRequest when it is zero.
+func NewRecordingErrorHandler(recorder middlewares.NetErrorRecorder, clientClosedRequestStatus int) *RecordingErrorHandler {
+	if clientClosedRequestStatus == 0 {
+		clientClosedRequestStatus = StatusClientClosedRequest
+	}
+	return &RecordingErrorHandler{netErrorRecorder: recorder, clientClosedRequestStatus: clientClosedRequestStatus}
 }
 
 func (eh *RecordingErrorHandler) ServeHTTP(w http.ResponseWriter, req *http.Request, err error) {
+	if req.Context().Err() == context.Canceled {
+		log.Debugf("client_closed_request: %s %s: %v", req.Method, req.URL, err)
+		statusText := http.StatusText(eh.clientClosedRequestStatus)
+		if eh.clientClosedRequestStatus == StatusClientClosedRequest {
+			statusText = StatusClientClosedRequestText
+		}
+		eh.writeResponse(w, req, eh.clientClosedRequestStatus, statusText)
+		return
+	}
+
 	statusCode := http.StatusInternalServerError
 
-	if e, ok := err.(net.Error); ok {
+	if req.Context().Err() == context.DeadlineExceeded {
+		// the request timeout of the frontend is exceeded
+		statusCode = http.StatusGatewayTimeout
+	} else if e, ok := err.(net.Error); ok {
 		eh.netErrorRecorder.Record(req.Context())
 		if e.Timeout() {
 			statusCode = http.StatusGatewayTimeout
@@ -35,6 +64,12 @@ func (eh *RecordingErrorHandler) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		statusCode = http.StatusBadGateway
 	}
 
+	eh.writeResponse(w, req, statusCode, http.StatusText(statusCode))
+}
+
+func (eh *RecordingErrorHandler) writeResponse(w http.ResponseWriter, req *http.Request, statusCode int, statusText string) {
 	w.WriteHeader(statusCode)
-	w.Write([]byte(http.StatusText(statusCode)))
+	if _, err := w.Write([]byte(statusText)); err != nil {
+		log.Debugf("Error while writing the %d response of %s %s: %v", statusCode, req.Method, req.URL, err)
+	}
 }
@@ -18,10 +18,12 @@ func (e *timeoutError) Temporary() bool { return true }
 
 func TestServeHTTP(t *testing.T) {
 	tests := []struct {
-		name               string
-		err                error
-		wantHTTPStatus     int
-		wantNetErrRecorded bool
+		name                      string
+		err                       error
+		cancelRequest             bool
+		clientClosedRequestStatus int
+		wantHTTPStatus            int
+		wantNetErrRecorded        bool
 	}{
 		{
 			name:               "net.Error",
@@ -47,6 +49,21 @@ func TestServeHTTP(t *testing.T) {
 			wantHTTPStatus:     http.StatusInternalServerError,
 			wantNetErrRecorded: false,
 		},
+		{
+			name:               "client closed request",
+			err:                context.Canceled,
+			cancelRequest:      true,
+			wantHTTPStatus:     StatusClientClosedRequest,
+			wantNetErrRecorded: false,
+		},
+		{
+			name:                      "client closed request with bad gateway status",
+			err:                       context.Canceled,
+			cancelRequest:             true,
+			clientClosedRequestStatus: http.StatusBadGateway,
+			wantHTTPStatus:            http.StatusBadGateway,
+			wantNetErrRecorded:        false,
+		},
 		{
 			name:               "nil error",
 			err:                nil,
@@ -65,8 +82,13 @@ func TestServeHTTP(t *testing.T) {
 
 			errorRecorder := &netErrorRecorder{}
 			req := httptest.NewRequest(http.MethodGet, "http://localhost:3000/any", nil)
+			if test.cancelRequest {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
 
-			recordingErrorHandler := NewRecordingErrorHandler(errorRecorder)
+			recordingErrorHandler := NewRecordingErrorHandler(errorRecorder, test.clientClosedRequestStatus)
 			recordingErrorHandler.ServeHTTP(recorder, req, test.err)
 
 			if recorder.Code != test.wantHTTPStatus {
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+
+	"github.com/containous/traefik/healthcheck"
+	"github.com/containous/traefik/middlewares"
+	"github.com/urfave/negroni"
+	"github.com/vulcand/oxy/forward"
+)
+
+// frontendForwardingCtxKey is a custom type that is used as key for the context.
+type frontendForwardingCtxKey string
+
+// defaultFrontendForwardingCtxKey is the actual key which value holds the forwarding options of the frontend of a request.
+var defaultFrontendForwardingCtxKey frontendForwardingCtxKey = "FrontendForwardingCtxKey"
+
+// sharedBackend holds the handlers of a backend on an entrypoint, shared by the frontends forwarding their requests to it
+type sharedBackend struct {
+	// handler sends the requests to the servers, through the circuit breaker, retry and connection limit of the backend
+	handler http.Handler
+	// loadBalancer selects the server of the requests
+	loadBalancer http.Handler
+	// forwarder sends the requests to the server already selected
+	forwarder http.Handler
+	servers   healthcheck.LoadBalancer
+}
+
+// frontendForwarding holds the options of a frontend applied when its requests are forwarded by the shared backends
+type frontendForwarding struct {
+	responseModifier func(*http.Response) error
+	hostHeader       string
+	passHostHeader   bool
+	noServer         http.Handler
+	backends         map[string]*frontendBackend
+}
+
+// frontendBackend holds the options of a frontend for one of its backends
+type frontendBackend struct {
+	// roundTripper replaces the transport of the backend when set
+	roundTripper http.RoundTripper
+	tracing      negroni.Handler
+	// loadBalancer replaces the load balancer of the backend when set, to override the servers of some requests
+	loadBalancer http.Handler
+}
+
+// newFrontendForwardingHandler adds the forwarding options of a frontend to the context of its requests
+func newFrontendForwardingHandler(next http.Handler, forwarding *frontendForwarding) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), defaultFrontendForwardingCtxKey, forwarding)
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+// getFrontendForwarding returns the forwarding options of the frontend of a request, or nil if there are none
+func getFrontendForwarding(ctx context.Context) *frontendForwarding {
+	forwarding, _ := ctx.Value(defaultFrontendForwardingCtxKey).(*frontendForwarding)
+	return forwarding
+}
+
+// getFrontendBackend returns the options of the frontend of a request for a backend, or nil if there are none
+func getFrontendBackend(ctx context.Context, backendName string) *frontendBackend {
+	if forwarding := getFrontendForwarding(ctx); forwarding != nil {
+		return forwarding.backends[backendName]
+	}
+	return nil
+}
+
+// frontendRoundTripper sends the requests with the transport of their frontend if it has one,
+// and with the transport of the backend otherwise
+type frontendRoundTripper struct {
+	http.RoundTripper
+	backendName string
+}
+
+func (t *frontendRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if frontendBackend := getFrontendBackend(req.Context(), t.backendName); frontendBackend != nil && frontendBackend.roundTripper != nil {
+		return frontendBackend.roundTripper.RoundTrip(req)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// websocketTLSClientConfig returns the TLS configuration of the WebSocket connections to the servers of the backend,
+// which the forwarder requires when the transport is not an *http.Transport
+func (t *frontendRoundTripper) websocketTLSClientConfig() *tls.Config {
+	var tlsConfig *tls.Config
+	switch roundTripper := t.RoundTripper.(type) {
+	case interface{ websocketTLSClientConfig() *tls.Config }:
+		tlsConfig = roundTripper.websocketTLSClientConfig()
+	case *http.Transport:
+		tlsConfig = roundTripper.TLSClientConfig
+	}
+	if tlsConfig == nil {
+		return &tls.Config{}
+	}
+	return tlsConfig
+}
+
+// modifyFrontendResponse modifies the responses with the response modifier of their frontend
+func modifyFrontendResponse(res *http.Response) error {
+	if res.Request == nil {
+		return nil
+	}
+	if forwarding := getFrontendForwarding(res.Request.Context()); forwarding != nil && forwarding.responseModifier != nil {
+		return forwarding.responseModifier(res)
+	}
+	return nil
+}
+
+// serveFrontendNoServer responds when there is no active server with the no server response of the frontend,
+// or with a 503
+func serveFrontendNoServer(rw http.ResponseWriter, req *http.Request) {
+	if forwarding := getFrontendForwarding(req.Context()); forwarding != nil && forwarding.noServer != nil {
+		forwarding.noServer.ServeHTTP(rw, req)
+		return
+	}
+	rw.WriteHeader(http.StatusServiceUnavailable)
+	rw.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+}
+
+// frontendHostHeader overrides the host header of the requests with the custom host of their frontend, or with the one of the backend.
+// Otherwise, the host of the server is sent unless the frontend passes the host header of the client.
+type frontendHostHeader struct {
+	next       http.Handler
+	hostHeader string
+}
+
+func (h *frontendHostHeader) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	hostHeader := h.hostHeader
+	passHostHeader := true
+	if forwarding := getFrontendForwarding(req.Context()); forwarding != nil {
+		if len(forwarding.hostHeader) > 0 {
+			hostHeader = forwarding.hostHeader
+		}
+		passHostHeader = forwarding.passHostHeader
+	}
+
+	if len(hostHeader) > 0 {
+		hostHeaderMiddleware := &middlewares.HostHeader{Host: hostHeader, Handler: h.next}
+		hostHeaderMiddleware.ServeHTTP(rw, req)
+		return
+	}
+	// the forwarder passes the host header, and never overrides the one of the WebSocket requests
+	if !passHostHeader && !forward.IsWebsocketRequest(req) {
+		req.Host = req.URL.Host
+	}
+	h.next.ServeHTTP(rw, req)
+}
+
+// frontendTracing traces the forwarding of the requests with the tracing middleware of their frontend
+type frontendTracing struct {
+	next        http.Handler
+	backendName string
+}
+
+func (t *frontendTracing) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if frontendBackend := getFrontendBackend(req.Context(), t.backendName); frontendBackend != nil && frontendBackend.tracing != nil {
+		frontendBackend.tracing.ServeHTTP(rw, req, t.next.ServeHTTP)
+		return
+	}
+	t.next.ServeHTTP(rw, req)
+}
+
+// frontendLoadBalancer sends the requests to the load balancer of their frontend if it has one,
+// and to the load balancer of the backend otherwise
+type frontendLoadBalancer struct {
+	next        http.Handler
+	backendName string
+}
+
+func (b *frontendLoadBalancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if frontendBackend := getFrontendBackend(req.Context(), b.backendName); frontendBackend != nil && frontendBackend.loadBalancer != nil {
+		frontendBackend.loadBalancer.ServeHTTP(rw, req)
+		return
+	}
+	b.next.ServeHTTP(rw, req)
+}
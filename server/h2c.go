@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// h2cTransport sends the requests to the http servers using HTTP/2 cleartext (h2c) with prior knowledge,
+// and the requests to the https servers using the wrapped transport.
+type h2cTransport struct {
+	h2c       *http2.Transport
+	transport *http.Transport
+}
+
+// newH2CTransport creates an h2cTransport dialing the http servers like transport does.
+func newH2CTransport(transport *http.Transport) *h2cTransport {
+	return &h2cTransport{
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return transport.DialContext(context.Background(), network, addr)
+			},
+		},
+		transport: transport,
+	}
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// websocketTLSClientConfig returns the TLS configuration used to reach the wss servers.
+// The forwarder can't get it from a round-tripper which is not an *http.Transport, so it must be given explicitly.
+func (t *h2cTransport) websocketTLSClientConfig() *tls.Config {
+	if t.transport.TLSClientConfig == nil {
+		return &tls.Config{}
+	}
+	return t.transport.TLSClientConfig
+}
+
+// CloseIdleConnections closes the idle connections of both the h2c transport and the wrapped one.
+func (t *h2cTransport) CloseIdleConnections() {
+	t.h2c.CloseIdleConnections()
+	t.transport.CloseIdleConnections()
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"golang.org/x/net/http2"
+)
+
+const (
+	minHTTP2ReadFrameSize = 1 << 14
+	maxHTTP2ReadFrameSize = 1<<24 - 1
+)
+
+// configureHTTP2 configures the HTTP/2 server of a TLS entrypoint with its settings, and the rapid reset guard when enabled.
+// The HTTP/2 server bundled with net/http, with its defaults, is kept when the entrypoint has no HTTP/2 settings.
+func (s *Server) configureHTTP2(httpServer *http.Server, entryPointName string, config *configuration.EntryPointHTTP2) error {
+	if config == nil {
+		return nil
+	}
+
+	h2Server, err := newHTTP2Server(httpServer, config)
+	if err != nil {
+		return err
+	}
+
+	if config.MaxResetStreams > 0 {
+		period := configuration.DefaultResetStreamsPeriod
+		if config.ResetStreamsPeriod > 0 {
+			period = time.Duration(config.ResetStreamsPeriod)
+		}
+		return configureRapidResetGuard(httpServer, h2Server, entryPointName, config.MaxResetStreams, period, s.metricsRegistry.EntrypointRapidResetConnsCounter())
+	}
+	return http2.ConfigureServer(httpServer, h2Server)
+}
+
+// newHTTP2Server creates the HTTP/2 server of httpServer with the settings of the entrypoint.
+func newHTTP2Server(httpServer *http.Server, config *configuration.EntryPointHTTP2) (*http2.Server, error) {
+	h2Server := &http2.Server{IdleTimeout: httpServer.IdleTimeout}
+	if err := validateHTTP2(config); err != nil {
+		return nil, err
+	}
+	h2Server.MaxConcurrentStreams = config.MaxConcurrentStreams
+	h2Server.MaxReadFrameSize = config.MaxReadFrameSize
+	if config.IdleTimeout > 0 {
+		h2Server.IdleTimeout = time.Duration(config.IdleTimeout)
+	}
+	return h2Server, nil
+}
+
+// validateHTTP2 checks the HTTP/2 settings of an entrypoint.
+func validateHTTP2(config *configuration.EntryPointHTTP2) error {
+	if config.MaxReadFrameSize != 0 && (config.MaxReadFrameSize < minHTTP2ReadFrameSize || config.MaxReadFrameSize > maxHTTP2ReadFrameSize) {
+		return fmt.Errorf("invalid MaxReadFrameSize %d: it must be between %d and %d", config.MaxReadFrameSize, minHTTP2ReadFrameSize, maxHTTP2ReadFrameSize)
+	}
+	if config.IdleTimeout < 0 {
+		return fmt.Errorf("invalid negative IdleTimeout: %s", time.Duration(config.IdleTimeout))
+	}
+	if config.MaxResetStreams < 0 {
+		return fmt.Errorf("invalid negative MaxResetStreams: %d", config.MaxResetStreams)
+	}
+	if config.ResetStreamsPeriod < 0 {
+		return fmt.Errorf("invalid negative ResetStreamsPeriod: %s", time.Duration(config.ResetStreamsPeriod))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestConfigureHTTP2(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		config           *configuration.EntryPointHTTP2
+		expectedSettings map[http2.SettingID]uint32
+		expectedError    bool
+	}{
+		{
+			desc: "server bundled with net/http",
+			expectedSettings: map[http2.SettingID]uint32{
+				http2.SettingMaxConcurrentStreams: 250,
+				http2.SettingMaxFrameSize:         1 << 20,
+			},
+		},
+		{
+			desc:   "default settings",
+			config: &configuration.EntryPointHTTP2{},
+			expectedSettings: map[http2.SettingID]uint32{
+				http2.SettingMaxConcurrentStreams: 250,
+				http2.SettingMaxFrameSize:         1 << 20,
+			},
+		},
+		{
+			desc: "entrypoint settings",
+			config: &configuration.EntryPointHTTP2{
+				MaxConcurrentStreams: 10,
+				MaxReadFrameSize:     1 << 15,
+				IdleTimeout:          flaeg.Duration(time.Minute),
+			},
+			expectedSettings: map[http2.SettingID]uint32{
+				http2.SettingMaxConcurrentStreams: 10,
+				http2.SettingMaxFrameSize:         1 << 15,
+			},
+		},
+		{
+			desc: "entrypoint settings with the rapid reset guard",
+			config: &configuration.EntryPointHTTP2{
+				MaxConcurrentStreams: 10,
+				MaxResetStreams:      50,
+			},
+			expectedSettings: map[http2.SettingID]uint32{
+				http2.SettingMaxConcurrentStreams: 10,
+				http2.SettingMaxFrameSize:         1 << 20,
+			},
+		},
+		{
+			desc:          "invalid frame size",
+			config:        &configuration.EntryPointHTTP2{MaxReadFrameSize: 1 << 24},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid max reset streams",
+			config:        &configuration.EntryPointHTTP2{MaxResetStreams: -1},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid reset streams period",
+			config:        &configuration.EntryPointHTTP2{ResetStreamsPeriod: flaeg.Duration(-time.Second)},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cert, err := generate.DefaultCertificate()
+			require.NoError(t, err)
+
+			httpServer := &http.Server{
+				Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					rw.WriteHeader(http.StatusOK)
+				}),
+				TLSConfig: &tls.Config{
+					Certificates: []tls.Certificate{*cert},
+					NextProtos:   []string{"h2", "http/1.1"},
+				},
+			}
+
+			srv := NewServer(configuration.GlobalConfiguration{}, nil)
+			err = srv.configureHTTP2(httpServer, "https", test.config)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			go httpServer.ServeTLS(listener, "", "")
+			defer httpServer.Close()
+
+			conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+				NextProtos:         []string{http2.NextProtoTLS},
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, err)
+			defer conn.Close()
+			require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+			_, err = conn.Write([]byte(http2.ClientPreface))
+			require.NoError(t, err)
+			framer := http2.NewFramer(conn, conn)
+			require.NoError(t, framer.WriteSettings())
+
+			// the first frame sent by the server holds its settings
+			frame, err := framer.ReadFrame()
+			require.NoError(t, err)
+			settings, ok := frame.(*http2.SettingsFrame)
+			require.True(t, ok, "unexpected frame %v", frame)
+
+			for id, expected := range test.expectedSettings {
+				value, ok := settings.Value(id)
+				require.True(t, ok, "setting %s not sent", id)
+				assert.Equal(t, expected, value, "setting %s", id)
+			}
+		})
+	}
+}
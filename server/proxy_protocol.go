@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// defaultProxyProtocolIdleTimeout is the time after which an unused client connection transport is dropped,
+// when the transports have no idle connection timeout.
+const defaultProxyProtocolIdleTimeout = 90 * time.Second
+
+// proxyProtocolTransport sends the requests to the servers over connections beginning with a Proxy Protocol header
+// carrying the addresses of the client connection of the requests.
+// As a connection can only carry the addresses of a single client connection, a transport,
+// with its own connection pool, is created for each of them.
+type proxyProtocolTransport struct {
+	version      int
+	newTransport func() *http.Transport
+	h2c          bool
+	idleTimeout  time.Duration
+	tlsConfig    *tls.Config
+
+	lock      sync.Mutex
+	clients   map[string]*proxyProtocolClient
+	lastSweep time.Time
+}
+
+type proxyProtocolClient struct {
+	roundTripper http.RoundTripper
+	lastUsed     time.Time
+}
+
+// newProxyProtocolTransport creates a proxyProtocolTransport sending headers of the given version,
+// and dialing the servers with the transports created by newTransport.
+func newProxyProtocolTransport(version int, newTransport func() *http.Transport, h2c bool) (*proxyProtocolTransport, error) {
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported Proxy Protocol version %d", version)
+	}
+
+	transport := newTransport()
+	idleTimeout := transport.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultProxyProtocolIdleTimeout
+	}
+
+	return &proxyProtocolTransport{
+		version:      version,
+		newTransport: newTransport,
+		h2c:          h2c,
+		idleTimeout:  idleTimeout,
+		tlsConfig:    transport.TLSClientConfig,
+		clients:      make(map[string]*proxyProtocolClient),
+		lastSweep:    time.Now(),
+	}, nil
+}
+
+func (t *proxyProtocolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := proxyProtocolHeader(t.version, req)
+	return t.getRoundTripper(header).RoundTrip(req)
+}
+
+// websocketTLSClientConfig returns the TLS configuration used to reach the wss servers.
+// The websocket connections are dialed by the forwarder, without a Proxy Protocol header.
+func (t *proxyProtocolTransport) websocketTLSClientConfig() *tls.Config {
+	if t.tlsConfig == nil {
+		return &tls.Config{}
+	}
+	return t.tlsConfig
+}
+
+// CloseIdleConnections closes the idle connections of all the client connection transports.
+func (t *proxyProtocolTransport) CloseIdleConnections() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, client := range t.clients {
+		if closer, ok := client.roundTripper.(interface{ CloseIdleConnections() }); ok {
+			closer.CloseIdleConnections()
+		}
+	}
+}
+
+// getRoundTripper returns the round-tripper of the client connection sending the given header,
+// and drops the ones which have not been used for the idle timeout of their connections.
+func (t *proxyProtocolTransport) getRoundTripper(header []byte) http.RoundTripper {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) > t.idleTimeout {
+		for key, client := range t.clients {
+			if now.Sub(client.lastUsed) > t.idleTimeout {
+				if closer, ok := client.roundTripper.(interface{ CloseIdleConnections() }); ok {
+					closer.CloseIdleConnections()
+				}
+				delete(t.clients, key)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	client, ok := t.clients[string(header)]
+	if !ok {
+		transport := t.newTransport()
+		dialContext := transport.DialContext
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := conn.Write(header); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		}
+
+		client = &proxyProtocolClient{roundTripper: transport}
+		if t.h2c {
+			client.roundTripper = newH2CTransport(transport)
+		}
+		t.clients[string(header)] = client
+	}
+	client.lastUsed = now
+	return client.roundTripper
+}
+
+// proxyProtocolHeader returns the Proxy Protocol header of the given version carrying the addresses of the client connection of req.
+// The source address is the remote address of the request, the destination address the one it has been received on.
+// The requests without remote address, such as the health checks, are not relayed from a client:
+// they are sent with an UNKNOWN header in version 1, and with the LOCAL command in version 2.
+func proxyProtocolHeader(version int, req *http.Request) []byte {
+	if len(req.RemoteAddr) == 0 {
+		if version == 1 {
+			return []byte("PROXY UNKNOWN\r\n")
+		}
+		// version 2 and LOCAL command, with unspecified protocol and without addresses
+		return append(append([]byte(nil), proxyProtocolV2Signature...), 0x20, 0x00, 0x00, 0x00)
+	}
+
+	source := parseTCPAddr(req.RemoteAddr)
+	destination := &net.TCPAddr{IP: net.IPv4zero}
+	if addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		if tcpAddr := parseTCPAddr(addr.String()); tcpAddr != nil {
+			destination = tcpAddr
+		}
+	}
+
+	ipv4 := source != nil && source.IP.To4() != nil && destination.IP.To4() != nil
+
+	if version == 1 {
+		switch {
+		case source == nil:
+			return []byte("PROXY UNKNOWN\r\n")
+		case ipv4:
+			return []byte(fmt.Sprintf("PROXY TCP4 %s %s %d %d\r\n", source.IP, destination.IP, source.Port, destination.Port))
+		default:
+			return []byte(fmt.Sprintf("PROXY TCP6 %s %s %d %d\r\n", ipv6String(source.IP), ipv6String(destination.IP), source.Port, destination.Port))
+		}
+	}
+
+	header := bytes.NewBuffer(append([]byte(nil), proxyProtocolV2Signature...))
+	// version 2 and PROXY command
+	header.WriteByte(0x21)
+	switch {
+	case source == nil:
+		// unspecified protocol, without addresses
+		header.Write([]byte{0x00, 0x00, 0x00})
+		return header.Bytes()
+	case ipv4:
+		// TCP over IPv4, with 12 bytes of addresses
+		header.Write([]byte{0x11, 0x00, 0x0C})
+		header.Write(source.IP.To4())
+		header.Write(destination.IP.To4())
+	default:
+		// TCP over IPv6, with 36 bytes of addresses
+		header.Write([]byte{0x21, 0x00, 0x24})
+		header.Write(source.IP.To16())
+		header.Write(destination.IP.To16())
+	}
+	binary.Write(header, binary.BigEndian, uint16(source.Port))
+	binary.Write(header, binary.BigEndian, uint16(destination.Port))
+	return header.Bytes()
+}
+
+// ipv6String returns the textual representation of ip as an IPv6 address, IPv4 addresses being mapped.
+func ipv6String(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return "::ffff:" + ip4.String()
+	}
+	return ip.String()
+}
+
+func parseTCPAddr(addr string) *net.TCPAddr {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if ip == nil || err != nil {
+		return nil
+	}
+	return &net.TCPAddr{IP: ip, Port: int(portNumber)}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/armon/go-proxyproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyProtocolHeader(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		version        int
+		remoteAddr     string
+		localAddr      net.Addr
+		expectedHeader string
+	}{
+		{
+			desc:           "version 1 over IPv4",
+			version:        1,
+			remoteAddr:     "192.0.2.1:1234",
+			localAddr:      &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 80},
+			expectedHeader: "PROXY TCP4 192.0.2.1 192.0.2.10 1234 80\r\n",
+		},
+		{
+			desc:           "version 1 over IPv6",
+			version:        1,
+			remoteAddr:     "[2001:db8::1]:1234",
+			localAddr:      &net.TCPAddr{IP: net.ParseIP("2001:db8::10"), Port: 443},
+			expectedHeader: "PROXY TCP6 2001:db8::1 2001:db8::10 1234 443\r\n",
+		},
+		{
+			desc:           "version 1 with an IPv4 client on an IPv6 address",
+			version:        1,
+			remoteAddr:     "192.0.2.1:1234",
+			localAddr:      &net.TCPAddr{IP: net.ParseIP("2001:db8::10"), Port: 443},
+			expectedHeader: "PROXY TCP6 ::ffff:192.0.2.1 2001:db8::10 1234 443\r\n",
+		},
+		{
+			desc:           "version 1 without local address",
+			version:        1,
+			remoteAddr:     "192.0.2.1:1234",
+			expectedHeader: "PROXY TCP4 192.0.2.1 0.0.0.0 1234 0\r\n",
+		},
+		{
+			desc:           "version 1 with an invalid remote address",
+			version:        1,
+			remoteAddr:     "invalid",
+			expectedHeader: "PROXY UNKNOWN\r\n",
+		},
+		{
+			desc:       "version 2 over IPv4",
+			version:    2,
+			remoteAddr: "192.0.2.1:1234",
+			localAddr:  &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 80},
+			expectedHeader: "\r\n\r\n\x00\r\nQUIT\n" + "\x21\x11\x00\x0C" +
+				"\xC0\x00\x02\x01" + "\xC0\x00\x02\x0A" + "\x04\xD2" + "\x00\x50",
+		},
+		{
+			desc:       "version 2 over IPv6",
+			version:    2,
+			remoteAddr: "[2001:db8::1]:1234",
+			localAddr:  &net.TCPAddr{IP: net.ParseIP("2001:db8::10"), Port: 443},
+			expectedHeader: "\r\n\r\n\x00\r\nQUIT\n" + "\x21\x21\x00\x24" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x10" +
+				"\x04\xD2" + "\x01\xBB",
+		},
+		{
+			desc:           "version 2 with an invalid remote address",
+			version:        2,
+			remoteAddr:     "invalid",
+			expectedHeader: "\r\n\r\n\x00\r\nQUIT\n" + "\x21\x00\x00\x00",
+		},
+		{
+			desc:           "version 1 without remote address",
+			version:        1,
+			expectedHeader: "PROXY UNKNOWN\r\n",
+		},
+		{
+			desc:           "version 2 without remote address",
+			version:        2,
+			expectedHeader: "\r\n\r\n\x00\r\nQUIT\n" + "\x20\x00\x00\x00",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://backend.example.com", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.localAddr != nil {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, test.localAddr))
+			}
+
+			header := proxyProtocolHeader(test.version, req)
+			assert.Equal(t, hex.Dump([]byte(test.expectedHeader)), hex.Dump(header))
+		})
+	}
+}
+
+func TestProxyProtocolTransport(t *testing.T) {
+	testCases := []struct {
+		desc string
+		tls  bool
+	}{
+		{
+			desc: "plaintext server",
+		},
+		{
+			desc: "TLS server",
+			tls:  true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var connections int32
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// the remote address is the one sent in the Proxy Protocol header
+				rw.Write([]byte(req.RemoteAddr))
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&connections, 1)
+				}
+			}
+			server.Listener = &proxyproto.Listener{Listener: server.Listener}
+			if test.tls {
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			transport, err := newProxyProtocolTransport(1, func() *http.Transport {
+				return &http.Transport{
+					DialContext:     (&net.Dialer{}).DialContext,
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				}
+			}, false)
+			require.NoError(t, err)
+
+			for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.1:1234", "192.0.2.2:5678"} {
+				req := httptest.NewRequest(http.MethodGet, server.URL, nil)
+				req.RequestURI = ""
+				req.RemoteAddr = remoteAddr
+
+				resp, err := transport.RoundTrip(req)
+				require.NoError(t, err)
+				body, err := ioutil.ReadAll(resp.Body)
+				require.NoError(t, err)
+				resp.Body.Close()
+
+				assert.Equal(t, remoteAddr, string(body))
+			}
+
+			// the connection of the first client is reused for its second request
+			assert.EqualValues(t, 2, atomic.LoadInt32(&connections))
+		})
+	}
+}
+
+// headerRecordingListener records the first line sent on its connections, before serving them
+type headerRecordingListener struct {
+	net.Listener
+	headers chan string
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (l *headerRecordingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l.headers <- header
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+func TestProxyProtocolTransportHealthCheck(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	listener := &headerRecordingListener{Listener: server.Listener, headers: make(chan string, 1)}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	transport, err := newProxyProtocolTransport(1, func() *http.Transport {
+		return &http.Transport{DialContext: (&net.Dialer{}).DialContext}
+	}, false)
+	require.NoError(t, err)
+
+	// the health checker creates client requests, without remote address
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/health", nil)
+	require.NoError(t, err)
+
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "PROXY UNKNOWN\r\n", <-listener.headers)
+}
+
+func TestNewProxyProtocolTransportInvalidVersion(t *testing.T) {
+	_, err := newProxyProtocolTransport(3, func() *http.Transport { return &http.Transport{} }, false)
+	assert.Error(t, err)
+}
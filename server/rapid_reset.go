@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/go-kit/kit/metrics"
+	"golang.org/x/net/http2"
+)
+
+// goAwayTimeout is the maximum duration to wait for the end of the frame being written by the HTTP/2 server,
+// before sending the GOAWAY frame of a connection exceeding the rapid reset limits, after which the connection is closed right away.
+const goAwayTimeout = time.Second
+
+// http2FrameHeaderLen is the length of the header of the HTTP/2 frames
+const http2FrameHeaderLen = 9
+
+// configureRapidResetGuard makes the HTTP/2 connections of the server, served by h2Server, closed with a GOAWAY frame
+// when their client resets more than maxResets streams within period, which mitigates the "rapid reset" attacks.
+// The reset streams are counted by the write scheduler of each connection, set through the NewWriteScheduler setting of h2Server.
+func configureRapidResetGuard(server *http.Server, h2Server *http2.Server, entryPointName string, maxResets int, period time.Duration, rapidResetConnsCounter metrics.Counter) error {
+	if err := http2.ConfigureServer(server, h2Server); err != nil {
+		return err
+	}
+
+	server.TLSNextProto[http2.NextProtoTLS] = func(hs *http.Server, conn *tls.Conn, handler http.Handler) {
+		guardedConn := &goAwayConn{Conn: conn}
+		// the write scheduler is created for each connection, so the settings are copied to close the right one
+		connServer := *h2Server
+		connServer.NewWriteScheduler = func() http2.WriteScheduler {
+			return newRapidResetScheduler(http2.NewRandomWriteScheduler(), maxResets, period, func(lastStreamID uint32) {
+				log.Debugf("Closing HTTP/2 connection from %s on entrypoint %s: too many reset streams", conn.RemoteAddr(), entryPointName)
+				rapidResetConnsCounter.With("entrypoint", entryPointName).Add(1)
+				guardedConn.goAway(lastStreamID, http2.ErrCodeEnhanceYourCalm)
+			})
+		}
+		connServer.ServeConn(guardedConn, &http2.ServeConnOpts{BaseConfig: hs, Handler: handler})
+	}
+	return nil
+}
+
+// goAwayConn is the TLS connection of an HTTP/2 server, which sends a GOAWAY frame between the frames written by the server
+// before being closed. The headers of the written frames are read to find the end of the frame being written.
+type goAwayConn struct {
+	*tls.Conn
+
+	lock sync.Mutex
+	// header holds the part of the header of the next frame already written, and payload the size of the payload left to write
+	header  []byte
+	payload int
+	// goAwayFrame is the GOAWAY frame waiting for the end of the frame being written
+	goAwayFrame []byte
+}
+
+func (c *goAwayConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n, err := c.Conn.Write(p)
+	c.readFrames(p[:n])
+	if err == nil && c.goAwayFrame != nil && c.betweenFrames() {
+		c.writeGoAway()
+	}
+	return n, err
+}
+
+// goAway sends a GOAWAY frame with lastStreamID and code, as soon as the frame being written is complete, and closes the connection
+func (c *goAwayConn) goAway(lastStreamID uint32, code http2.ErrCode) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.goAwayFrame != nil {
+		return
+	}
+	frame := &bytes.Buffer{}
+	if err := http2.NewFramer(frame, nil).WriteGoAway(lastStreamID, code, nil); err != nil {
+		log.Debugf("Error while building the GOAWAY frame of %s: %v", c.RemoteAddr(), err)
+		c.Conn.Close()
+		return
+	}
+	c.goAwayFrame = frame.Bytes()
+
+	if c.betweenFrames() {
+		c.writeGoAway()
+		return
+	}
+	time.AfterFunc(goAwayTimeout, func() {
+		c.Conn.Close()
+	})
+}
+
+func (c *goAwayConn) writeGoAway() {
+	if _, err := c.Conn.Write(c.goAwayFrame); err != nil {
+		log.Debugf("Error while sending the GOAWAY frame to %s: %v", c.RemoteAddr(), err)
+	}
+	c.Conn.Close()
+}
+
+// readFrames follows the frames written in p
+func (c *goAwayConn) readFrames(p []byte) {
+	for len(p) > 0 {
+		if c.payload > 0 {
+			n := c.payload
+			if n > len(p) {
+				n = len(p)
+			}
+			c.payload -= n
+			p = p[n:]
+			continue
+		}
+
+		n := http2FrameHeaderLen - len(c.header)
+		if n > len(p) {
+			n = len(p)
+		}
+		c.header = append(c.header, p[:n]...)
+		p = p[n:]
+		if len(c.header) == http2FrameHeaderLen {
+			// the header starts with the 24-bit length of the payload
+			c.payload = int(c.header[0])<<16 | int(c.header[1])<<8 | int(c.header[2])
+			c.header = c.header[:0]
+		}
+	}
+}
+
+func (c *goAwayConn) betweenFrames() bool {
+	return c.payload == 0 && len(c.header) == 0
+}
+
+// rapidResetScheduler is the write scheduler of an HTTP/2 connection counting the streams reset by the client,
+// that is closed before the server wrote any frame on them, and calling onExceeded with the last stream opened by the client
+// when there are too many of them.
+// Its methods are only called by the goroutine serving the connection.
+type rapidResetScheduler struct {
+	http2.WriteScheduler
+	maxResets  int
+	period     time.Duration
+	onExceeded func(lastStreamID uint32)
+
+	// unanswered holds the open streams on which no frame was written yet
+	unanswered   map[uint32]bool
+	lastStreamID uint32
+	resets       int
+	periodStart  time.Time
+}
+
+func newRapidResetScheduler(scheduler http2.WriteScheduler, maxResets int, period time.Duration, onExceeded func(lastStreamID uint32)) *rapidResetScheduler {
+	return &rapidResetScheduler{
+		WriteScheduler: scheduler,
+		maxResets:      maxResets,
+		period:         period,
+		onExceeded:     onExceeded,
+		unanswered:     make(map[uint32]bool),
+	}
+}
+
+func (s *rapidResetScheduler) OpenStream(streamID uint32, options http2.OpenStreamOptions) {
+	s.unanswered[streamID] = true
+	if streamID > s.lastStreamID {
+		s.lastStreamID = streamID
+	}
+	s.WriteScheduler.OpenStream(streamID, options)
+}
+
+func (s *rapidResetScheduler) Push(wr http2.FrameWriteRequest) {
+	delete(s.unanswered, wr.StreamID())
+	s.WriteScheduler.Push(wr)
+}
+
+func (s *rapidResetScheduler) CloseStream(streamID uint32) {
+	if s.unanswered[streamID] {
+		delete(s.unanswered, streamID)
+		s.countReset()
+	}
+	s.WriteScheduler.CloseStream(streamID)
+}
+
+// countReset calls onExceeded when the reset streams exceed the limit of the current period
+func (s *rapidResetScheduler) countReset() {
+	now := time.Now()
+	if now.Sub(s.periodStart) > s.period {
+		s.periodStart = now
+		s.resets = 0
+	}
+	s.resets++
+	if s.resets == s.maxResets+1 {
+		s.onExceeded(s.lastStreamID)
+	}
+}
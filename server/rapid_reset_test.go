@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestRapidResetGuard(t *testing.T) {
+	maxResetStreams := 5
+
+	testCases := []struct {
+		desc            string
+		resetStreams    int
+		expectedClosed  bool
+		expectedCounter float64
+	}{
+		{
+			desc:         "resets within the limit",
+			resetStreams: maxResetStreams,
+		},
+		{
+			desc:            "resets exceeding the limit",
+			resetStreams:    maxResetStreams + 1,
+			expectedClosed:  true,
+			expectedCounter: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cert, err := generate.DefaultCertificate()
+			require.NoError(t, err)
+
+			httpServer := &http.Server{
+				// the handler does not answer before the stream is reset
+				Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					<-req.Context().Done()
+				}),
+				TLSConfig: &tls.Config{
+					Certificates: []tls.Certificate{*cert},
+					NextProtos:   []string{"h2", "http/1.1"},
+				},
+			}
+			counter := &testhelpers.CollectingCounter{}
+			require.NoError(t, configureRapidResetGuard(httpServer, &http2.Server{}, "https", maxResetStreams, time.Minute, counter))
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			go httpServer.ServeTLS(listener, "", "")
+			defer httpServer.Close()
+
+			conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+				NextProtos:         []string{http2.NextProtoTLS},
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, err)
+			defer conn.Close()
+			require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+			_, err = conn.Write([]byte(http2.ClientPreface))
+			require.NoError(t, err)
+			framer := http2.NewFramer(conn, conn)
+			require.NoError(t, framer.WriteSettings())
+
+			headers := new(bytes.Buffer)
+			encoder := hpack.NewEncoder(headers)
+			for _, field := range []hpack.HeaderField{
+				{Name: ":method", Value: http.MethodGet},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":authority", Value: "localhost"},
+				{Name: ":path", Value: "/"},
+			} {
+				require.NoError(t, encoder.WriteField(field))
+			}
+
+			// each stream is reset right after being opened
+			for i := 0; i < test.resetStreams; i++ {
+				streamID := uint32(2*i + 1)
+				require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{
+					StreamID:      streamID,
+					BlockFragment: headers.Bytes(),
+					EndStream:     true,
+					EndHeaders:    true,
+				}))
+				require.NoError(t, framer.WriteRSTStream(streamID, http2.ErrCodeCancel))
+			}
+			// the server answers to the ping only if the connection is still open
+			require.NoError(t, framer.WritePing(false, [8]byte{}))
+
+			var pong bool
+			var goAway *http2.GoAwayFrame
+			for !pong && goAway == nil {
+				frame, err := framer.ReadFrame()
+				require.NoError(t, err)
+				switch frame := frame.(type) {
+				case *http2.PingFrame:
+					pong = frame.IsAck()
+				case *http2.GoAwayFrame:
+					goAway = frame
+				}
+			}
+
+			if test.expectedClosed {
+				require.NotNil(t, goAway, "the connection should be closed with a GOAWAY frame")
+				assert.Equal(t, http2.ErrCodeEnhanceYourCalm, goAway.ErrCode)
+				assert.Equal(t, uint32(2*test.resetStreams-1), goAway.LastStreamID)
+
+				_, err = framer.ReadFrame()
+				assert.Equal(t, io.EOF, err)
+			} else {
+				assert.True(t, pong)
+			}
+
+			assert.Equal(t, test.expectedCounter, counter.CounterValue)
+			if test.expectedCounter > 0 {
+				assert.Equal(t, []string{"entrypoint", "https"}, counter.LastLabelValues)
+			}
+		})
+	}
+}
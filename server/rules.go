@@ -6,11 +6,14 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/ty/fun"
 	"github.com/containous/mux"
+	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 )
 
@@ -115,11 +118,27 @@ func (r *Rules) replacePath(paths ...string) *mux.Route {
 
 func (r *Rules) replacePathRegex(paths ...string) *mux.Route {
 	for _, path := range paths {
+		if _, _, err := parseReplacePathRegex(path); err != nil {
+			r.err = err
+			return r.route.route
+		}
 		r.route.replacePathRegex = path
 	}
 	return r.route.route
 }
 
+// parseReplacePathRegex splits the value of a ReplacePathRegex rule into its regular expression, checked to compile, and its replacement
+func parseReplacePathRegex(value string) (string, string, error) {
+	parts := strings.Fields(value)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid syntax for ReplacePathRegex: %s. Separate the regular expression and the replacement by a space", value)
+	}
+	if _, err := regexp.Compile(parts[0]); err != nil {
+		return "", "", fmt.Errorf("invalid regular expression for ReplacePathRegex: %v", err)
+	}
+	return parts[0], parts[1], nil
+}
+
 func (r *Rules) addPrefix(paths ...string) *mux.Route {
 	for _, path := range paths {
 		r.route.addPrefix = path
@@ -159,32 +178,114 @@ func (r *Rules) headersRegexp(headers ...string) *mux.Route {
 	return r.route.route.HeadersRegexp(headers...)
 }
 
+// query matches the requests having all the query parameters, given as key=value pairs or as keys only to match their presence whatever their value
 func (r *Rules) query(query ...string) *mux.Route {
 	var queries []string
+	var keys []string
 	for _, elem := range query {
-		queries = append(queries, strings.Split(elem, "=")...)
+		parts := strings.SplitN(elem, "=", 2)
+		if len(parts) == 2 {
+			queries = append(queries, parts...)
+		} else {
+			keys = append(keys, elem)
+		}
 	}
 
-	return r.route.route.Queries(queries...)
+	if len(keys) > 0 {
+		r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+			values := req.URL.Query()
+			for _, key := range keys {
+				if _, ok := values[key]; !ok {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	if len(queries) > 0 {
+		return r.route.route.Queries(queries...)
+	}
+	return r.route.route
+}
+
+// port matches the requests received on one of the given local ports, i.e. on the port of the listener
+// which accepted the connection, whatever the port given in the Host header
+func (r *Rules) port(ports ...string) *mux.Route {
+	var expected []int
+	for _, port := range ports {
+		value, err := strconv.Atoi(strings.TrimSpace(port))
+		if err != nil || value <= 0 || value > 65535 {
+			r.err = fmt.Errorf("invalid port %q", port)
+			return r.route.route
+		}
+		expected = append(expected, value)
+	}
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		if !ok {
+			return false
+		}
+		_, localPort, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		value, err := strconv.Atoi(localPort)
+		if err != nil {
+			return false
+		}
+		for _, port := range expected {
+			if port == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// clientCertSubject matches the requests sent with a verified client certificate whose subject has all the given attribute=value pairs
+func (r *Rules) clientCertSubject(subjects ...string) *mux.Route {
+	matcher, err := traefikTls.NewSubjectMatcher(subjects...)
+	if err != nil {
+		r.err = err
+		return r.route.route
+	}
+	return r.route.route.MatcherFunc(func(req *http.Request, route *mux.RouteMatch) bool {
+		return matcher.Match(req.TLS)
+	})
+}
+
+// clientCertSubjectRequired rejects with a 403 the requests which are not sent with a verified client certificate
+// whose subject has all the given attribute=value pairs, instead of not matching them
+func (r *Rules) clientCertSubjectRequired(subjects ...string) *mux.Route {
+	matcher, err := traefikTls.NewSubjectMatcher(subjects...)
+	if err != nil {
+		r.err = err
+		return r.route.route
+	}
+	r.route.clientCertSubject = matcher
+	return r.route.route
 }
 
 func (r *Rules) parseRules(expression string, onRule func(functionName string, function interface{}, arguments []string) error) error {
 	functions := map[string]interface{}{
-		"Host":                 r.host,
-		"HostRegexp":           r.hostRegexp,
-		"Path":                 r.path,
-		"PathStrip":            r.pathStrip,
-		"PathStripRegex":       r.pathStripRegex,
-		"PathPrefix":           r.pathPrefix,
-		"PathPrefixStrip":      r.pathPrefixStrip,
-		"PathPrefixStripRegex": r.pathPrefixStripRegex,
-		"Method":               r.methods,
-		"Headers":              r.headers,
-		"HeadersRegexp":        r.headersRegexp,
-		"AddPrefix":            r.addPrefix,
-		"ReplacePath":          r.replacePath,
-		"ReplacePathRegex":     r.replacePathRegex,
-		"Query":                r.query,
+		"Host":                      r.host,
+		"HostRegexp":                r.hostRegexp,
+		"Path":                      r.path,
+		"PathStrip":                 r.pathStrip,
+		"PathStripRegex":            r.pathStripRegex,
+		"PathPrefix":                r.pathPrefix,
+		"PathPrefixStrip":           r.pathPrefixStrip,
+		"PathPrefixStripRegex":      r.pathPrefixStripRegex,
+		"Method":                    r.methods,
+		"Headers":                   r.headers,
+		"HeadersRegexp":             r.headersRegexp,
+		"AddPrefix":                 r.addPrefix,
+		"ReplacePath":               r.replacePath,
+		"ReplacePathRegex":          r.replacePathRegex,
+		"Query":                     r.query,
+		"Port":                      r.port,
+		"ClientCertSubject":         r.clientCertSubject,
+		"ClientCertSubjectRequired": r.clientCertSubjectRequired,
 	}
 
 	if len(expression) == 0 {
@@ -278,3 +379,144 @@ func (r *Rules) ParseDomains(expression string) ([]string, error) {
 	}
 	return fun.Map(types.CanonicalDomain, domains).([]string), nil
 }
+
+// pathRule is a path, or a path prefix, matched by the rules of a frontend
+type pathRule struct {
+	path   string
+	prefix bool
+}
+
+// rulesMatchers holds the matchers of the rules of a frontend telling which requests they can match.
+// Each of the hosts, paths, methods and ports is a list of alternatives, and each header and query parameter a list of values.
+// The other matchers, such as the regular expressions, are assumed to match any request.
+type rulesMatchers struct {
+	hosts   [][]string
+	paths   [][]pathRule
+	methods [][]string
+	ports   [][]string
+	headers map[string][]string
+	queries map[string][]string
+}
+
+func parseRulesMatchers(expressions []string) *rulesMatchers {
+	matchers := &rulesMatchers{
+		headers: make(map[string][]string),
+		queries: make(map[string][]string),
+	}
+	for _, expression := range expressions {
+		// a rule which can't be parsed is rejected when the frontend is built
+		_ = (&Rules{}).parseRules(expression, func(functionName string, function interface{}, arguments []string) error {
+			switch functionName {
+			case "Host":
+				matchers.hosts = append(matchers.hosts, fun.Map(types.CanonicalDomain, arguments).([]string))
+			case "Path", "PathStrip", "PathPrefix", "PathPrefixStrip":
+				var paths []pathRule
+				for _, path := range arguments {
+					// a path template can match any path
+					if strings.Contains(path, "{") {
+						return nil
+					}
+					paths = append(paths, pathRule{path: path, prefix: strings.HasPrefix(functionName, "PathPrefix")})
+				}
+				matchers.paths = append(matchers.paths, paths)
+			case "Method":
+				matchers.methods = append(matchers.methods, fun.Map(strings.ToUpper, arguments).([]string))
+			case "Port":
+				matchers.ports = append(matchers.ports, arguments)
+			case "Headers":
+				for i := 0; i+1 < len(arguments); i += 2 {
+					// an empty value matches any value
+					if len(arguments[i+1]) > 0 {
+						key := http.CanonicalHeaderKey(arguments[i])
+						matchers.headers[key] = append(matchers.headers[key], arguments[i+1])
+					}
+				}
+			case "Query":
+				for _, query := range arguments {
+					if parts := strings.SplitN(query, "=", 2); len(parts) == 2 {
+						matchers.queries[parts[0]] = append(matchers.queries[parts[0]], parts[1])
+					}
+				}
+			}
+			return nil
+		})
+	}
+	return matchers
+}
+
+// rulesOverlap returns whether the rules of two frontends can match the same requests,
+// that is whether none of their hosts, paths, methods, ports, headers or query parameters exclude each other
+func rulesOverlap(rules []string, other []string) bool {
+	matchers := parseRulesMatchers(rules)
+	otherMatchers := parseRulesMatchers(other)
+
+	return alternativesOverlap(matchers.hosts, otherMatchers.hosts) &&
+		alternativesOverlap(matchers.methods, otherMatchers.methods) &&
+		alternativesOverlap(matchers.ports, otherMatchers.ports) &&
+		pathsOverlap(matchers.paths, otherMatchers.paths) &&
+		valuesOverlap(matchers.headers, otherMatchers.headers) &&
+		valuesOverlap(matchers.queries, otherMatchers.queries)
+}
+
+// alternativesOverlap returns whether each list of alternatives has a value in common with each other list
+func alternativesOverlap(alternatives [][]string, others [][]string) bool {
+	for _, values := range alternatives {
+		for _, otherValues := range others {
+			if !anyEqual(values, otherValues) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func anyEqual(values []string, others []string) bool {
+	for _, value := range values {
+		for _, other := range others {
+			if value == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathsOverlap returns whether each list of paths has a path matching the same requests as a path of each other list
+func pathsOverlap(alternatives [][]pathRule, others [][]pathRule) bool {
+	for _, paths := range alternatives {
+		for _, otherPaths := range others {
+			if !anyPathOverlap(paths, otherPaths) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func anyPathOverlap(paths []pathRule, others []pathRule) bool {
+	for _, path := range paths {
+		for _, other := range others {
+			switch {
+			case path.path == other.path,
+				path.prefix && strings.HasPrefix(other.path, path.path),
+				other.prefix && strings.HasPrefix(path.path, other.path):
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// valuesOverlap returns whether the headers, or query parameters, required by both frontends have the same values
+func valuesOverlap(values map[string][]string, others map[string][]string) bool {
+	for key, keyValues := range values {
+		for _, value := range keyValues {
+			for _, other := range others[key] {
+				if value != other {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
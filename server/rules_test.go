@@ -1,7 +1,13 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -256,3 +262,469 @@ func TestPathPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestReplacePathRegexRule(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		expression          string
+		expectedReplacement string
+		expectedError       bool
+	}{
+		{
+			desc:                "regex and replacement",
+			expression:          "PathPrefix:/api/v1;ReplacePathRegex: ^/api/v1/(.*) /$1",
+			expectedReplacement: "^/api/v1/(.*) /$1",
+		},
+		{
+			desc:          "invalid regular expression",
+			expression:    "PathPrefix:/api/v1;ReplacePathRegex: ^/api/v1/(.* /$1",
+			expectedError: true,
+		},
+		{
+			desc:          "missing replacement",
+			expression:    "PathPrefix:/api/v1;ReplacePathRegex: ^/api/v1/(.*)",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			serverRoute := &serverRoute{route: mux.NewRouter().NewRoute()}
+			rules := &Rules{route: serverRoute}
+
+			_, err := rules.Parse(test.expression)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedReplacement, serverRoute.replacePathRegex)
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		expression string
+		urls       map[string]bool
+	}{
+		{
+			desc:       "key and value",
+			expression: "Query:version=2",
+			urls: map[string]bool{
+				"http://foo.com/?version=2":         true,
+				"http://foo.com/?foo=bar&version=2": true,
+				"http://foo.com/?version=1":         false,
+				"http://foo.com/":                   false,
+			},
+		},
+		{
+			desc:       "key only",
+			expression: "Query:debug",
+			urls: map[string]bool{
+				"http://foo.com/?debug":         true,
+				"http://foo.com/?debug=false":   true,
+				"http://foo.com/?version=2":     false,
+				"http://foo.com/?debugging=yes": false,
+			},
+		},
+		{
+			desc:       "value with equal sign",
+			expression: "Query:token=a=b",
+			urls: map[string]bool{
+				"http://foo.com/?token=a%3Db": true,
+				"http://foo.com/?token=a":     false,
+			},
+		},
+		{
+			desc:       "several parameters",
+			expression: "Query:version=2,debug",
+			urls: map[string]bool{
+				"http://foo.com/?version=2&debug": true,
+				"http://foo.com/?version=2":       false,
+				"http://foo.com/?debug":           false,
+			},
+		},
+		{
+			desc:       "combined with another matcher",
+			expression: "Host:foo.com;Query:version=2",
+			urls: map[string]bool{
+				"http://foo.com/?version=2": true,
+				"http://bar.com/?version=2": false,
+				"http://foo.com/?version=3": false,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rls := &Rules{
+				route: &serverRoute{
+					route: mux.NewRouter().NewRoute(),
+				},
+			}
+
+			rt, err := rls.Parse(test.expression)
+			require.NoError(t, err)
+
+			for testURL, expectedMatch := range test.urls {
+				req := testhelpers.MustNewRequest(http.MethodGet, testURL, nil)
+				match := rt.Match(req, &mux.RouteMatch{})
+				assert.Equal(t, expectedMatch, match, "%s with %s", test.expression, testURL)
+			}
+		})
+	}
+}
+
+func TestClientCertSubject(t *testing.T) {
+	partnerA := &x509.Certificate{Subject: pkix.Name{CommonName: "client1", Organization: []string{"Other", "PartnerA"}}}
+	partnerB := &x509.Certificate{Subject: pkix.Name{CommonName: "client1", Organization: []string{"PartnerB"}}}
+
+	testCases := []struct {
+		desc       string
+		expression string
+		states     map[string]*tls.ConnectionState
+		expected   map[string]bool
+	}{
+		{
+			desc:       "organization",
+			expression: "ClientCertSubject:O=PartnerA",
+			states: map[string]*tls.ConnectionState{
+				"verified partner A":   {PeerCertificates: []*x509.Certificate{partnerA}, VerifiedChains: [][]*x509.Certificate{{partnerA}}},
+				"unverified partner A": {PeerCertificates: []*x509.Certificate{partnerA}},
+				"verified partner B":   {PeerCertificates: []*x509.Certificate{partnerB}, VerifiedChains: [][]*x509.Certificate{{partnerB}}},
+				"no certificate":       {},
+				"no TLS":               nil,
+			},
+			expected: map[string]bool{
+				"verified partner A":   true,
+				"unverified partner A": false,
+				"verified partner B":   false,
+				"no certificate":       false,
+				"no TLS":               false,
+			},
+		},
+		{
+			desc:       "several attributes",
+			expression: "ClientCertSubject:cn=client1,O=PartnerB",
+			states: map[string]*tls.ConnectionState{
+				"verified partner A": {PeerCertificates: []*x509.Certificate{partnerA}, VerifiedChains: [][]*x509.Certificate{{partnerA}}},
+				"verified partner B": {PeerCertificates: []*x509.Certificate{partnerB}, VerifiedChains: [][]*x509.Certificate{{partnerB}}},
+			},
+			expected: map[string]bool{
+				"verified partner A": false,
+				"verified partner B": true,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rls := &Rules{
+				route: &serverRoute{
+					route: mux.NewRouter().NewRoute(),
+				},
+			}
+
+			rt, err := rls.Parse(test.expression)
+			require.NoError(t, err)
+
+			for name, state := range test.states {
+				req := testhelpers.MustNewRequest(http.MethodGet, "https://foo.com/", nil)
+				req.TLS = state
+				assert.Equal(t, test.expected[name], rt.Match(req, &mux.RouteMatch{}), name)
+			}
+		})
+	}
+}
+
+func TestClientCertSubjectInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"ClientCertSubject:PartnerA",
+		"ClientCertSubject:O=",
+		"ClientCertSubject:Email=foo@bar.com",
+		"ClientCertSubjectRequired:O",
+	} {
+		rls := &Rules{
+			route: &serverRoute{
+				route: mux.NewRouter().NewRoute(),
+			},
+		}
+
+		_, err := rls.Parse(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestClientCertSubjectRequired(t *testing.T) {
+	partnerA := &x509.Certificate{Subject: pkix.Name{Organization: []string{"PartnerA"}}}
+
+	route := &serverRoute{
+		route: mux.NewRouter().NewRoute(),
+	}
+	rls := &Rules{route: route}
+	_, err := rls.Parse("PathPrefix:/api;ClientCertSubjectRequired:O=PartnerA")
+	require.NoError(t, err)
+
+	srv := &Server{}
+	srv.wireFrontendBackend(route, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		desc     string
+		state    *tls.ConnectionState
+		expected int
+	}{
+		{
+			desc:     "matching verified certificate",
+			state:    &tls.ConnectionState{PeerCertificates: []*x509.Certificate{partnerA}, VerifiedChains: [][]*x509.Certificate{{partnerA}}},
+			expected: http.StatusOK,
+		},
+		{
+			desc:     "unverified certificate",
+			state:    &tls.ConnectionState{PeerCertificates: []*x509.Certificate{partnerA}},
+			expected: http.StatusForbidden,
+		},
+		{
+			desc:     "no certificate",
+			state:    &tls.ConnectionState{},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		req := testhelpers.MustNewRequest(http.MethodGet, "https://foo.com/api", nil)
+		req.TLS = test.state
+
+		// the rule does not prevent the route from matching
+		require.True(t, route.route.Match(req, &mux.RouteMatch{}), test.desc)
+
+		recorder := httptest.NewRecorder()
+		route.route.GetHandler().ServeHTTP(recorder, req)
+		assert.Equal(t, test.expected, recorder.Code, test.desc)
+	}
+}
+
+func TestPort(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		expression string
+		localAddrs map[string]bool
+	}{
+		{
+			desc:       "one port",
+			expression: "Port:8080",
+			localAddrs: map[string]bool{
+				"127.0.0.1:8080": true,
+				"[::1]:8080":     true,
+				"127.0.0.1:8443": false,
+				"":               false,
+			},
+		},
+		{
+			desc:       "several ports",
+			expression: "Port:8080,8443",
+			localAddrs: map[string]bool{
+				"127.0.0.1:8080": true,
+				"127.0.0.1:8443": true,
+				"127.0.0.1:80":   false,
+			},
+		},
+		{
+			desc:       "combined with another matcher",
+			expression: "Host:foo.com;Port:8443",
+			localAddrs: map[string]bool{
+				"127.0.0.1:8443": true,
+				"127.0.0.1:8080": false,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rls := &Rules{
+				route: &serverRoute{
+					route: mux.NewRouter().NewRoute(),
+				},
+			}
+
+			rt, err := rls.Parse(test.expression)
+			require.NoError(t, err)
+
+			for localAddr, expectedMatch := range test.localAddrs {
+				// the port of the Host header is not taken into account
+				req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.com:8080/", nil)
+				if len(localAddr) > 0 {
+					addr, err := net.ResolveTCPAddr("tcp", localAddr)
+					require.NoError(t, err)
+					req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+				}
+				match := rt.Match(req, &mux.RouteMatch{})
+				assert.Equal(t, expectedMatch, match, "%s with %q", test.expression, localAddr)
+			}
+		})
+	}
+}
+
+func TestPortInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"Port:http",
+		"Port:0",
+		"Port:65536",
+		"Port:-1",
+	} {
+		rls := &Rules{
+			route: &serverRoute{
+				route: mux.NewRouter().NewRoute(),
+			},
+		}
+
+		_, err := rls.Parse(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestRulesOverlap(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		rules    []string
+		other    []string
+		expected bool
+	}{
+		{
+			desc:     "reordered matchers",
+			rules:    []string{"Host:foo.bar;Path:/test"},
+			other:    []string{"Path:/test;Host:foo.bar"},
+			expected: true,
+		},
+		{
+			desc:     "other spaces",
+			rules:    []string{"Host: foo.bar ; Path:/test"},
+			other:    []string{"Host:foo.bar;Path:/test"},
+			expected: true,
+		},
+		{
+			desc:     "one host in common and host case",
+			rules:    []string{"Host:foo.bar,Bar.Foo"},
+			other:    []string{"Host:bar.foo, other.bar"},
+			expected: true,
+		},
+		{
+			desc:  "other hosts",
+			rules: []string{"Host:foo.bar"},
+			other: []string{"Host:bar.foo"},
+		},
+		{
+			desc:     "matchers split across routes",
+			rules:    []string{"Host:foo.bar", "Method:get"},
+			other:    []string{"Method:GET;Host:foo.bar"},
+			expected: true,
+		},
+		{
+			desc:  "other path",
+			rules: []string{"Host:foo.bar;Path:/test"},
+			other: []string{"Host:foo.bar;Path:/other"},
+		},
+		{
+			desc:  "path case",
+			rules: []string{"Path:/Test"},
+			other: []string{"Path:/test"},
+		},
+		{
+			desc:     "nested path prefixes",
+			rules:    []string{"PathPrefix:/a"},
+			other:    []string{"PathPrefix:/a/b"},
+			expected: true,
+		},
+		{
+			desc:     "path under a path prefix",
+			rules:    []string{"Path:/a/b"},
+			other:    []string{"PathPrefixStrip:/a"},
+			expected: true,
+		},
+		{
+			desc:  "path prefix under a path",
+			rules: []string{"Path:/a"},
+			other: []string{"PathPrefix:/a/b"},
+		},
+		{
+			desc:  "distinct path prefixes",
+			rules: []string{"PathPrefix:/a"},
+			other: []string{"PathPrefix:/b"},
+		},
+		{
+			desc:     "path template",
+			rules:    []string{"Path:/{id:[0-9]+}"},
+			other:    []string{"Path:/test"},
+			expected: true,
+		},
+		{
+			desc:     "host and other matcher",
+			rules:    []string{"Host:foo.bar"},
+			other:    []string{"PathPrefix:/a"},
+			expected: true,
+		},
+		{
+			desc:  "other methods",
+			rules: []string{"PathPrefix:/;Method:GET,HEAD"},
+			other: []string{"PathPrefix:/a;Method:POST"},
+		},
+		{
+			desc:  "other ports",
+			rules: []string{"Port:80"},
+			other: []string{"Port:443"},
+		},
+		{
+			desc:  "other header value",
+			rules: []string{"Headers:Content-Type,application/json"},
+			other: []string{"Headers:content-type,text/plain"},
+		},
+		{
+			desc:     "other headers",
+			rules:    []string{"Headers:Content-Type,application/json"},
+			other:    []string{"Headers:application/json,Content-Type"},
+			expected: true,
+		},
+		{
+			desc:  "other query value",
+			rules: []string{"Query:foo=bar"},
+			other: []string{"Query:foo=baz"},
+		},
+		{
+			desc:     "query presence",
+			rules:    []string{"Query:foo"},
+			other:    []string{"Query:foo=baz"},
+			expected: true,
+		},
+		{
+			desc:     "header regular expressions",
+			rules:    []string{"HeadersRegexp:Content-Type,^text"},
+			other:    []string{"HeadersRegexp:Content-Type,^application"},
+			expected: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, rulesOverlap(test.rules, test.other))
+			assert.Equal(t, test.expected, rulesOverlap(test.other, test.rules))
+		})
+	}
+}
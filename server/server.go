@@ -19,9 +19,11 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-proxyproto"
+	"github.com/containous/flaeg"
 	"github.com/containous/mux"
 	"github.com/containous/traefik/cluster"
 	"github.com/containous/traefik/configuration"
@@ -32,10 +34,13 @@ import (
 	"github.com/containous/traefik/middlewares/accesslog"
 	mauth "github.com/containous/traefik/middlewares/auth"
 	"github.com/containous/traefik/middlewares/redirect"
+	"github.com/containous/traefik/middlewares/requestid"
 	"github.com/containous/traefik/middlewares/tracing"
 	"github.com/containous/traefik/provider"
+	traefikRedis "github.com/containous/traefik/redis"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/server/cookie"
+	"github.com/containous/traefik/tcp"
 	traefikTls "github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/whitelist"
@@ -59,6 +64,7 @@ var (
 // Server is the reverse-proxy/load-balancer engine
 type Server struct {
 	serverEntryPoints             serverEntryPoints
+	tcpEntryPoints                map[string]*tcp.Proxy
 	configurationChan             chan types.ConfigMessage
 	configurationValidatedChan    chan types.ConfigMessage
 	signals                       chan os.Signal
@@ -72,7 +78,15 @@ type Server struct {
 	leadership                    *cluster.Leadership
 	defaultForwardingRoundTripper http.RoundTripper
 	metricsRegistry               metrics.Registry
+	webSocketConns                *middlewares.WebSocketConns
+	circuitBreakers               *middlewares.CircuitBreakers
+	serverCircuitBreakers         *middlewares.ServerCircuitBreakers
+	rateLimitStore                *middlewares.RateLimitStore
+	chainCompleter                *traefikTls.ChainCompleter
+	crlCheckers                   []*traefikTls.CRLChecker
 	provider                      provider.Provider
+	drainingBackends              map[string]map[string]bool
+	drainingBackendsLock          sync.RWMutex
 }
 
 type serverEntryPoints map[string]*serverEntryPoint
@@ -80,8 +94,15 @@ type serverEntryPoints map[string]*serverEntryPoint
 type serverEntryPoint struct {
 	httpServer *http.Server
 	listener   net.Listener
-	httpRouter *middlewares.HandlerSwitcher
-	certs      safe.Safe
+	// connections tracks the connections accepted by the listener, to close the hijacked ones at the end of the grace period
+	connections *connectionTracker
+	httpRouter  *middlewares.HandlerSwitcher
+	// circuitBreakers holds the circuit breakers created for the entrypoint when loading the configuration
+	circuitBreakers []*middlewares.CircuitBreaker
+	// serverCircuitBreakers holds the per-server circuit breakers created for the entrypoint when loading the configuration
+	serverCircuitBreakers []*middlewares.ServerCircuitBreaker
+	// certs holds the *traefikTls.CertificateIndex of the entrypoint, swapped atomically on reload
+	certs atomic.Value
 }
 
 type serverRoute struct {
@@ -91,6 +112,7 @@ type serverRoute struct {
 	addPrefix          string
 	replacePath        string
 	replacePathRegex   string
+	clientCertSubject  *traefikTls.SubjectMatcher
 }
 
 // NewServer returns an initialized Server.
@@ -99,6 +121,7 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration, provider p
 
 	server.provider = provider
 	server.serverEntryPoints = make(map[string]*serverEntryPoint)
+	server.tcpEntryPoints = make(map[string]*tcp.Proxy)
 	server.configurationChan = make(chan types.ConfigMessage, 100)
 	server.configurationValidatedChan = make(chan types.ConfigMessage, 100)
 	server.signals = make(chan os.Signal, 1)
@@ -107,13 +130,19 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration, provider p
 	currentConfigurations := make(types.Configurations)
 	server.currentConfigurations.Set(currentConfigurations)
 	server.providerConfigUpdateMap = make(map[string]chan types.ConfigMessage)
+	server.drainingBackends = make(map[string]map[string]bool)
 	server.globalConfiguration = globalConfiguration
 	if server.globalConfiguration.API != nil {
 		server.globalConfiguration.API.CurrentConfigurations = &server.currentConfigurations
+		server.globalConfiguration.API.DrainBackend = server.drainBackend
+		server.globalConfiguration.API.SetServerWeight = server.setServerWeight
+	}
+	if server.globalConfiguration.ACME != nil && server.globalConfiguration.DNSResolver != nil {
+		server.globalConfiguration.ACME.SetDNSResolver(server.globalConfiguration.DNSResolver)
 	}
 
 	server.routinesPool = safe.NewPool(context.Background())
-	server.defaultForwardingRoundTripper = createHTTPTransport(globalConfiguration)
+	server.defaultForwardingRoundTripper = createHTTPTransport(globalConfiguration, nil, newDefaultTransportSettings(globalConfiguration))
 
 	server.tracingMiddleware = globalConfiguration.Tracing
 	if globalConfiguration.Tracing != nil && globalConfiguration.Tracing.Backend != "" {
@@ -121,6 +150,21 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration, provider p
 	}
 
 	server.metricsRegistry = registerMetricClients(globalConfiguration.Metrics)
+	server.webSocketConns = middlewares.NewWebSocketConns(server.metricsRegistry.BackendWebSocketConnsGauge())
+	server.circuitBreakers = middlewares.NewCircuitBreakers(server.metricsRegistry.BackendCircuitBreakerOpenGauge())
+	server.serverCircuitBreakers = middlewares.NewServerCircuitBreakers(server.metricsRegistry.BackendEjectedServersGauge())
+	server.chainCompleter = traefikTls.NewChainCompleter(traefikTls.DefaultChainFetchTimeout)
+	if server.globalConfiguration.API != nil {
+		server.globalConfiguration.API.CircuitBreakers = server.circuitBreakers
+	}
+
+	if globalConfiguration.RateLimitStore != nil {
+		var err error
+		server.rateLimitStore, err = newRateLimitStore(globalConfiguration.RateLimitStore)
+		if err != nil {
+			log.Errorf("Unable to create the rate limit store, the rate limits are not shared: %v", err)
+		}
+	}
 
 	if globalConfiguration.Cluster != nil {
 		// leadership creation if cluster mode
@@ -146,21 +190,27 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration, provider p
 // An exception to this is the MaxIdleConns setting as we only provide the option MaxIdleConnsPerHost
 // in Traefik at this point in time. Setting this value to the default of 100 could lead to confusing
 // behaviour and backwards compatibility issues.
-func createHTTPTransport(globalConfiguration configuration.GlobalConfiguration) *http.Transport {
+// If localAddr is not nil, outgoing connections are bound to this local address.
+// The connection pool is sized according to the given settings.
+func createHTTPTransport(globalConfiguration configuration.GlobalConfiguration, localAddr net.Addr, settings transportSettings) *http.Transport {
 	dialer := &net.Dialer{
 		Timeout:   configuration.DefaultDialTimeout,
-		KeepAlive: 30 * time.Second,
+		KeepAlive: settings.dialKeepAlive,
 		DualStack: true,
+		LocalAddr: localAddr,
 	}
 	if globalConfiguration.ForwardingTimeouts != nil {
 		dialer.Timeout = time.Duration(globalConfiguration.ForwardingTimeouts.DialTimeout)
 	}
+	if globalConfiguration.DNSResolver != nil {
+		dialer.Resolver = globalConfiguration.DNSResolver.NewResolver()
+	}
 
 	transport := &http.Transport{
 		Proxy:                 http.ProxyFromEnvironment,
 		DialContext:           dialer.DialContext,
-		MaxIdleConnsPerHost:   globalConfiguration.MaxIdleConnsPerHost,
-		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   settings.maxIdleConnsPerHost,
+		IdleConnTimeout:       settings.idleConnTimeout,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
@@ -180,6 +230,72 @@ func createHTTPTransport(globalConfiguration configuration.GlobalConfiguration)
 	return transport
 }
 
+// disableHTTP2 makes the transport negotiate only HTTP/1.1 with the https servers, even if they support HTTP/2
+func disableHTTP2(transport *http.Transport) {
+	transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	if transport.TLSClientConfig == nil {
+		return
+	}
+
+	tlsConfig := transport.TLSClientConfig.Clone()
+	tlsConfig.NextProtos = nil
+	for _, proto := range transport.TLSClientConfig.NextProtos {
+		if proto != http2.NextProtoTLS {
+			tlsConfig.NextProtos = append(tlsConfig.NextProtos, proto)
+		}
+	}
+	transport.TLSClientConfig = tlsConfig
+}
+
+// transportSettings holds the settings of the connection pool of a transport
+type transportSettings struct {
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	dialKeepAlive       time.Duration
+}
+
+// newDefaultTransportSettings returns the settings of the transports of the backends which don't override them
+func newDefaultTransportSettings(globalConfiguration configuration.GlobalConfiguration) transportSettings {
+	return transportSettings{
+		maxIdleConnsPerHost: globalConfiguration.MaxIdleConnsPerHost,
+		idleConnTimeout:     90 * time.Second,
+		dialKeepAlive:       30 * time.Second,
+	}
+}
+
+// newTransportSettings returns the default transport settings overridden by the ones of a backend
+func newTransportSettings(globalConfiguration configuration.GlobalConfiguration, transport *types.Transport) (transportSettings, error) {
+	settings := newDefaultTransportSettings(globalConfiguration)
+	if transport == nil {
+		return settings, nil
+	}
+
+	if transport.MaxIdleConnsPerHost < 0 {
+		return settings, fmt.Errorf("invalid max idle connections per host %d: it must not be negative", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConnsPerHost > 0 {
+		settings.maxIdleConnsPerHost = transport.MaxIdleConnsPerHost
+	}
+
+	if len(transport.IdleConnTimeout) > 0 {
+		duration, err := time.ParseDuration(transport.IdleConnTimeout)
+		if err != nil || duration < 0 {
+			return settings, fmt.Errorf("invalid idle connection timeout %q: it must be a positive duration, or 0 for no timeout", transport.IdleConnTimeout)
+		}
+		settings.idleConnTimeout = duration
+	}
+
+	if len(transport.DialKeepAlive) > 0 {
+		duration, err := time.ParseDuration(transport.DialKeepAlive)
+		if err != nil || duration < 0 {
+			return settings, fmt.Errorf("invalid dial keep-alive %q: it must be a positive duration, or 0 to disable the keep-alives", transport.DialKeepAlive)
+		}
+		settings.dialKeepAlive = duration
+	}
+
+	return settings, nil
+}
+
 func createRootCACertPool(rootCAs traefikTls.RootCAs) *x509.CertPool {
 	roots := x509.NewCertPool()
 
@@ -198,6 +314,7 @@ func createRootCACertPool(rootCAs traefikTls.RootCAs) *x509.CertPool {
 // Start starts the server.
 func (s *Server) Start() {
 	s.startHTTPServers()
+	s.startTCPServers()
 	s.startLeadership()
 	s.routinesPool.Go(func(stop chan bool) {
 		s.listenProviders(stop)
@@ -229,10 +346,28 @@ func (s *Server) Stop() {
 				log.Debugf("Wait is over due to: %s", err)
 				serverEntryPoint.httpServer.Close()
 			}
+			// the hijacked connections, such as the WebSockets, are left open by the HTTP server
+			if err := serverEntryPoint.connections.Shutdown(ctx); err != nil {
+				log.Debugf("Hijacked connections closed on entrypoint %s due to: %s", serverEntryPointName, err)
+			}
 			cancel()
 			log.Debugf("Entrypoint %s closed", serverEntryPointName)
 		}(sepn, sep)
 	}
+	for tepn, tep := range s.tcpEntryPoints {
+		wg.Add(1)
+		go func(tcpEntryPointName string, tcpEntryPoint *tcp.Proxy) {
+			defer wg.Done()
+			graceTimeOut := time.Duration(s.globalConfiguration.LifeCycle.GraceTimeOut)
+			ctx, cancel := context.WithTimeout(context.Background(), graceTimeOut)
+			log.Debugf("Waiting %s seconds before killing connections on TCP entrypoint %s...", graceTimeOut, tcpEntryPointName)
+			if err := tcpEntryPoint.Shutdown(ctx); err != nil {
+				log.Debugf("Wait is over due to: %s", err)
+			}
+			cancel()
+			log.Debugf("Entrypoint %s closed", tcpEntryPointName)
+		}(tepn, tep)
+	}
 	wg.Wait()
 	s.stopChan <- true
 }
@@ -262,6 +397,11 @@ func (s *Server) Close() {
 			log.Errorf("Error closing access log file: %s", err)
 		}
 	}
+	if s.rateLimitStore != nil {
+		s.rateLimitStore.Close()
+	}
+	// flush the pending traces
+	s.tracingMiddleware.Close()
 	cancel()
 }
 
@@ -286,10 +426,59 @@ func (s *Server) startHTTPServers() {
 	}
 }
 
+// startTCPServers starts the entrypoints forwarding TCP connections, which are not reloaded with the configurations of the providers.
+func (s *Server) startTCPServers() {
+	var dialTimeout time.Duration
+	if s.globalConfiguration.ForwardingTimeouts != nil {
+		dialTimeout = time.Duration(s.globalConfiguration.ForwardingTimeouts.DialTimeout)
+	}
+
+	for entryPointName, entryPoint := range s.globalConfiguration.EntryPoints {
+		if entryPoint.TCP == nil {
+			continue
+		}
+
+		proxy, err := tcp.NewProxy(entryPoint.TCP, dialTimeout)
+		if err != nil {
+			log.Fatalf("Error preparing TCP entrypoint %s: %v", entryPointName, err)
+		}
+		listener, err := net.Listen("tcp", entryPoint.Address)
+		if err != nil {
+			log.Fatal("Error opening listener ", err)
+		}
+		s.tcpEntryPoints[entryPointName] = proxy
+
+		go func(address string) {
+			log.Infof("Starting TCP server on %s", address)
+			if err := proxy.Serve(listener); err != tcp.ErrProxyClosed {
+				log.Error("Error creating TCP server: ", err)
+			}
+		}(entryPoint.Address)
+	}
+}
+
 func (s *Server) setupServerEntryPoint(newServerEntryPointName string, newServerEntryPoint *serverEntryPoint) *serverEntryPoint {
 	serverMiddlewares := []negroni.Handler{middlewares.NegroniRecoverHandler()}
 	serverInternalMiddlewares := []negroni.Handler{middlewares.NegroniRecoverHandler()}
 
+	headerSizeLimiter := middlewares.NewHeaderSizeLimiter(newServerEntryPointName, s.globalConfiguration.EntryPoints[newServerEntryPointName].MaxHeaderBytes)
+	serverMiddlewares = append(serverMiddlewares, headerSizeLimiter)
+	serverInternalMiddlewares = append(serverInternalMiddlewares, headerSizeLimiter)
+
+	// the untrusted forwarded headers are removed first, so that the other middlewares never see them
+	if forwardedHeaders := s.globalConfiguration.EntryPoints[newServerEntryPointName].ForwardedHeaders; forwardedHeaders != nil {
+		forwardedHeadersMiddleware, err := middlewares.NewForwardedHeaders(forwardedHeaders.Insecure, forwardedHeaders.TrustedIPs)
+		if err != nil {
+			log.Fatal("Error starting server: ", err)
+		}
+		serverMiddlewares = append(serverMiddlewares, forwardedHeadersMiddleware)
+		serverInternalMiddlewares = append(serverInternalMiddlewares, forwardedHeadersMiddleware)
+	}
+
+	if s.globalConfiguration.RequestID != nil {
+		serverMiddlewares = append(serverMiddlewares, requestid.New(s.globalConfiguration.RequestID.HeaderName))
+	}
+
 	if s.tracingMiddleware.IsEnabled() {
 		serverMiddlewares = append(serverMiddlewares, s.tracingMiddleware.NewEntryPoint(newServerEntryPointName))
 	}
@@ -322,7 +511,15 @@ func (s *Server) setupServerEntryPoint(newServerEntryPointName string, newServer
 		serverInternalMiddlewares = append(serverInternalMiddlewares, authMiddleware)
 	}
 	if s.globalConfiguration.EntryPoints[newServerEntryPointName].Compress {
-		serverMiddlewares = append(serverMiddlewares, &middlewares.Compress{})
+		compressMiddleware := &middlewares.Compress{}
+		if compression := s.globalConfiguration.EntryPoints[newServerEntryPointName].Compression; compression != nil {
+			var err error
+			compressMiddleware, err = middlewares.NewCompress(compression.BrotliQuality, compression.MinSize, compression.ExcludedContentTypes, compression.Transcode)
+			if err != nil {
+				log.Fatal("Error starting server: ", err)
+			}
+		}
+		serverMiddlewares = append(serverMiddlewares, compressMiddleware)
 	}
 	if len(s.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange) > 0 {
 		ipWhitelistMiddleware, err := middlewares.NewIPWhitelister(s.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange)
@@ -338,7 +535,8 @@ func (s *Server) setupServerEntryPoint(newServerEntryPointName string, newServer
 	}
 	serverEntryPoint := s.serverEntryPoints[newServerEntryPointName]
 	serverEntryPoint.httpServer = newSrv
-	serverEntryPoint.listener = listener
+	serverEntryPoint.connections = newConnectionTracker(listener)
+	serverEntryPoint.listener = serverEntryPoint.connections
 
 	return serverEntryPoint
 }
@@ -358,8 +556,8 @@ func (s *Server) listenProviders(stop chan bool) {
 }
 
 func (s *Server) preLoadConfiguration(configMsg types.ConfigMessage) {
-	providersThrottleDuration := time.Duration(s.globalConfiguration.ProvidersThrottleDuration)
 	s.defaultConfigurationValues(configMsg.Configuration)
+	s.applyDrainingBackends(configMsg)
 	currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
 	jsonConf, _ := json.Marshal(configMsg.Configuration)
 	log.Debugf("Configuration received from provider %s: %s", configMsg.ProviderName, string(jsonConf))
@@ -370,10 +568,11 @@ func (s *Server) preLoadConfiguration(configMsg types.ConfigMessage) {
 	} else {
 		providerConfigUpdateCh, ok := s.providerConfigUpdateMap[configMsg.ProviderName]
 		if !ok {
+			providerThrottleDuration := s.globalConfiguration.GetProviderThrottleDuration(configMsg.ProviderName)
 			providerConfigUpdateCh = make(chan types.ConfigMessage)
 			s.providerConfigUpdateMap[configMsg.ProviderName] = providerConfigUpdateCh
 			s.routinesPool.Go(func(stop chan bool) {
-				throttleProviderConfigReload(providersThrottleDuration, s.configurationValidatedChan, providerConfigUpdateCh, stop)
+				throttleProviderConfigReload(providerThrottleDuration, s.configurationValidatedChan, providerConfigUpdateCh, stop)
 			})
 		}
 		providerConfigUpdateCh <- configMsg
@@ -418,6 +617,97 @@ func (s *Server) defaultConfigurationValues(configuration *types.Configuration)
 	configureBackends(configuration.Backends)
 }
 
+// drainBackend marks or unmarks a backend of the given provider as draining.
+// The state overrides the one given by the provider until it is changed again.
+func (s *Server) drainBackend(providerName, backendName string, draining bool) error {
+	currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
+	config, ok := currentConfigurations[providerName]
+	if !ok {
+		return fmt.Errorf("unknown provider %s", providerName)
+	}
+	if _, ok := config.Backends[backendName]; !ok {
+		return fmt.Errorf("unknown backend %s for provider %s", backendName, providerName)
+	}
+
+	s.drainingBackendsLock.Lock()
+	if s.drainingBackends[providerName] == nil {
+		s.drainingBackends[providerName] = make(map[string]bool)
+	}
+	s.drainingBackends[providerName][backendName] = draining
+	s.drainingBackendsLock.Unlock()
+
+	// Copy the backends so that the current configuration is left untouched
+	newConfig := *config
+	newConfig.Backends = make(map[string]*types.Backend, len(config.Backends))
+	for name, backend := range config.Backends {
+		newBackend := *backend
+		newConfig.Backends[name] = &newBackend
+	}
+
+	s.configurationChan <- types.ConfigMessage{ProviderName: providerName, Configuration: &newConfig}
+	return nil
+}
+
+// setServerWeight changes the weight of a server of a backend of the given provider, reloading the current configuration.
+// Unlike the draining state, the weight is not kept when the provider sends a new configuration.
+func (s *Server) setServerWeight(providerName, backendName, serverName string, weight int) error {
+	if weight < 0 {
+		return fmt.Errorf("invalid weight %d for server %s", weight, serverName)
+	}
+
+	currentConfigurations := s.currentConfigurations.Get().(types.Configurations)
+	config, ok := currentConfigurations[providerName]
+	if !ok {
+		return fmt.Errorf("unknown provider %s", providerName)
+	}
+	backend, ok := config.Backends[backendName]
+	if !ok {
+		return fmt.Errorf("unknown backend %s for provider %s", backendName, providerName)
+	}
+	if _, ok := backend.Servers[serverName]; !ok {
+		return fmt.Errorf("unknown server %s for backend %s of provider %s", serverName, backendName, providerName)
+	}
+	// the percentages of the other servers would no longer sum to 100
+	if backend.PercentWeights {
+		return fmt.Errorf("the weights of the servers of backend %s of provider %s are percentages", backendName, providerName)
+	}
+
+	// Copy the backends and the servers of the backend so that the current configuration is left untouched
+	newConfig := *config
+	newConfig.Backends = make(map[string]*types.Backend, len(config.Backends))
+	for name, backend := range config.Backends {
+		newBackend := *backend
+		newConfig.Backends[name] = &newBackend
+	}
+
+	newBackend := newConfig.Backends[backendName]
+	newBackend.Servers = make(map[string]types.Server, len(backend.Servers))
+	for name, server := range backend.Servers {
+		newBackend.Servers[name] = server
+	}
+	server := newBackend.Servers[serverName]
+	server.Weight = weight
+	newBackend.Servers[serverName] = server
+
+	s.configurationChan <- types.ConfigMessage{ProviderName: providerName, Configuration: &newConfig}
+	return nil
+}
+
+func (s *Server) applyDrainingBackends(configMsg types.ConfigMessage) {
+	if configMsg.Configuration == nil {
+		return
+	}
+
+	s.drainingBackendsLock.RLock()
+	defer s.drainingBackendsLock.RUnlock()
+
+	for backendName, draining := range s.drainingBackends[configMsg.ProviderName] {
+		if backend, ok := configMsg.Configuration.Backends[backendName]; ok {
+			backend.Draining = draining
+		}
+	}
+}
+
 func (s *Server) listenConfigurations(stop chan bool) {
 	for {
 		select {
@@ -444,21 +734,37 @@ func (s *Server) loadConfiguration(configMsg types.ConfigMessage) {
 	newConfigurations[configMsg.ProviderName] = configMsg.Configuration
 
 	s.metricsRegistry.ConfigReloadsCounter().Add(1)
+
+	// the invalid certificates on the strict entrypoints reject the configuration, the previous one being kept
+	if err := s.checkStrictCertificates(configMsg.Configuration); err != nil {
+		s.metricsRegistry.ConfigReloadsFailureCounter().Add(1)
+		s.metricsRegistry.LastConfigReloadFailureGauge().Set(float64(time.Now().Unix()))
+		log.Errorf("Error loading the certificates of the provider %s, configuration aborted: %v", configMsg.ProviderName, err)
+		return
+	}
+
 	newServerEntryPoints, err := s.loadConfig(newConfigurations, s.globalConfiguration)
 	if err == nil {
 		s.metricsRegistry.LastConfigReloadSuccessGauge().Set(float64(time.Now().Unix()))
+		var circuitBreakers []*middlewares.CircuitBreaker
+		var serverCircuitBreakers []*middlewares.ServerCircuitBreaker
 		for newServerEntryPointName, newServerEntryPoint := range newServerEntryPoints {
+			circuitBreakers = append(circuitBreakers, newServerEntryPoint.circuitBreakers...)
+			serverCircuitBreakers = append(serverCircuitBreakers, newServerEntryPoint.serverCircuitBreakers...)
 			s.serverEntryPoints[newServerEntryPointName].httpRouter.UpdateHandler(newServerEntryPoint.httpRouter.GetHandler())
 			if s.globalConfiguration.EntryPoints[newServerEntryPointName].TLS == nil {
-				if newServerEntryPoint.certs.Get() != nil {
+				if newServerEntryPoint.getCertificates() != nil {
 					log.Debugf("Certificates not added to non-TLS entryPoint %s.", newServerEntryPointName)
 				}
 			} else {
-				s.serverEntryPoints[newServerEntryPointName].certs.Set(newServerEntryPoint.certs.Get())
+				s.serverEntryPoints[newServerEntryPointName].setCertificates(newServerEntryPoint.getCertificates())
 			}
 			log.Infof("Server configuration reloaded on %s", s.serverEntryPoints[newServerEntryPointName].httpServer.Addr)
 		}
+		s.circuitBreakers.Set(circuitBreakers)
+		s.serverCircuitBreakers.Set(serverCircuitBreakers)
 		s.currentConfigurations.Set(newConfigurations)
+		s.reloadCRLs()
 		s.postLoadConfiguration()
 	} else {
 		s.metricsRegistry.ConfigReloadsFailureCounter().Add(1)
@@ -470,9 +776,19 @@ func (s *Server) loadConfiguration(configMsg types.ConfigMessage) {
 // loadHTTPSConfiguration add/delete HTTPS certificate managed dynamically
 func (s *Server) loadHTTPSConfiguration(configurations types.Configurations, defaultEntryPoints configuration.DefaultEntryPoints) (map[string]*traefikTls.DomainsCertificates, error) {
 	newEPCertificates := make(map[string]*traefikTls.DomainsCertificates)
+
+	// The providers are sorted by name so that, when several of them define a certificate for the same domains,
+	// the one which is kept does not depend on the order of the map iteration
+	var providerNames []string
+	for providerName := range configurations {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+
 	// Get all certificates
-	for _, configuration := range configurations {
-		if configuration.TLS != nil && len(configuration.TLS) > 0 {
+	for _, providerName := range providerNames {
+		configuration := configurations[providerName]
+		if configuration != nil && len(configuration.TLS) > 0 {
 			if err := traefikTls.SortTLSPerEntryPoints(configuration.TLS, newEPCertificates, defaultEntryPoints); err != nil {
 				return nil, err
 			}
@@ -481,23 +797,64 @@ func (s *Server) loadHTTPSConfiguration(configurations types.Configurations, def
 	return newEPCertificates, nil
 }
 
-// getCertificate allows to customize tlsConfig.Getcertificate behaviour to get the certificates inserted dynamically
-func (s *serverEntryPoint) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
-	if s.certs.Get() != nil {
-		domainToCheck := types.CanonicalDomain(clientHello.ServerName)
-		for domains, cert := range *s.certs.Get().(*traefikTls.DomainsCertificates) {
-			for _, domain := range strings.Split(domains, ",") {
-				selector := "^" + strings.Replace(domain, "*.", "[^\\.]*\\.?", -1) + "$"
-				domainCheck, _ := regexp.MatchString(selector, domainToCheck)
-				if domainCheck {
-					return cert, nil
-				}
+// checkStrictCertificates returns an error if a certificate of the configuration, for an entrypoint loading its certificates
+// strictly, can't be loaded
+func (s *Server) checkStrictCertificates(configuration *types.Configuration) error {
+	if configuration == nil {
+		return nil
+	}
+
+	for _, conf := range configuration.TLS {
+		if conf == nil || conf.Certificate == nil {
+			continue
+		}
+		entryPoints := conf.EntryPoints
+		if len(entryPoints) == 0 {
+			entryPoints = s.globalConfiguration.DefaultEntryPoints
+		}
+		for _, entryPointName := range entryPoints {
+			entryPoint := s.globalConfiguration.EntryPoints[entryPointName]
+			if entryPoint == nil || entryPoint.TLS == nil || !entryPoint.TLS.StrictLoad {
+				continue
+			}
+			if err := conf.Certificate.Validate(); err != nil {
+				return fmt.Errorf("invalid certificate for entrypoint %s: %v", entryPointName, err)
 			}
 		}
 	}
+	return nil
+}
+
+// setCertificates replaces the certificates inserted dynamically, indexing them for the handshakes
+func (s *serverEntryPoint) setCertificates(certs *traefikTls.DomainsCertificates) {
+	s.certs.Store(traefikTls.NewCertificateIndex(certs))
+}
+
+// getCertificates returns the certificates inserted dynamically, or nil if there are none
+func (s *serverEntryPoint) getCertificates() *traefikTls.DomainsCertificates {
+	index, _ := s.certs.Load().(*traefikTls.CertificateIndex)
+	return index.Certificates()
+}
+
+// getCertificate allows to customize tlsConfig.Getcertificate behaviour to get the certificates inserted dynamically.
+// The certificates are loaded once, so a handshake always uses a consistent set even if they are reloaded meanwhile.
+func (s *serverEntryPoint) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	index, _ := s.certs.Load().(*traefikTls.CertificateIndex)
+	if cert := index.GetCertificate(clientHello); cert != nil {
+		return cert, nil
+	}
 	return nil, nil
 }
 
+// reloadCRLs reloads the CRLs checking the client certificates of the entrypoints
+func (s *Server) reloadCRLs() {
+	for _, crlChecker := range s.crlCheckers {
+		if err := crlChecker.Reload(); err != nil {
+			log.Errorf("Error reloading the CRLs, keeping the previous ones: %v", err)
+		}
+	}
+}
+
 func (s *Server) postLoadConfiguration() {
 	metrics.OnConfigurationUpdate()
 
@@ -587,12 +944,113 @@ func createClientTLSConfig(entryPointName string, tlsOption *traefikTls.TLS) (*t
 	return config, nil
 }
 
+// clientAuth is the client authentication policy of a ClientCA
+type clientAuth struct {
+	clientCAs  *x509.CertPool
+	clientAuth tls.ClientAuthType
+	crlChecker *traefikTls.CRLChecker
+}
+
+// apply sets the client authentication policy to config
+func (c *clientAuth) apply(config *tls.Config) {
+	config.ClientCAs = c.clientCAs
+	config.ClientAuth = c.clientAuth
+	config.VerifyPeerCertificate = nil
+	if c.crlChecker != nil {
+		config.VerifyPeerCertificate = c.crlChecker.VerifyPeerCertificate
+	}
+}
+
+// createClientAuth returns the client authentication policy of the given ClientCA
+func createClientAuth(clientCA traefikTls.ClientCA) (*clientAuth, error) {
+	if len(clientCA.Files) == 0 {
+		return &clientAuth{clientAuth: tls.NoClientCert}, nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, caFile := range clientCA.Files {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		ok := pool.AppendCertsFromPEM(data)
+		if !ok {
+			return nil, errors.New("invalid certificate(s) in " + caFile)
+		}
+	}
+	policy := &clientAuth{clientCAs: pool, clientAuth: tls.RequireAndVerifyClientCert}
+	if clientCA.Optional {
+		policy.clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if len(clientCA.CRLFiles) > 0 || clientCA.FetchCRLs {
+		crlChecker, err := traefikTls.NewCRLChecker(clientCA.CRLFiles, clientCA.FetchCRLs)
+		if err != nil {
+			return nil, err
+		}
+		policy.crlChecker = crlChecker
+	}
+	return policy, nil
+}
+
+// createDomainsClientAuth returns a tls.Config.GetConfigForClient callback, applying the client authentication policy
+// of the requested server name to the handshake. Policies for an exact server name are preferred over wildcard ones,
+// and the handshakes requesting other server names keep the policy of the base config.
+// The CRL checkers of the policies are returned too.
+func createDomainsClientAuth(baseConfig *tls.Config, domainsClientCAs []traefikTls.DomainsClientCA) (func(*tls.ClientHelloInfo) (*tls.Config, error), []*traefikTls.CRLChecker, error) {
+	var crlCheckers []*traefikTls.CRLChecker
+	domainsClientAuth := make(map[string]*clientAuth)
+	for _, domainsClientCA := range domainsClientCAs {
+		policy, err := createClientAuth(domainsClientCA.ClientCA)
+		if err != nil {
+			return nil, nil, err
+		}
+		if policy.crlChecker != nil {
+			crlCheckers = append(crlCheckers, policy.crlChecker)
+		}
+		for _, domain := range domainsClientCA.Domains {
+			domain = types.CanonicalDomain(domain)
+			if _, exists := domainsClientAuth[domain]; exists {
+				return nil, nil, fmt.Errorf("duplicated client CA configuration for domain %s", domain)
+			}
+			domainsClientAuth[domain] = policy
+		}
+	}
+
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Config, error) {
+		serverName := types.CanonicalDomain(clientHello.ServerName)
+		policy, ok := domainsClientAuth[serverName]
+		if !ok {
+			if labels := strings.SplitN(serverName, ".", 2); len(labels) == 2 {
+				policy, ok = domainsClientAuth["*."+labels[1]]
+			}
+		}
+		if !ok {
+			return nil, nil
+		}
+
+		// the base config is cloned at handshake time to get all its settings, which are completed after its creation
+		config := baseConfig.Clone()
+		policy.apply(config)
+		return config, nil
+	}, crlCheckers, nil
+}
+
 // creates a TLS config that allows terminating HTTPS for multiple domains using SNI
 func (s *Server) createTLSConfig(entryPointName string, tlsOption *traefikTls.TLS, router *middlewares.HandlerSwitcher) (*tls.Config, error) {
 	if tlsOption == nil {
 		return nil, nil
 	}
 
+	// the certificates missing their certificate or key are otherwise ignored
+	if tlsOption.StrictLoad {
+		for i, certificate := range tlsOption.Certificates {
+			if err := certificate.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid certificate #%d of entrypoint %s: %v", i+1, entryPointName, err)
+			}
+		}
+	}
+
 	config, epDomainsCertificates, err := tlsOption.Certificates.CreateTLSConfig(entryPointName)
 	if err != nil {
 		return nil, err
@@ -603,7 +1061,13 @@ func (s *Server) createTLSConfig(entryPointName string, tlsOption *traefikTls.TL
 	} else {
 		*epDomainsCertificatesTmp = make(map[string]*tls.Certificate)
 	}
-	s.serverEntryPoints[entryPointName].certs.Set(epDomainsCertificatesTmp)
+	if tlsOption.CompleteChains {
+		for i := range config.Certificates {
+			s.chainCompleter.Complete(&config.Certificates[i])
+		}
+		epDomainsCertificatesTmp.CompleteChains(s.chainCompleter)
+	}
+	s.serverEntryPoints[entryPointName].setCertificates(epDomainsCertificatesTmp)
 	// ensure http2 enabled
 	config.NextProtos = []string{"h2", "http/1.1"}
 
@@ -612,25 +1076,26 @@ func (s *Server) createTLSConfig(entryPointName string, tlsOption *traefikTls.TL
 		tlsOption.ClientCA.Files = tlsOption.ClientCAFiles
 		tlsOption.ClientCA.Optional = false
 	}
+	var crlCheckers []*traefikTls.CRLChecker
 	if len(tlsOption.ClientCA.Files) > 0 {
-		pool := x509.NewCertPool()
-		for _, caFile := range tlsOption.ClientCA.Files {
-			data, err := ioutil.ReadFile(caFile)
-			if err != nil {
-				return nil, err
-			}
-			ok := pool.AppendCertsFromPEM(data)
-			if !ok {
-				return nil, errors.New("invalid certificate(s) in " + caFile)
-			}
+		policy, err := createClientAuth(tlsOption.ClientCA)
+		if err != nil {
+			return nil, err
 		}
-		config.ClientCAs = pool
-		if tlsOption.ClientCA.Optional {
-			config.ClientAuth = tls.VerifyClientCertIfGiven
-		} else {
-			config.ClientAuth = tls.RequireAndVerifyClientCert
+		policy.apply(config)
+		if policy.crlChecker != nil {
+			crlCheckers = append(crlCheckers, policy.crlChecker)
+		}
+	}
+	if len(tlsOption.DomainsClientCAs) > 0 {
+		var domainsCRLCheckers []*traefikTls.CRLChecker
+		config.GetConfigForClient, domainsCRLCheckers, err = createDomainsClientAuth(config, tlsOption.DomainsClientCAs)
+		if err != nil {
+			return nil, err
 		}
+		crlCheckers = append(crlCheckers, domainsCRLCheckers...)
 	}
+	s.crlCheckers = append(s.crlCheckers, crlCheckers...)
 
 	if s.globalConfiguration.ACME != nil {
 		if entryPointName == s.globalConfiguration.ACME.EntryPoint {
@@ -658,6 +1123,17 @@ func (s *Server) createTLSConfig(entryPointName string, tlsOption *traefikTls.TL
 	} else {
 		config.GetCertificate = s.serverEntryPoints[entryPointName].getCertificate
 	}
+	defaultCert, err := s.globalConfiguration.DefaultCertificates.GetCertificate(entryPointName)
+	if err != nil {
+		return nil, err
+	}
+	if defaultCert != nil {
+		if tlsOption.CompleteChains {
+			s.chainCompleter.Complete(defaultCert)
+		}
+		// the first certificate is served when no other one matches the requested server name
+		config.Certificates = append([]tls.Certificate{*defaultCert}, config.Certificates...)
+	}
 	if len(config.Certificates) == 0 {
 		return nil, errors.New("No certificates found for TLS entrypoint " + entryPointName)
 	}
@@ -773,17 +1249,45 @@ func (s *Server) prepareServer(entryPointName string, entryPoint *configuration.
 		}
 	}
 
-	return &http.Server{
-			Addr:         entryPoint.Address,
-			Handler:      internalMuxRouter,
-			TLSConfig:    tlsConfig,
-			ReadTimeout:  readTimeout,
-			WriteTimeout: writeTimeout,
-			IdleTimeout:  idleTimeout,
-			ErrorLog:     httpServerLogger,
-		},
-		listener,
-		nil
+	errorLog := httpServerLogger
+	if tlsConfig != nil && entryPoint.TLS.LogHandshakeErrors {
+		handshakeLogger := newHandshakeLogger(entryPointName)
+		handshakeLogger.watch(tlsConfig)
+		listener = handshakeLogger.listener(listener)
+		errorLog = stdlog.New(handshakeLogger, "", 0)
+	}
+
+	httpServer := &http.Server{
+		Addr:         entryPoint.Address,
+		Handler:      internalMuxRouter,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+		ErrorLog:     errorLog,
+		// the server rejects the larger headers without reading them entirely nor logging them,
+		// so that the other ones reach the header size limiter, which applies the exact limit
+		MaxHeaderBytes: 2 * maxHeaderBytes(entryPoint),
+	}
+
+	if tlsConfig != nil && s.metricsRegistry.IsEnabled() {
+		httpServer.ConnState = newTLSHandshakeCounter(entryPointName, s.metricsRegistry.EntrypointTLSHandshakesCounter()).connState
+	}
+
+	if tlsConfig != nil {
+		if err := s.configureHTTP2(httpServer, entryPointName, entryPoint.HTTP2); err != nil {
+			log.Errorf("Error configuring HTTP/2 on entrypoint %s: %s", entryPointName, err)
+		}
+	}
+
+	return httpServer, listener, nil
+}
+
+func maxHeaderBytes(entryPoint *configuration.EntryPoint) int {
+	if entryPoint.MaxHeaderBytes > 0 {
+		return entryPoint.MaxHeaderBytes
+	}
+	return middlewares.DefaultMaxHeaderBytes
 }
 
 func (s *Server) buildInternalRouter(entryPointName, path string, internalMiddlewares []negroni.Handler) *mux.Router {
@@ -837,7 +1341,10 @@ func buildServerTimeouts(globalConfig configuration.GlobalConfiguration) (readTi
 
 func (s *Server) buildEntryPoints(globalConfiguration configuration.GlobalConfiguration) map[string]*serverEntryPoint {
 	serverEntryPoints := make(map[string]*serverEntryPoint)
-	for entryPointName := range globalConfiguration.EntryPoints {
+	for entryPointName, entryPoint := range globalConfiguration.EntryPoints {
+		if entryPoint.TCP != nil {
+			continue
+		}
 		router := s.buildDefaultHTTPRouter()
 		serverEntryPoints[entryPointName] = &serverEntryPoint{
 			httpRouter: middlewares.NewHandlerSwitcher(router),
@@ -847,21 +1354,127 @@ func (s *Server) buildEntryPoints(globalConfiguration configuration.GlobalConfig
 }
 
 // getRoundTripper will either use server.defaultForwardingRoundTripper or create a new one
-// given a custom TLS configuration is passed and the passTLSCert option is set to true.
-func (s *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tls *traefikTls.TLS) (http.RoundTripper, error) {
+// given a custom TLS configuration is passed and the passTLSCert option is set to true,
+// or a source address, h2c, HTTP/1.1 only, the Proxy Protocol, TLS or transport settings are set on the backend.
+func (s *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tlsOption *traefikTls.TLS, backend *types.Backend, forwardingTimeouts *configuration.ForwardingTimeouts) (http.RoundTripper, error) {
+	var sourceAddress string
+	var h2c bool
+	var forceHTTP1 bool
+	var proxyProtocol *types.ProxyProtocol
+	var dnsRefresh *types.DNSRefresh
+	var backendTransport *types.Transport
+	var backendTLS *types.BackendTLS
+	if backend != nil {
+		sourceAddress = backend.SourceAddress
+		h2c = backend.H2C
+		forceHTTP1 = backend.ForceHTTP1
+		proxyProtocol = backend.ProxyProtocol
+		dnsRefresh = backend.DNSRefresh
+		backendTransport = backend.Transport
+		backendTLS = backend.TLS
+	}
+
+	if !passTLSCert && len(sourceAddress) == 0 && !h2c && !forceHTTP1 && proxyProtocol == nil && dnsRefresh == nil && backendTransport == nil && backendTLS == nil && forwardingTimeouts == nil {
+		return s.defaultForwardingRoundTripper, nil
+	}
+
+	if h2c && forceHTTP1 {
+		return nil, errors.New("h2c and forceHTTP1 can't be both enabled")
+	}
+
+	settings, err := newTransportSettings(globalConfiguration, backendTransport)
+	if err != nil {
+		return nil, err
+	}
+
+	if forwardingTimeouts != nil {
+		globalConfiguration.ForwardingTimeouts = forwardingTimeouts
+	}
+
+	var localAddr net.Addr
+	if len(sourceAddress) > 0 {
+		ip := net.ParseIP(sourceAddress)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address %q", sourceAddress)
+		}
+		localAddr = &net.TCPAddr{IP: ip}
+	}
+
+	var tlsConfig *tls.Config
 	if passTLSCert {
-		tlsConfig, err := createClientTLSConfig(entryPointName, tls)
+		var err error
+		tlsConfig, err = createClientTLSConfig(entryPointName, tlsOption)
 		if err != nil {
 			log.Errorf("Failed to create TLSClientConfig: %s", err)
 			return nil, err
 		}
+	}
+
+	var backendTLSConf *backendTLSConfig
+	if backendTLS != nil {
+		backendTLSConf, err = newBackendTLSConfig(backendTLS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var dnsRefreshTransport *dnsRefreshTransport
+	if dnsRefresh != nil {
+		var interval time.Duration
+		if len(dnsRefresh.Interval) > 0 {
+			var err error
+			interval, err = time.ParseDuration(dnsRefresh.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DNS refresh interval %q: %v", dnsRefresh.Interval, err)
+			}
+			if interval <= 0 {
+				return nil, fmt.Errorf("invalid DNS refresh interval %q: it must be positive", dnsRefresh.Interval)
+			}
+		}
+		dnsRefreshTransport = newDNSRefreshTransport(interval)
+		if globalConfiguration.DNSResolver != nil {
+			dnsRefreshTransport.lookupHost = globalConfiguration.DNSResolver.NewResolver().LookupHost
+		}
+	}
+
+	newTransport := func() *http.Transport {
+		transport := createHTTPTransport(globalConfiguration, localAddr, settings)
+		if dnsRefreshTransport != nil {
+			transport.DialContext = dnsRefreshTransport.dialContext(transport.DialContext)
+		}
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig
+		}
+		if backendTLSConf != nil {
+			transport.TLSClientConfig = backendTLSConf.apply(transport.TLSClientConfig)
+		}
+		if forceHTTP1 {
+			disableHTTP2(transport)
+		}
+		return transport
+	}
 
-		transport := createHTTPTransport(globalConfiguration)
-		transport.TLSClientConfig = tlsConfig
-		return transport, nil
+	newRoundTripper := func() (http.RoundTripper, error) {
+		if proxyProtocol != nil {
+			version := proxyProtocol.Version
+			if version == 0 {
+				version = 1
+			}
+			return newProxyProtocolTransport(version, newTransport, h2c)
+		}
+		if h2c {
+			return newH2CTransport(newTransport()), nil
+		}
+		return newTransport(), nil
 	}
 
-	return s.defaultForwardingRoundTripper, nil
+	if dnsRefreshTransport != nil {
+		if err := dnsRefreshTransport.start(newRoundTripper); err != nil {
+			return nil, err
+		}
+		return dnsRefreshTransport, nil
+	}
+	return newRoundTripper()
 }
 
 // loadConfig returns a new gorilla.mux Route from the specified global configuration and the dynamic
@@ -869,11 +1482,15 @@ func (s *Server) getRoundTripper(entryPointName string, globalConfiguration conf
 func (s *Server) loadConfig(configurations types.Configurations, globalConfiguration configuration.GlobalConfiguration) (map[string]*serverEntryPoint, error) {
 	serverEntryPoints := s.buildEntryPoints(globalConfiguration)
 	redirectHandlers := make(map[string]negroni.Handler)
-	backends := map[string]http.Handler{}
+	backends := map[string]*sharedBackend{}
 	backendsHealthCheck := map[string]*healthcheck.BackendHealthCheck{}
-	errorHandler := NewRecordingErrorHandler(middlewares.DefaultNetErrorRecorder{})
+	errorHandler := NewRecordingErrorHandler(middlewares.DefaultNetErrorRecorder{}, globalConfiguration.ClientClosedRequestStatus)
+
+	entryPointsRoutes := make(map[string][]frontendRoute)
+	priorities := configurations.EffectivePriorities()
 
-	for _, config := range configurations {
+	for _, providerName := range sortedProviderNames(configurations) {
+		config := configurations[providerName]
 		frontendNames := sortedFrontendNamesForConfig(config)
 	frontend:
 		for _, frontendName := range frontendNames {
@@ -896,6 +1513,15 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 				log.Errorf("Skipping frontend %s...", frontendName)
 				continue frontend
 			}
+
+			forwardingTimeouts, requestTimeout, err := parseForwardingTimeouts(globalConfiguration.ForwardingTimeouts, frontend.ForwardingTimeouts)
+			if err != nil {
+				log.Errorf("Error parsing forwarding timeouts for frontend %s: %v", frontendName, err)
+				log.Errorf("Skipping frontend %s...", frontendName)
+				continue frontend
+			}
+
+			useDefaultMiddlewares := globalConfiguration.DefaultMiddlewares != nil && !frontend.SkipDefaultMiddlewares
 			for _, entryPointName := range frontend.EntryPoints {
 				log.Debugf("Wiring frontend %s to entryPoint %s", frontendName, entryPointName)
 
@@ -925,128 +1551,176 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						redirectHandlers[entryPointName] = handlerToUse
 					}
 				}
-				if backends[entryPointName+frontend.Backend] == nil {
-					log.Debugf("Creating backend %s", frontend.Backend)
-
-					roundTripper, err := s.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS)
-					if err != nil {
-						log.Errorf("Failed to create RoundTripper for frontend %s: %v", frontendName, err)
+				if frontend.StaticResponse != nil {
+					log.Debugf("Creating static response for frontend %s", frontendName)
+					if err := s.buildStaticResponseBackend(n, frontendName, frontend); err != nil {
+						log.Errorf("Error creating the static response for frontend %s: %v", frontendName, err)
 						log.Errorf("Skipping frontend %s...", frontendName)
 						continue frontend
 					}
-
-					rewriter, err := NewHeaderRewriter(entryPoint.ForwardedHeaders.TrustedIPs, entryPoint.ForwardedHeaders.Insecure)
-					if err != nil {
-						log.Errorf("Error creating rewriter for frontend %s: %v", frontendName, err)
-						log.Errorf("Skipping frontend %s...", frontendName)
-						continue frontend
-					}
-
+				} else {
 					headerMiddleware := middlewares.NewHeaderFromStruct(frontend.Headers)
-					var responseModifier func(res *http.Response) error
-					if headerMiddleware != nil {
-						responseModifier = headerMiddleware.ModifyResponseHeaders
+					var defaultHeaderMiddleware *middlewares.HeaderStruct
+					if useDefaultMiddlewares {
+						defaultHeaderMiddleware = middlewares.NewHeaderFromStruct(globalConfiguration.DefaultMiddlewares.Headers)
 					}
-
-					var fwd http.Handler
-
-					fwd, err = forward.New(
-						forward.Stream(true),
-						forward.PassHostHeader(frontend.PassHostHeader),
-						forward.RoundTripper(roundTripper),
-						forward.ErrorHandler(errorHandler),
-						forward.Rewriter(rewriter),
-						forward.ResponseModifier(responseModifier),
-					)
-
-					if err != nil {
-						log.Errorf("Error creating forwarder for frontend %s: %v", frontendName, err)
-						log.Errorf("Skipping frontend %s...", frontendName)
-						continue frontend
+					var cors *middlewares.CORS
+					if frontend.CORS != nil {
+						cors, err = middlewares.NewCORS(frontend.CORS)
+						if err != nil {
+							log.Errorf("Error creating CORS for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
 					}
 
-					if s.tracingMiddleware.IsEnabled() {
-						tm := s.tracingMiddleware.NewForwarderMiddleware(frontendName, frontend.Backend)
-
-						next := fwd
-						fwd = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-							tm.ServeHTTP(w, r, next.ServeHTTP)
-						})
+					var responseModifiers []func(res *http.Response) error
+					if defaultHeaderMiddleware != nil {
+						responseModifiers = append(responseModifiers, defaultHeaderMiddleware.ModifyResponseHeaders)
 					}
-
-					var rr *roundrobin.RoundRobin
-					var saveFrontend http.Handler
-					if s.accessLoggerMiddleware != nil {
-						saveBackend := accesslog.NewSaveBackend(fwd, frontend.Backend)
-						saveFrontend = accesslog.NewSaveFrontend(saveBackend, frontendName)
-						rr, _ = roundrobin.New(saveFrontend)
-					} else {
-						rr, _ = roundrobin.New(fwd)
+					// the headers of the frontend are set after the default ones, to override them
+					if headerMiddleware != nil {
+						responseModifiers = append(responseModifiers, headerMiddleware.ModifyResponseHeaders)
+					}
+					if locationRewrite := middlewares.NewLocationRewrite(frontend.LocationRewrite); locationRewrite != nil {
+						responseModifiers = append(responseModifiers, locationRewrite.ModifyResponseHeaders)
+					}
+					if cors != nil {
+						responseModifiers = append(responseModifiers, cors.ModifyResponseHeaders)
 					}
 
-					if config.Backends[frontend.Backend] == nil {
-						log.Errorf("Undefined backend '%s' for frontend %s", frontend.Backend, frontendName)
-						log.Errorf("Skipping frontend %s...", frontendName)
-						continue frontend
+					var responseModifier func(res *http.Response) error
+					switch len(responseModifiers) {
+					case 0:
+					case 1:
+						responseModifier = responseModifiers[0]
+					default:
+						responseModifier = func(res *http.Response) error {
+							for _, modify := range responseModifiers {
+								if err := modify(res); err != nil {
+									return err
+								}
+							}
+							return nil
+						}
 					}
 
-					lbMethod, err := types.NewLoadBalancerMethod(config.Backends[frontend.Backend].LoadBalancer)
+					noServerHandler, err := middlewares.NewNoServerHandler(frontend.NoServer)
 					if err != nil {
-						log.Errorf("Error loading load balancer method '%+v' for frontend %s: %v", config.Backends[frontend.Backend].LoadBalancer, frontendName, err)
+						log.Errorf("Error creating the no server response for frontend %s: %v", frontendName, err)
 						log.Errorf("Skipping frontend %s...", frontendName)
 						continue frontend
 					}
 
-					var sticky *roundrobin.StickySession
-					var cookieName string
-					if stickiness := config.Backends[frontend.Backend].LoadBalancer.Stickiness; stickiness != nil {
-						cookieName = cookie.GetName(stickiness.CookieName, frontend.Backend)
-						sticky = roundrobin.NewStickySession(cookieName)
+					// a frontend splitting its requests across weighted backends, or selecting them by language,
+					// gets the handler of each of them, which is otherwise the handler of its backend
+					backendNames := []string{frontend.Backend}
+					if len(frontend.WeightedBackends) > 0 {
+						if err := checkWeightedBackends(frontend, config.Backends); err != nil {
+							log.Errorf("Error creating the weighted backends for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendNames = nil
+						for _, weightedBackend := range frontend.WeightedBackends {
+							backendNames = append(backendNames, weightedBackend.Backend)
+						}
+					} else if len(frontend.LanguageBackends) > 0 {
+						if err := checkLanguageBackends(frontend, config.Backends); err != nil {
+							log.Errorf("Error creating the language backends for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						backendNames = languageBackendNames(frontend)
 					}
-
+					// the options of the frontend are applied by the forwarders of its backends, which are shared with the other frontends
+					forwarding := &frontendForwarding{
+						responseModifier: responseModifier,
+						hostHeader:       frontend.CustomHost,
+						passHostHeader:   frontend.PassHostHeader,
+						noServer:         noServerHandler,
+						backends:         make(map[string]*frontendBackend),
+					}
+					multipleBackends := len(frontend.WeightedBackends) > 0 || len(frontend.LanguageBackends) > 0
+					weighted := middlewares.NewWeightedBackends()
+					backendHandlers := make(map[string]http.Handler)
 					var lb http.Handler
-					switch lbMethod {
-					case types.Drr:
-						log.Debugf("Creating load-balancer drr")
-						rebalancer, _ := roundrobin.NewRebalancer(rr)
-						if sticky != nil {
-							log.Debugf("Sticky session with cookie %v", cookieName)
-							rebalancer, _ = roundrobin.NewRebalancer(rr, roundrobin.RebalancerStickySession(sticky))
-						}
-						lb = rebalancer
-						if err := s.configureLBServers(rebalancer, config, frontend); err != nil {
+
+					for backendIndex, backendName := range backendNames {
+						backendConfig := config.Backends[backendName]
+						if backendConfig == nil {
+							log.Errorf("Undefined backend '%s' for frontend %s", backendName, frontendName)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
-						hcOpts := parseHealthCheckOptions(rebalancer, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
-						if hcOpts != nil {
-							log.Debugf("Setting up backend health check %s", *hcOpts)
-							hcOpts.Transport = s.defaultForwardingRoundTripper
-							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts, frontend.Backend)
+
+						backendKey := entryPointName + backendName
+						backend := backends[backendKey]
+						if backend == nil {
+							log.Debugf("Creating backend %s", backendName)
+							backend, err = s.buildBackend(entryPointName, globalConfiguration, backendName, backendConfig, errorHandler, serverEntryPoints[entryPointName], backendsHealthCheck)
+							if err != nil {
+								log.Errorf("Error creating backend %s for frontend %s: %v", backendName, frontendName, err)
+								log.Errorf("Skipping frontend %s...", frontendName)
+								continue frontend
+							}
+							backends[backendKey] = backend
+						} else {
+							log.Debugf("Reusing backend %s", backendName)
 						}
-						lb = middlewares.NewEmptyBackendHandler(rebalancer, lb)
-					case types.Wrr:
-						log.Debugf("Creating load-balancer wrr")
-						if sticky != nil {
-							log.Debugf("Sticky session with cookie %v", cookieName)
-							if s.accessLoggerMiddleware != nil {
-								rr, _ = roundrobin.New(saveFrontend, roundrobin.EnableStickySession(sticky))
-							} else {
-								rr, _ = roundrobin.New(fwd, roundrobin.EnableStickySession(sticky))
+
+						frontendBackend := &frontendBackend{}
+						// a frontend passing the TLS certificates, or with its own timeouts, has its own transport to the servers
+						if frontend.PassTLSCert || forwardingTimeouts != nil {
+							frontendBackend.roundTripper, err = s.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS, backendConfig, forwardingTimeouts)
+							if err != nil {
+								log.Errorf("Failed to create RoundTripper for frontend %s: %v", frontendName, err)
+								log.Errorf("Skipping frontend %s...", frontendName)
+								continue frontend
 							}
 						}
-						lb = rr
-						if err := s.configureLBServers(rr, config, frontend); err != nil {
+						if s.tracingMiddleware.IsEnabled() {
+							frontendBackend.tracing = s.tracingMiddleware.NewForwarderMiddleware(frontendName, backendName)
+						}
+						if frontend.HeaderOverride != nil {
+							frontendBackend.loadBalancer, err = s.buildHeaderOverride(backend.loadBalancer, backend.forwarder, frontendName, frontend, config.Backends)
+							if err != nil {
+								log.Errorf("Error creating the header override for frontend %s: %v", frontendName, err)
+								log.Errorf("Skipping frontend %s...", frontendName)
+								continue frontend
+							}
+						}
+						forwarding.backends[backendName] = frontendBackend
+
+						if !multipleBackends {
+							lb = backend.handler
+							continue
+						}
+						backendLB := backend.handler
+						if s.metricsRegistry.IsEnabled() {
+							// the metrics of the requests are recorded for the weighted or language backend which was selected
+							backendNegroni := negroni.New(middlewares.NewBackendMetricsMiddleware(s.metricsRegistry, backendName))
+							backendNegroni.UseHandler(backendLB)
+							backendLB = backendNegroni
+						}
+						if len(frontend.WeightedBackends) > 0 {
+							weighted.AddBackend(backendName, frontend.WeightedBackends[backendIndex].Weight, backendLB, backend.servers)
+						}
+						backendHandlers[backendName] = backendLB
+					}
+					if len(frontend.WeightedBackends) > 0 {
+						lb = weighted
+					} else if len(frontend.LanguageBackends) > 0 {
+						localeHandlers := make(map[string]http.Handler)
+						for locale, backendName := range frontend.LanguageBackends {
+							localeHandlers[locale] = backendHandlers[backendName]
+						}
+						lb, err = middlewares.NewLanguageBackends(localeHandlers, backendHandlers[frontend.Backend])
+						if err != nil {
+							log.Errorf("Error creating the language backends for frontend %s: %v", frontendName, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
-						hcOpts := parseHealthCheckOptions(rr, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
-						if hcOpts != nil {
-							log.Debugf("Setting up backend health check %s", *hcOpts)
-							hcOpts.Transport = s.defaultForwardingRoundTripper
-							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts, frontend.Backend)
-						}
-						lb = middlewares.NewEmptyBackendHandler(rr, lb)
 					}
 
 					if len(frontend.Errors) > 0 {
@@ -1064,41 +1738,40 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						}
 					}
 
-					if frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0 {
-						lb, err = s.buildRateLimiter(lb, frontend.RateLimit)
-						lb = s.wrapHTTPHandlerWithAccessLog(lb, fmt.Sprintf("rate limit for %s", frontendName))
+					if frontend.StatusMapping != nil {
+						statusMappingHandler, err := newStatusMappingHandler(config, frontend.StatusMapping)
 						if err != nil {
-							log.Errorf("Error creating rate limiter: %v", err)
+							log.Errorf("Error creating status mapping for frontend %s: %v", frontendName, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
+						n.Use(statusMappingHandler)
 					}
 
-					maxConns := config.Backends[frontend.Backend].MaxConn
-					if maxConns != nil && maxConns.Amount != 0 {
-						extractFunc, err := utils.NewExtractor(maxConns.ExtractorFunc)
-						if err != nil {
-							log.Errorf("Error creating connlimit: %v", err)
-							log.Errorf("Skipping frontend %s...", frontendName)
-							continue frontend
-						}
-						log.Debugf("Creating load-balancer connlimit")
-						lb, err = connlimit.New(lb, extractFunc, maxConns.Amount)
-						lb = s.wrapHTTPHandlerWithAccessLog(lb, fmt.Sprintf("connection limit for %s", frontendName))
+					if frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0 {
+						lb, err = s.buildRateLimiter(lb, frontendName, frontend.RateLimit)
+						lb = s.wrapHTTPHandlerWithAccessLog(lb, fmt.Sprintf("rate limit for %s", frontendName))
 						if err != nil {
-							log.Errorf("Error creating connlimit: %v", err)
+							log.Errorf("Error creating rate limiter: %v", err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
 					}
 
-					if globalConfiguration.Retry != nil {
-						countServers := len(config.Backends[frontend.Backend].Servers)
-						lb = s.buildRetryMiddleware(lb, globalConfiguration, countServers, frontend.Backend)
+					if s.metricsRegistry.IsEnabled() && !multipleBackends {
+						n.Use(middlewares.NewBackendMetricsMiddleware(s.metricsRegistry, frontend.Backend))
 					}
 
-					if s.metricsRegistry.IsEnabled() {
-						n.Use(middlewares.NewBackendMetricsMiddleware(s.metricsRegistry, frontend.Backend))
+					if useDefaultMiddlewares {
+						defaultMiddlewares, err := s.buildDefaultMiddlewares(globalConfiguration.DefaultMiddlewares, defaultHeaderMiddleware, frontendName)
+						if err != nil {
+							log.Errorf("Error creating the default middlewares for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						for _, defaultMiddleware := range defaultMiddlewares {
+							n.Use(defaultMiddleware)
+						}
 					}
 
 					ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(frontend.WhitelistSourceRange)
@@ -1120,6 +1793,34 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						}
 					}
 
+					// the preflight requests are answered before being rejected for their missing headers or credentials
+					if cors != nil {
+						log.Debugf("Adding CORS middleware for frontend %s", frontendName)
+						n.Use(s.wrapNegroniHandlerWithAccessLog(s.tracingMiddleware.NewNegroniHandlerWrapper("CORS", cors, false), fmt.Sprintf("CORS for %s", frontendName)))
+					}
+
+					if len(frontend.RequiredHeaders) > 0 {
+						requiredHeaders, err := middlewares.NewRequiredHeaders(frontend.RequiredHeaders)
+						if err != nil {
+							log.Errorf("Error creating required headers for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						log.Debugf("Adding required headers middleware for frontend %s", frontendName)
+						n.Use(s.wrapNegroniHandlerWithAccessLog(requiredHeaders, fmt.Sprintf("required headers for %s", frontendName)))
+					}
+
+					if frontend.JWTAuth != nil {
+						jwtAuth, err := mauth.NewJWTAuth(frontend.JWTAuth)
+						if err != nil {
+							log.Errorf("Error creating JWT auth for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						log.Debugf("Adding JWT auth middleware for frontend %s", frontendName)
+						n.Use(s.wrapNegroniHandlerWithAccessLog(s.tracingMiddleware.NewNegroniHandlerWrapper("JWT Auth", jwtAuth, false), fmt.Sprintf("JWT auth for %s", frontendName)))
+					}
+
 					if len(frontend.BasicAuth) > 0 {
 						users := types.Users{}
 						for _, user := range frontend.BasicAuth {
@@ -1149,37 +1850,88 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 						n.UseFunc(secureMiddleware.HandlerFuncWithNext)
 					}
 
-					if config.Backends[frontend.Backend].Buffering != nil {
-						bufferedLb, err := s.buildBufferingMiddleware(lb, config.Backends[frontend.Backend].Buffering)
-
-						if err != nil {
-							log.Errorf("Error setting up buffering middleware: %s", err)
-						} else {
-							lb = bufferedLb
-						}
+					// the responses are only shared between the clients passing the access control of the frontend
+					if frontend.SingleFlight != nil {
+						lb = middlewares.NewSingleFlight(lb, frontend.SingleFlight)
 					}
-
-					if config.Backends[frontend.Backend].CircuitBreaker != nil {
-						log.Debugf("Creating circuit breaker %s", config.Backends[frontend.Backend].CircuitBreaker.Expression)
-						expression := config.Backends[frontend.Backend].CircuitBreaker.Expression
-						circuitBreaker, err := middlewares.NewCircuitBreaker(lb, expression, middlewares.NewCircuitBreakerOptions(expression))
+					// the requests missing the cache can then be coalesced
+					if frontend.Cache != nil {
+						cache, err := middlewares.NewCache(lb, frontend.Cache)
 						if err != nil {
-							log.Errorf("Error creating circuit breaker: %v", err)
+							log.Errorf("Error setting up the cache of frontend %s: %v", frontendName, err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
-						n.Use(s.tracingMiddleware.NewNegroniHandlerWrapper("Circuit breaker", circuitBreaker, false))
-					} else {
-						n.UseHandler(lb)
+						lb = cache
 					}
-					backends[entryPointName+frontend.Backend] = n
-				} else {
-					log.Debugf("Reusing backend %s", frontend.Backend)
+
+					if s.accessLoggerMiddleware != nil {
+						lb = accesslog.NewSaveFrontend(lb, frontendName)
+					}
+					n.UseHandler(newFrontendForwardingHandler(lb, forwarding))
+				}
+				newServerRoute.route.Priority(priorities[providerName][frontendName])
+
+				var backendHandler http.Handler = n
+				if frontend.Buffering != nil {
+					// the backend handler can be shared between frontends, so the buffering is added in front of it
+					bufferedHandler, err := s.buildBufferingMiddleware(backendHandler, frontend.Buffering)
+					if err != nil {
+						log.Errorf("Error setting up buffering middleware for frontend %s: %s", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					backendHandler = bufferedHandler
+				}
+				if requestTimeout > 0 {
+					backendHandler = &middlewares.RequestTimeout{
+						Handler: backendHandler,
+						Timeout: requestTimeout,
+					}
+				}
+				if frontend.Mirroring != nil {
+					mirror, err := s.buildMirrorMiddleware(backendHandler, frontend.Mirroring, config.Backends)
+					if err != nil {
+						log.Errorf("Error setting up mirroring for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					backendHandler = mirror
 				}
-				if frontend.Priority > 0 {
-					newServerRoute.route.Priority(frontend.Priority)
+				if frontend.GRPCWeb != nil {
+					log.Debugf("Adding gRPC-Web translation for frontend %s", frontendName)
+					backendHandler = middlewares.NewGRPCWeb(backendHandler, frontend.GRPCWeb)
 				}
-				s.wireFrontendBackend(newServerRoute, backends[entryPointName+frontend.Backend])
+				if s.accessLoggerMiddleware != nil && frontend.AccessLog != nil {
+					accessLogFilter, err := accesslog.NewFilter(frontend.AccessLog)
+					if err != nil {
+						log.Errorf("Error creating the access log filter for frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					backendHandler = accesslog.NewSaveFilter(backendHandler, accessLogFilter)
+				}
+				// the headers are removed once all the middlewares of the frontend have modified the response
+				if frontend.Headers != nil && len(frontend.Headers.RemovedResponseHeaders) > 0 {
+					backendHandler = middlewares.NewResponseHeadersRemover(backendHandler, frontend.Headers.RemovedResponseHeaders)
+				}
+				if frontend.FormJSON != nil {
+					formJSON, err := middlewares.NewFormJSON(backendHandler, frontend.FormJSON)
+					if err != nil {
+						log.Errorf("Error setting up the form to JSON conversion of frontend %s: %v", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					log.Debugf("Adding form to JSON conversion for frontend %s", frontendName)
+					backendHandler = formJSON
+				}
+
+				s.wireFrontendBackend(newServerRoute, backendHandler)
+				entryPointsRoutes[entryPointName] = append(entryPointsRoutes[entryPointName], frontendRoute{
+					name:     frontendName,
+					priority: frontend.Priority,
+					rules:    sortedRules(frontend.Routes),
+				})
 
 				err := newServerRoute.route.GetError()
 				if err != nil {
@@ -1188,6 +1940,12 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 			}
 		}
 	}
+	for entryPointName, routes := range entryPointsRoutes {
+		if err := checkAmbiguousRoutes(routes, globalConfiguration.AmbiguousRoutes); err != nil {
+			return nil, fmt.Errorf("ambiguous routes on entrypoint %s: %v", entryPointName, err)
+		}
+	}
+
 	healthcheck.GetHealthCheck(s.metricsRegistry).SetBackendsConfiguration(s.routinesPool.Ctx(), backendsHealthCheck)
 	// Get new certificates list sorted per entrypoints
 	// Update certificates
@@ -1197,15 +1955,271 @@ func (s *Server) loadConfig(configurations types.Configurations, globalConfigura
 		serverEntryPoint.httpRouter.GetHandler().SortRoutes()
 		_, exists := entryPointsCertificates[serverEntryPointName]
 		if exists {
-			serverEntryPoint.certs.Set(entryPointsCertificates[serverEntryPointName])
+			if entryPoint := globalConfiguration.EntryPoints[serverEntryPointName]; entryPoint != nil && entryPoint.TLS != nil && entryPoint.TLS.CompleteChains {
+				entryPointsCertificates[serverEntryPointName].CompleteChains(s.chainCompleter)
+			}
+			serverEntryPoint.setCertificates(entryPointsCertificates[serverEntryPointName])
 		}
 	}
 
 	return serverEntryPoints, err
 }
 
-func (s *Server) configureLBServers(lb healthcheck.LoadBalancer, config *types.Configuration, frontend *types.Frontend) error {
-	for name, srv := range config.Backends[frontend.Backend].Servers {
+// buildBackend creates the load balancer of a backend on an entrypoint, shared by the frontends forwarding their requests to it,
+// along with its health check and circuit breakers
+func (s *Server) buildBackend(entryPointName string, globalConfiguration configuration.GlobalConfiguration, backendName string, backend *types.Backend,
+	errorHandler utils.ErrorHandler, serverEntryPoint *serverEntryPoint, backendsHealthCheck map[string]*healthcheck.BackendHealthCheck) (*sharedBackend, error) {
+	entryPoint := globalConfiguration.EntryPoints[entryPointName]
+	rewriter, err := NewHeaderRewriter(entryPoint.ForwardedHeaders.TrustedIPs, entryPoint.ForwardedHeaders.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rewriter: %v", err)
+	}
+
+	backend, err = translatePercentWeights(backend)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the weights: %v", err)
+	}
+
+	roundTripper, err := s.getRoundTripper(entryPointName, globalConfiguration, false, entryPoint.TLS, backend, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RoundTripper: %v", err)
+	}
+	frontendRoundTripper := &frontendRoundTripper{RoundTripper: roundTripper, backendName: backendName}
+
+	var fwd http.Handler
+	fwd, err = forward.New(
+		forward.Stream(true),
+		forward.PassHostHeader(true),
+		forward.RoundTripper(frontendRoundTripper),
+		forward.ErrorHandler(errorHandler),
+		forward.Rewriter(rewriter),
+		forward.ResponseModifier(modifyFrontendResponse),
+		forward.WebsocketTLSClientConfig(frontendRoundTripper.websocketTLSClientConfig()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating forwarder: %v", err)
+	}
+
+	var webSocketWriteTimeout, webSocketIdleTimeout time.Duration
+	if backend.WebSocket != nil {
+		webSocketWriteTimeout, err = parseWebSocketTimeout(backend.WebSocket.WriteTimeout)
+		if err == nil {
+			webSocketIdleTimeout, err = parseWebSocketTimeout(backend.WebSocket.IdleTimeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error loading WebSocket configuration: %v", err)
+		}
+	}
+	fwd = middlewares.NewWebSocket(fwd, backendName, webSocketWriteTimeout, webSocketIdleTimeout, s.webSocketConns)
+
+	// an explicit host header on the frontend, then on the backend, takes precedence over the client's one
+	fwd = &frontendHostHeader{next: fwd, hostHeader: backend.HostHeader}
+
+	if s.tracingMiddleware.IsEnabled() {
+		fwd = &frontendTracing{next: fwd, backendName: backendName}
+	}
+
+	var serverCircuitBreaker *middlewares.ServerCircuitBreaker
+	if backend.CircuitBreaker != nil && backend.CircuitBreaker.PerServer != nil {
+		serverCircuitBreaker, err = newServerCircuitBreaker(fwd, backendName, backend)
+		if err != nil {
+			return nil, fmt.Errorf("error creating the per-server circuit breaker: %v", err)
+		}
+		fwd = serverCircuitBreaker
+	}
+	forwarder := fwd
+
+	if s.accessLoggerMiddleware != nil {
+		fwd = accesslog.NewSaveBackend(fwd, backendName)
+	}
+	rr, _ := roundrobin.New(fwd)
+
+	lbMethod, err := types.NewLoadBalancerMethod(backend.LoadBalancer)
+	if err != nil {
+		return nil, fmt.Errorf("error loading load balancer method '%+v': %v", backend.LoadBalancer, err)
+	}
+
+	var sticky *roundrobin.StickySession
+	var cookieName string
+	if stickiness := backend.LoadBalancer.Stickiness; stickiness != nil {
+		cookieName = cookie.GetName(stickiness.CookieName, backendName)
+		sticky = roundrobin.NewStickySession(cookieName)
+	}
+
+	// the health checks of the backends expecting the Proxy Protocol are sent with its header
+	healthCheckTransport := s.defaultForwardingRoundTripper
+	if backend.ProxyProtocol != nil {
+		healthCheckTransport = roundTripper
+	}
+
+	// the requests are sent to the servers by the load balancer, then by the forwarder with the options of their frontend
+	noServerHandler := http.HandlerFunc(serveFrontendNoServer)
+	var backendLB http.Handler
+	var lbServers healthcheck.LoadBalancer
+	switch lbMethod {
+	case types.Drr:
+		log.Debugf("Creating load-balancer drr")
+		rebalancer, _ := roundrobin.NewRebalancer(rr)
+		if sticky != nil {
+			log.Debugf("Sticky session with cookie %v", cookieName)
+			rebalancer, _ = roundrobin.NewRebalancer(rr, roundrobin.RebalancerStickySession(sticky))
+		}
+		lbServers = wrapTieredLoadBalancer(rebalancer, backend)
+		backendLB = middlewares.NewEmptyBackendHandler(rebalancer, rebalancer, noServerHandler)
+	case types.Wrr:
+		log.Debugf("Creating load-balancer wrr")
+		if sticky != nil {
+			log.Debugf("Sticky session with cookie %v", cookieName)
+			rr, _ = roundrobin.New(fwd, roundrobin.EnableStickySession(sticky))
+		}
+		lbServers = wrapTieredLoadBalancer(rr, backend)
+		backendLB = middlewares.NewEmptyBackendHandler(rr, rr, noServerHandler)
+	case types.LeastConn:
+		log.Debugf("Creating load-balancer leastconn")
+		if sticky != nil {
+			log.Debugf("Sticky session with cookie %v", cookieName)
+		}
+		leastConn := middlewares.NewLeastConn(fwd, sticky)
+		lbServers = wrapTieredLoadBalancer(leastConn, backend)
+		backendLB = middlewares.NewEmptyBackendHandler(leastConn, leastConn, noServerHandler)
+	}
+	if err := s.configureLBServers(lbServers, backendName, backend); err != nil {
+		return nil, err
+	}
+	hcOpts, err := parseHealthCheckOptions(lbServers, backendName, backend.HealthCheck, globalConfiguration.HealthCheck)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the health check: %v", err)
+	}
+	if hcOpts != nil {
+		log.Debugf("Setting up backend health check %s", *hcOpts)
+		hcOpts.Transport = healthCheckTransport
+		backendsHealthCheck[entryPointName+backendName] = healthcheck.NewBackendHealthCheck(*hcOpts, backendName)
+	}
+
+	if serverCircuitBreaker != nil {
+		serverCircuitBreaker.SetLoadBalancer(lbServers)
+		serverEntryPoint.serverCircuitBreakers = append(serverEntryPoint.serverCircuitBreakers, serverCircuitBreaker)
+	}
+
+	loadBalancer := backendLB
+	// a frontend can override the servers selected by the load balancer
+	backendLB = &frontendLoadBalancer{next: backendLB, backendName: backendName}
+
+	maxConns := backend.MaxConn
+	if maxConns != nil && maxConns.Amount != 0 {
+		extractFunc, err := utils.NewExtractor(maxConns.ExtractorFunc)
+		if err != nil {
+			return nil, fmt.Errorf("error creating connlimit: %v", err)
+		}
+		log.Debugf("Creating load-balancer connlimit")
+		backendLB, err = connlimit.New(backendLB, extractFunc, maxConns.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("error creating connlimit: %v", err)
+		}
+		backendLB = s.wrapHTTPHandlerWithAccessLog(backendLB, fmt.Sprintf("connection limit for %s", backendName))
+	}
+
+	if globalConfiguration.Retry != nil {
+		countServers := len(backend.Servers)
+		backendLB, err = s.buildRetryMiddleware(backendLB, globalConfiguration, countServers, backend.RetryBudget, backendName)
+		if err != nil {
+			return nil, fmt.Errorf("error creating retry middleware: %v", err)
+		}
+	}
+
+	if backend.Buffering != nil {
+		bufferedLb, err := s.buildBufferingMiddleware(backendLB, backend.Buffering)
+
+		if err != nil {
+			log.Errorf("Error setting up buffering middleware: %s", err)
+		} else {
+			backendLB = bufferedLb
+		}
+	}
+
+	if backend.CircuitBreaker != nil && len(backend.CircuitBreaker.Expression) > 0 {
+		log.Debugf("Creating circuit breaker %s", backend.CircuitBreaker.Expression)
+		expression := backend.CircuitBreaker.Expression
+		circuitBreaker, err := middlewares.NewCircuitBreaker(backendLB, backendName, entryPointName, expression, middlewares.NewCircuitBreakerOptions(expression))
+		if err != nil {
+			return nil, fmt.Errorf("error creating circuit breaker: %v", err)
+		}
+		serverEntryPoint.circuitBreakers = append(serverEntryPoint.circuitBreakers, circuitBreaker)
+		backendLB = negroni.New(s.tracingMiddleware.NewNegroniHandlerWrapper("Circuit breaker", circuitBreaker, false))
+	}
+
+	return &sharedBackend{
+		handler:      backendLB,
+		loadBalancer: loadBalancer,
+		forwarder:    forwarder,
+		servers:      lbServers,
+	}, nil
+}
+
+// buildStaticResponseBackend completes the middlewares of a frontend sending a static response,
+// which only restricts the access to it before the response is sent
+func (s *Server) buildStaticResponseBackend(n *negroni.Negroni, frontendName string, frontend *types.Frontend) error {
+	staticResponse, err := middlewares.NewStaticResponse(frontend.StaticResponse)
+	if err != nil {
+		return err
+	}
+
+	ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(frontend.WhitelistSourceRange)
+	if err != nil {
+		return err
+	}
+	if ipWhitelistMiddleware != nil {
+		ipWhitelistMiddleware = s.wrapNegroniHandlerWithAccessLog(ipWhitelistMiddleware, fmt.Sprintf("ipwhitelister for %s", frontendName))
+		n.Use(s.tracingMiddleware.NewNegroniHandlerWrapper("IP whitelist", ipWhitelistMiddleware, false))
+	}
+
+	if frontend.CORS != nil {
+		cors, err := middlewares.NewCORS(frontend.CORS)
+		if err != nil {
+			return err
+		}
+		n.Use(s.wrapNegroniHandlerWithAccessLog(s.tracingMiddleware.NewNegroniHandlerWrapper("CORS", cors, false), fmt.Sprintf("CORS for %s", frontendName)))
+		n.UseFunc(cors.SetResponseHeaders)
+	}
+
+	if len(frontend.RequiredHeaders) > 0 {
+		requiredHeaders, err := middlewares.NewRequiredHeaders(frontend.RequiredHeaders)
+		if err != nil {
+			return err
+		}
+		n.Use(s.wrapNegroniHandlerWithAccessLog(requiredHeaders, fmt.Sprintf("required headers for %s", frontendName)))
+	}
+
+	if frontend.JWTAuth != nil {
+		jwtAuth, err := mauth.NewJWTAuth(frontend.JWTAuth)
+		if err != nil {
+			return err
+		}
+		n.Use(s.wrapNegroniHandlerWithAccessLog(s.tracingMiddleware.NewNegroniHandlerWrapper("JWT Auth", jwtAuth, false), fmt.Sprintf("JWT auth for %s", frontendName)))
+	}
+
+	if len(frontend.BasicAuth) > 0 {
+		auth := &types.Auth{
+			Basic: &types.Basic{Users: types.Users(frontend.BasicAuth)},
+		}
+		authMiddleware, err := mauth.NewAuthenticator(auth, s.tracingMiddleware)
+		if err != nil {
+			return err
+		}
+		n.Use(s.wrapNegroniHandlerWithAccessLog(authMiddleware, fmt.Sprintf("Auth for %s", frontendName)))
+	}
+
+	n.UseHandler(s.wrapHTTPHandlerWithAccessLog(staticResponse, frontendName))
+	return nil
+}
+
+func (s *Server) configureLBServers(lb healthcheck.LoadBalancer, backendName string, backend *types.Backend) error {
+	if backend.Draining {
+		log.Infof("Backend %s is draining, not adding its servers to the load balancer", backendName)
+		return nil
+	}
+
+	for name, srv := range backend.Servers {
 		u, err := url.Parse(srv.URL)
 		if err != nil {
 			log.Errorf("Error parsing server URL %s: %v", srv.URL, err)
@@ -1216,11 +2230,108 @@ func (s *Server) configureLBServers(lb healthcheck.LoadBalancer, config *types.C
 			log.Errorf("Error adding server %s to load balancer: %v", srv.URL, err)
 			return err
 		}
-		s.metricsRegistry.BackendServerUpGauge().With("backend", frontend.Backend, "url", srv.URL).Set(1)
+		s.metricsRegistry.BackendServerUpGauge().With("backend", backendName, "url", srv.URL).Set(1)
 	}
 	return nil
 }
 
+// newServerCircuitBreaker creates the per-server circuit breaker of a backend, restoring the weights of its servers when they are re-admitted
+func newServerCircuitBreaker(next http.Handler, backendName string, backend *types.Backend) (*middlewares.ServerCircuitBreaker, error) {
+	config := backend.CircuitBreaker.PerServer
+	if config.ConsecutiveFailures < 0 {
+		return nil, fmt.Errorf("invalid consecutive failures %d: it must not be negative", config.ConsecutiveFailures)
+	}
+
+	var cooldown time.Duration
+	if len(config.Cooldown) > 0 {
+		var err error
+		cooldown, err = time.ParseDuration(config.Cooldown)
+		if err != nil || cooldown <= 0 {
+			return nil, fmt.Errorf("invalid cooldown %q: it must be a positive duration", config.Cooldown)
+		}
+	}
+
+	var maxResponseTime time.Duration
+	if len(config.MaxResponseTime) > 0 {
+		var err error
+		maxResponseTime, err = time.ParseDuration(config.MaxResponseTime)
+		if err != nil || maxResponseTime <= 0 {
+			return nil, fmt.Errorf("invalid max response time %q: it must be a positive duration", config.MaxResponseTime)
+		}
+	}
+	if config.ResponseTimeSamples < 0 {
+		return nil, fmt.Errorf("invalid response time samples %d: it must not be negative", config.ResponseTimeSamples)
+	}
+
+	weights := make(map[string]int)
+	for _, srv := range backend.Servers {
+		if u, err := url.Parse(srv.URL); err == nil {
+			weights[u.String()] = srv.Weight
+		}
+	}
+
+	circuitBreaker := middlewares.NewServerCircuitBreaker(next, backendName, config.ConsecutiveFailures, cooldown, weights)
+	if maxResponseTime > 0 {
+		circuitBreaker.SetMaxResponseTime(maxResponseTime, config.ResponseTimeSamples)
+	}
+	return circuitBreaker, nil
+}
+
+// wrapTieredLoadBalancer wraps lb into a TieredLoadBalancer when the servers of the backend are spread over several tiers.
+func wrapTieredLoadBalancer(lb healthcheck.LoadBalancer, backend *types.Backend) healthcheck.LoadBalancer {
+	tiers := make(map[string]int)
+	distinctTiers := make(map[int]bool)
+	for _, srv := range backend.Servers {
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			continue
+		}
+		tiers[u.String()] = srv.Tier
+		distinctTiers[srv.Tier] = true
+	}
+
+	if len(distinctTiers) < 2 {
+		return lb
+	}
+	return healthcheck.NewTieredLoadBalancer(lb, tiers)
+}
+
+// buildDefaultMiddlewares returns the middlewares applied to the frontends before their own ones, in this order:
+// IP whitelisting, basic authentication, custom headers and security headers.
+func (s *Server) buildDefaultMiddlewares(defaults *configuration.DefaultMiddlewares, headerMiddleware *middlewares.HeaderStruct, frontendName string) ([]negroni.Handler, error) {
+	var handlers []negroni.Handler
+
+	ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(defaults.WhitelistSourceRange)
+	if err != nil {
+		return nil, fmt.Errorf("error creating IP Whitelister: %v", err)
+	}
+	if ipWhitelistMiddleware != nil {
+		ipWhitelistMiddleware = s.wrapNegroniHandlerWithAccessLog(ipWhitelistMiddleware, fmt.Sprintf("default ipwhitelister for %s", frontendName))
+		handlers = append(handlers, s.tracingMiddleware.NewNegroniHandlerWrapper("Default IP whitelist", ipWhitelistMiddleware, false))
+	}
+
+	if len(defaults.BasicAuth) > 0 {
+		auth := &types.Auth{
+			Basic: &types.Basic{Users: types.Users(defaults.BasicAuth)},
+		}
+		authMiddleware, err := mauth.NewAuthenticator(auth, s.tracingMiddleware)
+		if err != nil {
+			return nil, fmt.Errorf("error creating Auth: %v", err)
+		}
+		handlers = append(handlers, s.wrapNegroniHandlerWithAccessLog(authMiddleware, fmt.Sprintf("default Auth for %s", frontendName)))
+	}
+
+	if headerMiddleware != nil {
+		handlers = append(handlers, s.tracingMiddleware.NewNegroniHandlerWrapper("Default header", headerMiddleware, false))
+	}
+
+	if secureMiddleware := middlewares.NewSecure(defaults.Headers); secureMiddleware != nil {
+		handlers = append(handlers, negroni.HandlerFunc(secureMiddleware.HandlerFuncWithNext))
+	}
+
+	return handlers, nil
+}
+
 func configureIPWhitelistMiddleware(whitelistSourceRanges []string) (negroni.Handler, error) {
 	if len(whitelistSourceRanges) > 0 {
 		ipSourceRanges := whitelistSourceRanges
@@ -1236,6 +2347,19 @@ func configureIPWhitelistMiddleware(whitelistSourceRanges []string) (negroni.Han
 	return nil, nil
 }
 
+// newStatusMappingHandler creates the status mapping middleware of a frontend, with the error page found on the server "error" of its backend, if any.
+func newStatusMappingHandler(config *types.Configuration, statusMapping *types.StatusMapping) (*middlewares.StatusMappingHandler, error) {
+	var errorPageURL string
+	if len(statusMapping.Backend) > 0 {
+		backend := config.Backends[statusMapping.Backend]
+		if backend == nil || len(backend.Servers["error"].URL) == 0 {
+			return nil, fmt.Errorf("backend %s is not set or has no error server URL", statusMapping.Backend)
+		}
+		errorPageURL = backend.Servers["error"].URL
+	}
+	return middlewares.NewStatusMappingHandler(statusMapping, errorPageURL)
+}
+
 func (s *Server) wireFrontendBackend(serverRoute *serverRoute, handler http.Handler) {
 	// path replace - This needs to always be the very last on the handler chain (first in the order in this function)
 	// -- Replacing Path should happen at the very end of the Modifier chain, after all the Matcher+Modifiers ran
@@ -1247,11 +2371,11 @@ func (s *Server) wireFrontendBackend(serverRoute *serverRoute, handler http.Hand
 	}
 
 	if len(serverRoute.replacePathRegex) > 0 {
-		sp := strings.Split(serverRoute.replacePathRegex, " ")
-		if len(sp) == 2 {
-			handler = middlewares.NewReplacePathRegexHandler(sp[0], sp[1], handler)
+		regex, replacement, err := parseReplacePathRegex(serverRoute.replacePathRegex)
+		if err == nil {
+			handler = middlewares.NewReplacePathRegexHandler(regex, replacement, handler)
 		} else {
-			log.Warnf("Invalid syntax for ReplacePathRegex: %s. Separate the regular expression and the replacement by a space.", serverRoute.replacePathRegex)
+			log.Warn(err)
 		}
 	}
 
@@ -1277,10 +2401,23 @@ func (s *Server) wireFrontendBackend(serverRoute *serverRoute, handler http.Hand
 		handler = middlewares.NewStripPrefixRegex(handler, serverRoute.stripPrefixesRegex)
 	}
 
+	// client certificate subject - This is checked before any modifier runs
+	if serverRoute.clientCertSubject != nil {
+		handler = &middlewares.ClientCertSubject{
+			Matcher: serverRoute.clientCertSubject,
+			Handler: handler,
+		}
+	}
+
 	serverRoute.route.Handler(handler)
 }
 
 func (s *Server) buildRedirectHandler(srcEntryPointName string, opt *types.Redirect) (negroni.Handler, error) {
+	statusCode, err := redirect.StatusCode(opt.Permanent, opt.StatusCode)
+	if err != nil {
+		return nil, err
+	}
+
 	// entry point redirect
 	if len(opt.EntryPoint) > 0 {
 		entryPoint := s.globalConfiguration.EntryPoints[opt.EntryPoint]
@@ -1288,11 +2425,11 @@ func (s *Server) buildRedirectHandler(srcEntryPointName string, opt *types.Redir
 			return nil, fmt.Errorf("unknown target entrypoint %q", srcEntryPointName)
 		}
 		log.Debugf("Creating entry point redirect %s -> %s", srcEntryPointName, opt.EntryPoint)
-		return redirect.NewEntryPointHandler(entryPoint, opt.Permanent)
+		return redirect.NewEntryPointHandler(entryPoint, opt.Port, statusCode)
 	}
 
 	// regex redirect
-	redirection, err := redirect.NewRegexHandler(opt.Regex, opt.Replacement, opt.Permanent)
+	redirection, err := redirect.NewRegexHandler(opt.Regex, opt.Replacement, statusCode)
 	if err != nil {
 		return nil, err
 	}
@@ -1304,14 +2441,30 @@ func (s *Server) buildRedirectHandler(srcEntryPointName string, opt *types.Redir
 func (s *Server) buildDefaultHTTPRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.NotFoundHandler = s.wrapHTTPHandlerWithAccessLog(http.HandlerFunc(notFoundHandler), "backend not found")
+	router.MethodNotAllowedHandler = s.wrapHTTPHandlerWithAccessLog(methodNotAllowedHandler(router), "method not allowed")
 	router.StrictSlash(true)
 	router.SkipClean(true)
 	return router
 }
 
-func parseHealthCheckOptions(lb healthcheck.LoadBalancer, backend string, hc *types.HealthCheck, hcConfig *configuration.HealthCheckConfig) *healthcheck.Options {
+// parseWebSocketTimeout parses a timeout of the WebSocket connections, an empty one being disabled.
+func parseWebSocketTimeout(timeout string) (time.Duration, error) {
+	if len(timeout) == 0 {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WebSocket timeout %q: %v", timeout, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid WebSocket timeout %q: it must not be negative", timeout)
+	}
+	return duration, nil
+}
+
+func parseHealthCheckOptions(lb healthcheck.LoadBalancer, backend string, hc *types.HealthCheck, hcConfig *configuration.HealthCheckConfig) (*healthcheck.Options, error) {
 	if hc == nil || hc.Path == "" || hcConfig == nil {
-		return nil
+		return nil, nil
 	}
 
 	interval := time.Duration(hcConfig.Interval)
@@ -1327,12 +2480,24 @@ func parseHealthCheckOptions(lb healthcheck.LoadBalancer, backend string, hc *ty
 		}
 	}
 
-	return &healthcheck.Options{
-		Path:     hc.Path,
-		Port:     hc.Port,
-		Interval: interval,
-		LB:       lb,
+	var expectedBodyRegex *regexp.Regexp
+	if len(hc.ExpectedBodyRegex) > 0 {
+		var err error
+		expectedBodyRegex, err = regexp.Compile(hc.ExpectedBodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected body regex %q: %v", hc.ExpectedBodyRegex, err)
+		}
 	}
+
+	return &healthcheck.Options{
+		Path:              hc.Path,
+		Port:              hc.Port,
+		Headers:           hc.Headers,
+		ExpectedBody:      hc.ExpectedBody,
+		ExpectedBodyRegex: expectedBodyRegex,
+		Interval:          interval,
+		LB:                lb,
+	}, nil
 }
 
 func getRoute(serverRoute *serverRoute, route *types.Route) error {
@@ -1341,11 +2506,51 @@ func getRoute(serverRoute *serverRoute, route *types.Route) error {
 	if err != nil {
 		return err
 	}
-	newRoute.Priority(serverRoute.route.GetPriority() + len(route.Rule))
 	serverRoute.route = newRoute
 	return nil
 }
 
+type frontendRoute struct {
+	name     string
+	priority int
+	rules    []string
+}
+
+// checkAmbiguousRoutes rejects, in fail mode, the routes which can match the same requests with the same explicit priority,
+// the one with the longest rules, then the first declared, being matched first otherwise.
+func checkAmbiguousRoutes(routes []frontendRoute, ambiguousRoutes string) error {
+	if ambiguousRoutes != configuration.AmbiguousRoutesFail {
+		return nil
+	}
+
+	for i, r := range routes {
+		for _, previous := range routes[:i] {
+			if previous.priority == r.priority && rulesOverlap(previous.rules, r.rules) {
+				return fmt.Errorf("frontends %s and %s can match the same requests with the same priority", previous.name, r.name)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedRules(routes map[string]types.Route) []string {
+	var rules []string
+	for _, route := range routes {
+		rules = append(rules, route.Rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func sortedProviderNames(configurations types.Configurations) []string {
+	var keys []string
+	for key := range configurations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func sortedFrontendNamesForConfig(configuration *types.Configuration) []string {
 	var keys []string
 	for key := range configuration.Frontends {
@@ -1433,8 +2638,8 @@ func stopMetricsClients() {
 	metrics.StopInfluxDB()
 }
 
-func (s *Server) buildRateLimiter(handler http.Handler, rlConfig *types.RateLimit) (http.Handler, error) {
-	extractFunc, err := utils.NewExtractor(rlConfig.ExtractorFunc)
+func (s *Server) buildRateLimiter(handler http.Handler, frontendName string, rlConfig *types.RateLimit) (http.Handler, error) {
+	extractFunc, err := middlewares.NewSourceExtractor(rlConfig.ExtractorFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -1445,12 +2650,50 @@ func (s *Server) buildRateLimiter(handler http.Handler, rlConfig *types.RateLimi
 			return nil, err
 		}
 	}
+
+	if s.rateLimitStore != nil {
+		// the requests are let through when the store is unreachable, unless they are limited locally
+		var fallback http.Handler = handler
+		if s.globalConfiguration.RateLimitStore.FallbackToLocal {
+			if fallback, err = ratelimit.New(handler, extractFunc, rateSet); err != nil {
+				return nil, err
+			}
+		}
+		rateLimiter, err := middlewares.NewRedisRateLimiter(handler, frontendName, extractFunc, rlConfig.RateSet, s.rateLimitStore, fallback)
+		if err != nil {
+			return nil, err
+		}
+		return s.tracingMiddleware.NewHTTPHandlerWrapper("Rate limit", rateLimiter, false), nil
+	}
+
 	rateLimiter, err := ratelimit.New(handler, extractFunc, rateSet)
 	return s.tracingMiddleware.NewHTTPHandlerWrapper("Rate limit", rateLimiter, false), err
 
 }
 
-func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, countServers int, backendName string) http.Handler {
+// newRateLimitStore creates the store sharing the counters of the rate limits between the Traefik instances.
+func newRateLimitStore(config *configuration.RateLimitStore) (*middlewares.RateLimitStore, error) {
+	if len(strings.TrimSpace(config.Endpoint)) == 0 {
+		return nil, errors.New("no endpoint defined")
+	}
+
+	options := traefikRedis.Options{
+		ConnectionTimeout: time.Duration(config.DialTimeout),
+		Username:          config.Username,
+		Password:          config.Password,
+		DB:                config.DB,
+	}
+	if config.TLS != nil {
+		var err error
+		options.TLS, err = config.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return middlewares.NewRateLimitStore(strings.Split(config.Endpoint, ","), options, config.Prefix, time.Duration(config.Timeout)), nil
+}
+
+func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, countServers int, retryBudget *types.RetryBudget, backendName string) (http.Handler, error) {
 	retryListeners := middlewares.RetryListeners{}
 	if s.metricsRegistry.IsEnabled() {
 		retryListeners = append(retryListeners, middlewares.NewMetricsRetryListener(s.metricsRegistry, backendName))
@@ -1458,6 +2701,9 @@ func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configu
 	if s.accessLoggerMiddleware != nil {
 		retryListeners = append(retryListeners, &accesslog.SaveRetries{})
 	}
+	if s.tracingMiddleware.IsEnabled() {
+		retryListeners = append(retryListeners, &tracing.RetryListener{})
+	}
 
 	retryAttempts := countServers
 	if globalConfig.Retry.Attempts > 0 {
@@ -1466,7 +2712,21 @@ func (s *Server) buildRetryMiddleware(handler http.Handler, globalConfig configu
 
 	log.Debugf("Creating retries max attempts %d", retryAttempts)
 
-	return s.tracingMiddleware.NewHTTPHandlerWrapper("Retry", middlewares.NewRetry(retryAttempts, handler, retryListeners), false)
+	// The retry budget of a backend replaces the global one, and a zero ratio disables it.
+	if retryBudget == nil {
+		retryBudget = globalConfig.Retry.Budget
+	}
+	var budget *middlewares.RetryBudget
+	if retryBudget != nil && retryBudget.Ratio != 0 {
+		var err error
+		budget, err = middlewares.NewRetryBudget(retryBudget.Ratio, retryBudget.Burst, s.metricsRegistry.BackendRetryBudgetGauge().With("backend", backendName))
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Creating retry budget of ratio %v and burst %d for backend %s", retryBudget.Ratio, retryBudget.Burst, backendName)
+	}
+
+	return s.tracingMiddleware.NewHTTPHandlerWrapper("Retry", middlewares.NewRetryWithBudget(retryAttempts, budget, handler, retryListeners), false), nil
 }
 func (s *Server) wrapNegroniHandlerWithAccessLog(handler negroni.Handler, frontendName string) negroni.Handler {
 	if s.accessLoggerMiddleware != nil {
@@ -1486,6 +2746,281 @@ func (s *Server) wrapHTTPHandlerWithAccessLog(handler http.Handler, frontendName
 	return handler
 }
 
+func (s *Server) buildMirrorMiddleware(handler http.Handler, mirroring *types.Mirroring, backends map[string]*types.Backend) (http.Handler, error) {
+	mirrors := make(map[string][]string)
+	for _, backendName := range mirroring.Backends {
+		backend, ok := backends[backendName]
+		if !ok {
+			return nil, fmt.Errorf("undefined mirror backend '%s'", backendName)
+		}
+
+		var serverNames []string
+		for serverName := range backend.Servers {
+			serverNames = append(serverNames, serverName)
+		}
+		sort.Strings(serverNames)
+		for _, serverName := range serverNames {
+			mirrors[backendName] = append(mirrors[backendName], backend.Servers[serverName].URL)
+		}
+	}
+
+	percent := mirroring.Percent
+	if percent == 0 {
+		percent = 100
+	}
+
+	log.Debugf("Mirroring %d%% of the requests to %v", percent, mirroring.Backends)
+	return middlewares.NewMirror(handler, mirrors, percent, mirroring.MaxBodySize, s.defaultForwardingRoundTripper)
+}
+
+// buildHeaderOverride wraps the load-balancer of a frontend to send the requests matching its header override
+// to the given server of its backend, or to the servers of the given backend, with the forwarder of its backend.
+func (s *Server) buildHeaderOverride(lb http.Handler, fwd http.Handler, frontendName string, frontend *types.Frontend, backends map[string]*types.Backend) (http.Handler, error) {
+	override := frontend.HeaderOverride
+	target, err := buildHeaderOverrideTarget(fwd, frontend, backends)
+	if err != nil {
+		return nil, err
+	}
+
+	backendName := frontend.Backend
+	if len(override.Backend) > 0 {
+		backendName = override.Backend
+	}
+	if s.accessLoggerMiddleware != nil {
+		target = accesslog.NewSaveBackend(target, backendName)
+	}
+
+	if len(override.Server) > 0 {
+		log.Debugf("Sending the requests of frontend %s with the header %s %q to the server %s", frontendName, override.Header, override.Value, override.Server)
+	} else {
+		log.Debugf("Sending the requests of frontend %s with the header %s %q to the backend %s", frontendName, override.Header, override.Value, override.Backend)
+	}
+	return middlewares.NewHeaderOverride(lb, target, override.Header, override.Value), nil
+}
+
+// buildHeaderOverrideTarget creates the handler receiving the requests matching the header override of a frontend.
+func buildHeaderOverrideTarget(fwd http.Handler, frontend *types.Frontend, backends map[string]*types.Backend) (http.Handler, error) {
+	override := frontend.HeaderOverride
+	if len(strings.TrimSpace(override.Header)) == 0 {
+		return nil, errors.New("no header defined")
+	}
+
+	switch {
+	case len(override.Server) > 0 && len(override.Backend) > 0:
+		return nil, errors.New("both a server and a backend are defined")
+
+	case len(override.Server) > 0:
+		backend := backends[frontend.Backend]
+		if backend == nil {
+			return nil, fmt.Errorf("undefined backend %s", frontend.Backend)
+		}
+		server, ok := backend.Servers[override.Server]
+		if !ok {
+			return nil, fmt.Errorf("undefined server %s in backend %s", override.Server, frontend.Backend)
+		}
+		serverURL, err := url.Parse(server.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL of server %s: %v", override.Server, err)
+		}
+		return middlewares.NewServerForwarder(fwd, serverURL), nil
+
+	case len(override.Backend) > 0:
+		backend := backends[override.Backend]
+		if backend == nil {
+			return nil, fmt.Errorf("undefined backend %s", override.Backend)
+		}
+		if len(backend.Servers) == 0 {
+			return nil, fmt.Errorf("backend %s has no server", override.Backend)
+		}
+		backend, err := translatePercentWeights(backend)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backend %s: %v", override.Backend, err)
+		}
+		rr, err := roundrobin.New(fwd)
+		if err != nil {
+			return nil, err
+		}
+		for name, server := range backend.Servers {
+			serverURL, err := url.Parse(server.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URL of server %s: %v", name, err)
+			}
+			if err := rr.UpsertServer(serverURL, roundrobin.Weight(server.Weight)); err != nil {
+				return nil, fmt.Errorf("invalid server %s: %v", name, err)
+			}
+		}
+		return rr, nil
+
+	default:
+		return nil, errors.New("neither a server nor a backend is defined")
+	}
+}
+
+// checkWeightedBackends returns an error if the weighted backends of the frontend can't split its requests
+func checkWeightedBackends(frontend *types.Frontend, backends map[string]*types.Backend) error {
+	if len(frontend.Backend) > 0 {
+		return errors.New("both a backend and weighted backends are defined")
+	}
+	if frontend.HeaderOverride != nil {
+		return errors.New("the header override can't select the servers of weighted backends")
+	}
+
+	seen := make(map[string]bool)
+	totalWeight := 0
+	for _, weightedBackend := range frontend.WeightedBackends {
+		if weightedBackend == nil {
+			return errors.New("empty weighted backend")
+		}
+		if backends[weightedBackend.Backend] == nil {
+			return fmt.Errorf("undefined backend %q", weightedBackend.Backend)
+		}
+		if seen[weightedBackend.Backend] {
+			return fmt.Errorf("duplicated backend %q", weightedBackend.Backend)
+		}
+		seen[weightedBackend.Backend] = true
+		if _, err := translatePercentWeights(backends[weightedBackend.Backend]); err != nil {
+			return fmt.Errorf("invalid backend %q: %v", weightedBackend.Backend, err)
+		}
+		if weightedBackend.Weight < 0 {
+			return fmt.Errorf("invalid weight %d of backend %q: it must not be negative", weightedBackend.Weight, weightedBackend.Backend)
+		}
+		totalWeight += weightedBackend.Weight
+	}
+	if totalWeight == 0 {
+		return errors.New("no backend has a weight")
+	}
+	return nil
+}
+
+// checkLanguageBackends returns an error if the language backends of the frontend can't receive its requests
+func checkLanguageBackends(frontend *types.Frontend, backends map[string]*types.Backend) error {
+	if len(frontend.WeightedBackends) > 0 {
+		return errors.New("both weighted backends and language backends are defined")
+	}
+	if frontend.HeaderOverride != nil {
+		return errors.New("the header override can't select the servers of language backends")
+	}
+	if len(frontend.Backend) == 0 {
+		return errors.New("no default backend is defined")
+	}
+
+	for _, backendName := range languageBackendNames(frontend) {
+		if backends[backendName] == nil {
+			return fmt.Errorf("undefined backend %q", backendName)
+		}
+		if _, err := translatePercentWeights(backends[backendName]); err != nil {
+			return fmt.Errorf("invalid backend %q: %v", backendName, err)
+		}
+	}
+
+	locales := make(map[string]http.Handler)
+	for locale := range frontend.LanguageBackends {
+		locales[locale] = nil
+	}
+	_, err := middlewares.NewLanguageBackends(locales, nil)
+	return err
+}
+
+// languageBackendNames returns the default backend of the frontend, followed by the other backends of its locales
+func languageBackendNames(frontend *types.Frontend) []string {
+	var names []string
+	for _, backendName := range frontend.LanguageBackends {
+		if backendName != frontend.Backend {
+			names = append(names, backendName)
+		}
+	}
+	sort.Strings(names)
+
+	backendNames := []string{frontend.Backend}
+	for i, backendName := range names {
+		if i == 0 || backendName != names[i-1] {
+			backendNames = append(backendNames, backendName)
+		}
+	}
+	return backendNames
+}
+
+// translatePercentWeights returns a copy of the backend whose weights of the servers are percentages, with the round-robin weights
+// splitting the requests in the same proportions, or the backend itself if its weights are not percentages.
+func translatePercentWeights(backend *types.Backend) (*types.Backend, error) {
+	if !backend.PercentWeights {
+		return backend, nil
+	}
+
+	total := 0
+	divisor := 0
+	for name, server := range backend.Servers {
+		if server.Weight < 0 {
+			return nil, fmt.Errorf("invalid weight %d%% of server %s: it must not be negative", server.Weight, name)
+		}
+		total += server.Weight
+		divisor = gcd(divisor, server.Weight)
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("the weights of the servers sum to %d%% instead of 100%%", total)
+	}
+
+	translated := *backend
+	translated.Servers = make(map[string]types.Server, len(backend.Servers))
+	for name, server := range backend.Servers {
+		server.Weight /= divisor
+		translated.Servers[name] = server
+	}
+	return &translated, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// parseForwardingTimeouts layers the forwarding timeouts of a frontend on the global ones,
+// and parses the timeout of its whole requests, which is zero if not set.
+// The forwarding timeouts are nil when the frontend doesn't override them.
+func parseForwardingTimeouts(global *configuration.ForwardingTimeouts, frontend *types.ForwardingTimeouts) (*configuration.ForwardingTimeouts, time.Duration, error) {
+	if frontend == nil {
+		return nil, 0, nil
+	}
+
+	var requestTimeout time.Duration
+	if len(frontend.RequestTimeout) > 0 {
+		duration, err := time.ParseDuration(frontend.RequestTimeout)
+		if err != nil || duration <= 0 {
+			return nil, 0, fmt.Errorf("invalid request timeout %q: it must be a positive duration", frontend.RequestTimeout)
+		}
+		requestTimeout = duration
+	}
+
+	timeouts := configuration.ForwardingTimeouts{DialTimeout: flaeg.Duration(configuration.DefaultDialTimeout)}
+	if global != nil {
+		timeouts = *global
+	}
+	// the request timeout replaces the global response header timeout, but not the one of the frontend
+	if requestTimeout > 0 {
+		timeouts.ResponseHeaderTimeout = 0
+	}
+
+	if len(frontend.DialTimeout) > 0 {
+		duration, err := time.ParseDuration(frontend.DialTimeout)
+		if err != nil || duration < 0 {
+			return nil, 0, fmt.Errorf("invalid dial timeout %q: it must be a positive duration, or 0 for no timeout", frontend.DialTimeout)
+		}
+		timeouts.DialTimeout = flaeg.Duration(duration)
+	}
+
+	if len(frontend.ResponseHeaderTimeout) > 0 {
+		duration, err := time.ParseDuration(frontend.ResponseHeaderTimeout)
+		if err != nil || duration < 0 {
+			return nil, 0, fmt.Errorf("invalid response header timeout %q: it must be a positive duration, or 0 for no timeout", frontend.ResponseHeaderTimeout)
+		}
+		timeouts.ResponseHeaderTimeout = flaeg.Duration(duration)
+	}
+
+	return &timeouts, requestTimeout, nil
+}
+
 func (s *Server) buildBufferingMiddleware(handler http.Handler, config *types.Buffering) (http.Handler, error) {
 	log.Debugf("Setting up buffering: request limits: %d (mem), %d (max), response limits: %d (mem), %d (max) with retry: '%s'",
 		config.MemRequestBodyBytes, config.MaxRequestBodyBytes, config.MemResponseBodyBytes,
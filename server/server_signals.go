@@ -32,6 +32,9 @@ func (s *Server) listenSignals() {
 			}
 		default:
 			log.Infof("I have to go... %+v", sig)
+			if s.globalConfiguration.Ping != nil {
+				s.globalConfiguration.Ping.SetTerminating()
+			}
 			reqAcceptGraceTimeOut := time.Duration(s.globalConfiguration.LifeCycle.RequestAcceptGraceTimeout)
 			if reqAcceptGraceTimeOut > 0 {
 				log.Infof("Waiting %s for incoming requests to cease", reqAcceptGraceTimeOut)
@@ -19,6 +19,9 @@ func (s *Server) listenSignals() {
 		switch sig {
 		default:
 			log.Infof("I have to go... %+v", sig)
+			if s.globalConfiguration.Ping != nil {
+				s.globalConfiguration.Ping.SetTerminating()
+			}
 			log.Info("Stopping server")
 			s.Stop()
 		}
@@ -1,11 +1,32 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,12 +38,17 @@ import (
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
 	"github.com/containous/traefik/types"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/dgrijalva/jwt-go"
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
 	"github.com/vulcand/oxy/roundrobin"
+	"golang.org/x/net/http2"
 )
 
 // LocalhostCert is a PEM-encoded TLS cert with SAN IPs
@@ -430,7 +456,7 @@ func TestServerLoadConfigHealthCheckOptions(t *testing.T) {
 		},
 	}
 
-	for _, lbMethod := range []string{"Wrr", "Drr"} {
+	for _, lbMethod := range []string{"Wrr", "Drr", "LeastConn"} {
 		for _, healthCheck := range healthChecks {
 			t.Run(fmt.Sprintf("%s/hc=%t", lbMethod, healthCheck != nil), func(t *testing.T) {
 				globalConfig := configuration.GlobalConfiguration{
@@ -501,6 +527,7 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 		desc     string
 		hc       *types.HealthCheck
 		wantOpts *healthcheck.Options
+		wantErr  bool
 	}{
 		{
 			desc:     "nil health check",
@@ -550,6 +577,31 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 				LB:       lb,
 			},
 		},
+		{
+			desc: "headers and expected body",
+			hc: &types.HealthCheck{
+				Path:              "/path",
+				Headers:           map[string]string{"Authorization": "Bearer token"},
+				ExpectedBody:      `"status"`,
+				ExpectedBodyRegex: `"status": *"up"`,
+			},
+			wantOpts: &healthcheck.Options{
+				Path:              "/path",
+				Headers:           map[string]string{"Authorization": "Bearer token"},
+				ExpectedBody:      `"status"`,
+				ExpectedBodyRegex: regexp.MustCompile(`"status": *"up"`),
+				Interval:          globalInterval,
+				LB:                lb,
+			},
+		},
+		{
+			desc: "invalid expected body regex",
+			hc: &types.HealthCheck{
+				Path:              "/path",
+				ExpectedBodyRegex: "[a-z",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -557,7 +609,16 @@ func TestServerParseHealthCheckOptions(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			gotOpts := parseHealthCheckOptions(lb, "backend", test.hc, &configuration.HealthCheckConfig{Interval: flaeg.Duration(globalInterval)})
+			gotOpts, err := parseHealthCheckOptions(lb, "backend", test.hc, &configuration.HealthCheckConfig{Interval: flaeg.Duration(globalInterval)})
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("got no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error: %s", err)
+			}
 			if !reflect.DeepEqual(gotOpts, test.wantOpts) {
 				t.Errorf("got health check options %+v, want %+v", gotOpts, test.wantOpts)
 			}
@@ -591,7 +652,7 @@ func TestNewServerWithWhitelistSourceRange(t *testing.T) {
 				"foo",
 			},
 			middlewareConfigured: false,
-			errMessage:           "parsing CIDR whitelist [foo]: parsing CIDR whitelist <nil>: invalid CIDR address: foo",
+			errMessage:           `parsing CIDR whitelist [foo]: parsing CIDR whitelist "foo": invalid CIDR address: foo`,
 		},
 	}
 
@@ -678,212 +739,201 @@ func TestServerLoadCertificateWithDefaultEntryPoint(t *testing.T) {
 	srv := NewServer(globalConfig, nil)
 	if mapEntryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig); err != nil {
 		t.Fatalf("got error: %s", err)
-	} else if mapEntryPoints["https"].certs.Get() == nil {
+	} else if mapEntryPoints["https"].getCertificates() == nil {
 		t.Fatal("got error: https entryPoint must have TLS certificates.")
 	}
 }
 
-func TestConfigureBackends(t *testing.T) {
-	validMethod := "Drr"
-	defaultMethod := "wrr"
-
-	tests := []struct {
-		desc           string
-		lb             *types.LoadBalancer
-		wantMethod     string
-		wantStickiness *types.Stickiness
-	}{
-		{
-			desc: "valid load balancer method with sticky enabled",
-			lb: &types.LoadBalancer{
-				Method:     validMethod,
-				Stickiness: &types.Stickiness{},
-			},
-			wantMethod:     validMethod,
-			wantStickiness: &types.Stickiness{},
-		},
-		{
-			desc: "valid load balancer method with sticky disabled",
-			lb: &types.LoadBalancer{
-				Method:     validMethod,
-				Stickiness: nil,
-			},
-			wantMethod: validMethod,
-		},
-		{
-			desc: "invalid load balancer method with sticky enabled",
-			lb: &types.LoadBalancer{
-				Method:     "Invalid",
-				Stickiness: &types.Stickiness{},
-			},
-			wantMethod:     defaultMethod,
-			wantStickiness: &types.Stickiness{},
-		},
-		{
-			desc: "invalid load balancer method with sticky disabled",
-			lb: &types.LoadBalancer{
-				Method:     "Invalid",
-				Stickiness: nil,
+func TestServerLoadHTTPSConfigurationMergesProviders(t *testing.T) {
+	newConfiguration := func(domain string) *tls.Configuration {
+		cert, key, err := generate.KeyPair(domain, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		return &tls.Configuration{
+			EntryPoints: []string{"https"},
+			Certificate: &tls.Certificate{
+				CertFile: tls.FileOrContent(cert),
+				KeyFile:  tls.FileOrContent(key),
 			},
-			wantMethod: defaultMethod,
+		}
+	}
+
+	sharedFirst := newConfiguration("shared.example.com")
+	dynamicConfigs := types.Configurations{
+		"provider2": &types.Configuration{
+			TLS: []*tls.Configuration{newConfiguration("b.example.com"), newConfiguration("shared.example.com")},
 		},
-		{
-			desc:       "missing load balancer",
-			lb:         nil,
-			wantMethod: defaultMethod,
+		"provider1": &types.Configuration{
+			TLS: []*tls.Configuration{newConfiguration("a.example.com"), sharedFirst},
 		},
 	}
 
-	for _, test := range tests {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			backend := &types.Backend{
-				LoadBalancer: test.lb,
-			}
+	srv := NewServer(configuration.GlobalConfiguration{}, nil)
 
-			configureBackends(map[string]*types.Backend{
-				"backend": backend,
-			})
+	// The result must not depend on the order of the iteration over the providers
+	for i := 0; i < 10; i++ {
+		certs, err := srv.loadHTTPSConfiguration(dynamicConfigs, nil)
+		require.NoError(t, err)
+		require.NotNil(t, certs["https"])
+		assert.Len(t, *certs["https"], 3)
 
-			wantLB := types.LoadBalancer{
-				Method:     test.wantMethod,
-				Stickiness: test.wantStickiness,
-			}
-			if !reflect.DeepEqual(*backend.LoadBalancer, wantLB) {
-				t.Errorf("got backend load-balancer\n%v\nwant\n%v\n", spew.Sdump(backend.LoadBalancer), spew.Sdump(wantLB))
-			}
-		})
+		for _, domain := range []string{"a.example.com", "b.example.com"} {
+			cert := certs["https"].GetBestCertificate(domain)
+			require.NotNil(t, cert, domain)
+			x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+			require.NoError(t, err)
+			assert.Equal(t, []string{domain}, x509Cert.DNSNames)
+		}
+
+		// The certificate of the first provider, sorted by name, is kept
+		block, _ := pem.Decode([]byte(sharedFirst.Certificate.CertFile))
+		require.NotNil(t, block)
+		cert := certs["https"].GetBestCertificate("shared.example.com")
+		require.NotNil(t, cert)
+		assert.Equal(t, block.Bytes, cert.Certificate[0])
 	}
 }
 
-func TestServerEntryPointWhitelistConfig(t *testing.T) {
-	tests := []struct {
-		desc           string
-		entrypoint     *configuration.EntryPoint
-		wantMiddleware bool
+func TestServerStrictLoadCertificates(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		strictLoad    bool
+		certificates  tls.Certificates
+		expectedError bool
 	}{
 		{
-			desc: "no whitelist middleware if no config on entrypoint",
-			entrypoint: &configuration.EntryPoint{
-				Address:          ":0",
-				ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
-			},
-			wantMiddleware: false,
+			desc:         "incomplete certificate skipped",
+			certificates: tls.Certificates{{CertFile: localhostCert}},
 		},
 		{
-			desc: "whitelist middleware should be added if configured on entrypoint",
-			entrypoint: &configuration.EntryPoint{
-				Address: ":0",
-				WhitelistSourceRange: []string{
-					"127.0.0.1/32",
-				},
-				ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
-			},
-			wantMiddleware: true,
+			desc:          "incomplete certificate with strict load",
+			strictLoad:    true,
+			certificates:  tls.Certificates{{CertFile: localhostCert}},
+			expectedError: true,
+		},
+		{
+			desc:         "valid certificate with strict load",
+			strictLoad:   true,
+			certificates: tls.Certificates{{CertFile: localhostCert, KeyFile: localhostKey}},
 		},
 	}
 
-	for _, test := range tests {
+	for _, test := range testCases {
 		test := test
+
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			srv := Server{
-				globalConfiguration: configuration.GlobalConfiguration{
-					EntryPoints: map[string]*configuration.EntryPoint{
-						"test": test.entrypoint,
-					},
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"https": &configuration.EntryPoint{TLS: &tls.TLS{
+						StrictLoad:   test.strictLoad,
+						Certificates: test.certificates,
+					}},
 				},
-				metricsRegistry: metrics.NewVoidRegistry(),
-			}
-
-			srv.serverEntryPoints = srv.buildEntryPoints(srv.globalConfiguration)
-			srvEntryPoint := srv.setupServerEntryPoint("test", srv.serverEntryPoints["test"])
-			handler := srvEntryPoint.httpServer.Handler.(*mux.Router).NotFoundHandler.(*negroni.Negroni)
-			found := false
-			for _, handler := range handler.Handlers() {
-				if reflect.TypeOf(handler) == reflect.TypeOf((*middlewares.IPWhiteLister)(nil)) {
-					found = true
-				}
 			}
 
-			if found && !test.wantMiddleware {
-				t.Errorf("ip whitelist middleware was installed even though it should not")
-			}
+			srv := NewServer(globalConfig, nil)
+			srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
 
-			if !found && test.wantMiddleware {
-				t.Errorf("ip whitelist middleware was not installed even though it should have")
+			_, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
 			}
 		})
 	}
 }
 
-func TestServerResponseEmptyBackend(t *testing.T) {
-	const requestPath = "/path"
-	const routeRule = "Path:" + requestPath
+func TestServerLoadConfigurationInvalidStrictCertificates(t *testing.T) {
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{StrictLoad: true}},
+		},
+		DefaultEntryPoints: []string{"https"},
+	}
 
-	testCases := []struct {
-		desc           string
-		dynamicConfig  func(testServerURL string) *types.Configuration
-		wantStatusCode int
-	}{
-		{
-			desc: "Ok",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig(
-					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
-					withBackend("backend", buildBackend(withServer("testServer", testServerURL))),
-				)
+	srv := NewServer(globalConfig, nil)
+	previousConfiguration := buildDynamicConfig()
+	srv.currentConfigurations.Set(types.Configurations{"file": previousConfiguration})
+
+	// the configuration, the first one of its provider, is rejected instead of stopping Traefik
+	srv.loadConfiguration(types.ConfigMessage{
+		ProviderName: "docker",
+		Configuration: &types.Configuration{
+			TLS: []*tls.Configuration{
+				{Certificate: &tls.Certificate{CertFile: localhostCert, KeyFile: "invalid"}},
 			},
-			wantStatusCode: http.StatusOK,
 		},
+	})
+
+	currentConfigurations := srv.currentConfigurations.Get().(types.Configurations)
+	assert.Equal(t, types.Configurations{"file": previousConfiguration}, currentConfigurations)
+}
+
+func TestServerCheckStrictCertificates(t *testing.T) {
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https":  &configuration.EntryPoint{TLS: &tls.TLS{StrictLoad: true}},
+			"https2": &configuration.EntryPoint{TLS: &tls.TLS{}},
+			"http":   &configuration.EntryPoint{},
+		},
+		DefaultEntryPoints: []string{"http", "https"},
+	}
+
+	invalidCertificate := &tls.Certificate{CertFile: localhostCert, KeyFile: "invalid"}
+
+	testCases := []struct {
+		desc          string
+		configuration *types.Configuration
+		expectedError bool
+	}{
 		{
-			desc: "No Frontend",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig()
-			},
-			wantStatusCode: http.StatusNotFound,
+			desc:          "no configuration",
+			configuration: nil,
 		},
 		{
-			desc: "Empty Backend LB-Drr",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig(
-					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
-					withBackend("backend", buildBackend(withLoadBalancer("Drr", false))),
-				)
+			desc: "valid certificate on a strict entrypoint",
+			configuration: &types.Configuration{
+				TLS: []*tls.Configuration{
+					{
+						EntryPoints: []string{"https"},
+						Certificate: &tls.Certificate{CertFile: localhostCert, KeyFile: localhostKey},
+					},
+				},
 			},
-			wantStatusCode: http.StatusServiceUnavailable,
 		},
 		{
-			desc: "Empty Backend LB-Drr Sticky",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig(
-					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
-					withBackend("backend", buildBackend(withLoadBalancer("Drr", true))),
-				)
+			desc: "invalid certificate on a strict entrypoint",
+			configuration: &types.Configuration{
+				TLS: []*tls.Configuration{
+					{
+						EntryPoints: []string{"https2", "https"},
+						Certificate: invalidCertificate,
+					},
+				},
 			},
-			wantStatusCode: http.StatusServiceUnavailable,
+			expectedError: true,
 		},
 		{
-			desc: "Empty Backend LB-Wrr",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig(
-					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
-					withBackend("backend", buildBackend(withLoadBalancer("Wrr", false))),
-				)
+			desc: "invalid certificate on the default entrypoints",
+			configuration: &types.Configuration{
+				TLS: []*tls.Configuration{
+					{Certificate: invalidCertificate},
+				},
 			},
-			wantStatusCode: http.StatusServiceUnavailable,
+			expectedError: true,
 		},
 		{
-			desc: "Empty Backend LB-Wrr Sticky",
-			dynamicConfig: func(testServerURL string) *types.Configuration {
-				return buildDynamicConfig(
-					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
-					withBackend("backend", buildBackend(withLoadBalancer("Wrr", true))),
-				)
+			desc: "invalid certificate on a non strict entrypoint",
+			configuration: &types.Configuration{
+				TLS: []*tls.Configuration{
+					{
+						EntryPoints: []string{"https2"},
+						Certificate: invalidCertificate,
+					},
+				},
 			},
-			wantStatusCode: http.StatusServiceUnavailable,
 		},
 	}
 
@@ -893,193 +943,4289 @@ func TestServerResponseEmptyBackend(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-				rw.WriteHeader(http.StatusOK)
-			}))
-			defer testServer.Close()
-
-			globalConfig := configuration.GlobalConfiguration{
-				EntryPoints: configuration.EntryPoints{
-					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
-				},
-			}
-			dynamicConfigs := types.Configurations{"config": test.dynamicConfig(testServer.URL)}
-
 			srv := NewServer(globalConfig, nil)
-			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
-			if err != nil {
-				t.Fatalf("error loading config: %s", err)
-			}
-
-			responseRecorder := &httptest.ResponseRecorder{}
-			request := httptest.NewRequest(http.MethodGet, testServer.URL+requestPath, nil)
 
-			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
-
-			if responseRecorder.Result().StatusCode != test.wantStatusCode {
-				t.Errorf("got status code %d, want %d", responseRecorder.Result().StatusCode, test.wantStatusCode)
+			err := srv.checkStrictCertificates(test.configuration)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
 			}
 		})
 	}
 }
 
-func TestBuildRedirectHandler(t *testing.T) {
-	srv := Server{
-		globalConfiguration: configuration.GlobalConfiguration{
-			EntryPoints: configuration.EntryPoints{
-				"http":  &configuration.EntryPoint{Address: ":80"},
-				"https": &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+func TestServerDefaultCertificatePerEntryPoint(t *testing.T) {
+	globalCert, globalKey, err := generate.KeyPair("global.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	httpsCert, httpsKey, err := generate.KeyPair("https.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https":  &configuration.EntryPoint{TLS: &tls.TLS{}},
+			"https2": &configuration.EntryPoint{TLS: &tls.TLS{}},
+		},
+		DefaultCertificates: tls.DefaultCertificates{
+			{
+				Certificate: &tls.Certificate{
+					CertFile: tls.FileOrContent(globalCert),
+					KeyFile:  tls.FileOrContent(globalKey),
+				},
+			},
+			{
+				EntryPoints: []string{"https"},
+				Certificate: &tls.Certificate{
+					CertFile: tls.FileOrContent(httpsCert),
+					KeyFile:  tls.FileOrContent(httpsKey),
+				},
 			},
 		},
 	}
 
 	testCases := []struct {
-		desc              string
-		srcEntryPointName string
-		url               string
-		entryPoint        *configuration.EntryPoint
-		redirect          *types.Redirect
-		expectedURL       string
+		entryPoint     string
+		expectedDomain string
 	}{
 		{
-			desc:              "redirect regex",
-			srcEntryPointName: "http",
-			url:               "http://foo.com",
-			redirect: &types.Redirect{
-				Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
-				Replacement: "https://$1{{\"bar\"}}$2",
-			},
-			entryPoint: &configuration.EntryPoint{
-				Address: ":80",
-				Redirect: &types.Redirect{
-					Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
-					Replacement: "https://$1{{\"bar\"}}$2",
-				},
-			},
-			expectedURL: "https://foobar.com",
+			entryPoint:     "https",
+			expectedDomain: "https.example.com",
 		},
 		{
-			desc:              "redirect entry point",
-			srcEntryPointName: "http",
-			url:               "http://foo:80",
-			redirect: &types.Redirect{
-				EntryPoint: "https",
-			},
-			entryPoint: &configuration.EntryPoint{
-				Address: ":80",
-				Redirect: &types.Redirect{
-					EntryPoint: "https",
+			entryPoint:     "https2",
+			expectedDomain: "global.example.com",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.entryPoint, func(t *testing.T) {
+			t.Parallel()
+
+			srv := NewServer(globalConfig, nil)
+			srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+			config, err := srv.createTLSConfig(test.entryPoint, globalConfig.EntryPoints[test.entryPoint].TLS, nil)
+			require.NoError(t, err)
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+
+			go func() {
+				tlsServer := cryptotls.Server(serverConn, config)
+				tlsServer.Handshake()
+				tlsServer.Close()
+			}()
+
+			tlsClient := cryptotls.Client(clientConn, &cryptotls.Config{
+				ServerName:         "unknown.example.com",
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, tlsClient.Handshake())
+
+			peerCertificates := tlsClient.ConnectionState().PeerCertificates
+			require.NotEmpty(t, peerCertificates)
+			assert.Equal(t, []string{test.expectedDomain}, peerCertificates[0].DNSNames)
+		})
+	}
+}
+
+func TestServerSNICertificatePrecedence(t *testing.T) {
+	certificates := tls.Certificates{}
+	for _, domain := range []string{"*.example.com", "api.example.com"} {
+		cert, key, err := generate.KeyPair(domain, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		certificates = append(certificates, tls.Certificate{
+			CertFile: tls.FileOrContent(cert),
+			KeyFile:  tls.FileOrContent(key),
+		})
+	}
+	defaultCert, defaultKey, err := generate.KeyPair("default.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{Certificates: certificates}},
+		},
+		DefaultCertificates: tls.DefaultCertificates{
+			{
+				Certificate: &tls.Certificate{
+					CertFile: tls.FileOrContent(defaultCert),
+					KeyFile:  tls.FileOrContent(defaultKey),
 				},
 			},
-			expectedURL: "https://foo:443",
 		},
+	}
+
+	testCases := []struct {
+		serverName     string
+		expectedDomain string
+	}{
 		{
-			desc:              "redirect entry point with regex (ignored)",
-			srcEntryPointName: "http",
-			url:               "http://foo.com:80",
-			redirect: &types.Redirect{
-				EntryPoint:  "https",
-				Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
-				Replacement: "https://$1{{\"bar\"}}$2",
-			},
-			entryPoint: &configuration.EntryPoint{
-				Address: ":80",
-				Redirect: &types.Redirect{
-					EntryPoint:  "https",
-					Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
-					Replacement: "https://$1{{\"bar\"}}$2",
+			serverName:     "api.example.com",
+			expectedDomain: "api.example.com",
+		},
+		{
+			serverName:     "www.example.com",
+			expectedDomain: "*.example.com",
+		},
+		{
+			serverName:     "example.com",
+			expectedDomain: "*.example.com",
+		},
+		{
+			serverName:     "www.unknown.org",
+			expectedDomain: "default.example.org",
+		},
+	}
+
+	srv := NewServer(globalConfig, nil)
+	srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+	config, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+	require.NoError(t, err)
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.serverName, func(t *testing.T) {
+			t.Parallel()
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+
+			go func() {
+				tlsServer := cryptotls.Server(serverConn, config)
+				tlsServer.Handshake()
+				tlsServer.Close()
+			}()
+
+			tlsClient := cryptotls.Client(clientConn, &cryptotls.Config{
+				ServerName:         test.serverName,
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, tlsClient.Handshake())
+
+			peerCertificates := tlsClient.ConnectionState().PeerCertificates
+			require.NotEmpty(t, peerCertificates)
+			assert.Equal(t, []string{test.expectedDomain}, peerCertificates[0].DNSNames)
+		})
+	}
+}
+
+func TestServerDomainsClientCA(t *testing.T) {
+	serverCert, serverKey, err := generate.KeyPair("default.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	// the self-signed client certificate is its own CA
+	clientCert, clientKey, err := generate.KeyPair("client.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	clientCertificate, err := cryptotls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	caFile, err := ioutil.TempFile("", "clientca")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	_, err = caFile.Write(clientCert)
+	require.NoError(t, err)
+	require.NoError(t, caFile.Close())
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{
+				Certificates: tls.Certificates{
+					{CertFile: tls.FileOrContent(serverCert), KeyFile: tls.FileOrContent(serverKey)},
 				},
-			},
-			expectedURL: "https://foo.com:443",
+				ClientCA: tls.ClientCA{Files: []string{caFile.Name()}},
+				DomainsClientCAs: []tls.DomainsClientCA{
+					{
+						Domains:  []string{"secure.tenanta.com"},
+						ClientCA: tls.ClientCA{Files: []string{caFile.Name()}},
+					},
+					{
+						Domains: []string{"*.tenantb.com"},
+					},
+					{
+						Domains:  []string{"optional.tenantb.com"},
+						ClientCA: tls.ClientCA{Files: []string{caFile.Name()}, Optional: true},
+					},
+				},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		desc                    string
+		serverName              string
+		withClientCertificate   bool
+		expectedError           bool
+		expectedPeerCertificate bool
+	}{
+		{
+			desc:          "required client certificate missing",
+			serverName:    "secure.tenanta.com",
+			expectedError: true,
+		},
+		{
+			desc:                    "required client certificate provided",
+			serverName:              "secure.tenanta.com",
+			withClientCertificate:   true,
+			expectedPeerCertificate: true,
+		},
+		{
+			desc:       "no client certificate required by the wildcard domain",
+			serverName: "public.tenantb.com",
+		},
+		{
+			desc:       "optional client certificate for the exact domain",
+			serverName: "optional.tenantb.com",
+		},
+		{
+			desc:                    "optional client certificate provided",
+			serverName:              "optional.tenantb.com",
+			withClientCertificate:   true,
+			expectedPeerCertificate: true,
+		},
+		{
+			desc:          "client certificate required by the entrypoint for other domains",
+			serverName:    "other.example.com",
+			expectedError: true,
 		},
 	}
 
+	srv := NewServer(globalConfig, nil)
+	srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+	config, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+	require.NoError(t, err)
+
 	for _, test := range testCases {
 		test := test
+
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			rewrite, err := srv.buildRedirectHandler(test.srcEntryPointName, test.redirect)
-			require.NoError(t, err)
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
 
-			req := testhelpers.MustNewRequest(http.MethodGet, test.url, nil)
-			recorder := httptest.NewRecorder()
+			type handshakeResult struct {
+				err              error
+				peerCertificates int
+			}
+			results := make(chan handshakeResult, 1)
+			go func() {
+				tlsServer := cryptotls.Server(serverConn, config)
+				err := tlsServer.Handshake()
+				results <- handshakeResult{err: err, peerCertificates: len(tlsServer.ConnectionState().PeerCertificates)}
+				tlsServer.Close()
+			}()
 
-			rewrite.ServeHTTP(recorder, req, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Add("Location", "fail")
-			}))
+			clientConfig := &cryptotls.Config{
+				ServerName:         test.serverName,
+				InsecureSkipVerify: true,
+			}
+			if test.withClientCertificate {
+				clientConfig.Certificates = []cryptotls.Certificate{clientCertificate}
+			}
+			tlsClient := cryptotls.Client(clientConn, clientConfig)
+			if err := tlsClient.Handshake(); err == nil {
+				// reads until the server closes the connection, or rejects the handshake
+				ioutil.ReadAll(tlsClient)
+			}
 
-			location, err := recorder.Result().Location()
-			require.NoError(t, err)
-			assert.Equal(t, test.expectedURL, location.String())
+			result := <-results
+			if test.expectedError {
+				assert.Error(t, result.err)
+				return
+			}
+			require.NoError(t, result.err)
+			assert.Equal(t, test.expectedPeerCertificate, result.peerCertificates > 0)
 		})
 	}
 }
 
-func buildDynamicConfig(dynamicConfigBuilders ...func(*types.Configuration)) *types.Configuration {
-	config := &types.Configuration{
-		Frontends: make(map[string]*types.Frontend),
-		Backends:  make(map[string]*types.Backend),
+func TestServerClientCACRL(t *testing.T) {
+	serverCert, serverKey, err := generate.KeyPair("default.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "client ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 	}
-	for _, build := range dynamicConfigBuilders {
-		build(config)
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "client.example.org"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 	}
-	return config
-}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, ca, &clientKey.PublicKey, caKey)
+	require.NoError(t, err)
+	clientCertificate := cryptotls.Certificate{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}
 
-func withFrontend(frontendName string, frontend *types.Frontend) func(*types.Configuration) {
-	return func(config *types.Configuration) {
-		config.Frontends[frontendName] = frontend
+	dir, err := ioutil.TempDir("", "clientca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	writeCRL := func(revoked ...pkix.RevokedCertificate) {
+		crl, err := ca.CreateCRL(rand.Reader, caKey, revoked, time.Now(), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crl"), pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), 0600))
 	}
-}
+	writeCRL()
 
-func withBackend(backendName string, backend *types.Backend) func(*types.Configuration) {
-	return func(config *types.Configuration) {
-		config.Backends[backendName] = backend
+	clientCA := tls.ClientCA{Files: []string{caFile}, CRLFiles: []string{filepath.Join(dir, "ca.crl")}}
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{
+				Certificates: tls.Certificates{
+					{CertFile: tls.FileOrContent(serverCert), KeyFile: tls.FileOrContent(serverKey)},
+				},
+				ClientCA: clientCA,
+				DomainsClientCAs: []tls.DomainsClientCA{
+					{Domains: []string{"secure.example.org"}, ClientCA: clientCA},
+				},
+			}},
+		},
 	}
-}
 
-func buildFrontend(frontendBuilders ...func(*types.Frontend)) *types.Frontend {
-	fe := &types.Frontend{
-		EntryPoints: []string{"http"},
-		Backend:     "backend",
-		Routes:      make(map[string]types.Route),
+	srv := NewServer(globalConfig, nil)
+	srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+	config, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+	require.NoError(t, err)
+	assert.Len(t, srv.crlCheckers, 2)
+
+	handshake := func(serverName string) error {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+
+		results := make(chan error, 1)
+		go func() {
+			tlsServer := cryptotls.Server(serverConn, config)
+			results <- tlsServer.Handshake()
+			tlsServer.Close()
+		}()
+
+		tlsClient := cryptotls.Client(clientConn, &cryptotls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+			Certificates:       []cryptotls.Certificate{clientCertificate},
+		})
+		if err := tlsClient.Handshake(); err == nil {
+			// reads until the server closes the connection, or rejects the handshake
+			ioutil.ReadAll(tlsClient)
+		}
+		return <-results
 	}
-	for _, build := range frontendBuilders {
-		build(fe)
+
+	for _, serverName := range []string{"other.example.org", "secure.example.org"} {
+		assert.NoError(t, handshake(serverName), serverName)
 	}
-	return fe
-}
 
-func withRoute(routeName, rule string) func(*types.Frontend) {
-	return func(fe *types.Frontend) {
-		fe.Routes[routeName] = types.Route{Rule: rule}
+	// the revocation is applied once the CRLs are reloaded
+	writeCRL(pkix.RevokedCertificate{SerialNumber: clientTemplate.SerialNumber, RevocationTime: time.Now()})
+	assert.NoError(t, handshake("other.example.org"))
+	srv.reloadCRLs()
+
+	for _, serverName := range []string{"other.example.org", "secure.example.org"} {
+		assert.Error(t, handshake(serverName), serverName)
 	}
 }
 
-func buildBackend(backendBuilders ...func(*types.Backend)) *types.Backend {
-	be := &types.Backend{
-		Servers:      make(map[string]types.Server),
-		LoadBalancer: &types.LoadBalancer{Method: "Wrr"},
+func TestServerCertificatesReloadDuringHandshakes(t *testing.T) {
+	defaultCert, defaultKey, err := generate.KeyPair("default.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{}},
+		},
+		DefaultCertificates: tls.DefaultCertificates{
+			{
+				Certificate: &tls.Certificate{
+					CertFile: tls.FileOrContent(defaultCert),
+					KeyFile:  tls.FileOrContent(defaultKey),
+				},
+			},
+		},
 	}
-	for _, build := range backendBuilders {
-		build(be)
+
+	// each reload alternates between two certificate sets serving the same domain
+	var certificateSets []*tls.DomainsCertificates
+	for i := 0; i < 2; i++ {
+		cert, key, err := generate.KeyPair("reload.example.com", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		certificate := &tls.Certificate{
+			CertFile: tls.FileOrContent(cert),
+			KeyFile:  tls.FileOrContent(key),
+		}
+		certs := make(map[string]*tls.DomainsCertificates)
+		require.NoError(t, certificate.AppendCertificates(certs, "https"))
+		certificateSets = append(certificateSets, certs["https"])
 	}
-	return be
-}
 
-func withServer(name, url string) func(backend *types.Backend) {
-	return func(be *types.Backend) {
-		be.Servers[name] = types.Server{URL: url}
+	srv := NewServer(globalConfig, nil)
+	srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+	config, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+	require.NoError(t, err)
+	srv.serverEntryPoints["https"].setCertificates(certificateSets[0])
+
+	stop := make(chan struct{})
+	reloaded := make(chan struct{})
+	go func() {
+		defer close(reloaded)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				srv.serverEntryPoints["https"].setCertificates(certificateSets[i%2])
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+
+			go func() {
+				tlsServer := cryptotls.Server(serverConn, config)
+				tlsServer.Handshake()
+				tlsServer.Close()
+			}()
+
+			tlsClient := cryptotls.Client(clientConn, &cryptotls.Config{
+				ServerName:         "reload.example.com",
+				InsecureSkipVerify: true,
+			})
+			if err := tlsClient.Handshake(); err != nil {
+				errs <- err
+				return
+			}
+			if dnsNames := tlsClient.ConnectionState().PeerCertificates[0].DNSNames; len(dnsNames) != 1 || dnsNames[0] != "reload.example.com" {
+				errs <- fmt.Errorf("unexpected certificate served for %v", dnsNames)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-reloaded
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
 	}
 }
 
-func withLoadBalancer(method string, sticky bool) func(*types.Backend) {
-	return func(be *types.Backend) {
-		if sticky {
-			be.LoadBalancer = &types.LoadBalancer{Method: method, Stickiness: &types.Stickiness{CookieName: "test"}}
-		} else {
-			be.LoadBalancer = &types.LoadBalancer{Method: method}
-		}
+func TestConfigureBackends(t *testing.T) {
+	validMethod := "Drr"
+	defaultMethod := "wrr"
+
+	tests := []struct {
+		desc           string
+		lb             *types.LoadBalancer
+		wantMethod     string
+		wantStickiness *types.Stickiness
+	}{
+		{
+			desc: "valid load balancer method with sticky enabled",
+			lb: &types.LoadBalancer{
+				Method:     validMethod,
+				Stickiness: &types.Stickiness{},
+			},
+			wantMethod:     validMethod,
+			wantStickiness: &types.Stickiness{},
+		},
+		{
+			desc: "valid load balancer method with sticky disabled",
+			lb: &types.LoadBalancer{
+				Method:     validMethod,
+				Stickiness: nil,
+			},
+			wantMethod: validMethod,
+		},
+		{
+			desc: "invalid load balancer method with sticky enabled",
+			lb: &types.LoadBalancer{
+				Method:     "Invalid",
+				Stickiness: &types.Stickiness{},
+			},
+			wantMethod:     defaultMethod,
+			wantStickiness: &types.Stickiness{},
+		},
+		{
+			desc: "invalid load balancer method with sticky disabled",
+			lb: &types.LoadBalancer{
+				Method:     "Invalid",
+				Stickiness: nil,
+			},
+			wantMethod: defaultMethod,
+		},
+		{
+			desc:       "missing load balancer",
+			lb:         nil,
+			wantMethod: defaultMethod,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+			backend := &types.Backend{
+				LoadBalancer: test.lb,
+			}
+
+			configureBackends(map[string]*types.Backend{
+				"backend": backend,
+			})
+
+			wantLB := types.LoadBalancer{
+				Method:     test.wantMethod,
+				Stickiness: test.wantStickiness,
+			}
+			if !reflect.DeepEqual(*backend.LoadBalancer, wantLB) {
+				t.Errorf("got backend load-balancer\n%v\nwant\n%v\n", spew.Sdump(backend.LoadBalancer), spew.Sdump(wantLB))
+			}
+		})
+	}
+}
+
+func TestServerEntryPointWhitelistConfig(t *testing.T) {
+	tests := []struct {
+		desc           string
+		entrypoint     *configuration.EntryPoint
+		wantMiddleware bool
+	}{
+		{
+			desc: "no whitelist middleware if no config on entrypoint",
+			entrypoint: &configuration.EntryPoint{
+				Address:          ":0",
+				ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
+			},
+			wantMiddleware: false,
+		},
+		{
+			desc: "whitelist middleware should be added if configured on entrypoint",
+			entrypoint: &configuration.EntryPoint{
+				Address: ":0",
+				WhitelistSourceRange: []string{
+					"127.0.0.1/32",
+				},
+				ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
+			},
+			wantMiddleware: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			srv := Server{
+				globalConfiguration: configuration.GlobalConfiguration{
+					EntryPoints: map[string]*configuration.EntryPoint{
+						"test": test.entrypoint,
+					},
+				},
+				metricsRegistry: metrics.NewVoidRegistry(),
+			}
+
+			srv.serverEntryPoints = srv.buildEntryPoints(srv.globalConfiguration)
+			srvEntryPoint := srv.setupServerEntryPoint("test", srv.serverEntryPoints["test"])
+			handler := srvEntryPoint.httpServer.Handler.(*mux.Router).NotFoundHandler.(*negroni.Negroni)
+			found := false
+			for _, handler := range handler.Handlers() {
+				if reflect.TypeOf(handler) == reflect.TypeOf((*middlewares.IPWhiteLister)(nil)) {
+					found = true
+				}
+			}
+
+			if found && !test.wantMiddleware {
+				t.Errorf("ip whitelist middleware was installed even though it should not")
+			}
+
+			if !found && test.wantMiddleware {
+				t.Errorf("ip whitelist middleware was not installed even though it should have")
+			}
+		})
+	}
+}
+
+func TestServerResponseEmptyBackend(t *testing.T) {
+	const requestPath = "/path"
+	const routeRule = "Path:" + requestPath
+
+	testCases := []struct {
+		desc           string
+		dynamicConfig  func(testServerURL string) *types.Configuration
+		wantStatusCode int
+	}{
+		{
+			desc: "Ok",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withServer("testServer", testServerURL))),
+				)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc: "No Frontend",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig()
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			desc: "Empty Backend LB-Drr",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("Drr", false))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			desc: "Empty Backend LB-Drr Sticky",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("Drr", true))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			desc: "Empty Backend LB-LeastConn Sticky",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("LeastConn", true))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			desc: "Empty Backend with a no server redirect",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule), withNoServer(&types.NoServerResponse{Redirect: "http://maintenance.example.com/"}))),
+					withBackend("backend", buildBackend(withLoadBalancer("Wrr", false))),
+				)
+			},
+			wantStatusCode: http.StatusFound,
+		},
+		{
+			desc: "Empty Backend LB-Wrr",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("Wrr", false))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			desc: "Empty Backend LB-Wrr Sticky",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("Wrr", true))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{"config": test.dynamicConfig(testServer.URL)}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			if err != nil {
+				t.Fatalf("error loading config: %s", err)
+			}
+
+			responseRecorder := &httptest.ResponseRecorder{}
+			request := httptest.NewRequest(http.MethodGet, testServer.URL+requestPath, nil)
+
+			entryPoints["http"].httpRouter.ServeHTTP(responseRecorder, request)
+
+			if responseRecorder.Result().StatusCode != test.wantStatusCode {
+				t.Errorf("got status code %d, want %d", responseRecorder.Result().StatusCode, test.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestServerBackendHostHeader(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		passHostHeader bool
+		customHost     string
+		hostHeader     string
+		expectedHost   string
+	}{
+		{
+			desc:           "client host is passed",
+			passHostHeader: true,
+			expectedHost:   "frontend.example.com",
+		},
+		{
+			desc:           "backend host header overrides client host",
+			passHostHeader: true,
+			hostHeader:     "backend.example.com",
+			expectedHost:   "backend.example.com",
+		},
+		{
+			desc:         "backend host header is used without passHostHeader",
+			hostHeader:   "backend.example.com",
+			expectedHost: "backend.example.com",
+		},
+		{
+			desc:         "frontend custom host is used without passHostHeader",
+			customHost:   "custom.example.com",
+			expectedHost: "custom.example.com",
+		},
+		{
+			desc:         "frontend custom host overrides backend host header",
+			customHost:   "custom.example.com",
+			hostHeader:   "backend.example.com",
+			expectedHost: "custom.example.com",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var receivedHost string
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				receivedHost = req.Host
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(
+						withRoute("route", "Path:/"),
+						withPassHostHeader(test.passHostHeader),
+						withCustomHost(test.customHost),
+					)),
+					withBackend("backend", buildBackend(
+						withServer("testServer", testServer.URL),
+						withHostHeader(test.hostHeader),
+					)),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expectedHost, receivedHost)
+		})
+	}
+}
+
+func TestServerDrainingBackend(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		lbMethod       string
+		draining       bool
+		expectedStatus int
+	}{
+		{
+			desc:           "wrr backend not draining",
+			lbMethod:       "wrr",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "wrr backend draining",
+			lbMethod:       "wrr",
+			draining:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:           "leastconn backend not draining",
+			lbMethod:       "leastconn",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "leastconn backend draining",
+			lbMethod:       "leastconn",
+			draining:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:           "drr backend draining",
+			lbMethod:       "drr",
+			draining:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", buildBackend(
+						withServer("testServer", testServer.URL),
+						withLoadBalancer(test.lbMethod, false),
+						withDraining(test.draining),
+					)),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestServerDrainBackend(t *testing.T) {
+	srv := NewServer(configuration.GlobalConfiguration{}, nil)
+	srv.currentConfigurations.Set(types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+			withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"))),
+		),
+	})
+
+	assert.Error(t, srv.drainBackend("unknown", "backend", true))
+	assert.Error(t, srv.drainBackend("config", "unknown", true))
+
+	require.NoError(t, srv.drainBackend("config", "backend", true))
+	configMsg := <-srv.configurationChan
+	assert.Equal(t, "config", configMsg.ProviderName)
+
+	// The current configuration must not be modified in place
+	current := srv.currentConfigurations.Get().(types.Configurations)
+	assert.False(t, current["config"].Backends["backend"].Draining)
+
+	// The override is applied to configurations coming from the provider
+	srv.applyDrainingBackends(configMsg)
+	assert.True(t, configMsg.Configuration.Backends["backend"].Draining)
+
+	providerMsg := types.ConfigMessage{
+		ProviderName: "config",
+		Configuration: buildDynamicConfig(
+			withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"))),
+		),
+	}
+	srv.applyDrainingBackends(providerMsg)
+	assert.True(t, providerMsg.Configuration.Backends["backend"].Draining)
+}
+
+func TestServerSetServerWeight(t *testing.T) {
+	srv := NewServer(configuration.GlobalConfiguration{}, nil)
+	srv.currentConfigurations.Set(types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+			withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"), withServer("other", "http://127.0.0.2"))),
+		),
+	})
+
+	assert.Error(t, srv.setServerWeight("unknown", "backend", "server", 2))
+	assert.Error(t, srv.setServerWeight("config", "unknown", "server", 2))
+	assert.Error(t, srv.setServerWeight("config", "backend", "unknown", 2))
+	assert.Error(t, srv.setServerWeight("config", "backend", "server", -1))
+
+	require.NoError(t, srv.setServerWeight("config", "backend", "server", 5))
+	configMsg := <-srv.configurationChan
+	assert.Equal(t, "config", configMsg.ProviderName)
+	assert.Equal(t, 5, configMsg.Configuration.Backends["backend"].Servers["server"].Weight)
+	assert.Equal(t, 0, configMsg.Configuration.Backends["backend"].Servers["other"].Weight)
+
+	// The current configuration must not be modified in place
+	current := srv.currentConfigurations.Get().(types.Configurations)
+	assert.Equal(t, 0, current["config"].Backends["backend"].Servers["server"].Weight)
+}
+
+func TestServerSetServerWeightPercent(t *testing.T) {
+	srv := NewServer(configuration.GlobalConfiguration{}, nil)
+	srv.currentConfigurations.Set(types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+			withBackend("backend", buildBackend(
+				withWeightedServer("server", "http://127.0.0.1", 30),
+				withWeightedServer("other", "http://127.0.0.2", 70),
+				withPercentWeights(true),
+			)),
+		),
+	})
+
+	assert.Error(t, srv.setServerWeight("config", "backend", "server", 40))
+}
+
+func TestTranslatePercentWeights(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		backend         *types.Backend
+		expectedWeights map[string]int
+		expectedError   bool
+	}{
+		{
+			desc: "raw weights",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", 30),
+				withWeightedServer("server2", "http://127.0.0.2", 60),
+			),
+			expectedWeights: map[string]int{"server1": 30, "server2": 60},
+		},
+		{
+			desc: "percentages",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", 30),
+				withWeightedServer("server2", "http://127.0.0.2", 70),
+				withPercentWeights(true),
+			),
+			expectedWeights: map[string]int{"server1": 3, "server2": 7},
+		},
+		{
+			desc: "percentages with a common divisor",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", 25),
+				withWeightedServer("server2", "http://127.0.0.2", 25),
+				withWeightedServer("server3", "http://127.0.0.3", 50),
+				withPercentWeights(true),
+			),
+			expectedWeights: map[string]int{"server1": 1, "server2": 1, "server3": 2},
+		},
+		{
+			desc: "server without requests",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", 0),
+				withWeightedServer("server2", "http://127.0.0.2", 100),
+				withPercentWeights(true),
+			),
+			expectedWeights: map[string]int{"server1": 0, "server2": 1},
+		},
+		{
+			desc: "percentages not summing to 100",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", 30),
+				withWeightedServer("server2", "http://127.0.0.2", 60),
+				withPercentWeights(true),
+			),
+			expectedError: true,
+		},
+		{
+			desc: "negative percentage",
+			backend: buildBackend(
+				withWeightedServer("server1", "http://127.0.0.1", -10),
+				withWeightedServer("server2", "http://127.0.0.2", 110),
+				withPercentWeights(true),
+			),
+			expectedError: true,
+		},
+		{
+			desc:          "no server",
+			backend:       buildBackend(withPercentWeights(true)),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			translated, err := translatePercentWeights(test.backend)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			weights := make(map[string]int)
+			for name, server := range translated.Servers {
+				weights[name] = server.Weight
+			}
+			assert.Equal(t, test.expectedWeights, weights)
+		})
+	}
+}
+
+func TestServerPercentWeights(t *testing.T) {
+	var lock sync.Mutex
+	requests := make(map[string]int)
+	newBackendServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			requests[name]++
+		}))
+	}
+	server1 := newBackendServer("server1")
+	defer server1.Close()
+	server2 := newBackendServer("server2")
+	defer server2.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Host:frontend.example.com"))),
+			withFrontend("invalid", buildFrontend(withRoute("route", "Host:invalid.example.com"), withFrontendBackend("invalid"))),
+			withBackend("backend", buildBackend(
+				withWeightedServer("server1", server1.URL, 30),
+				withWeightedServer("server2", server2.URL, 70),
+				withPercentWeights(true),
+			)),
+			withBackend("invalid", buildBackend(
+				withWeightedServer("server1", server1.URL, 30),
+				withWeightedServer("server2", server2.URL, 60),
+				withPercentWeights(true),
+			)),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	lock.Lock()
+	assert.Equal(t, map[string]int{"server1": 3, "server2": 7}, requests)
+	lock.Unlock()
+
+	// the frontend of a backend whose percentages don't sum to 100 is skipped
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://invalid.example.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServerRetryBudget(t *testing.T) {
+	// the backend server counts the attempts and closes their connections without responding, failing every one of them
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		conn, _, err := rw.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+	serverURL := server.URL
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+		Retry: &configuration.Retry{Attempts: 3, Budget: &types.RetryBudget{Ratio: 0.5, Burst: 1}},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Host:frontend.example.com"))),
+			withFrontend("unlimited", buildFrontend(withRoute("route", "Host:unlimited.example.com"), withFrontendBackend("unlimited"))),
+			withFrontend("invalid", buildFrontend(withRoute("route", "Host:invalid.example.com"), withFrontendBackend("invalid"))),
+			withBackend("backend", buildBackend(withServer("server", serverURL))),
+			withBackend("unlimited", buildBackend(withServer("server", serverURL), withRetryBudget(&types.RetryBudget{}))),
+			withBackend("invalid", buildBackend(withServer("server", serverURL), withRetryBudget(&types.RetryBudget{Ratio: -1}))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	serve := func(host string) int32 {
+		before := atomic.LoadInt32(&attempts)
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil))
+		return atomic.LoadInt32(&attempts) - before
+	}
+
+	// the budget starts with one retry, and each request adds half a retry to it
+	assert.Equal(t, int32(2), serve("frontend.example.com"))
+	assert.Equal(t, int32(1), serve("frontend.example.com"))
+	assert.Equal(t, int32(2), serve("frontend.example.com"))
+
+	// a zero ratio disables the global budget for the backend
+	assert.Equal(t, int32(3), serve("unlimited.example.com"))
+
+	// the frontend of a backend with an invalid budget is skipped
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://invalid.example.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServerBackendSourceAddress(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		sourceAddress  string
+		expectedStatus int
+		expectedIP     string
+	}{
+		{
+			desc:           "default source address",
+			expectedStatus: http.StatusOK,
+			expectedIP:     "127.0.0.1",
+		},
+		{
+			desc:           "bound source address",
+			sourceAddress:  "127.0.0.2",
+			expectedStatus: http.StatusOK,
+			expectedIP:     "127.0.0.2",
+		},
+		{
+			desc:           "invalid source address",
+			sourceAddress:  "not-an-ip",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var remoteIP string
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				remoteIP, _, _ = net.SplitHostPort(req.RemoteAddr)
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", buildBackend(
+						withServer("testServer", testServer.URL),
+						withSourceAddress(test.sourceAddress),
+					)),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedIP, remoteIP)
+		})
+	}
+}
+
+func TestServerBackendTransport(t *testing.T) {
+	globalConfig := configuration.GlobalConfiguration{MaxIdleConnsPerHost: 200}
+
+	testCases := []struct {
+		desc             string
+		transport        *types.Transport
+		expectedSettings transportSettings
+		expectedError    bool
+	}{
+		{
+			desc: "global settings",
+			expectedSettings: transportSettings{
+				maxIdleConnsPerHost: 200,
+				idleConnTimeout:     90 * time.Second,
+				dialKeepAlive:       30 * time.Second,
+			},
+		},
+		{
+			desc: "overridden settings",
+			transport: &types.Transport{
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     "5m",
+				DialKeepAlive:       "1s",
+			},
+			expectedSettings: transportSettings{
+				maxIdleConnsPerHost: 10,
+				idleConnTimeout:     5 * time.Minute,
+				dialKeepAlive:       time.Second,
+			},
+		},
+		{
+			desc: "partially overridden settings",
+			transport: &types.Transport{
+				IdleConnTimeout: "0s",
+			},
+			expectedSettings: transportSettings{
+				maxIdleConnsPerHost: 200,
+				idleConnTimeout:     0,
+				dialKeepAlive:       30 * time.Second,
+			},
+		},
+		{
+			desc:          "negative max idle connections",
+			transport:     &types.Transport{MaxIdleConnsPerHost: -1},
+			expectedError: true,
+		},
+		{
+			desc:          "invalid idle connection timeout",
+			transport:     &types.Transport{IdleConnTimeout: "soon"},
+			expectedError: true,
+		},
+		{
+			desc:          "negative dial keep-alive",
+			transport:     &types.Transport{DialKeepAlive: "-1s"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			srv := NewServer(globalConfig, nil)
+			roundTripper, err := srv.getRoundTripper("http", globalConfig, false, nil, &types.Backend{Transport: test.transport}, nil)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			transport, ok := roundTripper.(*http.Transport)
+			require.True(t, ok)
+			assert.Equal(t, test.expectedSettings.maxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+			assert.Equal(t, test.expectedSettings.idleConnTimeout, transport.IdleConnTimeout)
+
+			settings, err := newTransportSettings(globalConfig, test.transport)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSettings, settings)
+		})
+	}
+}
+
+func TestServerBackendH2C(t *testing.T) {
+	release := make(chan struct{})
+	h2cHandler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Trailer", "Grpc-Status")
+		rw.WriteHeader(http.StatusOK)
+		fmt.Fprintf(rw, "%s first\n", req.Proto)
+		rw.(http.Flusher).Flush()
+
+		<-release
+		fmt.Fprintln(rw, "second")
+		rw.Header().Set("Grpc-Status", "0")
+	})
+
+	// the backend only speaks HTTP/2 cleartext with prior knowledge
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		h2Server := &http2.Server{}
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go h2Server.ServeConn(conn, &http2.ServeConnOpts{Handler: h2cHandler})
+		}
+	}()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+			withBackend("backend", buildBackend(
+				withServer("h2cServer", "http://"+listener.Addr().String()),
+				withH2C(true),
+			)),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	frontendServer := httptest.NewServer(entryPoints["http"].httpRouter)
+	defer frontendServer.Close()
+
+	resp, err := http.Get(frontendServer.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the first part of the response is received while the backend is still streaming
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0 first\n", line)
+
+	close(release)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", line)
+
+	_, err = ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+}
+
+func TestServerRequiredHeaders(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(
+				withRoute("route", "Path:/"),
+				withRequiredHeaders(map[string]*types.RequiredHeader{"X-Api-Version": {Values: []string{"1"}}}),
+			)),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		apiVersion     string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "valid header",
+			apiVersion:     "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "backend",
+		},
+		{
+			desc:           "missing header",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "missing required header X-Api-Version\n",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://traefik.test/", nil)
+			if len(test.apiVersion) > 0 {
+				req.Header.Set("X-Api-Version", test.apiVersion)
+			}
+
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestServerDNSResolver(t *testing.T) {
+	// the hostname of the backend is only known by the custom DNS server
+	dnsConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dnsServer := &dns.Server{
+		PacketConn: dnsConn,
+		Handler: dns.HandlerFunc(func(rw dns.ResponseWriter, req *dns.Msg) {
+			msg := &dns.Msg{}
+			msg.SetReply(req)
+			if req.Question[0].Qtype == dns.TypeA && req.Question[0].Name == "backend.traefik.test." {
+				msg.Answer = append(msg.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+					A:   net.ParseIP("127.0.0.1"),
+				})
+			}
+			rw.WriteMsg(msg)
+		}),
+	}
+	go dnsServer.ActivateAndServe()
+	defer dnsServer.Shutdown()
+
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+	_, port, err := net.SplitHostPort(backendServer.Listener.Addr().String())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		dnsRefresh *types.DNSRefresh
+	}{
+		{
+			desc: "resolved by the dialer",
+		},
+		{
+			desc:       "resolved by the DNS refresh",
+			dnsRefresh: &types.DNSRefresh{Interval: "1m"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				DNSResolver: &types.DNSResolver{
+					Servers: []string{dnsConn.LocalAddr().String()},
+					Timeout: flaeg.Duration(time.Second),
+				},
+			}
+			backend := buildBackend(withServer("server", "http://backend.traefik.test:"+port))
+			backend.DNSRefresh = test.dnsRefresh
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", backend),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://traefik.test/", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "backend", recorder.Body.String())
+		})
+	}
+}
+
+func TestServerGRPCWeb(t *testing.T) {
+	// the backend answers like a gRPC server, with undeclared trailers
+	grpcHandler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil || req.ProtoMajor != 2 || req.Header.Get("Content-Type") != "application/grpc" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/grpc")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(body)
+		rw.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		h2Server := &http2.Server{}
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go h2Server.ServeConn(conn, &http2.ServeConnOpts{Handler: grpcHandler})
+		}
+	}()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(
+				withRoute("route", "Path:/helloworld.Greeter/SayHello"),
+				withGRPCWeb(&types.GRPCWeb{AllowedOrigins: []string{"https://app.example.com"}}),
+			)),
+			withBackend("backend", buildBackend(
+				withServer("h2cServer", "http://"+listener.Addr().String()),
+				withH2C(true),
+			)),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	frontendServer := httptest.NewServer(entryPoints["http"].httpRouter)
+	defer frontendServer.Close()
+
+	message := []byte{0, 0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}
+	req, err := http.NewRequest(http.MethodPost, frontendServer.URL+"/helloworld.Greeter/SayHello", bytes.NewReader(message))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc-web")
+	req.Header.Set("Origin", "https://app.example.com")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/grpc-web", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	expected := append(message, 0x80, 0, 0, 0, 16)
+	expected = append(expected, "grpc-status: 0\r\n"...)
+	assert.Equal(t, expected, body)
+}
+
+func TestServerClientClosedRequest(t *testing.T) {
+	testCases := []struct {
+		desc                      string
+		clientClosedRequestStatus int
+		expectedStatusCode        int
+	}{
+		{
+			desc:               "default status",
+			expectedStatusCode: StatusClientClosedRequest,
+		},
+		{
+			desc:                      "bad gateway status",
+			clientClosedRequestStatus: http.StatusBadGateway,
+			expectedStatusCode:        http.StatusBadGateway,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			backendCanceled := make(chan struct{})
+			backendStarted := make(chan struct{})
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				close(backendStarted)
+				<-req.Context().Done()
+				close(backendCanceled)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				ClientClosedRequestStatus: test.clientClosedRequestStatus,
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", buildBackend(withServer("testServer", testServer.URL))),
+				),
+			}
+
+			reqsCounter := &testhelpers.CollectingCounter{}
+			srv := NewServer(globalConfig, nil)
+			srv.metricsRegistry = &collectingBackendMetrics{Registry: metrics.NewVoidRegistry(), reqsCounter: reqsCounter}
+
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil).WithContext(ctx)
+			recorder := httptest.NewRecorder()
+
+			served := make(chan struct{})
+			go func() {
+				entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+				close(served)
+			}()
+
+			select {
+			case <-backendStarted:
+			case <-time.After(5 * time.Second):
+				t.Fatal("backend did not receive the request")
+			}
+
+			cancel()
+
+			select {
+			case <-backendCanceled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("backend request context was not canceled")
+			}
+			select {
+			case <-served:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request was not completed")
+			}
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, float64(1), reqsCounter.CounterValue)
+			assert.Contains(t, reqsCounter.LastLabelValues, strconv.Itoa(test.expectedStatusCode))
+		})
+	}
+}
+
+type collectingBackendMetrics struct {
+	metrics.Registry
+	reqsCounter *testhelpers.CollectingCounter
+}
+
+func (m *collectingBackendMetrics) IsEnabled() bool {
+	return true
+}
+
+func (m *collectingBackendMetrics) BackendReqsCounter() gokitmetrics.Counter {
+	return m.reqsCounter
+}
+
+func TestServerFrontendBuffering(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{
+			desc:           "frontend without buffering",
+			path:           "/unbuffered",
+			body:           "a body larger than the limit",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  1,
+		},
+		{
+			desc:           "request body too large",
+			path:           "/buffered",
+			body:           "a body larger than the limit",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCalls:  0,
+		},
+		{
+			desc:           "retry on buffered response status",
+			path:           "/buffered",
+			body:           "body",
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// the first call fails, the following ones succeed
+			var calls int32
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					rw.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("buffered", buildFrontend(
+						withRoute("route", "Path:/buffered"),
+						withFrontendBuffering(&types.Buffering{
+							MaxRequestBodyBytes: 10,
+							RetryExpression:     "ResponseCode() == 503 && Attempts() < 2",
+						}),
+					)),
+					withFrontend("unbuffered", buildFrontend(withRoute("route", "Path:/unbuffered"))),
+					withBackend("backend", buildBackend(withServer("testServer", testServer.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "http://frontend.example.com"+test.path, strings.NewReader(test.body))
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestServerFrontendRequestTimeout(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		path             string
+		expectedStatus   int
+		expectedCanceled bool
+	}{
+		{
+			desc:           "response header timeout of the entrypoint",
+			path:           "/default",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			desc:           "request timeout longer than the response header timeout",
+			path:           "/slow",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:             "request timeout exceeded",
+			path:             "/timedout",
+			expectedStatus:   http.StatusGatewayTimeout,
+			expectedCanceled: true,
+		},
+		{
+			desc:           "request timeout with the response header timeout of the frontend",
+			path:           "/both",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			desc:           "invalid request timeout",
+			path:           "/invalid",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			canceled := make(chan struct{})
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				select {
+				case <-time.After(300 * time.Millisecond):
+					rw.WriteHeader(http.StatusOK)
+				case <-req.Context().Done():
+					close(canceled)
+				}
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				ForwardingTimeouts: &configuration.ForwardingTimeouts{
+					ResponseHeaderTimeout: flaeg.Duration(100 * time.Millisecond),
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("default", buildFrontend(withRoute("route", "Path:/default"))),
+					withFrontend("slow", buildFrontend(withRoute("route", "Path:/slow"), withForwardingTimeouts(&types.ForwardingTimeouts{RequestTimeout: "1s"}))),
+					withFrontend("timedout", buildFrontend(withRoute("route", "Path:/timedout"), withForwardingTimeouts(&types.ForwardingTimeouts{RequestTimeout: "50ms"}))),
+					withFrontend("both", buildFrontend(withRoute("route", "Path:/both"),
+						withForwardingTimeouts(&types.ForwardingTimeouts{RequestTimeout: "1s", ResponseHeaderTimeout: "50ms"}))),
+					withFrontend("invalid", buildFrontend(withRoute("route", "Path:/invalid"), withForwardingTimeouts(&types.ForwardingTimeouts{RequestTimeout: "1 minute"}))),
+					withBackend("backend", buildBackend(withServer("testServer", testServer.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com"+test.path, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if test.expectedCanceled {
+				select {
+				case <-canceled:
+				case <-time.After(time.Second):
+					t.Error("the request to the backend should be canceled")
+				}
+			}
+		})
+	}
+}
+
+func TestServerFrontendForwardingTimeouts(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		path           string
+		expectedStatus int
+	}{
+		{
+			desc:           "global response header timeout",
+			path:           "/default",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			desc:           "long response header timeout of the frontend",
+			path:           "/long",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "short response header timeout of the frontend",
+			path:           "/short",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			desc:           "frontend overriding only the dial timeout",
+			path:           "/dial",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			desc:           "invalid timeout",
+			path:           "/invalid",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				select {
+				case <-time.After(300 * time.Millisecond):
+					rw.WriteHeader(http.StatusOK)
+				case <-req.Context().Done():
+				}
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				ForwardingTimeouts: &configuration.ForwardingTimeouts{
+					DialTimeout:           flaeg.Duration(time.Second),
+					ResponseHeaderTimeout: flaeg.Duration(200 * time.Millisecond),
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("default", buildFrontend(withRoute("route", "Path:/default"))),
+					withFrontend("long", buildFrontend(withRoute("route", "Path:/long"),
+						withForwardingTimeouts(&types.ForwardingTimeouts{ResponseHeaderTimeout: "1s"}))),
+					withFrontend("short", buildFrontend(withRoute("route", "Path:/short"),
+						withForwardingTimeouts(&types.ForwardingTimeouts{ResponseHeaderTimeout: "50ms"}))),
+					withFrontend("dial", buildFrontend(withRoute("route", "Path:/dial"),
+						withForwardingTimeouts(&types.ForwardingTimeouts{DialTimeout: "5s"}))),
+					withFrontend("invalid", buildFrontend(withRoute("route", "Path:/invalid"),
+						withForwardingTimeouts(&types.ForwardingTimeouts{ResponseHeaderTimeout: "-1s"}))),
+					withBackend("backend", buildBackend(withServer("testServer", testServer.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "http://frontend.example.com"+test.path, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestParseForwardingTimeouts(t *testing.T) {
+	testCases := []struct {
+		desc                   string
+		global                 *configuration.ForwardingTimeouts
+		frontend               *types.ForwardingTimeouts
+		expected               *configuration.ForwardingTimeouts
+		expectedRequestTimeout time.Duration
+		expectedError          bool
+	}{
+		{
+			desc:   "no frontend timeouts",
+			global: &configuration.ForwardingTimeouts{DialTimeout: flaeg.Duration(time.Second)},
+		},
+		{
+			desc:     "frontend timeouts without global ones",
+			frontend: &types.ForwardingTimeouts{ResponseHeaderTimeout: "10s"},
+			expected: &configuration.ForwardingTimeouts{
+				DialTimeout:           flaeg.Duration(configuration.DefaultDialTimeout),
+				ResponseHeaderTimeout: flaeg.Duration(10 * time.Second),
+			},
+		},
+		{
+			desc: "frontend timeouts layered on the global ones",
+			global: &configuration.ForwardingTimeouts{
+				DialTimeout:           flaeg.Duration(time.Second),
+				ResponseHeaderTimeout: flaeg.Duration(time.Minute),
+			},
+			frontend: &types.ForwardingTimeouts{DialTimeout: "0s"},
+			expected: &configuration.ForwardingTimeouts{
+				ResponseHeaderTimeout: flaeg.Duration(time.Minute),
+			},
+		},
+		{
+			desc: "request timeout replacing the global response header timeout",
+			global: &configuration.ForwardingTimeouts{
+				DialTimeout:           flaeg.Duration(time.Second),
+				ResponseHeaderTimeout: flaeg.Duration(time.Minute),
+			},
+			frontend: &types.ForwardingTimeouts{RequestTimeout: "2m"},
+			expected: &configuration.ForwardingTimeouts{
+				DialTimeout: flaeg.Duration(time.Second),
+			},
+			expectedRequestTimeout: 2 * time.Minute,
+		},
+		{
+			desc:     "request timeout with the response header timeout of the frontend",
+			global:   &configuration.ForwardingTimeouts{ResponseHeaderTimeout: flaeg.Duration(time.Minute)},
+			frontend: &types.ForwardingTimeouts{RequestTimeout: "2m", ResponseHeaderTimeout: "10s"},
+			expected: &configuration.ForwardingTimeouts{
+				ResponseHeaderTimeout: flaeg.Duration(10 * time.Second),
+			},
+			expectedRequestTimeout: 2 * time.Minute,
+		},
+		{
+			desc:          "invalid dial timeout",
+			frontend:      &types.ForwardingTimeouts{DialTimeout: "1 minute"},
+			expectedError: true,
+		},
+		{
+			desc:          "negative response header timeout",
+			frontend:      &types.ForwardingTimeouts{ResponseHeaderTimeout: "-1s"},
+			expectedError: true,
+		},
+		{
+			desc:          "zero request timeout",
+			frontend:      &types.ForwardingTimeouts{RequestTimeout: "0s"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			timeouts, requestTimeout, err := parseForwardingTimeouts(test.global, test.frontend)
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, timeouts)
+			assert.Equal(t, test.expectedRequestTimeout, requestTimeout)
+		})
+	}
+}
+
+func TestServerAmbiguousRoutes(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		ambiguousRoutes string
+		expectedError   bool
+	}{
+		{
+			desc: "default picks the first frontend",
+		},
+		{
+			desc:            "first picks the first frontend",
+			ambiguousRoutes: configuration.AmbiguousRoutesFirst,
+		},
+		{
+			desc:            "fail rejects the configuration",
+			ambiguousRoutes: configuration.AmbiguousRoutesFail,
+			expectedError:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var servedBy []string
+			newTestServer := func(name string) *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					servedBy = append(servedBy, name)
+					rw.WriteHeader(http.StatusOK)
+				}))
+			}
+			serverA := newTestServer("a")
+			defer serverA.Close()
+			serverB := newTestServer("b")
+			defer serverB.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				AmbiguousRoutes: test.ambiguousRoutes,
+			}
+
+			frontendA := buildFrontend(withRoute("route", "Path:/test;Method:GET"))
+			frontendA.Backend = "backend-a"
+			// the rules of frontend b are equivalent to the ones of frontend a, though written differently
+			frontendB := buildFrontend(withRoute("route", "Method:get;Path:/test"))
+			frontendB.Backend = "backend-b"
+
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend-a", frontendA),
+					withFrontend("frontend-b", frontendB),
+					withBackend("backend-a", buildBackend(withServer("server", serverA.URL))),
+					withBackend("backend-b", buildBackend(withServer("server", serverB.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+
+			for i := 0; i < 10; i++ {
+				entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+				if test.expectedError {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+
+				request := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+				recorder := httptest.NewRecorder()
+				entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+				require.Equal(t, http.StatusOK, recorder.Code)
+			}
+
+			require.Len(t, servedBy, 10)
+			for _, name := range servedBy {
+				assert.Equal(t, "a", name)
+			}
+		})
+	}
+}
+
+func TestServerAmbiguousRoutesFirstDeclared(t *testing.T) {
+	var servedBy []string
+	var servers []*httptest.Server
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+
+	// more than the 12 routes up to which an unstable sort keeps the order of equal routes, with rules of the same length
+	dynamicConfig := buildDynamicConfig()
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("%02d", i)
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			servedBy = append(servedBy, name)
+			rw.WriteHeader(http.StatusOK)
+		}))
+		servers = append(servers, server)
+
+		frontend := buildFrontend(withRoute("route", "PathPrefix:/"+name[:1]), withPriority(10))
+		frontend.Backend = "backend-" + name
+		dynamicConfig.Frontends["frontend-"+name] = frontend
+		dynamicConfig.Backends["backend-"+name] = buildBackend(withServer("server", server.URL))
+	}
+	dynamicConfigs := types.Configurations{"config": dynamicConfig}
+
+	srv := NewServer(globalConfig, nil)
+
+	for i := 0; i < 10; i++ {
+		entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+		require.NoError(t, err)
+
+		for _, path := range []string{"/0", "/1"} {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+		}
+	}
+
+	require.Len(t, servedBy, 20)
+	for i, name := range servedBy {
+		assert.Equal(t, []string{"00", "10"}[i%2], name)
+	}
+}
+
+func TestServerAmbiguousRoutesOverlap(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		frontendA     *types.Frontend
+		frontendB     *types.Frontend
+		expectedError bool
+	}{
+		{
+			desc:          "nested path prefixes",
+			frontendA:     buildFrontend(withRoute("route", "PathPrefix:/a")),
+			frontendB:     buildFrontend(withRoute("route", "PathPrefix:/a/b")),
+			expectedError: true,
+		},
+		{
+			desc:          "nested path prefixes with the same explicit priority",
+			frontendA:     buildFrontend(withRoute("route", "PathPrefix:/a"), withPriority(10)),
+			frontendB:     buildFrontend(withRoute("route", "Host:foo.bar;PathPrefix:/a/b"), withPriority(10)),
+			expectedError: true,
+		},
+		{
+			desc:      "nested path prefixes with other priorities",
+			frontendA: buildFrontend(withRoute("route", "PathPrefix:/a"), withPriority(10)),
+			frontendB: buildFrontend(withRoute("route", "PathPrefix:/a/b"), withPriority(20)),
+		},
+		{
+			desc:      "distinct path prefixes",
+			frontendA: buildFrontend(withRoute("route", "PathPrefix:/a")),
+			frontendB: buildFrontend(withRoute("route", "PathPrefix:/b")),
+		},
+		{
+			desc:      "other hosts",
+			frontendA: buildFrontend(withRoute("route", "Host:foo.bar;PathPrefix:/")),
+			frontendB: buildFrontend(withRoute("route", "Host:bar.foo;PathPrefix:/a")),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				AmbiguousRoutes: configuration.AmbiguousRoutesFail,
+			}
+
+			test.frontendA.Backend = "backend"
+			test.frontendB.Backend = "backend"
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend-a", test.frontendA),
+					withFrontend("frontend-b", test.frontendB),
+					withBackend("backend", buildBackend(withServer("server", "http://localhost"))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+
+			_, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServerFrontendPriority(t *testing.T) {
+	newTestServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(name))
+		}))
+	}
+	serverLong := newTestServer("long")
+	defer serverLong.Close()
+	serverPriority := newTestServer("priority")
+	defer serverPriority.Close()
+	serverPriorityLonger := newTestServer("priority-longer")
+	defer serverPriorityLonger.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend-long", buildFrontend(
+				withRoute("route", "Host:example.com;PathPrefix:/api/v1"),
+				withFrontendBackend("backend-long"))),
+			withFrontend("frontend-priority", buildFrontend(
+				withRoute("route", "Host:example.com"),
+				withFrontendBackend("backend-priority"),
+				withPriority(10))),
+			withFrontend("frontend-priority-longer", buildFrontend(
+				withRoute("route", "Host:example.com;PathPrefix:/api"),
+				withFrontendBackend("backend-priority-longer"),
+				withPriority(10))),
+			withBackend("backend-long", buildBackend(withServer("server", serverLong.URL))),
+			withBackend("backend-priority", buildBackend(withServer("server", serverPriority.URL))),
+			withBackend("backend-priority-longer", buildBackend(withServer("server", serverPriorityLonger.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		path       string
+		expectedBy string
+	}{
+		{
+			desc:       "explicit priority over longer rules",
+			path:       "/api/v1/foo",
+			expectedBy: "priority-longer",
+		},
+		{
+			desc:       "same priority falls back to the longest rules",
+			path:       "/api/foo",
+			expectedBy: "priority-longer",
+		},
+		{
+			desc:       "only the shortest rules match",
+			path:       "/foo",
+			expectedBy: "priority",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://example.com"+test.path, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expectedBy, recorder.Body.String())
+		})
+	}
+}
+
+func TestBuildRedirectHandler(t *testing.T) {
+	srv := Server{
+		globalConfiguration: configuration.GlobalConfiguration{
+			EntryPoints: configuration.EntryPoints{
+				"http":  &configuration.EntryPoint{Address: ":80"},
+				"https": &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc              string
+		srcEntryPointName string
+		url               string
+		entryPoint        *configuration.EntryPoint
+		redirect          *types.Redirect
+		expectedURL       string
+	}{
+		{
+			desc:              "redirect regex",
+			srcEntryPointName: "http",
+			url:               "http://foo.com",
+			redirect: &types.Redirect{
+				Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
+				Replacement: "https://$1{{\"bar\"}}$2",
+			},
+			entryPoint: &configuration.EntryPoint{
+				Address: ":80",
+				Redirect: &types.Redirect{
+					Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
+					Replacement: "https://$1{{\"bar\"}}$2",
+				},
+			},
+			expectedURL: "https://foobar.com",
+		},
+		{
+			desc:              "redirect entry point",
+			srcEntryPointName: "http",
+			url:               "http://foo:80",
+			redirect: &types.Redirect{
+				EntryPoint: "https",
+			},
+			entryPoint: &configuration.EntryPoint{
+				Address: ":80",
+				Redirect: &types.Redirect{
+					EntryPoint: "https",
+				},
+			},
+			expectedURL: "https://foo:443",
+		},
+		{
+			desc:              "redirect entry point with regex (ignored)",
+			srcEntryPointName: "http",
+			url:               "http://foo.com:80",
+			redirect: &types.Redirect{
+				EntryPoint:  "https",
+				Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
+				Replacement: "https://$1{{\"bar\"}}$2",
+			},
+			entryPoint: &configuration.EntryPoint{
+				Address: ":80",
+				Redirect: &types.Redirect{
+					EntryPoint:  "https",
+					Regex:       `^(?:http?:\/\/)(foo)(\.com)$`,
+					Replacement: "https://$1{{\"bar\"}}$2",
+				},
+			},
+			expectedURL: "https://foo.com:443",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rewrite, err := srv.buildRedirectHandler(test.srcEntryPointName, test.redirect)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, test.url, nil)
+			recorder := httptest.NewRecorder()
+
+			rewrite.ServeHTTP(recorder, req, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Location", "fail")
+			}))
+
+			location, err := recorder.Result().Location()
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedURL, location.String())
+		})
+	}
+}
+
+func TestServerMethodNotAllowed(t *testing.T) {
+	newBackendServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(name))
+		}))
+	}
+	getServer := newBackendServer("get")
+	defer getServer.Close()
+	postServer := newBackendServer("post")
+	defer postServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend-get", buildFrontend(
+				withRoute("route", "Host:frontend.example.com;Path:/x;Method:GET"),
+				withFrontendBackend("backend-get"))),
+			withFrontend("frontend-post", buildFrontend(
+				withRoute("route", "Host:frontend.example.com;Path:/x;Method:POST,PUT"),
+				withFrontendBackend("backend-post"))),
+			withFrontend("frontend-other-host", buildFrontend(
+				withRoute("route", "Host:other.example.com;Path:/x;Method:PATCH"),
+				withFrontendBackend("backend-post"))),
+			withBackend("backend-get", buildBackend(withServer("server", getServer.URL))),
+			withBackend("backend-post", buildBackend(withServer("server", postServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedAllow  string
+	}{
+		{
+			desc:           "GET routed to its frontend",
+			method:         http.MethodGet,
+			path:           "/x",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "get",
+		},
+		{
+			desc:           "POST routed to its frontend",
+			method:         http.MethodPost,
+			path:           "/x",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "post",
+		},
+		{
+			desc:           "method not allowed by any frontend",
+			method:         http.MethodDelete,
+			path:           "/x",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedAllow:  "GET, POST, PUT",
+		},
+		{
+			desc:           "path not matched",
+			method:         http.MethodDelete,
+			path:           "/y",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "http://frontend.example.com"+test.path, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedAllow, recorder.Header().Get("Allow"))
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerDefaultMiddlewares(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+		DefaultMiddlewares: &configuration.DefaultMiddlewares{
+			Headers: &types.Headers{
+				CustomResponseHeaders: map[string]string{
+					"X-Default":  "default",
+					"X-Override": "default",
+				},
+				FrameDeny: true,
+			},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend-defaults", buildFrontend(
+				withRoute("route", "Host:defaults.example.com"),
+				withFrontendHeaders(&types.Headers{CustomResponseHeaders: map[string]string{"X-Override": "frontend"}}))),
+			withFrontend("frontend-no-defaults", buildFrontend(
+				withRoute("route", "Host:nodefaults.example.com"),
+				withSkipDefaultMiddlewares(true))),
+			withBackend("backend", buildBackend(withServer("server", testServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc            string
+		host            string
+		expectedHeaders map[string]string
+	}{
+		{
+			desc: "frontend with the default middlewares",
+			host: "defaults.example.com",
+			expectedHeaders: map[string]string{
+				"X-Default":       "default",
+				"X-Override":      "frontend",
+				"X-Frame-Options": "DENY",
+			},
+		},
+		{
+			desc: "frontend opting out of the default middlewares",
+			host: "nodefaults.example.com",
+			expectedHeaders: map[string]string{
+				"X-Default":       "",
+				"X-Override":      "",
+				"X-Frame-Options": "",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://"+test.host+"/", nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, recorder.Header().Get(name), name)
+			}
+		})
+	}
+}
+
+func TestServerMirroring(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("primary"))
+	}))
+	defer testServer.Close()
+
+	mirrored := make(chan string, 1)
+	mirrorServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mirrored <- req.URL.Path
+	}))
+	defer mirrorServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend-mirrored", buildFrontend(
+				withRoute("route", "Host:mirrored.example.com"),
+				withMirroring(&types.Mirroring{Backends: []string{"staging"}}))),
+			withFrontend("frontend-undefined-mirror", buildFrontend(
+				withRoute("route", "Host:undefined.example.com"),
+				withMirroring(&types.Mirroring{Backends: []string{"undefined"}}))),
+			withBackend("backend", buildBackend(withServer("server", testServer.URL))),
+			withBackend("staging", buildBackend(withServer("server", mirrorServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://mirrored.example.com/foo", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "primary", recorder.Body.String())
+
+	select {
+	case path := <-mirrored:
+		assert.Equal(t, "/foo", path)
+	case <-time.After(time.Second):
+		t.Error("request not mirrored")
+	}
+
+	// the frontend with an undefined mirror backend is skipped
+	recorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://undefined.example.com/foo", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServerStaticResponse(t *testing.T) {
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("health", buildFrontend(
+				withRoute("route", "Path:/health"),
+				withFrontendBackend(""),
+				withStaticResponse(&types.StaticResponse{
+					Headers: map[string]string{"Content-Type": "application/json"},
+					Body:    `{"host":"{{ .Host }}"}`,
+				}),
+			)),
+			withFrontend("redirect", buildFrontend(
+				withRoute("route", "Host:old.example.com"),
+				withFrontendBackend("undefined"),
+				withStaticResponse(&types.StaticResponse{
+					StatusCode: http.StatusMovedPermanently,
+					Redirect:   "https://new.example.com{{ .RequestURI }}",
+				}),
+			)),
+			withFrontend("private", buildFrontend(
+				withRoute("route", "Path:/private"),
+				withStaticResponse(&types.StaticResponse{Body: "private"}),
+				func(fe *types.Frontend) {
+					fe.WhitelistSourceRange = []string{"10.0.0.0/8"}
+				},
+			)),
+			withFrontend("invalid", buildFrontend(
+				withRoute("route", "Path:/invalid"),
+				withStaticResponse(&types.StaticResponse{StatusCode: http.StatusOK, Redirect: "https://example.com"}),
+			)),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc             string
+		url              string
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			desc:           "templated body",
+			url:            "http://frontend.example.com/health",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"host":"frontend.example.com"}`,
+		},
+		{
+			desc:             "redirection",
+			url:              "http://old.example.com/foo?bar=1",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://new.example.com/foo?bar=1",
+		},
+		{
+			desc:           "frontend middlewares",
+			url:            "http://frontend.example.com/private",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			desc:           "invalid static response",
+			url:            "http://frontend.example.com/invalid",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.url, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if len(test.expectedBody) > 0 {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+			assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestServerRemovedResponseHeaders(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Server", "Apache")
+		rw.Header().Set("X-Powered-By", "PHP")
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("hardened", buildFrontend(
+				withRoute("route", "Path:/hardened"),
+				withFrontendHeaders(&types.Headers{
+					CustomResponseHeaders:  map[string]string{"X-Powered-By": "Traefik"},
+					RemovedResponseHeaders: []string{"server", "X-Powered-By"},
+				}),
+			)),
+			withFrontend("default", buildFrontend(
+				withRoute("route", "Path:/default"),
+				withFrontendBackend("backend2"),
+			)),
+			withBackend("backend", buildBackend(withServer("server", backend.URL))),
+			withBackend("backend2", buildBackend(withServer("server", backend.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc              string
+		url               string
+		expectedServer    string
+		expectedPoweredBy string
+	}{
+		{
+			desc: "removed headers",
+			url:  "http://frontend.example.com/hardened",
+		},
+		{
+			desc:              "frontend without removed headers",
+			url:               "http://frontend.example.com/default",
+			expectedServer:    "Apache",
+			expectedPoweredBy: "PHP",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.url, nil)
+			recorder := httptest.NewRecorder()
+
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expectedServer, recorder.Header().Get("Server"))
+			assert.Equal(t, test.expectedPoweredBy, recorder.Header().Get("X-Powered-By"))
+		})
+	}
+}
+
+func TestServerHeaderOverride(t *testing.T) {
+	newTestServer := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(body))
+		}))
+	}
+	stableServer := newTestServer("stable")
+	defer stableServer.Close()
+	canaryServer := newTestServer("canary")
+	defer canaryServer.Close()
+	canaryBackendServer := newTestServer("canary backend")
+	defer canaryBackendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend-server", buildFrontend(
+				withRoute("route", "Host:server.example.com"),
+				withFrontendBackend("mixed"),
+				withHeaderOverride(&types.HeaderOverride{Header: "X-Canary", Value: "true", Server: "canary"}))),
+			withFrontend("frontend-backend", buildFrontend(
+				withRoute("route", "Host:backend.example.com"),
+				withHeaderOverride(&types.HeaderOverride{Header: "X-Canary", Backend: "canary"}))),
+			withFrontend("frontend-undefined-server", buildFrontend(
+				withRoute("route", "Host:undefined.example.com"),
+				withHeaderOverride(&types.HeaderOverride{Header: "X-Canary", Server: "undefined"}))),
+			withBackend("backend", buildBackend(withServer("stable", stableServer.URL))),
+			withBackend("mixed", buildBackend(withServer("stable", stableServer.URL), withServer("canary", canaryServer.URL))),
+			withBackend("canary", buildBackend(withServer("server", canaryBackendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc         string
+		url          string
+		header       string
+		expectedBody string
+	}{
+		{
+			desc:         "named server",
+			url:          "http://server.example.com/",
+			header:       "true",
+			expectedBody: "canary",
+		},
+		{
+			desc:         "canary backend",
+			url:          "http://backend.example.com/",
+			header:       "yes",
+			expectedBody: "canary backend",
+		},
+		{
+			desc:         "no header",
+			url:          "http://backend.example.com/",
+			expectedBody: "stable",
+		},
+	}
+
+	for _, test := range testCases {
+		// several requests are sent, the load-balancer alternating between the servers otherwise
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+			if len(test.header) > 0 {
+				req.Header.Set("X-Canary", test.header)
+			}
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+			assert.Equal(t, http.StatusOK, recorder.Code, test.desc)
+			assert.Equal(t, test.expectedBody, recorder.Body.String(), test.desc)
+		}
+	}
+
+	// the requests not matching the header value are balanced
+	bodies := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://server.example.com/", nil)
+		req.Header.Set("X-Canary", "false")
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+		bodies[recorder.Body.String()] = true
+	}
+	assert.Equal(t, map[string]bool{"stable": true, "canary": true}, bodies)
+
+	// the frontend with an undefined server is skipped
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://undefined.example.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServerFrontendMiddlewaresSharedBackend(t *testing.T) {
+	testCases := []struct {
+		desc string
+		// the backend has no server without a handler
+		handler http.HandlerFunc
+		// the middleware is configured on the second frontend only, both of them sharing the backend
+		middleware       func(*types.Frontend)
+		assertPlain      func(t *testing.T, recorder *httptest.ResponseRecorder)
+		assertMiddleware func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			desc: "location rewrite",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Location", "http://backend.internal/next")
+				rw.WriteHeader(http.StatusFound)
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.LocationRewrite = &types.LocationRewrite{BackendHost: "backend.internal"}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "http://backend.internal/next", recorder.Header().Get("Location"))
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "http://middleware.example.com/next", recorder.Header().Get("Location"))
+			},
+		},
+		{
+			desc: "status mapping",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusInternalServerError)
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.StatusMapping = &types.StatusMapping{Codes: map[string]int{"500": http.StatusServiceUnavailable}}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			desc: "no server response",
+			middleware: func(fe *types.Frontend) {
+				fe.NoServer = &types.NoServerResponse{StatusCode: http.StatusTeapot, Body: "no server"}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusTeapot, recorder.Code)
+				assert.Equal(t, "no server", recorder.Body.String())
+			},
+		},
+		{
+			desc: "custom host",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte(req.Host))
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.CustomHost = "custom.example.com"
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "plain.example.com", recorder.Body.String())
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "custom.example.com", recorder.Body.String())
+			},
+		},
+		{
+			desc: "required headers",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte("backend"))
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.RequiredHeaders = map[string]*types.RequiredHeader{"X-Api-Version": {Values: []string{"1"}}}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "backend", recorder.Body.String())
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			desc: "forwarding timeouts",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				select {
+				case <-time.After(200 * time.Millisecond):
+					rw.WriteHeader(http.StatusOK)
+				case <-req.Context().Done():
+				}
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.ForwardingTimeouts = &types.ForwardingTimeouts{ResponseHeaderTimeout: "50ms"}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+			},
+		},
+		{
+			desc: "response headers",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte("backend"))
+			},
+			middleware: func(fe *types.Frontend) {
+				fe.Headers = &types.Headers{CustomResponseHeaders: map[string]string{"X-Frontend": "middleware"}}
+			},
+			assertPlain: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Empty(t, recorder.Header().Get("X-Frontend"))
+			},
+			assertMiddleware: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "middleware", recorder.Header().Get("X-Frontend"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			// the frontends share the circuit breaker of the backend
+			backend := buildBackend(withCircuitBreaker("NetworkErrorRatio() > 0.5"))
+			if test.handler != nil {
+				backendServer := httptest.NewServer(test.handler)
+				defer backendServer.Close()
+				withServer("server", backendServer.URL)(backend)
+			}
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend1", buildFrontend(withRoute("route", "Host:plain.example.com"), withPassHostHeader(true))),
+					withFrontend("frontend2", buildFrontend(withRoute("route", "Host:middleware.example.com"), withPassHostHeader(true), test.middleware)),
+					withBackend("backend", backend),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+			assert.Len(t, entryPoints["http"].circuitBreakers, 1)
+
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://plain.example.com/", nil))
+			test.assertPlain(t, recorder)
+
+			recorder = httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://middleware.example.com/", nil))
+			test.assertMiddleware(t, recorder)
+		})
+	}
+}
+
+func TestServerFrontendsSharingBackend(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		servers        int
+		loadBalancer   func(*types.Backend)
+		passHostHeader bool
+		// the requests are sent alternately to both frontends, with the cookies of the previous responses
+		requests       int
+		expectedCode   int
+		expectedBodies func(t *testing.T, bodies []string)
+		expectedHosts  func(t *testing.T, hosts []string, serverHost string)
+	}{
+		{
+			desc:           "load balancer shared between the frontends",
+			servers:        2,
+			loadBalancer:   withLoadBalancer("wrr", false),
+			passHostHeader: true,
+			requests:       4,
+			expectedCode:   http.StatusOK,
+			expectedBodies: func(t *testing.T, bodies []string) {
+				assert.Equal(t, []string{bodies[0], bodies[1], bodies[0], bodies[1]}, bodies)
+				assert.NotEqual(t, bodies[0], bodies[1])
+			},
+		},
+		{
+			desc:           "sticky session shared between the frontends",
+			servers:        2,
+			loadBalancer:   withLoadBalancer("wrr", true),
+			passHostHeader: true,
+			requests:       4,
+			expectedCode:   http.StatusOK,
+			expectedBodies: func(t *testing.T, bodies []string) {
+				assert.Equal(t, []string{bodies[0], bodies[0], bodies[0], bodies[0]}, bodies)
+			},
+		},
+		{
+			desc:           "host header passed by both frontends",
+			servers:        1,
+			passHostHeader: true,
+			requests:       2,
+			expectedCode:   http.StatusOK,
+			expectedHosts: func(t *testing.T, hosts []string, serverHost string) {
+				assert.Equal(t, []string{"frontend1.example.com", "frontend2.example.com"}, hosts)
+			},
+		},
+		{
+			desc:           "host header passed by neither frontend",
+			servers:        1,
+			passHostHeader: false,
+			requests:       2,
+			expectedCode:   http.StatusOK,
+			expectedHosts: func(t *testing.T, hosts []string, serverHost string) {
+				assert.Equal(t, []string{serverHost, serverHost}, hosts)
+			},
+		},
+		{
+			desc:           "no server",
+			servers:        0,
+			passHostHeader: true,
+			requests:       2,
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedBodies: func(t *testing.T, bodies []string) {
+				assert.Equal(t, []string{"Service Unavailable", "Service Unavailable"}, bodies)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			backend := buildBackend()
+			if test.loadBalancer != nil {
+				test.loadBalancer(backend)
+			}
+			var serverHost string
+			for i := 0; i < test.servers; i++ {
+				name := fmt.Sprintf("server%d", i)
+				backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					rw.Header().Set("X-Host", req.Host)
+					rw.Write([]byte(name))
+				}))
+				defer backendServer.Close()
+				withServer(name, backendServer.URL)(backend)
+				serverHost = backendServer.Listener.Addr().String()
+			}
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend1", buildFrontend(withRoute("route", "Host:frontend1.example.com"), withPassHostHeader(test.passHostHeader))),
+					withFrontend("frontend2", buildFrontend(withRoute("route", "Host:frontend2.example.com"), withPassHostHeader(test.passHostHeader))),
+					withBackend("backend", backend),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			var bodies, hosts []string
+			var cookies []*http.Cookie
+			for i := 0; i < test.requests; i++ {
+				req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://frontend%d.example.com/", i%2+1), nil)
+				for _, cookie := range cookies {
+					req.AddCookie(cookie)
+				}
+				recorder := httptest.NewRecorder()
+				entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+
+				assert.Equal(t, test.expectedCode, recorder.Code)
+				bodies = append(bodies, recorder.Body.String())
+				hosts = append(hosts, recorder.Header().Get("X-Host"))
+				if responseCookies := recorder.Result().Cookies(); len(responseCookies) > 0 {
+					cookies = responseCookies
+				}
+			}
+
+			if test.expectedBodies != nil {
+				test.expectedBodies(t, bodies)
+			}
+			if test.expectedHosts != nil {
+				test.expectedHosts(t, hosts, serverHost)
+			}
+		})
+	}
+}
+
+func TestServerFormJSON(t *testing.T) {
+	// the backend only accepts JSON objects, and answers with the object received
+	jsonServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		var object map[string]interface{}
+		if err != nil || req.Header.Get("Content-Type") != "application/json" || int64(len(body)) != req.ContentLength || json.Unmarshal(body, &object) != nil {
+			rw.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(body)
+	}))
+	defer jsonServer.Close()
+
+	testCases := []struct {
+		desc                string
+		formJSON            *types.FormJSON
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			desc:                "JSON response",
+			formJSON:            &types.FormJSON{},
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"traefik","tags":["proxy","go"]}`,
+		},
+		{
+			desc:                "response converted back",
+			formJSON:            &types.FormJSON{Responses: true},
+			expectedContentType: "application/x-www-form-urlencoded",
+			expectedBody:        "name=traefik&tags=proxy&tags=go",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(
+						withRoute("route", "Path:/form"),
+						withFormJSON(test.formJSON),
+					)),
+					withBackend("backend", buildBackend(withServer("server", jsonServer.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			frontendServer := httptest.NewServer(entryPoints["http"].httpRouter)
+			defer frontendServer.Close()
+
+			resp, err := http.PostForm(frontendServer.URL+"/form", url.Values{"name": {"traefik"}, "tags": {"proxy", "go"}})
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, test.expectedContentType, resp.Header.Get("Content-Type"))
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedBody, string(body))
+		})
+	}
+}
+
+func buildDynamicConfig(dynamicConfigBuilders ...func(*types.Configuration)) *types.Configuration {
+	config := &types.Configuration{
+		Frontends: make(map[string]*types.Frontend),
+		Backends:  make(map[string]*types.Backend),
+	}
+	for _, build := range dynamicConfigBuilders {
+		build(config)
+	}
+	return config
+}
+
+func withFrontend(frontendName string, frontend *types.Frontend) func(*types.Configuration) {
+	return func(config *types.Configuration) {
+		config.Frontends[frontendName] = frontend
+	}
+}
+
+func withBackend(backendName string, backend *types.Backend) func(*types.Configuration) {
+	return func(config *types.Configuration) {
+		config.Backends[backendName] = backend
+	}
+}
+
+func buildFrontend(frontendBuilders ...func(*types.Frontend)) *types.Frontend {
+	fe := &types.Frontend{
+		EntryPoints: []string{"http"},
+		Backend:     "backend",
+		Routes:      make(map[string]types.Route),
+	}
+	for _, build := range frontendBuilders {
+		build(fe)
+	}
+	return fe
+}
+
+func withRoute(routeName, rule string) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Routes[routeName] = types.Route{Rule: rule}
+	}
+}
+
+func withFrontendBackend(backendName string) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Backend = backendName
+	}
+}
+
+func withFrontendHeaders(headers *types.Headers) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Headers = headers
+	}
+}
+
+func withSkipDefaultMiddlewares(skip bool) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.SkipDefaultMiddlewares = skip
+	}
+}
+
+func withMirroring(mirroring *types.Mirroring) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Mirroring = mirroring
+	}
+}
+
+func withHeaderOverride(headerOverride *types.HeaderOverride) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.HeaderOverride = headerOverride
+	}
+}
+
+func withStatusMapping(statusMapping *types.StatusMapping) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.StatusMapping = statusMapping
+	}
+}
+
+func withErrorPage(name string, errorPage *types.ErrorPage) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		if fe.Errors == nil {
+			fe.Errors = make(map[string]*types.ErrorPage)
+		}
+		fe.Errors[name] = errorPage
+	}
+}
+
+func withAccessLog(accessLog *types.FrontendAccessLog) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.AccessLog = accessLog
+	}
+}
+
+func withStaticResponse(staticResponse *types.StaticResponse) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.StaticResponse = staticResponse
+	}
+}
+
+func withNoServer(noServer *types.NoServerResponse) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.NoServer = noServer
+	}
+}
+
+func withPriority(priority int) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Priority = priority
+	}
+}
+
+func withPassHostHeader(passHostHeader bool) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.PassHostHeader = passHostHeader
+	}
+}
+
+func withCustomHost(customHost string) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.CustomHost = customHost
+	}
+}
+
+func withGRPCWeb(grpcWeb *types.GRPCWeb) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.GRPCWeb = grpcWeb
+	}
+}
+
+func withRequiredHeaders(requiredHeaders map[string]*types.RequiredHeader) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.RequiredHeaders = requiredHeaders
+	}
+}
+
+func withWhitelistSourceRange(whitelistSourceRange ...string) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.WhitelistSourceRange = whitelistSourceRange
+	}
+}
+
+func withSingleFlight(singleFlight *types.SingleFlight) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.SingleFlight = singleFlight
+	}
+}
+
+func withCache(cache *types.Cache) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Cache = cache
+	}
+}
+
+func withCORS(cors *types.CORS) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.CORS = cors
+	}
+}
+
+func withJWTAuth(jwtAuth *types.JWTAuth) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.JWTAuth = jwtAuth
+	}
+}
+
+func withWeightedBackends(weightedBackends ...*types.WeightedBackend) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Backend = ""
+		fe.WeightedBackends = weightedBackends
+	}
+}
+
+func withFrontendBuffering(buffering *types.Buffering) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.Buffering = buffering
+	}
+}
+
+func withForwardingTimeouts(forwardingTimeouts *types.ForwardingTimeouts) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.ForwardingTimeouts = forwardingTimeouts
+	}
+}
+
+func withFormJSON(formJSON *types.FormJSON) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.FormJSON = formJSON
+	}
+}
+
+func buildBackend(backendBuilders ...func(*types.Backend)) *types.Backend {
+	be := &types.Backend{
+		Servers:      make(map[string]types.Server),
+		LoadBalancer: &types.LoadBalancer{Method: "Wrr"},
+	}
+	for _, build := range backendBuilders {
+		build(be)
+	}
+	return be
+}
+
+func withServer(name, url string) func(backend *types.Backend) {
+	return func(be *types.Backend) {
+		be.Servers[name] = types.Server{URL: url}
+	}
+}
+
+func withWeightedServer(name, url string, weight int) func(backend *types.Backend) {
+	return func(be *types.Backend) {
+		be.Servers[name] = types.Server{URL: url, Weight: weight}
+	}
+}
+
+func withPercentWeights(percentWeights bool) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.PercentWeights = percentWeights
+	}
+}
+
+func withHealthCheck(healthCheck *types.HealthCheck) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.HealthCheck = healthCheck
+	}
+}
+
+func withRetryBudget(retryBudget *types.RetryBudget) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.RetryBudget = retryBudget
+	}
+}
+
+func withLanguageBackends(languageBackends map[string]string) func(*types.Frontend) {
+	return func(fe *types.Frontend) {
+		fe.LanguageBackends = languageBackends
+	}
+}
+
+func withLoadBalancer(method string, sticky bool) func(*types.Backend) {
+	return func(be *types.Backend) {
+		if sticky {
+			be.LoadBalancer = &types.LoadBalancer{Method: method, Stickiness: &types.Stickiness{CookieName: "test"}}
+		} else {
+			be.LoadBalancer = &types.LoadBalancer{Method: method}
+		}
+	}
+}
+
+func withCircuitBreaker(expression string) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.CircuitBreaker = &types.CircuitBreaker{Expression: expression}
+	}
+}
+
+func withHostHeader(hostHeader string) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.HostHeader = hostHeader
+	}
+}
+
+func withDraining(draining bool) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.Draining = draining
+	}
+}
+
+func withSourceAddress(sourceAddress string) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.SourceAddress = sourceAddress
+	}
+}
+
+func withH2C(h2c bool) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.H2C = h2c
+	}
+}
+
+func withForceHTTP1(forceHTTP1 bool) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.ForceHTTP1 = forceHTTP1
+	}
+}
+
+func withBackendTLS(ca, cert, key []byte) func(*types.Backend) {
+	return func(be *types.Backend) {
+		be.TLS = &types.BackendTLS{
+			CA:   tls.FileOrContent(ca),
+			Cert: tls.FileOrContent(cert),
+			Key:  tls.FileOrContent(key),
+		}
+	}
+}
+
+func TestServerWeightedBackends(t *testing.T) {
+	newBackendServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(rw, name)
+		}))
+	}
+	blueServer := newBackendServer("blue")
+	defer blueServer.Close()
+	greenServer := newBackendServer("green")
+	defer greenServer.Close()
+
+	testCases := []struct {
+		desc             string
+		frontend         *types.Frontend
+		blueDraining     bool
+		expectedStatus   int
+		expectedRequests map[string]int
+	}{
+		{
+			desc: "weighted backends",
+			frontend: buildFrontend(withRoute("route", "Path:/"), withWeightedBackends(
+				&types.WeightedBackend{Backend: "blue", Weight: 3},
+				&types.WeightedBackend{Backend: "green", Weight: 1},
+			)),
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"blue": 6, "green": 2},
+		},
+		{
+			desc: "backend without server",
+			frontend: buildFrontend(withRoute("route", "Path:/"), withWeightedBackends(
+				&types.WeightedBackend{Backend: "blue", Weight: 3},
+				&types.WeightedBackend{Backend: "green", Weight: 1},
+			)),
+			blueDraining:     true,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"green": 8},
+		},
+		{
+			desc: "switched backend",
+			frontend: buildFrontend(withRoute("route", "Path:/"), withWeightedBackends(
+				&types.WeightedBackend{Backend: "blue", Weight: 0},
+				&types.WeightedBackend{Backend: "green", Weight: 1},
+			)),
+			expectedStatus:   http.StatusOK,
+			expectedRequests: map[string]int{"green": 8},
+		},
+		{
+			desc: "both a backend and weighted backends",
+			frontend: buildFrontend(withRoute("route", "Path:/"), withWeightedBackends(
+				&types.WeightedBackend{Backend: "blue", Weight: 1},
+			), withFrontendBackend("green")),
+			expectedStatus:   http.StatusNotFound,
+			expectedRequests: map[string]int{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", test.frontend),
+					withBackend("blue", buildBackend(withServer("server", blueServer.URL), withDraining(test.blueDraining))),
+					withBackend("green", buildBackend(withServer("server", greenServer.URL))),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			requests := make(map[string]int)
+			for i := 0; i < 8; i++ {
+				recorder := httptest.NewRecorder()
+				entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://frontend.example.org/", nil))
+				require.Equal(t, test.expectedStatus, recorder.Code)
+				if recorder.Code == http.StatusOK {
+					requests[recorder.Body.String()]++
+				}
+			}
+			assert.Equal(t, test.expectedRequests, requests)
+		})
+	}
+}
+
+func TestServerLanguageBackends(t *testing.T) {
+	newBackendServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(rw, name)
+		}))
+	}
+	englishServer := newBackendServer("english")
+	defer englishServer.Close()
+	frenchServer := newBackendServer("french")
+	defer frenchServer.Close()
+	germanServer := newBackendServer("german")
+	defer germanServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(
+				withRoute("route", "Host:frontend.example.com"),
+				withFrontendBackend("english"),
+				withLanguageBackends(map[string]string{"en": "english", "fr": "french", "fr-CA": "french", "de": "german"}),
+			)),
+			withFrontend("invalid", buildFrontend(
+				withRoute("route", "Host:invalid.example.com"),
+				withFrontendBackend("english"),
+				withLanguageBackends(map[string]string{"fr": "french", "de": "unknown"}),
+			)),
+			withBackend("english", buildBackend(withServer("server", englishServer.URL))),
+			withBackend("french", buildBackend(withServer("server", frenchServer.URL))),
+			withBackend("german", buildBackend(withServer("server", germanServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			desc:     "default backend",
+			expected: "english",
+		},
+		{
+			desc:           "best match",
+			acceptLanguage: "fr-CH, de;q=0.9",
+			expected:       "french",
+		},
+		{
+			desc:           "quality values",
+			acceptLanguage: "fr;q=0.5, de;q=0.8, *;q=0.1",
+			expected:       "german",
+		},
+		{
+			desc:           "unsupported language",
+			acceptLanguage: "ja",
+			expected:       "english",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://frontend.example.com/", nil)
+			if len(test.acceptLanguage) > 0 {
+				req.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expected, recorder.Body.String())
+			assert.Equal(t, "Accept-Language", recorder.Header().Get("Vary"))
+		})
+	}
+
+	// the frontend of a locale with an undefined backend is skipped
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://invalid.example.com/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestServerJWTAuth(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(req.Header.Get("X-User")))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("frontend", buildFrontend(
+				withRoute("route", "Host:jwt.example.com"),
+				withJWTAuth(&types.JWTAuth{Secret: "secret", ForwardClaims: map[string]string{"sub": "X-User"}}),
+			)),
+			withFrontend("invalid", buildFrontend(
+				withRoute("route", "Host:invalid.example.com"),
+				withJWTAuth(&types.JWTAuth{Issuer: "issuer"}),
+			)),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		host           string
+		authorization  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "valid token",
+			host:           "jwt.example.com",
+			authorization:  "Bearer " + token,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "user1",
+		},
+		{
+			desc:           "no token",
+			host:           "jwt.example.com",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Unauthorized\n",
+		},
+		{
+			desc:           "skipped frontend",
+			host:           "invalid.example.com",
+			authorization:  "Bearer " + token,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "404 page not found\n",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://"+test.host+"/", nil)
+			if len(test.authorization) > 0 {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	var calls int32
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("cached", buildFrontend(withRoute("route", "Host:cached.example.com"), withCache(&types.Cache{}))),
+			withFrontend("uncached", buildFrontend(withRoute("route", "Host:uncached.example.com"))),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	for _, expected := range []string{"MISS", "HIT"} {
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://cached.example.com/", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "backend", recorder.Body.String())
+		assert.Equal(t, expected, recorder.Header().Get("X-Cache"))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the cache of a frontend is not shared with the other frontends of its backend
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://uncached.example.com/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("X-Cache"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestServerCacheAccessControl(t *testing.T) {
+	var calls int32
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("cached", buildFrontend(withRoute("route", "Host:cached.example.com"), withCache(&types.Cache{}),
+				withWhitelistSourceRange("10.0.0.1/32"))),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "http://cached.example.com/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+
+	// the response is cached, but the client is still rejected by the whitelist
+	request = httptest.NewRequest(http.MethodGet, "http://cached.example.com/", nil)
+	request.RemoteAddr = "192.168.1.1:1234"
+	recorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("X-Cache"))
+	assert.NotEqual(t, "backend", recorder.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestServerSingleFlightAccessControl(t *testing.T) {
+	var calls int32
+	received := make(chan struct{})
+	release := make(chan struct{})
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		close(received)
+		<-release
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("coalesced", buildFrontend(withRoute("route", "Host:coalesced.example.com"), withSingleFlight(&types.SingleFlight{}),
+				withWhitelistSourceRange("10.0.0.1/32"))),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	permitted := make(chan *httptest.ResponseRecorder)
+	go func() {
+		request := httptest.NewRequest(http.MethodGet, "http://coalesced.example.com/", nil)
+		request.RemoteAddr = "10.0.0.1:1234"
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+		permitted <- recorder
+	}()
+	<-received
+
+	// the identical request of a client rejected by the whitelist doesn't join the one in flight
+	denied := make(chan *httptest.ResponseRecorder)
+	go func() {
+		request := httptest.NewRequest(http.MethodGet, "http://coalesced.example.com/", nil)
+		request.RemoteAddr = "192.168.1.1:1234"
+		recorder := httptest.NewRecorder()
+		entryPoints["http"].httpRouter.ServeHTTP(recorder, request)
+		denied <- recorder
+	}()
+
+	select {
+	case recorder := <-denied:
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.NotEqual(t, "backend", recorder.Body.String())
+	case <-time.After(time.Second):
+		t.Error("the request of the denied client should not wait for the one in flight")
+	}
+	close(release)
+
+	recorder := <-permitted
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "backend", recorder.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestServerCORS(t *testing.T) {
+	var calls int32
+	backendServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+		rw.Write([]byte("backend"))
+	}))
+	defer backendServer.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+	cors := &types.CORS{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedHeaders:   []string{"Authorization"},
+		AllowCredentials: true,
+	}
+	dynamicConfigs := types.Configurations{
+		"config": buildDynamicConfig(
+			withFrontend("cors", buildFrontend(
+				withRoute("route", "Host:cors.example.com"),
+				withCORS(cors),
+				withRequiredHeaders(map[string]*types.RequiredHeader{"X-Tenant": nil}),
+			)),
+			withFrontend("static", buildFrontend(
+				withRoute("route", "Host:static.example.com"),
+				withCORS(cors),
+				withStaticResponse(&types.StaticResponse{StatusCode: http.StatusOK, Body: "static"}),
+			)),
+			withBackend("backend", buildBackend(withServer("server", backendServer.URL))),
+		),
+	}
+
+	srv := NewServer(globalConfig, nil)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	// the preflight request is answered before being rejected for its missing header
+	req := httptest.NewRequest(http.MethodOptions, "http://cors.example.com/", nil)
+	req.Header.Set("Origin", "https://App.Example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "authorization")
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://App.Example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "authorization", recorder.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	// the CORS headers of the backend are replaced
+	req = httptest.NewRequest(http.MethodGet, "http://cors.example.com/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("X-Tenant", "tenant")
+	recorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{"https://app.example.com"}, recorder.Header()["Access-Control-Allow-Origin"])
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	req = httptest.NewRequest(http.MethodGet, "http://static.example.com/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	recorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "static", recorder.Body.String())
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestServerForceHTTP1(t *testing.T) {
+	// the backend supports both HTTP/2 and HTTP/1.1
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, req.Proto)
+	}))
+	require.NoError(t, http2.ConfigureServer(backendServer.Config, nil))
+	backendServer.TLS = &cryptotls.Config{NextProtos: []string{http2.NextProtoTLS, "http/1.1"}}
+	backendServer.StartTLS()
+	defer backendServer.Close()
+
+	testCases := []struct {
+		desc           string
+		backend        *types.Backend
+		expectedStatus int
+		expectedProto  string
+	}{
+		{
+			desc:           "auto-negotiation",
+			backend:        buildBackend(withServer("server", backendServer.URL)),
+			expectedStatus: http.StatusOK,
+			expectedProto:  "HTTP/2.0",
+		},
+		{
+			desc:           "HTTP/1.1 only",
+			backend:        buildBackend(withServer("server", backendServer.URL), withForceHTTP1(true)),
+			expectedStatus: http.StatusOK,
+			expectedProto:  "HTTP/1.1",
+		},
+		{
+			desc:           "HTTP/1.1 only with h2c",
+			backend:        buildBackend(withServer("server", backendServer.URL), withForceHTTP1(true), withH2C(true)),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+				InsecureSkipVerify: true,
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", test.backend),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://frontend.example.org/", nil))
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, test.expectedProto, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerBackendTLS(t *testing.T) {
+	serverCert, serverKey, err := generate.KeyPair("localhost", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	clientCert, clientKey, err := generate.KeyPair("client.example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	certificate, err := cryptotls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(clientCert))
+
+	// the backend requires a client certificate signed by its own CA
+	backendServer := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	backendServer.TLS = &cryptotls.Config{
+		Certificates: []cryptotls.Certificate{certificate},
+		ClientAuth:   cryptotls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	backendServer.StartTLS()
+	defer backendServer.Close()
+
+	_, port, err := net.SplitHostPort(backendServer.Listener.Addr().String())
+	require.NoError(t, err)
+	backendURL := "https://localhost:" + port
+
+	testCases := []struct {
+		desc           string
+		backendTLS     func(*types.Backend)
+		expectedStatus int
+	}{
+		{
+			desc:           "backend CA and client certificate",
+			backendTLS:     withBackendTLS(serverCert, clientCert, clientKey),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "backend CA without client certificate",
+			backendTLS:     withBackendTLS(serverCert, nil, nil),
+			expectedStatus: http.StatusBadGateway,
+		},
+		{
+			desc:           "no backend TLS",
+			backendTLS:     func(*types.Backend) {},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			dynamicConfigs := types.Configurations{
+				"config": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Path:/"))),
+					withBackend("backend", buildBackend(
+						withServer("server", backendURL),
+						test.backendTLS,
+					)),
+				),
+			}
+
+			srv := NewServer(globalConfig, nil)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://frontend.example.org/", nil))
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestServerRSAAndECDSACertificates(t *testing.T) {
+	rsaCert, rsaKey, err := generate.KeyPair("dual.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	ecdsaCert, ecdsaKey, err := generate.ECDSAKeyPair("dual.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"https": &configuration.EntryPoint{TLS: &tls.TLS{
+				Certificates: tls.Certificates{
+					{CertFile: tls.FileOrContent(rsaCert), KeyFile: tls.FileOrContent(rsaKey)},
+					{CertFile: tls.FileOrContent(ecdsaCert), KeyFile: tls.FileOrContent(ecdsaKey)},
+				},
+			}},
+		},
+	}
+
+	srv := NewServer(globalConfig, nil)
+	srv.serverEntryPoints = srv.buildEntryPoints(globalConfig)
+
+	config, err := srv.createTLSConfig("https", globalConfig.EntryPoints["https"].TLS, nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                 string
+		clientConfig         *cryptotls.Config
+		expectedKeyAlgorithm x509.PublicKeyAlgorithm
+	}{
+		{
+			desc:                 "client supporting ECDSA",
+			clientConfig:         &cryptotls.Config{},
+			expectedKeyAlgorithm: x509.ECDSA,
+		},
+		{
+			desc: "client supporting RSA only",
+			clientConfig: &cryptotls.Config{
+				MaxVersion:   cryptotls.VersionTLS12,
+				CipherSuites: []uint16{cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			},
+			expectedKeyAlgorithm: x509.RSA,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+
+			go func() {
+				tlsServer := cryptotls.Server(serverConn, config)
+				tlsServer.Handshake()
+				tlsServer.Close()
+			}()
+
+			clientConfig := test.clientConfig
+			clientConfig.ServerName = "dual.example.com"
+			clientConfig.InsecureSkipVerify = true
+			tlsClient := cryptotls.Client(clientConn, clientConfig)
+			require.NoError(t, tlsClient.Handshake())
+
+			peerCertificates := tlsClient.ConnectionState().PeerCertificates
+			require.NotEmpty(t, peerCertificates)
+			assert.Equal(t, test.expectedKeyAlgorithm, peerCertificates[0].PublicKeyAlgorithm)
+			assert.Equal(t, []string{"dual.example.com"}, peerCertificates[0].DNSNames)
+		})
 	}
 }
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+)
+
+const tlsHandshakeErrorPrefix = "http: TLS handshake error from "
+
+// handshakeLogger logs the failed TLS handshakes of an entrypoint, with the ClientHello sent by the clients.
+// It records the ClientHello of the connections of its listener until they are closed,
+// and is the error log of the http.Server, which reports the handshake failures before closing the connections.
+type handshakeLogger struct {
+	entryPointName string
+	lock           sync.Mutex
+	clientHellos   map[string]*tls.ClientHelloInfo
+	debugf         func(format string, args ...interface{})
+}
+
+func newHandshakeLogger(entryPointName string) *handshakeLogger {
+	return &handshakeLogger{
+		entryPointName: entryPointName,
+		clientHellos:   make(map[string]*tls.ClientHelloInfo),
+		debugf:         log.Debugf,
+	}
+}
+
+// watch records the ClientHello of the handshakes using tlsConfig, before calling its GetConfigForClient callback.
+func (h *handshakeLogger) watch(tlsConfig *tls.Config) {
+	getConfigForClient := tlsConfig.GetConfigForClient
+	tlsConfig.GetConfigForClient = func(clientHello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if clientHello.Conn != nil {
+			h.lock.Lock()
+			h.clientHellos[clientHello.Conn.RemoteAddr().String()] = clientHello
+			h.lock.Unlock()
+		}
+		if getConfigForClient == nil {
+			return nil, nil
+		}
+		return getConfigForClient(clientHello)
+	}
+}
+
+// listener forgets the ClientHello of the connections accepted by listener once they are closed.
+func (h *handshakeLogger) listener(listener net.Listener) net.Listener {
+	return &handshakeLoggerListener{Listener: listener, logger: h}
+}
+
+func (h *handshakeLogger) forget(remoteAddr string) {
+	h.lock.Lock()
+	delete(h.clientHellos, remoteAddr)
+	h.lock.Unlock()
+}
+
+// Write logs the handshake errors reported by the http.Server with their ClientHello,
+// and the other errors as the default http.Server logger.
+func (h *handshakeLogger) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	if !strings.HasPrefix(line, tlsHandshakeErrorPrefix) {
+		httpServerLogger.Print(line)
+		return len(p), nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(line, tlsHandshakeErrorPrefix), ": ", 2)
+	if len(parts) != 2 {
+		httpServerLogger.Print(line)
+		return len(p), nil
+	}
+	remoteAddr, reason := parts[0], parts[1]
+
+	h.lock.Lock()
+	clientHello := h.clientHellos[remoteAddr]
+	h.lock.Unlock()
+
+	if clientHello == nil {
+		h.debugf("TLS handshake failed on entrypoint %s from %s before its ClientHello: %s", h.entryPointName, remoteAddr, reason)
+		return len(p), nil
+	}
+
+	var cipherSuites []string
+	for _, cipherSuite := range clientHello.CipherSuites {
+		cipherSuites = append(cipherSuites, traefikTls.GetCipherSuiteName(cipherSuite))
+	}
+	h.debugf("TLS handshake failed on entrypoint %s from %s with SNI %q, offered cipher suites [%s]: %s",
+		h.entryPointName, remoteAddr, clientHello.ServerName, strings.Join(cipherSuites, ", "), reason)
+	return len(p), nil
+}
+
+type handshakeLoggerListener struct {
+	net.Listener
+	logger *handshakeLogger
+}
+
+func (l *handshakeLoggerListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &handshakeLoggerConn{Conn: conn, logger: l.logger}, nil
+}
+
+type handshakeLoggerConn struct {
+	net.Conn
+	logger *handshakeLogger
+}
+
+func (c *handshakeLoggerConn) Close() error {
+	c.logger.forget(c.RemoteAddr().String())
+	return c.Conn.Close()
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	stdlog "log"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/tls/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakeLogger(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		serverConfig     func(config *tls.Config)
+		handshake        func(addr string) error
+		expectedContains []string
+	}{
+		{
+			desc: "missing client certificate",
+			serverConfig: func(config *tls.Config) {
+				config.ClientAuth = tls.RequireAnyClientCert
+			},
+			handshake: func(addr string) error {
+				conn, err := tls.Dial("tcp", addr, &tls.Config{
+					ServerName:         "foo.example.com",
+					InsecureSkipVerify: true,
+					MaxVersion:         tls.VersionTLS12,
+					CipherSuites:       []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+				})
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				// the server reports the missing certificate once the client finished its handshake
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err = conn.Read(make([]byte, 1))
+				return err
+			},
+			expectedContains: []string{
+				"entrypoint https",
+				`SNI "foo.example.com"`,
+				"offered cipher suites [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]",
+				"client didn't provide a certificate",
+			},
+		},
+		{
+			desc: "no shared cipher suite",
+			serverConfig: func(config *tls.Config) {
+				config.MaxVersion = tls.VersionTLS12
+				// HTTP/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+				config.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+			},
+			handshake: func(addr string) error {
+				conn, err := tls.Dial("tcp", addr, &tls.Config{
+					ServerName:         "bar.example.com",
+					InsecureSkipVerify: true,
+					MaxVersion:         tls.VersionTLS12,
+					CipherSuites:       []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+				})
+				if err == nil {
+					conn.Close()
+				}
+				return err
+			},
+			expectedContains: []string{
+				`SNI "bar.example.com"`,
+				"offered cipher suites [TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384]",
+				"no cipher suite supported by both client and server",
+			},
+		},
+		{
+			desc: "no ClientHello",
+			handshake: func(addr string) error {
+				conn, err := net.Dial("tcp", addr)
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				fmt.Fprint(conn, "hello world\n")
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err = conn.Read(make([]byte, 1))
+				return err
+			},
+			expectedContains: []string{
+				"before its ClientHello",
+				"does not look like a TLS handshake",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cert, err := generate.DefaultCertificate()
+			require.NoError(t, err)
+
+			tlsConfig := &tls.Config{Certificates: []tls.Certificate{*cert}}
+			if test.serverConfig != nil {
+				test.serverConfig(tlsConfig)
+			}
+
+			messages := make(chan string, 10)
+			handshakeLogger := newHandshakeLogger("https")
+			handshakeLogger.debugf = func(format string, args ...interface{}) {
+				messages <- fmt.Sprintf(format, args...)
+			}
+			handshakeLogger.watch(tlsConfig)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			httpServer := &http.Server{
+				Handler:   http.NotFoundHandler(),
+				TLSConfig: tlsConfig,
+				ErrorLog:  stdlog.New(handshakeLogger, "", 0),
+			}
+			go httpServer.ServeTLS(handshakeLogger.listener(listener), "", "")
+			defer httpServer.Close()
+
+			assert.Error(t, test.handshake(listener.Addr().String()))
+
+			select {
+			case message := <-messages:
+				for _, expected := range test.expectedContains {
+					assert.Contains(t, message, expected)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no handshake failure logged")
+			}
+		})
+	}
+}
+
+func TestHandshakeLoggerForgetsClosedConnections(t *testing.T) {
+	cert, err := generate.DefaultCertificate()
+	require.NoError(t, err)
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{*cert}}
+	handshakeLogger := newHandshakeLogger("https")
+	handshakeLogger.watch(tlsConfig)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	tlsListener := tls.NewListener(handshakeLogger.listener(listener), tlsConfig)
+
+	go func() {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := tlsListener.Accept()
+	require.NoError(t, err)
+	require.NoError(t, conn.(*tls.Conn).Handshake())
+
+	handshakeLogger.lock.Lock()
+	assert.Len(t, handshakeLogger.clientHellos, 1)
+	handshakeLogger.lock.Unlock()
+
+	require.NoError(t, conn.Close())
+
+	handshakeLogger.lock.Lock()
+	assert.Empty(t, handshakeLogger.clientHellos)
+	handshakeLogger.lock.Unlock()
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// tlsHandshakeCounter counts the TLS handshakes of the connections of an entrypoint, partitioned by whether they resumed a session.
+// It is the ConnState hook of the http.Server, which completes the handshake of a connection before reading its first request:
+// the handshake is counted when the connection becomes active for the first time.
+type tlsHandshakeCounter struct {
+	entryPointName string
+	counter        metrics.Counter
+	lock           sync.Mutex
+	counted        map[net.Conn]bool
+}
+
+func newTLSHandshakeCounter(entryPointName string, counter metrics.Counter) *tlsHandshakeCounter {
+	return &tlsHandshakeCounter{
+		entryPointName: entryPointName,
+		counter:        counter,
+		counted:        make(map[net.Conn]bool),
+	}
+}
+
+func (c *tlsHandshakeCounter) connState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateActive:
+		// the HTTP/2 connections are the *tls.Conn, or embed it
+		tlsConn, ok := conn.(interface {
+			ConnectionState() tls.ConnectionState
+		})
+		if !ok {
+			return
+		}
+
+		c.lock.Lock()
+		counted := c.counted[conn]
+		c.counted[conn] = true
+		c.lock.Unlock()
+		if counted {
+			return
+		}
+
+		connectionState := tlsConn.ConnectionState()
+		if connectionState.HandshakeComplete {
+			c.counter.With("entrypoint", c.entryPointName, "resumed", strconv.FormatBool(connectionState.DidResume)).Add(1)
+		}
+	case http.StateClosed, http.StateHijacked:
+		c.lock.Lock()
+		delete(c.counted, conn)
+		c.lock.Unlock()
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSHandshakeCounter(t *testing.T) {
+	counter := &labeledCounter{values: make(map[string]float64)}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = newTLSHandshakeCounter("https", counter).connState
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+				ClientSessionCache: tls.NewLRUClientSessionCache(1),
+			},
+		},
+	}
+	get := func() {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// the requests of a connection count its handshake once
+	get()
+	get()
+	assert.Equal(t, map[string]float64{"entrypoint,https,resumed,false": 1}, counter.get())
+
+	// the new connection resumes the session of the first one
+	client.Transport.(*http.Transport).CloseIdleConnections()
+	get()
+	assert.Equal(t, map[string]float64{"entrypoint,https,resumed,false": 1, "entrypoint,https,resumed,true": 1}, counter.get())
+}
+
+// labeledCounter is a metrics.Counter recording the value of each combination of labels.
+type labeledCounter struct {
+	lock   sync.Mutex
+	labels []string
+	values map[string]float64
+	parent *labeledCounter
+}
+
+func (c *labeledCounter) With(labelValues ...string) metrics.Counter {
+	root := c
+	if c.parent != nil {
+		root = c.parent
+	}
+	return &labeledCounter{labels: append(append([]string{}, c.labels...), labelValues...), parent: root}
+}
+
+func (c *labeledCounter) Add(delta float64) {
+	c.parent.lock.Lock()
+	defer c.parent.lock.Unlock()
+	c.parent.values[strings.Join(c.labels, ",")] += delta
+}
+
+func (c *labeledCounter) get() map[string]float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	values := make(map[string]float64)
+	for labels, value := range c.values {
+		values[labels] = value
+	}
+	return values
+}
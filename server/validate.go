@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/middlewares/accesslog"
+	mauth "github.com/containous/traefik/middlewares/auth"
+	"github.com/containous/traefik/middlewares/redirect"
+	"github.com/containous/traefik/tcp"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+)
+
+// ValidateConfiguration checks the global configuration and the dynamic configurations
+// as they are checked when loaded, without binding the entrypoints nor starting the providers.
+// The default values are set in the dynamic configurations, as when they are received from the providers.
+// It returns all the errors found, the ones of the global configuration first.
+func ValidateConfiguration(globalConfiguration configuration.GlobalConfiguration, configurations types.Configurations) []error {
+	errs := globalConfiguration.Validate()
+
+	var entryPointNames []string
+	for entryPointName := range globalConfiguration.EntryPoints {
+		entryPointNames = append(entryPointNames, entryPointName)
+	}
+	sort.Strings(entryPointNames)
+
+	for _, entryPointName := range entryPointNames {
+		entryPoint := globalConfiguration.EntryPoints[entryPointName]
+		if entryPoint.Redirect != nil {
+			if err := validateRedirect(globalConfiguration, entryPoint.Redirect); err != nil {
+				errs = append(errs, fmt.Errorf("invalid redirect for entrypoint %s: %v", entryPointName, err))
+			}
+		}
+		if entryPoint.HTTP2 != nil {
+			if err := validateHTTP2(entryPoint.HTTP2); err != nil {
+				errs = append(errs, fmt.Errorf("invalid HTTP/2 configuration for entrypoint %s: %v", entryPointName, err))
+			}
+		}
+		if entryPoint.TCP != nil {
+			if _, err := tcp.NewProxy(entryPoint.TCP, 0); err != nil {
+				errs = append(errs, fmt.Errorf("invalid TCP configuration for entrypoint %s: %v", entryPointName, err))
+			}
+			continue
+		}
+		if entryPoint.TLS != nil {
+			if err := validateEntryPointTLS(globalConfiguration, entryPointName, entryPoint.TLS); err != nil {
+				errs = append(errs, fmt.Errorf("invalid TLS configuration for entrypoint %s: %v", entryPointName, err))
+			}
+		}
+	}
+
+	for _, providerName := range sortedProviderNames(configurations) {
+		config := configurations[providerName]
+		if config.Frontends != nil {
+			configureFrontends(config.Frontends, globalConfiguration.DefaultEntryPoints)
+			configureBackends(config.Backends)
+		}
+
+		if len(config.TLS) > 0 {
+			if err := traefikTls.SortTLSPerEntryPoints(config.TLS, make(map[string]*traefikTls.DomainsCertificates), globalConfiguration.DefaultEntryPoints); err != nil {
+				errs = append(errs, fmt.Errorf("invalid TLS certificates of provider %s: %v", providerName, err))
+			}
+		}
+
+		for _, frontendName := range sortedFrontendNamesForConfig(config) {
+			for _, err := range validateFrontend(globalConfiguration, config, config.Frontends[frontendName]) {
+				errs = append(errs, fmt.Errorf("invalid frontend %s of provider %s: %v", frontendName, providerName, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateFrontend(globalConfiguration configuration.GlobalConfiguration, config *types.Configuration, frontend *types.Frontend) []error {
+	var errs []error
+
+	if len(frontend.EntryPoints) == 0 {
+		errs = append(errs, errors.New("no entrypoint defined"))
+	}
+	for _, entryPointName := range frontend.EntryPoints {
+		if entryPoint, ok := globalConfiguration.EntryPoints[entryPointName]; !ok {
+			errs = append(errs, fmt.Errorf("undefined entrypoint %q", entryPointName))
+		} else if entryPoint.TCP != nil {
+			errs = append(errs, fmt.Errorf("TCP entrypoint %q can't be used by a frontend", entryPointName))
+		}
+	}
+
+	if frontend.StaticResponse != nil {
+		if _, err := middlewares.NewStaticResponse(frontend.StaticResponse); err != nil {
+			errs = append(errs, fmt.Errorf("invalid static response: %v", err))
+		}
+	} else if len(frontend.WeightedBackends) > 0 {
+		if err := checkWeightedBackends(frontend, config.Backends); err != nil {
+			errs = append(errs, fmt.Errorf("invalid weighted backends: %v", err))
+		}
+	} else if len(frontend.LanguageBackends) > 0 {
+		if err := checkLanguageBackends(frontend, config.Backends); err != nil {
+			errs = append(errs, fmt.Errorf("invalid language backends: %v", err))
+		}
+	} else if config.Backends[frontend.Backend] == nil {
+		errs = append(errs, fmt.Errorf("undefined backend %q", frontend.Backend))
+	} else if _, err := translatePercentWeights(config.Backends[frontend.Backend]); err != nil {
+		errs = append(errs, fmt.Errorf("invalid backend %q: %v", frontend.Backend, err))
+	} else if _, err := parseHealthCheckOptions(nil, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, &configuration.HealthCheckConfig{}); err != nil {
+		errs = append(errs, fmt.Errorf("invalid health check of backend %q: %v", frontend.Backend, err))
+	} else if budget := config.Backends[frontend.Backend].RetryBudget; budget != nil && budget.Ratio != 0 {
+		if _, err := middlewares.NewRetryBudget(budget.Ratio, budget.Burst, nil); err != nil {
+			errs = append(errs, fmt.Errorf("invalid retry budget of backend %q: %v", frontend.Backend, err))
+		}
+	}
+
+	var routeNames []string
+	for routeName := range frontend.Routes {
+		routeNames = append(routeNames, routeName)
+	}
+	sort.Strings(routeNames)
+
+	for _, routeName := range routeNames {
+		route := frontend.Routes[routeName]
+		if err := getRoute(&serverRoute{route: mux.NewRouter().NewRoute()}, &route); err != nil {
+			errs = append(errs, fmt.Errorf("invalid route %s: %v", routeName, err))
+		}
+	}
+
+	if _, _, err := parseForwardingTimeouts(nil, frontend.ForwardingTimeouts); err != nil {
+		errs = append(errs, err)
+	}
+
+	if frontend.Redirect != nil {
+		if err := validateRedirect(globalConfiguration, frontend.Redirect); err != nil {
+			errs = append(errs, fmt.Errorf("invalid redirect: %v", err))
+		}
+	}
+
+	var errorPageNames []string
+	for errorPageName := range frontend.Errors {
+		errorPageNames = append(errorPageNames, errorPageName)
+	}
+	sort.Strings(errorPageNames)
+
+	for _, errorPageName := range errorPageNames {
+		if _, err := middlewares.NewErrorPagesHandler(frontend.Errors[errorPageName], ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid error page %s: %v", errorPageName, err))
+		}
+	}
+
+	if frontend.StatusMapping != nil {
+		if _, err := newStatusMappingHandler(config, frontend.StatusMapping); err != nil {
+			errs = append(errs, fmt.Errorf("invalid status mapping: %v", err))
+		}
+	}
+
+	if frontend.AccessLog != nil {
+		if _, err := accesslog.NewFilter(frontend.AccessLog); err != nil {
+			errs = append(errs, fmt.Errorf("invalid access log settings: %v", err))
+		}
+	}
+
+	if frontend.HeaderOverride != nil {
+		if _, err := buildHeaderOverrideTarget(http.NotFoundHandler(), frontend, config.Backends); err != nil {
+			errs = append(errs, fmt.Errorf("invalid header override: %v", err))
+		}
+	}
+
+	if len(frontend.RequiredHeaders) > 0 {
+		if _, err := middlewares.NewRequiredHeaders(frontend.RequiredHeaders); err != nil {
+			errs = append(errs, fmt.Errorf("invalid required headers: %v", err))
+		}
+	}
+
+	if frontend.Cache != nil {
+		if _, err := middlewares.NewCache(http.NotFoundHandler(), frontend.Cache); err != nil {
+			errs = append(errs, fmt.Errorf("invalid cache: %v", err))
+		}
+	}
+
+	if frontend.CORS != nil {
+		if _, err := middlewares.NewCORS(frontend.CORS); err != nil {
+			errs = append(errs, fmt.Errorf("invalid CORS: %v", err))
+		}
+	}
+
+	if frontend.JWTAuth != nil {
+		if _, err := mauth.NewJWTAuth(frontend.JWTAuth); err != nil {
+			errs = append(errs, fmt.Errorf("invalid JWT auth: %v", err))
+		}
+	}
+
+	if _, err := middlewares.NewNoServerHandler(frontend.NoServer); err != nil {
+		errs = append(errs, fmt.Errorf("invalid no server response: %v", err))
+	}
+
+	return errs
+}
+
+func validateRedirect(globalConfiguration configuration.GlobalConfiguration, opt *types.Redirect) error {
+	statusCode, err := redirect.StatusCode(opt.Permanent, opt.StatusCode)
+	if err != nil {
+		return err
+	}
+
+	if len(opt.EntryPoint) > 0 {
+		entryPoint := globalConfiguration.EntryPoints[opt.EntryPoint]
+		if entryPoint == nil {
+			return fmt.Errorf("unknown target entrypoint %q", opt.EntryPoint)
+		}
+		_, err := redirect.NewEntryPointHandler(entryPoint, opt.Port, statusCode)
+		return err
+	}
+
+	_, err = redirect.NewRegexHandler(opt.Regex, opt.Replacement, statusCode)
+	return err
+}
+
+// validateEntryPointTLS checks that the TLS configuration of the entrypoint can be created, with its certificates loaded.
+func validateEntryPointTLS(globalConfiguration configuration.GlobalConfiguration, entryPointName string, tlsOption *traefikTls.TLS) error {
+	config, _, err := tlsOption.Certificates.CreateTLSConfig(entryPointName)
+	if err != nil {
+		return err
+	}
+
+	clientCA := tlsOption.ClientCA
+	if len(tlsOption.ClientCAFiles) > 0 {
+		clientCA = traefikTls.ClientCA{Files: tlsOption.ClientCAFiles}
+	}
+	if len(clientCA.Files) > 0 {
+		if _, err := createClientAuth(clientCA); err != nil {
+			return err
+		}
+	}
+	if len(tlsOption.DomainsClientCAs) > 0 {
+		if _, _, err := createDomainsClientAuth(config, tlsOption.DomainsClientCAs); err != nil {
+			return err
+		}
+	}
+
+	if _, err := globalConfiguration.DefaultCertificates.GetCertificate(entryPointName); err != nil {
+		return err
+	}
+
+	for _, cipher := range tlsOption.CipherSuites {
+		if _, exists := traefikTls.CipherSuites[cipher]; !exists {
+			return fmt.Errorf("invalid CipherSuite: %s", cipher)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/tls/generate"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfiguration(t *testing.T) {
+	certPEM, keyPEM, err := generate.KeyPair("foo.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	validCertificates := traefikTls.Certificates{{
+		CertFile: traefikTls.FileOrContent(certPEM),
+		KeyFile:  traefikTls.FileOrContent(keyPEM),
+	}}
+
+	testCases := []struct {
+		desc           string
+		entryPoints    configuration.EntryPoints
+		configurations types.Configurations
+		expectedErrors []string
+	}{
+		{
+			desc: "valid configuration",
+			entryPoints: configuration.EntryPoints{
+				"http":  {Address: ":80", Redirect: &types.Redirect{EntryPoint: "https", StatusCode: http.StatusPermanentRedirect}},
+				"https": {Address: ":443", TLS: &traefikTls.TLS{Certificates: validCertificates}},
+			},
+			configurations: types.Configurations{
+				"file": buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute("route", "Host:foo.example.com;PathPrefix:/bar"))),
+					withFrontend("static", buildFrontend(withRoute("route", "Path:/health"), withFrontendBackend(""), withStaticResponse(&types.StaticResponse{Body: "ok"}))),
+					withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"))),
+				),
+			},
+		},
+		{
+			desc: "invalid entrypoints",
+			entryPoints: configuration.EntryPoints{
+				"http":  {Redirect: &types.Redirect{EntryPoint: "unknown"}},
+				"http2": {Address: ":81", Redirect: &types.Redirect{EntryPoint: "https4", StatusCode: http.StatusOK}},
+				"https": {TLS: &traefikTls.TLS{Certificates: traefikTls.Certificates{{
+					CertFile: traefikTls.FileOrContent("/nonexistent/cert.pem"),
+					KeyFile:  traefikTls.FileOrContent("/nonexistent/key.pem"),
+				}}}},
+				"https2": {TLS: &traefikTls.TLS{ClientCA: traefikTls.ClientCA{Files: []string{"/nonexistent/ca.pem"}}}},
+				"https3": {TLS: &traefikTls.TLS{Certificates: validCertificates, CipherSuites: []string{"unknown"}}},
+				"https4": {TLS: &traefikTls.TLS{Certificates: validCertificates}, HTTP2: &configuration.EntryPointHTTP2{MaxReadFrameSize: 1024}},
+				"tcp":    {TCP: &configuration.EntryPointTCP{Routes: []*configuration.TCPRoute{{ServerName: "foo.example.com"}}, PeekTimeout: flaeg.Duration(time.Second)}},
+			},
+			expectedErrors: []string{
+				`invalid redirect for entrypoint http: unknown target entrypoint "unknown"`,
+				`invalid redirect for entrypoint http2: invalid redirect status code 200`,
+				`invalid TLS configuration for entrypoint https: `,
+				`invalid TLS configuration for entrypoint https2: `,
+				`invalid TLS configuration for entrypoint https3: invalid CipherSuite: unknown`,
+				`invalid HTTP/2 configuration for entrypoint https4: invalid MaxReadFrameSize 1024: it must be between 16384 and 16777215`,
+				`invalid TCP configuration for entrypoint tcp: no server defined for server name foo.example.com`,
+			},
+		},
+		{
+			desc: "invalid frontends",
+			entryPoints: configuration.EntryPoints{
+				"http": {},
+				"tcp":  {TCP: &configuration.EntryPointTCP{Servers: []string{"127.0.0.1:443"}}},
+			},
+			configurations: types.Configurations{
+				"file": buildDynamicConfig(
+					withFrontend("frontend1", buildFrontend(withRoute("route", "Unknown:foo"))),
+					withFrontend("frontend2", buildFrontend(withRoute("route", "Path:/foo"), withFrontendBackend("unknown"))),
+					withFrontend("frontend3", buildFrontend(withRoute("route", "Path:/foo"), withForwardingTimeouts(&types.ForwardingTimeouts{RequestTimeout: "foo"}))),
+					withFrontend("frontend4", buildFrontend(withRoute("route", "Path:/foo"), withStatusMapping(&types.StatusMapping{
+						Codes:   map[string]int{"418": 503},
+						Backend: "unknown",
+					}))),
+					withFrontend("frontend5", buildFrontend(withRoute("route", "Path:/foo"), withNoServer(&types.NoServerResponse{StatusCode: 42}))),
+					withFrontend("frontend6", buildFrontend(withRoute("route", "Path:/foo"), withErrorPage("network", &types.ErrorPage{Status: []string{"500-599"}, Timeout: "foo"}))),
+					withFrontend("frontend7", buildFrontend(withRoute("route", "Path:/foo"), withAccessLog(&types.FrontendAccessLog{ExcludedStatus: []string{"200-foo"}}))),
+					withFrontend("frontend8", buildFrontend(withRoute("route", "Path:/foo"), withHeaderOverride(&types.HeaderOverride{Header: "X-Canary", Server: "canary"}))),
+					withFrontend("frontend9", buildFrontend(withRoute("route", "Path:/foo"), withHeaderOverride(&types.HeaderOverride{Server: "server"}))),
+					withFrontend("frontend10", buildFrontend(withRoute("route", "Path:/foo"), withStaticResponse(&types.StaticResponse{StatusCode: 42}))),
+					withFrontend("frontend11", buildFrontend(withRoute("route", "Path:/foo"), withRequiredHeaders(map[string]*types.RequiredHeader{"X-Tenant": {Regex: "[a-z"}}))),
+					withFrontend("frontend12", buildFrontend(withRoute("route", "Path:/foo"), withWeightedBackends(
+						&types.WeightedBackend{Backend: "backend", Weight: 3},
+						&types.WeightedBackend{Backend: "unknown", Weight: 1},
+					))),
+					withFrontend("frontend13", buildFrontend(withRoute("route", "Path:/foo"), withJWTAuth(&types.JWTAuth{JWKSURL: "/jwks.json"}))),
+					withFrontend("frontend14", buildFrontend(withRoute("route", "Path:/foo"), withCache(&types.Cache{TTL: "foo"}))),
+					withFrontend("frontend15", buildFrontend(withRoute("route", "Path:/foo"), withCORS(&types.CORS{AllowedOrigins: []string{"https://example.com"}, MaxAge: -1}))),
+					withFrontend("frontend16", buildFrontend(withRoute("route", "Path:/foo"), withFrontendBackend("percent"))),
+					withFrontend("frontend17", buildFrontend(withRoute("route", "Path:/foo"), withFrontendBackend("healthcheck"))),
+					withFrontend("frontend18", buildFrontend(withRoute("route", "Path:/foo"), withFrontendBackend("budget"))),
+					withFrontend("frontend19", buildFrontend(withRoute("route", "Path:/foo"), withLanguageBackends(map[string]string{"fr": "unknown"}))),
+					withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"))),
+					withBackend("percent", buildBackend(withServer("server", "http://127.0.0.1"), withPercentWeights(true))),
+					withBackend("healthcheck", buildBackend(withServer("server", "http://127.0.0.1"), withHealthCheck(&types.HealthCheck{Path: "/health", ExpectedBodyRegex: "[a-z"}))),
+					withBackend("budget", buildBackend(withServer("server", "http://127.0.0.1"), withRetryBudget(&types.RetryBudget{Ratio: 0.1, Burst: -1}))),
+				),
+				"other": buildDynamicConfig(
+					withFrontend("frontend", &types.Frontend{
+						EntryPoints: []string{"https", "tcp"},
+						Backend:     "backend",
+						Routes:      map[string]types.Route{"route": {Rule: "Path:/foo"}},
+					}),
+					withBackend("backend", buildBackend(withServer("server", "http://127.0.0.1"))),
+				),
+			},
+			expectedErrors: []string{
+				`invalid frontend frontend1 of provider file: invalid route route: error parsing rule: error parsing rule: 'Unknown:foo'. Unknown function: 'Unknown'`,
+				`invalid frontend frontend10 of provider file: invalid static response: invalid status code 42`,
+				`invalid frontend frontend11 of provider file: invalid required headers: invalid regex of the required header X-Tenant: `,
+				`invalid frontend frontend12 of provider file: invalid weighted backends: undefined backend "unknown"`,
+				`invalid frontend frontend13 of provider file: invalid JWT auth: invalid JWKS URL "/jwks.json"`,
+				`invalid frontend frontend14 of provider file: invalid cache: invalid TTL "foo": time: invalid duration "foo"`,
+				`invalid frontend frontend15 of provider file: invalid CORS: invalid max age -1`,
+				`invalid frontend frontend16 of provider file: invalid backend "percent": the weights of the servers sum to 0% instead of 100%`,
+				"invalid frontend frontend17 of provider file: invalid health check of backend \"healthcheck\": invalid expected body regex \"[a-z\": error parsing regexp: missing closing ]: `[a-z`",
+				`invalid frontend frontend18 of provider file: invalid retry budget of backend "budget": retry budget burst must not be negative, got -1`,
+				`invalid frontend frontend19 of provider file: invalid language backends: undefined backend "unknown"`,
+				`invalid frontend frontend2 of provider file: undefined backend "unknown"`,
+				`invalid frontend frontend3 of provider file: `,
+				`invalid frontend frontend4 of provider file: invalid status mapping: backend unknown is not set or has no error server URL`,
+				`invalid frontend frontend5 of provider file: invalid no server response: invalid status code 42`,
+				`invalid frontend frontend6 of provider file: invalid error page network: invalid timeout "foo"`,
+				`invalid frontend frontend7 of provider file: invalid access log settings: invalid excluded status "200-foo"`,
+				`invalid frontend frontend8 of provider file: invalid header override: undefined server canary in backend backend`,
+				`invalid frontend frontend9 of provider file: invalid header override: no header defined`,
+				`invalid frontend frontend of provider other: undefined entrypoint "https"`,
+				`invalid frontend frontend of provider other: TCP entrypoint "tcp" can't be used by a frontend`,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints:        test.entryPoints,
+				DefaultEntryPoints: []string{"http"},
+			}
+
+			errs := ValidateConfiguration(globalConfig, test.configurations)
+
+			require.Len(t, errs, len(test.expectedErrors), "%v", errs)
+			for i, expected := range test.expectedErrors {
+				assert.Contains(t, errs[i].Error(), expected)
+			}
+		})
+	}
+}
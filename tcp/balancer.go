@@ -0,0 +1,54 @@
+package tcp
+
+import (
+	"sync"
+)
+
+// balancer selects the server with the fewest open connections.
+type balancer struct {
+	lock    sync.Mutex
+	servers []*server
+	// index of the last selected server, used to spread the connections between servers with the same load
+	index int
+}
+
+type server struct {
+	address string
+	conns   int
+}
+
+func newBalancer(addresses []string) *balancer {
+	b := &balancer{index: -1}
+	for _, address := range addresses {
+		b.servers = append(b.servers, &server{address: address})
+	}
+	return b
+}
+
+// acquire selects the least loaded server and counts the connection to it.
+func (b *balancer) acquire() *server {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var selected *server
+	start := b.index
+	for i := 1; i <= len(b.servers); i++ {
+		index := (start + i) % len(b.servers)
+		if srv := b.servers[index]; selected == nil || srv.conns < selected.conns {
+			selected = srv
+			b.index = index
+		}
+	}
+
+	if selected != nil {
+		selected.conns++
+	}
+	return selected
+}
+
+func (b *balancer) release(srv *server) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	srv.conns--
+}
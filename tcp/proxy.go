@@ -0,0 +1,264 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/configuration"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// ErrProxyClosed is returned by Serve once the proxy is shut down or closed.
+var ErrProxyClosed = errors.New("tcp: proxy closed")
+
+// Proxy forwards the connections accepted by a TCP entry point to the servers with the fewest open connections.
+// When routes are defined, the TLS connections are forwarded to the servers of the server name sent by the client,
+// the other connections being forwarded to the default servers once the TLS ClientHello isn't received within peekTimeout.
+type Proxy struct {
+	defaultServers *balancer
+	routes         []*route
+	peekTimeout    time.Duration
+	dialTimeout    time.Duration
+
+	lock     sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+type route struct {
+	serverName string
+	servers    *balancer
+}
+
+// NewProxy creates a Proxy from the configuration of a TCP entry point.
+// The connections to the servers time out after dialTimeout, if not zero.
+func NewProxy(config *configuration.EntryPointTCP, dialTimeout time.Duration) (*Proxy, error) {
+	if len(config.Servers) == 0 && len(config.Routes) == 0 {
+		return nil, errors.New("no server defined")
+	}
+	// reading the server name delays the connections of the protocols where the server speaks first, which must be explicit
+	if len(config.Routes) > 0 && config.PeekTimeout <= 0 {
+		return nil, errors.New("routes without peek timeout")
+	}
+
+	proxy := &Proxy{
+		peekTimeout: time.Duration(config.PeekTimeout),
+		dialTimeout: dialTimeout,
+		conns:       make(map[net.Conn]struct{}),
+	}
+	if len(config.Servers) > 0 {
+		proxy.defaultServers = newBalancer(config.Servers)
+	}
+
+	serverNames := make(map[string]bool)
+	for _, r := range config.Routes {
+		serverName := types.CanonicalDomain(r.ServerName)
+		if len(serverName) == 0 {
+			return nil, errors.New("route without server name")
+		}
+		if serverNames[serverName] {
+			return nil, fmt.Errorf("duplicated route for server name %s", serverName)
+		}
+		if len(r.Servers) == 0 {
+			return nil, fmt.Errorf("no server defined for server name %s", serverName)
+		}
+		serverNames[serverName] = true
+		proxy.routes = append(proxy.routes, &route{serverName: serverName, servers: newBalancer(r.Servers)})
+	}
+	return proxy, nil
+}
+
+// Serve accepts the connections of the listener and forwards them, until the proxy is shut down or closed.
+func (p *Proxy) Serve(listener net.Listener) error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		listener.Close()
+		return ErrProxyClosed
+	}
+	p.listener = listener
+	p.lock.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if p.isClosed() {
+				return ErrProxyClosed
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				log.Debugf("Temporary error accepting TCP connection: %v", err)
+				time.Sleep(5 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		if !p.track(conn, false) {
+			conn.Close()
+			return ErrProxyClosed
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *Proxy) handle(conn net.Conn) {
+	defer p.untrack(conn)
+	defer conn.Close()
+
+	servers := p.defaultServers
+	var reader io.Reader = conn
+	var serverName string
+	if len(p.routes) > 0 {
+		conn.SetReadDeadline(time.Now().Add(p.peekTimeout))
+		var peeked []byte
+		serverName, peeked = peekServerName(conn)
+		conn.SetReadDeadline(time.Time{})
+
+		if r := p.match(serverName); r != nil {
+			servers = r.servers
+		}
+		reader = io.MultiReader(bytes.NewReader(peeked), conn)
+	}
+
+	if servers == nil {
+		log.Debugf("No server for the TCP connection from %s with server name %q", conn.RemoteAddr(), serverName)
+		return
+	}
+
+	srv := servers.acquire()
+	defer servers.release(srv)
+
+	backendConn, err := net.DialTimeout("tcp", srv.address, p.dialTimeout)
+	if err != nil {
+		log.Errorf("Error connecting to TCP server %s: %v", srv.address, err)
+		return
+	}
+	// the connection to the server is tracked even once the proxy is shut down, to be closed with the client one
+	p.track(backendConn, true)
+	defer p.untrack(backendConn)
+	defer backendConn.Close()
+
+	errs := make(chan error, 2)
+	go forward(backendConn, reader, errs)
+	go forward(conn, backendConn, errs)
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			log.Debugf("Error forwarding the TCP connection from %s to %s: %v", conn.RemoteAddr(), srv.address, err)
+			return
+		}
+	}
+}
+
+// forward copies the data read from src to dst, then closes the writing side of dst.
+func forward(dst net.Conn, src io.Reader, errs chan<- error) {
+	_, err := io.Copy(dst, src)
+	if tcpConn, ok := dst.(*net.TCPConn); ok {
+		tcpConn.CloseWrite()
+	}
+	errs <- err
+}
+
+// match returns the route of the server name, the exact server names being matched before the wildcard ones.
+func (p *Proxy) match(serverName string) *route {
+	serverName = types.CanonicalDomain(serverName)
+	if len(serverName) == 0 {
+		return nil
+	}
+
+	for _, r := range p.routes {
+		if r.serverName == serverName {
+			return r
+		}
+	}
+
+	labels := strings.SplitN(serverName, ".", 2)
+	if len(labels) < 2 {
+		return nil
+	}
+	for _, r := range p.routes {
+		if r.serverName == "*."+labels[1] {
+			return r
+		}
+	}
+	return nil
+}
+
+// track registers an open connection, which is refused if the proxy is shut down or closed, unless force is set.
+func (p *Proxy) track(conn net.Conn, force bool) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed && !force {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	p.wg.Add(1)
+	return true
+}
+
+func (p *Proxy) untrack(conn net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.conns, conn)
+	p.wg.Done()
+}
+
+func (p *Proxy) isClosed() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.closed
+}
+
+// Shutdown stops accepting connections, and waits for the open connections to be closed,
+// or closes them once the context is done.
+func (p *Proxy) Shutdown(ctx context.Context) error {
+	p.stopListening()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.Close()
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting connections and closes the open connections.
+func (p *Proxy) Close() error {
+	p.stopListening()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (p *Proxy) stopListening() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	if p.listener != nil {
+		p.listener.Close()
+	}
+}
@@ -0,0 +1,323 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxy(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        *configuration.EntryPointTCP
+		expectedError string
+	}{
+		{
+			desc:   "default servers",
+			config: &configuration.EntryPointTCP{Servers: []string{"127.0.0.1:8080"}},
+		},
+		{
+			desc: "routes only",
+			config: &configuration.EntryPointTCP{
+				Routes: []*configuration.TCPRoute{
+					{ServerName: "foo.example.com", Servers: []string{"127.0.0.1:8080"}},
+				},
+				PeekTimeout: flaeg.Duration(time.Second),
+			},
+		},
+		{
+			desc: "routes without peek timeout",
+			config: &configuration.EntryPointTCP{
+				Servers: []string{"127.0.0.1:8080"},
+				Routes: []*configuration.TCPRoute{
+					{ServerName: "foo.example.com", Servers: []string{"127.0.0.1:8081"}},
+				},
+			},
+			expectedError: "routes without peek timeout",
+		},
+		{
+			desc:          "no server",
+			config:        &configuration.EntryPointTCP{},
+			expectedError: "no server defined",
+		},
+		{
+			desc: "route without server name",
+			config: &configuration.EntryPointTCP{
+				Routes: []*configuration.TCPRoute{
+					{Servers: []string{"127.0.0.1:8080"}},
+				},
+				PeekTimeout: flaeg.Duration(time.Second),
+			},
+			expectedError: "route without server name",
+		},
+		{
+			desc: "route without server",
+			config: &configuration.EntryPointTCP{
+				Routes: []*configuration.TCPRoute{
+					{ServerName: "foo.example.com"},
+				},
+				PeekTimeout: flaeg.Duration(time.Second),
+			},
+			expectedError: "no server defined for server name foo.example.com",
+		},
+		{
+			desc: "duplicated route",
+			config: &configuration.EntryPointTCP{
+				Routes: []*configuration.TCPRoute{
+					{ServerName: "foo.example.com", Servers: []string{"127.0.0.1:8080"}},
+					{ServerName: "Foo.Example.com", Servers: []string{"127.0.0.1:8081"}},
+				},
+				PeekTimeout: flaeg.Duration(time.Second),
+			},
+			expectedError: "duplicated route for server name foo.example.com",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewProxy(test.config, 0)
+			if len(test.expectedError) > 0 {
+				assert.EqualError(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProxyMatch(t *testing.T) {
+	proxy, err := NewProxy(&configuration.EntryPointTCP{
+		Routes: []*configuration.TCPRoute{
+			{ServerName: "*.example.com", Servers: []string{"wildcard"}},
+			{ServerName: "foo.example.com", Servers: []string{"foo"}},
+		},
+		PeekTimeout: flaeg.Duration(time.Second),
+	}, 0)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		serverName    string
+		expectedRoute string
+	}{
+		{serverName: "foo.example.com", expectedRoute: "foo.example.com"},
+		{serverName: "FOO.example.com", expectedRoute: "foo.example.com"},
+		{serverName: "bar.example.com", expectedRoute: "*.example.com"},
+		{serverName: "bar.foo.example.com"},
+		{serverName: "example.com"},
+		{serverName: ""},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.serverName, func(t *testing.T) {
+			t.Parallel()
+
+			r := proxy.match(test.serverName)
+			if len(test.expectedRoute) == 0 {
+				assert.Nil(t, r)
+			} else {
+				require.NotNil(t, r)
+				assert.Equal(t, test.expectedRoute, r.serverName)
+			}
+		})
+	}
+}
+
+func TestBalancer(t *testing.T) {
+	b := newBalancer([]string{"a", "b", "c"})
+
+	a := b.acquire()
+	assert.Equal(t, "a", a.address)
+	assert.Equal(t, "b", b.acquire().address)
+	c := b.acquire()
+	assert.Equal(t, "c", c.address)
+
+	b.release(a)
+	b.release(c)
+	assert.Equal(t, "a", b.acquire().address)
+	assert.Equal(t, "c", b.acquire().address)
+	assert.Equal(t, "a", b.acquire().address)
+}
+
+func TestProxyDefaultServers(t *testing.T) {
+	backend := startEchoServer(t)
+	defer backend.Close()
+
+	proxy, err := NewProxy(&configuration.EntryPointTCP{Servers: []string{backend.Addr().String()}}, time.Second)
+	require.NoError(t, err)
+	address := startProxy(t, proxy)
+	defer proxy.Close()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	data, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestProxyRoutes(t *testing.T) {
+	foo := startTLSServer("foo")
+	defer foo.Close()
+	bar := startTLSServer("bar")
+	defer bar.Close()
+	defaultServer := startTLSServer("default")
+	defer defaultServer.Close()
+
+	proxy, err := NewProxy(&configuration.EntryPointTCP{
+		Servers: []string{defaultServer.Listener.Addr().String()},
+		Routes: []*configuration.TCPRoute{
+			{ServerName: "foo.example.com", Servers: []string{foo.Listener.Addr().String()}},
+			{ServerName: "*.bar.example.com", Servers: []string{bar.Listener.Addr().String()}},
+		},
+		PeekTimeout: flaeg.Duration(time.Second),
+	}, time.Second)
+	require.NoError(t, err)
+	address := startProxy(t, proxy)
+	defer proxy.Close()
+
+	testCases := []struct {
+		serverName string
+		expected   string
+	}{
+		{serverName: "foo.example.com", expected: "foo"},
+		{serverName: "baz.bar.example.com", expected: "bar"},
+		{serverName: "unknown.example.com", expected: "default"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.serverName, func(t *testing.T) {
+			client := &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return net.Dial("tcp", address)
+					},
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				},
+			}
+			resp, err := client.Get("https://" + test.serverName)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestProxyNonTLSConnectionWithRoutes(t *testing.T) {
+	backend := startEchoServer(t)
+	defer backend.Close()
+
+	proxy, err := NewProxy(&configuration.EntryPointTCP{
+		Servers: []string{backend.Addr().String()},
+		Routes: []*configuration.TCPRoute{
+			{ServerName: "foo.example.com", Servers: []string{"127.0.0.1:1"}},
+		},
+		PeekTimeout: flaeg.Duration(time.Second),
+	}, time.Second)
+	require.NoError(t, err)
+	address := startProxy(t, proxy)
+	defer proxy.Close()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello world, this is not TLS"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	data, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world, this is not TLS", string(data))
+}
+
+func TestProxyShutdown(t *testing.T) {
+	backend := startEchoServer(t)
+	defer backend.Close()
+
+	proxy, err := NewProxy(&configuration.EntryPointTCP{Servers: []string{backend.Addr().String()}}, time.Second)
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- proxy.Serve(listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, proxy.Shutdown(ctx))
+	assert.Equal(t, ErrProxyClosed, <-served)
+
+	// the open connection is closed once the shutdown timed out
+	_, err = ioutil.ReadAll(conn)
+	assert.NoError(t, err)
+
+	_, err = net.Dial("tcp", listener.Addr().String())
+	assert.Error(t, err)
+}
+
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func startTLSServer(body string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(body))
+	}))
+}
+
+func startProxy(t *testing.T, proxy *Proxy) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go proxy.Serve(listener)
+	return listener.Addr().String()
+}
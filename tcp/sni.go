@@ -0,0 +1,44 @@
+package tcp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+)
+
+var errClientHelloRead = errors.New("client hello read")
+
+// peekServerName reads the TLS ClientHello of the connection to get the server name sent by the client, without terminating TLS.
+// It returns the bytes read from the connection, to be forwarded to the server before the rest of the connection.
+// The server name is empty if the connection does not start with a TLS handshake, or without SNI.
+func peekServerName(conn net.Conn) (string, []byte) {
+	peeked := new(bytes.Buffer)
+
+	var serverName string
+	tlsConn := tls.Server(readOnlyConn{Conn: conn, reader: io.TeeReader(conn, peeked)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			// the handshake is stopped as soon as the ClientHello is parsed
+			return nil, errClientHelloRead
+		},
+	})
+	tlsConn.Handshake()
+
+	return serverName, peeked.Bytes()
+}
+
+// readOnlyConn is a connection whose writes are discarded, so that the TLS handshake cannot answer the client.
+type readOnlyConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c readOnlyConn) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
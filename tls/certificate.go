@@ -3,6 +3,7 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -128,6 +129,34 @@ func (c *Certificates) isEmpty() bool {
 	return key == len(*c)
 }
 
+// keyPair parses the certificate and key of a Certificate
+func (c *Certificate) keyPair() (*tls.Certificate, error) {
+	certContent, err := c.CertFile.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	keyContent, err := c.KeyFile.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCert, err := tls.X509KeyPair(certContent, keyContent)
+	if err != nil {
+		return nil, err
+	}
+	return &tlsCert, nil
+}
+
+// Validate checks that the certificate and key of a Certificate are set and can be loaded
+func (c *Certificate) Validate() error {
+	if len(c.CertFile) == 0 || len(c.KeyFile) == 0 {
+		return errors.New("both the certificate and the key must be set")
+	}
+	_, err := c.keyPair()
+	return err
+}
+
 // AppendCertificates appends a Certificate to a certificates map sorted by entrypoints
 func (c *Certificate) AppendCertificates(certs map[string]*DomainsCertificates, ep string) error {
 
@@ -157,6 +186,9 @@ func (c *Certificate) AppendCertificates(certs map[string]*DomainsCertificates,
 		}
 
 	}
+	if parsedCert.PublicKeyAlgorithm == x509.ECDSA {
+		certKey += ecdsaKeySuffix
+	}
 
 	certExists := false
 	if certs[ep] == nil {
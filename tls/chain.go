@@ -0,0 +1,160 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+)
+
+const (
+	// DefaultChainFetchTimeout is the default timeout of the requests fetching the intermediate certificates
+	DefaultChainFetchTimeout = 10 * time.Second
+
+	// maxChainLength bounds the number of certificates of a completed chain
+	maxChainLength = 6
+	// maxIssuerSize bounds the size of a fetched certificate
+	maxIssuerSize = 1 << 20
+)
+
+// ChainCompleter completes the chains of the certificates lacking their intermediate certificates.
+// The issuer of the last certificate of a chain is fetched from the caIssuers URL of its Authority Information Access extension,
+// and appended to the chain, until a self-signed root certificate, which is not served, is reached.
+// The fetched certificates are cached by URL.
+type ChainCompleter struct {
+	client  *http.Client
+	lock    sync.Mutex
+	issuers map[string]*x509.Certificate
+}
+
+// NewChainCompleter creates a ChainCompleter whose requests time out after timeout
+func NewChainCompleter(timeout time.Duration) *ChainCompleter {
+	if timeout <= 0 {
+		timeout = DefaultChainFetchTimeout
+	}
+	return &ChainCompleter{
+		client:  &http.Client{Timeout: timeout},
+		issuers: make(map[string]*x509.Certificate),
+	}
+}
+
+// Complete appends the missing intermediate certificates to the chain of cert.
+// A chain which cannot be completed is logged, and the certificates found so far are kept.
+func (c *ChainCompleter) Complete(cert *tls.Certificate) {
+	if c == nil || cert == nil || len(cert.Certificate) == 0 {
+		return
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		log.Warnf("Unable to complete the chain of the certificate: %v", err)
+		return
+	}
+
+	last := leaf
+	if len(cert.Certificate) > 1 {
+		last, err = x509.ParseCertificate(cert.Certificate[len(cert.Certificate)-1])
+		if err != nil {
+			log.Warnf("Unable to complete the chain of the certificate %s: %v", leaf.Subject.CommonName, err)
+			return
+		}
+	}
+
+	chain := append([][]byte{}, cert.Certificate...)
+	for len(chain) < maxChainLength && len(last.IssuingCertificateURL) > 0 && !isSelfSigned(last) {
+		issuer, err := c.fetchIssuer(last)
+		if err != nil {
+			log.Warnf("Unable to complete the chain of the certificate %s: %v", leaf.Subject.CommonName, err)
+			break
+		}
+		if isSelfSigned(issuer) {
+			break
+		}
+		log.Debugf("Add the intermediate certificate %s to the chain of the certificate %s", issuer.Subject.CommonName, leaf.Subject.CommonName)
+		chain = append(chain, issuer.Raw)
+		last = issuer
+	}
+	cert.Certificate = chain
+}
+
+// CompleteChains completes the chains of the certificates with completer
+func (dc *DomainsCertificates) CompleteChains(completer *ChainCompleter) {
+	if dc == nil {
+		return
+	}
+	for _, cert := range *dc {
+		completer.Complete(cert)
+	}
+}
+
+// fetchIssuer returns the certificate which signed cert, fetched from its caIssuers URLs
+func (c *ChainCompleter) fetchIssuer(cert *x509.Certificate) (*x509.Certificate, error) {
+	var lastErr error
+	for _, issuerURL := range cert.IssuingCertificateURL {
+		issuer, err := c.getIssuer(issuerURL)
+		if err == nil {
+			err = cert.CheckSignatureFrom(issuer)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("invalid issuer from %s: %v", issuerURL, err)
+			continue
+		}
+		return issuer, nil
+	}
+	return nil, lastErr
+}
+
+func (c *ChainCompleter) getIssuer(issuerURL string) (*x509.Certificate, error) {
+	c.lock.Lock()
+	issuer, ok := c.issuers[issuerURL]
+	c.lock.Unlock()
+	if ok {
+		return issuer, nil
+	}
+
+	resp, err := c.client.Get(issuerURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxIssuerSize))
+	if err != nil {
+		return nil, err
+	}
+	issuer, err = parseCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.issuers[issuerURL] = issuer
+	c.lock.Unlock()
+	return issuer, nil
+}
+
+// parseCertificate parses a DER or PEM encoded certificate
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block %s", block.Type)
+		}
+		data = block.Bytes
+	}
+	return x509.ParseCertificate(data)
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
@@ -0,0 +1,131 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainCompleterComplete(t *testing.T) {
+	var fetches int32
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	root, rootKey := createTestCertificate(t, "root", "", nil, nil)
+	intermediate, intermediateKey := createTestCertificate(t, "intermediate", server.URL+"/root.cer", root, rootKey)
+	leaf, _ := createTestCertificate(t, "leaf", server.URL+"/intermediate.pem", intermediate, intermediateKey)
+	orphan, _ := createTestCertificate(t, "orphan", server.URL+"/missing.cer", intermediate, intermediateKey)
+
+	mux.HandleFunc("/root.cer", func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		rw.Write(root.Raw)
+	})
+	mux.HandleFunc("/intermediate.pem", func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		pem.Encode(rw, &pem.Block{Type: "CERTIFICATE", Bytes: intermediate.Raw})
+	})
+
+	testCases := []struct {
+		desc          string
+		chain         [][]byte
+		expectedChain [][]byte
+	}{
+		{
+			desc:          "missing intermediate",
+			chain:         [][]byte{leaf.Raw},
+			expectedChain: [][]byte{leaf.Raw, intermediate.Raw},
+		},
+		{
+			desc:          "complete chain",
+			chain:         [][]byte{leaf.Raw, intermediate.Raw},
+			expectedChain: [][]byte{leaf.Raw, intermediate.Raw},
+		},
+		{
+			desc:          "self-signed certificate",
+			chain:         [][]byte{root.Raw},
+			expectedChain: [][]byte{root.Raw},
+		},
+		{
+			desc:          "unavailable issuer",
+			chain:         [][]byte{orphan.Raw},
+			expectedChain: [][]byte{orphan.Raw},
+		},
+	}
+
+	completer := NewChainCompleter(time.Second)
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			cert := &tls.Certificate{Certificate: test.chain}
+			completer.Complete(cert)
+			assert.Equal(t, test.expectedChain, cert.Certificate)
+		})
+	}
+
+	// the issuers are fetched once
+	completer.Complete(&tls.Certificate{Certificate: [][]byte{leaf.Raw}})
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+}
+
+func TestChainCompleterInvalidIssuer(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	intermediate, intermediateKey := createTestCertificate(t, "intermediate", "", nil, nil)
+	other, _ := createTestCertificate(t, "other", "", nil, nil)
+	leaf, _ := createTestCertificate(t, "leaf", server.URL+"/intermediate.cer", intermediate, intermediateKey)
+
+	mux.HandleFunc("/intermediate.cer", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write(other.Raw)
+	})
+
+	cert := &tls.Certificate{Certificate: [][]byte{leaf.Raw}}
+	NewChainCompleter(time.Second).Complete(cert)
+	assert.Equal(t, [][]byte{leaf.Raw}, cert.Certificate)
+}
+
+// createTestCertificate creates a certificate signed by parent, or a self-signed one when parent is nil
+func createTestCertificate(t *testing.T, commonName string, issuerURL string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	if len(issuerURL) > 0 {
+		template.IssuingCertificateURL = []string{issuerURL}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
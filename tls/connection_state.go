@@ -0,0 +1,50 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// ConnectionInfo holds the parameters negotiated during the TLS handshake of a connection
+type ConnectionInfo struct {
+	Version             string `json:"version"`
+	CipherSuite         string `json:"cipherSuite"`
+	ServerName          string `json:"serverName,omitempty"`
+	ClientCertPresented bool   `json:"clientCertPresented"`
+	ClientCertVerified  bool   `json:"clientCertVerified"`
+}
+
+// NewConnectionInfo extracts the negotiated TLS parameters from a connection state
+func NewConnectionInfo(state *tls.ConnectionState) *ConnectionInfo {
+	if state == nil {
+		return nil
+	}
+
+	return &ConnectionInfo{
+		Version:             GetVersionName(state.Version),
+		CipherSuite:         GetCipherSuiteName(state.CipherSuite),
+		ServerName:          state.ServerName,
+		ClientCertPresented: len(state.PeerCertificates) > 0,
+		ClientCertVerified:  len(state.VerifiedChains) > 0,
+	}
+}
+
+// GetVersionName returns the name of a TLS version as used in the configuration
+func GetVersionName(version uint16) string {
+	for name, value := range MinVersion {
+		if value == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+// GetCipherSuiteName returns the name of a cipher suite as used in the configuration
+func GetCipherSuiteName(cipherSuite uint16) string {
+	for name, value := range CipherSuites {
+		if value == cipherSuite {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04X", cipherSuite)
+}
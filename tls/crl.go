@@ -0,0 +1,300 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+)
+
+const (
+	// DefaultCRLFetchTimeout is the default timeout of the requests fetching the CRLs from the distribution points
+	DefaultCRLFetchTimeout = 10 * time.Second
+
+	// maxCRLSize bounds the size of a fetched CRL
+	maxCRLSize = 10 << 20
+	// defaultFetchedCRLValidity is how long a fetched CRL without next update is cached
+	defaultFetchedCRLValidity = time.Hour
+	// failedCRLFetchRetryDelay is how long a failed fetch is cached, before the distribution point is requested again
+	failedCRLFetchRetryDelay = time.Minute
+)
+
+// CRLChecker rejects the client certificates revoked by a Certificate Revocation List.
+// The CRLs are loaded from files, and optionally fetched from the CRL distribution points of the certificates.
+// A CRL is only applied to the certificates of the CA which signed it.
+type CRLChecker struct {
+	files  []string
+	fetch  bool
+	client *http.Client
+
+	lock    sync.RWMutex
+	crls    []*revocationList
+	fetched map[string]*fetchedCRL
+}
+
+// revocationList is a CRL, with the serial numbers of its revoked certificates
+type revocationList struct {
+	list    *pkix.CertificateList
+	revoked map[string]struct{}
+	// issuers caches whether the CRL is signed by a CA, by raw certificate
+	issuers sync.Map
+}
+
+// fetchedCRL is a CRL fetched from a distribution point, or the error of its first fetch.
+// It is fetched again in the background from the refresh time, ahead of its next update, the handshakes still checking
+// the certificates with the previous CRL meanwhile, which is kept if the CRL can't be fetched again.
+type fetchedCRL struct {
+	crl     *revocationList
+	err     error
+	refresh time.Time
+	// fetching is closed once the CRL being fetched is cached, and is nil when no fetch is in progress
+	fetching chan struct{}
+}
+
+// NewCRLChecker creates a CRLChecker loading the CRLs of files, and fetching the ones of the distribution points if fetch is true
+func NewCRLChecker(files []string, fetch bool) (*CRLChecker, error) {
+	c := &CRLChecker{
+		files:   files,
+		fetch:   fetch,
+		client:  &http.Client{Timeout: DefaultCRLFetchTimeout},
+		fetched: make(map[string]*fetchedCRL),
+	}
+	crls, err := loadCRLFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	c.crls = crls
+	return c, nil
+}
+
+// Reload loads the CRL files again, and discards the fetched CRLs.
+// The previous CRLs are kept if one of the files can't be loaded.
+func (c *CRLChecker) Reload() error {
+	if c == nil {
+		return nil
+	}
+	crls, err := loadCRLFiles(c.files)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.crls = crls
+	c.fetched = make(map[string]*fetchedCRL)
+	c.lock.Unlock()
+	return nil
+}
+
+// VerifyPeerCertificate is a tls.Config.VerifyPeerCertificate callback, failing the handshake when every verified chain
+// of the client certificate contains a revoked certificate
+func (c *CRLChecker) VerifyPeerCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 {
+		// no client certificate was provided
+		return nil
+	}
+
+	var err error
+	for _, chain := range verifiedChains {
+		if err = c.checkChain(chain); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// checkChain returns an error if one of the certificates of the chain is revoked by its issuer
+func (c *CRLChecker) checkChain(chain []*x509.Certificate) error {
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		for _, crl := range c.getCRLs(cert, issuer) {
+			if _, revoked := crl.revoked[cert.SerialNumber.String()]; revoked {
+				return fmt.Errorf("certificate %s, serial number %s, is revoked by %s", cert.Subject.CommonName, cert.SerialNumber, issuer.Subject.CommonName)
+			}
+		}
+	}
+	return nil
+}
+
+// getCRLs returns the CRLs signed by issuer, loaded from the files or fetched from the distribution points of cert
+func (c *CRLChecker) getCRLs(cert *x509.Certificate, issuer *x509.Certificate) []*revocationList {
+	c.lock.RLock()
+	crls := c.crls
+	c.lock.RUnlock()
+
+	var issued []*revocationList
+	for _, crl := range crls {
+		if crl.isSignedBy(issuer) {
+			issued = append(issued, crl)
+		}
+	}
+
+	if !c.fetch {
+		return issued
+	}
+	for _, crlURL := range cert.CRLDistributionPoints {
+		crl, err := c.getFetchedCRL(crlURL)
+		if err == nil && !crl.isSignedBy(issuer) {
+			err = errors.New("not signed by the issuer of the certificate")
+		}
+		if err != nil {
+			// the fetch failures are not fatal, to not reject all the clients while a distribution point is unavailable
+			log.Warnf("Unable to check the revocation of the certificate %s with the CRL from %s: %v", cert.Subject.CommonName, crlURL, err)
+			continue
+		}
+		issued = append(issued, crl)
+	}
+	return issued
+}
+
+// getFetchedCRL returns the CRL of a distribution point, only the handshakes coming before the end of its first fetch
+// waiting for it, which is shared by all of them
+func (c *CRLChecker) getFetchedCRL(crlURL string) (*revocationList, error) {
+	c.lock.Lock()
+	fetched, ok := c.fetched[crlURL]
+	if !ok {
+		fetched = &fetchedCRL{}
+		c.fetched[crlURL] = fetched
+	}
+	if fetched.fetching == nil && !time.Now().Before(fetched.refresh) {
+		fetched.fetching = make(chan struct{})
+		fetching := fetched.fetching
+		safe.Go(func() {
+			c.refreshCRL(crlURL, fetched, fetching)
+		})
+	}
+	crl, err, fetching := fetched.crl, fetched.err, fetched.fetching
+	c.lock.Unlock()
+
+	if crl == nil && err == nil {
+		<-fetching
+		c.lock.RLock()
+		crl, err = fetched.crl, fetched.err
+		c.lock.RUnlock()
+	}
+	return crl, err
+}
+
+// refreshCRL fetches the CRL of a distribution point, then caches it until its refresh time and closes fetching
+func (c *CRLChecker) refreshCRL(crlURL string, fetched *fetchedCRL, fetching chan struct{}) {
+	crl, err := c.fetchCRL(crlURL)
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	switch {
+	case err != nil:
+		fetched.refresh = now.Add(failedCRLFetchRetryDelay)
+		if fetched.crl != nil {
+			log.Warnf("Unable to fetch the CRL from %s again, the previous one is kept: %v", crlURL, err)
+		} else {
+			fetched.err = err
+		}
+	case crl.list.TBSCertList.NextUpdate.After(now):
+		// the CRL is refreshed after nine tenths of the time remaining until its next update
+		fetched.crl, fetched.err = crl, nil
+		fetched.refresh = now.Add(crl.list.TBSCertList.NextUpdate.Sub(now) * 9 / 10)
+	default:
+		fetched.crl, fetched.err = crl, nil
+		fetched.refresh = now.Add(defaultFetchedCRLValidity)
+	}
+	fetched.fetching = nil
+	close(fetching)
+}
+
+func (c *CRLChecker) fetchCRL(crlURL string) (*revocationList, error) {
+	resp, err := c.client.Get(crlURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCRLSize))
+	if err != nil {
+		return nil, err
+	}
+	crls, err := parseCRLs(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(crls) != 1 {
+		return nil, fmt.Errorf("expected one CRL, got %d", len(crls))
+	}
+	return crls[0], nil
+}
+
+// isSignedBy returns whether the CRL is signed by the CA issuer
+func (r *revocationList) isSignedBy(issuer *x509.Certificate) bool {
+	if signed, ok := r.issuers.Load(string(issuer.Raw)); ok {
+		return signed.(bool)
+	}
+	signed := issuer.CheckCRLSignature(r.list) == nil
+	r.issuers.Store(string(issuer.Raw), signed)
+	return signed
+}
+
+// loadCRLFiles loads the CRLs of the files, each one holding one or several PEM encoded CRLs, or a DER encoded one
+func loadCRLFiles(files []string) ([]*revocationList, error) {
+	var crls []*revocationList
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileCRLs, err := parseCRLs(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CRL(s) in %s: %v", file, err)
+		}
+		for _, crl := range fileCRLs {
+			if crl.list.HasExpired(time.Now()) {
+				log.Warnf("The CRL of %s, from %s, has expired and should be updated", crl.list.TBSCertList.Issuer, file)
+			}
+		}
+		crls = append(crls, fileCRLs...)
+	}
+	return crls, nil
+}
+
+// parseCRLs parses the PEM encoded CRLs of data, or the DER encoded one
+func parseCRLs(data []byte) ([]*revocationList, error) {
+	var ders [][]byte
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("unexpected PEM block %s", block.Type)
+		}
+		ders = append(ders, block.Bytes)
+	}
+	if len(ders) == 0 {
+		ders = [][]byte{data}
+	}
+
+	var crls []*revocationList
+	for _, der := range ders {
+		list, err := x509.ParseDERCRL(der)
+		if err != nil {
+			return nil, err
+		}
+		crl := &revocationList{list: list, revoked: make(map[string]struct{})}
+		for _, revoked := range list.TBSCertList.RevokedCertificates {
+			crl.revoked[revoked.SerialNumber.String()] = struct{}{}
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
@@ -0,0 +1,283 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRLCheckerVerifyPeerCertificate(t *testing.T) {
+	ca, caKey := createTestCertificate(t, "ca", "", nil, nil)
+	otherCA, otherCAKey := createTestCertificate(t, "other ca", "", nil, nil)
+	revoked, _ := createTestCertificate(t, "revoked", "", ca, caKey)
+	valid, _ := createTestCertificate(t, "valid", "", ca, caKey)
+	revokedByOther, _ := createTestCertificate(t, "revoked by other", "", otherCA, otherCAKey)
+
+	dir, err := ioutil.TempDir("", "crl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the CRL of the other CA lists the serial number of the valid certificate, but doesn't apply to the certificates of the CA
+	crlFile := filepath.Join(dir, "crls.pem")
+	crls := append(
+		pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: createTestCRL(t, ca, caKey, revoked)}),
+		pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: createTestCRL(t, otherCA, otherCAKey, valid, revokedByOther)})...,
+	)
+	require.NoError(t, ioutil.WriteFile(crlFile, crls, 0600))
+
+	checker, err := NewCRLChecker([]string{crlFile}, false)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc           string
+		verifiedChains [][]*x509.Certificate
+		expectedError  bool
+	}{
+		{
+			desc: "no client certificate",
+		},
+		{
+			desc:           "valid certificate",
+			verifiedChains: [][]*x509.Certificate{{valid, ca}},
+		},
+		{
+			desc:           "revoked certificate",
+			verifiedChains: [][]*x509.Certificate{{revoked, ca}},
+			expectedError:  true,
+		},
+		{
+			desc:           "certificate revoked by the other CA",
+			verifiedChains: [][]*x509.Certificate{{revokedByOther, otherCA}},
+			expectedError:  true,
+		},
+		{
+			desc:           "revoked certificate with a valid chain",
+			verifiedChains: [][]*x509.Certificate{{revoked, ca}, {revoked, otherCA}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := checker.VerifyPeerCertificate(nil, test.verifiedChains)
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCRLCheckerFetch(t *testing.T) {
+	var fetches int32
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ca, caKey := createTestCertificate(t, "ca", "", nil, nil)
+	revoked := createTestLeafCertificate(t, "revoked", server.URL+"/ca.crl", ca, caKey)
+	valid := createTestLeafCertificate(t, "valid", server.URL+"/ca.crl", ca, caKey)
+	unavailable := createTestLeafCertificate(t, "unavailable", server.URL+"/missing.crl", ca, caKey)
+
+	crl := createTestCRL(t, ca, caKey, revoked)
+	mux.HandleFunc("/ca.crl", func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		rw.Write(crl)
+	})
+	mux.HandleFunc("/missing.crl", func(rw http.ResponseWriter, req *http.Request) {
+		http.NotFound(rw, req)
+	})
+
+	checker, err := NewCRLChecker(nil, true)
+	require.NoError(t, err)
+
+	assert.Error(t, checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca}}))
+	assert.NoError(t, checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{valid, ca}}))
+	// the unavailable CRLs don't fail the handshakes
+	assert.NoError(t, checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{unavailable, ca}}))
+
+	// the CRL is fetched once, until its next update
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	// the fetched CRLs are discarded on reload
+	require.NoError(t, checker.Reload())
+	assert.Error(t, checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca}}))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+
+	// the CRLs are not fetched when disabled
+	checker, err = NewCRLChecker(nil, false)
+	require.NoError(t, err)
+	assert.NoError(t, checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca}}))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+}
+
+func TestCRLCheckerFetchConcurrent(t *testing.T) {
+	var fetches int32
+	var available int32 = 1
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ca, caKey := createTestCertificate(t, "ca", "", nil, nil)
+	revoked := createTestLeafCertificate(t, "revoked", server.URL+"/ca.crl", ca, caKey)
+	chains := [][]*x509.Certificate{{revoked, ca}}
+
+	crl := createTestCRL(t, ca, caKey, revoked)
+	mux.HandleFunc("/ca.crl", func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		entered <- struct{}{}
+		<-release
+		if atomic.LoadInt32(&available) == 0 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Write(crl)
+	})
+
+	checker, err := NewCRLChecker(nil, true)
+	require.NoError(t, err)
+
+	// the handshakes share the first fetch of the CRL
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			errs <- checker.VerifyPeerCertificate(nil, chains)
+		}()
+	}
+	<-entered
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < cap(errs); i++ {
+		assert.Error(t, <-errs)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	checker.lock.RLock()
+	fetched := checker.fetched[server.URL+"/ca.crl"]
+	assert.WithinDuration(t, time.Now().Add(54*time.Minute), fetched.refresh, time.Minute)
+	checker.lock.RUnlock()
+
+	refresh := func() {
+		checker.lock.Lock()
+		fetched.refresh = time.Now()
+		checker.lock.Unlock()
+		assert.Error(t, checker.VerifyPeerCertificate(nil, chains))
+		<-entered
+
+		checker.lock.RLock()
+		fetching := fetched.fetching
+		checker.lock.RUnlock()
+		require.NotNil(t, fetching)
+		<-fetching
+	}
+
+	// the CRL is refreshed in the background, ahead of its next update
+	refresh()
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+	assert.Error(t, checker.VerifyPeerCertificate(nil, chains))
+
+	// the previous CRL is kept when it can't be fetched again
+	atomic.StoreInt32(&available, 0)
+	refresh()
+	assert.EqualValues(t, 3, atomic.LoadInt32(&fetches))
+	assert.Error(t, checker.VerifyPeerCertificate(nil, chains))
+	checker.lock.RLock()
+	assert.WithinDuration(t, time.Now().Add(failedCRLFetchRetryDelay), fetched.refresh, time.Second)
+	checker.lock.RUnlock()
+}
+
+func TestCRLCheckerReload(t *testing.T) {
+	ca, caKey := createTestCertificate(t, "ca", "", nil, nil)
+	leaf, _ := createTestCertificate(t, "leaf", "", ca, caKey)
+	chains := [][]*x509.Certificate{{leaf, ca}}
+
+	dir, err := ioutil.TempDir("", "crl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the CRL is written in DER format
+	crlFile := filepath.Join(dir, "ca.crl")
+	require.NoError(t, ioutil.WriteFile(crlFile, createTestCRL(t, ca, caKey), 0600))
+
+	checker, err := NewCRLChecker([]string{crlFile}, false)
+	require.NoError(t, err)
+	assert.NoError(t, checker.VerifyPeerCertificate(nil, chains))
+
+	require.NoError(t, ioutil.WriteFile(crlFile, createTestCRL(t, ca, caKey, leaf), 0600))
+	require.NoError(t, checker.Reload())
+	assert.Error(t, checker.VerifyPeerCertificate(nil, chains))
+
+	// the previous CRLs are kept when the reload fails
+	require.NoError(t, ioutil.WriteFile(crlFile, []byte("not a CRL"), 0600))
+	assert.Error(t, checker.Reload())
+	assert.Error(t, checker.VerifyPeerCertificate(nil, chains))
+}
+
+func TestNewCRLCheckerInvalidFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, _ := createTestCertificate(t, "ca", "", nil, nil)
+	certFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+
+	_, err = NewCRLChecker([]string{filepath.Join(dir, "missing.crl")}, false)
+	assert.Error(t, err)
+
+	_, err = NewCRLChecker([]string{certFile}, false)
+	assert.Error(t, err)
+}
+
+// createTestLeafCertificate creates a client certificate signed by parent, whose CRL is distributed at crlURL
+func createTestLeafCertificate(t *testing.T, commonName string, crlURL string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		CRLDistributionPoints: []string{crlURL},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &parentKey.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// createTestCRL creates a DER encoded CRL of ca, revoking the certificates
+func createTestCRL(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, certs ...*x509.Certificate) []byte {
+	var revoked []pkix.RevokedCertificate
+	for _, cert := range certs {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: cert.SerialNumber, RevocationTime: time.Now()})
+	}
+
+	crl, err := ca.CreateCRL(rand.Reader, caKey, revoked, time.Now(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return crl
+}
@@ -1,6 +1,9 @@
 package generate
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -53,6 +56,25 @@ func KeyPair(domain string, expiration time.Time) ([]byte, []byte, error) {
 	return certPEM, keyPEM, nil
 }
 
+// ECDSAKeyPair generates cert and key files, with an ECDSA key on the P-256 curve
+func ECDSAKeyPair(domain string, expiration time.Time) ([]byte, []byte, error) {
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(ecdsaPrivKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	derBytes, err := derCert(ecdsaPrivKey, expiration, domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}), keyPEM, nil
+}
+
 // PemCert generates PEM cert file
 func PemCert(privKey *rsa.PrivateKey, domain string, expiration time.Time) ([]byte, error) {
 	derBytes, err := derCert(privKey, expiration, domain)
@@ -63,7 +85,7 @@ func PemCert(privKey *rsa.PrivateKey, domain string, expiration time.Time) ([]by
 	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}), nil
 }
 
-func derCert(privKey *rsa.PrivateKey, expiration time.Time, domain string) ([]byte, error) {
+func derCert(privKey crypto.Signer, expiration time.Time, domain string) ([]byte, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
@@ -87,5 +109,5 @@ func derCert(privKey *rsa.PrivateKey, expiration time.Time, domain string) ([]by
 		DNSNames:              []string{domain},
 	}
 
-	return x509.CreateCertificate(rand.Reader, &template, &template, &privKey.PublicKey, privKey)
+	return x509.CreateCertificate(rand.Reader, &template, &template, privKey.Public(), privKey)
 }
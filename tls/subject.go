@@ -0,0 +1,106 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"strings"
+)
+
+// subjectAttributes are the attributes of a certificate subject which can be matched, by their usual short name
+var subjectAttributes = map[string]func(subject pkix.Name) []string{
+	"CN": func(subject pkix.Name) []string { return []string{subject.CommonName} },
+	"O":  func(subject pkix.Name) []string { return subject.Organization },
+	"OU": func(subject pkix.Name) []string { return subject.OrganizationalUnit },
+	"C":  func(subject pkix.Name) []string { return subject.Country },
+	"L":  func(subject pkix.Name) []string { return subject.Locality },
+	"ST": func(subject pkix.Name) []string { return subject.Province },
+}
+
+type subjectAttribute struct {
+	name  string
+	value string
+}
+
+// SubjectMatcher matches the subject of the verified client certificate of a connection
+// against a list of attribute=value pairs, such as O=PartnerA
+type SubjectMatcher struct {
+	attributes []subjectAttribute
+}
+
+// NewSubjectMatcher creates a SubjectMatcher from attribute=value pairs, all of which must match.
+// The supported attributes are CN, O, OU, C, L and ST.
+func NewSubjectMatcher(subjects ...string) (*SubjectMatcher, error) {
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("no client certificate subject defined")
+	}
+
+	matcher := &SubjectMatcher{}
+	for _, subject := range subjects {
+		parts := strings.SplitN(subject, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[1])) == 0 {
+			return nil, fmt.Errorf("invalid client certificate subject %q: expected attribute=value", subject)
+		}
+		name := strings.ToUpper(strings.TrimSpace(parts[0]))
+		if _, ok := subjectAttributes[name]; !ok {
+			return nil, fmt.Errorf("invalid client certificate subject %q: unknown attribute %s", subject, parts[0])
+		}
+		matcher.attributes = append(matcher.attributes, subjectAttribute{name: name, value: strings.TrimSpace(parts[1])})
+	}
+	return matcher, nil
+}
+
+// Match returns true if the connection presented a client certificate which has been verified,
+// and whose subject has all the attributes of the matcher.
+// An attribute holding several values in the certificate matches if any of them is equal to the expected one.
+func (m *SubjectMatcher) Match(state *tls.ConnectionState) bool {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return false
+	}
+
+	subject := state.PeerCertificates[0].Subject
+	for _, attribute := range m.attributes {
+		if !containsValue(subjectAttributes[attribute.name](subject), attribute.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSubjectAttributes checks that the attributes are supported by FormatSubject
+func CheckSubjectAttributes(attributes []string) error {
+	for _, attribute := range attributes {
+		if _, ok := subjectAttributes[strings.ToUpper(strings.TrimSpace(attribute))]; !ok {
+			return fmt.Errorf("unknown client certificate subject attribute %q", attribute)
+		}
+	}
+	return nil
+}
+
+// FormatSubject returns the given attributes of a certificate subject as comma separated attribute=value pairs,
+// in the order of the attributes. An attribute holding several values is repeated, and an unset one is omitted.
+func FormatSubject(subject pkix.Name, attributes []string) string {
+	var pairs []string
+	for _, attribute := range attributes {
+		name := strings.ToUpper(strings.TrimSpace(attribute))
+		values, ok := subjectAttributes[name]
+		if !ok {
+			continue
+		}
+		for _, value := range values(subject) {
+			if len(value) > 0 {
+				pairs = append(pairs, name+"="+value)
+			}
+		}
+	}
+	return strings.Join(pairs, ",")
+}
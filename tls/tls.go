@@ -3,6 +3,7 @@ package tls
 import (
 	"crypto/tls"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/containous/traefik/log"
@@ -18,15 +19,35 @@ const (
 type ClientCA struct {
 	Files    []string
 	Optional bool
+	// CRLFiles holds the Certificate Revocation Lists, in PEM or DER format, rejecting the revoked client certificates
+	CRLFiles []string
+	// FetchCRLs checks the client certificates against the CRLs of their distribution points too
+	FetchCRLs bool
+}
+
+// DomainsClientCA defines the ClientCA applied to the handshakes requesting one of the domains,
+// instead of the ClientCA of the entryPoint
+type DomainsClientCA struct {
+	Domains  []string
+	ClientCA ClientCA
 }
 
 // TLS configures TLS for an entry point
 type TLS struct {
-	MinVersion    string `export:"true"`
-	CipherSuites  []string
-	Certificates  Certificates
-	ClientCAFiles []string // Deprecated
-	ClientCA      ClientCA
+	MinVersion       string `export:"true"`
+	CipherSuites     []string
+	Certificates     Certificates
+	ClientCAFiles    []string // Deprecated
+	ClientCA         ClientCA
+	DomainsClientCAs []DomainsClientCA
+	// CompleteChains appends the intermediate certificates missing from the chains of the certificates,
+	// fetched from the caIssuers URL of their Authority Information Access extension
+	CompleteChains bool
+	// LogHandshakeErrors logs the failed handshakes at the debug level, with the server name and the cipher suites
+	// requested by the clients
+	LogHandshakeErrors bool
+	// StrictLoad makes the loading of the certificates of the entrypoint fail on any invalid one, instead of skipping it
+	StrictLoad bool
 }
 
 // RootCAs hold the CA we want to have in root
@@ -41,6 +62,69 @@ type Configuration struct {
 	Certificate *Certificate
 }
 
+// DefaultCertificates holds the certificates served when the requested server name matches no other certificate.
+// A default certificate without entrypoints is used by the entrypoints which have no specific one.
+type DefaultCertificates []*Configuration
+
+// GetCertificate returns the default certificate of the given entrypoint, or nil if there is none
+func (d DefaultCertificates) GetCertificate(entryPointName string) (*tls.Certificate, error) {
+	var global *Certificate
+	for _, defaultCert := range d {
+		if defaultCert == nil || defaultCert.Certificate == nil {
+			continue
+		}
+		if len(defaultCert.EntryPoints) == 0 {
+			if global == nil {
+				global = defaultCert.Certificate
+			}
+			continue
+		}
+		for _, ep := range defaultCert.EntryPoints {
+			if ep == entryPointName {
+				return defaultCert.Certificate.keyPair()
+			}
+		}
+	}
+
+	if global == nil {
+		return nil, nil
+	}
+	return global.keyPair()
+}
+
+// String is the method to format the flag's value, part of the flag.Value interface.
+// It lists the certificate files of the default certificates.
+func (d *DefaultCertificates) String() string {
+	var certFiles []string
+	for _, defaultCert := range *d {
+		if defaultCert != nil && defaultCert.Certificate != nil {
+			certFiles = append(certFiles, defaultCert.Certificate.CertFile.String())
+		}
+	}
+	return strings.Join(certFiles, ",")
+}
+
+// Set is the method to set the flag value, part of the flag.Value interface.
+// The default certificates are only configurable through the configuration file, so it always fails.
+func (d *DefaultCertificates) Set(value string) error {
+	return fmt.Errorf("default certificates are only configurable through the configuration file, got %q", value)
+}
+
+// Get return the DefaultCertificates list
+func (d *DefaultCertificates) Get() interface{} {
+	return *d
+}
+
+// SetValue sets the DefaultCertificates with val
+func (d *DefaultCertificates) SetValue(val interface{}) {
+	*d = val.(DefaultCertificates)
+}
+
+// Type is type of the struct
+func (d *DefaultCertificates) Type() string {
+	return "defaultcertificates"
+}
+
 // Set is the method to set the flag value, part of the flag.Value interface.
 // Set's argument is a string to be parsed to set the flag.
 // It's a comma-separated list, so we split it.
@@ -54,6 +138,192 @@ func (dc *DomainsCertificates) Get() interface{} {
 	return map[string]*tls.Certificate(*dc)
 }
 
+// GetBestCertificate returns the certificate matching the given server name, or nil if there is none.
+// A certificate for the exact server name is preferred over a wildcard one, itself preferred over a wildcard
+// certificate of the server name as bare domain, *.example.com matching example.com.
+// The certificates are checked in the order of their domains to make the selection deterministic.
+// When both an RSA and an ECDSA certificate match, the RSA one is returned.
+func (dc *DomainsCertificates) GetBestCertificate(serverName string) *tls.Certificate {
+	return NewCertificateIndex(dc).GetBestCertificate(serverName)
+}
+
+// GetCertificate returns the certificate matching the server name requested by the client, or nil if there is none,
+// selected as by GetBestCertificate, except that an ECDSA certificate is preferred over an RSA one when the client supports it.
+func (dc *DomainsCertificates) GetCertificate(clientHello *tls.ClientHelloInfo) *tls.Certificate {
+	return NewCertificateIndex(dc).GetCertificate(clientHello)
+}
+
+// CertificateIndex holds DomainsCertificates indexed by domain, so that the certificate of a server name
+// is selected without going through all the certificates on every handshake.
+type CertificateIndex struct {
+	certs   *DomainsCertificates
+	domains map[string]*certificateCandidates
+}
+
+// NewCertificateIndex indexes the certificates by their lowercased domains, in the order of their keys.
+func NewCertificateIndex(dc *DomainsCertificates) *CertificateIndex {
+	index := &CertificateIndex{
+		certs:   dc,
+		domains: make(map[string]*certificateCandidates),
+	}
+	if dc == nil {
+		return index
+	}
+
+	var keys []string
+	for key := range *dc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		isECDSA := strings.HasSuffix(key, ecdsaKeySuffix)
+		for _, domain := range strings.Split(strings.ToLower(KeyDomains(key)), ",") {
+			candidates := index.domains[domain]
+			if candidates == nil {
+				candidates = &certificateCandidates{}
+				index.domains[domain] = candidates
+			}
+			candidates.add((*dc)[key], isECDSA)
+		}
+	}
+	return index
+}
+
+// Certificates returns the indexed certificates.
+func (i *CertificateIndex) Certificates() *DomainsCertificates {
+	if i == nil {
+		return nil
+	}
+	return i.certs
+}
+
+// GetBestCertificate returns the certificate matching the given server name, or nil if there is none,
+// selected as by DomainsCertificates.GetBestCertificate.
+func (i *CertificateIndex) GetBestCertificate(serverName string) *tls.Certificate {
+	return i.getBestCertificate(serverName, false)
+}
+
+// GetCertificate returns the certificate matching the server name requested by the client, or nil if there is none,
+// selected as by DomainsCertificates.GetCertificate.
+func (i *CertificateIndex) GetCertificate(clientHello *tls.ClientHelloInfo) *tls.Certificate {
+	if clientHello == nil {
+		return nil
+	}
+	return i.getBestCertificate(clientHello.ServerName, supportsECDSA(clientHello))
+}
+
+func (i *CertificateIndex) getBestCertificate(serverName string, preferECDSA bool) *tls.Certificate {
+	serverName = strings.ToLower(strings.TrimSpace(serverName))
+	if i == nil || len(serverName) == 0 {
+		return nil
+	}
+
+	names := []string{serverName}
+	if labels := strings.SplitN(serverName, ".", 2); len(labels) == 2 {
+		names = append(names, "*."+labels[1])
+	}
+	names = append(names, "*."+serverName)
+
+	for _, name := range names {
+		if candidates := i.domains[name]; candidates != nil {
+			if cert := candidates.get(preferECDSA); cert != nil {
+				return cert
+			}
+		}
+	}
+	return nil
+}
+
+// ecdsaKeySuffix ends the key of an ECDSA certificate,
+// so that it is stored next to the RSA certificate of the same domains.
+const ecdsaKeySuffix = "|ECDSA"
+
+// KeyDomains returns the comma-separated domains of a DomainsCertificates key.
+func KeyDomains(key string) string {
+	return strings.TrimSuffix(key, ecdsaKeySuffix)
+}
+
+// certificateCandidates holds the first ECDSA certificate and the first other (RSA) certificate matching a server name.
+type certificateCandidates struct {
+	rsa   *tls.Certificate
+	ecdsa *tls.Certificate
+}
+
+func (c *certificateCandidates) add(cert *tls.Certificate, isECDSA bool) {
+	if isECDSA {
+		if c.ecdsa == nil {
+			c.ecdsa = cert
+		}
+	} else if c.rsa == nil {
+		c.rsa = cert
+	}
+}
+
+func (c *certificateCandidates) get(preferECDSA bool) *tls.Certificate {
+	if c.ecdsa != nil && (preferECDSA || c.rsa == nil) {
+		return c.ecdsa
+	}
+	return c.rsa
+}
+
+var ecdsaSignatureSchemes = map[tls.SignatureScheme]bool{
+	tls.ECDSAWithP256AndSHA256: true,
+	tls.ECDSAWithP384AndSHA384: true,
+	tls.ECDSAWithP521AndSHA512: true,
+}
+
+var ecdsaCurves = map[tls.CurveID]bool{
+	tls.CurveP256: true,
+	tls.CurveP384: true,
+	tls.CurveP521: true,
+}
+
+var ecdsaCipherSuites = map[uint16]bool{
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA:        true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA:    true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA:    true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256: true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: true,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305:  true,
+}
+
+// supportsECDSA reports whether the client can authenticate the server with an ECDSA certificate:
+// it offers an ECDSA cipher suite, and its supported signature schemes and curves, when sent, include ECDSA ones.
+func supportsECDSA(clientHello *tls.ClientHelloInfo) bool {
+	if len(clientHello.SignatureSchemes) > 0 && !containsSignatureScheme(clientHello.SignatureSchemes) {
+		return false
+	}
+	if len(clientHello.SupportedCurves) > 0 && !containsCurve(clientHello.SupportedCurves) {
+		return false
+	}
+	for _, suite := range clientHello.CipherSuites {
+		if ecdsaCipherSuites[suite] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSignatureScheme(schemes []tls.SignatureScheme) bool {
+	for _, scheme := range schemes {
+		if ecdsaSignatureSchemes[scheme] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCurve(curves []tls.CurveID) bool {
+	for _, curve := range curves {
+		if ecdsaCurves[curve] {
+			return true
+		}
+	}
+	return false
+}
+
 // String is the method to format the flag's value, part of the flag.Value interface.
 // The String method's output will be used in diagnostics.
 func (r *RootCAs) String() string {
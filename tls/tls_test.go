@@ -0,0 +1,248 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/tls/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainsCertificatesGetBestCertificate(t *testing.T) {
+	wildcardCert := &tls.Certificate{}
+	apiCert := &tls.Certificate{}
+	otherWildcardCert := &tls.Certificate{}
+	wwwWildcardCert := &tls.Certificate{}
+
+	dc := DomainsCertificates{
+		"*.example.com":                 wildcardCert,
+		"API.example.com,foo.other.org": apiCert,
+		"*.api.example.com":             otherWildcardCert,
+		"*.www.example.com":             wwwWildcardCert,
+	}
+
+	testCases := []struct {
+		desc         string
+		serverName   string
+		expectedCert *tls.Certificate
+	}{
+		{
+			desc:         "exact host is preferred over wildcard",
+			serverName:   "api.example.com",
+			expectedCert: apiCert,
+		},
+		{
+			desc:         "exact host matches any domain of the certificate",
+			serverName:   "foo.other.org",
+			expectedCert: apiCert,
+		},
+		{
+			desc:         "wildcard matches a sub-domain",
+			serverName:   "www.example.com",
+			expectedCert: wildcardCert,
+		},
+		{
+			desc:         "wildcard matches a single label only",
+			serverName:   "www.api.example.com",
+			expectedCert: otherWildcardCert,
+		},
+		{
+			desc:         "wildcard matches the bare domain",
+			serverName:   "example.com",
+			expectedCert: wildcardCert,
+		},
+		{
+			desc:         "wildcard of the sub-domain is preferred over the wildcard of the bare domain",
+			serverName:   "www.example.com",
+			expectedCert: wildcardCert,
+		},
+
+		{
+			desc:         "no certificate matches",
+			serverName:   "www.unknown.org",
+			expectedCert: nil,
+		},
+		{
+			desc:         "empty server name",
+			serverName:   "",
+			expectedCert: nil,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// the selection does not depend on the map iteration order
+			for i := 0; i < 10; i++ {
+				assert.True(t, test.expectedCert == dc.GetBestCertificate(test.serverName))
+			}
+		})
+	}
+}
+
+func TestDomainsCertificatesGetBestCertificateNil(t *testing.T) {
+	var dc *DomainsCertificates
+	assert.Nil(t, dc.GetBestCertificate("www.example.com"))
+}
+
+func TestDomainsCertificatesGetCertificate(t *testing.T) {
+	rsaCert := &tls.Certificate{}
+	ecdsaCert := &tls.Certificate{}
+	rsaWildcardCert := &tls.Certificate{}
+	ecdsaOnlyCert := &tls.Certificate{}
+
+	dc := DomainsCertificates{
+		"dual.example.com":                   rsaCert,
+		"dual.example.com" + ecdsaKeySuffix:  ecdsaCert,
+		"*.example.com":                      rsaWildcardCert,
+		"ecdsa.example.org" + ecdsaKeySuffix: ecdsaOnlyCert,
+	}
+
+	ecdsaClient := &tls.ClientHelloInfo{
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		SupportedCurves:  []tls.CurveID{tls.X25519, tls.CurveP256},
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256, tls.PKCS1WithSHA256},
+	}
+	rsaClient := &tls.ClientHelloInfo{
+		CipherSuites:     []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_AES_128_CBC_SHA},
+		SignatureSchemes: []tls.SignatureScheme{tls.PKCS1WithSHA256},
+	}
+	rsaSignaturesClient := &tls.ClientHelloInfo{
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		SignatureSchemes: []tls.SignatureScheme{tls.PKCS1WithSHA256},
+	}
+
+	testCases := []struct {
+		desc         string
+		serverName   string
+		clientHello  *tls.ClientHelloInfo
+		expectedCert *tls.Certificate
+	}{
+		{
+			desc:         "ECDSA preferred when supported",
+			serverName:   "dual.example.com",
+			clientHello:  ecdsaClient,
+			expectedCert: ecdsaCert,
+		},
+		{
+			desc:         "RSA for a client without ECDSA cipher suites",
+			serverName:   "dual.example.com",
+			clientHello:  rsaClient,
+			expectedCert: rsaCert,
+		},
+		{
+			desc:         "RSA for a client without ECDSA signature schemes",
+			serverName:   "DUAL.example.com",
+			clientHello:  rsaSignaturesClient,
+			expectedCert: rsaCert,
+		},
+		{
+			desc:         "wildcard RSA certificate",
+			serverName:   "www.example.com",
+			clientHello:  ecdsaClient,
+			expectedCert: rsaWildcardCert,
+		},
+		{
+			desc:         "single ECDSA certificate served to any client",
+			serverName:   "ecdsa.example.org",
+			clientHello:  rsaClient,
+			expectedCert: ecdsaOnlyCert,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clientHello := *test.clientHello
+			clientHello.ServerName = test.serverName
+			assert.True(t, test.expectedCert == dc.GetCertificate(&clientHello))
+		})
+	}
+
+	// without client information, the RSA certificate is returned
+	assert.True(t, rsaCert == dc.GetBestCertificate("dual.example.com"))
+}
+
+func TestAppendCertificatesRSAAndECDSA(t *testing.T) {
+	rsaCert, rsaKey, err := generate.KeyPair("dual.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	ecdsaCert, ecdsaKey, err := generate.ECDSAKeyPair("dual.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	certs := make(map[string]*DomainsCertificates)
+	for _, certificate := range []Certificate{
+		{CertFile: FileOrContent(rsaCert), KeyFile: FileOrContent(rsaKey)},
+		{CertFile: FileOrContent(ecdsaCert), KeyFile: FileOrContent(ecdsaKey)},
+	} {
+		require.NoError(t, certificate.AppendCertificates(certs, "https"))
+	}
+
+	require.NotNil(t, certs["https"])
+	assert.Len(t, *certs["https"], 2)
+
+	cert := certs["https"].GetCertificate(&tls.ClientHelloInfo{
+		ServerName:   "dual.example.com",
+		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	})
+	require.NotNil(t, cert)
+	assert.IsType(t, &ecdsa.PrivateKey{}, cert.PrivateKey)
+}
+
+func TestCertificateValidate(t *testing.T) {
+	cert, key, err := generate.KeyPair("valid.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	otherCert, _, err := generate.KeyPair("other.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc          string
+		certificate   Certificate
+		expectedError bool
+	}{
+		{
+			desc:        "valid certificate",
+			certificate: Certificate{CertFile: FileOrContent(cert), KeyFile: FileOrContent(key)},
+		},
+		{
+			desc:          "missing key",
+			certificate:   Certificate{CertFile: FileOrContent(cert)},
+			expectedError: true,
+		},
+		{
+			desc:          "missing certificate",
+			certificate:   Certificate{KeyFile: FileOrContent(key)},
+			expectedError: true,
+		},
+		{
+			desc:          "key not matching the certificate",
+			certificate:   Certificate{CertFile: FileOrContent(otherCert), KeyFile: FileOrContent(key)},
+			expectedError: true,
+		},
+		{
+			desc:          "missing certificate file",
+			certificate:   Certificate{CertFile: "/path/to/missing.cert", KeyFile: FileOrContent(key)},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.certificate.Validate()
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/containous/flaeg"
+)
+
+// DefaultDNSResolverTimeout is the timeout of the DNS queries sent to the servers of a DNSResolver, when not configured.
+const DefaultDNSResolverTimeout = 5 * time.Second
+
+// DNSResolver holds the DNS servers used to resolve the hostnames, instead of the system resolver
+type DNSResolver struct {
+	Servers DNSServers     `description:"Addresses of the DNS servers, the port defaulting to 53" export:"true"`
+	Timeout flaeg.Duration `description:"Timeout of the DNS queries. Defaults to 5 seconds" export:"true"`
+}
+
+// DNSServers holds the addresses of the DNS servers of a DNSResolver
+type DNSServers []string
+
+// Set adds strings elem into the the parser
+// it splits str on "," and ";"
+func (s *DNSServers) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	*s = append(*s, strings.FieldsFunc(str, fargs)...)
+	return nil
+}
+
+// Get []string
+func (s *DNSServers) Get() interface{} { return *s }
+
+// String return slice in a string
+func (s *DNSServers) String() string { return fmt.Sprintf("%v", *s) }
+
+// SetValue sets []string into the parser
+func (s *DNSServers) SetValue(val interface{}) {
+	*s = val.(DNSServers)
+}
+
+// Nameservers returns the addresses of the DNS servers, with the default port when it is missing
+func (r *DNSResolver) Nameservers() []string {
+	var nameservers []string
+	for _, server := range r.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		nameservers = append(nameservers, server)
+	}
+	return nameservers
+}
+
+// GetTimeout returns the timeout of the DNS queries
+func (r *DNSResolver) GetTimeout() time.Duration {
+	if r.Timeout <= 0 {
+		return DefaultDNSResolverTimeout
+	}
+	return time.Duration(r.Timeout)
+}
+
+// NewResolver creates a pure Go resolver sending its queries to the DNS servers, each retry being sent to the next one.
+// The system resolver is returned when no server is configured.
+func (r *DNSResolver) NewResolver() *net.Resolver {
+	nameservers := r.Nameservers()
+	if len(nameservers) == 0 {
+		return net.DefaultResolver
+	}
+	timeout := r.GetTimeout()
+	var next uint32
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			nameserver := nameservers[int(atomic.AddUint32(&next, 1)-1)%len(nameservers)]
+
+			dialer := &net.Dialer{Timeout: timeout}
+			conn, err := dialer.DialContext(ctx, network, nameserver)
+			if err != nil {
+				return nil, err
+			}
+			// a connection is dialed for each query, whose exchange is bounded by the timeout
+			conn.SetDeadline(time.Now().Add(timeout))
+			return conn, nil
+		},
+	}
+}
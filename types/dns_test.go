@@ -0,0 +1,56 @@
+package types
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSResolverNameservers(t *testing.T) {
+	resolver := &DNSResolver{Servers: []string{"10.0.0.1", "10.0.0.2:5353", "fd00::1", "[fd00::2]:5353"}}
+
+	assert.Equal(t, []string{"10.0.0.1:53", "10.0.0.2:5353", "[fd00::1]:53", "[fd00::2]:5353"}, resolver.Nameservers())
+}
+
+func TestDNSResolverGetTimeout(t *testing.T) {
+	assert.Equal(t, DefaultDNSResolverTimeout, (&DNSResolver{}).GetTimeout())
+	assert.Equal(t, time.Second, (&DNSResolver{Timeout: flaeg.Duration(time.Second)}).GetTimeout())
+}
+
+func TestDNSResolverNewResolver(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(func(rw dns.ResponseWriter, req *dns.Msg) {
+			msg := &dns.Msg{}
+			msg.SetReply(req)
+			if req.Question[0].Qtype == dns.TypeA && req.Question[0].Name == "backend.example.com." {
+				msg.Answer = append(msg.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+					A:   net.ParseIP("10.1.2.3"),
+				})
+			}
+			rw.WriteMsg(msg)
+		}),
+	}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	resolver := (&DNSResolver{Servers: []string{conn.LocalAddr().String()}, Timeout: flaeg.Duration(time.Second)}).NewResolver()
+
+	addrs, err := resolver.LookupHost(context.Background(), "backend.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.2.3"}, addrs)
+}
+
+func TestDNSResolverNewResolverWithoutServers(t *testing.T) {
+	assert.Equal(t, net.DefaultResolver, (&DNSResolver{}).NewResolver())
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -26,6 +27,57 @@ type Backend struct {
 	MaxConn        *MaxConn          `json:"maxConn,omitempty"`
 	HealthCheck    *HealthCheck      `json:"healthCheck,omitempty"`
 	Buffering      *Buffering        `json:"buffering,omitempty"`
+	HostHeader     string            `json:"hostHeader,omitempty"`
+	Draining       bool              `json:"draining,omitempty"`
+	SourceAddress  string            `json:"sourceAddress,omitempty"`
+	H2C            bool              `json:"h2c,omitempty"`
+	ForceHTTP1     bool              `json:"forceHTTP1,omitempty"`
+	PercentWeights bool              `json:"percentWeights,omitempty"`
+	ProxyProtocol  *ProxyProtocol    `json:"proxyProtocol,omitempty"`
+	DNSRefresh     *DNSRefresh       `json:"dnsRefresh,omitempty"`
+	WebSocket      *WebSocket        `json:"webSocket,omitempty"`
+	Transport      *Transport        `json:"transport,omitempty"`
+	TLS            *BackendTLS       `json:"tls,omitempty"`
+	RetryBudget    *RetryBudget      `json:"retryBudget,omitempty"`
+}
+
+// BackendTLS holds the TLS configuration of the connections to the servers of a backend:
+// the CA bundle used to verify their certificates, and the client certificate presented to them.
+// They could be either file paths, or the PEM contents themselves.
+type BackendTLS struct {
+	CA   traefikTls.FileOrContent `json:"ca,omitempty"`
+	Cert traefikTls.FileOrContent `json:"cert,omitempty"`
+	Key  traefikTls.FileOrContent `json:"key,omitempty"`
+}
+
+// Transport holds the settings of the connection pool to the servers of a backend, overriding the global ones
+type Transport struct {
+	MaxIdleConnsPerHost int    `json:"maxIdleConnsPerHost,omitempty"`
+	IdleConnTimeout     string `json:"idleConnTimeout,omitempty"`
+	DialKeepAlive       string `json:"dialKeepAlive,omitempty"`
+}
+
+// WebSocket holds the timeouts of the WebSocket connections upgraded by a backend
+type WebSocket struct {
+	WriteTimeout string `json:"writeTimeout,omitempty"`
+	IdleTimeout  string `json:"idleTimeout,omitempty"`
+}
+
+// DNSRefresh holds the periodic resolution configuration of the hostnames of the servers of a backend
+type DNSRefresh struct {
+	Interval string `json:"interval,omitempty"`
+}
+
+// RetryBudget holds the ratio of the requests of a backend which can be retried,
+// and the number of retries allowed on top of it
+type RetryBudget struct {
+	Ratio float64 `json:"ratio,omitempty" description:"Ratio of the requests which may be retried" export:"true"`
+	Burst int     `json:"burst,omitempty" description:"Number of retries allowed on top of the ratio. Defaults to 10" export:"true"`
+}
+
+// ProxyProtocol holds the Proxy Protocol configuration of the connections to the servers of a backend
+type ProxyProtocol struct {
+	Version int `json:"version,omitempty"`
 }
 
 // MaxConn holds maximum connection configuration
@@ -48,7 +100,17 @@ type Stickiness struct {
 
 // CircuitBreaker holds circuit breaker configuration.
 type CircuitBreaker struct {
-	Expression string `json:"expression,omitempty"`
+	Expression string                `json:"expression,omitempty"`
+	PerServer  *ServerCircuitBreaker `json:"perServer,omitempty"`
+}
+
+// ServerCircuitBreaker holds the configuration of the ejection from the load-balancer of the servers of a backend
+// failing consecutively, or whose 95th percentile of the response times exceeds MaxResponseTime, until they are re-admitted after a cooldown
+type ServerCircuitBreaker struct {
+	ConsecutiveFailures int    `json:"consecutiveFailures,omitempty"`
+	Cooldown            string `json:"cooldown,omitempty"`
+	MaxResponseTime     string `json:"maxResponseTime,omitempty"`
+	ResponseTimeSamples int    `json:"responseTimeSamples,omitempty"`
 }
 
 // Buffering holds request/response buffering configuration/
@@ -60,17 +122,29 @@ type Buffering struct {
 	RetryExpression      string `json:"retryExpression,omitempty"`
 }
 
+// ForwardingTimeouts holds the timeouts of the requests of a frontend forwarded to the servers of its backend, overriding the global ones,
+// and the timeout of its whole requests
+type ForwardingTimeouts struct {
+	DialTimeout           string `json:"dialTimeout,omitempty"`
+	ResponseHeaderTimeout string `json:"responseHeaderTimeout,omitempty"`
+	RequestTimeout        string `json:"requestTimeout,omitempty"`
+}
+
 // HealthCheck holds HealthCheck configuration
 type HealthCheck struct {
-	Path     string `json:"path,omitempty"`
-	Port     int    `json:"port,omitempty"`
-	Interval string `json:"interval,omitempty"`
+	Path              string            `json:"path,omitempty"`
+	Port              int               `json:"port,omitempty"`
+	Interval          string            `json:"interval,omitempty"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	ExpectedBody      string            `json:"expectedBody,omitempty"`
+	ExpectedBodyRegex string            `json:"expectedBodyRegex,omitempty"`
 }
 
 // Server holds server configuration.
 type Server struct {
 	URL    string `json:"url,omitempty"`
 	Weight int    `json:"weight"`
+	Tier   int    `json:"tier,omitempty"`
 }
 
 // Route holds route configuration.
@@ -83,6 +157,7 @@ type ErrorPage struct {
 	Status  []string `json:"status,omitempty"`
 	Backend string   `json:"backend,omitempty"`
 	Query   string   `json:"query,omitempty"`
+	Timeout string   `json:"timeout,omitempty"`
 }
 
 // Rate holds a rate limiting configuration for a specific time period
@@ -102,6 +177,7 @@ type RateLimit struct {
 type Headers struct {
 	CustomRequestHeaders    map[string]string `json:"customRequestHeaders,omitempty"`
 	CustomResponseHeaders   map[string]string `json:"customResponseHeaders,omitempty"`
+	RemovedResponseHeaders  []string          `json:"removedResponseHeaders,omitempty"`
 	AllowedHosts            []string          `json:"allowedHosts,omitempty"`
 	HostsProxyHeaders       []string          `json:"hostsProxyHeaders,omitempty"`
 	SSLRedirect             bool              `json:"sslRedirect,omitempty"`
@@ -152,18 +228,166 @@ func (h *Headers) HasSecureHeadersDefined() bool {
 
 // Frontend holds frontend configuration.
 type Frontend struct {
-	EntryPoints          []string              `json:"entryPoints,omitempty"`
-	Backend              string                `json:"backend,omitempty"`
-	Routes               map[string]Route      `json:"routes,omitempty"`
-	PassHostHeader       bool                  `json:"passHostHeader,omitempty"`
-	PassTLSCert          bool                  `json:"passTLSCert,omitempty"`
-	Priority             int                   `json:"priority"`
-	BasicAuth            []string              `json:"basicAuth"`
-	WhitelistSourceRange []string              `json:"whitelistSourceRange,omitempty"`
-	Headers              *Headers              `json:"headers,omitempty"`
-	Errors               map[string]*ErrorPage `json:"errors,omitempty"`
-	RateLimit            *RateLimit            `json:"ratelimit,omitempty"`
-	Redirect             *Redirect             `json:"redirect,omitempty"`
+	EntryPoints            []string                   `json:"entryPoints,omitempty"`
+	Backend                string                     `json:"backend,omitempty"`
+	Routes                 map[string]Route           `json:"routes,omitempty"`
+	PassHostHeader         bool                       `json:"passHostHeader,omitempty"`
+	CustomHost             string                     `json:"customHost,omitempty"`
+	PassTLSCert            bool                       `json:"passTLSCert,omitempty"`
+	Priority               int                        `json:"priority"`
+	BasicAuth              []string                   `json:"basicAuth"`
+	WhitelistSourceRange   []string                   `json:"whitelistSourceRange,omitempty"`
+	Headers                *Headers                   `json:"headers,omitempty"`
+	Errors                 map[string]*ErrorPage      `json:"errors,omitempty"`
+	RateLimit              *RateLimit                 `json:"ratelimit,omitempty"`
+	Redirect               *Redirect                  `json:"redirect,omitempty"`
+	Buffering              *Buffering                 `json:"buffering,omitempty"`
+	SkipDefaultMiddlewares bool                       `json:"skipDefaultMiddlewares,omitempty"`
+	Mirroring              *Mirroring                 `json:"mirroring,omitempty"`
+	LocationRewrite        *LocationRewrite           `json:"locationRewrite,omitempty"`
+	StatusMapping          *StatusMapping             `json:"statusMapping,omitempty"`
+	NoServer               *NoServerResponse          `json:"noServer,omitempty"`
+	AccessLog              *FrontendAccessLog         `json:"accessLog,omitempty"`
+	HeaderOverride         *HeaderOverride            `json:"headerOverride,omitempty"`
+	StaticResponse         *StaticResponse            `json:"staticResponse,omitempty"`
+	SingleFlight           *SingleFlight              `json:"singleFlight,omitempty"`
+	GRPCWeb                *GRPCWeb                   `json:"grpcWeb,omitempty"`
+	RequiredHeaders        map[string]*RequiredHeader `json:"requiredHeaders,omitempty"`
+	WeightedBackends       []*WeightedBackend         `json:"weightedBackends,omitempty"`
+	JWTAuth                *JWTAuth                   `json:"jwtAuth,omitempty"`
+	Cache                  *Cache                     `json:"cache,omitempty"`
+	CORS                   *CORS                      `json:"cors,omitempty"`
+	LanguageBackends       map[string]string          `json:"languageBackends,omitempty"`
+	ForwardingTimeouts     *ForwardingTimeouts        `json:"forwardingTimeouts,omitempty"`
+	FormJSON               *FormJSON                  `json:"formJSON,omitempty"`
+}
+
+// CORS holds the Cross-Origin Resource Sharing policy of a frontend, whose preflight requests are answered without being forwarded.
+// The allowed origins are either *, origins such as https://example.com, or origins with * wildcards such as https://*.example.com,
+// or else match one of AllowedOriginsRegex. MaxAge is the duration of the caching of the preflight responses, in seconds.
+type CORS struct {
+	AllowedOrigins      []string `json:"allowedOrigins,omitempty"`
+	AllowedOriginsRegex []string `json:"allowedOriginsRegex,omitempty"`
+	AllowedMethods      []string `json:"allowedMethods,omitempty"`
+	AllowedHeaders      []string `json:"allowedHeaders,omitempty"`
+	ExposedHeaders      []string `json:"exposedHeaders,omitempty"`
+	AllowCredentials    bool     `json:"allowCredentials,omitempty"`
+	MaxAge              int      `json:"maxAge,omitempty"`
+}
+
+// Cache holds the configuration of the in-memory caching of the responses to the GET and HEAD requests of a frontend.
+// The responses are cached for the duration given by their Cache-Control or Expires headers, or for TTL without any.
+// They are cached by method, host, path, query and values of the Headers, and of the headers listed in their Vary header.
+// Once expired, they can still be sent during StaleWhileRevalidate while they are fetched again in the background.
+type Cache struct {
+	TTL                  string   `json:"ttl,omitempty"`
+	MaxSize              int64    `json:"maxSize,omitempty"`
+	MaxBodySize          int64    `json:"maxBodySize,omitempty"`
+	Headers              []string `json:"headers,omitempty"`
+	StaleWhileRevalidate string   `json:"staleWhileRevalidate,omitempty"`
+}
+
+// JWTAuth holds the validation of the JSON Web Tokens sent as bearer tokens by the requests of a frontend.
+// The tokens are signed with one of the keys published at JWKSURL, or with the HMAC Secret.
+// ForwardClaims maps the names of the claims forwarded to the backend to the names of their headers.
+type JWTAuth struct {
+	JWKSURL       string            `json:"jwksURL,omitempty"`
+	Secret        string            `json:"secret,omitempty"`
+	Issuer        string            `json:"issuer,omitempty"`
+	Audience      string            `json:"audience,omitempty"`
+	ForwardClaims map[string]string `json:"forwardClaims,omitempty"`
+}
+
+// WeightedBackend holds a backend of a frontend splitting its requests across several backends,
+// which receives a share of the requests proportional to its weight
+type WeightedBackend struct {
+	Backend string `json:"backend,omitempty"`
+	Weight  int    `json:"weight"`
+}
+
+// RequiredHeader holds the constraints on a header which must be present in the requests of a frontend,
+// whose value must then be one of Values, if set, and match Regex, if set
+type RequiredHeader struct {
+	Values []string `json:"values,omitempty"`
+	Regex  string   `json:"regex,omitempty"`
+}
+
+// GRPCWeb holds the configuration of the translation of the gRPC-Web requests of a frontend into gRPC requests to its backend.
+// The CORS preflight requests of the allowed origins, or of any origin with "*", are answered.
+type GRPCWeb struct {
+	AllowedOrigins []string `json:"allowedOrigins,omitempty"`
+}
+
+// SingleFlight holds the configuration of the coalescing of the concurrent identical GET and HEAD requests of a frontend
+// into a single request to its backend, whose response is sent to all of them.
+// The requests are identical when their method, host, path, query and values of the headers are.
+type SingleFlight struct {
+	Headers     []string `json:"headers,omitempty"`
+	MaxBodySize int64    `json:"maxBodySize,omitempty"`
+}
+
+// StaticResponse holds the response sent by a frontend itself instead of forwarding the requests to a backend,
+// either a status code, headers and a body, or a redirection. The body and the redirection URL are templates.
+type StaticResponse struct {
+	StatusCode int               `json:"statusCode,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Redirect   string            `json:"redirect,omitempty"`
+}
+
+// HeaderOverride holds the selection, for the requests whose header matches a value, of a server of the backend of a frontend,
+// or of another backend, instead of the load-balancer
+type HeaderOverride struct {
+	Header  string `json:"header,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Server  string `json:"server,omitempty"`
+	Backend string `json:"backend,omitempty"`
+}
+
+// FrontendAccessLog holds the access log settings of a frontend, to exclude all its requests,
+// or the ones matching a path prefix or a status code range, from the access log
+type FrontendAccessLog struct {
+	Disabled       bool     `json:"disabled,omitempty"`
+	ExcludedPaths  []string `json:"excludedPaths,omitempty"`
+	ExcludedStatus []string `json:"excludedStatus,omitempty"`
+}
+
+// NoServerResponse holds the response of a frontend when no server of its backend is available,
+// either a custom status code and body, or a redirection
+type NoServerResponse struct {
+	StatusCode  int    `json:"statusCode,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Redirect    string `json:"redirect,omitempty"`
+}
+
+// StatusMapping holds the configuration of the rewriting of the status codes of the responses of a frontend
+type StatusMapping struct {
+	Codes   map[string]int `json:"codes,omitempty"`
+	Backend string         `json:"backend,omitempty"`
+	Query   string         `json:"query,omitempty"`
+}
+
+// LocationRewrite holds the configuration of the rewriting of the backend host to the public one in the response headers of a frontend
+type LocationRewrite struct {
+	BackendHost     string `json:"backendHost,omitempty"`
+	ContentLocation bool   `json:"contentLocation,omitempty"`
+	SetCookieDomain bool   `json:"setCookieDomain,omitempty"`
+}
+
+// Mirroring holds the configuration of the mirroring of the requests of a frontend to other backends
+type Mirroring struct {
+	Backends    []string `json:"backends,omitempty"`
+	Percent     int      `json:"percent,omitempty"`
+	MaxBodySize int64    `json:"maxBodySize,omitempty"`
+}
+
+// FormJSON holds the configuration of the conversion of the form encoded request bodies of a frontend into JSON objects.
+// With Responses, the JSON objects of the responses are converted back into form encoded bodies.
+// MaxBodySize is the size of the largest body converted, in bytes.
+type FormJSON struct {
+	Responses   bool  `json:"responses,omitempty"`
+	MaxBodySize int64 `json:"maxBodySize,omitempty"`
 }
 
 // Redirect configures a redirection of an entry point to another, or to an URL
@@ -172,6 +396,8 @@ type Redirect struct {
 	Regex       string `json:"regex,omitempty"`
 	Replacement string `json:"replacement,omitempty"`
 	Permanent   bool   `json:"permanent,omitempty"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	Port        int    `json:"port,omitempty"`
 }
 
 // LoadBalancerMethod holds the method of load balancing to use.
@@ -182,11 +408,14 @@ const (
 	Wrr LoadBalancerMethod = iota
 	// Drr = Dynamic Round Robin
 	Drr
+	// LeastConn = Least Connections
+	LeastConn
 )
 
 var loadBalancerMethodNames = []string{
 	"Wrr",
 	"Drr",
+	"LeastConn",
 }
 
 // NewLoadBalancerMethod create a new LoadBalancerMethod from a given LoadBalancer.
@@ -206,6 +435,71 @@ func NewLoadBalancerMethod(loadBalancer *LoadBalancer) (LoadBalancerMethod, erro
 // Configurations is for currentConfigurations Map
 type Configurations map[string]*Configuration
 
+// EffectivePriorities returns the priority of the routes of each frontend, by provider and frontend name.
+// The frontends with the highest explicit priority are matched first, then the ones with the longest rules,
+// and then the first ones, providers being ordered by name, then frontends by name.
+func (c Configurations) EffectivePriorities() map[string]map[string]int {
+	type frontendPriority struct {
+		providerName string
+		frontendName string
+		priority     int
+		rulesLength  int
+	}
+
+	var providerNames []string
+	for providerName := range c {
+		providerNames = append(providerNames, providerName)
+	}
+	sort.Strings(providerNames)
+
+	var frontends []frontendPriority
+	for _, providerName := range providerNames {
+		if c[providerName] == nil {
+			continue
+		}
+
+		var frontendNames []string
+		for frontendName := range c[providerName].Frontends {
+			frontendNames = append(frontendNames, frontendName)
+		}
+		sort.Strings(frontendNames)
+
+		for _, frontendName := range frontendNames {
+			frontend := c[providerName].Frontends[frontendName]
+			if frontend == nil {
+				continue
+			}
+
+			var rulesLength int
+			for _, route := range frontend.Routes {
+				rulesLength += len(route.Rule)
+			}
+			frontends = append(frontends, frontendPriority{
+				providerName: providerName,
+				frontendName: frontendName,
+				priority:     frontend.Priority,
+				rulesLength:  rulesLength,
+			})
+		}
+	}
+
+	sort.SliceStable(frontends, func(i, j int) bool {
+		if frontends[i].priority != frontends[j].priority {
+			return frontends[i].priority > frontends[j].priority
+		}
+		return frontends[i].rulesLength > frontends[j].rulesLength
+	})
+
+	priorities := make(map[string]map[string]int)
+	for i, frontend := range frontends {
+		if priorities[frontend.providerName] == nil {
+			priorities[frontend.providerName] = make(map[string]int)
+		}
+		priorities[frontend.providerName][frontend.frontendName] = len(frontends) - i
+	}
+	return priorities
+}
+
 // Configuration of a provider.
 type Configuration struct {
 	Backends  map[string]*Backend         `json:"backends,omitempty"`
@@ -296,7 +590,7 @@ func (c *Constraint) MatchConstraintWithAtLeastOneTag(tags []string) bool {
 	return false
 }
 
-//Set []*Constraint
+// Set []*Constraint
 func (cs *Constraints) Set(str string) error {
 	exps := strings.Split(str, ",")
 	if len(exps) == 0 {
@@ -315,13 +609,13 @@ func (cs *Constraints) Set(str string) error {
 // Constraints holds a Constraint parser
 type Constraints []*Constraint
 
-//Get []*Constraint
+// Get []*Constraint
 func (cs *Constraints) Get() interface{} { return []*Constraint(*cs) }
 
-//String returns []*Constraint in string
+// String returns []*Constraint in string
 func (cs *Constraints) String() string { return fmt.Sprintf("%+v", *cs) }
 
-//SetValue sets []*Constraint into the parser
+// SetValue sets []*Constraint into the parser
 func (cs *Constraints) SetValue(val interface{}) {
 	*cs = val.(Constraints)
 }
@@ -369,9 +663,11 @@ type Digest struct {
 
 // Forward authentication
 type Forward struct {
-	Address            string     `description:"Authentication server address"`
-	TLS                *ClientTLS `description:"Enable TLS support" export:"true"`
-	TrustForwardHeader bool       `description:"Trust X-Forwarded-* headers" export:"true"`
+	Address               string     `description:"Authentication server address"`
+	TLS                   *ClientTLS `description:"Enable TLS support" export:"true"`
+	TrustForwardHeader    bool       `description:"Trust X-Forwarded-* headers" export:"true"`
+	PassClientCert        bool       `description:"Pass the verified client certificate to the authentication server" export:"true"`
+	PassClientCertSubject []string   `description:"Subject attributes of the verified client certificate passed to the authentication server" export:"true"`
 }
 
 // CanonicalDomain returns a lower case domain with trim space
@@ -408,19 +704,26 @@ type Datadog struct {
 type Statsd struct {
 	Address      string `description:"StatsD address"`
 	PushInterval string `description:"StatsD push interval" export:"true"`
+	Prefix       string `description:"Prefix of the StatsD metric names" export:"true"`
 }
 
-// InfluxDB contains address and metrics pushing interval configuration
+// InfluxDB contains address, protocol and metrics pushing interval configuration
 type InfluxDB struct {
-	Address      string `description:"InfluxDB address"`
-	PushInterval string `description:"InfluxDB push interval"`
+	Address         string            `description:"InfluxDB address"`
+	Protocol        string            `description:"InfluxDB address protocol (udp or http)"`
+	PushInterval    string            `description:"InfluxDB push interval"`
+	Database        string            `description:"InfluxDB database used when protocol is http"`
+	RetentionPolicy string            `description:"InfluxDB retention policy used when protocol is http"`
+	Username        string            `description:"InfluxDB username (only with http)"`
+	Password        string            `description:"InfluxDB password (only with http)"`
+	Tags            map[string]string // only configurable through the configuration file
 }
 
 // Buckets holds Prometheus Buckets
 type Buckets []float64
 
-//Set adds strings elem into the the parser
-//it splits str on "," and ";" and apply ParseFloat to string
+// Set adds strings elem into the the parser
+// it splits str on "," and ";" and apply ParseFloat to string
 func (b *Buckets) Set(str string) error {
 	fargs := func(c rune) bool {
 		return c == ',' || c == ';'
@@ -437,13 +740,13 @@ func (b *Buckets) Set(str string) error {
 	return nil
 }
 
-//Get []float64
+// Get []float64
 func (b *Buckets) Get() interface{} { return *b }
 
-//String return slice in a string
+// String return slice in a string
 func (b *Buckets) String() string { return fmt.Sprintf("%v", *b) }
 
-//SetValue sets []float64 into the parser
+// SetValue sets []float64 into the parser
 func (b *Buckets) SetValue(val interface{}) {
 	*b = val.(Buckets)
 }
@@ -456,8 +759,24 @@ type TraefikLog struct {
 
 // AccessLog holds the configuration settings for the access logger (middlewares/accesslog).
 type AccessLog struct {
-	FilePath string `json:"file,omitempty" description:"Access log file path. Stdout is used when omitted or empty" export:"true"`
-	Format   string `json:"format,omitempty" description:"Access log format: json | common" export:"true"`
+	FilePath string             `json:"file,omitempty" description:"Access log file path. Stdout is used when omitted or empty" export:"true"`
+	Format   string             `json:"format,omitempty" description:"Access log format: json | common" export:"true"`
+	Syslog   *AccessLogSyslog   `json:"syslog,omitempty" description:"Send access logs to a syslog server" export:"true"`
+	Sampling *AccessLogSampling `json:"sampling,omitempty" description:"Log only a sample of the requests" export:"true"`
+}
+
+// AccessLogSampling holds the configuration settings for logging only a sample of the requests.
+type AccessLogSampling struct {
+	Rate        int  `json:"rate,omitempty" description:"Log one request out of rate" export:"true"`
+	SuccessOnly bool `json:"successOnly,omitempty" description:"Sample only the 2xx responses, logging all the others" export:"true"`
+}
+
+// AccessLogSyslog holds the configuration settings for sending the access logs to a syslog server.
+type AccessLogSyslog struct {
+	Address  string `json:"address,omitempty" description:"Syslog server address (host:port)"`
+	Network  string `json:"network,omitempty" description:"Syslog server network: udp | tcp (default: udp)" export:"true"`
+	Facility string `json:"facility,omitempty" description:"Syslog facility, e.g. local0 (default: local0)" export:"true"`
+	Tag      string `json:"tag,omitempty" description:"Syslog tag (default: traefik)" export:"true"`
 }
 
 // ClientTLS holds TLS specific configurations as client
@@ -6,6 +6,40 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestEffectivePriorities(t *testing.T) {
+	configurations := Configurations{
+		"provider2": &Configuration{
+			Frontends: map[string]*Frontend{
+				"same-rules": {Routes: map[string]Route{"route": {Rule: "Path:/foo"}}},
+			},
+		},
+		"provider1": &Configuration{
+			Frontends: map[string]*Frontend{
+				"short":    {Routes: map[string]Route{"route": {Rule: "Path:/foo"}}},
+				"long":     {Routes: map[string]Route{"route": {Rule: "Host:foo.bar;Path:/foo"}}},
+				"two":      {Routes: map[string]Route{"host": {Rule: "Host:foo.bar"}, "path": {Rule: "Path:/foo/bar"}}},
+				"priority": {Priority: 10, Routes: map[string]Route{"route": {Rule: "PathPrefix:/"}}},
+				"negative": {Priority: -1, Routes: map[string]Route{"route": {Rule: "Host:foo.bar;PathPrefix:/foo/bar"}}},
+			},
+		},
+		"provider3": nil,
+	}
+
+	expected := map[string]map[string]int{
+		"provider1": {
+			"priority": 6,
+			"two":      5,
+			"long":     4,
+			"short":    3,
+			"negative": 1,
+		},
+		"provider2": {
+			"same-rules": 2,
+		},
+	}
+	assert.Equal(t, expected, configurations.EffectivePriorities())
+}
+
 func TestHeaders_ShouldReturnFalseWhenNotHasCustomHeadersDefined(t *testing.T) {
 	headers := Headers{}
 
@@ -3,6 +3,7 @@ package whitelist
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -14,23 +15,24 @@ type IP struct {
 	insecure      bool
 }
 
-// NewIP builds a new IP given a list of CIDR-Strings to whitelist
+// NewIP builds a new IP given a list of CIDR-Strings to whitelist.
+// The IPv6 addresses can be enclosed in brackets, and their zone is ignored.
 func NewIP(whitelistStrings []string, insecure bool) (*IP, error) {
 	if len(whitelistStrings) == 0 && !insecure {
 		return nil, errors.New("no whiteListsNet provided")
 	}
 
-	ip := IP{}
+	ip := IP{insecure: insecure}
 
 	if !insecure {
 		for _, whitelistString := range whitelistStrings {
-			ipAddr := net.ParseIP(whitelistString)
+			ipAddr := parseIP(whitelistString)
 			if ipAddr != nil {
 				ip.whiteListsIPs = append(ip.whiteListsIPs, &ipAddr)
 			} else {
-				_, whitelist, err := net.ParseCIDR(whitelistString)
+				_, whitelist, err := net.ParseCIDR(strings.TrimSpace(whitelistString))
 				if err != nil {
-					return nil, fmt.Errorf("parsing CIDR whitelist %s: %v", whitelist, err)
+					return nil, fmt.Errorf("parsing CIDR whitelist %q: %v", whitelistString, err)
 				}
 				ip.whiteListsNet = append(ip.whiteListsNet, whitelist)
 			}
@@ -40,7 +42,7 @@ func NewIP(whitelistStrings []string, insecure bool) (*IP, error) {
 	return &ip, nil
 }
 
-// Contains checks if provided address is in the white list
+// Contains checks if provided address, which can have a port, is in the white list
 func (ip *IP) Contains(addr string) (bool, net.IP, error) {
 	if ip.insecure {
 		return true, nil, nil
@@ -76,11 +78,29 @@ func (ip *IP) ContainsIP(addr net.IP) (bool, error) {
 	return false, nil
 }
 
+// ipFromRemoteAddr parses the IP of an address such as 10.0.0.1, 10.0.0.1:80, 2001:db8::1, [2001:db8::1]:80 or fe80::1%eth0
 func ipFromRemoteAddr(addr string) (net.IP, error) {
-	userIP := net.ParseIP(addr)
+	userIP := parseIP(addr)
+	if userIP == nil {
+		if host, _, err := net.SplitHostPort(strings.TrimSpace(addr)); err == nil {
+			userIP = parseIP(host)
+		}
+	}
 	if userIP == nil {
 		return nil, fmt.Errorf("can't parse IP from address %s", addr)
 	}
 
 	return userIP, nil
 }
+
+// parseIP parses an IPv4 or IPv6 address, the IPv6 addresses being possibly enclosed in brackets, and their zone being ignored
+func parseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	if i := strings.LastIndex(addr, "%"); i > 0 && strings.Contains(addr[:i], ":") {
+		addr = addr[:i]
+	}
+	return net.ParseIP(addr)
+}
@@ -33,21 +33,21 @@ func TestNew(t *testing.T) {
 				"fe80::/16",
 			},
 			expectedWhitelists: nil,
-			errMessage:         "parsing CIDR whitelist <nil>: invalid CIDR address: ",
+			errMessage:         `parsing CIDR whitelist "": invalid CIDR address: `,
 		}, {
 			desc: "whitelist containing only an empty string",
 			whitelistStrings: []string{
 				"",
 			},
 			expectedWhitelists: nil,
-			errMessage:         "parsing CIDR whitelist <nil>: invalid CIDR address: ",
+			errMessage:         `parsing CIDR whitelist "": invalid CIDR address: `,
 		}, {
 			desc: "whitelist containing an invalid string",
 			whitelistStrings: []string{
 				"foo",
 			},
 			expectedWhitelists: nil,
-			errMessage:         "parsing CIDR whitelist <nil>: invalid CIDR address: foo",
+			errMessage:         `parsing CIDR whitelist "foo": invalid CIDR address: foo`,
 		}, {
 			desc: "IPv4 & IPv6 whitelist",
 			whitelistStrings: []string{
@@ -262,6 +262,67 @@ func TestIsAllowed(t *testing.T) {
 				"4.8.8.8",
 			},
 		},
+		{
+			desc: "IPv6",
+			whitelistStrings: []string{
+				"2a03:4000:6:d080::/64",
+			},
+			passIPs: []string{
+				"2a03:4000:6:d080::",
+				"2a03:4000:6:d080::1",
+				"[2a03:4000:6:d080::42]",
+				"[2a03:4000:6:d080::42]:8080",
+				"2a03:4000:6:d080:dead:beef:ffff:ffff",
+			},
+			rejectIPs: []string{
+				"2a03:4000:7:d080::",
+				"2a03:4000:7:d080::1",
+				"[fe80::1]:8080",
+				"4242::1",
+			},
+		},
+		{
+			desc: "IPv6 single IP",
+			whitelistStrings: []string{
+				"[2a03:4000:6:d080::42]",
+				"fe80::1%eth0",
+			},
+			passIPs: []string{
+				"2a03:4000:6:d080::42",
+				"2A03:4000:6:D080:0:0:0:42",
+				"fe80::1",
+				"fe80::1%eth1",
+				"[fe80::1%eth0]:8080",
+			},
+			rejectIPs: []string{
+				"2a03:4000:6:d080::41",
+				"2a03:4000:6:d080::43",
+				"fe80::2%eth0",
+			},
+		},
+		{
+			desc: "mixed IPv4 and IPv6",
+			whitelistStrings: []string{
+				"10.0.0.0/8",
+				"2a03:4000:6:d080::/64",
+				"::ffff:192.168.1.1",
+			},
+			passIPs: []string{
+				"10.1.2.3",
+				"10.1.2.3:8080",
+				"::ffff:10.1.2.3",
+				"[::ffff:10.1.2.3]:8080",
+				"192.168.1.1",
+				"2a03:4000:6:d080::1",
+			},
+			rejectIPs: []string{
+				"11.1.2.3",
+				"::ffff:11.1.2.3",
+				"192.168.1.2",
+				"::a01:203",
+				"2a03:4000:7:d080::1",
+			},
+		},
 		{
 			desc: "broken IP-addresses",
 			whitelistStrings: []string{
@@ -304,6 +365,9 @@ func TestBrokenIPs(t *testing.T) {
 		"fe:::80",
 		"",
 		"\\&$§&/(",
+		"10.0.0.1%eth0",
+		"[10.0.0.1",
+		"fe80::1]:80",
 	}
 
 	whiteLister, err := NewIP([]string{"1.2.3.4/24"}, false)
@@ -316,3 +380,16 @@ func TestBrokenIPs(t *testing.T) {
 	}
 
 }
+
+func TestInsecure(t *testing.T) {
+	whiteLister, err := NewIP(nil, true)
+	require.NoError(t, err)
+
+	allowed, _, err := whiteLister.Contains("10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = whiteLister.ContainsIP(net.ParseIP("fe80::1"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}